@@ -0,0 +1,59 @@
+// Package auth signs interactive users in with OpenID Connect and keeps
+// their sessions in encrypted cookies.
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+// Role is what a user may do. Readers may use the read-only views, admins
+// may also use the admin routes.
+type Role string
+
+// Roles granted to users.
+const (
+	RoleReader Role = "reader"
+	RoleAdmin  Role = "admin"
+)
+
+var (
+	// ErrNoRole is returned when none of the groups of a user grant a role.
+	ErrNoRole = errors.New("no role is granted to the user")
+	// ErrSessionExpired is returned when a session can no longer be refreshed.
+	ErrSessionExpired = errors.New("session expired")
+)
+
+// Allows reports whether the role grants the required one, admins may do
+// everything readers may.
+func (r Role) Allows(required Role) bool {
+	return r == RoleAdmin || (r != "" && r == required)
+}
+
+// Session is a signed-in user. It is kept in a cookie, encrypted as it holds
+// the refresh token.
+type Session struct {
+	Subject string `json:"sub"`
+	Name    string `json:"name,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Role    Role   `json:"role"`
+	// Expiry is when the ID token the session was last verified with expires.
+	Expiry time.Time `json:"exp"`
+	// SignedIn is when the user signed in, the session TTL counts from it.
+	SignedIn     time.Time `json:"signed_in"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+}
+
+// Expired reports whether the session has to be refreshed at the given instant.
+func (s Session) Expired(at time.Time) bool {
+	return !at.Before(s.Expiry)
+}
+
+// Actor returns the audit log actor of changes made in the session.
+func (s Session) Actor() string {
+	if s.Email != "" {
+		return "oidc:" + s.Email
+	}
+
+	return "oidc:" + s.Subject
+}
@@ -0,0 +1,239 @@
+// Package authtest provides a fake OpenID Connect provider for tests. It
+// serves discovery, its signing keys as a JWKS and a token endpoint that
+// redeems the codes and refresh tokens it issued.
+package authtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+// ClientID and ClientSecret are the credentials the provider accepts.
+const (
+	ClientID     = "oncall"
+	ClientSecret = "oncall-secret"
+)
+
+const keyID = "test"
+
+// Provider is a fake OpenID Connect provider.
+type Provider struct {
+	server *httptest.Server
+
+	mu  sync.Mutex
+	key *rsa.PrivateKey
+	// jwks is the published key set, which keeps the original key
+	jwks   jose.JSONWebKeySet
+	ttl    time.Duration
+	claims map[string]map[string]any
+	codes  map[string]grant
+	tokens map[string]string
+	serial int
+}
+
+// grant is an issued authorization code.
+type grant struct {
+	subject string
+	nonce   string
+}
+
+// NewProvider starts a provider, which is closed with the test. ID tokens
+// expire after an hour.
+func NewProvider(t *testing.T) *Provider {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	p := &Provider{
+		key:    key,
+		jwks:   jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: keyID, Algorithm: string(jose.RS256), Use: "sig"}}},
+		ttl:    time.Hour,
+		claims: make(map[string]map[string]any),
+		codes:  make(map[string]grant),
+		tokens: make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /keys", p.keys)
+	mux.HandleFunc("POST /token", p.token)
+
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+
+	return p
+}
+
+// Issuer returns the issuer URL of the provider.
+func (p *Provider) Issuer() string {
+	return p.server.URL
+}
+
+// SetTokenTTL sets how long the ID tokens issued from now on are valid,
+// negative values issue expired tokens.
+func (p *Provider) SetTokenTTL(ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ttl = ttl
+}
+
+// SetClaims sets the claims of the user with the given sub claim, which go
+// into the ID tokens issued for them from now on.
+func (p *Provider) SetClaims(claims map[string]any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.claims[claims["sub"].(string)] = maps.Clone(claims)
+}
+
+// IssueCode sets the claims of a user and returns an authorization code
+// signing them in, as the provider would after redirecting them back.
+func (p *Provider) IssueCode(nonce string, claims map[string]any) string {
+	p.SetClaims(claims)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.serial++
+	code := fmt.Sprintf("code-%d", p.serial)
+	p.codes[code] = grant{subject: claims["sub"].(string), nonce: nonce}
+
+	return code
+}
+
+// UseUnpublishedKey signs the tokens issued from now on with a key missing
+// from the key set, so they fail verification.
+func (p *Provider) UseUnpublishedKey() {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.key = key
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.server.URL,
+		"authorization_endpoint":                p.server.URL + "/authorize",
+		"token_endpoint":                        p.server.URL + "/token",
+		"jwks_uri":                              p.server.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (p *Provider) keys(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, p.jwks)
+}
+
+// token redeems authorization codes once and refresh tokens, which are
+// rotated on every use.
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	if id, secret, ok := r.BasicAuth(); !ok || id != ClientID || secret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var subject, nonce string
+	switch r.PostFormValue("grant_type") {
+	case "authorization_code":
+		g, ok := p.codes[r.PostFormValue("code")]
+		if !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(p.codes, r.PostFormValue("code"))
+		subject, nonce = g.subject, g.nonce
+	case "refresh_token":
+		var ok bool
+		if subject, ok = p.tokens[r.PostFormValue("refresh_token")]; !ok {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(p.tokens, r.PostFormValue("refresh_token"))
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	now := time.Now()
+	claims := map[string]any{
+		"iss": p.server.URL,
+		"aud": ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(p.ttl).Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	maps.Copy(claims, p.claims[subject])
+
+	idToken, err := p.sign(claims)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	p.serial++
+	refreshToken := fmt.Sprintf("refresh-%d", p.serial)
+	p.tokens[refreshToken] = subject
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  fmt.Sprintf("access-%d", p.serial),
+		"token_type":    "Bearer",
+		"expires_in":    int(p.ttl.Seconds()),
+		"refresh_token": refreshToken,
+		"id_token":      idToken,
+	})
+}
+
+// sign signs claims as a JWT with the current key.
+func (p *Provider) sign(claims map[string]any) (string, error) {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: p.key, KeyID: keyID}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to create signer: %w", err)
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+
+	signed, err := signer.Sign(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign claims: %w", err)
+	}
+
+	return signed.CompactSerialize()
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDC signs users in with the authorization code flow of an OpenID Connect
+// provider and maps the groups in their ID tokens to roles.
+type OIDC struct {
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+	client   *http.Client
+	aead     cipher.AEAD
+
+	ttl          time.Duration
+	groupsClaim  string
+	adminGroups  []string
+	readerGroups []string
+}
+
+// NewOIDC discovers the provider at the issuer of the configuration, which
+// must be reachable. It returns nil when the issuer is empty, as sign-in is
+// then disabled.
+func NewOIDC(ctx context.Context, cfg config.OIDCConfig, client *http.Client) (*OIDC, error) {
+	if cfg.Issuer == "" {
+		return nil, nil
+	}
+
+	switch {
+	case cfg.ClientID == "":
+		return nil, fmt.Errorf("oidc client id is required")
+	case cfg.RedirectURL == "":
+		return nil, fmt.Errorf("oidc redirect url is required")
+	case len(cfg.SessionSecret) < 32:
+		return nil, fmt.Errorf("oidc session secret must be at least 32 bytes")
+	}
+
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, client), cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover oidc provider: %w", err)
+	}
+
+	key := sha256.Sum256([]byte(cfg.SessionSecret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session cipher: %w", err)
+	}
+
+	return &OIDC{
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       cfg.Scopes,
+		},
+		verifier:     provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+		client:       client,
+		aead:         aead,
+		ttl:          cfg.SessionTTL,
+		groupsClaim:  cfg.GroupsClaim,
+		adminGroups:  cfg.AdminGroups,
+		readerGroups: cfg.ReaderGroups,
+	}, nil
+}
+
+// TTL returns how long a session lasts from sign-in.
+func (o *OIDC) TTL() time.Duration {
+	return o.ttl
+}
+
+// AuthCodeURL returns the provider URL users are sent to for signing in.
+func (o *OIDC) AuthCodeURL(state, nonce string) string {
+	return o.oauth2.AuthCodeURL(state, oidc.Nonce(nonce))
+}
+
+// Exchange redeems the authorization code of a callback for a session. The
+// ID token must carry the nonce the sign-in was started with.
+func (o *OIDC) Exchange(ctx context.Context, code, nonce string) (Session, error) {
+	ctx = oidc.ClientContext(ctx, o.client)
+
+	token, err := o.oauth2.Exchange(ctx, code)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	session, err := o.session(ctx, token, nonce)
+	if err != nil {
+		return Session{}, err
+	}
+	session.SignedIn = time.Now()
+
+	return session, nil
+}
+
+// Refresh renews a session whose ID token expired with its refresh token.
+// The role is mapped again, so changes to the groups of the user apply.
+// Sessions without a refresh token or past their TTL are expired.
+func (o *OIDC) Refresh(ctx context.Context, session Session) (Session, error) {
+	if session.RefreshToken == "" || time.Since(session.SignedIn) >= o.ttl {
+		return Session{}, ErrSessionExpired
+	}
+
+	ctx = oidc.ClientContext(ctx, o.client)
+
+	token, err := o.oauth2.TokenSource(ctx, &oauth2.Token{RefreshToken: session.RefreshToken}).Token()
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	refreshed, err := o.session(ctx, token, "")
+	if err != nil {
+		return Session{}, err
+	}
+	if refreshed.Subject != session.Subject {
+		return Session{}, fmt.Errorf("refreshed token belongs to another subject")
+	}
+
+	refreshed.SignedIn = session.SignedIn
+	// Providers may keep the refresh token instead of rotating it
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = session.RefreshToken
+	}
+
+	return refreshed, nil
+}
+
+// session verifies the ID token of a token response, which refreshed ones
+// carry without a nonce.
+func (o *OIDC) session(ctx context.Context, token *oauth2.Token, nonce string) (Session, error) {
+	raw, ok := token.Extra("id_token").(string)
+	if !ok {
+		return Session{}, fmt.Errorf("token response has no id token")
+	}
+
+	idToken, err := o.verifier.Verify(ctx, raw)
+	if err != nil {
+		return Session{}, fmt.Errorf("failed to verify id token: %w", err)
+	}
+	if nonce != "" && idToken.Nonce != nonce {
+		return Session{}, fmt.Errorf("id token nonce does not match")
+	}
+
+	var claims map[string]any
+	if err := idToken.Claims(&claims); err != nil {
+		return Session{}, fmt.Errorf("failed to parse id token claims: %w", err)
+	}
+
+	role := o.role(groups(claims[o.groupsClaim]))
+	if role == "" {
+		return Session{}, ErrNoRole
+	}
+
+	name, _ := claims["name"].(string)
+	email, _ := claims["email"].(string)
+
+	return Session{
+		Subject:      idToken.Subject,
+		Name:         name,
+		Email:        email,
+		Role:         role,
+		Expiry:       idToken.Expiry,
+		RefreshToken: token.RefreshToken,
+	}, nil
+}
+
+// role maps groups to the highest role they grant, or to no role.
+func (o *OIDC) role(groups []string) Role {
+	granted := func(allowed []string) bool {
+		return slices.ContainsFunc(groups, func(group string) bool { return slices.Contains(allowed, group) })
+	}
+
+	switch {
+	case granted(o.adminGroups):
+		return RoleAdmin
+	case len(o.readerGroups) == 0 || granted(o.readerGroups):
+		return RoleReader
+	default:
+		return ""
+	}
+}
+
+// groups reads a groups claim, which providers send as a list or, with a
+// single group, as a string.
+func groups(claim any) []string {
+	switch claim := claim.(type) {
+	case string:
+		return []string{claim}
+	case []any:
+		var result []string
+		for _, group := range claim {
+			if group, ok := group.(string); ok {
+				result = append(result, group)
+			}
+		}
+		return result
+	default:
+		return nil
+	}
+}
+
+// Seal encrypts a value into a cookie of the given name. The name is
+// authenticated too, so a cookie cannot be replayed as another one.
+func (o *OIDC) Seal(name string, v any) (string, error) {
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cookie: %w", err)
+	}
+
+	nonce := make([]byte, o.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate cookie nonce: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(o.aead.Seal(nonce, nonce, plain, []byte(name))), nil
+}
+
+// Open decrypts a cookie of the given name sealed by Seal into v.
+func (o *OIDC) Open(name, value string, v any) error {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(sealed) < o.aead.NonceSize() {
+		return errors.New("malformed cookie")
+	}
+
+	plain, err := o.aead.Open(nil, sealed[:o.aead.NonceSize()], sealed[o.aead.NonceSize():], []byte(name))
+	if err != nil {
+		return errors.New("invalid cookie")
+	}
+
+	if err := json.Unmarshal(plain, v); err != nil {
+		return fmt.Errorf("failed to decode cookie: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth/authtest"
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOIDC(t *testing.T, p *authtest.Provider, modify func(*config.OIDCConfig)) *OIDC {
+	t.Helper()
+
+	cfg := config.OIDCConfig{
+		Issuer:        p.Issuer(),
+		ClientID:      authtest.ClientID,
+		ClientSecret:  authtest.ClientSecret,
+		RedirectURL:   "http://oncall.test/auth/callback",
+		Scopes:        []string{"openid", "profile", "email"},
+		SessionSecret: strings.Repeat("s", 32),
+		SessionTTL:    12 * time.Hour,
+		GroupsClaim:   "groups",
+		AdminGroups:   []string{"sre-leads"},
+	}
+	if modify != nil {
+		modify(&cfg)
+	}
+
+	o, err := NewOIDC(context.Background(), cfg, http.DefaultClient)
+	require.NoError(t, err)
+
+	return o
+}
+
+func alice(groups ...any) map[string]any {
+	return map[string]any{"sub": "alice-id", "name": "Alice", "email": "alice@example.org", "groups": groups}
+}
+
+func TestNewOIDC(t *testing.T) {
+	o, err := NewOIDC(context.Background(), config.OIDCConfig{}, http.DefaultClient)
+	require.NoError(t, err)
+	assert.Nil(t, o, "sign-in is disabled without an issuer")
+
+	p := authtest.NewProvider(t)
+	valid := config.OIDCConfig{
+		Issuer:        p.Issuer(),
+		ClientID:      authtest.ClientID,
+		RedirectURL:   "http://oncall.test/auth/callback",
+		SessionSecret: strings.Repeat("s", 32),
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*config.OIDCConfig)
+	}{
+		{"missing client id", func(c *config.OIDCConfig) { c.ClientID = "" }},
+		{"missing redirect url", func(c *config.OIDCConfig) { c.RedirectURL = "" }},
+		{"short session secret", func(c *config.OIDCConfig) { c.SessionSecret = "short" }},
+		{"unreachable issuer", func(c *config.OIDCConfig) { c.Issuer = p.Issuer() + "/missing" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+
+			_, err := NewOIDC(context.Background(), cfg, http.DefaultClient)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOIDC_Exchange(t *testing.T) {
+	p := authtest.NewProvider(t)
+	o := newOIDC(t, p, nil)
+	ctx := context.Background()
+
+	session, err := o.Exchange(ctx, p.IssueCode("nonce", alice("sre-leads")), "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, "alice-id", session.Subject)
+	assert.Equal(t, "Alice", session.Name)
+	assert.Equal(t, "alice@example.org", session.Email)
+	assert.Equal(t, RoleAdmin, session.Role)
+	assert.NotEmpty(t, session.RefreshToken)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), session.Expiry, time.Minute)
+	assert.WithinDuration(t, time.Now(), session.SignedIn, time.Minute)
+	assert.Equal(t, "oidc:alice@example.org", session.Actor())
+
+	// Every user is a reader without reader groups, and a single group may be a string
+	claims := alice()
+	claims["groups"] = "developers"
+	session, err = o.Exchange(ctx, p.IssueCode("nonce", claims), "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, RoleReader, session.Role)
+
+	// Codes are redeemed once
+	code := p.IssueCode("nonce", alice())
+	_, err = o.Exchange(ctx, code, "nonce")
+	require.NoError(t, err)
+	_, err = o.Exchange(ctx, code, "nonce")
+	assert.Error(t, err)
+
+	_, err = o.Exchange(ctx, p.IssueCode("nonce", alice()), "other")
+	assert.ErrorContains(t, err, "nonce")
+
+	restricted := newOIDC(t, p, func(c *config.OIDCConfig) { c.ReaderGroups = []string{"oncall"} })
+	_, err = restricted.Exchange(ctx, p.IssueCode("nonce", alice("developers")), "nonce")
+	assert.ErrorIs(t, err, ErrNoRole)
+	session, err = restricted.Exchange(ctx, p.IssueCode("nonce", alice("developers", "oncall")), "nonce")
+	require.NoError(t, err)
+	assert.Equal(t, RoleReader, session.Role)
+
+	other := newOIDC(t, p, func(c *config.OIDCConfig) { c.ClientID = "another-client" })
+	_, err = other.Exchange(ctx, p.IssueCode("nonce", alice()), "nonce")
+	assert.Error(t, err, "tokens of other clients are rejected")
+
+	p.SetTokenTTL(-time.Minute)
+	_, err = o.Exchange(ctx, p.IssueCode("nonce", alice()), "nonce")
+	assert.ErrorContains(t, err, "expired")
+	p.SetTokenTTL(time.Hour)
+
+	p.UseUnpublishedKey()
+	_, err = o.Exchange(ctx, p.IssueCode("nonce", alice()), "nonce")
+	assert.ErrorContains(t, err, "verify")
+}
+
+func TestOIDC_Refresh(t *testing.T) {
+	p := authtest.NewProvider(t)
+	o := newOIDC(t, p, nil)
+	ctx := context.Background()
+
+	session, err := o.Exchange(ctx, p.IssueCode("nonce", alice()), "nonce")
+	require.NoError(t, err)
+	require.Equal(t, RoleReader, session.Role)
+
+	// Groups changed since sign-in apply on refresh
+	p.SetClaims(alice("sre-leads"))
+	refreshed, err := o.Refresh(ctx, session)
+	require.NoError(t, err)
+	assert.Equal(t, "alice-id", refreshed.Subject)
+	assert.Equal(t, RoleAdmin, refreshed.Role)
+	assert.Equal(t, session.SignedIn, refreshed.SignedIn)
+	assert.NotEqual(t, session.RefreshToken, refreshed.RefreshToken, "the provider rotates refresh tokens")
+
+	// The rotated token is gone
+	_, err = o.Refresh(ctx, session)
+	assert.Error(t, err)
+
+	p.SetClaims(alice("sre-leads"))
+	restricted := newOIDC(t, p, func(c *config.OIDCConfig) { c.ReaderGroups = []string{"oncall"}; c.AdminGroups = nil })
+	_, err = restricted.Refresh(ctx, refreshed)
+	assert.ErrorIs(t, err, ErrNoRole)
+
+	stale := refreshed
+	stale.SignedIn = time.Now().Add(-13 * time.Hour)
+	_, err = o.Refresh(ctx, stale)
+	assert.ErrorIs(t, err, ErrSessionExpired)
+
+	_, err = o.Refresh(ctx, Session{Subject: "alice-id", SignedIn: time.Now()})
+	assert.ErrorIs(t, err, ErrSessionExpired)
+}
+
+func TestOIDC_SealOpen(t *testing.T) {
+	p := authtest.NewProvider(t)
+	o := newOIDC(t, p, nil)
+
+	session := Session{Subject: "alice-id", Role: RoleAdmin, Expiry: time.Now().Add(time.Hour).UTC().Truncate(time.Second), RefreshToken: "refresh"}
+
+	sealed, err := o.Seal("session", session)
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, "refresh")
+
+	var opened Session
+	require.NoError(t, o.Open("session", sealed, &opened))
+	assert.Equal(t, session, opened)
+
+	assert.Error(t, o.Open("login", sealed, &opened), "cookies cannot be replayed under another name")
+	assert.Error(t, o.Open("session", sealed[:len(sealed)-2]+"AA", &opened))
+	assert.Error(t, o.Open("session", "not base64!", &opened))
+
+	other := newOIDC(t, p, func(c *config.OIDCConfig) { c.SessionSecret = strings.Repeat("x", 32) })
+	assert.Error(t, other.Open("session", sealed, &opened))
+}
+
+func TestRole_Allows(t *testing.T) {
+	assert.True(t, RoleAdmin.Allows(RoleAdmin))
+	assert.True(t, RoleAdmin.Allows(RoleReader))
+	assert.True(t, RoleReader.Allows(RoleReader))
+	assert.False(t, RoleReader.Allows(RoleAdmin))
+	assert.False(t, Role("").Allows(""))
+}
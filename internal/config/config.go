@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf/parsers/yaml"
 	"github.com/knadh/koanf/providers/env/v2"
@@ -15,27 +17,410 @@ const prefix = "ONCALL_"
 
 // Config holds the application configuration.
 type Config struct {
-	Server   ServerConfig   `koanf:"server"`
-	Database DatabaseConfig `koanf:"database"`
+	Server         ServerConfig         `koanf:"server"`
+	Logging        LoggingConfig        `koanf:"logging"`
+	Database       DatabaseConfig       `koanf:"database"`
+	Cache          CacheConfig          `koanf:"cache"`
+	Metrics        MetricsConfig        `koanf:"metrics"`
+	Admin          AdminConfig          `koanf:"admin"`
+	SCIM           SCIMConfig           `koanf:"scim"`
+	OIDC           OIDCConfig           `koanf:"oidc"`
+	Seed           SeedConfig           `koanf:"seed"`
+	Quota          QuotaConfig          `koanf:"quota"`
+	Week           WeekConfig           `koanf:"week"`
+	Hierarchy      HierarchyConfig      `koanf:"hierarchy"`
+	Integrity      IntegrityConfig      `koanf:"integrity"`
+	UI             UIConfig             `koanf:"ui"`
+	GoogleCalendar GoogleCalendarConfig `koanf:"google_calendar"`
+	HandoffNotes   HandoffNotesConfig   `koanf:"handoff_notes"`
+	Routing        RoutingConfig        `koanf:"routing"`
+	Janitor        JanitorConfig        `koanf:"janitor"`
+	Notify         NotifyConfig         `koanf:"notify"`
 }
 
 // ServerConfig holds the server configuration.
 type ServerConfig struct {
-	Address string `koanf:"address"`
-	Port    int    `koanf:"port"`
+	Address        string        `koanf:"address"`
+	Port           int           `koanf:"port"`
+	RequestTimeout time.Duration `koanf:"request_timeout"`
+	MaxInFlight    int           `koanf:"max_in_flight"`
+	QueueSize      int           `koanf:"queue_size"`
+	QueueTimeout   time.Duration `koanf:"queue_timeout"`
+	ReadOnly       bool          `koanf:"read_only"`
+	ReadOnlyReason string        `koanf:"read_only_reason"`
+	// DrainDelay is how long the server reports itself as not ready before it stops accepting requests.
+	DrainDelay time.Duration `koanf:"drain_delay"`
+	// OncallMaxAge is the longest clients are told to cache on-call answers for.
+	OncallMaxAge time.Duration `koanf:"oncall_max_age"`
+	// ShutdownTimeout bounds the wait for in-flight requests once the server stops accepting requests.
+	ShutdownTimeout time.Duration `koanf:"shutdown_timeout"`
+	// Listeners are the addresses the server is served on, each on its own
+	// listener. Without any it is served on Address and Port.
+	Listeners []ListenerConfig `koanf:"listeners"`
+	// Socket serves the server on a unix domain socket as well, or instead
+	// of the listeners when Socket.Only is set.
+	Socket SocketConfig `koanf:"socket"`
+}
+
+// SocketConfig holds the configuration of the unix domain socket the server
+// is served on, which is disabled when Path is empty.
+type SocketConfig struct {
+	Path string `koanf:"path"`
+	// Mode is the octal file mode of the socket, e.g. "0660".
+	Mode string `koanf:"mode"`
+	// Only serves the server on the socket alone, without the listeners.
+	Only bool `koanf:"only"`
+}
+
+// Enabled reports whether the server is served on the socket.
+func (c SocketConfig) Enabled() bool {
+	return c.Path != ""
+}
+
+// FileMode parses the mode of the socket.
+func (c SocketConfig) FileMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(c.Mode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q, use an octal mode such as 0660", c.Mode)
+	}
+
+	return os.FileMode(mode), nil
+}
+
+// ListenerConfig holds the configuration of a single listener of the server.
+type ListenerConfig struct {
+	Address string    `koanf:"address"`
+	Port    int       `koanf:"port"`
+	TLS     TLSConfig `koanf:"tls"`
+	// RedirectToHTTPS redirects every request but the health checks to the
+	// first TLS listener, for plain listeners kept during a move to TLS.
+	RedirectToHTTPS bool `koanf:"redirect_to_https"`
+}
+
+// TLSConfig holds the certificate a listener serves TLS with, which is
+// disabled when either file is empty.
+type TLSConfig struct {
+	CertFile string `koanf:"cert_file"`
+	KeyFile  string `koanf:"key_file"`
+}
+
+// Enabled reports whether the listener serves TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// LoggingConfig holds the logger configuration.
+type LoggingConfig struct {
+	// Level is the minimum level logged, one of debug, info, warn, error,
+	// dpanic, panic and fatal.
+	Level string `koanf:"level"`
+	// Encoding is either json or console.
+	Encoding string `koanf:"encoding"`
+	// OutputPaths are the files or stdout and stderr logs are written to.
+	OutputPaths []string `koanf:"output_paths"`
+	// ErrorOutputPaths are where the errors of the logger itself are written.
+	ErrorOutputPaths []string       `koanf:"error_output_paths"`
+	Sampling         SamplingConfig `koanf:"sampling"`
+	// StacktraceLevel is the minimum level logged with a stacktrace.
+	StacktraceLevel string `koanf:"stacktrace_level"`
+	// AccessLog logs every request the server answers.
+	AccessLog bool `koanf:"access_log"`
+}
+
+// SamplingConfig holds the sampling of the logger: every second, the first
+// Initial entries with the same level and message are logged, then every
+// Thereafter-th of them. Sampling is disabled when Initial is zero.
+type SamplingConfig struct {
+	Initial    int `koanf:"initial"`
+	Thereafter int `koanf:"thereafter"`
 }
 
 // DatabaseConfig holds the database configuration.
 type DatabaseConfig struct {
-	Host            string `koanf:"host"`
-	Port            int    `koanf:"port"`
-	User            string `koanf:"user"`
-	Password        string `koanf:"password"`
-	Database        string `koanf:"database"`
-	SSLMode         string `koanf:"ssl_mode"`
-	MaxConnections  int32  `koanf:"max_connections"`
-	MinConnections  int32  `koanf:"min_connections"`
-	MigrationsPath  string `koanf:"migrations_path"`
+	Host           string        `koanf:"host"`
+	Port           int           `koanf:"port"`
+	User           string        `koanf:"user"`
+	Password       string        `koanf:"password"`
+	Database       string        `koanf:"database"`
+	SSLMode        string        `koanf:"ssl_mode"`
+	MaxConnections int32         `koanf:"max_connections"`
+	MinConnections int32         `koanf:"min_connections"`
+	MigrationsPath string        `koanf:"migrations_path"`
+	Breaker        BreakerConfig `koanf:"breaker"`
+}
+
+// BreakerConfig holds the circuit breaker configuration of the database storage.
+type BreakerConfig struct {
+	Threshold int           `koanf:"threshold"`
+	Cooldown  time.Duration `koanf:"cooldown"`
+}
+
+// CacheConfig holds the configuration of the cache in front of the database storage.
+type CacheConfig struct {
+	TTL          time.Duration `koanf:"ttl"`
+	Warmup       bool          `koanf:"warmup"`
+	WarmupBudget time.Duration `koanf:"warmup_budget"`
+}
+
+// MetricsConfig holds the configuration of the optional Prometheus metrics.
+type MetricsConfig struct {
+	// Storage measures the latency, calls and errors of every storage method.
+	Storage bool `koanf:"storage"`
+}
+
+// AdminConfig holds the configuration of the admin API.
+type AdminConfig struct {
+	// Token is the bearer token of the admin routes, which are disabled when it is empty.
+	Token string `koanf:"token"`
+	// Keys are API keys accepted next to the ones created through the API, to bootstrap them.
+	Keys []APIKeyConfig `koanf:"keys"`
+}
+
+// APIKeyConfig holds an API key of the config file.
+type APIKeyConfig struct {
+	// Name is the audit log actor of the requests made with the key.
+	Name string `koanf:"name"`
+	Key  string `koanf:"key"`
+	// Role is either reader or admin.
+	Role string `koanf:"role"`
+}
+
+// SCIMConfig holds the configuration of the SCIM user provisioning API.
+type SCIMConfig struct {
+	// Token is the bearer token of the identity provider, provisioning is disabled when it is empty.
+	Token string `koanf:"token"`
+}
+
+// OIDCConfig holds the configuration of the OpenID Connect sign-in of
+// interactive users, which is disabled when the issuer is empty.
+type OIDCConfig struct {
+	Issuer       string `koanf:"issuer"`
+	ClientID     string `koanf:"client_id"`
+	ClientSecret string `koanf:"client_secret"`
+	RedirectURL  string `koanf:"redirect_url"`
+	// Scopes are requested on sign-in, some providers need offline_access for refresh tokens.
+	Scopes []string `koanf:"scopes"`
+	// SessionSecret is the key session cookies are encrypted with, it must be shared by all instances.
+	SessionSecret string `koanf:"session_secret"`
+	// SessionTTL bounds how long a session is refreshed for before signing in again.
+	SessionTTL time.Duration `koanf:"session_ttl"`
+	// GroupsClaim is the ID token claim holding the groups of the user.
+	GroupsClaim string `koanf:"groups_claim"`
+	// AdminGroups are the groups granted the admin role.
+	AdminGroups []string `koanf:"admin_groups"`
+	// ReaderGroups are the groups granted the reader role, every user is a reader when it is empty.
+	ReaderGroups []string `koanf:"reader_groups"`
+}
+
+// SeedConfig holds the configuration of the data loaded on startup.
+type SeedConfig struct {
+	// File is the YAML document of teams and schedules to load, nothing is loaded when it is empty.
+	File string `koanf:"file"`
+	// Strict fails startup on a malformed seed file instead of skipping the bad entries.
+	Strict bool `koanf:"strict"`
+}
+
+// QuotaConfig holds the limits on the resources of every team, zero means unlimited.
+type QuotaConfig struct {
+	Schedules int `koanf:"schedules"`
+	// Pins limits the pins across all the schedules of a team.
+	Pins int `koanf:"pins"`
+	// Webhooks limits the subscriptions to a team, the ones to every team share a quota.
+	Webhooks int `koanf:"webhooks"`
+	// Teams maps team names to limits overriding the ones above, unset fields are inherited.
+	Teams map[string]QuotaTeamConfig `koanf:"teams"`
+}
+
+// QuotaTeamConfig holds the limits of a single team.
+type QuotaTeamConfig struct {
+	Schedules int `koanf:"schedules"`
+	Pins      int `koanf:"pins"`
+	Webhooks  int `koanf:"webhooks"`
+}
+
+// WeekConfig holds the week conventions of the teams, which the day keywords
+// of schedules, the weekly statistics, the digest and the weekend coverage
+// warning follow.
+type WeekConfig struct {
+	// Start is the first day of the week, such as monday.
+	Start string `koanf:"week_start"`
+	// WeekendDays are the days of the weekend.
+	WeekendDays []string `koanf:"weekend_days"`
+	// Teams maps team names to conventions overriding the ones above, unset fields are inherited.
+	Teams map[string]WeekTeamConfig `koanf:"teams"`
+}
+
+// WeekTeamConfig holds the week convention of a single team.
+type WeekTeamConfig struct {
+	Start       string   `koanf:"week_start"`
+	WeekendDays []string `koanf:"weekend_days"`
+}
+
+// DefaultOncallMaxAge is the longest clients are told to cache on-call answers
+// for when none is configured.
+const DefaultOncallMaxAge = 5 * time.Minute
+
+// DefaultRoutingKeys are the routing keys schedules may set when none are configured.
+var DefaultRoutingKeys = []string{"pagerduty_service_id", "opsgenie_team", "slack_channel"}
+
+// DefaultHierarchyDepth is how many parents up on-call lookups go when none
+// is configured.
+const DefaultHierarchyDepth = 3
+
+// HierarchyConfig holds the configuration of the teams inheriting the
+// schedules of their parent team.
+type HierarchyConfig struct {
+	// MaxDepth is how many parents up on-call lookups go for a team with
+	// nothing matching of its own.
+	MaxDepth int `koanf:"max_depth"`
+}
+
+// IntegrityConfig holds the configuration of the periodic check of the
+// members the schedules reference.
+type IntegrityConfig struct {
+	// Interval is how often every team is checked to keep the findings
+	// metric current, zero disables the checks.
+	Interval time.Duration `koanf:"interval"`
+}
+
+// UIConfig holds the configuration of the web UI showing who is on call.
+type UIConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// BasePath is the path prefix the server is reachable under behind a
+	// reverse proxy, the UI prefixes the requests it makes with it.
+	BasePath string `koanf:"base_path"`
+}
+
+// GoogleCalendarConfig holds the configuration of the sync pushing the
+// upcoming shifts of the teams to the Google calendars set in their settings.
+type GoogleCalendarConfig struct {
+	Enabled bool `koanf:"enabled"`
+	// Credentials is the path of the JSON key of the service account the
+	// calendars are shared with.
+	Credentials string `koanf:"credentials"`
+	// Weeks is how many weeks of shifts ahead of now are pushed.
+	Weeks int `koanf:"weeks"`
+	// Interval is how often the teams are checked for changed shifts.
+	Interval time.Duration `koanf:"interval"`
+	// Reconcile is how often the calendars are compared with the shifts
+	// even when nothing changed, undoing edits made in them.
+	Reconcile time.Duration `koanf:"reconcile"`
+}
+
+// DefaultHandoffNoteTTL is the longest a handoff note is shown for when none
+// is configured.
+const DefaultHandoffNoteTTL = 72 * time.Hour
+
+// HandoffNotesConfig holds the configuration of the notes the members on
+// call leave to whoever takes the next shift over.
+type HandoffNotesConfig struct {
+	// TTL is the longest a note is shown for once its shift ended, notes
+	// expire earlier at the end of the next shift.
+	TTL time.Duration `koanf:"ttl"`
+}
+
+// RoutingConfig holds the configuration of the alert-routing metadata of the schedules.
+type RoutingConfig struct {
+	// Keys are the keys schedules may set in their routing, DefaultRoutingKeys when empty.
+	Keys []string `koanf:"keys"`
+}
+
+// JanitorConfig holds the configuration of the periodic cleanup of expired and stale data.
+type JanitorConfig struct {
+	Enabled  bool          `koanf:"enabled"`
+	Interval time.Duration `koanf:"interval"`
+	// ScheduleGrace is how long an ended schedule is kept before it is soft-deleted.
+	ScheduleGrace time.Duration `koanf:"schedule_grace"`
+	// HistoryRetention is how long audit log entries are kept.
+	HistoryRetention time.Duration `koanf:"history_retention"`
+}
+
+// NotifyConfig holds the configuration of the on-call notifications.
+type NotifyConfig struct {
+	// Interval is how often teams are checked for handoffs and gaps, zero disables the checks.
+	Interval time.Duration `koanf:"interval"`
+	// Attempts is how many times a notification is tried before it is given up on.
+	Attempts int `koanf:"attempts"`
+	// Backoff is the delay before the first retry, it doubles on every later one.
+	Backoff   time.Duration   `koanf:"backoff"`
+	Reminders RemindersConfig `koanf:"reminders"`
+	Observers ObserversConfig `koanf:"observers"`
+	Gaps      GapsConfig      `koanf:"gaps"`
+	Digest    DigestConfig    `koanf:"digest"`
+	Telegram  TelegramConfig  `koanf:"telegram"`
+	MSTeams   MSTeamsConfig   `koanf:"msteams"`
+	Webhook   WebhookConfig   `koanf:"webhook"`
+}
+
+// RemindersConfig holds the configuration of the reminders sent before a shift starts.
+type RemindersConfig struct {
+	// LeadTime is how long before the start of a shift its member is reminded, zero disables reminders.
+	LeadTime time.Duration `koanf:"lead_time"`
+	// Teams maps team names to lead times overriding LeadTime, zero disables the reminders of the team.
+	Teams map[string]time.Duration `koanf:"teams"`
+}
+
+// ObserversConfig holds whether the observers of the teams, who are on their
+// rosters but never on call, are named in their handoff notifications.
+type ObserversConfig struct {
+	// Handoff names the observers of every team in its handoff notifications.
+	Handoff bool `koanf:"handoff"`
+	// Teams maps team names to settings overriding Handoff.
+	Teams map[string]bool `koanf:"teams"`
+}
+
+// GapsConfig holds the configuration of the monitor alerting on upcoming coverage gaps.
+type GapsConfig struct {
+	// Interval is how often the coverage of every team is evaluated, zero disables the monitor.
+	Interval time.Duration `koanf:"interval"`
+	// Horizon is how far ahead of now the coverage is evaluated.
+	Horizon time.Duration `koanf:"horizon"`
+}
+
+// DigestConfig holds the configuration of the daily digest of upcoming shifts.
+type DigestConfig struct {
+	// Time is the time of day the digest is sent at, as HH:MM in Timezone, empty disables the digest.
+	Time    string        `koanf:"time"`
+	Horizon time.Duration `koanf:"horizon"`
+	// Timezone is the IANA zone the send time and the shifts of the digest are in.
+	Timezone string `koanf:"timezone"`
+	// Template is a Go template overriding the default digest.
+	Template string `koanf:"template"`
+	// Teams maps team names to settings overriding the ones above, unset fields are inherited.
+	Teams map[string]DigestTeamConfig `koanf:"teams"`
+}
+
+// DigestTeamConfig holds the digest settings of a single team.
+type DigestTeamConfig struct {
+	Time     string        `koanf:"time"`
+	Horizon  time.Duration `koanf:"horizon"`
+	Timezone string        `koanf:"timezone"`
+}
+
+// TelegramConfig holds the configuration of the Telegram notifier, which is
+// disabled when the token is empty.
+type TelegramConfig struct {
+	Token  string `koanf:"token"`
+	APIURL string `koanf:"api_url"`
+	// Chats maps team names to chat IDs, teams without one use DefaultChat.
+	Chats       map[string]string `koanf:"chats"`
+	DefaultChat string            `koanf:"default_chat"`
+	// Templates maps event kinds to Go templates overriding the default messages.
+	Templates map[string]string `koanf:"templates"`
+}
+
+// MSTeamsConfig holds the configuration of the Microsoft Teams notifier,
+// which posts to incoming webhooks.
+type MSTeamsConfig struct {
+	// Webhooks maps team names to webhook URLs, teams without one use DefaultWebhook.
+	Webhooks       map[string]string `koanf:"webhooks"`
+	DefaultWebhook string            `koanf:"default_webhook"`
+}
+
+// WebhookConfig holds the configuration of the webhook subscriptions.
+type WebhookConfig struct {
+	// AllowUnsigned allows creating subscriptions without a secret, whose deliveries are not signed.
+	AllowUnsigned bool `koanf:"allow_unsigned"`
 }
 
 // Load loads configuration from file and environment variables.
@@ -79,6 +464,52 @@ func Load() (*Config, error) {
 	if cfg.Server.Port == 0 {
 		cfg.Server.Port = 1373
 	}
+	if len(cfg.Server.Listeners) == 0 {
+		cfg.Server.Listeners = []ListenerConfig{{Address: cfg.Server.Address, Port: cfg.Server.Port}}
+	}
+	if cfg.Server.Socket.Mode == "" {
+		cfg.Server.Socket.Mode = "0660"
+	}
+	if cfg.Server.RequestTimeout == 0 {
+		cfg.Server.RequestTimeout = 30 * time.Second
+	}
+	if cfg.Server.QueueTimeout == 0 {
+		cfg.Server.QueueTimeout = 100 * time.Millisecond
+	}
+	if cfg.Server.DrainDelay == 0 {
+		cfg.Server.DrainDelay = 5 * time.Second
+	}
+	if cfg.Server.OncallMaxAge == 0 {
+		cfg.Server.OncallMaxAge = DefaultOncallMaxAge
+	}
+	if cfg.Server.ShutdownTimeout == 0 {
+		cfg.Server.ShutdownTimeout = 15 * time.Second
+	}
+
+	// Logging defaults
+	if cfg.Logging.Level == "" {
+		cfg.Logging.Level = "info"
+	}
+	if cfg.Logging.Encoding == "" {
+		cfg.Logging.Encoding = "json"
+	}
+	if len(cfg.Logging.OutputPaths) == 0 {
+		cfg.Logging.OutputPaths = []string{"stderr"}
+	}
+	if len(cfg.Logging.ErrorOutputPaths) == 0 {
+		cfg.Logging.ErrorOutputPaths = []string{"stderr"}
+	}
+	// Sampling and the access log are only defaulted when they are not set,
+	// as they are disabled with zero values
+	if !k.Exists("logging.sampling") {
+		cfg.Logging.Sampling = SamplingConfig{Initial: 100, Thereafter: 100}
+	}
+	if cfg.Logging.StacktraceLevel == "" {
+		cfg.Logging.StacktraceLevel = "error"
+	}
+	if !k.Exists("logging.access_log") {
+		cfg.Logging.AccessLog = true
+	}
 
 	// Database defaults
 	if cfg.Database.Host == "" {
@@ -105,6 +536,99 @@ func Load() (*Config, error) {
 	if cfg.Database.MigrationsPath == "" {
 		cfg.Database.MigrationsPath = "migrations"
 	}
+	if cfg.Database.Breaker.Threshold == 0 {
+		cfg.Database.Breaker.Threshold = 5
+	}
+	if cfg.Database.Breaker.Cooldown == 0 {
+		cfg.Database.Breaker.Cooldown = 10 * time.Second
+	}
+
+	// Cache defaults
+	if cfg.Cache.TTL == 0 {
+		cfg.Cache.TTL = 30 * time.Second
+	}
+	if cfg.Cache.WarmupBudget == 0 {
+		cfg.Cache.WarmupBudget = 10 * time.Second
+	}
+
+	// OIDC defaults
+	if len(cfg.OIDC.Scopes) == 0 {
+		cfg.OIDC.Scopes = []string{"openid", "profile", "email"}
+	}
+	if cfg.OIDC.SessionTTL == 0 {
+		cfg.OIDC.SessionTTL = 12 * time.Hour
+	}
+	if cfg.OIDC.GroupsClaim == "" {
+		cfg.OIDC.GroupsClaim = "groups"
+	}
+
+	// Week defaults
+	if cfg.Week.Start == "" {
+		cfg.Week.Start = "monday"
+	}
+	if len(cfg.Week.WeekendDays) == 0 {
+		cfg.Week.WeekendDays = []string{"saturday", "sunday"}
+	}
+
+	// Hierarchy defaults
+	if cfg.Hierarchy.MaxDepth == 0 {
+		cfg.Hierarchy.MaxDepth = DefaultHierarchyDepth
+	}
+
+	// UI defaults, the base path is either empty or starts with a slash
+	cfg.UI.BasePath = strings.TrimRight(cfg.UI.BasePath, "/")
+	if cfg.UI.BasePath != "" && !strings.HasPrefix(cfg.UI.BasePath, "/") {
+		cfg.UI.BasePath = "/" + cfg.UI.BasePath
+	}
+
+	// Google Calendar defaults
+	if cfg.GoogleCalendar.Weeks == 0 {
+		cfg.GoogleCalendar.Weeks = 4
+	}
+	if cfg.GoogleCalendar.Interval == 0 {
+		cfg.GoogleCalendar.Interval = time.Minute
+	}
+	if cfg.GoogleCalendar.Reconcile == 0 {
+		cfg.GoogleCalendar.Reconcile = time.Hour
+	}
+
+	// Handoff note defaults
+	if cfg.HandoffNotes.TTL <= 0 {
+		cfg.HandoffNotes.TTL = DefaultHandoffNoteTTL
+	}
+
+	// Routing defaults
+	if len(cfg.Routing.Keys) == 0 {
+		cfg.Routing.Keys = DefaultRoutingKeys
+	}
+
+	// Janitor defaults
+	if cfg.Janitor.Interval == 0 {
+		cfg.Janitor.Interval = time.Hour
+	}
+	if cfg.Janitor.HistoryRetention == 0 {
+		cfg.Janitor.HistoryRetention = 90 * 24 * time.Hour
+	}
+
+	// Notification defaults
+	if cfg.Notify.Attempts == 0 {
+		cfg.Notify.Attempts = 3
+	}
+	if cfg.Notify.Backoff == 0 {
+		cfg.Notify.Backoff = time.Second
+	}
+	if cfg.Notify.Gaps.Horizon == 0 {
+		cfg.Notify.Gaps.Horizon = 24 * time.Hour
+	}
+	if cfg.Notify.Digest.Horizon == 0 {
+		cfg.Notify.Digest.Horizon = 24 * time.Hour
+	}
+	if cfg.Notify.Digest.Timezone == "" {
+		cfg.Notify.Digest.Timezone = "UTC"
+	}
+	if cfg.Notify.Telegram.APIURL == "" {
+		cfg.Notify.Telegram.APIURL = "https://api.telegram.org"
+	}
 
 	return &cfg, nil
 }
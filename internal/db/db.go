@@ -2,12 +2,14 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/1995parham-learning/oncall-schedule/internal/config"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
@@ -50,6 +52,11 @@ func New(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*DB, error) {
 
 	var pool *pgxpool.Pool
 
+	// The pool only exists once started, so it is set on the returned DB then
+	db := &DB{
+		log: log,
+	}
+
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			// Create connection pool
@@ -59,6 +66,7 @@ func New(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*DB, error) {
 			}
 
 			pool = p
+			db.Pool = p
 
 			// Test connection
 			if err := pool.Ping(ctx); err != nil {
@@ -87,11 +95,6 @@ func New(lc fx.Lifecycle, cfg *config.Config, logger *zap.Logger) (*DB, error) {
 		},
 	})
 
-	db := &DB{
-		Pool: pool,
-		log:  log,
-	}
-
 	return db, nil
 }
 
@@ -141,3 +144,53 @@ func runMigrations(connString, migrationsPath string, log *zap.Logger) error {
 func (db *DB) Health(ctx context.Context) error {
 	return db.Pool.Ping(ctx)
 }
+
+// MigrationStatus returns the schema version of the database and whether the
+// last migration failed halfway, as recorded by golang-migrate. A database no
+// migration ran on is at version zero.
+func (db *DB) MigrationStatus(ctx context.Context) (uint, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+
+	err := db.Pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+
+	return uint(version), dirty, nil
+}
+
+// TryLock takes the session-level advisory lock with the given key without
+// waiting for it. The lock is held on a dedicated connection until release is
+// called, ok is false when another session holds it.
+func (db *DB) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	conn, err := db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	var ok bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+
+	if !ok {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	release := func() {
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			db.log.Warn("failed to release advisory lock", zap.Int64("key", key), zap.Error(err))
+		}
+		conn.Release()
+	}
+
+	return release, true, nil
+}
@@ -0,0 +1,297 @@
+// Package diff compares two definitions of a schedule, such as two of its
+// versions, and describes what changed between them.
+package diff
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+)
+
+// Kind is the kind of a change.
+type Kind string
+
+// Change kinds. Fields holding a single value are changed, while members,
+// days and tags are added, removed or, for members only, reordered.
+const (
+	KindChanged   Kind = "changed"
+	KindAdded     Kind = "added"
+	KindRemoved   Kind = "removed"
+	KindReordered Kind = "reordered"
+)
+
+// clockLayout is the layout of the start and end times and of the handoff.
+const clockLayout = "15:04"
+
+// Change is a difference between two definitions of a schedule. Changed
+// fields hold their Old and New values, added entries are in New and removed
+// ones in Old, and reordered members hold both orders. Summary describes the
+// change for people.
+type Change struct {
+	Field   string `json:"field"`
+	Kind    Kind   `json:"kind"`
+	Old     any    `json:"old,omitempty"`
+	New     any    `json:"new,omitempty"`
+	Summary string `json:"summary"`
+}
+
+// Schedules returns the changes from one definition of a schedule to
+// another, in the order of the fields of storage.Schedule. The ID, pins and
+// inactive members are not part of the definition and are ignored, so
+// identical definitions have no changes. Neither are the members of
+// schedules rotating through their team.
+func Schedules(from, to storage.Schedule) []Change {
+	var changes []Change
+
+	changes = append(changes, changed("name", from.Name, to.Name)...)
+	changes = append(changes, changed("description", from.Description, to.Description)...)
+	changes = append(changes, changed("notes", from.Notes, to.Notes)...)
+	// The members of the team are not part of the definition of schedules
+	// using them, and the members of groups are compared as references
+	if !from.TeamMembers || !to.TeamMembers {
+		changes = append(changes, members(listed(from), listed(to))...)
+	}
+	changes = append(changes, changed("team_members", from.TeamMembers, to.TeamMembers)...)
+	changes = append(changes, entries("days", weekdays(from.Days), weekdays(to.Days))...)
+	changes = append(changes, changed("cron", from.Cron, to.Cron)...)
+	changes = append(changes, changed("rrule", from.RRule, to.RRule)...)
+	changes = append(changes, changed("anchor", instant(from.Anchor), instant(to.Anchor))...)
+	changes = append(changes, changed("start", from.Start.Format(clockLayout), to.Start.Format(clockLayout))...)
+	changes = append(changes, changed("end", from.End.Format(clockLayout), to.End.Format(clockLayout))...)
+	changes = append(changes, changed("timezone", from.Start.Location().String(), to.Start.Location().String())...)
+	changes = append(changes, changed("valid_until", instant(from.ValidUntil), instant(to.ValidUntil))...)
+	changes = append(changes, entries("tags", from.Tags, to.Tags)...)
+	changes = append(changes, mapping("routing", from.Routing, to.Routing, strings.Compare)...)
+	changes = append(changes, changed("rotation_offset", from.RotationOffset, to.RotationOffset)...)
+	changes = append(changes, changed("manual", from.Manual, to.Manual)...)
+	changes = append(changes, changed("split", from.Split, to.Split)...)
+	changes = append(changes, changed("required_count", max(from.RequiredCount, 1), max(to.RequiredCount, 1))...)
+	changes = append(changes, changed("compensation", compensation(from.Compensation), compensation(to.Compensation))...)
+	changes = append(changes, changed("handoff", handoff(from.Handoff), handoff(to.Handoff))...)
+	changes = append(changes, dayAssignments(from.DayAssignments, to.DayAssignments)...)
+
+	return changes
+}
+
+// changed returns the change of a field holding a single value, if any.
+func changed[T comparable](field string, from, to T) []Change {
+	if from == to {
+		return nil
+	}
+
+	return []Change{{
+		Field:   field,
+		Kind:    KindChanged,
+		Old:     from,
+		New:     to,
+		Summary: fmt.Sprintf("%s changed from %s to %s", field, display(from), display(to)),
+	}}
+}
+
+// listed returns the members of a schedule as given, group references
+// included.
+func listed(schedule storage.Schedule) []string {
+	if len(schedule.MemberRefs) > 0 {
+		return schedule.MemberRefs
+	}
+
+	return schedule.Members
+}
+
+// members returns the members added to and removed from the rotation, and
+// whether the members in both are in another order. Members may be listed
+// more than once, so the occurrences beyond those of the other list are the
+// added or removed ones, and the rest are compared in order.
+func members(from, to []string) []Change {
+	removed, keptFrom := surplus(from, to)
+	added, keptTo := surplus(to, from)
+
+	var changes []Change
+	if len(added) > 0 {
+		changes = append(changes, Change{
+			Field:   "members",
+			Kind:    KindAdded,
+			New:     added,
+			Summary: fmt.Sprintf("added %s to members", strings.Join(added, ", ")),
+		})
+	}
+	if len(removed) > 0 {
+		changes = append(changes, Change{
+			Field:   "members",
+			Kind:    KindRemoved,
+			Old:     removed,
+			Summary: fmt.Sprintf("removed %s from members", strings.Join(removed, ", ")),
+		})
+	}
+	if !slices.Equal(keptFrom, keptTo) {
+		changes = append(changes, Change{
+			Field:   "members",
+			Kind:    KindReordered,
+			Old:     keptFrom,
+			New:     keptTo,
+			Summary: fmt.Sprintf("reordered members from %s to %s", strings.Join(keptFrom, ", "), strings.Join(keptTo, ", ")),
+		})
+	}
+
+	return changes
+}
+
+// surplus splits the list into the occurrences beyond those in the other
+// list and the rest, both in the order of the list.
+func surplus(list, other []string) ([]string, []string) {
+	available := make(map[string]int, len(other))
+	for _, entry := range other {
+		available[entry]++
+	}
+
+	var extra, kept []string
+	for _, entry := range list {
+		if available[entry] > 0 {
+			available[entry]--
+			kept = append(kept, entry)
+		} else {
+			extra = append(extra, entry)
+		}
+	}
+
+	return extra, kept
+}
+
+// entries returns the entries added to and removed from a field holding a
+// set, in the order of the list they are in.
+func entries(field string, from, to []string) []Change {
+	var added, removed []string
+	for _, entry := range to {
+		if !slices.Contains(from, entry) && !slices.Contains(added, entry) {
+			added = append(added, entry)
+		}
+	}
+	for _, entry := range from {
+		if !slices.Contains(to, entry) && !slices.Contains(removed, entry) {
+			removed = append(removed, entry)
+		}
+	}
+
+	var changes []Change
+	if len(added) > 0 {
+		changes = append(changes, Change{
+			Field:   field,
+			Kind:    KindAdded,
+			New:     added,
+			Summary: fmt.Sprintf("added %s to %s", strings.Join(added, ", "), field),
+		})
+	}
+	if len(removed) > 0 {
+		changes = append(changes, Change{
+			Field:   field,
+			Kind:    KindRemoved,
+			Old:     removed,
+			Summary: fmt.Sprintf("removed %s from %s", strings.Join(removed, ", "), field),
+		})
+	}
+
+	return changes
+}
+
+// mapping returns the changes of the entries of a field holding a map, as
+// fields of their own named after the key, ordered by key.
+func mapping[K comparable](field string, from, to map[K]string, compare func(a, b K) int) []Change {
+	keys := slices.Collect(maps.Keys(from))
+	for key := range to {
+		if _, ok := from[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortFunc(keys, compare)
+
+	var changes []Change
+	for _, key := range keys {
+		name := fmt.Sprintf("%s.%v", field, key)
+		old, inFrom := from[key]
+		value, inTo := to[key]
+
+		switch {
+		case !inFrom:
+			changes = append(changes, Change{
+				Field: name, Kind: KindAdded, New: value,
+				Summary: fmt.Sprintf("%s set to %s", name, display(value)),
+			})
+		case !inTo:
+			changes = append(changes, Change{
+				Field: name, Kind: KindRemoved, Old: old,
+				Summary: fmt.Sprintf("%s removed, it was %s", name, display(old)),
+			})
+		default:
+			changes = append(changes, changed(name, old, value)...)
+		}
+	}
+
+	return changes
+}
+
+// dayAssignments returns the changes of the members assigned to weekdays.
+func dayAssignments(from, to map[time.Weekday]string) []Change {
+	return mapping("day_assignments", from, to, func(a, b time.Weekday) int { return int(a) - int(b) })
+}
+
+// weekdays returns the names of the days.
+func weekdays(days []time.Weekday) []string {
+	names := make([]string, 0, len(days))
+	for _, day := range days {
+		names = append(names, day.String())
+	}
+
+	return names
+}
+
+// instant renders an instant in RFC3339, empty when it is not set.
+func instant(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// handoff renders the handoff of a schedule, empty when it has none.
+func handoff(h *storage.Handoff) string {
+	if h == nil {
+		return ""
+	}
+
+	return h.Day.String() + " " + h.Time.Format(clockLayout)
+}
+
+// compensation renders the compensation of a schedule, empty when it has
+// none.
+func compensation(c *storage.Compensation) string {
+	if c == nil {
+		return ""
+	}
+
+	rendered := fmt.Sprintf("%s x%s", c.RateClass, strconv.FormatFloat(c.Multiplier, 'f', -1, 64))
+	if c.WeekendRateClass != "" {
+		rendered += fmt.Sprintf(", weekend %s x%s", c.WeekendRateClass, strconv.FormatFloat(c.WeekendMultiplier, 'f', -1, 64))
+	}
+
+	return rendered
+}
+
+// display renders a value in a summary, quoting strings and naming empty
+// ones none.
+func display(value any) string {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return "none"
+		}
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
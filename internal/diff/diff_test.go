@@ -0,0 +1,339 @@
+package diff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func clock(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	at, err := time.Parse(time.Kitchen, value)
+	require.NoError(t, err)
+
+	return at
+}
+
+func weekday(t *testing.T) storage.Schedule {
+	t.Helper()
+
+	return storage.Schedule{
+		ID:      "1",
+		Name:    "Weekday",
+		Members: []string{"Alice", "Bob", "Carol"},
+		Days:    []time.Weekday{time.Monday, time.Tuesday},
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   clock(t, "9:00AM"),
+		End:     clock(t, "5:00PM"),
+		Tags:    []string{"backend"},
+		Routing: map[string]string{"service": "api"},
+	}
+}
+
+func TestSchedules_Identical(t *testing.T) {
+	from := weekday(t)
+	to := weekday(t)
+	// Neither the ID nor the state read along with the definition count
+	to.ID = "2"
+	to.Pins = []storage.Pin{{Date: time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC), Member: "Bob"}}
+	to.Inactive = []string{"Carol"}
+
+	assert.Empty(t, Schedules(from, to))
+	assert.Empty(t, Schedules(storage.Schedule{}, storage.Schedule{}))
+
+	// The team of schedules using its members changing is not a change of theirs
+	from.TeamMembers, from.Members = true, []string{"Alice", "Bob"}
+	to.TeamMembers = true
+	assert.Empty(t, Schedules(from, to))
+}
+
+func TestSchedules_Members(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    []string
+		to      []string
+		changes []Change
+	}{
+		{
+			name: "added",
+			from: []string{"Alice", "Bob"},
+			to:   []string{"Alice", "Bob", "Carol"},
+			changes: []Change{
+				{Field: "members", Kind: KindAdded, New: []string{"Carol"}, Summary: "added Carol to members"},
+			},
+		},
+		{
+			name: "removed",
+			from: []string{"Alice", "Bob", "Carol"},
+			to:   []string{"Alice", "Carol"},
+			changes: []Change{
+				{Field: "members", Kind: KindRemoved, Old: []string{"Bob"}, Summary: "removed Bob from members"},
+			},
+		},
+		{
+			name: "reordered",
+			from: []string{"Alice", "Bob", "Carol"},
+			to:   []string{"Carol", "Alice", "Bob"},
+			changes: []Change{
+				{
+					Field: "members", Kind: KindReordered,
+					Old:     []string{"Alice", "Bob", "Carol"},
+					New:     []string{"Carol", "Alice", "Bob"},
+					Summary: "reordered members from Alice, Bob, Carol to Carol, Alice, Bob",
+				},
+			},
+		},
+		{
+			// Removing a member keeps the others in order
+			name: "removed from the middle",
+			from: []string{"Alice", "Bob", "Carol", "Dave"},
+			to:   []string{"Alice", "Carol", "Dave"},
+			changes: []Change{
+				{Field: "members", Kind: KindRemoved, Old: []string{"Bob"}, Summary: "removed Bob from members"},
+			},
+		},
+		{
+			// Only the members in both versions are compared in order
+			name: "replaced and reordered",
+			from: []string{"Alice", "Bob", "Carol"},
+			to:   []string{"Bob", "Dave", "Alice"},
+			changes: []Change{
+				{Field: "members", Kind: KindAdded, New: []string{"Dave"}, Summary: "added Dave to members"},
+				{Field: "members", Kind: KindRemoved, Old: []string{"Carol"}, Summary: "removed Carol from members"},
+				{
+					Field: "members", Kind: KindReordered,
+					Old:     []string{"Alice", "Bob"},
+					New:     []string{"Bob", "Alice"},
+					Summary: "reordered members from Alice, Bob to Bob, Alice",
+				},
+			},
+		},
+		{
+			name: "repeated member added",
+			from: []string{"Alice", "Bob"},
+			to:   []string{"Alice", "Bob", "Alice"},
+			changes: []Change{
+				{Field: "members", Kind: KindAdded, New: []string{"Alice"}, Summary: "added Alice to members"},
+			},
+		},
+		{
+			// Dropping the first of two turns moves the other one
+			name: "repeated member removed",
+			from: []string{"Alice", "Bob", "Alice"},
+			to:   []string{"Bob", "Alice"},
+			changes: []Change{
+				{Field: "members", Kind: KindRemoved, Old: []string{"Alice"}, Summary: "removed Alice from members"},
+				{
+					Field: "members", Kind: KindReordered,
+					Old:     []string{"Alice", "Bob"},
+					New:     []string{"Bob", "Alice"},
+					Summary: "reordered members from Alice, Bob to Bob, Alice",
+				},
+			},
+		},
+		{
+			name: "swapped pair",
+			from: []string{"Alice", "Bob"},
+			to:   []string{"Bob", "Alice"},
+			changes: []Change{
+				{
+					Field: "members", Kind: KindReordered,
+					Old:     []string{"Alice", "Bob"},
+					New:     []string{"Bob", "Alice"},
+					Summary: "reordered members from Alice, Bob to Bob, Alice",
+				},
+			},
+		},
+		{
+			name: "unchanged",
+			from: []string{"Alice", "Bob", "Alice"},
+			to:   []string{"Alice", "Bob", "Alice"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := weekday(t)
+			from.Members = tt.from
+			to := weekday(t)
+			to.Members = tt.to
+
+			assert.Equal(t, tt.changes, Schedules(from, to))
+		})
+	}
+}
+
+func TestSchedules_Fields(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(*storage.Schedule)
+		changes []Change
+	}{
+		{
+			name:   "name",
+			change: func(s *storage.Schedule) { s.Name = "Weekdays" },
+			changes: []Change{
+				{Field: "name", Kind: KindChanged, Old: "Weekday", New: "Weekdays", Summary: `name changed from "Weekday" to "Weekdays"`},
+			},
+		},
+		{
+			name:   "description set",
+			change: func(s *storage.Schedule) { s.Description = "Primary" },
+			changes: []Change{
+				{Field: "description", Kind: KindChanged, Old: "", New: "Primary", Summary: `description changed from none to "Primary"`},
+			},
+		},
+		{
+			name:   "days",
+			change: func(s *storage.Schedule) { s.Days = []time.Weekday{time.Tuesday, time.Wednesday, time.Thursday} },
+			changes: []Change{
+				{Field: "days", Kind: KindAdded, New: []string{"Wednesday", "Thursday"}, Summary: "added Wednesday, Thursday to days"},
+				{Field: "days", Kind: KindRemoved, Old: []string{"Monday"}, Summary: "removed Monday from days"},
+			},
+		},
+		{
+			name:    "days reordered",
+			change:  func(s *storage.Schedule) { s.Days = []time.Weekday{time.Tuesday, time.Monday} },
+			changes: nil,
+		},
+		{
+			name: "times",
+			change: func(s *storage.Schedule) {
+				s.Start = clock(t, "10:00AM")
+				s.End = clock(t, "6:30PM")
+			},
+			changes: []Change{
+				{Field: "start", Kind: KindChanged, Old: "09:00", New: "10:00", Summary: `start changed from "09:00" to "10:00"`},
+				{Field: "end", Kind: KindChanged, Old: "17:00", New: "18:30", Summary: `end changed from "17:00" to "18:30"`},
+			},
+		},
+		{
+			name: "timezone",
+			change: func(s *storage.Schedule) {
+				tehran := time.FixedZone("Asia/Tehran", 7*30*60)
+				s.Start = time.Date(0, 1, 1, 9, 0, 0, 0, tehran)
+				s.End = time.Date(0, 1, 1, 17, 0, 0, 0, tehran)
+			},
+			changes: []Change{
+				{Field: "timezone", Kind: KindChanged, Old: "UTC", New: "Asia/Tehran", Summary: `timezone changed from "UTC" to "Asia/Tehran"`},
+			},
+		},
+		{
+			name:   "valid until",
+			change: func(s *storage.Schedule) { s.ValidUntil = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) },
+			changes: []Change{
+				{Field: "valid_until", Kind: KindChanged, Old: "", New: "2025-06-01T00:00:00Z", Summary: `valid_until changed from none to "2025-06-01T00:00:00Z"`},
+			},
+		},
+		{
+			name: "rotation",
+			change: func(s *storage.Schedule) {
+				s.Manual = true
+				s.RotationOffset = 2
+			},
+			changes: []Change{
+				{Field: "rotation_offset", Kind: KindChanged, Old: 0, New: 2, Summary: "rotation_offset changed from 0 to 2"},
+				{Field: "manual", Kind: KindChanged, Old: false, New: true, Summary: "manual changed from false to true"},
+			},
+		},
+		{
+			name:   "handoff",
+			change: func(s *storage.Schedule) { s.Handoff = &storage.Handoff{Day: time.Monday, Time: clock(t, "9:00AM")} },
+			changes: []Change{
+				{Field: "handoff", Kind: KindChanged, Old: "", New: "Monday 09:00", Summary: `handoff changed from none to "Monday 09:00"`},
+			},
+		},
+		{
+			name: "compensation",
+			change: func(s *storage.Schedule) {
+				s.Compensation = &storage.Compensation{RateClass: "night", Multiplier: 1.5, WeekendRateClass: "weekend", WeekendMultiplier: 2}
+			},
+			changes: []Change{
+				{
+					Field: "compensation", Kind: KindChanged, Old: "", New: "night x1.5, weekend weekend x2",
+					Summary: `compensation changed from none to "night x1.5, weekend weekend x2"`,
+				},
+			},
+		},
+		{
+			name:   "tags",
+			change: func(s *storage.Schedule) { s.Tags = []string{"primary"} },
+			changes: []Change{
+				{Field: "tags", Kind: KindAdded, New: []string{"primary"}, Summary: "added primary to tags"},
+				{Field: "tags", Kind: KindRemoved, Old: []string{"backend"}, Summary: "removed backend from tags"},
+			},
+		},
+		{
+			name:   "routing",
+			change: func(s *storage.Schedule) { s.Routing = map[string]string{"service": "gateway", "tier": "1"} },
+			changes: []Change{
+				{Field: "routing.service", Kind: KindChanged, Old: "api", New: "gateway", Summary: `routing.service changed from "api" to "gateway"`},
+				{Field: "routing.tier", Kind: KindAdded, New: "1", Summary: `routing.tier set to "1"`},
+			},
+		},
+		{
+			name:   "routing removed",
+			change: func(s *storage.Schedule) { s.Routing = nil },
+			changes: []Change{
+				{Field: "routing.service", Kind: KindRemoved, Old: "api", Summary: `routing.service removed, it was "api"`},
+			},
+		},
+		{
+			name: "day assignments",
+			change: func(s *storage.Schedule) {
+				s.DayAssignments = map[time.Weekday]string{time.Tuesday: "Bob", time.Monday: "Alice"}
+			},
+			changes: []Change{
+				{Field: "day_assignments.Monday", Kind: KindAdded, New: "Alice", Summary: `day_assignments.Monday set to "Alice"`},
+				{Field: "day_assignments.Tuesday", Kind: KindAdded, New: "Bob", Summary: `day_assignments.Tuesday set to "Bob"`},
+			},
+		},
+		{
+			name: "team members",
+			change: func(s *storage.Schedule) {
+				s.Members = nil
+				s.TeamMembers = true
+			},
+			changes: []Change{
+				{Field: "members", Kind: KindRemoved, Old: []string{"Alice", "Bob", "Carol"}, Summary: "removed Alice, Bob, Carol from members"},
+				{Field: "team_members", Kind: KindChanged, Old: false, New: true, Summary: "team_members changed from false to true"},
+			},
+		},
+		{
+			name:   "rrule",
+			change: func(s *storage.Schedule) { s.RRule = "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO" },
+			changes: []Change{
+				{Field: "rrule", Kind: KindChanged, Old: "", New: "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO", Summary: `rrule changed from none to "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO"`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from := weekday(t)
+			to := weekday(t)
+			tt.change(&to)
+
+			assert.Equal(t, tt.changes, Schedules(from, to))
+		})
+	}
+}
+
+func TestSchedules_Order(t *testing.T) {
+	from := weekday(t)
+	to := weekday(t)
+	to.Name = "Weekdays"
+	to.Members = []string{"Bob", "Alice", "Carol"}
+	to.End = clock(t, "6:00PM")
+
+	// Changes follow the fields of the schedule
+	var fields []string
+	for _, change := range Schedules(from, to) {
+		fields = append(fields, change.Field)
+	}
+	assert.Equal(t, []string{"name", "members", "end"}, fields)
+}
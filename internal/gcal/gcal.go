@@ -0,0 +1,421 @@
+// Package gcal keeps the shared Google calendars of teams in sync with their
+// schedules, with an event for every upcoming shift.
+package gcal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// PropertyTeam is the private extended property tagging the events pushed
+// for a team, only the events carrying it are ever changed or deleted.
+const PropertyTeam = "oncall-schedule-team"
+
+// Sync states of a team.
+const (
+	// StatePending is a team whose calendar was not synced yet.
+	StatePending = "pending"
+	// StateSynced is a team whose last sync succeeded.
+	StateSynced = "synced"
+	// StateFailed is a team whose last sync failed, it is retried on the
+	// next check.
+	StateFailed = "failed"
+)
+
+var syncs = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "oncall_google_calendar_syncs_total",
+	Help: "Number of Google calendar syncs of the teams by result.",
+}, []string{"result"})
+
+// eventIDs encodes event IDs with the characters Google allows in them,
+// lowercase letters a to v and digits.
+var eventIDs = base32.HexEncoding.WithPadding(base32.NoPadding)
+
+// Event is an event of a calendar, in UTC.
+type Event struct {
+	ID          string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+}
+
+// equal reports whether the events show the same.
+func (e Event) equal(other Event) bool {
+	return e.Summary == other.Summary && e.Description == other.Description &&
+		e.Start.Equal(other.Start) && e.End.Equal(other.End)
+}
+
+// Client changes the events of Google calendars. Every event it inserts or
+// updates is tagged with PropertyTeam, and List only returns the tagged ones.
+type Client interface {
+	// List returns the events tagged with the team ending after from.
+	List(ctx context.Context, calendarID, team string, from time.Time) ([]Event, error)
+	// Insert adds an event with its ID to the calendar, tagged with the team.
+	Insert(ctx context.Context, calendarID, team string, event Event) error
+	// Update replaces the event with its ID, tagged with the team.
+	Update(ctx context.Context, calendarID, team string, event Event) error
+	// Delete removes the event, an event already gone is not an error.
+	Delete(ctx context.Context, calendarID, eventID string) error
+}
+
+// Plan is what a sync changes in a calendar.
+type Plan struct {
+	Insert []Event
+	Update []Event
+	Delete []string
+}
+
+// plan returns the changes making the existing events the wanted ones, in
+// the order of the wanted events and of the existing ones for deletions.
+func plan(wanted, existing []Event) Plan {
+	current := make(map[string]Event, len(existing))
+	for _, event := range existing {
+		current[event.ID] = event
+	}
+
+	var p Plan
+	keep := make(map[string]bool, len(wanted))
+	for _, event := range wanted {
+		keep[event.ID] = true
+
+		have, ok := current[event.ID]
+		switch {
+		case !ok:
+			p.Insert = append(p.Insert, event)
+		case !have.equal(event):
+			p.Update = append(p.Update, event)
+		}
+	}
+
+	for _, event := range existing {
+		if !keep[event.ID] {
+			p.Delete = append(p.Delete, event.ID)
+		}
+	}
+
+	return p
+}
+
+// Status is the outcome of the last sync of a team. The counts are those of
+// the last successful one.
+type Status struct {
+	Team        string
+	CalendarID  string
+	State       string
+	LastAttempt time.Time
+	LastSuccess time.Time
+	Events      int
+	Inserted    int
+	Updated     int
+	Deleted     int
+	Error       string
+}
+
+// synced is the last successful sync of a team, the fingerprint being the
+// calendar and events it pushed.
+type synced struct {
+	fingerprint string
+	at          time.Time
+}
+
+// Syncer pushes the upcoming shifts of every team with a Google calendar to
+// it, when they change and every reconcile interval to undo edits made in
+// the calendar. It keeps the status of every team, a failing team does not
+// stop the others and leaves the API untouched.
+type Syncer struct {
+	storage   storage.Storage
+	client    Client
+	logger    *zap.Logger
+	now       func() time.Time
+	horizon   time.Duration
+	reconcile time.Duration
+
+	mu     sync.Mutex
+	status map[string]Status
+	synced map[string]synced
+}
+
+// New creates a syncer, with a Google client authenticated with the service
+// account of the configuration when the sync is enabled.
+func New(s storage.Storage, cfg *config.Config, logger *zap.Logger) (*Syncer, error) {
+	var client Client
+	if cfg.GoogleCalendar.Enabled {
+		var err error
+		client, err = NewGoogleClient(cfg.GoogleCalendar.Credentials)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create google calendar client: %w", err)
+		}
+	}
+
+	return NewSyncer(s, client, cfg.GoogleCalendar, logger), nil
+}
+
+// NewSyncer creates a syncer pushing to the calendars through the client, a
+// nil client disables it.
+func NewSyncer(s storage.Storage, client Client, cfg config.GoogleCalendarConfig, logger *zap.Logger) *Syncer {
+	return &Syncer{
+		storage:   s,
+		client:    client,
+		logger:    logger.Named("gcal"),
+		now:       time.Now,
+		horizon:   time.Duration(cfg.Weeks) * 7 * 24 * time.Hour,
+		reconcile: cfg.Reconcile,
+		status:    make(map[string]Status),
+		synced:    make(map[string]synced),
+	}
+}
+
+// Enabled reports whether the shifts are pushed to the calendars.
+func (s *Syncer) Enabled() bool {
+	return s != nil && s.client != nil
+}
+
+// Status returns the status of the sync of a team, false when it was not
+// synced yet.
+func (s *Syncer) Status(team string) (Status, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status, ok := s.status[team]
+	return status, ok
+}
+
+// Run syncs the calendar of every team whose shifts changed since its last
+// successful sync, or whose last one is older than the reconcile interval.
+// A failing team does not stop the others, their errors are joined.
+func (s *Syncer) Run(ctx context.Context) error {
+	calendars, err := s.storage.ListGoogleCalendars(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list google calendars: %w", err)
+	}
+
+	// The calendar of a deleted team goes along with it
+	s.mu.Lock()
+	gone := make(map[string]string)
+	for team, status := range s.status {
+		if _, ok := calendars[team]; !ok {
+			gone[team] = status.CalendarID
+		}
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	for _, team := range slices.Sorted(maps.Keys(gone)) {
+		if err := s.clear(ctx, team, gone[team]); err != nil {
+			errs = append(errs, fmt.Errorf("team %s: %w", team, err))
+			continue
+		}
+
+		s.mu.Lock()
+		delete(s.status, team)
+		delete(s.synced, team)
+		s.mu.Unlock()
+	}
+
+	for _, team := range slices.Sorted(maps.Keys(calendars)) {
+		if err := s.sync(ctx, team, calendars[team], false); err != nil {
+			errs = append(errs, fmt.Errorf("team %s: %w", team, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Team syncs the calendar of a team now, whether its shifts changed or not.
+func (s *Syncer) Team(ctx context.Context, team, calendarID string) error {
+	return s.sync(ctx, team, calendarID, true)
+}
+
+// Loop runs the syncer every interval until ctx is done.
+func (s *Syncer) Loop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Run(ctx); err != nil {
+				s.logger.Error("google calendar sync failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// sync pushes the upcoming shifts of the team to its calendar, unless they
+// did not change since the last successful sync and it is not due for a
+// reconcile, or force is set.
+func (s *Syncer) sync(ctx context.Context, team, calendarID string, force bool) error {
+	now := s.now()
+
+	events, err := s.events(ctx, team, now)
+	if err != nil {
+		return s.fail(team, calendarID, now, err)
+	}
+	fingerprint := fingerprintOf(calendarID, events)
+
+	s.mu.Lock()
+	last, ok := s.synced[team]
+	s.mu.Unlock()
+	if !force && ok && last.fingerprint == fingerprint && now.Sub(last.at) < s.reconcile {
+		return nil
+	}
+
+	existing, err := s.client.List(ctx, calendarID, team, now)
+	if err != nil {
+		return s.fail(team, calendarID, now, fmt.Errorf("failed to list events: %w", err))
+	}
+
+	p := plan(events, existing)
+	for _, event := range p.Insert {
+		if err := s.client.Insert(ctx, calendarID, team, event); err != nil {
+			return s.fail(team, calendarID, now, fmt.Errorf("failed to insert event %s: %w", event.ID, err))
+		}
+	}
+	for _, event := range p.Update {
+		if err := s.client.Update(ctx, calendarID, team, event); err != nil {
+			return s.fail(team, calendarID, now, fmt.Errorf("failed to update event %s: %w", event.ID, err))
+		}
+	}
+	for _, id := range p.Delete {
+		if err := s.client.Delete(ctx, calendarID, id); err != nil {
+			return s.fail(team, calendarID, now, fmt.Errorf("failed to delete event %s: %w", id, err))
+		}
+	}
+
+	s.mu.Lock()
+	s.synced[team] = synced{fingerprint: fingerprint, at: now}
+	s.status[team] = Status{
+		Team:        team,
+		CalendarID:  calendarID,
+		State:       StateSynced,
+		LastAttempt: now,
+		LastSuccess: now,
+		Events:      len(events),
+		Inserted:    len(p.Insert),
+		Updated:     len(p.Update),
+		Deleted:     len(p.Delete),
+	}
+	s.mu.Unlock()
+
+	syncs.WithLabelValues(StateSynced).Inc()
+	if len(p.Insert)+len(p.Update)+len(p.Delete) > 0 {
+		s.logger.Info("google calendar synced",
+			zap.String("team", team),
+			zap.Int("inserted", len(p.Insert)),
+			zap.Int("updated", len(p.Update)),
+			zap.Int("deleted", len(p.Delete)),
+		)
+	}
+
+	return nil
+}
+
+// clear takes the upcoming shifts of a deleted team out of the calendar it
+// was synced to. The shifts of teams whose calendar was only unset are left
+// in it.
+func (s *Syncer) clear(ctx context.Context, team, calendarID string) error {
+	_, err := s.storage.GetTeam(ctx, team)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, storage.ErrTeamNotFound) {
+		return fmt.Errorf("failed to get team: %w", err)
+	}
+
+	existing, err := s.client.List(ctx, calendarID, team, s.now())
+	if err != nil {
+		return fmt.Errorf("failed to list events: %w", err)
+	}
+	for _, id := range plan(nil, existing).Delete {
+		if err := s.client.Delete(ctx, calendarID, id); err != nil {
+			return fmt.Errorf("failed to delete event %s: %w", id, err)
+		}
+	}
+
+	s.logger.Info("google calendar of deleted team cleared", zap.String("team", team))
+
+	return nil
+}
+
+// fail records a failed sync of the team, keeping the counts of the last
+// successful one, and returns err. The team is synced again on the next run.
+func (s *Syncer) fail(team, calendarID string, now time.Time, err error) error {
+	s.mu.Lock()
+	status, ok := s.status[team]
+	if !ok || status.CalendarID != calendarID {
+		status = Status{Team: team, CalendarID: calendarID}
+	}
+	status.State = StateFailed
+	status.LastAttempt = now
+	status.Error = err.Error()
+	s.status[team] = status
+	delete(s.synced, team)
+	s.mu.Unlock()
+
+	syncs.WithLabelValues(StateFailed).Inc()
+
+	return err
+}
+
+// events returns an event for every duty of the team from now to the
+// horizon, ordered by start. Pins and day assignments are respected, like
+// the on-call lookup does.
+func (s *Syncer) events(ctx context.Context, team string, now time.Time) ([]Event, error) {
+	// A deleted team has no duties left, its events are all deleted
+	t, err := s.storage.GetTeam(ctx, team)
+	if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	duties := storage.Duties(t.Schedules, now, now.Add(s.horizon))
+
+	events := make([]Event, 0, len(duties))
+	for _, duty := range duties {
+		events = append(events, Event{
+			ID:          eventID(team, duty.ScheduleID, duty.Start),
+			Summary:     "On call: " + duty.Member,
+			Description: fmt.Sprintf("%s shift of %s", duty.Schedule, team),
+			Start:       duty.Start.UTC(),
+			End:         duty.End.UTC(),
+		})
+	}
+
+	return events, nil
+}
+
+// eventID returns the ID of the event of the duty of the schedule starting at
+// start, which stays the same when its member changes.
+func eventID(team, scheduleID string, start time.Time) string {
+	digest := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%d", team, scheduleID, start.Unix()))
+
+	return strings.ToLower(eventIDs.EncodeToString(digest[:20]))
+}
+
+// fingerprintOf returns a digest of the calendar and the events pushed to it.
+func fingerprintOf(calendarID string, events []Event) string {
+	digest := sha256.New()
+	fmt.Fprintf(digest, "%s\n", calendarID)
+	for _, event := range events {
+		fmt.Fprintf(digest, "%s\x00%s\x00%s\x00%d\x00%d\n",
+			event.ID, event.Summary, event.Description, event.Start.Unix(), event.End.Unix())
+	}
+
+	return string(digest.Sum(nil))
+}
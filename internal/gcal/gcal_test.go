@@ -0,0 +1,244 @@
+package gcal
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const calendarID = "payments@group.calendar.google.com"
+
+// fakeClient keeps the events of the calendars in memory and records the
+// calls changing them.
+type fakeClient struct {
+	events map[string][]Event
+	calls  []string
+	err    error
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{events: make(map[string][]Event)}
+}
+
+func (f *fakeClient) List(_ context.Context, calendarID, team string, from time.Time) ([]Event, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var events []Event
+	for _, event := range f.events[calendarID+"/"+team] {
+		if event.End.After(from) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func (f *fakeClient) Insert(_ context.Context, calendarID, team string, event Event) error {
+	f.calls = append(f.calls, "insert "+event.Summary+" "+event.Start.Format(time.RFC3339))
+	f.events[calendarID+"/"+team] = append(f.events[calendarID+"/"+team], event)
+
+	return nil
+}
+
+func (f *fakeClient) Update(_ context.Context, calendarID, team string, event Event) error {
+	f.calls = append(f.calls, "update "+event.Summary+" "+event.Start.Format(time.RFC3339))
+	events := f.events[calendarID+"/"+team]
+	for i := range events {
+		if events[i].ID == event.ID {
+			events[i] = event
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeClient) Delete(_ context.Context, calendarID, eventID string) error {
+	f.calls = append(f.calls, "delete "+eventID)
+	for key, events := range f.events {
+		f.events[key] = slices.DeleteFunc(events, func(event Event) bool { return event.ID == eventID })
+	}
+
+	return nil
+}
+
+// newTestSyncer returns a syncer pushing two weeks of the payments team on
+// Monday March 2, 2026 at 06:00 UTC, whose Days schedule rotates Alice and
+// Bob weekly from that day, on weekdays from 9:00AM to 5:00PM.
+func newTestSyncer(t *testing.T) (*Syncer, *fakeClient, storage.Storage, *time.Time) {
+	t.Helper()
+
+	ctx := context.Background()
+	store := storage.NewMemoryStorage()
+
+	start, err := time.Parse(time.Kitchen, "9:00AM")
+	require.NoError(t, err)
+	end, err := time.Parse(time.Kitchen, "5:00PM")
+	require.NoError(t, err)
+
+	_, err = store.AddSchedule(ctx, "payments", storage.Schedule{
+		Name:    "Days",
+		Members: []string{"Alice", "Bob"},
+		Days:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Start:   start,
+		End:     end,
+		Anchor:  time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.SetGoogleCalendar(ctx, "payments", calendarID))
+
+	client := newFakeClient()
+	now := time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC)
+
+	s := NewSyncer(store, client, config.GoogleCalendarConfig{Weeks: 2, Reconcile: time.Hour}, zap.NewNop())
+	s.now = func() time.Time { return now }
+
+	return s, client, store, &now
+}
+
+func TestPlan(t *testing.T) {
+	at := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	event := func(id, summary string) Event {
+		return Event{ID: id, Summary: summary, Start: at, End: at.Add(8 * time.Hour)}
+	}
+
+	p := plan(
+		[]Event{event("a", "On call: Alice"), event("b", "On call: Bob"), event("c", "On call: Carol")},
+		[]Event{event("d", "On call: Dave"), event("b", "On call: Alice"), event("a", "On call: Alice")},
+	)
+
+	assert.Equal(t, Plan{
+		Insert: []Event{event("c", "On call: Carol")},
+		Update: []Event{event("b", "On call: Bob")},
+		Delete: []string{"d"},
+	}, p)
+}
+
+func TestSyncer_PushesShifts(t *testing.T) {
+	s, client, _, _ := newTestSyncer(t)
+
+	require.NoError(t, s.Run(context.Background()))
+
+	// Ten weekdays of the two weeks ahead, the rotation handing over weekly
+	require.Len(t, client.calls, 10)
+	assert.Equal(t, "insert On call: Alice 2026-03-02T09:00:00Z", client.calls[0])
+	assert.Equal(t, "insert On call: Bob 2026-03-09T09:00:00Z", client.calls[5])
+
+	events := client.events[calendarID+"/payments"]
+	assert.Equal(t, "Days shift of payments", events[0].Description)
+	assert.Equal(t, time.Date(2026, 3, 2, 17, 0, 0, 0, time.UTC), events[0].End)
+	assert.Regexp(t, `^[0-9a-v]{32}$`, events[0].ID)
+
+	status, ok := s.Status("payments")
+	require.True(t, ok)
+	assert.Equal(t, StateSynced, status.State)
+	assert.Equal(t, 10, status.Events)
+	assert.Equal(t, 10, status.Inserted)
+
+	// Nothing changed, so the calendar is not even listed again
+	client.err = errors.New("unreachable")
+	require.NoError(t, s.Run(context.Background()))
+	assert.Len(t, client.calls, 10)
+}
+
+func TestSyncer_FollowsChanges(t *testing.T) {
+	s, client, store, now := newTestSyncer(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.Run(ctx))
+	events := client.events[calendarID+"/payments"]
+
+	// Bob takes the first Tuesday over, and an event is added by hand to
+	// the calendar with the tag
+	team, err := store.GetTeam(ctx, "payments")
+	require.NoError(t, err)
+	_, _, err = store.AddPin(ctx, "payments", team.Schedules[0].ID, storage.Pin{
+		Date:   time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC),
+		Member: "Bob",
+	})
+	require.NoError(t, err)
+	stray := Event{ID: "stray", Summary: "On call: Mallory", Start: *now, End: now.Add(time.Hour)}
+	client.events[calendarID+"/payments"] = append(client.events[calendarID+"/payments"], stray)
+
+	client.calls = nil
+	require.NoError(t, s.Run(ctx))
+	assert.Equal(t, []string{"update On call: Bob 2026-03-03T09:00:00Z", "delete stray"}, client.calls)
+	assert.Equal(t, events[1].ID, client.events[calendarID+"/payments"][1].ID)
+
+	// A week later, the shifts of the past week are left alone and the
+	// ones of the week after next are added
+	*now = now.AddDate(0, 0, 7)
+	client.calls = nil
+	require.NoError(t, s.Run(ctx))
+	assert.Equal(t, []string{
+		"insert On call: Alice 2026-03-16T09:00:00Z",
+		"insert On call: Alice 2026-03-17T09:00:00Z",
+		"insert On call: Alice 2026-03-18T09:00:00Z",
+		"insert On call: Alice 2026-03-19T09:00:00Z",
+		"insert On call: Alice 2026-03-20T09:00:00Z",
+	}, client.calls)
+
+	// Deleting the team deletes its upcoming shifts
+	require.NoError(t, store.DeleteTeam(ctx, "payments"))
+	client.calls = nil
+	require.NoError(t, s.Run(ctx))
+	assert.Len(t, client.calls, 10)
+	assert.Empty(t, client.events[calendarID+"/payments"][5:])
+}
+
+func TestSyncer_Reconciles(t *testing.T) {
+	s, client, _, now := newTestSyncer(t)
+	ctx := context.Background()
+
+	require.NoError(t, s.Run(ctx))
+
+	// An event deleted by hand comes back on the next reconcile only
+	deleted := client.events[calendarID+"/payments"][2]
+	client.events[calendarID+"/payments"] = slices.Delete(client.events[calendarID+"/payments"], 2, 3)
+
+	client.calls = nil
+	*now = now.Add(30 * time.Minute)
+	require.NoError(t, s.Run(ctx))
+	assert.Empty(t, client.calls)
+
+	*now = now.Add(time.Hour)
+	require.NoError(t, s.Run(ctx))
+	assert.Equal(t, []string{"insert " + deleted.Summary + " " + deleted.Start.Format(time.RFC3339)}, client.calls)
+}
+
+func TestSyncer_Failures(t *testing.T) {
+	s, client, store, _ := newTestSyncer(t)
+	ctx := context.Background()
+
+	client.err = errors.New("google calendar answered 403: forbidden")
+	require.Error(t, s.Run(ctx))
+
+	status, ok := s.Status("payments")
+	require.True(t, ok)
+	assert.Equal(t, StateFailed, status.State)
+	assert.Contains(t, status.Error, "403")
+	assert.True(t, status.LastSuccess.IsZero())
+
+	// The failed team is retried on the next run
+	client.err = nil
+	require.NoError(t, s.Run(ctx))
+	status, _ = s.Status("payments")
+	assert.Equal(t, StateSynced, status.State)
+	assert.Empty(t, status.Error)
+	assert.Equal(t, 10, status.Inserted)
+
+	// Teams whose calendar is cleared are forgotten
+	require.NoError(t, store.SetGoogleCalendar(ctx, "payments", ""))
+	require.NoError(t, s.Run(ctx))
+	_, ok = s.Status("payments")
+	assert.False(t, ok)
+}
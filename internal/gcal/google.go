@@ -0,0 +1,289 @@
+package gcal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2/jwt"
+)
+
+// Google Calendar API defaults.
+const (
+	// DefaultEndpoint is the base URL of the Google Calendar API.
+	DefaultEndpoint = "https://www.googleapis.com/calendar/v3"
+	// DefaultTokenURL is where service accounts get their access tokens when
+	// their key does not say.
+	DefaultTokenURL = "https://oauth2.googleapis.com/token"
+	// Scope grants changing the events of the calendars shared with the
+	// service account.
+	Scope = "https://www.googleapis.com/auth/calendar.events"
+)
+
+// requestTimeout bounds every call to the Google Calendar API.
+const requestTimeout = 30 * time.Second
+
+// maxResponseBody bounds how much of a response is read.
+const maxResponseBody = 8 << 20
+
+// HTTPClient is the part of *http.Client the Google client uses, so tests
+// can replace it.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// serviceAccount is the part of the JSON key of a service account used to
+// sign in.
+type serviceAccount struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// GoogleClient changes the events of Google calendars through the Google
+// Calendar API.
+type GoogleClient struct {
+	client   HTTPClient
+	endpoint string
+}
+
+// NewGoogleClient creates a client signed in as the service account whose
+// JSON key is at path. The calendars have to be shared with the account.
+func NewGoogleClient(path string) (*GoogleClient, error) {
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account key: %w", err)
+	}
+
+	var account serviceAccount
+	if err := json.Unmarshal(key, &account); err != nil {
+		return nil, fmt.Errorf("failed to parse service account key: %w", err)
+	}
+	if account.ClientEmail == "" || account.PrivateKey == "" {
+		return nil, errors.New("service account key has no client_email or private_key")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = DefaultTokenURL
+	}
+
+	cfg := &jwt.Config{
+		Email:        account.ClientEmail,
+		PrivateKey:   []byte(account.PrivateKey),
+		PrivateKeyID: account.PrivateKeyID,
+		Scopes:       []string{Scope},
+		TokenURL:     account.TokenURI,
+	}
+	client := cfg.Client(context.Background())
+	client.Timeout = requestTimeout
+
+	return NewGoogleClientWith(client, DefaultEndpoint), nil
+}
+
+// NewGoogleClientWith creates a client sending its calls through client to
+// the API at endpoint, which signs them in already.
+func NewGoogleClientWith(client HTTPClient, endpoint string) *GoogleClient {
+	return &GoogleClient{client: client, endpoint: endpoint}
+}
+
+// googleTime is a start or end of a Google Calendar event.
+type googleTime struct {
+	DateTime string `json:"dateTime,omitempty"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// googleEvent is a Google Calendar event.
+type googleEvent struct {
+	ID                 string     `json:"id"`
+	Status             string     `json:"status,omitempty"`
+	Summary            string     `json:"summary"`
+	Description        string     `json:"description"`
+	Start              googleTime `json:"start"`
+	End                googleTime `json:"end"`
+	ExtendedProperties struct {
+		Private map[string]string `json:"private,omitempty"`
+	} `json:"extendedProperties"`
+}
+
+// googleEvents is a page of a listing of events.
+type googleEvents struct {
+	Items         []googleEvent `json:"items"`
+	NextPageToken string        `json:"nextPageToken"`
+}
+
+// List returns the events tagged with the team ending after from, following
+// every page of the listing.
+func (g *GoogleClient) List(ctx context.Context, calendarID, team string, from time.Time) ([]Event, error) {
+	query := url.Values{}
+	query.Set("privateExtendedProperty", PropertyTeam+"="+team)
+	query.Set("timeMin", from.UTC().Format(time.RFC3339))
+	query.Set("singleEvents", "true")
+	query.Set("maxResults", "2500")
+
+	var events []Event
+	for {
+		var page googleEvents
+		if err := g.call(ctx, http.MethodGet, g.eventsURL(calendarID, "")+"?"+query.Encode(), nil, &page); err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			event, err := item.event()
+			if err != nil {
+				return nil, fmt.Errorf("event %s: %w", item.ID, err)
+			}
+			events = append(events, event)
+		}
+
+		if page.NextPageToken == "" {
+			return events, nil
+		}
+		query.Set("pageToken", page.NextPageToken)
+	}
+}
+
+// Insert adds the event to the calendar. Google keeps the IDs of deleted
+// events, so an event inserted again is restored by updating it.
+func (g *GoogleClient) Insert(ctx context.Context, calendarID, team string, event Event) error {
+	err := g.call(ctx, http.MethodPost, g.eventsURL(calendarID, ""), newGoogleEvent(team, event), nil)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusConflict {
+		return g.Update(ctx, calendarID, team, event)
+	}
+
+	return err
+}
+
+// Update replaces the event, restoring it when it was deleted.
+func (g *GoogleClient) Update(ctx context.Context, calendarID, team string, event Event) error {
+	return g.call(ctx, http.MethodPut, g.eventsURL(calendarID, event.ID), newGoogleEvent(team, event), nil)
+}
+
+// Delete removes the event, an event already gone is not an error.
+func (g *GoogleClient) Delete(ctx context.Context, calendarID, eventID string) error {
+	err := g.call(ctx, http.MethodDelete, g.eventsURL(calendarID, eventID), nil, nil)
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.Status == http.StatusNotFound || apiErr.Status == http.StatusGone) {
+		return nil
+	}
+
+	return err
+}
+
+// APIError is a call the Google Calendar API answered with an error status.
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("google calendar answered %d: %s", e.Status, e.Message)
+}
+
+// eventsURL returns the URL of the events of the calendar, or of one of them.
+func (g *GoogleClient) eventsURL(calendarID, eventID string) string {
+	target := g.endpoint + "/calendars/" + url.PathEscape(calendarID) + "/events"
+	if eventID != "" {
+		target += "/" + url.PathEscape(eventID)
+	}
+
+	return target
+}
+
+// call sends a request with body encoded as JSON, decoding the response into
+// out unless it is nil.
+func (g *GoogleClient) call(ctx context.Context, method, target string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		var failure struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		message := http.StatusText(resp.StatusCode)
+		if json.Unmarshal(content, &failure) == nil && failure.Error.Message != "" {
+			message = failure.Error.Message
+		}
+
+		return &APIError{Status: resp.StatusCode, Message: message}
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(content, out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// newGoogleEvent returns the Google Calendar event of an event of the team.
+func newGoogleEvent(team string, event Event) googleEvent {
+	g := googleEvent{
+		ID:          event.ID,
+		Status:      "confirmed",
+		Summary:     event.Summary,
+		Description: event.Description,
+		Start:       googleTime{DateTime: event.Start.UTC().Format(time.RFC3339), TimeZone: "UTC"},
+		End:         googleTime{DateTime: event.End.UTC().Format(time.RFC3339), TimeZone: "UTC"},
+	}
+	g.ExtendedProperties.Private = map[string]string{PropertyTeam: team}
+
+	return g
+}
+
+// event returns the event of a listed Google Calendar event.
+func (g googleEvent) event() (Event, error) {
+	start, err := time.Parse(time.RFC3339, g.Start.DateTime)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, g.End.DateTime)
+	if err != nil {
+		return Event{}, fmt.Errorf("invalid end: %w", err)
+	}
+
+	return Event{
+		ID:          g.ID,
+		Summary:     g.Summary,
+		Description: g.Description,
+		Start:       start.UTC(),
+		End:         end.UTC(),
+	}, nil
+}
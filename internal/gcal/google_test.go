@@ -0,0 +1,143 @@
+package gcal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordedCall is a call the fake Google Calendar API received.
+type recordedCall struct {
+	Method string
+	Path   string
+	Query  string
+	Body   map[string]any
+}
+
+// newFakeGoogle returns a client talking to a fake Google Calendar API
+// answering with the status of the path, 200 when it has none, and the
+// calls it received.
+func newFakeGoogle(t *testing.T, statuses map[string]int, pages map[string]string) (*GoogleClient, *[]recordedCall) {
+	t.Helper()
+
+	var calls []recordedCall
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := recordedCall{Method: r.Method, Path: r.URL.EscapedPath(), Query: r.URL.RawQuery}
+		if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+			require.NoError(t, json.Unmarshal(body, &call.Body))
+		}
+		calls = append(calls, call)
+
+		if status, ok := statuses[r.Method+" "+call.Path]; ok {
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"error": {"message": "The requested identifier already exists."}}`))
+			return
+		}
+		_, _ = w.Write([]byte(pages[r.URL.Query().Get("pageToken")]))
+	}))
+	t.Cleanup(server.Close)
+
+	return NewGoogleClientWith(server.Client(), server.URL), &calls
+}
+
+func TestGoogleClient_List(t *testing.T) {
+	client, calls := newFakeGoogle(t, nil, map[string]string{
+		"": `{"items": [{"id": "a1", "summary": "On call: Alice", "description": "Days shift of payments",
+			"start": {"dateTime": "2026-03-02T09:00:00Z"}, "end": {"dateTime": "2026-03-02T17:00:00Z"}}],
+			"nextPageToken": "next"}`,
+		"next": `{"items": [{"id": "b2", "summary": "On call: Bob",
+			"start": {"dateTime": "2026-03-03T12:30:00+03:30"}, "end": {"dateTime": "2026-03-03T20:30:00+03:30"}}]}`,
+	})
+
+	events, err := client.List(context.Background(), calendarID, "payments", time.Date(2026, 3, 2, 6, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []Event{
+		{
+			ID:          "a1",
+			Summary:     "On call: Alice",
+			Description: "Days shift of payments",
+			Start:       time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+			End:         time.Date(2026, 3, 2, 17, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:      "b2",
+			Summary: "On call: Bob",
+			Start:   time.Date(2026, 3, 3, 9, 0, 0, 0, time.UTC),
+			End:     time.Date(2026, 3, 3, 17, 0, 0, 0, time.UTC),
+		},
+	}, events)
+
+	// Only the events tagged with the team are listed, page by page
+	require.Len(t, *calls, 2)
+	assert.Equal(t, "/calendars/payments@group.calendar.google.com/events", (*calls)[0].Path)
+	assert.Contains(t, (*calls)[0].Query, "privateExtendedProperty=oncall-schedule-team%3Dpayments")
+	assert.Contains(t, (*calls)[0].Query, "timeMin=2026-03-02T06%3A00%3A00Z")
+	assert.Contains(t, (*calls)[1].Query, "pageToken=next")
+}
+
+func TestGoogleClient_Changes(t *testing.T) {
+	event := Event{
+		ID:          "a1",
+		Summary:     "On call: Alice",
+		Description: "Days shift of payments",
+		Start:       time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		End:         time.Date(2026, 3, 2, 17, 0, 0, 0, time.UTC),
+	}
+
+	// Events deleted before keep their ID, so inserting them again updates
+	// them, and events already gone are deleted
+	client, calls := newFakeGoogle(t, map[string]int{
+		"POST /calendars/payments@group.calendar.google.com/events":      http.StatusConflict,
+		"DELETE /calendars/payments@group.calendar.google.com/events/b2": http.StatusGone,
+	}, nil)
+
+	ctx := context.Background()
+	require.NoError(t, client.Insert(ctx, calendarID, "payments", event))
+	require.NoError(t, client.Delete(ctx, calendarID, "b2"))
+
+	require.Len(t, *calls, 3)
+	assert.Equal(t, http.MethodPost, (*calls)[0].Method)
+	assert.Equal(t, http.MethodPut, (*calls)[1].Method)
+	assert.Equal(t, "/calendars/payments@group.calendar.google.com/events/a1", (*calls)[1].Path)
+	assert.Equal(t, map[string]any{
+		"id":          "a1",
+		"status":      "confirmed",
+		"summary":     "On call: Alice",
+		"description": "Days shift of payments",
+		"start":       map[string]any{"dateTime": "2026-03-02T09:00:00Z", "timeZone": "UTC"},
+		"end":         map[string]any{"dateTime": "2026-03-02T17:00:00Z", "timeZone": "UTC"},
+		"extendedProperties": map[string]any{
+			"private": map[string]any{PropertyTeam: "payments"},
+		},
+	}, (*calls)[1].Body)
+	assert.Equal(t, http.MethodDelete, (*calls)[2].Method)
+
+	// Other errors are reported with the message of the API
+	client, _ = newFakeGoogle(t, map[string]int{
+		"PUT /calendars/payments@group.calendar.google.com/events/a1": http.StatusForbidden,
+	}, nil)
+	err := client.Update(ctx, calendarID, "payments", event)
+	require.Error(t, err)
+	assert.Equal(t, "google calendar answered 403: The requested identifier already exists.", err.Error())
+}
+
+func TestNewGoogleClient(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewGoogleClient(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+
+	path := filepath.Join(dir, "key.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"client_email": "sync@project.iam.gserviceaccount.com"}`), 0o600))
+	_, err = NewGoogleClient(path)
+	require.ErrorContains(t, err, "private_key")
+}
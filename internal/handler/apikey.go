@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// APIKeyActorPrefix prefixes the name of an API key in the audit log actor
+// of the requests made with it.
+const APIKeyActorPrefix = "apikey:"
+
+// maxAPIKeyNameLength bounds API key names, which end up in the audit log.
+const maxAPIKeyNameLength = 255
+
+// APIKeyRequest represents an API key request.
+type APIKeyRequest struct {
+	Name string    `json:"name"`
+	Role auth.Role `json:"role"`
+	// ExpiresAt is the RFC3339 instant the key expires at, it never expires when empty.
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// APIKeyResponse represents an API key. The key itself is only returned
+// once, on creation.
+type APIKeyResponse struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Role      auth.Role `json:"role"`
+	Key       string    `json:"key,omitempty"`
+	ExpiresAt string    `json:"expires_at,omitempty"`
+	RevokedAt string    `json:"revoked_at,omitempty"`
+	CreatedAt string    `json:"created_at"`
+}
+
+// SetAPIKeys sets the API keys of the config file, which are accepted along
+// with the ones created through the API.
+func (h *Handler) SetAPIKeys(keys []config.APIKeyConfig) {
+	h.apiKeys = keys
+}
+
+// CreateAPIKey handles API key requests. Only the hash of the generated key
+// is stored.
+func (h *Handler) CreateAPIKey(c echo.Context) error {
+	var req APIKeyRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "name is required"})
+	}
+	if len(req.Name) > maxAPIKeyNameLength {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("name must be at most %d bytes", maxAPIKeyNameLength)})
+	}
+	if req.Role != auth.RoleReader && req.Role != auth.RoleAdmin {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "role must be reader or admin"})
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresAt != "" {
+		var err error
+		if expiresAt, err = time.Parse(time.RFC3339, req.ExpiresAt); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid expires_at format, use RFC3339 format"})
+		}
+		if !expiresAt.After(h.now()) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "expires_at must be in the future"})
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	// Names identify keys in the audit log, so the usable ones are unique
+	keys, err := h.storage.ListAPIKeys(ctx)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list api keys to create %q: %w", req.Name, err), "failed to create api key")
+	}
+	for _, key := range keys {
+		if key.Name == req.Name && key.Valid(h.now()) {
+			return c.JSON(http.StatusConflict, ErrorResponse{Error: "an api key with the name already exists"})
+		}
+	}
+	for _, key := range h.apiKeys {
+		if key.Name == req.Name {
+			return c.JSON(http.StatusConflict, ErrorResponse{Error: "an api key with the name already exists"})
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return h.internalError(c, fmt.Errorf("generate api key %q: %w", req.Name, err), "failed to generate api key")
+	}
+	raw := hex.EncodeToString(secret)
+
+	key, err := h.storage.AddAPIKey(ctx, storage.APIKey{
+		Name:      req.Name,
+		Role:      string(req.Role),
+		Hash:      hashToken(raw),
+		ExpiresAt: expiresAt.UTC(),
+	})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add api key %q: %w", req.Name, err), "failed to create api key")
+	}
+
+	h.logger.Info("api key created",
+		zap.Int64("id", key.ID),
+		zap.String("name", key.Name),
+		zap.String("role", key.Role),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	resp := newAPIKeyResponse(key)
+	resp.Key = raw
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// ListAPIKeys handles API key listing requests, the keys themselves are
+// never returned.
+func (h *Handler) ListAPIKeys(c echo.Context) error {
+	keys, err := h.storage.ListAPIKeys(c.Request().Context())
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list api keys: %w", err), "failed to list api keys")
+	}
+
+	resp := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, newAPIKeyResponse(key))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RevokeAPIKey handles API key revocation requests. Revoked keys are kept,
+// so the actors of the audit log stay known.
+func (h *Handler) RevokeAPIKey(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid api key id"})
+	}
+
+	ctx := c.Request().Context()
+
+	revoked, err := h.storage.RevokeAPIKey(ctx, id, h.now().UTC())
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("revoke api key %d: %w", id, err), "failed to revoke api key")
+	}
+
+	if !revoked {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "api key not found"})
+	}
+
+	h.logger.Info("api key revoked", zap.Int64("id", id), zap.String("actor", storage.ActorFrom(ctx)))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// apiKey looks up the API key of the bearer token of the request, in the
+// config file first and in storage then. It reports false when the key is
+// unknown, expired or revoked.
+func (h *Handler) apiKey(c echo.Context) (storage.APIKey, bool, error) {
+	raw, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	if !ok || raw == "" {
+		return storage.APIKey{}, false, nil
+	}
+
+	for _, key := range h.apiKeys {
+		if key.Key != "" && subtle.ConstantTimeCompare([]byte(raw), []byte(key.Key)) == 1 {
+			return storage.APIKey{Name: key.Name, Role: key.Role}, true, nil
+		}
+	}
+
+	key, found, err := h.storage.GetAPIKey(c.Request().Context(), hashToken(raw))
+	if err != nil || !found {
+		return storage.APIKey{}, false, err
+	}
+
+	if !key.Valid(h.now()) {
+		return storage.APIKey{}, false, nil
+	}
+
+	return key, true, nil
+}
+
+// newAPIKeyResponse converts an API key without the key itself.
+func newAPIKeyResponse(key storage.APIKey) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        key.ID,
+		Name:      key.Name,
+		Role:      auth.Role(key.Role),
+		CreatedAt: key.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !key.ExpiresAt.IsZero() {
+		resp.ExpiresAt = key.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	if !key.RevokedAt.IsZero() {
+		resp.RevokedAt = key.RevokedAt.UTC().Format(time.RFC3339)
+	}
+
+	return resp
+}
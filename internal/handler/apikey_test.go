@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeClock is a clock tests move by hand.
+type fakeClock struct {
+	now time.Time
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func newAPIKeyServer(t *testing.T) (*echo.Echo, *fakeClock) {
+	t.Helper()
+
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.now = clock.Now
+	h.SetAPIKeys([]config.APIKeyConfig{{Name: "bootstrap", Key: "bootstrap-key", Role: "admin"}})
+
+	admin := e.Group("/admin", h.Authenticate(auth.RoleAdmin, "secret"))
+	admin.POST("/apikeys", h.CreateAPIKey)
+	admin.GET("/apikeys", h.ListAPIKeys)
+	admin.DELETE("/apikeys/:id", h.RevokeAPIKey)
+	admin.GET("/actor", func(c echo.Context) error {
+		return c.String(http.StatusOK, storage.ActorFrom(c.Request().Context()))
+	})
+	e.GET("/reader/actor", func(c echo.Context) error {
+		return c.String(http.StatusOK, storage.ActorFrom(c.Request().Context()))
+	}, h.Authenticate(auth.RoleReader, "secret"))
+
+	return e, clock
+}
+
+// createAPIKey creates an API key with the given token and returns it.
+func createAPIKey(t *testing.T, e *echo.Echo, token string, req APIKeyRequest) APIKeyResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/admin/apikeys", req, token)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp APIKeyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestAPIKey_CreateAndUse(t *testing.T) {
+	e, _ := newAPIKeyServer(t)
+
+	// The config file key bootstraps the first stored one
+	rec := serveJSON(e, http.MethodGet, "/admin/actor", nil, "bootstrap-key")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "apikey:bootstrap", rec.Body.String())
+
+	deploy := createAPIKey(t, e, "bootstrap-key", APIKeyRequest{Name: "deploy", Role: auth.RoleAdmin})
+	assert.Equal(t, "deploy", deploy.Name)
+	assert.Equal(t, auth.RoleAdmin, deploy.Role)
+	assert.Len(t, deploy.Key, 64)
+	assert.Empty(t, deploy.ExpiresAt)
+
+	rec = serveJSON(e, http.MethodGet, "/admin/actor", nil, deploy.Key)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "apikey:deploy", rec.Body.String())
+
+	dashboard := createAPIKey(t, e, deploy.Key, APIKeyRequest{Name: "dashboard", Role: auth.RoleReader})
+
+	rec = serveJSON(e, http.MethodGet, "/reader/actor", nil, dashboard.Key)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "apikey:dashboard", rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/admin/actor", nil, dashboard.Key)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveJSON(e, http.MethodGet, "/admin/actor", nil, "unknown-key")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The admin token keeps working
+	rec = serveJSON(e, http.MethodGet, "/admin/actor", nil, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, AdminActor, rec.Body.String())
+
+	// Listing never returns the keys
+	rec = serveJSON(e, http.MethodGet, "/admin/apikeys", nil, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), deploy.Key)
+
+	var keys []APIKeyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &keys))
+	require.Len(t, keys, 2)
+	assert.Equal(t, "deploy", keys[0].Name)
+	assert.Equal(t, auth.RoleReader, keys[1].Role)
+}
+
+func TestAPIKey_Create_Validation(t *testing.T) {
+	e, _ := newAPIKeyServer(t)
+
+	createAPIKey(t, e, "secret", APIKeyRequest{Name: "deploy", Role: auth.RoleAdmin})
+
+	tests := []struct {
+		name string
+		req  APIKeyRequest
+		code int
+	}{
+		{"missing name", APIKeyRequest{Role: auth.RoleAdmin}, http.StatusBadRequest},
+		{"unknown role", APIKeyRequest{Name: "ci", Role: "owner"}, http.StatusBadRequest},
+		{"invalid expiry", APIKeyRequest{Name: "ci", Role: auth.RoleAdmin, ExpiresAt: "tomorrow"}, http.StatusBadRequest},
+		{"past expiry", APIKeyRequest{Name: "ci", Role: auth.RoleAdmin, ExpiresAt: "2026-03-01T09:00:00Z"}, http.StatusBadRequest},
+		{"duplicate name", APIKeyRequest{Name: "deploy", Role: auth.RoleReader}, http.StatusConflict},
+		{"config file name", APIKeyRequest{Name: "bootstrap", Role: auth.RoleReader}, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPost, "/admin/apikeys", tt.req, "secret")
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+
+	rec := serveJSON(e, http.MethodPost, "/admin/apikeys", APIKeyRequest{Name: "ci", Role: auth.RoleAdmin}, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAPIKey_Revoke(t *testing.T) {
+	e, clock := newAPIKeyServer(t)
+
+	deploy := createAPIKey(t, e, "secret", APIKeyRequest{Name: "deploy", Role: auth.RoleAdmin})
+
+	rec := serveJSON(e, http.MethodDelete, "/admin/apikeys/1", nil, "secret")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serveJSON(e, http.MethodGet, "/admin/actor", nil, deploy.Key)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Keys are revoked once
+	rec = serveJSON(e, http.MethodDelete, "/admin/apikeys/1", nil, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, "/admin/apikeys/abc", nil, "secret")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSON(e, http.MethodGet, "/admin/apikeys", nil, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var keys []APIKeyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &keys))
+	require.Len(t, keys, 1)
+	assert.Equal(t, clock.now.Format(time.RFC3339), keys[0].RevokedAt)
+
+	// The name of a revoked key may be used again
+	createAPIKey(t, e, "secret", APIKeyRequest{Name: "deploy", Role: auth.RoleAdmin})
+}
+
+func TestAPIKey_Expiry(t *testing.T) {
+	e, clock := newAPIKeyServer(t)
+
+	expiresAt := clock.now.Add(time.Hour)
+	ci := createAPIKey(t, e, "secret", APIKeyRequest{Name: "ci", Role: auth.RoleAdmin, ExpiresAt: expiresAt.Format(time.RFC3339)})
+	assert.Equal(t, expiresAt.Format(time.RFC3339), ci.ExpiresAt)
+
+	clock.now = expiresAt.Add(-time.Second)
+	rec := serveJSON(e, http.MethodGet, "/admin/actor", nil, ci.Key)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	clock.now = expiresAt
+	rec = serveJSON(e, http.MethodGet, "/admin/actor", nil, ci.Key)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The name of an expired key may be used again
+	createAPIKey(t, e, "secret", APIKeyRequest{Name: "ci", Role: auth.RoleAdmin})
+}
+
+func TestAPIKey_StorageFailure(t *testing.T) {
+	e := echo.New()
+	h := New(&blockingStorage{canceled: make(chan struct{})}, zap.NewNop())
+	e.GET("/admin/actor", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}, Timeout(10*time.Millisecond), h.Authenticate(auth.RoleAdmin, "secret"))
+
+	// A key that cannot be looked up is not accepted
+	rec := serveJSON(e, http.MethodGet, "/admin/actor", nil, "some-key")
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+}
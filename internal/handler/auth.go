@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// Cookies of the sign-in flow. The login cookie carries the state of a
+// sign-in to its callback and the session cookie the signed-in user.
+const (
+	sessionCookie = "oncall_session"
+	loginCookie   = "oncall_login"
+)
+
+// logoutPath is the route of signing out.
+const logoutPath = "/auth/logout"
+
+// loginTimeout bounds how long a user has to sign in at the provider.
+const loginTimeout = 10 * time.Minute
+
+// sessionKey is the echo context key of the session of a request.
+const sessionKey = "session"
+
+// loginState is the state of a sign-in, kept in the login cookie.
+type loginState struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	ReturnTo string `json:"return_to,omitempty"`
+}
+
+// SessionResponse represents the signed-in user.
+type SessionResponse struct {
+	Subject string    `json:"subject"`
+	Name    string    `json:"name,omitempty"`
+	Email   string    `json:"email,omitempty"`
+	Role    auth.Role `json:"role"`
+	Expiry  string    `json:"expiry"`
+}
+
+// SetOIDC sets the OpenID Connect provider users sign in with. Without one
+// sign-in is disabled and only tokens are accepted.
+func (h *Handler) SetOIDC(o *auth.OIDC) {
+	h.oidc = o
+}
+
+// Authenticate guards routes requiring the given role. Requests are accepted
+// with a bearer token, either the admin token, which grants the admin role,
+// or an API key holding the role, or with a session cookie of a user holding
+// the role. Sessions whose ID token expired are refreshed on the way, and
+// cleared when that fails. Changes are attributed to AdminActor, to the API
+// key, or to the user of the session.
+func (h *Handler) Authenticate(role auth.Role, token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			// A bearer token is never mistaken for a session
+			if c.Request().Header.Get(echo.HeaderAuthorization) != "" {
+				actor := AdminActor
+				if !bearerMatches(c, token) {
+					key, ok, err := h.apiKey(c)
+					if err != nil {
+						return h.storageFailure(c, fmt.Errorf("look up api key: %w", err), "failed to authenticate")
+					}
+					if !ok {
+						return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+					}
+					if !auth.Role(key.Role).Allows(role) {
+						return c.JSON(http.StatusForbidden, ErrorResponse{Error: "the " + string(role) + " role is required"})
+					}
+					actor = APIKeyActorPrefix + key.Name
+				}
+
+				c.SetRequest(c.Request().WithContext(storage.WithActor(c.Request().Context(), actor)))
+
+				return next(c)
+			}
+
+			session, ok := h.session(c)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+			}
+			if !session.Role.Allows(role) {
+				return c.JSON(http.StatusForbidden, ErrorResponse{Error: "the " + string(role) + " role is required"})
+			}
+
+			c.Set(sessionKey, session)
+			c.SetRequest(c.Request().WithContext(storage.WithActor(c.Request().Context(), session.Actor())))
+
+			return next(c)
+		}
+	}
+}
+
+// session returns the session of the request, refreshed when it expired.
+func (h *Handler) session(c echo.Context) (auth.Session, bool) {
+	if h.oidc == nil {
+		return auth.Session{}, false
+	}
+
+	cookie, err := c.Cookie(sessionCookie)
+	if err != nil {
+		return auth.Session{}, false
+	}
+
+	var session auth.Session
+	if err := h.oidc.Open(sessionCookie, cookie.Value, &session); err != nil {
+		h.clearCookie(c, sessionCookie, "/")
+		return auth.Session{}, false
+	}
+
+	if !session.Expired(time.Now()) {
+		return session, true
+	}
+
+	refreshed, err := h.oidc.Refresh(c.Request().Context(), session)
+	if err != nil {
+		h.logger.Info("failed to refresh session", zap.String("subject", session.Subject), zap.Error(err))
+		h.clearCookie(c, sessionCookie, "/")
+		return auth.Session{}, false
+	}
+
+	if err := h.setSessionCookie(c, refreshed); err != nil {
+		h.logger.Error("failed to set session cookie", zap.Error(err))
+		return auth.Session{}, false
+	}
+
+	return refreshed, true
+}
+
+// Login handles sign-in requests by redirecting to the provider. The
+// return_to query parameter is the local path the callback redirects to.
+func (h *Handler) Login(c echo.Context) error {
+	if h.oidc == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "sign-in is not configured"})
+	}
+
+	state := loginState{
+		State:    randomToken(),
+		Nonce:    randomToken(),
+		ReturnTo: localPath(c.QueryParam("return_to")),
+	}
+
+	value, err := h.oidc.Seal(loginCookie, state)
+	if err != nil {
+		return h.internalError(c, fmt.Errorf("seal login state: %w", err), "failed to start sign-in")
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     loginCookie,
+		Value:    value,
+		Path:     "/auth",
+		MaxAge:   int(loginTimeout.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		// The provider redirects back with a top-level navigation
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return c.Redirect(http.StatusFound, h.oidc.AuthCodeURL(state.State, state.Nonce))
+}
+
+// Callback handles the redirect back from the provider. The code is
+// redeemed for a session, which is stored in the session cookie, and the
+// user is sent on to where they signed in from.
+func (h *Handler) Callback(c echo.Context) error {
+	if h.oidc == nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "sign-in is not configured"})
+	}
+
+	if reason := c.QueryParam("error"); reason != "" {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "sign-in failed: " + reason})
+	}
+
+	cookie, err := c.Cookie(loginCookie)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "no sign-in in progress"})
+	}
+	h.clearCookie(c, loginCookie, "/auth")
+
+	var state loginState
+	if err := h.oidc.Open(loginCookie, cookie.Value, &state); err != nil ||
+		subtle.ConstantTimeCompare([]byte(state.State), []byte(c.QueryParam("state"))) != 1 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid sign-in state"})
+	}
+
+	session, err := h.oidc.Exchange(c.Request().Context(), c.QueryParam("code"), state.Nonce)
+	if err != nil {
+		if errors.Is(err, auth.ErrNoRole) {
+			return c.JSON(http.StatusForbidden, ErrorResponse{Error: "no role is granted to the user"})
+		}
+		h.logger.Warn("failed to sign in", zap.Error(err))
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "sign-in failed"})
+	}
+
+	if err := h.setSessionCookie(c, session); err != nil {
+		return h.internalError(c, fmt.Errorf("set session cookie of %q: %w", session.Subject, err), "failed to sign in")
+	}
+
+	h.logger.Info("user signed in", zap.String("subject", session.Subject), zap.String("role", string(session.Role)))
+
+	returnTo := state.ReturnTo
+	if returnTo == "" {
+		returnTo = "/"
+	}
+
+	return c.Redirect(http.StatusFound, returnTo)
+}
+
+// Logout handles sign-out requests by clearing the session cookie.
+func (h *Handler) Logout(c echo.Context) error {
+	h.clearCookie(c, sessionCookie, "/")
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Me handles requests for the signed-in user, it must be guarded by
+// Authenticate. Requests with the admin token have no user.
+func (h *Handler) Me(c echo.Context) error {
+	session, ok := c.Get(sessionKey).(auth.Session)
+	if !ok {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "not signed in"})
+	}
+
+	return c.JSON(http.StatusOK, SessionResponse{
+		Subject: session.Subject,
+		Name:    session.Name,
+		Email:   session.Email,
+		Role:    session.Role,
+		Expiry:  session.Expiry.Format(time.RFC3339),
+	})
+}
+
+// setSessionCookie stores the session, the cookie lasts as long as the
+// session can be refreshed.
+func (h *Handler) setSessionCookie(c echo.Context, session auth.Session) error {
+	value, err := h.oidc.Seal(sessionCookie, session)
+	if err != nil {
+		return err
+	}
+
+	c.SetCookie(&http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		Expires:  session.SignedIn.Add(h.oidc.TTL()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return nil
+}
+
+// clearCookie removes a cookie set on the given path.
+func (h *Handler) clearCookie(c echo.Context, name, path string) {
+	c.SetCookie(&http.Cookie{
+		Name:     name,
+		Path:     path,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// localPath returns the path if it stays on this server, so the callback
+// cannot be used to redirect elsewhere, or an empty string.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return ""
+	}
+
+	return path
+}
+
+// randomToken returns a random URL safe token for the state and nonce of a
+// sign-in.
+func randomToken() string {
+	b := make([]byte, 32)
+	// Read never fails, it panics when the system source is unavailable
+	_, _ = rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
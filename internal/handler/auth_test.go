@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/auth/authtest"
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newAuthServer(t *testing.T) (*echo.Echo, *Handler, *authtest.Provider) {
+	t.Helper()
+
+	p := authtest.NewProvider(t)
+	o, err := auth.NewOIDC(context.Background(), config.OIDCConfig{
+		Issuer:        p.Issuer(),
+		ClientID:      authtest.ClientID,
+		ClientSecret:  authtest.ClientSecret,
+		RedirectURL:   "http://oncall.test/auth/callback",
+		Scopes:        []string{"openid", "email"},
+		SessionSecret: strings.Repeat("s", 32),
+		SessionTTL:    12 * time.Hour,
+		GroupsClaim:   "groups",
+		AdminGroups:   []string{"sre-leads"},
+		ReaderGroups:  []string{"oncall"},
+	}, http.DefaultClient)
+	require.NoError(t, err)
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.SetOIDC(o)
+
+	e.GET("/auth/login", h.Login)
+	e.GET("/auth/callback", h.Callback)
+	e.POST("/auth/logout", h.Logout)
+	e.GET("/auth/me", h.Me, h.Authenticate(auth.RoleReader, "secret"))
+	e.GET("/admin/actor", func(c echo.Context) error {
+		return c.String(http.StatusOK, storage.ActorFrom(c.Request().Context()))
+	}, h.Authenticate(auth.RoleAdmin, "secret"))
+
+	return e, h, p
+}
+
+// serveCookies sends a request with the given cookies and bearer token.
+func serveCookies(e *echo.Echo, method, target, token string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// responseCookie returns the cookie of the given name set by a response.
+func responseCookie(rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rec.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+
+	return nil
+}
+
+// startLogin starts a sign-in and returns the login cookie with the state
+// and nonce sent to the provider.
+func startLogin(t *testing.T, e *echo.Echo, target string) (*http.Cookie, string, string) {
+	t.Helper()
+
+	rec := serveCookies(e, http.MethodGet, target, "")
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	location, err := url.Parse(rec.Header().Get(echo.HeaderLocation))
+	require.NoError(t, err)
+
+	cookie := responseCookie(rec, loginCookie)
+	require.NotNil(t, cookie)
+
+	return cookie, location.Query().Get("state"), location.Query().Get("nonce")
+}
+
+// signIn runs the whole sign-in of a user and returns their session cookie.
+func signIn(t *testing.T, e *echo.Echo, p *authtest.Provider, claims map[string]any) *http.Cookie {
+	t.Helper()
+
+	cookie, state, nonce := startLogin(t, e, "/auth/login")
+	code := p.IssueCode(nonce, claims)
+
+	rec := serveCookies(e, http.MethodGet, "/auth/callback?code="+code+"&state="+state, "", cookie)
+	require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+
+	session := responseCookie(rec, sessionCookie)
+	require.NotNil(t, session)
+
+	return session
+}
+
+func TestAuth_LoginFlow(t *testing.T) {
+	e, _, p := newAuthServer(t)
+
+	rec := serveCookies(e, http.MethodGet, "/auth/login?return_to=/teams/backend-team/timeline", "")
+	require.Equal(t, http.StatusFound, rec.Code)
+
+	location, err := url.Parse(rec.Header().Get(echo.HeaderLocation))
+	require.NoError(t, err)
+	assert.Equal(t, p.Issuer()+"/authorize", location.Scheme+"://"+location.Host+location.Path)
+	assert.Equal(t, authtest.ClientID, location.Query().Get("client_id"))
+	assert.Equal(t, "http://oncall.test/auth/callback", location.Query().Get("redirect_uri"))
+	assert.Equal(t, "openid email", location.Query().Get("scope"))
+	assert.NotEmpty(t, location.Query().Get("state"))
+	assert.NotEmpty(t, location.Query().Get("nonce"))
+
+	login := responseCookie(rec, loginCookie)
+	require.NotNil(t, login)
+	assert.True(t, login.HttpOnly)
+	assert.True(t, login.Secure)
+
+	code := p.IssueCode(location.Query().Get("nonce"), map[string]any{"sub": "alice-id", "email": "alice@example.org", "groups": []string{"oncall"}})
+	rec = serveCookies(e, http.MethodGet, "/auth/callback?code="+code+"&state="+location.Query().Get("state"), "", login)
+	require.Equal(t, http.StatusFound, rec.Code, rec.Body.String())
+	assert.Equal(t, "/teams/backend-team/timeline", rec.Header().Get(echo.HeaderLocation))
+
+	session := responseCookie(rec, sessionCookie)
+	require.NotNil(t, session)
+	assert.True(t, session.HttpOnly)
+	assert.True(t, session.Secure)
+	assert.Equal(t, http.SameSiteLaxMode, session.SameSite)
+	assert.Equal(t, -1, responseCookie(rec, loginCookie).MaxAge, "the login cookie is cleared")
+
+	rec = serveCookies(e, http.MethodGet, "/auth/me", "", session)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var me SessionResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &me))
+	assert.Equal(t, "alice-id", me.Subject)
+	assert.Equal(t, "alice@example.org", me.Email)
+	assert.Equal(t, auth.RoleReader, me.Role)
+
+	rec = serveCookies(e, http.MethodPost, "/auth/logout", "", session)
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, -1, responseCookie(rec, sessionCookie).MaxAge)
+}
+
+func TestAuth_LoginIgnoresForeignReturnTo(t *testing.T) {
+	e, _, p := newAuthServer(t)
+
+	for _, returnTo := range []string{"https://evil.test", "//evil.test", "/\\evil.test", "teams"} {
+		cookie, state, nonce := startLogin(t, e, "/auth/login?return_to="+url.QueryEscape(returnTo))
+		code := p.IssueCode(nonce, map[string]any{"sub": "alice-id", "groups": []string{"oncall"}})
+
+		rec := serveCookies(e, http.MethodGet, "/auth/callback?code="+code+"&state="+state, "", cookie)
+		require.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "/", rec.Header().Get(echo.HeaderLocation), returnTo)
+	}
+}
+
+func TestAuth_CallbackFailures(t *testing.T) {
+	e, _, p := newAuthServer(t)
+
+	t.Run("provider error", func(t *testing.T) {
+		rec := serveCookies(e, http.MethodGet, "/auth/callback?error=access_denied", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Contains(t, rec.Body.String(), "access_denied")
+	})
+
+	t.Run("no sign-in in progress", func(t *testing.T) {
+		rec := serveCookies(e, http.MethodGet, "/auth/callback?code=x&state=y", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("state mismatch", func(t *testing.T) {
+		cookie, _, nonce := startLogin(t, e, "/auth/login")
+		code := p.IssueCode(nonce, map[string]any{"sub": "alice-id", "groups": []string{"oncall"}})
+
+		rec := serveCookies(e, http.MethodGet, "/auth/callback?code="+code+"&state=forged", "", cookie)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		cookie, state, _ := startLogin(t, e, "/auth/login")
+		code := p.IssueCode("replayed", map[string]any{"sub": "alice-id", "groups": []string{"oncall"}})
+
+		rec := serveCookies(e, http.MethodGet, "/auth/callback?code="+code+"&state="+state, "", cookie)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Nil(t, responseCookie(rec, sessionCookie))
+	})
+
+	t.Run("no role", func(t *testing.T) {
+		cookie, state, nonce := startLogin(t, e, "/auth/login")
+		code := p.IssueCode(nonce, map[string]any{"sub": "mallory-id", "groups": []string{"contractors"}})
+
+		rec := serveCookies(e, http.MethodGet, "/auth/callback?code="+code+"&state="+state, "", cookie)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Nil(t, responseCookie(rec, sessionCookie))
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	e, _, p := newAuthServer(t)
+
+	reader := signIn(t, e, p, map[string]any{"sub": "alice-id", "email": "alice@example.org", "groups": []string{"oncall"}})
+	admin := signIn(t, e, p, map[string]any{"sub": "bob-id", "email": "bob@example.org", "groups": []string{"oncall", "sre-leads"}})
+
+	rec := serveCookies(e, http.MethodGet, "/admin/actor", "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, AdminActor, rec.Body.String())
+
+	rec = serveCookies(e, http.MethodGet, "/admin/actor", "", admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "oidc:bob@example.org", rec.Body.String())
+
+	rec = serveCookies(e, http.MethodGet, "/admin/actor", "", reader)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveCookies(e, http.MethodGet, "/admin/actor", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// A wrong token is rejected even with a valid session
+	rec = serveCookies(e, http.MethodGet, "/admin/actor", "wrong", admin)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// The admin token has no user
+	rec = serveCookies(e, http.MethodGet, "/auth/me", "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	tampered := *admin
+	tampered.Value = strings.ToUpper(tampered.Value)
+	rec = serveCookies(e, http.MethodGet, "/admin/actor", "", &tampered)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, -1, responseCookie(rec, sessionCookie).MaxAge)
+}
+
+func TestAuthenticate_WithoutOIDC(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	e.GET("/admin/actor", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, h.Authenticate(auth.RoleAdmin, "secret"))
+	e.GET("/auth/login", h.Login)
+
+	assert.Equal(t, http.StatusOK, serveCookies(e, http.MethodGet, "/admin/actor", "secret").Code)
+	assert.Equal(t, http.StatusUnauthorized, serveCookies(e, http.MethodGet, "/admin/actor", "",
+		&http.Cookie{Name: sessionCookie, Value: "anything"}).Code)
+	assert.Equal(t, http.StatusNotFound, serveCookies(e, http.MethodGet, "/auth/login", "").Code)
+}
+
+func TestAuthenticate_RefreshesExpiredSession(t *testing.T) {
+	e, h, p := newAuthServer(t)
+
+	claims := map[string]any{"sub": "alice-id", "email": "alice@example.org", "groups": []string{"oncall"}}
+	cookie := signIn(t, e, p, claims)
+
+	// expire returns the cookie with its ID token expired
+	expire := func(cookie *http.Cookie) *http.Cookie {
+		var session auth.Session
+		require.NoError(t, h.oidc.Open(sessionCookie, cookie.Value, &session))
+		session.Expiry = time.Now().Add(-time.Minute)
+
+		value, err := h.oidc.Seal(sessionCookie, session)
+		require.NoError(t, err)
+
+		return &http.Cookie{Name: sessionCookie, Value: value}
+	}
+
+	// Alice was made an admin since she signed in
+	claims["groups"] = []string{"oncall", "sre-leads"}
+	p.SetClaims(claims)
+
+	expired := expire(cookie)
+	rec := serveCookies(e, http.MethodGet, "/admin/actor", "", expired)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	refreshed := responseCookie(rec, sessionCookie)
+	require.NotNil(t, refreshed)
+
+	rec = serveCookies(e, http.MethodGet, "/auth/me", "", refreshed)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var me SessionResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &me))
+	assert.Equal(t, auth.RoleAdmin, me.Role)
+	assert.Nil(t, responseCookie(rec, sessionCookie), "a valid session is not reissued")
+
+	// The refresh token was rotated, so the old session cannot be refreshed again
+	rec = serveCookies(e, http.MethodGet, "/auth/me", "", expired)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, -1, responseCookie(rec, sessionCookie).MaxAge)
+
+	// Refreshed tokens are verified like the ones of the sign-in
+	p.UseUnpublishedKey()
+	rec = serveCookies(e, http.MethodGet, "/auth/me", "", expire(refreshed))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,407 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// BackupVersion is the format version of backup documents.
+const BackupVersion = 1
+
+// Restore modes.
+const (
+	// RestoreMerge adds the schedules missing from storage and keeps everything else.
+	RestoreMerge = "merge"
+	// RestoreReplace deletes every team before loading the document.
+	RestoreReplace = "replace"
+)
+
+// BackupDocument is a dump of every team.
+type BackupDocument struct {
+	Version   int            `json:"version"`
+	CreatedAt string         `json:"created_at"`
+	Teams     []TeamDocument `json:"teams"`
+}
+
+// PauseDocument is the pause of a team in a backup document.
+type PauseDocument struct {
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+	Since  string `json:"since" yaml:"since"`
+	Until  string `json:"until,omitempty" yaml:"until,omitempty"`
+}
+
+// RestoreResponse summarizes a restore.
+type RestoreResponse struct {
+	Mode    string `json:"mode"`
+	Deleted int    `json:"deleted"`
+	Created int    `json:"created"`
+	Skipped int    `json:"skipped"`
+}
+
+// Backup handles full backup requests. The document is streamed one team at
+// a time, so a failure halfway through leaves it truncated rather than
+// holding every team in memory.
+func (h *Handler) Backup(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	names, err := h.storage.ListTeams(ctx)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list teams to back up: %w", err), "failed to list teams")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="oncall-backup.json"`)
+	c.Response().WriteHeader(http.StatusOK)
+
+	if err := h.writeBackup(ctx, c.Response(), names, time.Now()); err != nil {
+		// The status is already sent, the truncated document tells the client
+		h.logger.Error("failed to write backup", zap.Error(err))
+	}
+
+	return nil
+}
+
+// Restore handles restore requests of a backup document. The whole document is
+// validated before storage is touched, so an invalid one changes nothing.
+func (h *Handler) Restore(c echo.Context) error {
+	mode := c.QueryParam("mode")
+	if mode == "" {
+		mode = RestoreMerge
+	}
+	if mode != RestoreMerge && mode != RestoreReplace {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid mode, use 'merge' or 'replace'"})
+	}
+
+	var doc BackupDocument
+	if err := json.NewDecoder(c.Request().Body).Decode(&doc); err != nil {
+		h.logger.Error("failed to decode backup document", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid backup document"})
+	}
+
+	if doc.Version != BackupVersion {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("unsupported backup version %d", doc.Version)})
+	}
+
+	teams, err := h.parseTeams(doc.Teams)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	resp := RestoreResponse{Mode: mode}
+
+	if mode == RestoreReplace {
+		names, err := h.storage.ListTeams(ctx)
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("list teams to replace: %w", err), "failed to restore backup")
+		}
+
+		for _, name := range names {
+			// The team was deleted since it was listed
+			err := h.storage.DeleteTeam(ctx, name)
+			if errors.Is(err, storage.ErrTeamNotFound) {
+				continue
+			}
+			if err != nil {
+				return h.storageFailure(c, fmt.Errorf("delete team %q: %w", name, err), "failed to restore backup")
+			}
+			resp.Deleted++
+		}
+	}
+
+	for _, team := range teams {
+		created, skipped, err := h.restoreTeam(ctx, team)
+		resp.Created += created
+		resp.Skipped += skipped
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("restore team %q: %w", team.name, err), "failed to restore backup")
+		}
+	}
+
+	h.logger.Info("backup restored",
+		zap.String("mode", mode),
+		zap.Int("deleted", resp.Deleted),
+		zap.Int("created", resp.Created),
+		zap.Int("skipped", resp.Skipped),
+	)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// parsedTeam is a validated team of a backup document.
+type parsedTeam struct {
+	name      string
+	schedules []storage.Schedule
+	groups    []storage.Group
+	pause     *storage.Pause
+}
+
+// parseTeams validates the teams of a document.
+func (h *Handler) parseTeams(docs []TeamDocument) ([]parsedTeam, error) {
+	teams := make([]parsedTeam, 0, len(docs))
+
+	for _, doc := range docs {
+		if doc.Team == "" {
+			return nil, fmt.Errorf("team is required")
+		}
+
+		team := parsedTeam{name: doc.Team}
+		for _, req := range doc.Schedules {
+			req.Team = doc.Team
+
+			schedule, err := h.parseRequest(&req)
+			if err != nil {
+				return nil, fmt.Errorf("invalid schedule %q of team %q: %w", req.Name, doc.Team, err)
+			}
+			team.schedules = append(team.schedules, schedule)
+		}
+
+		for _, req := range doc.Groups {
+			group, err := parseGroup(req.Name, req.Members)
+			if err != nil {
+				return nil, fmt.Errorf("invalid group %q of team %q: %w", req.Name, doc.Team, err)
+			}
+			team.groups = append(team.groups, group)
+		}
+
+		if doc.Pause != nil {
+			pause, err := parsePauseDocument(*doc.Pause)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pause of team %q: %w", doc.Team, err)
+			}
+			team.pause = &pause
+		}
+
+		teams = append(teams, team)
+	}
+
+	return teams, nil
+}
+
+// restoreTeam adds the schedules and groups of a team that it does not have
+// yet and restores its pause. It returns the number of created and skipped
+// schedules.
+func (h *Handler) restoreTeam(ctx context.Context, team parsedTeam) (int, int, error) {
+	existing, err := h.storage.GetTeam(ctx, team.name)
+	if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+		return 0, 0, err
+	}
+
+	names := make(map[string]bool)
+	for _, sched := range existing.Schedules {
+		names[sched.Name] = true
+	}
+
+	created, skipped := 0, 0
+	for _, schedule := range team.schedules {
+		if names[schedule.Name] {
+			skipped++
+			continue
+		}
+
+		if _, err := h.storage.AddSchedule(ctx, team.name, schedule); err != nil {
+			return created, skipped, err
+		}
+		names[schedule.Name] = true
+		created++
+	}
+
+	// Groups need the team, which the schedules create
+	groups, err := h.storage.ListGroups(ctx, team.name)
+	if err != nil {
+		return created, skipped, err
+	}
+	for _, group := range team.groups {
+		if slices.ContainsFunc(groups, func(g storage.Group) bool { return g.Name == group.Name }) {
+			continue
+		}
+		if _, _, err := h.storage.SetGroup(ctx, team.name, group); err != nil {
+			return created, skipped, err
+		}
+	}
+
+	if team.pause != nil {
+		if _, err := h.storage.PauseTeam(ctx, team.name, *team.pause); err != nil {
+			return created, skipped, err
+		}
+	}
+
+	return created, skipped, nil
+}
+
+// writeBackup writes the backup document of the given teams to w.
+func (h *Handler) writeBackup(ctx context.Context, w io.Writer, names []string, now time.Time) error {
+	header := fmt.Sprintf(`{"version":%d,"created_at":%q,"teams":[`, BackupVersion, now.UTC().Format(time.RFC3339))
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	first := true
+	for _, name := range names {
+		// The team was deleted since it was listed
+		doc, err := h.teamDocument(ctx, name)
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to export team %s: %w", name, err)
+		}
+
+		encoded, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+
+		if !first {
+			encoded = append([]byte{','}, encoded...)
+		}
+		first = false
+
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+
+	_, err := io.WriteString(w, "]}\n")
+	return err
+}
+
+// teamDocument exports a team with its schedules and pause. It returns
+// storage.ErrTeamNotFound when the team does not exist.
+func (h *Handler) teamDocument(ctx context.Context, name string) (TeamDocument, error) {
+	team, err := h.storage.GetTeam(ctx, name)
+	if err != nil {
+		return TeamDocument{}, err
+	}
+
+	doc := TeamDocument{
+		Team:      name,
+		Schedules: make([]Request, 0, len(team.Schedules)),
+	}
+	for _, sched := range team.Schedules {
+		doc.Schedules = append(doc.Schedules, scheduleRequest(name, sched))
+	}
+
+	groups, err := h.storage.ListGroups(ctx, name)
+	if err != nil {
+		return TeamDocument{}, err
+	}
+	for _, group := range groups {
+		doc.Groups = append(doc.Groups, GroupRequest{Name: group.Name, Members: group.Members})
+	}
+
+	pause, paused, err := h.storage.GetPause(ctx, name)
+	if err != nil {
+		return TeamDocument{}, err
+	}
+	if paused {
+		resp := newPauseResponse(name, pause)
+		doc.Pause = &PauseDocument{Reason: resp.Reason, Since: resp.Since, Until: resp.Until}
+	}
+
+	return doc, nil
+}
+
+// scheduleRequest renders a schedule back into the request that creates it.
+func scheduleRequest(team string, sched storage.Schedule) Request {
+	req := Request{
+		Name:           sched.Name,
+		Description:    sched.Description,
+		Notes:          sched.Notes,
+		Team:           team,
+		Members:        sched.Members,
+		Cron:           sched.Cron,
+		RRule:          sched.RRule,
+		Start:          sched.Start.Format(time.Kitchen),
+		End:            sched.End.Format(time.Kitchen),
+		Tags:           sched.Tags,
+		Routing:        sched.Routing,
+		RotationOffset: sched.RotationOffset,
+		Split:          sched.Split,
+		RequiredCount:  sched.RequiredCount,
+	}
+
+	if sched.Compensation != nil {
+		req.Compensation = &Compensation{
+			RateClass:         sched.Compensation.RateClass,
+			Multiplier:        sched.Compensation.Multiplier,
+			WeekendRateClass:  sched.Compensation.WeekendRateClass,
+			WeekendMultiplier: sched.Compensation.WeekendMultiplier,
+		}
+	}
+
+	if sched.Handoff != nil {
+		req.Handoff = &Handoff{Day: sched.Handoff.Day.String(), Time: sched.Handoff.Time.Format(time.Kitchen)}
+	}
+
+	for _, day := range sched.Days {
+		req.Days = append(req.Days, day.String())
+	}
+
+	// The members of the team are resolved whenever the schedule is read
+	if sched.TeamMembers {
+		req.UseTeamMembers = true
+		req.Members = nil
+	}
+
+	// Groups are kept as references, their members change over time
+	if len(sched.MemberRefs) > 0 {
+		req.Members = sched.MemberRefs
+	}
+
+	if len(sched.DayAssignments) > 0 {
+		req.Assignment = AssignmentFixed
+		req.Members = nil
+		req.DayAssignments = make(map[string]string, len(sched.DayAssignments))
+		for day, member := range sched.DayAssignments {
+			req.DayAssignments[day.String()] = member
+		}
+	}
+
+	if sched.Manual {
+		req.Rotation = RotationManual
+	}
+
+	if !sched.Anchor.IsZero() {
+		req.Anchor = sched.Anchor.Format(time.DateOnly)
+	}
+
+	if !sched.ValidUntil.IsZero() {
+		req.ValidUntil = sched.ValidUntil.UTC().Format(time.RFC3339)
+	}
+
+	return req
+}
+
+// parsePauseDocument parses the pause of a backup document.
+func parsePauseDocument(doc PauseDocument) (storage.Pause, error) {
+	since, err := time.Parse(time.RFC3339, doc.Since)
+	if err != nil {
+		return storage.Pause{}, fmt.Errorf("invalid since format, use RFC3339 format")
+	}
+
+	pause := storage.Pause{Reason: doc.Reason, Since: since}
+	if doc.Until != "" {
+		until, err := time.Parse(time.RFC3339, doc.Until)
+		if err != nil {
+			return storage.Pause{}, fmt.Errorf("invalid until format, use RFC3339 format")
+		}
+		pause.Until = until
+	}
+
+	return pause, nil
+}
@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newBackupServer wires the backup routes in front of store.
+func newBackupServer(t *testing.T, store storage.Storage) *echo.Echo {
+	t.Helper()
+
+	e := echo.New()
+	logger, _ := zap.NewDevelopment()
+	h := New(store, logger)
+
+	admin := e.Group("/admin", Admin("secret"))
+	admin.GET("/backup", h.Backup)
+	admin.POST("/restore", h.Restore)
+
+	return e
+}
+
+func serveBackup(t *testing.T, e *echo.Echo) []byte {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/backup", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	return rec.Body.Bytes()
+}
+
+func serveRestore(t *testing.T, e *echo.Echo, mode string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/restore?mode="+mode, bytes.NewReader(body))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func populate(t *testing.T, store storage.Storage) {
+	t.Helper()
+
+	weekdays := storage.Schedule{
+		Name:        "Weekday Coverage",
+		Description: "Covers the EU business hours",
+		Notes:       "Escalate to **#sre-eu**,\nthen page the lead.",
+		Members:     []string{"Alice", "Bob"},
+		Days:        []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Anchor:      time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:       parseTime(t, "9:00AM"),
+		End:         parseTime(t, "5:00PM"),
+	}
+	biweekly := storage.Schedule{
+		Name:    "Biweekly",
+		Members: []string{"Charlie"},
+		RRule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=SA",
+		Anchor:  time.Date(2025, 4, 26, 0, 0, 0, 0, time.UTC),
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+	firstMonday := storage.Schedule{
+		Name:    "First Monday Night",
+		Members: []string{"Dana"},
+		Cron:    "0 18 1-7 * MON",
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   parseTime(t, "6:00PM"),
+		End:     parseTime(t, "11:00PM"),
+	}
+
+	_, err := store.AddSchedule(context.Background(), "backend-team", weekdays)
+	require.NoError(t, err)
+	_, err = store.AddSchedule(context.Background(), "backend-team", biweekly)
+	require.NoError(t, err)
+	_, err = store.AddSchedule(context.Background(), "frontend-team", firstMonday)
+	require.NoError(t, err)
+
+	pause := storage.Pause{
+		Reason: "migration",
+		Since:  time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		Until:  time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC),
+	}
+	_, err = store.PauseTeam(context.Background(), "frontend-team", pause)
+	require.NoError(t, err)
+}
+
+// answers collects the on-call answers of both teams over a few weeks.
+func answers(t *testing.T, store storage.Storage) []string {
+	t.Helper()
+
+	var got []string
+	for at := time.Date(2025, 4, 26, 0, 0, 0, 0, time.UTC); at.Before(time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)); at = at.Add(3 * time.Hour) {
+		for _, team := range []string{"backend-team", "frontend-team"} {
+			oncall, err := store.GetCurrentOncall(context.Background(), team, at)
+			if errors.Is(err, storage.ErrNoCoverage) {
+				continue
+			}
+			require.NoError(t, err)
+			got = append(got, team+"/"+at.Format(time.RFC3339)+"/"+oncall)
+		}
+	}
+
+	return got
+}
+
+func TestBackup_RoundTrip(t *testing.T) {
+	source := storage.NewMemoryStorage()
+	populate(t, source)
+
+	backup := serveBackup(t, newBackupServer(t, source))
+
+	var doc BackupDocument
+	require.NoError(t, json.Unmarshal(backup, &doc))
+	assert.Equal(t, BackupVersion, doc.Version)
+	require.Len(t, doc.Teams, 2)
+	assert.Equal(t, "Covers the EU business hours", doc.Teams[0].Schedules[0].Description)
+
+	// Restore into a wiped storage
+	target := storage.NewMemoryStorage()
+	rec := serveRestore(t, newBackupServer(t, target), RestoreReplace, backup)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp RestoreResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, 3, resp.Created)
+
+	want := answers(t, source)
+	require.NotEmpty(t, want)
+	assert.Equal(t, want, answers(t, target))
+
+	pause, found, err := target.GetPause(context.Background(), "frontend-team")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "migration", pause.Reason)
+	assert.True(t, pause.Until.Equal(time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC)))
+
+	// A second backup of the restored storage is the same document
+	again := serveBackup(t, newBackupServer(t, target))
+
+	var restored BackupDocument
+	require.NoError(t, json.Unmarshal(again, &restored))
+	assert.Equal(t, doc.Teams, restored.Teams)
+}
+
+func TestBackup_Groups(t *testing.T) {
+	source := storage.NewMemoryStorage()
+	populate(t, source)
+
+	_, found, err := source.SetGroup(context.Background(), "backend-team", storage.Group{Name: "storage", Members: []string{"Erin", "Frank"}})
+	require.NoError(t, err)
+	require.True(t, found)
+
+	weekend := storage.Schedule{
+		Name:       "Weekend",
+		Members:    []string{"Alice"},
+		MemberRefs: []string{storage.GroupPrefix + "storage", "Alice"},
+		Days:       []time.Weekday{time.Saturday, time.Sunday},
+		Anchor:     time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:      parseTime(t, "9:00AM"),
+		End:        parseTime(t, "5:00PM"),
+	}
+	_, err = source.AddSchedule(context.Background(), "backend-team", weekend)
+	require.NoError(t, err)
+
+	backup := serveBackup(t, newBackupServer(t, source))
+
+	var doc BackupDocument
+	require.NoError(t, json.Unmarshal(backup, &doc))
+	assert.Equal(t, []GroupRequest{{Name: "storage", Members: []string{"Erin", "Frank"}}}, doc.Teams[0].Groups)
+	assert.Equal(t, []string{"@group:storage", "Alice"}, doc.Teams[0].Schedules[2].Members)
+
+	target := storage.NewMemoryStorage()
+	rec := serveRestore(t, newBackupServer(t, target), RestoreReplace, backup)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	want := answers(t, source)
+	require.Contains(t, want, "backend-team/2025-05-03T09:00:00Z/Erin")
+	assert.Equal(t, want, answers(t, target))
+
+	groups, err := target.ListGroups(context.Background(), "backend-team")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, []string{"Erin", "Frank"}, groups[0].Members)
+}
+
+func TestRestore_MergeAndReplace(t *testing.T) {
+	source := storage.NewMemoryStorage()
+	populate(t, source)
+	backup := serveBackup(t, newBackupServer(t, source))
+
+	target := storage.NewMemoryStorage()
+	extra := storage.Schedule{
+		Name:    "Ops",
+		Members: []string{"Eve"},
+		Days:    []time.Weekday{time.Sunday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+	_, err := target.AddSchedule(context.Background(), "ops-team", extra)
+	require.NoError(t, err)
+	e := newBackupServer(t, target)
+
+	// Merge keeps the existing team and is idempotent
+	require.Equal(t, http.StatusOK, serveRestore(t, e, RestoreMerge, backup).Code)
+
+	rec := serveRestore(t, e, RestoreMerge, backup)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp RestoreResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, RestoreResponse{Mode: RestoreMerge, Skipped: 3}, resp)
+
+	names, err := target.ListTeams(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backend-team", "frontend-team", "ops-team"}, names)
+
+	// Replace drops it
+	require.Equal(t, http.StatusOK, serveRestore(t, e, RestoreReplace, backup).Code)
+
+	names, err = target.ListTeams(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backend-team", "frontend-team"}, names)
+}
+
+func TestRestore_InvalidDocumentChangesNothing(t *testing.T) {
+	target := storage.NewMemoryStorage()
+	populate(t, target)
+	e := newBackupServer(t, target)
+
+	doc := BackupDocument{
+		Version: BackupVersion,
+		Teams: []TeamDocument{{
+			Team:      "backend-team",
+			Schedules: []Request{{Name: "Broken", Members: []string{"Alice"}, Days: []string{"Someday"}, Start: "9:00AM", End: "5:00PM"}},
+		}},
+	}
+	body, err := json.Marshal(doc)
+	require.NoError(t, err)
+
+	rec := serveRestore(t, e, RestoreReplace, body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	names, err := target.ListTeams(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, names, 2)
+
+	rec = serveRestore(t, e, "overwrite", body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// BlackoutRequest represents the team blackout request.
+type BlackoutRequest struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// BlackoutResponse represents a blackout of a team.
+type BlackoutResponse struct {
+	ID        int64  `json:"id"`
+	Team      string `json:"team"`
+	Reason    string `json:"reason,omitempty"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	CreatedAt string `json:"created_at"`
+}
+
+// AddBlackout handles team blackout requests. Uncovered time inside a
+// blackout is expected, so it is neither alerted nor reported as a gap,
+// while the on-call lookup still answers from the schedules.
+func (h *Handler) AddBlackout(c echo.Context) error {
+	team := c.Param("team")
+
+	var req BlackoutRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	if req.Start == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "start is required"})
+	}
+	start, err := time.Parse(time.RFC3339, req.Start)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid start format, use RFC3339 format"})
+	}
+
+	if req.End == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end is required"})
+	}
+	end, err := time.Parse(time.RFC3339, req.End)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid end format, use RFC3339 format"})
+	}
+	if !end.After(start) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end must be after start"})
+	}
+	if !end.After(h.now()) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end must be in the future"})
+	}
+
+	ctx := c.Request().Context()
+
+	blackout, found, err := h.storage.AddBlackout(ctx, team, storage.Blackout{
+		Reason: req.Reason,
+		Start:  start.UTC(),
+		End:    end.UTC(),
+	})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add blackout to team %q: %w", team, err), "failed to add blackout")
+	}
+
+	if !found {
+		return h.teamNotFound(c, team, "team not found")
+	}
+
+	h.logger.Info("blackout added",
+		zap.String("team", team),
+		zap.String("reason", blackout.Reason),
+		zap.Time("start", blackout.Start),
+		zap.Time("end", blackout.End),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	return c.JSON(http.StatusCreated, newBlackoutResponse(team, blackout))
+}
+
+// ListBlackouts handles requests listing the blackouts of a team, ended ones included.
+func (h *Handler) ListBlackouts(c echo.Context) error {
+	team := c.Param("team")
+
+	blackouts, err := h.storage.ListBlackouts(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list blackouts of team %q: %w", team, err), "failed to list blackouts")
+	}
+
+	resp := make([]BlackoutResponse, 0, len(blackouts))
+	for _, blackout := range blackouts {
+		resp = append(resp, newBlackoutResponse(team, blackout))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CancelBlackout handles requests canceling a blackout of a team, whether or
+// not it started already.
+func (h *Handler) CancelBlackout(c echo.Context) error {
+	team := c.Param("team")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid blackout id"})
+	}
+
+	ctx := c.Request().Context()
+
+	canceled, err := h.storage.CancelBlackout(ctx, team, id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("cancel blackout %d of team %q: %w", id, team, err), "failed to cancel blackout")
+	}
+
+	if !canceled {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "blackout not found"})
+	}
+
+	h.logger.Info("blackout canceled", zap.String("team", team), zap.Int64("id", id), zap.String("actor", storage.ActorFrom(ctx)))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// newBlackoutResponse converts a blackout of the team.
+func newBlackoutResponse(team string, blackout storage.Blackout) BlackoutResponse {
+	return BlackoutResponse{
+		ID:        blackout.ID,
+		Team:      team,
+		Reason:    blackout.Reason,
+		Start:     blackout.Start.UTC().Format(time.RFC3339),
+		End:       blackout.End.UTC().Format(time.RFC3339),
+		CreatedAt: blackout.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
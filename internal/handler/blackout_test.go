@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newBlackoutServer(t *testing.T) (*echo.Echo, *fakeClock) {
+	t.Helper()
+
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.now = clock.Now
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.POST("/teams/:team/blackouts", h.AddBlackout, h.Authenticate(auth.RoleAdmin, "secret"))
+	e.GET("/teams/:team/blackouts", h.ListBlackouts)
+	e.DELETE("/teams/:team/blackouts/:id", h.CancelBlackout, h.Authenticate(auth.RoleAdmin, "secret"))
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, clock
+}
+
+// blackoutBackend adds a blackout to the backend team over the given window.
+func blackoutBackend(t *testing.T, e *echo.Echo, start, end time.Time) BlackoutResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/teams/backend-team/blackouts", BlackoutRequest{
+		Start:  start.Format(time.RFC3339),
+		End:    end.Format(time.RFC3339),
+		Reason: "new-year shutdown",
+	}, "secret")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var blackout BlackoutResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &blackout))
+
+	return blackout
+}
+
+func TestBlackout_ListCancel(t *testing.T) {
+	e, clock := newBlackoutServer(t)
+
+	later := blackoutBackend(t, e, clock.now.Add(48*time.Hour), clock.now.Add(72*time.Hour))
+	current := blackoutBackend(t, e, clock.now, clock.now.Add(8*time.Hour))
+	assert.Equal(t, "backend-team", current.Team)
+	assert.Equal(t, "new-year shutdown", current.Reason)
+	assert.Equal(t, "2026-03-02T09:00:00Z", current.Start)
+
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/blackouts", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var blackouts []BlackoutResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &blackouts))
+	require.Len(t, blackouts, 2)
+	assert.Equal(t, current.ID, blackouts[0].ID)
+	assert.Equal(t, later.ID, blackouts[1].ID)
+
+	// Blackouts do not remove coverage
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=2026-03-02T10:00:00Z", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var oncall OncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &oncall))
+	assert.NotEmpty(t, oncall.Oncall)
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/blackouts/"+strconv.FormatInt(current.ID, 10), nil, "secret")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/blackouts/"+strconv.FormatInt(current.ID, 10), nil, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/blackouts/latest", nil, "secret")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestBlackout_Validation(t *testing.T) {
+	e, clock := newBlackoutServer(t)
+
+	past := clock.now.Add(-time.Hour).Format(time.RFC3339)
+	now := clock.now.Format(time.RFC3339)
+	future := clock.now.Add(time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name   string
+		target string
+		req    BlackoutRequest
+		token  string
+		code   int
+	}{
+		{"no start", "/teams/backend-team/blackouts", BlackoutRequest{End: future}, "secret", http.StatusBadRequest},
+		{"invalid start", "/teams/backend-team/blackouts", BlackoutRequest{Start: "now", End: future}, "secret", http.StatusBadRequest},
+		{"no end", "/teams/backend-team/blackouts", BlackoutRequest{Start: now}, "secret", http.StatusBadRequest},
+		{"invalid end", "/teams/backend-team/blackouts", BlackoutRequest{Start: now, End: "tomorrow"}, "secret", http.StatusBadRequest},
+		{"end before start", "/teams/backend-team/blackouts", BlackoutRequest{Start: future, End: now}, "secret", http.StatusBadRequest},
+		{"ended", "/teams/backend-team/blackouts", BlackoutRequest{Start: past, End: now}, "secret", http.StatusBadRequest},
+		{"unknown team", "/teams/frontend-team/blackouts", BlackoutRequest{Start: now, End: future}, "secret", http.StatusNotFound},
+		{"no token", "/teams/backend-team/blackouts", BlackoutRequest{Start: now, End: future}, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPost, tt.target, tt.req, tt.token)
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
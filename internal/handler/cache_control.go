@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// uncoveredMaxAge is how long clients may cache on-call answers that name
+// nobody or come from failing storage, which a new schedule or recovered
+// storage may change at any time.
+const uncoveredMaxAge = 10 * time.Second
+
+// SetOncallMaxAge sets the longest clients are told to cache on-call answers
+// for.
+func (h *Handler) SetOncallMaxAge(maxAge time.Duration) {
+	h.oncallMaxAge = maxAge
+}
+
+// cacheOncall tells clients to cache the on-call answer for the schedules at
+// the time until the earliest instant it could change, the end of the current
+// shift or the start of the next one, handoffs and pins included, and no
+// longer than the configured maximum. Substitutes and unavailability are not
+// tied to shifts and only show up once the maximum runs out.
+func (h *Handler) cacheOncall(c echo.Context, schedules []storage.Schedule, at time.Time) {
+	limit := at.Add(h.oncallMaxAge)
+	h.cacheFor(c, storage.NextChange(schedules, at, limit).Sub(at))
+}
+
+// cacheUncovered tells clients to cache an answer naming nobody for a short
+// while only.
+func (h *Handler) cacheUncovered(c echo.Context) {
+	h.cacheFor(c, min(uncoveredMaxAge, h.oncallMaxAge))
+}
+
+// cacheFor sets the Cache-Control and Expires headers of a response clients
+// may cache for maxAge, in whole seconds.
+func (h *Handler) cacheFor(c echo.Context, maxAge time.Duration) {
+	seconds := max(maxAge/time.Second, 0)
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderCacheControl, fmt.Sprintf("max-age=%d", seconds))
+	header.Set("Expires", h.now().Add(seconds*time.Second).UTC().Format(http.TimeFormat))
+}
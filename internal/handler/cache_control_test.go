@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newCacheServer(t *testing.T) (*echo.Echo, *Handler, *fakeClock) {
+	t.Helper()
+
+	// Monday March 2, 2026
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC)}
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.now = clock.Now
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, h, clock
+}
+
+func TestGetSchedule_CacheControl(t *testing.T) {
+	e, h, clock := newCacheServer(t)
+
+	tests := []struct {
+		name   string
+		now    time.Time
+		maxAge time.Duration
+		want   string
+	}{
+		{"mid-shift capped", time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC), 5 * time.Minute, "max-age=300"},
+		{"mid-shift until its end", time.Date(2026, 3, 2, 16, 0, 0, 0, time.UTC), 2 * time.Hour, "max-age=3600"},
+		{"a minute before handoff", time.Date(2026, 3, 2, 16, 59, 0, 0, time.UTC), 5 * time.Minute, "max-age=60"},
+		{"seconds are rounded down", time.Date(2026, 3, 2, 16, 59, 0, 500_000_000, time.UTC), 5 * time.Minute, "max-age=59"},
+	}
+
+	for _, tt := range tests {
+		clock.now = tt.now
+		h.SetOncallMaxAge(tt.maxAge)
+
+		rec := serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=now", nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, tt.want, rec.Header().Get(echo.HeaderCacheControl), tt.name)
+	}
+
+	expires, err := http.ParseTime(serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=now", nil, "").Header().Get("Expires"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 2, 16, 59, 59, 0, time.UTC), expires)
+}
+
+func TestGetSchedule_CacheControlUncovered(t *testing.T) {
+	e, _, _ := newCacheServer(t)
+
+	for _, target := range []string{
+		// Nobody is on call after hours
+		"/schedule?team=backend-team&time=2026-03-02T20:00:00Z",
+		"/schedule?team=other-team&time=now",
+	} {
+		rec := serveJSON(e, http.MethodGet, target, nil, "")
+		require.Equal(t, http.StatusNotFound, rec.Code, target)
+		assert.Equal(t, "max-age=10", rec.Header().Get(echo.HeaderCacheControl), target)
+		assert.Equal(t, "Mon, 02 Mar 2026 16:00:10 GMT", rec.Header().Get("Expires"), target)
+	}
+}
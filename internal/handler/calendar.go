@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// icsHandoffTimeLayout is the layout of the handoff time in icsHandoffProperty.
+const icsHandoffTimeLayout = "150405Z"
+
+// icsTimeLayout is the UTC date-time layout used by iCalendar.
+const icsTimeLayout = "20060102T150405Z"
+
+// icsDateLayout is the date layout used by iCalendar.
+const icsDateLayout = "20060102"
+
+// icsAnchorProperty, icsRotationOffsetProperty, icsRotationProperty,
+// icsSplitProperty, icsRequiredCountProperty, icsHandoffProperty,
+// icsDayAssignmentsProperty and icsTeamMembersProperty carry the rotation of a schedule, which iCalendar
+// has no properties for, so imports rotate alike. Day assignments are
+// written as MO=Alice,TU=Bob, handoffs as MO=090000Z, in UTC, and schedules
+// using the members of their team as TRUE.
+const (
+	icsAnchorProperty         = "X-ONCALL-ANCHOR"
+	icsRotationOffsetProperty = "X-ONCALL-ROTATION-OFFSET"
+	icsRotationProperty       = "X-ONCALL-ROTATION"
+	icsSplitProperty          = "X-ONCALL-SPLIT"
+	icsRequiredCountProperty  = "X-ONCALL-REQUIRED-COUNT"
+	icsHandoffProperty        = "X-ONCALL-HANDOFF"
+	icsDayAssignmentsProperty = "X-ONCALL-DAY-ASSIGNMENTS"
+	icsTeamMembersProperty    = "X-ONCALL-TEAM-MEMBERS"
+)
+
+// pinnedMemberPrefix starts the description of a pinned occurrence.
+const pinnedMemberPrefix = "Pinned: "
+
+// icsWeekdays maps weekdays to their iCalendar BYDAY codes.
+var icsWeekdays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// ExportCalendar handles team calendar export requests in iCalendar format.
+// Each schedule becomes a recurring event. Cron schedules cannot be expressed
+// as an RRULE and are left out. Calendar clients cannot send headers, so the
+// feed is authenticated with a calendar token in the token query parameter;
+// a member token limits the feed to the schedules of the member, shown in
+// the zone of the member when they have one.
+func (h *Handler) ExportCalendar(c echo.Context) error {
+	teamName := c.Param("team")
+	ctx := c.Request().Context()
+
+	token, valid, err := h.calendarToken(ctx, teamName, c.QueryParam(calendarTokenParam))
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get calendar token of team %q: %w", teamName, err), "failed to validate calendar token")
+	}
+
+	if !valid {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Error: "a valid calendar token is required",
+			Code:  CodeInvalidCalendarToken,
+		})
+	}
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	schedules := team.Schedules
+	var timezone string
+	if token.Member != "" {
+		schedules = memberSchedules(schedules, token.Member)
+
+		loc, local, err := storage.MemberLocation(ctx, h.storage, token.Member)
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("get zone of member %q: %w", token.Member, err), "failed to retrieve member")
+		}
+		if local {
+			timezone = loc.String()
+		}
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Response().WriteHeader(http.StatusOK)
+
+	return writeCalendar(c.Response(), teamName, schedules, timezone, time.Now())
+}
+
+// writeCalendar writes the schedules of a team as an iCalendar document.
+// Times are always in UTC, and a non-empty timezone is the zone calendar
+// clients are asked to show them in.
+func writeCalendar(w io.Writer, team string, schedules []storage.Schedule, timezone string, now time.Time) error {
+	var b strings.Builder
+
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//oncall-schedule//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText(team))
+	if timezone != "" {
+		writeICSLine(&b, "X-WR-TIMEZONE:"+timezone)
+	}
+
+	for _, sched := range schedules {
+		start, rule, ok := calendarRecurrence(sched)
+		if !ok {
+			continue
+		}
+
+		uid := escapeICSText(fmt.Sprintf("%s/%s@oncall-schedule", team, sched.Name))
+
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+uid)
+		writeICSLine(&b, "DTSTAMP:"+now.UTC().Format(icsTimeLayout))
+		writeICSLine(&b, "DTSTART:"+start.Format(icsTimeLayout))
+		writeICSLine(&b, "DTEND:"+start.Add(sched.End.Sub(sched.Start)).Format(icsTimeLayout))
+		writeICSLine(&b, "RRULE:"+rule)
+		if !sched.Anchor.IsZero() {
+			writeICSLine(&b, icsAnchorProperty+":"+sched.Anchor.Format(icsDateLayout))
+		}
+		if sched.RotationOffset != 0 {
+			writeICSLine(&b, icsRotationOffsetProperty+":"+strconv.Itoa(sched.RotationOffset))
+		}
+		if sched.Manual {
+			writeICSLine(&b, icsRotationProperty+":"+RotationManual)
+		}
+		if sched.Split > 1 {
+			writeICSLine(&b, icsSplitProperty+":"+strconv.Itoa(sched.Split))
+		}
+		if sched.RequiredCount > 1 {
+			writeICSLine(&b, icsRequiredCountProperty+":"+strconv.Itoa(sched.RequiredCount))
+		}
+		if sched.Handoff != nil {
+			writeICSLine(&b, icsHandoffProperty+":"+icsWeekdays[sched.Handoff.Day]+"="+sched.Handoff.Time.Format(icsHandoffTimeLayout))
+		}
+		if len(sched.DayAssignments) > 0 {
+			writeICSLine(&b, icsDayAssignmentsProperty+":"+escapeICSText(calendarDayAssignments(sched)))
+		}
+		if sched.TeamMembers {
+			writeICSLine(&b, icsTeamMembersProperty+":TRUE")
+		}
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(fmt.Sprintf("%s: %s", team, sched.Name)))
+		writeICSLine(&b, "DESCRIPTION:"+escapeICSText(calendarDescription(sched)))
+		if len(sched.Tags) > 0 {
+			writeICSLine(&b, "CATEGORIES:"+strings.Join(sched.Tags, ","))
+		}
+		writeICSLine(&b, "END:VEVENT")
+
+		writePinnedOccurrences(&b, team, uid, sched, now)
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writePinnedOccurrences writes the occurrences of a schedule changed by its
+// pins, as events overriding the recurring one on their RECURRENCE-ID.
+func writePinnedOccurrences(b *strings.Builder, team, uid string, sched storage.Schedule, now time.Time) {
+	for _, pin := range sched.Pins {
+		shift, ok := sched.NextShift(pin.Date.Add(-time.Nanosecond))
+		if !ok || !storage.PinDate(shift.Start).Equal(pin.Date) {
+			continue
+		}
+
+		writeICSLine(b, "BEGIN:VEVENT")
+		writeICSLine(b, "UID:"+uid)
+		writeICSLine(b, "DTSTAMP:"+now.UTC().Format(icsTimeLayout))
+		writeICSLine(b, "RECURRENCE-ID:"+shift.Start.Format(icsTimeLayout))
+		writeICSLine(b, "DTSTART:"+shift.Start.Format(icsTimeLayout))
+		writeICSLine(b, "DTEND:"+shift.End.Format(icsTimeLayout))
+		// The pinned member is joined by the others on duty for the shift
+		members := []string{pin.Member}
+		if duty, ok := sched.DutyAt(shift.Start); ok {
+			members = duty.Members()
+		}
+
+		writeICSLine(b, "SUMMARY:"+escapeICSText(fmt.Sprintf("%s: %s (%s)", team, sched.Name, strings.Join(members, ", "))))
+		writeICSLine(b, "DESCRIPTION:"+escapeICSText(pinnedMemberPrefix+pin.Member))
+		writeICSLine(b, "END:VEVENT")
+	}
+}
+
+// calendarDescription returns the event description of a schedule, its
+// description and notes followed by its members on the last line.
+func calendarDescription(sched storage.Schedule) string {
+	var parts []string
+	for _, part := range []string{sched.Description, sched.Notes} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	// Groups are kept as references, so imports rotate through them alike
+	members := sched.Members
+	if len(sched.MemberRefs) > 0 {
+		members = sched.MemberRefs
+	}
+
+	return strings.Join(append(parts, exportedMembersPrefix+strings.Join(members, ", ")), "\n\n")
+}
+
+// calendarDayAssignments returns the day assignments of a schedule in the
+// order of its days.
+func calendarDayAssignments(sched storage.Schedule) string {
+	pairs := make([]string, 0, len(sched.Days))
+	for _, day := range sched.Days {
+		if member, ok := sched.DayAssignments[day]; ok {
+			pairs = append(pairs, icsWeekdays[day]+"="+member)
+		}
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// calendarRecurrence returns the first occurrence and the RRULE of a schedule.
+// RFC 5545 counts DTSTART as the first occurrence, so it must match the rule.
+func calendarRecurrence(sched storage.Schedule) (time.Time, string, bool) {
+	switch {
+	case sched.Cron != "":
+		return time.Time{}, "", false
+	case sched.RRule != "":
+		spec, err := storage.ParseRRule(sched.RRule, sched.RRuleStart())
+		if err != nil {
+			return time.Time{}, "", false
+		}
+
+		first := spec.First()
+		if first.IsZero() {
+			return time.Time{}, "", false
+		}
+
+		return first, sched.RRule, true
+	}
+
+	if len(sched.Days) == 0 {
+		return time.Time{}, "", false
+	}
+
+	days := make([]string, 0, len(sched.Days))
+	covered := make(map[time.Weekday]bool)
+	for _, d := range sched.Days {
+		days = append(days, icsWeekdays[d])
+		covered[d] = true
+	}
+
+	first := sched.RRuleStart()
+	for !covered[first.Weekday()] {
+		first = first.AddDate(0, 0, 1)
+	}
+
+	return first, "FREQ=WEEKLY;BYDAY=" + strings.Join(days, ","), true
+}
+
+// writeICSLine writes a content line, folded at 75 octets as RFC 5545 requires.
+func writeICSLine(b *strings.Builder, line string) {
+	// Continuation lines start with a space, which counts towards the limit
+	limit := 75
+
+	for len(line) > limit {
+		cut := limit
+		// Do not split a multi-byte UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// escapeICSText escapes a TEXT property value.
+func escapeICSText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+	).Replace(s)
+}
@@ -0,0 +1,621 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// maxCalendarSize bounds the size of an imported iCalendar document.
+const maxCalendarSize = 1 << 20
+
+// icsLocalLayout is the floating or TZID date-time layout used by iCalendar.
+const icsLocalLayout = "20060102T150405"
+
+// exportedMembersPrefix starts the last line of the description of the events
+// this service exports.
+const exportedMembersPrefix = "Members: "
+
+// ImportResponse reports the outcome of a calendar import.
+type ImportResponse struct {
+	Imported []ImportedSchedule `json:"imported"`
+	Failed   []ImportFailure    `json:"failed"`
+}
+
+// ImportedSchedule is a schedule created from a calendar event.
+type ImportedSchedule struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+}
+
+// ImportFailure is a calendar event that could not be imported.
+type ImportFailure struct {
+	UID   string `json:"uid"`
+	Error string `json:"error"`
+}
+
+// icsProperty is a content line of an iCalendar component.
+type icsProperty struct {
+	Params map[string]string
+	Value  string
+}
+
+// icsEvent holds the properties of a VEVENT by name.
+type icsEvent map[string][]icsProperty
+
+// get returns the first property with the given name.
+func (e icsEvent) get(name string) (icsProperty, bool) {
+	props := e[name]
+	if len(props) == 0 {
+		return icsProperty{}, false
+	}
+
+	return props[0], true
+}
+
+// ImportCalendar handles iCalendar imports. Every recurring event of the
+// text/calendar body becomes a schedule of the team given by the team query
+// parameter. Events that cannot be translated are reported by UID without
+// stopping the others.
+//
+// Members come from the ATTENDEE list of an event, or from its SUMMARY when it
+// has no attendees. The repeatable member query parameter maps attendee emails,
+// attendee names or summaries to members as "key:Member", a summary may map to
+// several comma separated members.
+func (h *Handler) ImportCalendar(c echo.Context) error {
+	team := c.QueryParam("team")
+	if team == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "team is required"})
+	}
+
+	mapping, err := memberMapping(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	events, err := parseCalendar(io.LimitReader(c.Request().Body, maxCalendarSize+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	if frozen, err := h.rejectFrozen(c, team, "import calendar"); frozen {
+		return err
+	}
+
+	resp := ImportResponse{Imported: []ImportedSchedule{}, Failed: []ImportFailure{}}
+	ctx := h.withQuota(c.Request().Context(), storage.QuotaSchedules, team)
+
+	for i, event := range events {
+		uid := fmt.Sprintf("#%d", i+1)
+		if prop, ok := event.get("UID"); ok {
+			uid = prop.Value
+		}
+
+		// Changed occurrences of a series, such as pinned ones, are not imported
+		if _, ok := event.get("RECURRENCE-ID"); ok {
+			continue
+		}
+
+		req, err := eventRequest(event, team, mapping)
+		if err != nil {
+			resp.Failed = append(resp.Failed, ImportFailure{UID: uid, Error: err.Error()})
+			continue
+		}
+
+		rejected, err := h.importSchedule(ctx, team, req)
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("add imported schedule %q for team %q: %w", uid, team, err), "failed to create schedule")
+		}
+		if rejected != nil {
+			resp.Failed = append(resp.Failed, ImportFailure{UID: uid, Error: rejected.Error()})
+			continue
+		}
+
+		resp.Imported = append(resp.Imported, ImportedSchedule{UID: uid, Name: req.Name})
+	}
+
+	h.logger.Info("calendar imported",
+		zap.String("team", team),
+		zap.Int("imported", len(resp.Imported)),
+		zap.Int("failed", len(resp.Failed)),
+	)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// memberMapping parses the repeatable member query parameter of imports,
+// mapping keys written in lower case to members as "key:Member".
+func memberMapping(c echo.Context) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, entry := range c.QueryParams()["member"] {
+		i := strings.LastIndex(entry, ":")
+		if i <= 0 || i == len(entry)-1 {
+			return nil, fmt.Errorf("invalid member mapping %q, use 'key:Member' format", entry)
+		}
+		mapping[strings.ToLower(entry[:i])] = entry[i+1:]
+	}
+
+	return mapping, nil
+}
+
+// importSchedule creates the schedule of an import request through the same
+// checks as CreateSchedule. Requests the checks or the quota reject are
+// returned as rejected, for the import to report them without stopping,
+// while err is a storage failure.
+func (h *Handler) importSchedule(ctx context.Context, team string, req Request) (rejected, err error) {
+	schedule, err := h.parseRequest(&req)
+	if err != nil {
+		return err, nil
+	}
+
+	observer, found, err := h.observerIn(ctx, team, schedule.Members)
+	if err != nil {
+		return nil, fmt.Errorf("list members of team %q: %w", team, err)
+	}
+	if found {
+		return errors.New(observerMessage(observer, team)), nil
+	}
+
+	group, found, err := h.unknownGroup(ctx, team, schedule.MemberRefs)
+	if err != nil {
+		return nil, fmt.Errorf("list groups of team %q: %w", team, err)
+	}
+	if found {
+		return errors.New(unknownGroupMessage(group, team)), nil
+	}
+
+	if _, err := h.storage.AddSchedule(ctx, team, schedule); err != nil {
+		// The schedules past the quota or named like another one are
+		// reported like the ones failing to translate
+		var quotaErr *storage.QuotaError
+		if errors.As(err, &quotaErr) {
+			return quotaErr, nil
+		}
+		if errors.Is(err, storage.ErrScheduleExists) {
+			return err, nil
+		}
+		return nil, err
+	}
+
+	change := notify.NewEvent(notify.KindScheduleChange, team, time.Now())
+	change.Schedule = req.Name
+	change.Change = notify.ChangeCreated
+	h.events.Publish(change)
+
+	return nil, nil
+}
+
+// eventRequest translates a recurring event into a schedule creation request.
+// Weekly events become day schedules, every other rule is kept as an RRULE.
+// Times are converted to UTC, which schedules are evaluated in.
+func eventRequest(event icsEvent, team string, mapping map[string]string) (Request, error) {
+	req := Request{Team: team}
+
+	summary, _ := event.get("SUMMARY")
+	req.Name = unescapeICSText(summary.Value)
+	if req.Name == "" {
+		return Request{}, errors.New("SUMMARY is required")
+	}
+
+	dtstart, ok := event.get("DTSTART")
+	if !ok {
+		return Request{}, errors.New("DTSTART is required")
+	}
+	dtend, ok := event.get("DTEND")
+	if !ok {
+		return Request{}, errors.New("DTEND is required")
+	}
+
+	start, err := parseICSTime(dtstart)
+	if err != nil {
+		return Request{}, fmt.Errorf("invalid DTSTART: %w", err)
+	}
+	end, err := parseICSTime(dtend)
+	if err != nil {
+		return Request{}, fmt.Errorf("invalid DTEND: %w", err)
+	}
+
+	utcStart, utcEnd := start.UTC(), end.UTC()
+	if !utcEnd.After(utcStart) {
+		return Request{}, errors.New("DTEND must be after DTSTART")
+	}
+	if dayShift(utcStart, utcEnd) != 0 {
+		return Request{}, errors.New("events must end before midnight in UTC")
+	}
+	req.Start = utcStart.Format(time.Kitchen)
+	req.End = utcEnd.Format(time.Kitchen)
+
+	rrule, ok := event.get("RRULE")
+	if !ok {
+		return Request{}, errors.New("only recurring events can be imported")
+	}
+
+	// The weekdays of the rule are in the timezone of DTSTART
+	shift := dayShift(start, utcStart)
+
+	if days, ok := weeklyDays(rrule.Value, start.Weekday()); ok {
+		for _, day := range days {
+			req.Days = append(req.Days, time.Weekday((int(day)+shift+7)%7).String())
+		}
+	} else {
+		if shift != 0 {
+			return Request{}, errors.New("rules other than weekly ones must start on the same day in UTC")
+		}
+		req.RRule = rrule.Value
+	}
+
+	// The rotation starts at the first event unless the calendar was exported by this service
+	req.Anchor = utcStart.Format(time.DateOnly)
+	if prop, ok := event.get(icsAnchorProperty); ok {
+		anchor, err := time.Parse(icsDateLayout, prop.Value)
+		if err != nil {
+			return Request{}, fmt.Errorf("invalid %s: %w", icsAnchorProperty, err)
+		}
+		req.Anchor = anchor.Format(time.DateOnly)
+	}
+	if prop, ok := event.get(icsRotationOffsetProperty); ok {
+		offset, err := strconv.Atoi(prop.Value)
+		if err != nil {
+			return Request{}, fmt.Errorf("invalid %s: %w", icsRotationOffsetProperty, err)
+		}
+		req.RotationOffset = offset
+	}
+	if prop, ok := event.get(icsRotationProperty); ok {
+		req.Rotation = strings.ToLower(prop.Value)
+	}
+	if prop, ok := event.get(icsSplitProperty); ok {
+		split, err := strconv.Atoi(prop.Value)
+		if err != nil {
+			return Request{}, fmt.Errorf("invalid %s: %w", icsSplitProperty, err)
+		}
+		req.Split = split
+	}
+	if prop, ok := event.get(icsRequiredCountProperty); ok {
+		count, err := strconv.Atoi(prop.Value)
+		if err != nil {
+			return Request{}, fmt.Errorf("invalid %s: %w", icsRequiredCountProperty, err)
+		}
+		req.RequiredCount = count
+	}
+	if prop, ok := event.get(icsHandoffProperty); ok {
+		handoff, err := eventHandoff(prop)
+		if err != nil {
+			return Request{}, fmt.Errorf("invalid %s: %w", icsHandoffProperty, err)
+		}
+		req.Handoff = handoff
+	}
+
+	if prop, ok := event.get(icsDayAssignmentsProperty); ok {
+		assignments, err := eventDayAssignments(prop, shift)
+		if err != nil {
+			return Request{}, fmt.Errorf("invalid %s: %w", icsDayAssignmentsProperty, err)
+		}
+		req.Assignment = AssignmentFixed
+		req.DayAssignments = assignments
+
+		return req, nil
+	}
+
+	if prop, ok := event.get(icsTeamMembersProperty); ok && strings.EqualFold(prop.Value, "TRUE") {
+		req.UseTeamMembers = true
+
+		return req, nil
+	}
+
+	members, err := eventMembers(event, req.Name, mapping)
+	if err != nil {
+		return Request{}, err
+	}
+	req.Members = members
+
+	return req, nil
+}
+
+// eventDayAssignments parses the day assignments of an event, shifting their
+// days like the rule's into UTC.
+func eventDayAssignments(prop icsProperty, shift int) (map[string]string, error) {
+	assignments := make(map[string]string)
+
+	for _, pair := range strings.Split(unescapeICSText(prop.Value), ",") {
+		code, member, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not a DAY=Member pair", pair)
+		}
+
+		day, ok := icsWeekday(strings.TrimSpace(code))
+		if !ok {
+			return nil, fmt.Errorf("invalid day %q", code)
+		}
+		assignments[time.Weekday((int(day)+shift+7)%7).String()] = strings.TrimSpace(member)
+	}
+
+	return assignments, nil
+}
+
+// eventHandoff parses the handoff of an event, written as MO=090000Z.
+func eventHandoff(prop icsProperty) (*Handoff, error) {
+	code, value, ok := strings.Cut(prop.Value, "=")
+	if !ok {
+		return nil, fmt.Errorf("%q is not a DAY=time pair", prop.Value)
+	}
+
+	day, ok := icsWeekday(strings.TrimSpace(code))
+	if !ok {
+		return nil, fmt.Errorf("invalid day %q", code)
+	}
+
+	clock, err := time.Parse(icsHandoffTimeLayout, strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q", value)
+	}
+
+	return &Handoff{Day: day.String(), Time: clock.Format(time.Kitchen)}, nil
+}
+
+// dayShift returns by how many days the date of b, in its location, is after
+// the date of a, in its location.
+func dayShift(a, b time.Time) int {
+	da := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	db := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+
+	return int(db.Sub(da).Hours() / 24)
+}
+
+// weeklyDays returns the weekdays of a rule recurring every week on fixed
+// days, which is exactly what a day schedule covers. Rules with any other
+// part, such as an interval or an end, are not weekly ones.
+func weeklyDays(rule string, fallback time.Weekday) ([]time.Weekday, bool) {
+	var (
+		weekly bool
+		days   []time.Weekday
+	)
+
+	for _, part := range strings.Split(strings.TrimPrefix(rule, "RRULE:"), ";") {
+		key, value, _ := strings.Cut(part, "=")
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			weekly = strings.EqualFold(value, "WEEKLY")
+		case "INTERVAL":
+			if n, err := strconv.Atoi(value); err != nil || n != 1 {
+				return nil, false
+			}
+		case "WKST":
+		case "BYDAY":
+			for _, code := range strings.Split(value, ",") {
+				day, ok := icsWeekday(code)
+				if !ok {
+					return nil, false
+				}
+				days = append(days, day)
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	if !weekly {
+		return nil, false
+	}
+
+	if len(days) == 0 {
+		days = []time.Weekday{fallback}
+	}
+
+	return days, true
+}
+
+// icsWeekday parses a BYDAY code without an ordinal.
+func icsWeekday(code string) (time.Weekday, bool) {
+	for day, c := range icsWeekdays {
+		if strings.EqualFold(code, c) {
+			return time.Weekday(day), true
+		}
+	}
+
+	return 0, false
+}
+
+// eventMembers returns the members of an event. Attendees are mapped by email
+// or name and fall back to their name, then their email. Without attendees the
+// summary must be mapped, or the event must be one this service exported.
+func eventMembers(event icsEvent, summary string, mapping map[string]string) ([]string, error) {
+	var members []string
+
+	for _, attendee := range event["ATTENDEE"] {
+		email := attendee.Value
+		if len(email) >= len("mailto:") && strings.EqualFold(email[:len("mailto:")], "mailto:") {
+			email = email[len("mailto:"):]
+		}
+		name := attendee.Params["CN"]
+
+		switch {
+		case mapping[strings.ToLower(email)] != "":
+			members = append(members, mapping[strings.ToLower(email)])
+		case name != "" && mapping[strings.ToLower(name)] != "":
+			members = append(members, mapping[strings.ToLower(name)])
+		case name != "":
+			members = append(members, name)
+		case email != "":
+			members = append(members, email)
+		}
+	}
+
+	if len(members) > 0 {
+		return members, nil
+	}
+
+	if mapped, ok := mapping[strings.ToLower(summary)]; ok {
+		return splitMembers(mapped), nil
+	}
+
+	if description, ok := event.get("DESCRIPTION"); ok {
+		text := unescapeICSText(description.Value)
+		if list, ok := strings.CutPrefix(text[strings.LastIndex(text, "\n")+1:], exportedMembersPrefix); ok {
+			return splitMembers(list), nil
+		}
+	}
+
+	return nil, fmt.Errorf("no members, add attendees or map the summary %q with the member parameter", summary)
+}
+
+// splitMembers splits a comma separated list of members.
+func splitMembers(list string) []string {
+	var members []string
+	for _, member := range strings.Split(list, ",") {
+		if member = strings.TrimSpace(member); member != "" {
+			members = append(members, member)
+		}
+	}
+
+	return members
+}
+
+// parseICSTime parses a DATE-TIME property, which is either UTC, in the
+// timezone of its TZID parameter, or floating and then taken as UTC.
+func parseICSTime(prop icsProperty) (time.Time, error) {
+	if strings.EqualFold(prop.Params["VALUE"], "DATE") || len(prop.Value) == len(time.DateOnly)-2 {
+		return time.Time{}, errors.New("all-day events cannot be imported")
+	}
+
+	if strings.HasSuffix(prop.Value, "Z") {
+		return time.Parse(icsTimeLayout, prop.Value)
+	}
+
+	loc := time.UTC
+	if tzid := prop.Params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = time.LoadLocation(strings.Trim(tzid, `"`)); err != nil {
+			return time.Time{}, fmt.Errorf("unknown timezone %q", tzid)
+		}
+	}
+
+	return time.ParseInLocation(icsLocalLayout, prop.Value, loc)
+}
+
+// parseCalendar returns the events of an iCalendar document.
+func parseCalendar(r io.Reader) ([]icsEvent, error) {
+	lines, err := unfoldICSLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, errors.New("body is not an iCalendar document")
+	}
+
+	var (
+		events []icsEvent
+		event  icsEvent
+		// depth counts the components nested in the current event, e.g. alarms
+		depth int
+	)
+
+	for _, line := range lines {
+		name, prop := parseICSLine(line)
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(prop.Value, "VEVENT") && event == nil:
+			event = make(icsEvent)
+		case event == nil:
+		case name == "BEGIN":
+			depth++
+		case name == "END" && depth > 0:
+			depth--
+		case name == "END" && strings.EqualFold(prop.Value, "VEVENT"):
+			events = append(events, event)
+			event = nil
+		case depth == 0:
+			event[name] = append(event[name], prop)
+		}
+	}
+
+	return events, nil
+}
+
+// unfoldICSLines reads the content lines of a document, joining folded lines.
+func unfoldICSLines(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxCalendarSize+1)
+
+	var (
+		lines []string
+		size  int
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+
+		size += len(line) + 2
+		if size > maxCalendarSize {
+			return nil, fmt.Errorf("calendar is larger than %d bytes", maxCalendarSize)
+		}
+
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+
+	return lines, nil
+}
+
+// parseICSLine splits a content line into its upper-cased name and its property.
+func parseICSLine(line string) (string, icsProperty) {
+	prop := icsProperty{Params: make(map[string]string)}
+
+	// The value starts at the first colon outside a quoted parameter value
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		}
+		if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return strings.ToUpper(line), prop
+	}
+
+	prop.Value = line[colon+1:]
+
+	params := strings.Split(line[:colon], ";")
+	for _, param := range params[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.Params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+
+	return strings.ToUpper(params[0]), prop
+}
+
+// unescapeICSText reverses escapeICSText.
+func unescapeICSText(s string) string {
+	return strings.NewReplacer(
+		`\\`, `\`,
+		`\;`, ";",
+		`\,`, ",",
+		`\n`, "\n",
+		`\N`, "\n",
+	).Replace(s)
+}
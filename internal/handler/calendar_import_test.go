@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func importCalendar(t *testing.T, h *Handler, target, body string) (*httptest.ResponseRecorder, ImportResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, "text/calendar")
+	rec := httptest.NewRecorder()
+
+	require.NoError(t, h.ImportCalendar(echo.New().NewContext(req, rec)))
+
+	var resp ImportResponse
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+
+	return rec, resp
+}
+
+func TestImportCalendar_RoundTrip(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	ctx := context.Background()
+
+	_, err := store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:        "Weekday",
+		Description: "Covers the EU business hours",
+		Notes:       "Escalate to #sre-eu.\nMembers: are paged in order",
+		Members:     []string{"Alice", "Bob"},
+		Days:        []time.Weekday{time.Monday, time.Wednesday},
+		Anchor:      time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:       parseTime(t, "9:00AM"),
+		End:         parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Biweekly",
+		Members: []string{"Carol"},
+		RRule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,MO",
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   parseTime(t, "1:00PM"),
+		End:     parseTime(t, "9:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:           "Fixed",
+		Members:        []string{"Dana", "Erin"},
+		Days:           []time.Weekday{time.Thursday, time.Friday},
+		DayAssignments: map[time.Weekday]string{time.Thursday: "Dana", time.Friday: "Erin"},
+		Start:          parseTime(t, "6:00PM"),
+		End:            parseTime(t, "8:00PM"),
+	})
+	require.NoError(t, err)
+
+	team, err := store.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+
+	var ics bytes.Buffer
+	require.NoError(t, writeCalendar(&ics, "backend-team", team.Schedules, "", time.Now()))
+
+	rec, resp := importCalendar(t, h, "/schedules/import/ics?team=imported-team", ics.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, resp.Imported, 3)
+	assert.Empty(t, resp.Failed)
+
+	// Every hour of four weeks has the same answer in both teams
+	for at := time.Date(2025, 4, 28, 0, 30, 0, 0, time.UTC); at.Before(time.Date(2025, 5, 26, 0, 0, 0, 0, time.UTC)); at = at.Add(time.Hour) {
+		want, wantErr := store.GetCurrentOncall(ctx, "backend-team", at)
+		got, gotErr := store.GetCurrentOncall(ctx, "imported-team", at)
+
+		require.Equal(t, wantErr, gotErr, at.String())
+		require.Equal(t, want, got, at.String())
+	}
+}
+
+func TestImportCalendar_ReportsFailures(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"BEGIN:VEVENT",
+		"UID:berlin",
+		"SUMMARY:Early shift",
+		// 01:00 in Berlin is 23:00 UTC of the day before
+		"DTSTART;TZID=Europe/Berlin:20250113T010000",
+		"DTEND;TZID=Europe/Berlin:20250113T003000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:attendees",
+		"SUMMARY:Weekday",
+		"DTSTART;TZID=Europe/Berlin:20250113T100000",
+		"DTEND;TZID=Europe/Berlin:20250113T180000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,TU",
+		`ATTENDEE;CN="Alice Smith":mailto:alice@example.com`,
+		"ATTENDEE;CN=Bob:mailto:bob@example.com",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:summary",
+		"SUMMARY:Weekend",
+		"DTSTART:20250111T090000Z",
+		"DTEND:20250111T170000Z",
+		"RRULE:FREQ=WEEKLY;BYDAY=SA,SU",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:single",
+		"SUMMARY:Once",
+		"DTSTART:20250111T090000Z",
+		"DTEND:20250111T170000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:all-day",
+		"SUMMARY:Holiday",
+		"DTSTART;VALUE=DATE:20250111",
+		"DTEND;VALUE=DATE:20250112",
+		"RRULE:FREQ=YEARLY",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:unmapped",
+		"SUMMARY:Night",
+		"DTSTART:20250111T010000Z",
+		"DTEND:20250111T050000Z",
+		"RRULE:FREQ=DAILY",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	rec, resp := importCalendar(t, h, "/schedules/import/ics?team=backend-team&member=alice@example.com:Alice&member=weekend:Carol,Dave", ics)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, []ImportedSchedule{{UID: "attendees", Name: "Weekday"}, {UID: "summary", Name: "Weekend"}}, resp.Imported)
+
+	failed := make(map[string]string)
+	for _, f := range resp.Failed {
+		failed[f.UID] = f.Error
+	}
+	assert.Contains(t, failed["berlin"], "DTEND must be after DTSTART")
+	assert.Contains(t, failed["single"], "only recurring events")
+	assert.Contains(t, failed["all-day"], "all-day events")
+	assert.Contains(t, failed["unmapped"], "no members")
+
+	team, err := store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 2)
+
+	// Berlin is an hour ahead of UTC in January
+	weekday := team.Schedules[0]
+	assert.Equal(t, []string{"Alice", "Bob"}, weekday.Members)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday}, weekday.Days)
+	assert.Equal(t, parseTime(t, "9:00AM"), weekday.Start)
+	assert.Equal(t, parseTime(t, "5:00PM"), weekday.End)
+
+	assert.Equal(t, []string{"Carol", "Dave"}, team.Schedules[1].Members)
+}
+
+func TestImportCalendar_ShiftsDaysCrossingMidnight(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"UID:tehran",
+		"SUMMARY:Morning",
+		// 02:00 in Tehran is 22:30 UTC of the day before
+		"DTSTART;TZID=Asia/Tehran:20250414T020000",
+		"DTEND;TZID=Asia/Tehran:20250414T030000",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"ATTENDEE;CN=Alice:mailto:alice@example.com",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	rec, resp := importCalendar(t, h, "/schedules/import/ics?team=backend-team", ics)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, resp.Imported, 1)
+
+	team, err := store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Sunday}, team.Schedules[0].Days)
+	assert.Equal(t, parseTime(t, "10:30PM"), team.Schedules[0].Start)
+	assert.Equal(t, parseTime(t, "11:30PM"), team.Schedules[0].End)
+}
+
+func TestImportCalendar_InvalidRequests(t *testing.T) {
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"missing team", "/schedules/import/ics", "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"},
+		{"not a calendar", "/schedules/import/ics?team=backend-team", `{"name": "Weekday"}`},
+		{"invalid mapping", "/schedules/import/ics?team=backend-team&member=alice", "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _ := importCalendar(t, h, tt.target, tt.body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
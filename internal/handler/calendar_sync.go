@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/gcal"
+	"github.com/labstack/echo/v4"
+)
+
+// CalendarSyncResponse represents the status of the sync of the shifts of a
+// team to its Google calendar. The counts are those of the last successful
+// sync.
+type CalendarSyncResponse struct {
+	Team        string `json:"team"`
+	CalendarID  string `json:"calendar_id"`
+	State       string `json:"state"`
+	LastAttempt string `json:"last_attempt,omitempty"`
+	LastSuccess string `json:"last_success,omitempty"`
+	Events      int    `json:"events"`
+	Inserted    int    `json:"inserted"`
+	Updated     int    `json:"updated"`
+	Deleted     int    `json:"deleted"`
+	Error       string `json:"error,omitempty"`
+}
+
+// SetCalendarSync sets the syncer pushing the shifts to the Google calendars
+// of the teams.
+func (h *Handler) SetCalendarSync(s *gcal.Syncer) {
+	h.calendarSync = s
+}
+
+// CalendarSync handles requests for the status of the sync of a team to its
+// Google calendar. It only reads the status the syncer keeps, so it answers
+// while Google is unreachable.
+func (h *Handler) CalendarSync(c echo.Context) error {
+	team := c.Param("team")
+
+	if !h.calendarSync.Enabled() {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "google calendar sync is disabled"})
+	}
+
+	calendarID, found, err := h.storage.GoogleCalendar(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get google calendar of team %q: %w", team, err), "failed to retrieve calendar sync")
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "team has no google calendar"})
+	}
+
+	resp := CalendarSyncResponse{Team: team, CalendarID: calendarID, State: gcal.StatePending}
+
+	// A status of a calendar replaced since is pending for the new one
+	status, ok := h.calendarSync.Status(team)
+	if ok && status.CalendarID == calendarID {
+		resp.State = status.State
+		resp.LastAttempt = formatInstant(status.LastAttempt)
+		resp.LastSuccess = formatInstant(status.LastSuccess)
+		resp.Events = status.Events
+		resp.Inserted = status.Inserted
+		resp.Updated = status.Updated
+		resp.Deleted = status.Deleted
+		resp.Error = status.Error
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// formatInstant renders an instant in UTC, empty when it is zero.
+func formatInstant(at time.Time) string {
+	if at.IsZero() {
+		return ""
+	}
+
+	return at.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/gcal"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// countingCalendar is a Google calendar client counting the events inserted,
+// failing every call once err is set.
+type countingCalendar struct {
+	inserted int
+	err      error
+}
+
+func (c *countingCalendar) List(context.Context, string, string, time.Time) ([]gcal.Event, error) {
+	return nil, c.err
+}
+
+func (c *countingCalendar) Insert(context.Context, string, string, gcal.Event) error {
+	c.inserted++
+	return c.err
+}
+
+func (c *countingCalendar) Update(context.Context, string, string, gcal.Event) error {
+	return c.err
+}
+
+func (c *countingCalendar) Delete(context.Context, string, string) error {
+	return c.err
+}
+
+func newCalendarSyncServer(t *testing.T, syncer func(storage.Storage) *gcal.Syncer) (*echo.Echo, *gcal.Syncer) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	s := syncer(store)
+	h.SetCalendarSync(s)
+
+	e := echo.New()
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team/settings", h.GetTeamSettings)
+	e.PATCH("/teams/:team/settings", h.UpdateTeamSettings)
+	e.GET("/teams/:team/calendar/sync", h.CalendarSync)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", weekRequest("payments", "Days", "Alice", "Mon-Sun"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, s
+}
+
+func TestCalendarSync_Disabled(t *testing.T) {
+	e, _ := newCalendarSyncServer(t, func(s storage.Storage) *gcal.Syncer {
+		return gcal.NewSyncer(s, nil, config.GoogleCalendarConfig{}, zap.NewNop())
+	})
+
+	rec := serveJSON(e, http.MethodGet, "/teams/payments/calendar/sync", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "google calendar sync is disabled")
+}
+
+func TestCalendarSync(t *testing.T) {
+	client := &countingCalendar{}
+	e, syncer := newCalendarSyncServer(t, func(s storage.Storage) *gcal.Syncer {
+		return gcal.NewSyncer(s, client, config.GoogleCalendarConfig{Weeks: 1, Reconcile: time.Hour}, zap.NewNop())
+	})
+
+	rec := serveJSON(e, http.MethodGet, "/teams/payments/calendar/sync", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), "team has no google calendar")
+
+	// The calendar is a team setting, trimmed
+	calendarID := " payments@group.calendar.google.com "
+	rec = serveJSON(e, http.MethodPatch, "/teams/payments/settings", TeamSettingsRequest{GoogleCalendarID: &calendarID}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var settings TeamSettingsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &settings))
+	assert.Equal(t, TeamSettingsResponse{Team: "payments", GoogleCalendarID: "payments@group.calendar.google.com"}, settings)
+
+	// Nothing was pushed yet
+	var resp CalendarSyncResponse
+	rec = serveJSON(e, http.MethodGet, "/teams/payments/calendar/sync", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, CalendarSyncResponse{
+		Team:       "payments",
+		CalendarID: "payments@group.calendar.google.com",
+		State:      gcal.StatePending,
+	}, resp)
+
+	require.NoError(t, syncer.Run(context.Background()))
+
+	rec = serveJSON(e, http.MethodGet, "/teams/payments/calendar/sync", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, gcal.StateSynced, resp.State)
+	assert.Equal(t, client.inserted, resp.Events)
+	assert.Equal(t, client.inserted, resp.Inserted)
+	assert.NotEmpty(t, resp.LastSuccess)
+	assert.Empty(t, resp.Error)
+
+	// A failure keeps the counts of the last success
+	client.err = errors.New("google calendar answered 403: forbidden")
+	require.Error(t, syncer.Team(context.Background(), "payments", "payments@group.calendar.google.com"))
+
+	rec = serveJSON(e, http.MethodGet, "/teams/payments/calendar/sync", nil, "")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, gcal.StateFailed, resp.State)
+	assert.Equal(t, client.inserted, resp.Inserted)
+	assert.Contains(t, resp.Error, "403")
+
+	// Another calendar is pending until it is synced
+	other := "billing@group.calendar.google.com"
+	rec = serveJSON(e, http.MethodPatch, "/teams/payments/settings", TeamSettingsRequest{GoogleCalendarID: &other}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	resp = CalendarSyncResponse{}
+	rec = serveJSON(e, http.MethodGet, "/teams/payments/calendar/sync", nil, "")
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, CalendarSyncResponse{Team: "payments", CalendarID: other, State: gcal.StatePending}, resp)
+}
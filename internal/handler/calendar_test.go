@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExportCalendar_RRuleRoundTrip(t *testing.T) {
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	logger, _ := zap.NewDevelopment()
+	h := New(store, logger)
+
+	reqBody := Request{
+		Name:    "Biweekly",
+		Team:    "backend-team",
+		Members: []string{"Alice", "Bob"},
+		RRule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+		// Saturday, its week is the first one of the interval so the first
+		// occurrence is the Monday two weeks later
+		Anchor: "2025-04-26",
+		Start:  "9:00AM",
+		End:    "5:00PM",
+	}
+
+	body, err := json.Marshal(reqBody)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/schedule", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	require.NoError(t, h.CreateSchedule(c))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	token := createCalendarToken(t, h, "backend-team", CalendarTokenRequest{})
+
+	rec = exportCalendar(t, h, "backend-team", token.Token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/calendar")
+
+	ics := rec.Body.String()
+	assert.Contains(t, ics, "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE\r\n")
+	assert.Contains(t, ics, "DTSTART:20250505T090000Z\r\n")
+	assert.Contains(t, ics, "DTEND:20250505T170000Z\r\n")
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+}
+
+func TestExportCalendar_DaysAndCron(t *testing.T) {
+	var b bytes.Buffer
+
+	schedules := []storage.Schedule{
+		{
+			Name:    "Weekend",
+			Members: []string{"Alice"},
+			Days:    []time.Weekday{time.Saturday, time.Sunday},
+			Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC), // Monday
+			Start:   parseTime(t, "9:00AM"),
+			End:     parseTime(t, "5:00PM"),
+		},
+		{
+			Name:    "First Monday",
+			Members: []string{"Bob"},
+			Cron:    "0 9 1-7 * MON",
+			Start:   parseTime(t, "9:00AM"),
+			End:     parseTime(t, "5:00PM"),
+		},
+	}
+
+	now := time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, writeCalendar(&b, "backend-team", schedules, "", now))
+
+	ics := b.String()
+	assert.Contains(t, ics, "RRULE:FREQ=WEEKLY;BYDAY=SA,SU\r\n")
+	assert.Contains(t, ics, "DTSTART:20250503T090000Z\r\n")
+	assert.Equal(t, 1, strings.Count(ics, "BEGIN:VEVENT"))
+}
+
+func TestExportCalendar_RequiresToken(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	logger, _ := zap.NewDevelopment()
+	h := New(store, logger)
+
+	rec := exportCalendar(t, h, "unknown", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), CodeInvalidCalendarToken)
+}
+
+func TestWriteICSLine_Folding(t *testing.T) {
+	var b strings.Builder
+
+	writeICSLine(&b, "DESCRIPTION:"+strings.Repeat("a", 200))
+
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+	assert.Equal(t, "DESCRIPTION:"+strings.Repeat("a", 200), strings.ReplaceAll(strings.TrimSuffix(b.String(), "\r\n"), "\r\n ", ""))
+}
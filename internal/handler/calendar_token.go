@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CodeInvalidCalendarToken marks feed requests without a valid token.
+const CodeInvalidCalendarToken = "INVALID_CALENDAR_TOKEN"
+
+// calendarTokenParam is the query parameter calendar clients pass the token in.
+const calendarTokenParam = "token"
+
+// CalendarTokenRequest represents a calendar token request. A token for a
+// member only grants access to the schedules the member is part of.
+type CalendarTokenRequest struct {
+	Member string `json:"member,omitempty"`
+	// ExpiresAt is the RFC3339 instant the token expires at, it never expires when empty.
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
+
+// CalendarTokenResponse represents a created calendar token. The token itself
+// is only returned once, on creation.
+type CalendarTokenResponse struct {
+	ID        int64  `json:"id"`
+	Team      string `json:"team"`
+	Member    string `json:"member,omitempty"`
+	Token     string `json:"token"`
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CreateCalendarToken handles calendar token requests. Only the hash of the
+// generated token is stored.
+func (h *Handler) CreateCalendarToken(c echo.Context) error {
+	teamName := c.Param("team")
+
+	var req CalendarTokenRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	var expiresAt time.Time
+	if req.ExpiresAt != "" {
+		var err error
+		if expiresAt, err = time.Parse(time.RFC3339, req.ExpiresAt); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid expires_at format, use RFC3339 format"})
+		}
+		if !expiresAt.After(time.Now()) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "expires_at must be in the future"})
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	if req.Member != "" && len(memberSchedules(team.Schedules, req.Member)) == 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("%s is not a member of any schedule of the team", req.Member)})
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return h.internalError(c, fmt.Errorf("generate calendar token for team %q: %w", teamName, err), "failed to generate calendar token")
+	}
+	raw := hex.EncodeToString(secret)
+
+	token, err := h.storage.AddCalendarToken(ctx, storage.CalendarToken{
+		Team:      teamName,
+		Member:    req.Member,
+		Hash:      hashToken(raw),
+		ExpiresAt: expiresAt.UTC(),
+	})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add calendar token for team %q: %w", teamName, err), "failed to create calendar token")
+	}
+
+	h.logger.Info("calendar token created",
+		zap.Int64("id", token.ID),
+		zap.String("team", teamName),
+		zap.String("member", req.Member),
+	)
+
+	resp := CalendarTokenResponse{
+		ID:        token.ID,
+		Team:      token.Team,
+		Member:    token.Member,
+		Token:     raw,
+		URL:       fmt.Sprintf("/teams/%s/calendar.ics?%s=%s", url.PathEscape(teamName), calendarTokenParam, raw),
+		CreatedAt: token.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !token.ExpiresAt.IsZero() {
+		resp.ExpiresAt = token.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// DeleteCalendarToken handles calendar token revocation requests.
+func (h *Handler) DeleteCalendarToken(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid calendar token id"})
+	}
+
+	deleted, err := h.storage.DeleteCalendarToken(c.Request().Context(), c.Param("team"), id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("delete calendar token %d of team %q: %w", id, c.Param("team"), err), "failed to revoke calendar token")
+	}
+
+	if !deleted {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "calendar token not found"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// calendarToken looks up the token of a feed request for the team. It reports
+// false when the token is missing, unknown, expired, or for another team.
+func (h *Handler) calendarToken(ctx context.Context, team, raw string) (storage.CalendarToken, bool, error) {
+	if raw == "" {
+		return storage.CalendarToken{}, false, nil
+	}
+
+	token, found, err := h.storage.GetCalendarToken(ctx, hashToken(raw))
+	if err != nil || !found {
+		return storage.CalendarToken{}, false, err
+	}
+
+	if token.Team != team || !token.Valid(time.Now()) {
+		return storage.CalendarToken{}, false, nil
+	}
+
+	return token, true, nil
+}
+
+// hashToken returns the hex SHA-256 of a token, which is what storage holds.
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// memberSchedules returns the schedules the member is part of.
+func memberSchedules(schedules []storage.Schedule, member string) []storage.Schedule {
+	var result []storage.Schedule
+	for _, sched := range schedules {
+		if slices.Contains(sched.Members, member) {
+			result = append(result, sched)
+		}
+	}
+
+	return result
+}
+
+// RedactURI hides the calendar token in a request URI, so it never ends up in logs.
+func RedactURI(uri string) string {
+	u, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return uri
+	}
+
+	query := u.Query()
+	if !query.Has(calendarTokenParam) {
+		return uri
+	}
+
+	query.Set(calendarTokenParam, "REDACTED")
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func createCalendarToken(t *testing.T, h *Handler, team string, body CalendarTokenRequest) CalendarTokenResponse {
+	t.Helper()
+
+	rec := postCalendarToken(t, h, team, body)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp CalendarTokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func postCalendarToken(t *testing.T, h *Handler, team string, body CalendarTokenRequest) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/teams/"+team+"/calendar/token", bytes.NewReader(payload))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("team")
+	c.SetParamValues(team)
+
+	require.NoError(t, h.CreateCalendarToken(c))
+
+	return rec
+}
+
+func exportCalendar(t *testing.T, h *Handler, team, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/teams/"+team+"/calendar.ics?token="+url.QueryEscape(token), nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("team")
+	c.SetParamValues(team)
+
+	require.NoError(t, h.ExportCalendar(c))
+
+	return rec
+}
+
+func deleteCalendarToken(t *testing.T, h *Handler, team string, id int64) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("team", "id")
+	c.SetParamValues(team, strconv.FormatInt(id, 10))
+
+	require.NoError(t, h.DeleteCalendarToken(c))
+
+	return rec
+}
+
+func newCalendarTokenHandler(t *testing.T) (*Handler, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+
+	_, err := store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice", "Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Weekend",
+		Members: []string{"Carol"},
+		Days:    []time.Weekday{time.Saturday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "frontend-team", storage.Schedule{
+		Name:    "Weekday",
+		Members: []string{"Dave"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+
+	return New(store, zap.NewNop()), store
+}
+
+func TestCalendarToken_GenerateFetchRevoke(t *testing.T) {
+	h, store := newCalendarTokenHandler(t)
+
+	token := createCalendarToken(t, h, "backend-team", CalendarTokenRequest{})
+	assert.Len(t, token.Token, 64)
+	assert.Equal(t, "/teams/backend-team/calendar.ics?token="+token.Token, token.URL)
+	assert.Empty(t, token.ExpiresAt)
+
+	// Only the hash is stored
+	stored, found, err := store.GetCalendarToken(context.Background(), hashToken(token.Token))
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.NotEqual(t, token.Token, stored.Hash)
+
+	rec := exportCalendar(t, h, "backend-team", token.Token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "SUMMARY:backend-team: Weekday\r\n")
+	assert.Contains(t, rec.Body.String(), "SUMMARY:backend-team: Weekend\r\n")
+
+	// The token is only good for its own team
+	assert.Equal(t, http.StatusUnauthorized, exportCalendar(t, h, "frontend-team", token.Token).Code)
+
+	assert.Equal(t, http.StatusNoContent, deleteCalendarToken(t, h, "backend-team", token.ID).Code)
+	assert.Equal(t, http.StatusNotFound, deleteCalendarToken(t, h, "backend-team", token.ID).Code)
+
+	rec = exportCalendar(t, h, "backend-team", token.Token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), CodeInvalidCalendarToken)
+}
+
+func TestCalendarToken_Member(t *testing.T) {
+	h, _ := newCalendarTokenHandler(t)
+
+	token := createCalendarToken(t, h, "backend-team", CalendarTokenRequest{Member: "Carol"})
+	assert.Equal(t, "Carol", token.Member)
+
+	rec := exportCalendar(t, h, "backend-team", token.Token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "SUMMARY:backend-team: Weekend\r\n")
+	assert.NotContains(t, rec.Body.String(), "Weekday")
+	assert.NotContains(t, rec.Body.String(), "X-WR-TIMEZONE")
+}
+
+func TestCalendarToken_MemberTimezone(t *testing.T) {
+	h, store := newCalendarTokenHandler(t)
+
+	_, _, err := store.AddUser(context.Background(), storage.User{UserName: "Carol", Active: true, Timezone: "Asia/Tehran"})
+	require.NoError(t, err)
+
+	// Personal feeds ask clients to show the shifts in the zone of the member
+	token := createCalendarToken(t, h, "backend-team", CalendarTokenRequest{Member: "Carol"})
+	rec := exportCalendar(t, h, "backend-team", token.Token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "X-WR-TIMEZONE:Asia/Tehran\r\n")
+
+	token = createCalendarToken(t, h, "backend-team", CalendarTokenRequest{})
+	rec = exportCalendar(t, h, "backend-team", token.Token)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "X-WR-TIMEZONE")
+}
+
+func TestCalendarToken_Expired(t *testing.T) {
+	h, store := newCalendarTokenHandler(t)
+
+	token := createCalendarToken(t, h, "backend-team", CalendarTokenRequest{
+		ExpiresAt: time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+	})
+	assert.NotEmpty(t, token.ExpiresAt)
+	assert.Equal(t, http.StatusOK, exportCalendar(t, h, "backend-team", token.Token).Code)
+
+	// Tokens cannot be created with a past expiry, so store one directly
+	expired, err := store.AddCalendarToken(context.Background(), storage.CalendarToken{
+		Team:      "backend-team",
+		Hash:      hashToken("expired"),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.NotZero(t, expired.ID)
+
+	assert.Equal(t, http.StatusUnauthorized, exportCalendar(t, h, "backend-team", "expired").Code)
+}
+
+func TestCalendarToken_InvalidRequests(t *testing.T) {
+	h, _ := newCalendarTokenHandler(t)
+
+	tests := []struct {
+		name   string
+		team   string
+		body   CalendarTokenRequest
+		status int
+	}{
+		{"unknown team", "ops-team", CalendarTokenRequest{}, http.StatusNotFound},
+		{"unknown member", "backend-team", CalendarTokenRequest{Member: "Dave"}, http.StatusBadRequest},
+		{"invalid expiry", "backend-team", CalendarTokenRequest{ExpiresAt: "tomorrow"}, http.StatusBadRequest},
+		{"past expiry", "backend-team", CalendarTokenRequest{ExpiresAt: "2020-01-01T00:00:00Z"}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, postCalendarToken(t, h, tt.team, tt.body).Code)
+		})
+	}
+}
+
+func TestRedactURI(t *testing.T) {
+	assert.Equal(t, "/teams/backend-team/calendar.ics?token=REDACTED", RedactURI("/teams/backend-team/calendar.ics?token=secret"))
+	assert.NotContains(t, RedactURI("/teams/a/calendar.ics?lang=en&token=secret"), "secret")
+	assert.Equal(t, "/teams/backend-team/oncall?at=now", RedactURI("/teams/backend-team/oncall?at=now"))
+}
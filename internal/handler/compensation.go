@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// MaxRateClassLength bounds the rate classes of the compensation of a
+// schedule, in characters.
+const MaxRateClassLength = 64
+
+// maxCompensationRange bounds the range of a compensation report.
+const maxCompensationRange = 366 * 24 * time.Hour
+
+// Formats of a compensation report.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// compensationHeader is the header row of a compensation report in CSV.
+var compensationHeader = []string{"member", "rate_class", "hours", "weighted_hours"}
+
+// RateClassHours represents the time a member was on call at a rate class.
+// WeightedHours are the hours weighed by the multiplier of the schedule they
+// were on call for.
+type RateClassHours struct {
+	RateClass     string  `json:"rate_class"`
+	Hours         float64 `json:"hours"`
+	WeightedHours float64 `json:"weighted_hours"`
+}
+
+// MemberCompensation represents the time a member was on call, in total and
+// by rate class ordered by name.
+type MemberCompensation struct {
+	Member        string           `json:"member"`
+	Hours         float64          `json:"hours"`
+	WeightedHours float64          `json:"weighted_hours"`
+	RateClasses   []RateClassHours `json:"rate_classes"`
+}
+
+// CompensationResponse represents the time the members of a team were on
+// call by rate class, members ordered by name.
+type CompensationResponse struct {
+	Team    string               `json:"team"`
+	From    string               `json:"from"`
+	To      string               `json:"to"`
+	Weekend []string             `json:"weekend"`
+	Members []MemberCompensation `json:"members"`
+}
+
+// validateCompensation checks the compensation of a schedule names its rate
+// classes with positive multipliers, the weekend ones being optional.
+func validateCompensation(compensation *Compensation) error {
+	if compensation == nil {
+		return nil
+	}
+
+	if strings.TrimSpace(compensation.RateClass) == "" {
+		return fmt.Errorf("compensation rate_class must not be empty")
+	}
+	if compensation.Multiplier <= 0 {
+		return fmt.Errorf("compensation multiplier must be positive")
+	}
+
+	weekend := strings.TrimSpace(compensation.WeekendRateClass) != ""
+	if weekend != (compensation.WeekendMultiplier != 0) {
+		return fmt.Errorf("compensation weekend_rate_class and weekend_multiplier must be set together")
+	}
+	if compensation.WeekendMultiplier < 0 {
+		return fmt.Errorf("compensation weekend_multiplier must be positive")
+	}
+
+	for _, class := range []string{compensation.RateClass, compensation.WeekendRateClass} {
+		if utf8.RuneCountInString(class) > MaxRateClassLength {
+			return fmt.Errorf("compensation rate classes must be at most %d characters", MaxRateClassLength)
+		}
+	}
+
+	return nil
+}
+
+// TeamCompensation handles requests for the time the members of a team were
+// on call between from and to, by the rate class of the schedules they were
+// on call for. The stretches are resolved like the statistics, so time while
+// the team is paused is not counted and every member of multi-person shifts
+// is. Stretches are split at midnight in the tz zone, and the part on the
+// weekend days of the team is in the weekend rate class of its schedule, so
+// an overnight shift rolling into the weekend is paid at both. Schedules
+// without compensation are reported as storage.UntaggedRateClass. Given
+// format=csv the report is a CSV attachment, with a row for every member and
+// rate class.
+func (h *Handler) TeamCompensation(c echo.Context) error {
+	teamName := c.Param("team")
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = FormatJSON
+	}
+	if format != FormatJSON && format != FormatCSV {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("format must be %s or %s", FormatJSON, FormatCSV)})
+	}
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	from, err := h.parseTime(c.QueryParam("from"), "from", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	to, err := h.parseTime(c.QueryParam("to"), "to", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	if !from.Before(to) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must be before to"})
+	}
+	if to.Sub(from) > maxCompensationRange {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("range must not exceed %d days", int(maxCompensationRange.Hours()/24)),
+		})
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	pause, paused, err := h.storage.GetPause(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get pause of team %q: %w", teamName, err), "failed to retrieve team")
+	}
+	if !paused {
+		pause = storage.Pause{}
+	}
+
+	resp := h.compensation(teamName, team.Schedules, pause, from.In(loc), to.In(loc))
+
+	if format == FormatCSV {
+		startCSV(c, teamName+"-compensation.csv")
+
+		if err := writeCompensation(csv.NewWriter(c.Response()), resp); err != nil {
+			// The status is already sent, the truncated document tells the client
+			h.logger.Error("failed to write compensation report", zap.String("team", teamName), zap.Error(err))
+		}
+
+		return nil
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// compensation returns the time the members were on call during [from, to)
+// outside the pause, by rate class. The weekend is taken in the location of
+// from.
+func (h *Handler) compensation(
+	team string, schedules []storage.Schedule, pause storage.Pause, from, to time.Time,
+) CompensationResponse {
+	loc := from.Location()
+	wk := h.weeks.Of(team)
+
+	resp := CompensationResponse{
+		Team:    team,
+		From:    from.Format(time.RFC3339),
+		To:      to.Format(time.RFC3339),
+		Weekend: []string{},
+		Members: []MemberCompensation{},
+	}
+	for _, day := range wk.WeekendDays() {
+		resp.Weekend = append(resp.Weekend, day.String())
+	}
+
+	compensations := make(map[string]*storage.Compensation, len(schedules))
+	for _, sched := range schedules {
+		compensations[sched.ID] = sched.Compensation
+	}
+
+	type hours struct {
+		total    time.Duration
+		weighted float64
+	}
+	classes := make(map[string]map[string]*hours)
+
+	credit := func(member string, compensation *storage.Compensation, weekend bool, d time.Duration) {
+		if d <= 0 {
+			return
+		}
+
+		class, multiplier := compensation.Rate(weekend)
+		if classes[member] == nil {
+			classes[member] = make(map[string]*hours)
+		}
+		acc, ok := classes[member][class]
+		if !ok {
+			acc = new(hours)
+			classes[member][class] = acc
+		}
+		acc.total += d
+		acc.weighted += d.Hours() * multiplier
+	}
+
+	for _, duty := range storage.DutyTimeline(schedules, from, to) {
+		compensation := compensations[duty.ScheduleID]
+
+		for _, stretch := range outsidePause(duty.Shift, pause) {
+			start, end := stretch.Start.In(loc), stretch.End.In(loc)
+			weekend := wk.WeekendTime(start, end)
+
+			// Every member of a multi-person shift is on call for all of it
+			for _, member := range duty.Members() {
+				credit(member, compensation, false, end.Sub(start)-weekend)
+				credit(member, compensation, true, weekend)
+			}
+		}
+	}
+
+	for member, byClass := range classes {
+		mc := MemberCompensation{Member: member, RateClasses: make([]RateClassHours, 0, len(byClass))}
+		for class, acc := range byClass {
+			mc.RateClasses = append(mc.RateClasses, RateClassHours{
+				RateClass:     class,
+				Hours:         acc.total.Hours(),
+				WeightedHours: acc.weighted,
+			})
+			mc.Hours += acc.total.Hours()
+			mc.WeightedHours += acc.weighted
+		}
+		slices.SortFunc(mc.RateClasses, func(a, b RateClassHours) int {
+			return cmp.Compare(a.RateClass, b.RateClass)
+		})
+
+		resp.Members = append(resp.Members, mc)
+	}
+	slices.SortFunc(resp.Members, func(a, b MemberCompensation) int {
+		return cmp.Compare(a.Member, b.Member)
+	})
+
+	return resp
+}
+
+// writeCompensation writes the report as CSV, a row for every member and
+// rate class in the order of the report.
+func writeCompensation(w *csv.Writer, resp CompensationResponse) error {
+	if err := w.Write(compensationHeader); err != nil {
+		return err
+	}
+
+	for _, member := range resp.Members {
+		for _, class := range member.RateClasses {
+			if err := w.Write([]string{
+				member.Member,
+				class.RateClass,
+				strconv.FormatFloat(class.Hours, 'f', 2, 64),
+				strconv.FormatFloat(class.WeightedHours, 'f', 2, 64),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newCompensationServer creates the schedules of a team paid at different
+// rates: Alice on weekdays during business hours, Bob every weeknight from
+// 17:00 to 09:00 at the night rate, or the weekend one from Friday midnight,
+// and Charlie on weekend days without compensation.
+func newCompensationServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team/compensation", h.TeamCompensation)
+
+	day := quotaRequest("backend-team")
+	day.Name = "Day"
+	day.Members = []string{"Alice"}
+	day.Compensation = &Compensation{RateClass: "business", Multiplier: 1}
+
+	night := Request{
+		Name:    "Night",
+		Team:    "backend-team",
+		Members: []string{"Bob"},
+		Cron:    "0 17 * * 1-5",
+		Start:   "12:00AM",
+		End:     "4:00PM",
+		Compensation: &Compensation{
+			RateClass: "night", Multiplier: 1.5, WeekendRateClass: "weekend", WeekendMultiplier: 2,
+		},
+	}
+
+	weekend := quotaRequest("backend-team")
+	weekend.Name = "Weekend"
+	weekend.Members = []string{"Charlie"}
+	weekend.Days = []string{"Saturday", "Sunday"}
+
+	for _, req := range []Request{day, night, weekend} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	return e
+}
+
+func TestTeamCompensation(t *testing.T) {
+	e := newCompensationServer(t)
+
+	// The Friday night of Bob is 7 hours at the night rate and 9 at the weekend one
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/compensation?from=2025-04-28&to=2025-05-05", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var got bytes.Buffer
+	require.NoError(t, json.Indent(&got, rec.Body.Bytes(), "", "  "))
+	got.WriteByte('\n')
+
+	assertGolden(t, "compensation.json", got.Bytes())
+}
+
+func TestTeamCompensation_CSV(t *testing.T) {
+	e := newCompensationServer(t)
+
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/compensation?from=2025-04-28&to=2025-05-05&format=csv", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, `attachment; filename="backend-team-compensation.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+
+	assertGolden(t, "compensation.csv", rec.Body.Bytes())
+}
+
+func TestTeamCompensation_Weekend(t *testing.T) {
+	e := newCompensationServer(t)
+
+	// In Tehran the weekend of the night starting Friday 17:00 UTC starts at 20:30 UTC
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/compensation?from=2025-05-02T17:00:00Z&to=2025-05-03T09:00:00Z&tz=Asia/Tehran", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp CompensationResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Members, 1)
+	assert.Equal(t, MemberCompensation{
+		Member:        "Bob",
+		Hours:         16,
+		WeightedHours: 3.5*1.5 + 12.5*2,
+		RateClasses: []RateClassHours{
+			{RateClass: "night", Hours: 3.5, WeightedHours: 3.5 * 1.5},
+			{RateClass: "weekend", Hours: 12.5, WeightedHours: 12.5 * 2},
+		},
+	}, resp.Members[0])
+}
+
+func TestTeamCompensation_Invalid(t *testing.T) {
+	e := newCompensationServer(t)
+
+	tests := []struct {
+		name   string
+		target string
+		code   int
+	}{
+		{"unknown team", "/teams/frontend-team/compensation?from=2025-04-28&to=2025-05-05", http.StatusNotFound},
+		{"missing from", "/teams/backend-team/compensation?to=2025-05-05", http.StatusBadRequest},
+		{"reversed range", "/teams/backend-team/compensation?from=2025-05-05&to=2025-04-28", http.StatusBadRequest},
+		{"too long", "/teams/backend-team/compensation?from=2025-01-01&to=2026-03-01", http.StatusBadRequest},
+		{"unknown format", "/teams/backend-team/compensation?from=2025-04-28&to=2025-05-05&format=xml", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodGet, tt.target, nil, "")
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestCreateSchedule_InvalidCompensation(t *testing.T) {
+	e := newCompensationServer(t)
+
+	tests := []struct {
+		name         string
+		compensation Compensation
+	}{
+		{"missing rate class", Compensation{Multiplier: 1}},
+		{"blank rate class", Compensation{RateClass: " ", Multiplier: 1}},
+		{"missing multiplier", Compensation{RateClass: "business"}},
+		{"negative multiplier", Compensation{RateClass: "business", Multiplier: -1}},
+		{"weekend class alone", Compensation{RateClass: "business", Multiplier: 1, WeekendRateClass: "weekend"}},
+		{"weekend multiplier alone", Compensation{RateClass: "business", Multiplier: 1, WeekendMultiplier: 2}},
+		{"long rate class", Compensation{RateClass: strings.Repeat("a", MaxRateClassLength+1), Multiplier: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := quotaRequest("frontend-team")
+			req.Compensation = &tt.compensation
+
+			rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		})
+	}
+}
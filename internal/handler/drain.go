@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CodeShuttingDown is the error code of requests refused while the server shuts down.
+const CodeShuttingDown = "SHUTTING_DOWN"
+
+// ErrGoingAway is the cancellation cause of streams closed by a shutdown.
+// Stream handlers should tell their client to reconnect elsewhere when they
+// see it, e.g. with a final event or a WebSocket going-away close frame.
+var ErrGoingAway = errors.New("server is shutting down")
+
+// Drain phases, a server only ever moves forward through them.
+const (
+	drainServing int32 = iota
+	drainDraining
+	drainStopping
+)
+
+// Drain coordinates the graceful shutdown of the server. While draining the
+// server reports itself as not ready but keeps serving, so load balancers
+// stop sending traffic before anything is refused. Once stopping, new
+// requests are refused and long-running streams are closed.
+type Drain struct {
+	phase atomic.Int32
+
+	mu      sync.Mutex
+	next    int
+	streams map[int]context.CancelCauseFunc
+}
+
+// Ready reports whether the server accepts new traffic.
+func (d *Drain) Ready() bool {
+	return d.phase.Load() == drainServing
+}
+
+// Middleware refuses requests with a 503 and closes their connection once the
+// server is stopping. Requests racing the listener shutdown on kept-alive
+// connections end up here instead of being cut off.
+func (d *Drain) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if d.phase.Load() != drainStopping {
+				return next(c)
+			}
+
+			c.Response().Header().Set(echo.HeaderConnection, "close")
+			c.Response().Header().Set(echo.HeaderRetryAfter, "1")
+			return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: ErrGoingAway.Error(), Code: CodeShuttingDown})
+		}
+	}
+}
+
+// Stream registers a long-running stream, such as server-sent events or a
+// WebSocket, that would otherwise hold the shutdown for its whole timeout.
+// The returned context is canceled with ErrGoingAway once the server is
+// stopping, and done must be called when the stream ends.
+func (d *Drain) Stream(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.phase.Load() == drainStopping {
+		cancel(ErrGoingAway)
+		return ctx, func() {}
+	}
+
+	if d.streams == nil {
+		d.streams = make(map[int]context.CancelCauseFunc)
+	}
+
+	id := d.next
+	d.next++
+	d.streams[id] = cancel
+
+	return ctx, func() {
+		d.mu.Lock()
+		delete(d.streams, id)
+		d.mu.Unlock()
+
+		cancel(nil)
+	}
+}
+
+// Shutdown drains and stops e. It marks the server as not ready, waits delay
+// so load balancers notice, then refuses new requests, closes the streams,
+// and waits up to timeout for in-flight requests before closing the
+// remaining connections forcefully.
+func (d *Drain) Shutdown(ctx context.Context, e *echo.Echo, delay, timeout time.Duration) error {
+	d.Begin(ctx, delay)
+
+	return closeServer(ctx, timeout, e.Shutdown, e.Close)
+}
+
+// Begin drains the server without stopping any of its listeners. It marks
+// the server as not ready, waits delay so load balancers notice, then
+// refuses new requests and closes the streams. The listeners are shut down
+// after it, each on its own.
+func (d *Drain) Begin(ctx context.Context, delay time.Duration) {
+	d.phase.Store(drainDraining)
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+		}
+	}
+
+	d.stop()
+}
+
+// closeServer waits up to timeout for the in-flight requests of a server to
+// finish with shutdown, then closes its remaining connections with
+// forceClose.
+func closeServer(ctx context.Context, timeout time.Duration, shutdown func(context.Context) error, forceClose func() error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if err := shutdown(ctx); err != nil {
+		if closeErr := forceClose(); closeErr != nil {
+			return errors.Join(fmt.Errorf("failed to drain connections: %w", err), closeErr)
+		}
+		return fmt.Errorf("failed to drain connections: %w", err)
+	}
+
+	return nil
+}
+
+// stop moves to the stopping phase and closes every registered stream.
+func (d *Drain) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.phase.Store(drainStopping)
+
+	for id, cancel := range d.streams {
+		cancel(ErrGoingAway)
+		delete(d.streams, id)
+	}
+}
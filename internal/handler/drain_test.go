@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// drainServer is a live server with a slow route that blocks until release
+// is closed and a stream route that runs until the shutdown closes it.
+type drainServer struct {
+	e       *echo.Echo
+	h       *Handler
+	url     string
+	started chan struct{}
+	release chan struct{}
+}
+
+func newDrainServer(t *testing.T) *drainServer {
+	t.Helper()
+
+	s := &drainServer{
+		e:       echo.New(),
+		h:       New(storage.NewMemoryStorage(), zap.NewNop()),
+		started: make(chan struct{}, 2),
+		release: make(chan struct{}),
+	}
+	s.e.HideBanner = true
+	s.e.HidePort = true
+
+	s.e.Use(s.h.Drain().Middleware())
+	s.e.GET("/health", s.h.Health)
+	s.e.GET("/slow", func(c echo.Context) error {
+		s.started <- struct{}{}
+		<-s.release
+		return c.String(http.StatusOK, "done")
+	})
+	s.e.GET("/stream", func(c echo.Context) error {
+		ctx, done := s.h.Drain().Stream(c.Request().Context())
+		defer done()
+
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Flush()
+		s.started <- struct{}{}
+
+		<-ctx.Done()
+		if errors.Is(context.Cause(ctx), ErrGoingAway) {
+			_, err := io.WriteString(c.Response(), "event: goaway\n\n")
+			return err
+		}
+		return nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s.e.Listener = listener
+	s.url = "http://" + listener.Addr().String()
+
+	go func() { _ = s.e.Start("") }()
+	t.Cleanup(func() { _ = s.e.Close() })
+
+	return s
+}
+
+func TestDrain_FinishesInFlightAndRefusesNewRequests(t *testing.T) {
+	s := newDrainServer(t)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	slow := make(chan string, 1)
+	go func() {
+		resp, err := client.Get(s.url + "/slow")
+		if err != nil {
+			slow <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		slow <- string(body)
+	}()
+
+	stream := make(chan string, 1)
+	go func() {
+		resp, err := client.Get(s.url + "/stream")
+		if err != nil {
+			stream <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		stream <- string(body)
+	}()
+
+	<-s.started
+	<-s.started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- s.h.Drain().Shutdown(context.Background(), s.e, 200*time.Millisecond, 5*time.Second)
+	}()
+
+	// While draining the server still answers, but reports itself as not ready
+	require.Eventually(t, func() bool {
+		resp, err := client.Get(s.url + "/health")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, time.Second, 10*time.Millisecond)
+
+	// Once stopping, streams are told to go away and new requests are refused
+	assert.Equal(t, "event: goaway\n\n", <-stream)
+
+	resp, err := client.Get(s.url + "/health")
+	if err == nil {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "close", resp.Header.Get(echo.HeaderConnection))
+	}
+
+	close(s.release)
+	assert.Equal(t, "done", <-slow)
+	require.NoError(t, <-shutdown)
+}
+
+func TestDrain_TimeoutClosesStuckRequests(t *testing.T) {
+	s := newDrainServer(t)
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	slow := make(chan error, 1)
+	go func() {
+		resp, err := client.Get(s.url + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		slow <- err
+	}()
+	<-s.started
+
+	err := s.h.Drain().Shutdown(context.Background(), s.e, 0, 50*time.Millisecond)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The stuck request was cut off instead of holding the shutdown
+	assert.Error(t, <-slow)
+	close(s.release)
+}
+
+func TestDrain_StreamAfterStopIsClosedRightAway(t *testing.T) {
+	var d Drain
+	d.stop()
+
+	ctx, done := d.Stream(context.Background())
+	defer done()
+
+	assert.ErrorIs(t, context.Cause(ctx), ErrGoingAway)
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// dryRunPath is the route of notification dry runs, which stays open in
+// read-only mode as nothing is sent or recorded.
+const dryRunPath = "/teams/:team/notifications/dry-run"
+
+// maxDryRunRange bounds the window of a notification dry run.
+const maxDryRunRange = 31 * 24 * time.Hour
+
+// DryRunRequest represents a notification dry run request. From and To take
+// the same forms as the time query parameters.
+type DryRunRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// DryRunMessage represents a message a dry run found, as its notifier would
+// have delivered it.
+type DryRunMessage struct {
+	At        string `json:"at"`
+	Kind      string `json:"kind"`
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"`
+	Subject   string `json:"subject,omitempty"`
+	Body      string `json:"body"`
+}
+
+// DryRunResponse represents the messages a team would have got over a window.
+type DryRunResponse struct {
+	Team     string          `json:"team"`
+	From     string          `json:"from"`
+	To       string          `json:"to"`
+	Messages []DryRunMessage `json:"messages"`
+}
+
+// SetDryRun sets the dry run notification previews come from.
+func (h *Handler) SetDryRun(dryRun *notify.DryRun) {
+	h.dryRun = dryRun
+}
+
+// NotificationDryRun handles notification dry run requests. The handoffs,
+// gaps, reminders and digests the workers would have sent the team over the
+// window are rendered by the configured notifiers and returned, and nothing
+// is delivered or recorded as sent.
+func (h *Handler) NotificationDryRun(c echo.Context) error {
+	teamName := c.Param("team")
+
+	var req DryRunRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	if req.From == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from is required"})
+	}
+	if req.To == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "to is required"})
+	}
+
+	from, err := h.parseTime(req.From, "from", time.UTC)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	to, err := h.parseTime(req.To, "to", time.UTC)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	if !from.Before(to) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must be before to"})
+	}
+	if to.Sub(from) > maxDryRunRange {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("range must not exceed %d days", int(maxDryRunRange.Hours()/24)),
+		})
+	}
+
+	ctx := c.Request().Context()
+
+	_, err = h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	messages, err := h.dryRun.Run(ctx, teamName, from, to)
+	if err != nil {
+		return h.internalError(c, fmt.Errorf("dry run notifications of team %q: %w", teamName, err), "failed to run notifications")
+	}
+
+	resp := DryRunResponse{
+		Team:     teamName,
+		From:     from.UTC().Format(time.RFC3339),
+		To:       to.UTC().Format(time.RFC3339),
+		Messages: make([]DryRunMessage, 0, len(messages)),
+	}
+	for _, message := range messages {
+		resp.Messages = append(resp.Messages, DryRunMessage{
+			At:        message.Event.At.UTC().Format(time.RFC3339),
+			Kind:      string(message.Event.Kind),
+			Channel:   message.Delivery.Channel,
+			Recipient: message.Delivery.Recipient,
+			Subject:   message.Delivery.Subject,
+			Body:      message.Delivery.Body,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
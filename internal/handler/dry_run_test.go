@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// countingClient counts the requests notifiers send through it.
+type countingClient struct {
+	sends atomic.Int64
+}
+
+func (c *countingClient) Do(*http.Request) (*http.Response, error) {
+	c.sends.Add(1)
+	return nil, http.ErrHandlerTimeout
+}
+
+// newDryRunServer returns a server previewing the Telegram messages of the
+// backend team, along with the client the messages would be sent through.
+func newDryRunServer(t *testing.T) (*echo.Echo, *Handler, *countingClient) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	cfg := &config.Config{}
+	client := &countingClient{}
+
+	telegram, err := notify.NewTelegram(client, config.TelegramConfig{
+		Token: "123:secret",
+		Chats: map[string]string{"backend-team": "-1001"},
+	})
+	require.NoError(t, err)
+
+	dispatcher := notify.NewDispatcher([]notify.Notifier{telegram}, cfg, zap.NewNop())
+	digest, err := notify.NewDigest(store, dispatcher, nil, cfg, zap.NewNop())
+	require.NoError(t, err)
+
+	e := echo.New()
+	h := New(store, zap.NewNop())
+	h.SetDryRun(notify.NewDryRun(notify.NewWatcher(store, dispatcher, nil, cfg, zap.NewNop()), digest, dispatcher))
+	e.Use(h.ReadOnly().Middleware())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.POST(dryRunPath, h.NotificationDryRun, h.Authenticate(auth.RoleAdmin, "secret"))
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, h, client
+}
+
+func TestNotificationDryRun(t *testing.T) {
+	e, h, client := newDryRunServer(t)
+
+	// Previews stay available in read-only mode, as nothing is sent
+	h.ReadOnly().Set(true, "maintenance")
+
+	req := DryRunRequest{From: "2026-03-02T00:00:00Z", To: "2026-03-03T00:00:00Z"}
+	rec := serveJSON(e, http.MethodPost, "/teams/backend-team/notifications/dry-run", req, "secret")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp DryRunResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "backend-team", resp.Team)
+	assert.Equal(t, "2026-03-02T00:00:00Z", resp.From)
+	assert.Equal(t, "2026-03-03T00:00:00Z", resp.To)
+	assert.Equal(t, []DryRunMessage{
+		{
+			At:        "2026-03-02T09:00:00Z",
+			Kind:      "handoff",
+			Channel:   "telegram",
+			Recipient: "-1001",
+			Body:      "backend-team: Bob is now on call for Weekday until Mon 17:00 UTC.",
+		},
+		{
+			At:        "2026-03-02T17:00:00Z",
+			Kind:      "gap",
+			Channel:   "telegram",
+			Recipient: "-1001",
+			Body:      "backend-team: nobody is on call since the shift of Bob ended.",
+		},
+	}, resp.Messages)
+
+	assert.Zero(t, client.sends.Load())
+}
+
+func TestNotificationDryRun_Validation(t *testing.T) {
+	e, _, client := newDryRunServer(t)
+
+	for name, req := range map[string]DryRunRequest{
+		"no from":       {To: "2026-03-03T00:00:00Z"},
+		"no to":         {From: "2026-03-02T00:00:00Z"},
+		"invalid from":  {From: "yesterday", To: "2026-03-03T00:00:00Z"},
+		"reversed":      {From: "2026-03-03T00:00:00Z", To: "2026-03-02T00:00:00Z"},
+		"too long":      {From: "2026-03-01", To: "2026-04-02"},
+		"empty window":  {From: "2026-03-02", To: "2026-03-02"},
+		"invalid to":    {From: "2026-03-02", To: "tomorrow"},
+		"no timestamps": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPost, "/teams/backend-team/notifications/dry-run", req, "secret")
+			assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		})
+	}
+
+	req := DryRunRequest{From: "2026-03-02", To: "2026-03-03"}
+	rec := serveJSON(e, http.MethodPost, "/teams/backend-team/notifications/dry-run", req, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/teams/frontend-team/notifications/dry-run", req, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	assert.Zero(t, client.sends.Load())
+}
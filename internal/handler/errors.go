@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// ErrorHandler returns the error handler of the server. Errors turning into
+// 5xx responses are answered with their status text only, so internal error
+// text never reaches clients, the rest are handled as echo does.
+func ErrorHandler(e *echo.Echo) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		code := http.StatusInternalServerError
+		if he, ok := err.(*echo.HTTPError); ok {
+			code = he.Code
+		}
+
+		if code < http.StatusInternalServerError {
+			e.DefaultHTTPErrorHandler(err, c)
+			return
+		}
+
+		var werr error
+		if c.Request().Method == http.MethodHead {
+			werr = c.NoContent(code)
+		} else {
+			werr = c.JSON(code, ErrorResponse{Error: http.StatusText(code)})
+		}
+		if werr != nil {
+			e.Logger.Error(werr)
+		}
+	}
+}
+
+// log returns the logger of the request, which carries its ID so the log
+// lines of a failure match the correlation ID of its response.
+func (h *Handler) log(c echo.Context) *zap.Logger {
+	return h.logger.With(zap.String("request_id", c.Response().Header().Get(echo.HeaderXRequestID)))
+}
+
+// internalError logs err along with the request ID and responds with a 500
+// carrying message only.
+func (h *Handler) internalError(c echo.Context, err error, message string) error {
+	h.log(c).Error(message, zap.Error(err))
+
+	return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message})
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// dbErrorText is the text of the database errors of failingStorage, which
+// must never reach clients.
+const dbErrorText = `ERROR: relation "schedules" does not exist (SQLSTATE 42P01)`
+
+// failingStorage fails the writes of schedules with a database error.
+type failingStorage struct {
+	storage.Storage
+}
+
+func (failingStorage) AddSchedule(context.Context, string, storage.Schedule) (string, error) {
+	return "", errors.New(dbErrorText)
+}
+
+func newErrorServer(t *testing.T) (*echo.Echo, *observer.ObservedLogs) {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.ErrorLevel)
+
+	e := echo.New()
+	e.JSONSerializer = Serializer{}
+	e.HTTPErrorHandler = ErrorHandler(e)
+	e.Use(middleware.RequestID())
+
+	h := New(failingStorage{storage.NewMemoryStorage()}, zap.New(core))
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/fail", func(echo.Context) error {
+		return errors.New("dial tcp 10.0.0.5:5432: connect: connection refused")
+	})
+
+	return e, logs
+}
+
+// requireCorrelated checks a 5xx response carries the request ID as its
+// correlation ID and returns its error.
+func requireCorrelated(t *testing.T, rec *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	requestID := rec.Header().Get(echo.HeaderXRequestID)
+	require.NotEmpty(t, requestID)
+	assert.Equal(t, requestID, resp.CorrelationID)
+
+	return resp
+}
+
+func TestStorageFailure_CorrelationID(t *testing.T) {
+	e, logs := newErrorServer(t)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	resp := requireCorrelated(t, rec)
+	assert.Equal(t, "failed to create schedule", resp.Error)
+	assert.NotContains(t, rec.Body.String(), "SQLSTATE")
+
+	// A single line carries the operation, the root cause and the request ID
+	entries := logs.All()
+	require.Len(t, entries, 1)
+
+	fields := entries[0].ContextMap()
+	assert.Equal(t, rec.Header().Get(echo.HeaderXRequestID), fields["request_id"])
+	assert.Equal(t, `add schedule "Weekday" for team "backend-team": `+dbErrorText, fields["error"])
+}
+
+func TestStorageFailure_ClientErrorsAreNotCorrelated(t *testing.T) {
+	e, _ := newErrorServer(t)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", Request{Team: "backend-team"}, "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "correlation_id")
+}
+
+func TestErrorHandler(t *testing.T) {
+	e, _ := newErrorServer(t)
+
+	rec := serveJSON(e, http.MethodGet, "/fail", nil, "")
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	resp := requireCorrelated(t, rec)
+	assert.Equal(t, http.StatusText(http.StatusInternalServerError), resp.Error)
+	assert.NotContains(t, rec.Body.String(), "10.0.0.5")
+
+	// Client errors are handled as echo does
+	rec = serveJSON(e, http.MethodGet, "/missing", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "correlation_id")
+}
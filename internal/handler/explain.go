@@ -0,0 +1,438 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// Kinds of the overrides of an explanation.
+const (
+	OverridePin    = "pin"
+	OverrideForced = "forced"
+	OverrideSwap   = "swap"
+)
+
+// ExplainResponse is the trace of how the on-call lookup resolves an
+// instant, in the order it takes its steps: the pause of the team, the
+// schedules, the overrides of the selected one and the unavailability of
+// the member on duty.
+type ExplainResponse struct {
+	Team     string `json:"team"`
+	Time     string `json:"time"`
+	Timezone string `json:"timezone"`
+	// UTC is the instant the schedules are matched at.
+	UTC   ExplainInstant `json:"utc"`
+	Pause *PauseDocument `json:"pause,omitempty"`
+	// Schedules are ordered by priority, the first one with a shift
+	// running answers.
+	Schedules []ScheduleExplanation `json:"schedules"`
+	Selected  string                `json:"selected,omitempty"`
+	TieBreak  string                `json:"tie_break,omitempty"`
+	// Overrides are the pins of the date of the selected shift.
+	Overrides      []OverrideExplanation      `json:"overrides"`
+	Unavailability *UnavailabilityExplanation `json:"unavailability,omitempty"`
+	InheritedFrom  string                     `json:"inherited_from,omitempty"`
+	Oncall         string                     `json:"oncall,omitempty"`
+	Oncalls        []string                   `json:"oncalls,omitempty"`
+	SubstitutedFor string                     `json:"substituted_for,omitempty"`
+	Result         string                     `json:"result"`
+}
+
+// ExplainInstant is an instant in UTC along with the weekday and time of day
+// schedules are matched on.
+type ExplainInstant struct {
+	Time      string `json:"time"`
+	Weekday   string `json:"weekday"`
+	TimeOfDay string `json:"time_of_day"`
+}
+
+// ScheduleExplanation is a schedule considered by the lookup, see the
+// storage.Outcome constants.
+type ScheduleExplanation struct {
+	ID       string               `json:"id"`
+	Name     string               `json:"name"`
+	Priority int                  `json:"priority"`
+	Outcome  string               `json:"outcome"`
+	Reason   string               `json:"reason"`
+	Shift    *ShiftExplanation    `json:"shift,omitempty"`
+	Rule     string               `json:"rule,omitempty"`
+	Assigned string               `json:"assigned_weekday,omitempty"`
+	Rotation *RotationExplanation `json:"rotation,omitempty"`
+	Member   string               `json:"member,omitempty"`
+	Others   []string             `json:"others,omitempty"`
+}
+
+// ShiftExplanation is the shift of a schedule running at the instant, in
+// the requested zone and in UTC. Part is the index of the running part out
+// of Parts for shifts that are split or cut at a handoff.
+type ShiftExplanation struct {
+	Start    string `json:"start"`
+	End      string `json:"end"`
+	StartUTC string `json:"start_utc"`
+	EndUTC   string `json:"end_utc"`
+	Part     int    `json:"part"`
+	Parts    int    `json:"parts"`
+}
+
+// RotationExplanation is the rotation math putting Member on duty: turn is
+// offset plus the periods elapsed from the anchor to evaluated_at, taken
+// modulo the members taking turns, skipping the inactive ones.
+type RotationExplanation struct {
+	EvaluatedAt    string   `json:"evaluated_at"`
+	Manual         bool     `json:"manual"`
+	Anchor         string   `json:"anchor,omitempty"`
+	Period         string   `json:"period,omitempty"`
+	Elapsed        string   `json:"elapsed,omitempty"`
+	ElapsedPeriods int      `json:"elapsed_periods"`
+	Offset         int      `json:"offset"`
+	Turn           int      `json:"turn"`
+	Members        []string `json:"members"`
+	Skipped        []string `json:"skipped,omitempty"`
+	Index          int      `json:"index"`
+	SplitSteps     int      `json:"split_steps,omitempty"`
+	Member         string   `json:"member,omitempty"`
+}
+
+// OverrideExplanation is a pin of the date of the selected shift and whether
+// it was applied.
+type OverrideExplanation struct {
+	ID         int64  `json:"id"`
+	Kind       string `json:"kind"`
+	Date       string `json:"date"`
+	ShiftStart string `json:"shift_start,omitempty"`
+	Member     string `json:"member"`
+	Applied    bool   `json:"applied"`
+	Reason     string `json:"reason"`
+}
+
+// UnavailabilityExplanation is the unavailability check of the member on
+// duty, Unavailable being the members of the selected schedule who are out.
+type UnavailabilityExplanation struct {
+	Member      string   `json:"member"`
+	Unavailable []string `json:"unavailable"`
+	Applied     bool     `json:"applied"`
+	Substitute  string   `json:"substitute,omitempty"`
+}
+
+// ExplainOncall handles requests for the trace of how the on-call lookup
+// resolves the time of the team: which schedules were considered, which
+// matched and which was selected by priority, the rotation math putting the
+// member on duty, and the pins and unavailability applied or skipped. It
+// resolves the schedules like the lookup does and answers 200 even when
+// nobody is on call, the result saying why.
+func (h *Handler) ExplainOncall(c echo.Context) error {
+	teamName := c.Param("team")
+
+	timeStr := c.QueryParam("time")
+	if timeStr == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "time query parameter is required"})
+	}
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	at, err := h.parseTime(timeStr, "time", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to explain oncall")
+	}
+
+	resp, err := h.explain(ctx, teamName, team.Schedules, at, loc)
+	if err != nil {
+		return h.storageFailure(c, err, "failed to explain oncall")
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// explain returns the trace of how the lookup resolves the instant from the
+// schedules of the team.
+func (h *Handler) explain(
+	ctx context.Context, team string, schedules []storage.Schedule, at time.Time, loc *time.Location,
+) (ExplainResponse, error) {
+	utc := at.UTC()
+	resp := ExplainResponse{
+		Team:     team,
+		Time:     at.In(loc).Format(time.RFC3339),
+		Timezone: loc.String(),
+		UTC: ExplainInstant{
+			Time:      utc.Format(time.RFC3339),
+			Weekday:   utc.Weekday().String(),
+			TimeOfDay: utc.Format(time.TimeOnly),
+		},
+		Schedules: []ScheduleExplanation{},
+		Overrides: []OverrideExplanation{},
+	}
+
+	pause, paused, err := h.activePause(ctx, team, at)
+	if err != nil {
+		return ExplainResponse{}, fmt.Errorf("get pause of team %q: %w", team, err)
+	}
+	if paused {
+		doc := newPauseResponse(team, pause)
+		resp.Pause = &PauseDocument{Reason: doc.Reason, Since: doc.Since, Until: doc.Until}
+	}
+
+	explanation := storage.Explain(schedules, at)
+
+	running := 0
+	for i, candidate := range explanation.Candidates {
+		resp.Schedules = append(resp.Schedules, explainSchedule(schedules[i], candidate, explanation, loc))
+		if candidate.Outcome == storage.OutcomeSelected || candidate.Outcome == storage.OutcomeShadowed {
+			running++
+		}
+	}
+
+	if explanation.Selected != -1 {
+		selected := schedules[explanation.Selected]
+		resp.Selected = selected.Name
+		if running > 1 {
+			resp.TieBreak = fmt.Sprintf("%d schedules have a shift running, %s answers as it has the highest priority, having been added first", running, selected.Name)
+		}
+
+		shift := explanation.Candidates[explanation.Selected].Shift
+		if resp.Overrides, err = h.explainOverrides(ctx, team, selected, *shift); err != nil {
+			return ExplainResponse{}, err
+		}
+	}
+
+	if paused {
+		resp.Result = "the team is paused, nobody is on call"
+		return resp, nil
+	}
+
+	oncall, found := "", false
+	if explanation.Duty != nil {
+		oncall, found = explanation.Duty.Member, true
+	}
+
+	// A team with nothing matching falls back on its ancestors like the lookup
+	if explanation.Selected == -1 {
+		oncall, schedules, resp.InheritedFrom, found, err = h.inheritedOncall(ctx, team, at)
+		if err != nil {
+			return ExplainResponse{}, fmt.Errorf("get inherited oncall of team %q: %w", team, err)
+		}
+	}
+
+	if !found {
+		resp.Result = "no schedule puts anybody on call at the time"
+		if explanation.Selected != -1 {
+			resp.Result = fmt.Sprintf("%s puts nobody on duty at the time", resp.Selected)
+		}
+		return resp, nil
+	}
+
+	if resp.Unavailability, err = h.explainUnavailability(ctx, schedules, oncall, at); err != nil {
+		return ExplainResponse{}, err
+	}
+	if !resp.Unavailability.Applied {
+		resp.Oncall = oncall
+	} else if resp.Unavailability.Substitute != "" {
+		resp.Oncall, resp.SubstitutedFor = resp.Unavailability.Substitute, oncall
+	} else {
+		resp.Result = fmt.Sprintf("%s and every member who could take over are unavailable", oncall)
+		return resp, nil
+	}
+
+	if members, ok := storage.OncallsAt(schedules, at); ok && len(members) > 1 {
+		resp.Oncalls = members
+		resp.Oncalls[0] = resp.Oncall
+	}
+
+	switch {
+	case resp.InheritedFrom != "":
+		resp.Result = fmt.Sprintf("%s is on call, inherited from %s", resp.Oncall, resp.InheritedFrom)
+	case resp.SubstitutedFor != "":
+		resp.Result = fmt.Sprintf("%s is on call for %s, substituting for %s", resp.Oncall, resp.Selected, resp.SubstitutedFor)
+	default:
+		resp.Result = fmt.Sprintf("%s is on call for %s", resp.Oncall, resp.Selected)
+	}
+
+	return resp, nil
+}
+
+// explainSchedule renders a schedule considered by the lookup.
+func explainSchedule(
+	sched storage.Schedule, candidate storage.Candidate, explanation storage.Explanation, loc *time.Location,
+) ScheduleExplanation {
+	resp := ScheduleExplanation{
+		ID:       candidate.ID,
+		Name:     candidate.Name,
+		Priority: candidate.Priority,
+		Outcome:  candidate.Outcome,
+	}
+
+	if candidate.Shift != nil {
+		resp.Shift = &ShiftExplanation{
+			Start:    candidate.Shift.Start.In(loc).Format(time.RFC3339),
+			End:      candidate.Shift.End.In(loc).Format(time.RFC3339),
+			StartUTC: candidate.Shift.Start.UTC().Format(time.RFC3339),
+			EndUTC:   candidate.Shift.End.UTC().Format(time.RFC3339),
+			Part:     candidate.Part,
+			Parts:    candidate.Parts,
+		}
+	}
+
+	at := explanation.At
+	switch candidate.Outcome {
+	case storage.OutcomeSelected:
+		resp.Reason = "first schedule by priority with a shift running"
+	case storage.OutcomeShadowed:
+		resp.Reason = fmt.Sprintf("a shift is running, but %s has a higher priority", explanation.Candidates[explanation.Selected].Name)
+	case storage.OutcomeNoShift:
+		resp.Reason = fmt.Sprintf("no shift is running on %s at %s UTC", at.Weekday(), at.Format(time.TimeOnly))
+	case storage.OutcomeEnded:
+		resp.Reason = fmt.Sprintf("the schedule ended at %s", sched.ValidUntil.In(loc).Format(time.RFC3339))
+	case storage.OutcomeNoMembers:
+		resp.Reason = "the schedule has no members"
+	}
+
+	resolution := candidate.Resolution
+	if resolution == nil {
+		return resp
+	}
+
+	resp.Rule = resolution.Rule
+	if resolution.Rule == storage.RuleDayAssignment {
+		resp.Assigned = resolution.Weekday.String()
+	}
+	if trace := resolution.Rotation; trace != nil {
+		resp.Rotation = &RotationExplanation{
+			EvaluatedAt:    trace.At.UTC().Format(time.RFC3339),
+			Manual:         trace.Manual,
+			ElapsedPeriods: trace.ElapsedPeriods,
+			Offset:         trace.Offset,
+			Turn:           trace.Turn,
+			Members:        trace.Turns,
+			Skipped:        trace.Skipped,
+			Index:          trace.Index,
+			SplitSteps:     trace.SplitSteps,
+		}
+		if !trace.Manual {
+			resp.Rotation.Anchor = trace.Anchor.UTC().Format(time.RFC3339)
+			resp.Rotation.Period = trace.Period.String()
+			resp.Rotation.Elapsed = trace.Elapsed.String()
+		}
+		if trace.Index != -1 {
+			resp.Rotation.Member = sched.Members[trace.Index]
+		}
+	}
+
+	if duty := explanation.Duty; duty != nil {
+		resp.Member = duty.Member
+		resp.Others = duty.Others
+	}
+
+	return resp
+}
+
+// explainOverrides returns the pins of the selected schedule on the date of
+// its running shift, the first one matching the shift being applied like
+// the lookup does. Pins of a single shift are forced shifts, or swaps when
+// an accepted swap request put their member there.
+func (h *Handler) explainOverrides(ctx context.Context, team string, sched storage.Schedule, shift storage.Shift) ([]OverrideExplanation, error) {
+	overrides := []OverrideExplanation{}
+
+	date := storage.PinDate(shift.Start)
+	applied := false
+	var swaps []storage.SwapRequest
+	for _, pin := range sched.Pins {
+		if !pin.Date.Equal(date) {
+			continue
+		}
+
+		override := OverrideExplanation{
+			ID:     pin.ID,
+			Kind:   OverridePin,
+			Date:   pin.Date.Format(time.DateOnly),
+			Member: pin.Member,
+		}
+
+		if !pin.ShiftStart.IsZero() {
+			override.Kind = OverrideForced
+			override.ShiftStart = pin.ShiftStart.UTC().Format(time.RFC3339)
+
+			if swaps == nil {
+				var err error
+				if swaps, err = h.storage.ListSwapRequests(ctx, team, sched.ID); err != nil {
+					return nil, fmt.Errorf("list swap requests of schedule %q: %w", sched.ID, err)
+				}
+			}
+			if slices.ContainsFunc(swaps, func(r storage.SwapRequest) bool {
+				return r.Status == storage.SwapAccepted && r.To == pin.Member && r.ShiftStart.Equal(pin.ShiftStart)
+			}) {
+				override.Kind = OverrideSwap
+			}
+		}
+
+		switch {
+		case !pin.ShiftStart.IsZero() && !pin.ShiftStart.Equal(shift.Start):
+			override.Reason = "pins another shift of the date"
+		case applied:
+			override.Reason = "an earlier pin of the date takes precedence"
+		default:
+			override.Applied = true
+			override.Reason = fmt.Sprintf("puts %s on duty in place of the rotation", pin.Member)
+			applied = true
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	return overrides, nil
+}
+
+// explainUnavailability checks the member on duty against the unavailability
+// of the members, like the lookup does.
+func (h *Handler) explainUnavailability(
+	ctx context.Context, schedules []storage.Schedule, member string, at time.Time,
+) (*UnavailabilityExplanation, error) {
+	candidates := []string{member}
+	if sched, ok := storage.ScheduleAt(schedules, at); ok {
+		candidates = append(candidates, sched.Members...)
+	}
+
+	unavailable, err := h.storage.UnavailableMembers(ctx, candidates, at)
+	if err != nil {
+		return nil, fmt.Errorf("get unavailable members: %w", err)
+	}
+
+	resp := &UnavailabilityExplanation{Member: member, Unavailable: unavailable}
+	if resp.Unavailable == nil {
+		resp.Unavailable = []string{}
+	}
+
+	substitute, err := storage.Substitute(ctx, h.storage, schedules, member, at)
+	if errors.Is(err, storage.ErrAllUnavailable) {
+		resp.Applied = true
+		return resp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("substitute oncall %q: %w", member, err)
+	}
+
+	if substitute != member {
+		resp.Applied = true
+		resp.Substitute = substitute
+	}
+
+	return resp, nil
+}
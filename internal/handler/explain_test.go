@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newExplainServer creates two layers of schedules: the Primary rotation of
+// Alice, Bob and Charlie during business hours, added first so it takes
+// precedence, and the Fallback of Dave all day long. Erin is pinned to the
+// Primary shift of Wednesday 2025-05-07, on top of Bob's week.
+func newExplainServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	e := echo.New()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.GET("/teams/:team/oncall/explain", h.ExplainOncall)
+
+	primary := quotaRequest("backend-team")
+	primary.Name = "Primary"
+	primary.Members = []string{"Alice", "Bob", "Charlie"}
+	primary.Anchor = "2025-04-28"
+
+	fallback := quotaRequest("backend-team")
+	fallback.Name = "Fallback"
+	fallback.Members = []string{"Dave"}
+	fallback.Days = []string{"Monday-Sunday"}
+	fallback.Start, fallback.End = "12:00AM", "11:00PM"
+
+	for _, req := range []Request{primary, fallback} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+	_, _, err = store.AddPin(t.Context(), "backend-team", team.Schedules[0].ID, storage.Pin{
+		Date:   time.Date(2025, time.May, 7, 0, 0, 0, 0, time.UTC),
+		Member: "Erin",
+	})
+	require.NoError(t, err)
+
+	return e, store
+}
+
+// explainQuery asks the explanation of the on-call lookup of the team.
+func explainQuery(e *echo.Echo, team, at, tz string) (ExplainResponse, int, string) {
+	query := url.Values{}
+	query.Set("time", at)
+	if tz != "" {
+		query.Set("tz", tz)
+	}
+
+	rec := serveJSON(e, http.MethodGet, "/teams/"+team+"/oncall/explain?"+query.Encode(), nil, "")
+
+	var resp ExplainResponse
+	if rec.Code == http.StatusOK {
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	}
+
+	return resp, rec.Code, rec.Body.String()
+}
+
+func TestExplainOncall_Override(t *testing.T) {
+	e, store := newExplainServer(t)
+
+	// 14:00 in Tehran is 10:30 UTC, during the pinned Primary shift
+	query := url.Values{}
+	query.Set("time", "2025-05-07T14:00:00+03:30")
+	query.Set("tz", "Asia/Tehran")
+
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/oncall/explain?"+query.Encode(), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var got bytes.Buffer
+	require.NoError(t, json.Indent(&got, rec.Body.Bytes(), "", "  "))
+	got.WriteByte('\n')
+
+	assertGolden(t, "explain_override.json", numberSchedules(t, store, "backend-team", got.Bytes()))
+}
+
+func TestExplainOncall_Substitute(t *testing.T) {
+	e, store := newExplainServer(t)
+
+	_, err := store.AddUnavailability(t.Context(), storage.Unavailability{
+		Member: "Bob",
+		Start:  time.Date(2025, time.May, 6, 0, 0, 0, 0, time.UTC),
+		End:    time.Date(2025, time.May, 7, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	resp, code, body := explainQuery(e, "backend-team", "2025-05-06T10:00:00Z", "")
+	require.Equal(t, http.StatusOK, code, body)
+
+	assert.Empty(t, resp.Overrides)
+	assert.Equal(t, "Bob", resp.Schedules[0].Rotation.Member)
+	assert.Equal(t, &UnavailabilityExplanation{Member: "Bob", Unavailable: []string{"Bob"}, Applied: true, Substitute: "Charlie"}, resp.Unavailability)
+	assert.Equal(t, "Charlie", resp.Oncall)
+	assert.Equal(t, "Bob", resp.SubstitutedFor)
+}
+
+func TestExplainOncall_MatchesLookup(t *testing.T) {
+	e, _ := newExplainServer(t)
+
+	from := time.Date(2025, time.May, 5, 0, 0, 0, 0, time.UTC)
+	for at := from; at.Before(from.AddDate(0, 0, 7)); at = at.Add(90 * time.Minute) {
+		resp, code, body := explainQuery(e, "backend-team", at.Format(time.RFC3339), "")
+		require.Equal(t, http.StatusOK, code, body)
+
+		rec := oncallQuery(e, "backend-team", at, false)
+		if rec.Code != http.StatusOK {
+			assert.Empty(t, resp.Oncall, "at %s", at)
+			continue
+		}
+
+		var lookup OncallResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &lookup))
+		assert.Equal(t, lookup.Oncall, resp.Oncall, "at %s", at)
+	}
+}
+
+func TestExplainOncall_Invalid(t *testing.T) {
+	e, _ := newExplainServer(t)
+
+	tests := []struct {
+		name   string
+		target string
+		code   int
+	}{
+		{"unknown team", "/teams/frontend-team/oncall/explain?time=2025-05-07T10:00:00Z", http.StatusNotFound},
+		{"missing time", "/teams/backend-team/oncall/explain", http.StatusBadRequest},
+		{"invalid time", "/teams/backend-team/oncall/explain?time=noon", http.StatusBadRequest},
+		{"invalid zone", "/teams/backend-team/oncall/explain?time=2025-05-07T10:00:00Z&tz=Mars/Olympus", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodGet, tt.target, nil, "")
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
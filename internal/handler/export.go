@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// maxExportRange bounds the range of an on-call export.
+const maxExportRange = 3660 * 24 * time.Hour
+
+// exportHeader is the header row of an on-call export.
+var exportHeader = []string{"date", "start", "end", "schedule", "member", "hours", "routing"}
+
+// ExportOncall handles on-call export requests. It streams a CSV row for every
+// stretch of the range someone is on call, split at midnight in the tz zone so
+// every row belongs to a single date. Time while the team is paused is left out.
+func (h *Handler) ExportOncall(c echo.Context) error {
+	team := c.Param("team")
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	from, err := h.parseTime(c.QueryParam("from"), "from", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	to, err := h.parseTime(c.QueryParam("to"), "to", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	if !from.Before(to) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must be before to"})
+	}
+	if to.Sub(from) > maxExportRange {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "range must not exceed ten years"})
+	}
+
+	asConfigured, err := parseAsConfigured(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	t, err := h.storage.GetTeam(ctx, team)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, team, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", team, err), "failed to retrieve team")
+	}
+
+	pause, paused, err := h.storage.GetPause(ctx, team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get pause of team %q: %w", team, err), "failed to retrieve team")
+	}
+	if !paused {
+		pause = storage.Pause{}
+	}
+
+	var history []storage.TeamVersion
+	if asConfigured {
+		var warning string
+		history, warning, err = h.teamHistory(ctx, team, from, to)
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			return h.teamNotFound(c, team, "team not found")
+		}
+		if err != nil {
+			return h.storageFailure(c, err, "failed to retrieve team")
+		}
+		if warning != "" {
+			c.Response().Header().Set(HeaderOncallWarning, warning)
+		}
+	}
+
+	startCSV(c, team+"-oncall.csv")
+
+	if err := h.writeOncallExport(ctx, csv.NewWriter(c.Response()), c.Response(), team, t.Schedules, history, pause, from.In(loc), to.In(loc)); err != nil {
+		// The status is already sent, the truncated document tells the client
+		h.logger.Error("failed to write on-call export", zap.String("team", team), zap.Error(err))
+	}
+
+	return nil
+}
+
+// startCSV sends the status and headers of a CSV attachment with the given
+// file name, the rows are written to the response after it.
+func startCSV(c echo.Context, filename string) {
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename=%q`, filename))
+	c.Response().WriteHeader(http.StatusOK)
+}
+
+// writeOncallExport writes the export one day at a time, flushing after each,
+// so a large range is never held in memory. A zero pause leaves nothing out.
+// Given a history, stretches are resolved against the versions of the team
+// instead of its current schedules.
+func (h *Handler) writeOncallExport(
+	ctx context.Context, w *csv.Writer, flusher http.Flusher,
+	team string, schedules []storage.Schedule, history []storage.TeamVersion, pause storage.Pause, from, to time.Time,
+) error {
+	if err := w.Write(exportHeader); err != nil {
+		return err
+	}
+
+	loc := from.Location()
+
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		start, end := later(day, from), earlier(day.AddDate(0, 0, 1), to)
+
+		duties, err := h.exportDuties(ctx, team, schedules, history, pause, start, end)
+		if err != nil {
+			return err
+		}
+
+		for _, duty := range duties {
+			routing, err := exportRouting(schedules, history, duty)
+			if err != nil {
+				return err
+			}
+
+			if err := w.Write([]string{
+				day.Format(time.DateOnly),
+				duty.Start.In(loc).Format(time.RFC3339),
+				duty.End.In(loc).Format(time.RFC3339),
+				duty.Schedule,
+				duty.Member,
+				strconv.FormatFloat(duty.End.Sub(duty.Start).Hours(), 'f', 2, 64),
+				routing,
+			}); err != nil {
+				return err
+			}
+		}
+
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// exportDuties returns who is on call during [from, to) outside the pause,
+// resolved against the history when there is one, otherwise looking every
+// stretch up like the on-call lookup does.
+func (h *Handler) exportDuties(
+	ctx context.Context, team string, schedules []storage.Schedule, history []storage.TeamVersion,
+	pause storage.Pause, from, to time.Time,
+) ([]storage.Duty, error) {
+	var duties []storage.Duty
+
+	if history != nil {
+		for _, duty := range storage.HistoryTimeline(history, from, to) {
+			for _, stretch := range outsidePause(duty.Shift, pause) {
+				duties = append(duties, storage.Duty{Shift: stretch, ScheduleID: duty.ScheduleID, Member: duty.Member, Pinned: duty.Pinned})
+			}
+		}
+
+		return duties, nil
+	}
+
+	for _, shift := range storage.Timeline(schedules, from, to) {
+		for _, stretch := range outsidePause(shift, pause) {
+			member, err := h.storage.GetCurrentOncall(ctx, team, stretch.Start)
+			if storage.NobodyOnCall(err) {
+				continue
+			}
+			if err != nil && !errors.Is(err, storage.ErrStale) {
+				return nil, fmt.Errorf("failed to get oncall at %s: %w", stretch.Start, err)
+			}
+
+			duties = append(duties, storage.Duty{Shift: stretch, Member: member})
+		}
+	}
+
+	return duties, nil
+}
+
+// exportRouting returns the routing of the schedule of the duty as a JSON
+// object, taken from the version live at its start when there is a history.
+// It is empty when the schedule has no routing.
+func exportRouting(schedules []storage.Schedule, history []storage.TeamVersion, duty storage.Duty) (string, error) {
+	var routing map[string]string
+
+	if history != nil {
+		version := history[0]
+		for _, v := range history[1:] {
+			if !v.Since.After(duty.Start) {
+				version = v
+			}
+		}
+
+		for _, sched := range version.Team.Schedules {
+			if sched.ID == duty.ScheduleID {
+				routing = sched.Routing
+			}
+		}
+	} else {
+		for _, sched := range schedules {
+			if sched.Name == duty.Schedule {
+				routing = sched.Routing
+				break
+			}
+		}
+	}
+
+	if len(routing) == 0 {
+		return "", nil
+	}
+
+	encoded, err := json.Marshal(routing)
+	if err != nil {
+		return "", fmt.Errorf("encode routing of schedule %q: %w", duty.Schedule, err)
+	}
+
+	return string(encoded), nil
+}
+
+// outsidePause returns the parts of the shift outside the pause.
+func outsidePause(shift storage.Shift, pause storage.Pause) []storage.Shift {
+	if pause.Since.IsZero() || !shift.End.After(pause.Since) ||
+		(!pause.Until.IsZero() && !shift.Start.Before(pause.Until)) {
+		return []storage.Shift{shift}
+	}
+
+	var parts []storage.Shift
+	if shift.Start.Before(pause.Since) {
+		parts = append(parts, storage.Shift{Schedule: shift.Schedule, Start: shift.Start, End: pause.Since})
+	}
+	if !pause.Until.IsZero() && pause.Until.Before(shift.End) {
+		parts = append(parts, storage.Shift{Schedule: shift.Schedule, Start: pause.Until, End: shift.End})
+	}
+
+	return parts
+}
+
+// later returns the later of the two instants.
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+// earlier returns the earlier of the two instants.
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// assertGolden compares got with the golden file, or rewrites it with -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o600))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+// numberSchedules replaces the generated IDs of the schedules of the team in
+// got with their positions, starting at 1, so golden files stay stable.
+func numberSchedules(t *testing.T, store storage.Storage, team string, got []byte) []byte {
+	t.Helper()
+
+	found, err := store.GetTeam(context.Background(), team)
+	require.NoError(t, err)
+
+	for i, sched := range found.Schedules {
+		got = bytes.ReplaceAll(got, []byte(sched.ID), []byte(strconv.Itoa(i+1)))
+	}
+
+	return got
+}
+
+func newExportHandler(t *testing.T) (*Handler, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+
+	_, err := store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Day",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	// 20:00 to 23:00 UTC is 23:30 to 02:30 in Tehran
+	_, err = store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Evening",
+		Members: []string{"Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "8:00PM"),
+		End:     parseTime(t, "11:00PM"),
+	})
+	require.NoError(t, err)
+
+	return New(store, zap.NewNop()), store
+}
+
+func exportOncall(t *testing.T, h *Handler, team, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/teams/"+team+"/oncall/export.csv?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("team")
+	c.SetParamValues(team)
+
+	require.NoError(t, h.ExportOncall(c))
+
+	return rec
+}
+
+func TestExportOncall_SplitsOvernightShifts(t *testing.T) {
+	h, _ := newExportHandler(t)
+
+	rec := exportOncall(t, h, "backend-team", "from=2025-04-28&to=2025-04-30&tz=Asia/Tehran")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, `attachment; filename="backend-team-oncall.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+
+	assertGolden(t, "oncall_export.csv", rec.Body.Bytes())
+}
+
+func TestExportOncall_LeavesOutPauses(t *testing.T) {
+	h, store := newExportHandler(t)
+
+	_, err := store.PauseTeam(context.Background(), "backend-team", storage.Pause{
+		Since: time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC),
+		Until: time.Date(2025, 4, 28, 21, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	rec := exportOncall(t, h, "backend-team", "from=2025-04-28T00:00:00Z&to=2025-04-29T00:00:00Z")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "date,start,end,schedule,member,hours,routing\n"+
+		"2025-04-28,2025-04-28T09:00:00Z,2025-04-28T12:00:00Z,Day,Alice,3.00,\n"+
+		"2025-04-28,2025-04-28T21:00:00Z,2025-04-28T23:00:00Z,Evening,Bob,2.00,\n", rec.Body.String())
+}
+
+func TestExportOncall_InvalidRequests(t *testing.T) {
+	h, _ := newExportHandler(t)
+
+	tests := []struct {
+		name   string
+		team   string
+		query  string
+		status int
+	}{
+		{"missing from", "backend-team", "to=2025-04-30", http.StatusBadRequest},
+		{"invalid to", "backend-team", "from=2025-04-28&to=tomorrow", http.StatusBadRequest},
+		{"reversed range", "backend-team", "from=2025-04-30&to=2025-04-28", http.StatusBadRequest},
+		{"range too long", "backend-team", "from=2000-01-01&to=2025-01-01", http.StatusBadRequest},
+		{"invalid tz", "backend-team", "from=2025-04-28&to=2025-04-30&tz=Mars/Olympus", http.StatusBadRequest},
+		{"unknown team", "frontend-team", "from=2025-04-28&to=2025-04-30", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := exportOncall(t, h, tt.team, tt.query)
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestExportOncall_FixedAssignment(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	_, err := store.AddSchedule(context.Background(), "backend-team", storage.Schedule{
+		Name:           "Fixed",
+		Members:        []string{"Alice", "Bob"},
+		Days:           []time.Weekday{time.Monday, time.Tuesday},
+		DayAssignments: map[time.Weekday]string{time.Monday: "Alice", time.Tuesday: "Bob"},
+		Anchor:         time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:          parseTime(t, "9:00AM"),
+		End:            parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+
+	rec := exportOncall(t, New(store, zap.NewNop()), "backend-team", "from=2025-05-05&to=2025-05-07")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "date,start,end,schedule,member,hours,routing\n"+
+		"2025-05-05,2025-05-05T09:00:00Z,2025-05-05T17:00:00Z,Fixed,Alice,8.00,\n"+
+		"2025-05-06,2025-05-06T09:00:00Z,2025-05-06T17:00:00Z,Fixed,Bob,8.00,\n", rec.Body.String())
+}
+
+func TestExportOncall_Routing(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	_, err := store.AddSchedule(context.Background(), "backend-team", storage.Schedule{
+		Name:    "Day",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+		Routing: map[string]string{"slack_channel": "#backend", "pagerduty_service_id": "PXXXXXX"},
+	})
+	require.NoError(t, err)
+
+	rec := exportOncall(t, New(store, zap.NewNop()), "backend-team", "from=2025-04-28&to=2025-04-29")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "date,start,end,schedule,member,hours,routing\n"+
+		`2025-04-28,2025-04-28T09:00:00Z,2025-04-28T17:00:00Z,Day,Alice,8.00,"{""pagerduty_service_id"":""PXXXXXX"",""slack_channel"":""#backend""}"`+"\n",
+		rec.Body.String())
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// ForceNextRequest represents a request putting the member on duty for the
+// next shift of a schedule.
+type ForceNextRequest struct {
+	Member string `json:"member"`
+}
+
+// ForceNextResponse represents the pin forcing a member onto a single shift,
+// along with the shift it affects and the member it replaces.
+type ForceNextResponse struct {
+	PinResponse
+	ShiftStart string `json:"shift_start"`
+	ShiftEnd   string `json:"shift_end"`
+	Replaced   string `json:"replaced,omitempty"`
+}
+
+// ForceNext handles requests putting a member on duty for the next shift of
+// a schedule, the first one starting after the request. It records a pin
+// limited to that shift, so every other shift, including the others of its
+// date, and the rotation are left as they are. It replaces any pin of the
+// date, and takes the member and external query parameter like a pin.
+func (h *Handler) ForceNext(c echo.Context) error {
+	var req ForceNextRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	member := strings.TrimSpace(req.Member)
+	if member == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "member is required"})
+	}
+
+	external := false
+	if value := c.QueryParam("external"); value != "" {
+		var err error
+		if external, err = strconv.ParseBool(value); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid external query parameter"})
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	if !external && !slices.Contains(sched.Schedule.Members, member) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("%s is not a member of the schedule, pass external=true to force them anyway", member),
+		})
+	}
+
+	// The shift is resolved once, so the response names the one pinned
+	shift, ok := sched.Schedule.NextShift(h.now())
+	if !ok {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "the schedule has no upcoming shift"})
+	}
+	replaced, _ := sched.Schedule.MemberOnDuty(shift.Start)
+
+	change := fmt.Sprintf("force %s onto the shift starting %s on schedule %s", member, shift.Start.UTC().Format(time.RFC3339), sched.Schedule.Name)
+	if frozen, err := h.rejectFrozen(c, sched.Team, change); frozen {
+		return err
+	}
+
+	observer, found, err := h.observerIn(ctx, sched.Team, []string{member})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list members of team %q: %w", sched.Team, err), "failed to force member")
+	}
+	if found {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: observerMessage(observer, sched.Team)})
+	}
+
+	pin := storage.Pin{Date: storage.PinDate(shift.Start), ShiftStart: shift.Start.UTC(), Member: member}
+	pin, found, err = h.storage.AddPin(h.withQuota(ctx, storage.QuotaPins, sched.Team), sched.Team, sched.Schedule.ID, pin)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add pin to schedule %q of team %q: %w", sched.Schedule.ID, sched.Team, err), "failed to force member")
+	}
+	// The schedule may have been deleted in the meantime
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+
+	h.logger.Info("member forced onto next shift",
+		zap.String("team", sched.Team),
+		zap.String("schedule", sched.Schedule.Name),
+		zap.Time("shift_start", shift.Start),
+		zap.String("member", member),
+		zap.String("replaced", replaced),
+	)
+
+	resp := ForceNextResponse{
+		PinResponse: newPinResponse(sched.Team, sched.Schedule.ID, pin),
+		ShiftStart:  shift.Start.UTC().Format(time.RFC3339),
+		ShiftEnd:    shift.End.UTC().Format(time.RFC3339),
+	}
+	if replaced != member {
+		resp.Replaced = replaced
+	}
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// CancelForceNext handles requests canceling a forced shift, which is only
+// possible until the shift starts.
+func (h *Handler) CancelForceNext(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("pin"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid pin id"})
+	}
+
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	index := slices.IndexFunc(sched.Schedule.Pins, func(p storage.Pin) bool { return p.ID == id && !p.ShiftStart.IsZero() })
+	if index == -1 {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "forced shift not found"})
+	}
+	pin := sched.Schedule.Pins[index]
+
+	if !pin.ShiftStart.After(h.now()) {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "the forced shift already started"})
+	}
+
+	if frozen, err := h.rejectFrozen(c, sched.Team, fmt.Sprintf("cancel forced shift %d of schedule %s", id, sched.Schedule.Name)); frozen {
+		return err
+	}
+
+	deleted, err := h.storage.DeletePin(ctx, sched.Team, sched.Schedule.ID, id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("delete pin %d of schedule %q: %w", id, sched.Schedule.ID, err), "failed to cancel forced shift")
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "forced shift not found"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
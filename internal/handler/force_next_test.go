@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// forceShift is the start of an hourly shift of Alice in the schedule
+// created by newForceNextServer.
+var forceShift = time.Date(2030, 1, 7, 11, 0, 0, 0, time.UTC)
+
+// newForceNextServer creates the hourly schedule of Alice and Bob for the
+// backend team, with Alice on duty during the week of forceShift, and the
+// clock seconds before forceShift starts.
+func newForceNextServer(t *testing.T) (*echo.Echo, *Handler, string, *fakeClock) {
+	t.Helper()
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	clock := &fakeClock{now: forceShift.Add(-5 * time.Second)}
+	h.now = clock.Now
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.POST("/schedule/:id/force-next", h.ForceNext)
+	e.DELETE("/schedule/:id/force-next/:pin", h.CancelForceNext)
+
+	req := hourlyRequest("backend-team")
+	req.Anchor = forceShift.Format(time.DateOnly)
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	team, err := h.storage.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+
+	return e, h, team.Schedules[0].ID, clock
+}
+
+func forceNext(t *testing.T, e *echo.Echo, id, target, member string) ForceNextResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/schedule/"+id+"/force-next"+target, ForceNextRequest{Member: member}, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp ForceNextResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestForceNext(t *testing.T) {
+	e, h, id, _ := newForceNextServer(t)
+
+	// The shift starting seconds after the request is the next one
+	resp := forceNext(t, e, id, "", "Bob")
+	assert.Equal(t, "2030-01-07T11:00:00Z", resp.ShiftStart)
+	assert.Equal(t, "2030-01-07T12:00:00Z", resp.ShiftEnd)
+	assert.Equal(t, "Alice", resp.Replaced)
+	assert.Equal(t, "Bob", resp.Member)
+
+	assert.Equal(t, "Alice", swapOncall(t, h, forceShift.Add(-2*time.Hour)))
+	member, err := h.storage.GetCurrentOncall(t.Context(), "backend-team", forceShift.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", member)
+	// The rotation is left as it is
+	assert.Equal(t, "Alice", swapOncall(t, h, forceShift))
+	assert.Equal(t, "Alice", swapOncall(t, h, forceShift.AddDate(0, 0, 1).Add(-time.Hour)))
+
+	// The timeline shows the forced shift as pinned
+	rec := getTimeline(t, h, "backend-team", "from=2030-01-07&to=2030-01-08&granularity=hour")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var timeline TimelineResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &timeline))
+	require.Len(t, timeline.Lanes, 1)
+
+	pinned := []TimelineSegment{}
+	for _, segment := range timeline.Lanes[0].Segments {
+		if len(segment.Flags) > 0 {
+			pinned = append(pinned, segment)
+		}
+	}
+	require.Len(t, pinned, 1)
+	assert.Equal(t, TimelineSegment{
+		Member: "Bob",
+		Start:  "2030-01-07T11:00:00Z",
+		End:    "2030-01-07T12:00:00Z",
+		Flags:  []string{FlagPinned},
+	}, pinned[0])
+}
+
+func TestForceNext_AtShiftStart(t *testing.T) {
+	e, h, id, clock := newForceNextServer(t)
+
+	// A shift starting right at the request has already started
+	clock.now = forceShift
+
+	resp := forceNext(t, e, id, "?external=true", "Dana")
+	assert.Equal(t, "2030-01-07T12:00:00Z", resp.ShiftStart)
+	assert.Equal(t, "Alice", resp.Replaced)
+
+	assert.Equal(t, "Alice", swapOncall(t, h, forceShift.Add(-time.Hour)))
+	assert.Equal(t, "Dana", swapOncall(t, h, forceShift))
+}
+
+func TestForceNext_Cancel(t *testing.T) {
+	e, h, id, clock := newForceNextServer(t)
+
+	resp := forceNext(t, e, id, "", "Bob")
+	target := "/schedule/" + id + "/force-next/" + strconv.FormatInt(resp.ID, 10)
+
+	rec := serveJSON(e, http.MethodDelete, target, nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	assert.Equal(t, "Alice", swapOncall(t, h, forceShift.Add(-time.Hour)))
+
+	rec = serveJSON(e, http.MethodDelete, target, nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Once the shift started the override stays
+	resp = forceNext(t, e, id, "", "Bob")
+	target = "/schedule/" + id + "/force-next/" + strconv.FormatInt(resp.ID, 10)
+	clock.now = forceShift
+
+	rec = serveJSON(e, http.MethodDelete, target, nil, "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "Bob", swapOncall(t, h, forceShift.Add(-time.Hour)))
+}
+
+func TestForceNext_Validation(t *testing.T) {
+	e, _, id, _ := newForceNextServer(t)
+
+	for name, target := range map[string]string{
+		"no member":    "",
+		"not a member": "Zed",
+	} {
+		rec := serveJSON(e, http.MethodPost, "/schedule/"+id+"/force-next", ForceNextRequest{Member: target}, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, name)
+	}
+
+	rec := serveJSON(e, http.MethodPost, "/schedule/999/force-next", ForceNextRequest{Member: "Bob"}, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, "/schedule/"+id+"/force-next/abc", nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
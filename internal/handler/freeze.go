@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CodeTeamFrozen is the error code of changes rejected because the team is frozen.
+const CodeTeamFrozen = "TEAM_FROZEN"
+
+// forceKey is the echo context key of requests an admin forces through a freeze.
+const forceKey = "force"
+
+// FreezeRequest represents the team freeze request.
+type FreezeRequest struct {
+	// Start is the RFC3339 instant the freeze starts at, now when empty.
+	Start  string `json:"start,omitempty"`
+	End    string `json:"end"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// FreezeResponse represents a freeze of a team.
+type FreezeResponse struct {
+	ID        int64  `json:"id"`
+	Team      string `json:"team"`
+	Reason    string `json:"reason,omitempty"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	CreatedAt string `json:"created_at"`
+}
+
+// FrozenResponse is returned instead of changing a frozen team.
+type FrozenResponse struct {
+	ErrorResponse
+	Reason string `json:"reason,omitempty"`
+	Until  string `json:"until"`
+}
+
+// FreezeTeam handles team freeze requests. While a freeze is active the
+// schedules and pins of the team cannot change.
+func (h *Handler) FreezeTeam(c echo.Context) error {
+	team := c.Param("team")
+
+	var req FreezeRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	now := h.now().UTC()
+	freeze := storage.Freeze{Reason: req.Reason, Start: now}
+
+	if req.Start != "" {
+		start, err := time.Parse(time.RFC3339, req.Start)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid start format, use RFC3339 format"})
+		}
+		freeze.Start = start.UTC()
+	}
+
+	if req.End == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end is required"})
+	}
+	end, err := time.Parse(time.RFC3339, req.End)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid end format, use RFC3339 format"})
+	}
+	if !end.After(freeze.Start) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end must be after start"})
+	}
+	if !end.After(now) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end must be in the future"})
+	}
+	freeze.End = end.UTC()
+
+	ctx := c.Request().Context()
+
+	freeze, found, err := h.storage.AddFreeze(ctx, team, freeze)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("freeze team %q: %w", team, err), "failed to freeze team")
+	}
+
+	if !found {
+		return h.teamNotFound(c, team, "team not found")
+	}
+
+	h.logger.Info("team frozen",
+		zap.String("team", team),
+		zap.String("reason", freeze.Reason),
+		zap.Time("start", freeze.Start),
+		zap.Time("end", freeze.End),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	return c.JSON(http.StatusCreated, newFreezeResponse(team, freeze))
+}
+
+// ListFreezes handles requests listing the freezes of a team, ended ones included.
+func (h *Handler) ListFreezes(c echo.Context) error {
+	team := c.Param("team")
+
+	freezes, err := h.storage.ListFreezes(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list freezes of team %q: %w", team, err), "failed to list freezes")
+	}
+
+	resp := make([]FreezeResponse, 0, len(freezes))
+	for _, freeze := range freezes {
+		resp = append(resp, newFreezeResponse(team, freeze))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CancelFreeze handles requests canceling a freeze of a team, whether or not
+// it started already.
+func (h *Handler) CancelFreeze(c echo.Context) error {
+	team := c.Param("team")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid freeze id"})
+	}
+
+	ctx := c.Request().Context()
+
+	canceled, err := h.storage.CancelFreeze(ctx, team, id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("cancel freeze %d of team %q: %w", id, team, err), "failed to cancel freeze")
+	}
+
+	if !canceled {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "freeze not found"})
+	}
+
+	h.logger.Info("freeze canceled", zap.String("team", team), zap.Int64("id", id), zap.String("actor", storage.ActorFrom(ctx)))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Force lets admins push changes through the freeze of a team with the
+// force query parameter. Forced requests must authenticate as admins, the
+// others pass through untouched.
+func (h *Handler) Force(token string) echo.MiddlewareFunc {
+	authenticate := h.Authenticate(auth.RoleAdmin, token)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		forced := authenticate(func(c echo.Context) error {
+			c.Set(forceKey, true)
+			return next(c)
+		})
+
+		return func(c echo.Context) error {
+			value := c.QueryParam("force")
+			if value == "" {
+				return next(c)
+			}
+
+			force, err := strconv.ParseBool(value)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid force query parameter"})
+			}
+			if !force {
+				return next(c)
+			}
+
+			return forced(c)
+		}
+	}
+}
+
+// rejectFrozen responds with a 423 when the team is frozen, unless an admin
+// forces the change through, which is recorded in the audit log. It reports
+// whether the request was answered, in which case its handler returns err.
+func (h *Handler) rejectFrozen(c echo.Context, team, change string) (bool, error) {
+	ctx := c.Request().Context()
+
+	freezes, err := h.storage.ListFreezes(ctx, team)
+	if err != nil {
+		return true, h.storageFailure(c, fmt.Errorf("list freezes of team %q: %w", team, err), "failed to check team freezes")
+	}
+
+	freeze, frozen := activeFreeze(freezes, h.now())
+	if !frozen {
+		return false, nil
+	}
+
+	if forced, _ := c.Get(forceKey).(bool); forced {
+		if err := h.storage.RecordAudit(ctx, team, storage.AuditOverrideFreeze, change); err != nil {
+			return true, h.storageFailure(c, fmt.Errorf("audit freeze override of team %q: %w", team, err), "failed to override freeze")
+		}
+
+		h.logger.Warn("team freeze overridden",
+			zap.String("team", team),
+			zap.String("change", change),
+			zap.String("actor", storage.ActorFrom(ctx)),
+		)
+
+		return false, nil
+	}
+
+	return true, c.JSON(http.StatusLocked, FrozenResponse{
+		ErrorResponse: ErrorResponse{Error: "team is frozen", Code: CodeTeamFrozen},
+		Reason:        freeze.Reason,
+		Until:         freeze.End.UTC().Format(time.RFC3339),
+	})
+}
+
+// activeFreeze returns the freeze covering the given instant that ends
+// last, as changes are only allowed again once it is over.
+func activeFreeze(freezes []storage.Freeze, at time.Time) (storage.Freeze, bool) {
+	var active storage.Freeze
+	found := false
+
+	for _, freeze := range freezes {
+		if freeze.Active(at) && (!found || freeze.End.After(active.End)) {
+			active, found = freeze, true
+		}
+	}
+
+	return active, found
+}
+
+// newFreezeResponse converts a freeze of the team.
+func newFreezeResponse(team string, freeze storage.Freeze) FreezeResponse {
+	return FreezeResponse{
+		ID:        freeze.ID,
+		Team:      team,
+		Reason:    freeze.Reason,
+		Start:     freeze.Start.UTC().Format(time.RFC3339),
+		End:       freeze.End.UTC().Format(time.RFC3339),
+		CreatedAt: freeze.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
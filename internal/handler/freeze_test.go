@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newFreezeServer(t *testing.T) (*echo.Echo, storage.Storage, *fakeClock) {
+	t.Helper()
+
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	h.now = clock.Now
+
+	e.POST("/schedule", h.CreateSchedule, h.Force("secret"))
+	e.GET("/schedule", h.GetSchedule)
+	e.POST("/teams/:team/freeze", h.FreezeTeam, h.Authenticate(auth.RoleAdmin, "secret"))
+	e.GET("/teams/:team/freezes", h.ListFreezes)
+	e.DELETE("/teams/:team/freezes/:id", h.CancelFreeze, h.Authenticate(auth.RoleAdmin, "secret"))
+	e.DELETE("/teams/:team", h.DeleteTeam, h.Authenticate(auth.RoleAdmin, "secret"), h.Force("secret"))
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, store, clock
+}
+
+// freezeBackend freezes the backend team over the given window.
+func freezeBackend(t *testing.T, e *echo.Echo, start, end time.Time) FreezeResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/teams/backend-team/freeze", FreezeRequest{
+		Start:  start.Format(time.RFC3339),
+		End:    end.Format(time.RFC3339),
+		Reason: "year end",
+	}, "secret")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var freeze FreezeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &freeze))
+
+	return freeze
+}
+
+func TestFreeze_BlocksChanges(t *testing.T) {
+	e, _, clock := newFreezeServer(t)
+
+	start := clock.now.Add(time.Hour)
+	end := start.Add(24 * time.Hour)
+	freezeBackend(t, e, start, end)
+
+	req := quotaRequest("backend-team")
+	req.Name = "Weekend"
+
+	// Before the freeze starts changes are allowed
+	clock.now = start.Add(-time.Second)
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// While it is active they are rejected
+	clock.now = start
+	rec = serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusLocked, rec.Code, rec.Body.String())
+
+	var frozen FrozenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &frozen))
+	assert.Equal(t, CodeTeamFrozen, frozen.Code)
+	assert.Equal(t, "year end", frozen.Reason)
+	assert.Equal(t, end.Format(time.RFC3339), frozen.Until)
+
+	// Other teams are not affected
+	rec = serveJSON(e, http.MethodPost, "/schedule", quotaRequest("frontend-team"), "")
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// Reads still work
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=2026-03-02T10:00:00Z", nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Once it ends changes are allowed again
+	clock.now = end
+	req.Name = "Holiday"
+	rec = serveJSON(e, http.MethodPost, "/schedule", req, "")
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+}
+
+func TestFreeze_Force(t *testing.T) {
+	e, store, clock := newFreezeServer(t)
+
+	freezeBackend(t, e, clock.now, clock.now.Add(time.Hour))
+
+	req := quotaRequest("backend-team")
+	req.Name = "Weekend"
+
+	rec := serveJSON(e, http.MethodPost, "/schedule?force=true", req, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/schedule?force=maybe", req, "secret")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/schedule?force=false", req, "secret")
+	assert.Equal(t, http.StatusLocked, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/schedule?force=true", req, "secret")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	entries, err := store.AuditLog(t.Context(), "backend-team")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	override := entries[len(entries)-1]
+	assert.Equal(t, storage.AuditOverrideFreeze, override.Action)
+	assert.Equal(t, "create schedule Weekend", override.Detail)
+}
+
+func TestFreeze_DeleteTeam(t *testing.T) {
+	e, store, clock := newFreezeServer(t)
+
+	freezeBackend(t, e, clock.now, clock.now.Add(time.Hour))
+
+	rec := serveJSON(e, http.MethodDelete, "/teams/backend-team", nil, "secret")
+	require.Equal(t, http.StatusLocked, rec.Code, rec.Body.String())
+
+	_, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team?force=true", nil, "secret")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+
+	_, err = store.GetTeam(t.Context(), "backend-team")
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+}
+
+func TestFreeze_ListCancel(t *testing.T) {
+	e, _, clock := newFreezeServer(t)
+
+	later := freezeBackend(t, e, clock.now.Add(48*time.Hour), clock.now.Add(72*time.Hour))
+	current := freezeBackend(t, e, clock.now, clock.now.Add(time.Hour))
+
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/freezes", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var freezes []FreezeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &freezes))
+	require.Len(t, freezes, 2)
+	assert.Equal(t, current.ID, freezes[0].ID)
+	assert.Equal(t, later.ID, freezes[1].ID)
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/freezes/"+strconv.FormatInt(current.ID, 10), nil, "secret")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	req := quotaRequest("backend-team")
+	req.Name = "Weekend"
+	rec = serveJSON(e, http.MethodPost, "/schedule", req, "")
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/freezes/"+strconv.FormatInt(current.ID, 10), nil, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestFreeze_Validation(t *testing.T) {
+	e, _, clock := newFreezeServer(t)
+
+	past := clock.now.Add(-time.Hour).Format(time.RFC3339)
+	future := clock.now.Add(time.Hour).Format(time.RFC3339)
+
+	tests := []struct {
+		name   string
+		target string
+		req    FreezeRequest
+		token  string
+		code   int
+	}{
+		{"no end", "/teams/backend-team/freeze", FreezeRequest{}, "secret", http.StatusBadRequest},
+		{"invalid end", "/teams/backend-team/freeze", FreezeRequest{End: "tomorrow"}, "secret", http.StatusBadRequest},
+		{"invalid start", "/teams/backend-team/freeze", FreezeRequest{Start: "now", End: future}, "secret", http.StatusBadRequest},
+		{"end before start", "/teams/backend-team/freeze", FreezeRequest{Start: future, End: future}, "secret", http.StatusBadRequest},
+		{"ended", "/teams/backend-team/freeze", FreezeRequest{Start: past, End: past}, "secret", http.StatusBadRequest},
+		{"unknown team", "/teams/frontend-team/freeze", FreezeRequest{End: future}, "secret", http.StatusNotFound},
+		{"no token", "/teams/backend-team/freeze", FreezeRequest{End: future}, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPost, tt.target, tt.req, tt.token)
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// grafanaTimeLayout is the local date-time layout of Grafana OnCall shifts,
+// which are read in their time_zone.
+const grafanaTimeLayout = "2006-01-02T15:04:05"
+
+// Grafana OnCall shift types.
+const (
+	grafanaRollingUsers = "rolling_users"
+	grafanaOverride     = "override"
+)
+
+// GrafanaShift represents a Grafana OnCall on-call shift. Rolling users
+// shifts hand over to the next group of rolling_users every interval weeks,
+// override shifts put their users on call once, above every layer.
+type GrafanaShift struct {
+	Name                       string     `json:"name"`
+	Type                       string     `json:"type"`
+	TimeZone                   string     `json:"time_zone"`
+	Level                      int        `json:"level,omitempty"`
+	Start                      string     `json:"start"`
+	Duration                   int        `json:"duration"`
+	Frequency                  string     `json:"frequency,omitempty"`
+	Interval                   int        `json:"interval,omitempty"`
+	ByDay                      []string   `json:"by_day,omitempty"`
+	WeekStart                  string     `json:"week_start,omitempty"`
+	RollingUsers               [][]string `json:"rolling_users,omitempty"`
+	StartRotationFromUserIndex int        `json:"start_rotation_from_user_index,omitempty"`
+	Until                      string     `json:"until,omitempty"`
+	Users                      []string   `json:"users,omitempty"`
+}
+
+// GrafanaSchedule represents a team as a Grafana OnCall calendar schedule.
+// Warnings list the schedules, or parts of them, which could not be exported.
+type GrafanaSchedule struct {
+	Name     string         `json:"name"`
+	Type     string         `json:"type"`
+	TimeZone string         `json:"time_zone"`
+	Shifts   []GrafanaShift `json:"shifts"`
+	Warnings []string       `json:"warnings"`
+}
+
+// ExportGrafanaOnCall handles Grafana OnCall export requests. Every schedule
+// becomes a layer of rolling users shifts, earlier schedules on higher levels
+// as they take precedence, and upcoming pins become override shifts. Users
+// are the member names, to be mapped to Grafana users on import.
+func (h *Handler) ExportGrafanaOnCall(c echo.Context) error {
+	teamName := c.Param("team")
+
+	team, err := h.storage.GetTeam(c.Request().Context(), teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	return c.JSON(http.StatusOK, grafanaSchedule(teamName, team.Schedules, time.Now()))
+}
+
+// grafanaSchedule converts the schedules of a team. Schedules without an
+// anchor do not rotate, their shifts start on the date of now.
+func grafanaSchedule(team string, schedules []storage.Schedule, now time.Time) GrafanaSchedule {
+	result := GrafanaSchedule{
+		Name:     team,
+		Type:     "calendar",
+		TimeZone: "UTC",
+		Shifts:   []GrafanaShift{},
+		Warnings: []string{},
+	}
+
+	for i, sched := range schedules {
+		switch {
+		case sched.Cron != "":
+			result.Warnings = append(result.Warnings, fmt.Sprintf("schedule %s uses a cron expression, which is not exported", sched.Name))
+			continue
+		case sched.RRule != "":
+			result.Warnings = append(result.Warnings, fmt.Sprintf("schedule %s uses an RRULE, which is not exported", sched.Name))
+			continue
+		case sched.Split > 1:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("schedule %s splits its shifts, which is not exported", sched.Name))
+			continue
+		case sched.Handoff != nil && !sched.Manual:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("schedule %s hands off at a set time, which is not exported", sched.Name))
+			continue
+		case sched.TeamMembers:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("schedule %s rotates through the members of its team, which is not exported", sched.Name))
+			continue
+		case len(sched.MemberRefs) > 0:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("schedule %s rotates through groups of its team, which is not exported", sched.Name))
+			continue
+		case len(sched.Members) == 0:
+			result.Warnings = append(result.Warnings, fmt.Sprintf("schedule %s has no members and is not exported", sched.Name))
+			continue
+		}
+
+		anchor := sched.Anchor
+		if anchor.IsZero() {
+			anchor = now
+		}
+		anchor = storage.PinDate(anchor)
+
+		layer := GrafanaShift{
+			Name:      sched.Name,
+			Type:      grafanaRollingUsers,
+			TimeZone:  "UTC",
+			Level:     len(schedules) - i,
+			Start:     anchor.Add(timeOfDay(sched.Start)).Format(grafanaTimeLayout),
+			Duration:  int(sched.End.Sub(sched.Start).Seconds()),
+			Frequency: "weekly",
+			Interval:  1,
+			WeekStart: icsWeekdays[anchor.Weekday()],
+		}
+		if !sched.ValidUntil.IsZero() {
+			layer.Until = sched.ValidUntil.UTC().Format(grafanaTimeLayout)
+		}
+
+		if len(sched.DayAssignments) > 0 {
+			// Fixed assignment becomes a shift for each member on their days
+			var members []string
+			for _, day := range sched.Days {
+				if member, ok := sched.DayAssignments[day]; ok && !slices.Contains(members, member) {
+					members = append(members, member)
+				}
+			}
+
+			for _, member := range members {
+				shift := layer
+				shift.Name = sched.Name + " - " + member
+				shift.RollingUsers = [][]string{{member}}
+				for _, day := range sched.Days {
+					if sched.DayAssignments[day] == member {
+						shift.ByDay = append(shift.ByDay, icsWeekdays[day])
+					}
+				}
+				result.Shifts = append(result.Shifts, shift)
+			}
+		} else {
+			for _, day := range sched.Days {
+				layer.ByDay = append(layer.ByDay, icsWeekdays[day])
+			}
+
+			n := len(sched.Members)
+			if sched.Anchor.IsZero() || sched.Manual {
+				// Without an anchor, or until a manual schedule is advanced, the same members are always on duty
+				member, _ := sched.MemberOnDuty(anchor)
+				group := []string{member}
+				if i := slices.Index(sched.Members, member); i >= 0 {
+					group = grafanaGroup(sched, i)
+				}
+				layer.RollingUsers = [][]string{group}
+			} else {
+				for i := range sched.Members {
+					layer.RollingUsers = append(layer.RollingUsers, grafanaGroup(sched, i))
+				}
+				layer.StartRotationFromUserIndex = (sched.RotationOffset%n + n) % n
+			}
+
+			result.Shifts = append(result.Shifts, layer)
+		}
+
+		for _, pin := range sched.Pins {
+			if pin.Date.Before(storage.PinDate(now)) {
+				continue
+			}
+			// Pins of dates without a shift have nothing to override
+			shift, ok := sched.ShiftAt(pin.Date.Add(timeOfDay(sched.Start)))
+			if !ok {
+				continue
+			}
+
+			result.Shifts = append(result.Shifts, GrafanaShift{
+				Name:     sched.Name + " - pinned " + pin.Member,
+				Type:     grafanaOverride,
+				TimeZone: "UTC",
+				Start:    shift.Start.Format(grafanaTimeLayout),
+				Duration: layer.Duration,
+				Users:    []string{pin.Member},
+			})
+		}
+	}
+
+	return result
+}
+
+// grafanaGroup returns the users on duty together for the turn of the member
+// at the index, the member and the next ones of the schedule requiring more
+// than one.
+func grafanaGroup(sched storage.Schedule, index int) []string {
+	n := len(sched.Members)
+
+	group := make([]string, 0, max(sched.RequiredCount, 1))
+	for k := range min(max(sched.RequiredCount, 1), n) {
+		group = append(group, sched.Members[(index+k)%n])
+	}
+
+	return group
+}
+
+// timeOfDay returns the wall clock time of t as a duration since midnight.
+func timeOfDay(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+}
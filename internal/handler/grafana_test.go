@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGrafanaSchedule_Golden(t *testing.T) {
+	anchor := time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	schedules := []storage.Schedule{
+		{
+			Name:           "Weekday",
+			Members:        []string{"Alice", "Bob", "Carol"},
+			Days:           []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Anchor:         anchor,
+			RotationOffset: 4,
+			ValidUntil:     time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+			Start:          parseTime(t, "9:00AM"),
+			End:            parseTime(t, "5:00PM"),
+			Pins: []storage.Pin{
+				// Already past, and on a date without a shift
+				{Date: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), Member: "Dana"},
+				{Date: time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC), Member: "Dana"},
+				{Date: time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC), Member: "Dana"},
+			},
+		},
+		{
+			Name:           "Weekend",
+			Members:        []string{"Erin", "Frank"},
+			Days:           []time.Weekday{time.Saturday, time.Sunday},
+			DayAssignments: map[time.Weekday]string{time.Saturday: "Erin", time.Sunday: "Frank"},
+			Anchor:         anchor,
+			Start:          parseTime(t, "10:00AM"),
+			End:            parseTime(t, "4:00PM"),
+		},
+		{
+			Name:    "Night",
+			Members: []string{"Gina"},
+			Cron:    "0 22 * * *",
+			Start:   parseTime(t, "10:00PM"),
+			End:     parseTime(t, "11:00PM"),
+		},
+		{
+			Name:    "Biweekly",
+			Members: []string{"Gina"},
+			RRule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO",
+			Anchor:  anchor,
+			Start:   parseTime(t, "9:00AM"),
+			End:     parseTime(t, "5:00PM"),
+		},
+		{
+			Name:           "Legacy",
+			Members:        []string{"Gina", "Hank"},
+			Days:           []time.Weekday{time.Monday},
+			RotationOffset: 1,
+			Start:          parseTime(t, "6:00PM"),
+			End:            parseTime(t, "8:00PM"),
+		},
+	}
+
+	got, err := json.MarshalIndent(grafanaSchedule("backend-team", schedules, time.Date(2025, 5, 5, 12, 0, 0, 0, time.UTC)), "", "  ")
+	require.NoError(t, err)
+
+	assertGolden(t, "grafana_oncall.json", append(got, '\n'))
+}
+
+func TestExportGrafanaOnCall(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	_, err := store.AddSchedule(context.Background(), "backend-team", storage.Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	h := New(store, zap.NewNop())
+
+	export := func(team string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/teams/"+team+"/export/grafana-oncall", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("team")
+		c.SetParamValues(team)
+
+		require.NoError(t, h.ExportGrafanaOnCall(c))
+
+		return rec
+	}
+
+	rec := export("backend-team")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp GrafanaSchedule
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Shifts, 1)
+	assert.Equal(t, [][]string{{"Alice"}}, resp.Shifts[0].RollingUsers)
+	assert.Empty(t, resp.Warnings)
+
+	assert.Equal(t, http.StatusNotFound, export("frontend-team").Code)
+}
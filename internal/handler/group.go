@@ -0,0 +1,251 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// MaxGroupNameLength bounds the names of groups.
+const MaxGroupNameLength = 64
+
+// GroupRequest represents a group creation or update request. The name is
+// taken from the path on update.
+type GroupRequest struct {
+	Name    string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Members []string `json:"members" yaml:"members"`
+}
+
+// GroupResponse represents a group of a team.
+type GroupResponse struct {
+	Team      string   `json:"team"`
+	Name      string   `json:"name"`
+	Members   []string `json:"members"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+// CreateGroup handles requests creating a group of a team.
+func (h *Handler) CreateGroup(c echo.Context) error {
+	team := c.Param("team")
+
+	var req GroupRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	group, err := parseGroup(req.Name, req.Members)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	groups, err := h.storage.ListGroups(ctx, team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list groups of team %q: %w", team, err), "failed to create group")
+	}
+	if slices.ContainsFunc(groups, func(g storage.Group) bool { return g.Name == group.Name }) {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("group %s already exists", group.Name)})
+	}
+
+	return h.setGroup(c, team, group, http.StatusCreated)
+}
+
+// UpdateGroup handles requests replacing the members of a group of a team.
+// Schedules referencing the group take the change into account from their
+// next handoff.
+func (h *Handler) UpdateGroup(c echo.Context) error {
+	team, name := c.Param("team"), c.Param("name")
+
+	var req GroupRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	if req.Name != "" && req.Name != name {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "groups cannot be renamed"})
+	}
+
+	group, err := parseGroup(name, req.Members)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	groups, err := h.storage.ListGroups(ctx, team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list groups of team %q: %w", team, err), "failed to update group")
+	}
+	if !slices.ContainsFunc(groups, func(g storage.Group) bool { return g.Name == group.Name }) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "group not found"})
+	}
+
+	return h.setGroup(c, team, group, http.StatusOK)
+}
+
+// setGroup stores a validated group, rejecting observers of the team as
+// members, and responds with the given status.
+func (h *Handler) setGroup(c echo.Context, team string, group storage.Group, status int) error {
+	ctx := c.Request().Context()
+
+	observer, found, err := h.observerIn(ctx, team, group.Members)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list members of team %q: %w", team, err), "failed to set group")
+	}
+	if found {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: observerMessage(observer, team)})
+	}
+
+	group, found, err = h.storage.SetGroup(ctx, team, group)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("set group %q of team %q: %w", group.Name, team, err), "failed to set group")
+	}
+
+	if !found {
+		return h.teamNotFound(c, team, "team not found")
+	}
+
+	h.logger.Info("group set",
+		zap.String("team", team),
+		zap.String("name", group.Name),
+		zap.Strings("members", group.Members),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	return c.JSON(status, newGroupResponse(team, group))
+}
+
+// ListGroups handles requests listing the groups of a team.
+func (h *Handler) ListGroups(c echo.Context) error {
+	team := c.Param("team")
+
+	groups, err := h.storage.ListGroups(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list groups of team %q: %w", team, err), "failed to list groups")
+	}
+
+	resp := make([]GroupResponse, 0, len(groups))
+	for _, group := range groups {
+		resp = append(resp, newGroupResponse(team, group))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteGroup handles requests removing a group of a team. Groups that
+// schedules reference cannot be removed.
+func (h *Handler) DeleteGroup(c echo.Context) error {
+	team, name := c.Param("team"), c.Param("name")
+
+	deleted, err := h.storage.DeleteGroup(c.Request().Context(), team, name)
+	if errors.Is(err, storage.ErrGroupInUse) {
+		return c.JSON(http.StatusConflict, ErrorResponse{
+			Error: fmt.Sprintf("group %s is referenced by a schedule, remove it from the schedule first", name),
+		})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("delete group %q of team %q: %w", name, team, err), "failed to delete group")
+	}
+
+	if !deleted {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "group not found"})
+	}
+
+	h.logger.Info("group deleted", zap.String("team", team), zap.String("name", name))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// parseGroup validates the name and members of a group. Groups list members
+// only, references to other groups are rejected.
+func parseGroup(name string, members []string) (storage.Group, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return storage.Group{}, fmt.Errorf("name is required")
+	}
+	if len(name) > MaxGroupNameLength || !tagPattern.MatchString(name) {
+		return storage.Group{}, fmt.Errorf("invalid group name %q, use at most %d lowercase letters, digits, dashes and underscores", name, MaxGroupNameLength)
+	}
+
+	if len(members) == 0 {
+		return storage.Group{}, fmt.Errorf("at least one member is required")
+	}
+
+	group := storage.Group{Name: name}
+	for _, member := range members {
+		member = strings.TrimSpace(member)
+		switch {
+		case member == "":
+			return storage.Group{}, fmt.Errorf("members must not be empty")
+		case strings.HasPrefix(member, "@"):
+			return storage.Group{}, fmt.Errorf("groups cannot contain other groups: %s", member)
+		case len(member) > maxTeamMemberNameLength:
+			return storage.Group{}, fmt.Errorf("member names must be at most %d bytes", maxTeamMemberNameLength)
+		case slices.Contains(group.Members, member):
+			return storage.Group{}, fmt.Errorf("member %s is listed more than once", member)
+		}
+		group.Members = append(group.Members, member)
+	}
+
+	return group, nil
+}
+
+// unknownGroup returns the first group referenced by the members that the
+// team does not have.
+func (h *Handler) unknownGroup(ctx context.Context, team string, members []string) (string, bool, error) {
+	if !storage.HasGroupRefs(members) {
+		return "", false, nil
+	}
+
+	groups, err := h.storage.ListGroups(ctx, team)
+	if err != nil {
+		return "", false, err
+	}
+
+	for _, member := range members {
+		name, ok := storage.GroupRef(member)
+		if ok && !slices.ContainsFunc(groups, func(g storage.Group) bool { return g.Name == name }) {
+			return name, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// unknownGroupMessage is the validation error of a reference to a missing group.
+func unknownGroupMessage(group, team string) string {
+	return fmt.Sprintf("unknown group %s of team %s, create it first", group, team)
+}
+
+// groupOf returns the name of the first group listing the member.
+func groupOf(groups []storage.Group, member string) (string, bool) {
+	for _, group := range groups {
+		if slices.Contains(group.Members, member) {
+			return group.Name, true
+		}
+	}
+
+	return "", false
+}
+
+func newGroupResponse(team string, group storage.Group) GroupResponse {
+	return GroupResponse{
+		Team:      team,
+		Name:      group.Name,
+		Members:   group.Members,
+		CreatedAt: group.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt: group.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newGroupServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.PUT("/teams/:team/members/:name", h.SetTeamMember, h.Authenticate(auth.RoleAdmin, "secret"))
+	e.GET("/teams/:team/groups", h.ListGroups)
+	e.POST("/teams/:team/groups", h.CreateGroup, h.Authenticate(auth.RoleAdmin, "secret"))
+	e.PUT("/teams/:team/groups/:name", h.UpdateGroup, h.Authenticate(auth.RoleAdmin, "secret"))
+	e.DELETE("/teams/:team/groups/:name", h.DeleteGroup, h.Authenticate(auth.RoleAdmin, "secret"))
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, store
+}
+
+func TestGroups_CRUD(t *testing.T) {
+	e, _ := newGroupServer(t)
+
+	rec := serveJSON(e, http.MethodPost, "/teams/backend-team/groups", GroupRequest{Name: "storage", Members: []string{"Carol", "Dave"}}, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/teams/backend-team/groups", GroupRequest{Name: "storage", Members: []string{"Carol", "Dave"}}, "secret")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var group GroupResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &group))
+	assert.Equal(t, "backend-team", group.Team)
+	assert.Equal(t, []string{"Carol", "Dave"}, group.Members)
+
+	rec = serveJSON(e, http.MethodPost, "/teams/backend-team/groups", GroupRequest{Name: "storage", Members: []string{"Erin"}}, "secret")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/teams/missing-team/groups", GroupRequest{Name: "storage", Members: []string{"Erin"}}, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveJSON(e, http.MethodPut, "/teams/backend-team/groups/storage", GroupRequest{Members: []string{"Dave", "Erin"}}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodPut, "/teams/backend-team/groups/network", GroupRequest{Members: []string{"Erin"}}, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team/groups", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var groups []GroupResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &groups))
+	require.Len(t, groups, 1)
+	assert.Equal(t, []string{"Dave", "Erin"}, groups[0].Members)
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/groups/storage", nil, "secret")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/groups/storage", nil, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGroups_Validation(t *testing.T) {
+	e, _ := newGroupServer(t)
+
+	rec := serveJSON(e, http.MethodPut, "/teams/backend-team/members/Olivia", TeamMemberRequest{Role: storage.MemberRoleObserver}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	tests := []struct {
+		name string
+		req  GroupRequest
+		err  string
+	}{
+		{"missing name", GroupRequest{Members: []string{"Alice"}}, "name is required"},
+		{"invalid name", GroupRequest{Name: "Storage Team", Members: []string{"Alice"}}, `invalid group name "Storage Team"`},
+		{"no members", GroupRequest{Name: "storage"}, "at least one member is required"},
+		{"nested group", GroupRequest{Name: "storage", Members: []string{"Alice", "@group:network"}}, "groups cannot contain other groups: @group:network"},
+		{"duplicate member", GroupRequest{Name: "storage", Members: []string{"Alice", "Alice"}}, "member Alice is listed more than once"},
+		{"observer", GroupRequest{Name: "storage", Members: []string{"Olivia"}}, "Olivia is an observer of team backend-team"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPost, "/teams/backend-team/groups", tt.req, "secret")
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Contains(t, resp.Error, tt.err)
+		})
+	}
+}
+
+func TestGroups_Schedule(t *testing.T) {
+	e, store := newGroupServer(t)
+
+	req := quotaRequest("backend-team")
+	req.Name, req.Members, req.Days = "Storage", []string{"@group:storage", "Alice"}, []string{"Saturday"}
+
+	// Groups must exist before schedules reference them
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "unknown group storage of team backend-team")
+
+	rec = serveJSON(e, http.MethodPost, "/teams/backend-team/groups", GroupRequest{Name: "storage", Members: []string{"Carol", "Dave"}}, "secret")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	schedule := func() storage.Schedule {
+		t.Helper()
+
+		team, err := store.GetTeam(t.Context(), "backend-team")
+		require.NoError(t, err)
+		require.Len(t, team.Schedules, 2)
+
+		return team.Schedules[1]
+	}
+	assert.Equal(t, []string{"Carol", "Dave", "Alice"}, schedule().Members)
+
+	// Groups in use cannot be deleted, nor their members become observers
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/groups/storage", nil, "secret")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serveJSON(e, http.MethodPut, "/teams/backend-team/members/Carol", TeamMemberRequest{Role: storage.MemberRoleObserver}, "secret")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Carol is in group storage")
+
+	// Exports keep the reference rather than the members of the group
+	backup := scheduleRequest("backend-team", schedule())
+	assert.Equal(t, []string{"@group:storage", "Alice"}, backup.Members)
+
+	var ics bytes.Buffer
+	require.NoError(t, writeCalendar(&ics, "backend-team", []storage.Schedule{schedule()}, "", time.Now()))
+
+	events, err := parseCalendar(&ics)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	imported, err := eventRequest(events[0], "imported-team", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"@group:storage", "Alice"}, imported.Members)
+
+	tests := []struct {
+		name   string
+		modify func(*Request)
+		err    string
+	}{
+		{"current member", func(r *Request) { r.CurrentMember = "Alice" }, "current_member cannot be combined with groups in members, use rotation_offset"},
+		{"empty reference", func(r *Request) { r.Members = []string{"@group:"} }, "invalid group reference @group:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invalid := req
+			invalid.Name = "Invalid"
+			tt.modify(&invalid)
+
+			rec := serveJSON(e, http.MethodPost, "/schedule", invalid, "")
+			require.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.err, resp.Error)
+		})
+	}
+}
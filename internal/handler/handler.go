@@ -1,45 +1,293 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/gcal"
+	"github.com/1995parham-learning/oncall-schedule/internal/integrity"
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
 	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/week"
 	"github.com/labstack/echo/v4"
 	"go.uber.org/zap"
 )
 
 // Handler handles HTTP requests for the on-call schedule API.
 type Handler struct {
-	storage storage.Storage
-	logger  *zap.Logger
+	storage  storage.Storage
+	logger   *zap.Logger
+	readOnly *ReadOnly
+	drain    *Drain
+	events   *notify.Dispatcher
+	oidc     *auth.OIDC
+	apiKeys  []config.APIKeyConfig
+	quotas   config.QuotaConfig
+	// routingKeys are the keys schedules may set in their routing.
+	routingKeys []string
+	// weeks are the week conventions of the teams, the default one for
+	// every team when nil.
+	weeks *week.Conventions
+	// oncallMaxAge is the longest clients are told to cache on-call
+	// answers for.
+	oncallMaxAge time.Duration
+	// hierarchyDepth is how many parents up on-call lookups go.
+	hierarchyDepth int
+	// integrity checks the members the schedules reference.
+	integrity *integrity.Checker
+	// ui is the web UI configuration, it is not served unless enabled.
+	ui config.UIConfig
+	// calendarSync pushes the shifts to the Google calendars of the teams,
+	// nil when it does not.
+	calendarSync *gcal.Syncer
+	// handoffNoteTTL is the longest a handoff note is shown for.
+	handoffNoteTTL time.Duration
+	// migrations reports the schema version of the database, nil without
+	// one. expectedMigration is the version the binary was built with.
+	migrations        Migrations
+	expectedMigration uint
+	// storageHealth tells storage outages apart without calling it, nil
+	// when storage is never known to be down.
+	storageHealth StorageHealth
+	// pinger pings the database for readiness, nil without one.
+	pinger Pinger
+	// dryRun previews the notifications of a team without sending them.
+	dryRun *notify.DryRun
+	// tokenLimits counts the requests of the scoped tokens against their
+	// rate limits.
+	tokenLimits tokenLimiter
+
+	// now is the clock API keys are checked against and relative times
+	// such as now+2h are taken from.
+	now func() time.Time
+
+	allowUnsignedWebhooks bool
 }
 
 // New creates a new handler instance.
 func New(storage storage.Storage, logger *zap.Logger) *Handler {
 	return &Handler{
-		storage: storage,
-		logger:  logger,
+		storage:  storage,
+		logger:   logger,
+		readOnly: &ReadOnly{},
+		drain:    &Drain{},
+		now:      time.Now,
+
+		routingKeys:    config.DefaultRoutingKeys,
+		oncallMaxAge:   config.DefaultOncallMaxAge,
+		hierarchyDepth: config.DefaultHierarchyDepth,
+		handoffNoteTTL: config.DefaultHandoffNoteTTL,
 	}
 }
 
+// ReadOnly returns the read-only mode of the handler.
+func (h *Handler) ReadOnly() *ReadOnly {
+	return h.readOnly
+}
+
+// SetDispatcher sets the dispatcher that schedule changes are published
+// through. Without one no events are published.
+func (h *Handler) SetDispatcher(d *notify.Dispatcher) {
+	h.events = d
+}
+
+// SetWeeks sets the week conventions of the teams.
+func (h *Handler) SetWeeks(weeks *week.Conventions) {
+	h.weeks = weeks
+}
+
+// Migrations reports the schema version of the database and whether its last
+// migration failed halfway.
+type Migrations interface {
+	MigrationStatus(ctx context.Context) (version uint, dirty bool, err error)
+}
+
+// SetMigrations sets the database health reports the migration status of,
+// along with the version the binary expects it at. Without one, as with the
+// memory storage, health reports no migrations.
+func (h *Handler) SetMigrations(m Migrations, expected uint) {
+	h.migrations = m
+	h.expectedMigration = expected
+}
+
+// Drain returns the shutdown coordinator of the handler.
+func (h *Handler) Drain() *Drain {
+	return h.drain
+}
+
 // Request represents the schedule creation request.
 type Request struct {
-	Name    string   `json:"name"`
-	Team    string   `json:"team"`
-	Members []string `json:"members"`
-	Days    []string `json:"days"`
-	Start   string   `json:"start"`
-	End     string   `json:"end"`
+	// ID is set in listings, see listedSchedule, and ignored on creation.
+	ID   string `json:"id,omitempty" yaml:"-"`
+	Name string `json:"name" yaml:"name"`
+	// Description is a short summary of what the schedule covers.
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Notes is longer context, markdown is allowed and stored verbatim.
+	Notes   string   `json:"notes,omitempty" yaml:"notes,omitempty"`
+	Team    string   `json:"team" yaml:"team,omitempty"`
+	Members []string `json:"members" yaml:"members"`
+	// UseTeamMembers rotates through the members of the team, in the order
+	// they joined it, in place of Members.
+	UseTeamMembers bool     `json:"use_team_members,omitempty" yaml:"use_team_members,omitempty"`
+	Days           []string `json:"days" yaml:"days,omitempty"`
+	Cron           string   `json:"cron,omitempty" yaml:"cron,omitempty"`
+	RRule          string   `json:"rrule,omitempty" yaml:"rrule,omitempty"`
+	Anchor         string   `json:"anchor,omitempty" yaml:"anchor,omitempty"`
+	Start          string   `json:"start" yaml:"start"`
+	End            string   `json:"end" yaml:"end"`
+	// ValidUntil is the RFC3339 instant the schedule ends at, it never ends when empty.
+	ValidUntil string `json:"valid_until,omitempty" yaml:"valid_until,omitempty"`
+	// Tags group schedules across teams, see parseTags.
+	Tags []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	// Routing tells the alert router where to page the member on duty, by
+	// keys of the allow-list, see validateRouting.
+	Routing map[string]string `json:"routing,omitempty" yaml:"routing,omitempty"`
+	// RotationOffset is the index of the member on duty during the first
+	// week of the rotation, which starts at the anchor.
+	RotationOffset int `json:"rotation_offset,omitempty" yaml:"rotation_offset,omitempty"`
+	// CurrentMember sets the rotation offset so the member is on duty now.
+	// It is exclusive with RotationOffset and never returned.
+	CurrentMember string `json:"current_member,omitempty" yaml:"current_member,omitempty"`
+	// CurrentUntil is the RFC3339 instant CurrentMember stays on duty until,
+	// the anchor and handoff of the rotation are derived from it, see
+	// seedRotation. It is never returned.
+	CurrentUntil string `json:"current_until,omitempty" yaml:"current_until,omitempty"`
+	// Assignment is either AssignmentRotation, the default, or AssignmentFixed.
+	Assignment string `json:"assignment,omitempty" yaml:"assignment,omitempty"`
+	// Rotation is either RotationAutomatic, the default, or RotationManual.
+	Rotation string `json:"rotation,omitempty" yaml:"rotation,omitempty"`
+	// Split divides every shift evenly between as many consecutive members.
+	Split int `json:"split,omitempty" yaml:"split,omitempty"`
+	// RequiredCount is how many consecutive members of the rotation are on
+	// duty together for every shift, one when zero.
+	RequiredCount int `json:"required_count,omitempty" yaml:"required_count,omitempty"`
+	// Compensation tags the hours on duty for the schedule with the rate
+	// class they are paid at, see TeamCompensation.
+	Compensation *Compensation `json:"compensation,omitempty" yaml:"compensation,omitempty"`
+	// Handoff is when the rotation moves on to the next member every week,
+	// the start of the shifts when empty.
+	Handoff *Handoff `json:"handoff,omitempty" yaml:"handoff,omitempty"`
+	// DayAssignments maps weekdays to the member always on duty on them,
+	// in place of Members for fixed assignment.
+	DayAssignments map[string]string `json:"day_assignments,omitempty" yaml:"day_assignments,omitempty"`
+	// InheritedFrom is the ancestor of the team the schedule belongs to in
+	// effective listings, and ignored on creation.
+	InheritedFrom string `json:"inherited_from,omitempty" yaml:"-"`
+}
+
+// Handoff is the weekly handoff of a rotation, in UTC.
+type Handoff struct {
+	// Day is a weekday written like in Request.Days, without ranges.
+	Day string `json:"day" yaml:"day"`
+	// Time is in '3:04PM' format.
+	Time string `json:"time" yaml:"time"`
+}
+
+// Compensation is the rate class the hours on duty for a schedule are paid
+// at, and the one of the hours on the weekend days of the team if they differ.
+type Compensation struct {
+	RateClass  string  `json:"rate_class" yaml:"rate_class"`
+	Multiplier float64 `json:"multiplier" yaml:"multiplier"`
+	// WeekendRateClass and WeekendMultiplier are either both set or both
+	// empty, in which case the weekend hours are in RateClass.
+	WeekendRateClass  string  `json:"weekend_rate_class,omitempty" yaml:"weekend_rate_class,omitempty"`
+	WeekendMultiplier float64 `json:"weekend_multiplier,omitempty" yaml:"weekend_multiplier,omitempty"`
 }
 
-// ErrorResponse represents an error response.
+// Assignment modes of a schedule. Rotation schedules take turns through their
+// members, fixed ones have the same member on duty on each weekday.
+const (
+	AssignmentRotation = "rotation"
+	AssignmentFixed    = "fixed"
+)
+
+// Keywords of the days of a request, which expand by the week convention of
+// the team.
+const (
+	DaysWeekdays = "weekdays"
+	DaysWeekends = "weekends"
+)
+
+// Rotation modes of a rotation schedule. Automatic schedules hand over to the
+// next member every week from their anchor, manual ones keep their member on
+// duty until the schedule is advanced.
+const (
+	RotationAutomatic = "automatic"
+	RotationManual    = "manual"
+)
+
+// MaxDescriptionLength and MaxNotesLength bound the description and notes of
+// a schedule, in characters.
+const (
+	MaxDescriptionLength = 256
+	MaxNotesLength       = 4096
+)
+
+// ErrorResponse represents an error response. Code is a machine-readable
+// identifier set for errors that clients are expected to tell apart.
 type ErrorResponse struct {
 	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+	// CorrelationID is the request ID of 5xx responses, which the logs of
+	// the failure carry too.
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// HealthResponse represents the health check response.
+type HealthResponse struct {
+	Status         string           `json:"status"`
+	ReadOnly       bool             `json:"read_only"`
+	ReadOnlyReason string           `json:"read_only_reason,omitempty"`
+	Migrations     *MigrationHealth `json:"migrations,omitempty"`
+}
+
+// MigrationHealth represents the migration status of the database along with
+// the version the binary expects it at.
+type MigrationHealth struct {
+	Version  uint   `json:"version"`
+	Dirty    bool   `json:"dirty"`
+	Expected uint   `json:"expected"`
+	Error    string `json:"error,omitempty"`
+}
+
+// OncallResponse represents the current oncall lookup response.
+type OncallResponse struct {
+	Oncall string `json:"oncall"`
+	// Oncalls are all the members on call for schedules requiring more than
+	// one, Oncall first.
+	Oncalls []string `json:"oncalls,omitempty"`
+	// SubstitutedFor is the member on call by the schedule when they are
+	// unavailable and Oncall takes over from them.
+	SubstitutedFor string `json:"substituted_for,omitempty"`
+	Time           string `json:"time"`
+	Local          string `json:"local,omitempty"`
+	Stale          bool   `json:"stale,omitempty"`
+	Warning        string `json:"warning,omitempty"`
+	// Routing is the routing of the schedule the member is on duty for.
+	Routing map[string]string `json:"routing,omitempty"`
+	// InheritedFrom is the ancestor of the team the member is on call for
+	// when no schedule of the team itself matches.
+	InheritedFrom string `json:"inherited_from,omitempty"`
+	// HandoffNote is the note left by the member on call for the previous
+	// shift, shown until it expires.
+	HandoffNote *HandoffNoteResponse `json:"handoff_note,omitempty"`
 }
 
+// localLayout is the human-readable layout used for the local field, e.g. "17:00 Sat".
+const localLayout = "15:04 Mon"
+
+// HeaderOncallStale marks responses served from the stale cache while storage is unavailable.
+const HeaderOncallStale = "X-Oncall-Stale"
+
 // CreateSchedule handles schedule creation requests.
 func (h *Handler) CreateSchedule(c echo.Context) error {
 	var req Request
@@ -49,55 +297,68 @@ func (h *Handler) CreateSchedule(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
 	}
 
-	// Validate request
-	if err := h.validateRequest(&req); err != nil {
+	schedule, err := h.parseRequest(&req)
+	if err != nil {
 		h.logger.Warn("invalid request", zap.Error(err))
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
-	var schedule storage.Schedule
-	schedule.Name = req.Name
-	schedule.Members = req.Members
+	// Restored backups may hold ended schedules, new ones must not end right away
+	if !schedule.ValidUntil.IsZero() && !schedule.ValidUntil.After(time.Now()) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "valid_until must be in the future"})
+	}
 
-	// Parse days
-	for _, d := range req.Days {
-		day, err := parseWeekday(d)
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("invalid day: %s", d)})
-		}
-		schedule.Days = append(schedule.Days, day)
+	if frozen, err := h.rejectFrozen(c, req.Team, "create schedule "+req.Name); frozen {
+		return err
 	}
 
-	// Parse times
-	start, err := time.Parse(time.Kitchen, req.Start)
+	ctx := h.withQuota(c.Request().Context(), storage.QuotaSchedules, req.Team)
+
+	observer, found, err := h.observerIn(ctx, req.Team, schedule.Members)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid start time format, use '3:04PM' format"})
+		return h.storageFailure(c, fmt.Errorf("list members of team %q: %w", req.Team, err), "failed to create schedule")
+	}
+	if found {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: observerMessage(observer, req.Team)})
 	}
-	schedule.Start = start
 
-	end, err := time.Parse(time.Kitchen, req.End)
+	group, found, err := h.unknownGroup(ctx, req.Team, schedule.MemberRefs)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid end time format, use '3:04PM' format"})
+		return h.storageFailure(c, fmt.Errorf("list groups of team %q: %w", req.Team, err), "failed to create schedule")
+	}
+	if found {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: unknownGroupMessage(group, req.Team)})
 	}
-	schedule.End = end
 
-	// Validate time range
-	if !start.Before(end) {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "start time must be before end time"})
+	// Advisory checks run before adding, so the schedule does not overlap itself
+	warnings, err := h.adviseSchedule(ctx, req.Team, schedule, "")
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("check schedule %q for team %q: %w", req.Name, req.Team, err), "failed to create schedule")
 	}
 
-	if err := h.storage.AddSchedule(req.Team, schedule); err != nil {
-		h.logger.Error("failed to add schedule", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to create schedule"})
+	id, err := h.storage.AddSchedule(ctx, req.Team, schedule)
+	if errors.Is(err, storage.ErrScheduleExists) {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "schedule already exists"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add schedule %q for team %q: %w", req.Name, req.Team, err), "failed to create schedule")
 	}
 
 	h.logger.Info("schedule created",
+		zap.String("id", id),
 		zap.String("team", req.Team),
 		zap.String("name", req.Name),
 		zap.Strings("members", req.Members),
 	)
 
-	return c.NoContent(http.StatusCreated)
+	event := notify.NewEvent(notify.KindScheduleChange, req.Team, time.Now())
+	event.Schedule = req.Name
+	event.Change = notify.ChangeCreated
+	h.events.Publish(event)
+
+	c.Response().Header().Set(echo.HeaderLocation, "/schedule/"+id)
+
+	return c.JSON(http.StatusCreated, CreateScheduleResponse{ID: id, Team: req.Team, Name: req.Name, Warnings: warnings})
 }
 
 // GetSchedule handles schedule retrieval requests.
@@ -112,30 +373,464 @@ func (h *Handler) GetSchedule(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "time query parameter is required"})
 	}
 
-	askTime, err := time.Parse(time.RFC3339, timeStr)
+	// The display zone only affects rendering and the midnight of dates, the
+	// lookup itself is zone independent
+	tz := c.QueryParam("tz")
+	loc, err := parseLocation(tz)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	askTime, err := h.parseTime(timeStr, "time", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	asConfigured, err := parseAsConfigured(c)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid time format, use RFC3339 format"})
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
 	}
 
-	// Use the new GetCurrentOncall method which returns the currently oncall person
-	oncall, found, err := h.storage.GetCurrentOncall(team, askTime)
+	// A paused team has nobody on call, an expired pause is simply ignored
+	pause, paused, err := h.activePause(c.Request().Context(), team, askTime)
 	if err != nil {
-		h.logger.Error("failed to get current oncall", zap.Error(err))
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: "failed to retrieve oncall information"})
+		return h.storageFailure(c, fmt.Errorf("get pause of team %q: %w", team, err), "failed to retrieve oncall information")
+	}
+
+	if paused {
+		return c.JSON(http.StatusConflict, pausedResponse(pause, loc))
 	}
 
+	var oncall, warning string
+	var found, stale, missing bool
+	// schedules are the ones the answer comes from, for its routing and substitutes
+	var schedules []storage.Schedule
+	if asConfigured {
+		// Answer from the schedules live at the time, later changes aside
+		var history []storage.TeamVersion
+		history, warning, err = h.teamHistory(c.Request().Context(), team, askTime, askTime)
+		missing = errors.Is(err, storage.ErrTeamNotFound)
+		if err != nil && !missing {
+			return h.storageFailure(c, err, "failed to retrieve oncall information")
+		}
+		if !missing {
+			schedules = history[0].Team.Schedules
+			oncall, found = storage.OncallAt(schedules, askTime)
+		}
+	} else {
+		// Use the new GetCurrentOncall method which returns the currently oncall person
+		oncall, err = h.storage.GetCurrentOncall(c.Request().Context(), team, askTime)
+		stale = errors.Is(err, storage.ErrStale)
+		missing = errors.Is(err, storage.ErrTeamNotFound)
+		if err != nil && !stale && !storage.NobodyOnCall(err) {
+			return h.storageFailure(c, fmt.Errorf("get current oncall of team %q: %w", team, err), "failed to retrieve oncall information")
+		}
+		found = err == nil || stale
+
+		// A stale answer means storage is failing, it is served as it is
+		if found && !stale {
+			// A team deleted since has nothing to route with
+			t, err := h.storage.GetTeam(c.Request().Context(), team)
+			if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+				return h.storageFailure(c, fmt.Errorf("get team %q: %w", team, err), "failed to retrieve oncall information")
+			}
+			schedules = t.Schedules
+		}
+	}
+
+	// A team with nothing matching falls back on its ancestors
+	var inheritedFrom string
+	if !found && !stale && !asConfigured {
+		oncall, schedules, inheritedFrom, found, err = h.inheritedOncall(c.Request().Context(), team, askTime)
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("get inherited oncall of team %q: %w", team, err), "failed to retrieve oncall information")
+		}
+	}
+
+	if !found && stale {
+		h.cacheUncovered(c)
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "no oncall member found for the given time"})
+	}
+	// An unknown team is told apart from a team nobody covers at the time
+	if !found && missing {
+		h.cacheUncovered(c)
+		return h.teamNotFound(c, team, "team not found")
+	}
 	if !found {
+		h.cacheUncovered(c)
 		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "no oncall member found for the given time"})
 	}
 
+	// Pages go to the next available member when the one on call is out
+	var substitutedFor string
+	if !stale {
+		member, err := storage.Substitute(c.Request().Context(), h.storage, schedules, oncall, askTime)
+		if errors.Is(err, storage.ErrAllUnavailable) {
+			h.cacheUncovered(c)
+			return c.JSON(http.StatusNotFound, ErrorResponse{Error: fmt.Sprintf("%s and every member who could take over are unavailable", oncall), Code: CodeAllUnavailable})
+		}
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("substitute oncall %q of team %q: %w", oncall, team, err), "failed to retrieve oncall information")
+		}
+		if member != oncall {
+			substitutedFor, oncall = oncall, member
+		}
+	}
+
+	// Schedules requiring several members answer with all of them, the
+	// substitute taking the place of the first one
+	var oncalls []string
+	if members, ok := storage.OncallsAt(schedules, askTime); ok && len(members) > 1 {
+		oncalls = members
+		oncalls[0] = oncall
+	}
+
 	h.logger.Info("oncall member found",
 		zap.String("team", team),
 		zap.String("oncall", oncall),
+		zap.String("inherited_from", inheritedFrom),
 		zap.Time("time", askTime),
 	)
 
 	// Return single oncall member instead of array
-	return c.JSON(http.StatusOK, map[string]string{"oncall": oncall})
+	resp := OncallResponse{
+		Oncall:         oncall,
+		Oncalls:        oncalls,
+		SubstitutedFor: substitutedFor,
+		Time:           askTime.In(loc).Format(time.RFC3339),
+		Routing:        scheduleRouting(schedules, askTime),
+		InheritedFrom:  inheritedFrom,
+	}
+	if tz != "" {
+		resp.Local = askTime.In(loc).Format(localLayout)
+	}
+	// Notes come from the team the answer comes from, storage being up
+	if !stale && !asConfigured {
+		noteTeam := team
+		if inheritedFrom != "" {
+			noteTeam = inheritedFrom
+		}
+		resp.HandoffNote, err = h.visibleHandoffNote(c.Request().Context(), noteTeam, askTime)
+		if err != nil {
+			return h.storageFailure(c, err, "failed to retrieve oncall information")
+		}
+	}
+	if stale {
+		h.logger.Warn("serving stale oncall information", zap.String("team", team))
+		c.Response().Header().Set(HeaderOncallStale, "true")
+		resp.Stale = true
+		h.cacheUncovered(c)
+	} else {
+		h.cacheOncall(c, schedules, askTime)
+	}
+	if warning != "" {
+		c.Response().Header().Set(HeaderOncallWarning, warning)
+		resp.Warning = warning
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// parseRequest validates a schedule creation request and builds the schedule
+// it describes. The returned errors are meant for the client.
+func (h *Handler) parseRequest(req *Request) (storage.Schedule, error) {
+	if err := h.validateRequest(req); err != nil {
+		return storage.Schedule{}, err
+	}
+
+	var schedule storage.Schedule
+	schedule.Name = req.Name
+	schedule.Description = req.Description
+	schedule.Notes = req.Notes
+
+	tags, err := parseTags(req.Tags)
+	if err != nil {
+		return storage.Schedule{}, err
+	}
+	schedule.Tags = tags
+	if len(req.Routing) > 0 {
+		schedule.Routing = req.Routing
+	}
+	schedule.Members = req.Members
+	schedule.TeamMembers = req.UseTeamMembers
+	// References to groups are expanded by the storage, the members given
+	// directly are kept on the roster like the others
+	if storage.HasGroupRefs(req.Members) {
+		schedule.MemberRefs = req.Members
+		schedule.Members = slices.DeleteFunc(slices.Clone(req.Members), func(member string) bool {
+			_, ok := storage.GroupRef(member)
+			return ok
+		})
+	}
+
+	// Parse anchor, defaults to the creation day
+	schedule.Anchor = time.Now().UTC().Truncate(24 * time.Hour)
+	if req.Anchor != "" {
+		anchor, err := time.Parse(time.DateOnly, req.Anchor)
+		if err != nil {
+			return storage.Schedule{}, fmt.Errorf("invalid anchor format, use '2006-01-02' format")
+		}
+		schedule.Anchor = anchor
+	}
+
+	// Parse the recurrence, either a cron expression, an RRULE, or a list of days
+	switch {
+	case req.Cron != "":
+		if _, err := storage.ParseCron(req.Cron); err != nil {
+			return storage.Schedule{}, err
+		}
+		schedule.Cron = req.Cron
+	case req.RRule != "":
+		schedule.RRule = strings.TrimPrefix(strings.TrimSpace(req.RRule), "RRULE:")
+	default:
+		days, err := parseDays(req.Days, h.weeks.Of(req.Team))
+		if err != nil {
+			return storage.Schedule{}, err
+		}
+		schedule.Days = days
+	}
+
+	if req.Assignment == AssignmentFixed {
+		if err := setDayAssignments(&schedule, req); err != nil {
+			return storage.Schedule{}, err
+		}
+	}
+
+	// Parse times
+	start, err := time.Parse(time.Kitchen, req.Start)
+	if err != nil {
+		return storage.Schedule{}, fmt.Errorf("invalid start time format, use '3:04PM' format")
+	}
+	schedule.Start = start
+
+	end, err := time.Parse(time.Kitchen, req.End)
+	if err != nil {
+		return storage.Schedule{}, fmt.Errorf("invalid end time format, use '3:04PM' format")
+	}
+	schedule.End = end
+
+	// Validate time range
+	if !start.Before(end) {
+		return storage.Schedule{}, fmt.Errorf("start time must be before end time")
+	}
+
+	// Every part of a split shift lasts at least a minute
+	if req.Split > int(end.Sub(start)/time.Minute) {
+		return storage.Schedule{}, fmt.Errorf("shifts are too short to be split between %d members", req.Split)
+	}
+	schedule.Split = req.Split
+	schedule.RequiredCount = req.RequiredCount
+	if req.Compensation != nil {
+		schedule.Compensation = &storage.Compensation{
+			RateClass:         strings.TrimSpace(req.Compensation.RateClass),
+			Multiplier:        req.Compensation.Multiplier,
+			WeekendRateClass:  strings.TrimSpace(req.Compensation.WeekendRateClass),
+			WeekendMultiplier: req.Compensation.WeekendMultiplier,
+		}
+	}
+
+	if req.ValidUntil != "" {
+		validUntil, err := time.Parse(time.RFC3339, req.ValidUntil)
+		if err != nil {
+			return storage.Schedule{}, fmt.Errorf("invalid valid_until format, use RFC3339 format")
+		}
+		schedule.ValidUntil = validUntil.UTC()
+	}
+
+	schedule.Manual = req.Rotation == RotationManual
+
+	if req.Handoff != nil {
+		handoff, err := parseHandoff(req.Handoff, schedule)
+		if err != nil {
+			return storage.Schedule{}, err
+		}
+		schedule.Handoff = &handoff
+	}
+
+	if err := setRotationOffset(&schedule, req, h.now()); err != nil {
+		return storage.Schedule{}, err
+	}
+
+	// The rule starts at the anchor and start time, so it is checked once both are known
+	if schedule.RRule != "" {
+		if _, err := storage.ParseRRule(schedule.RRule, schedule.RRuleStart()); err != nil {
+			return storage.Schedule{}, err
+		}
+	}
+
+	return schedule, nil
+}
+
+// parseHandoff parses the handoff of a schedule. The handoff day has to be
+// one the schedule covers, unless its shifts last the whole day. The days of
+// cron and RRULE schedules are not checked.
+func parseHandoff(req *Handoff, schedule storage.Schedule) (storage.Handoff, error) {
+	day, err := parseWeekday(strings.TrimSpace(req.Day))
+	if err != nil {
+		return storage.Handoff{}, fmt.Errorf("invalid handoff day: %s", req.Day)
+	}
+
+	clock, err := time.Parse(time.Kitchen, req.Time)
+	if err != nil {
+		return storage.Handoff{}, fmt.Errorf("invalid handoff time format, use '3:04PM' format")
+	}
+
+	allDay := schedule.Start.Hour() == 0 && schedule.Start.Minute() == 0 && schedule.End.Hour() == 23 && schedule.End.Minute() == 59
+	if len(schedule.Days) > 0 && !allDay && !slices.Contains(schedule.Days, day) {
+		return storage.Handoff{}, fmt.Errorf("handoff day %s is not one of the days of the schedule", day)
+	}
+
+	return storage.Handoff{Day: day, Time: clock}, nil
+}
+
+// setRotationOffset sets the rotation offset of the schedule from the request,
+// either as given or so that its current member is on duty at now.
+func setRotationOffset(schedule *storage.Schedule, req *Request, now time.Time) error {
+	if len(schedule.DayAssignments) > 0 {
+		if req.RotationOffset != 0 || req.CurrentMember != "" {
+			return fmt.Errorf("rotation_offset and current_member cannot be combined with fixed assignment")
+		}
+		return nil
+	}
+
+	// The members of the team or of groups are only known once the schedule
+	// is read, so the offset is taken modulo their number then
+	if schedule.TeamMembers || len(schedule.MemberRefs) > 0 {
+		if req.CurrentMember != "" && schedule.TeamMembers {
+			return fmt.Errorf("current_member cannot be combined with use_team_members, use rotation_offset")
+		}
+		if req.CurrentMember != "" {
+			return fmt.Errorf("current_member cannot be combined with groups in members, use rotation_offset")
+		}
+		if req.RotationOffset < 0 {
+			return fmt.Errorf("rotation_offset must not be negative")
+		}
+		schedule.RotationOffset = req.RotationOffset
+		return nil
+	}
+
+	n := len(schedule.Members)
+
+	if req.CurrentMember == "" {
+		if req.RotationOffset < 0 || req.RotationOffset >= n {
+			return fmt.Errorf("rotation_offset must be between 0 and %d", n-1)
+		}
+		schedule.RotationOffset = req.RotationOffset
+		return nil
+	}
+
+	if req.RotationOffset != 0 {
+		return fmt.Errorf("rotation_offset and current_member are mutually exclusive")
+	}
+
+	index := slices.Index(schedule.Members, req.CurrentMember)
+	if index == -1 {
+		return fmt.Errorf("current_member %s is not a member of the schedule", req.CurrentMember)
+	}
+
+	if req.CurrentUntil != "" {
+		until, err := time.Parse(time.RFC3339, req.CurrentUntil)
+		if err != nil {
+			return fmt.Errorf("invalid current_until format, use RFC3339 format")
+		}
+		if err := seedRotation(schedule, until, now); err != nil {
+			return err
+		}
+	}
+
+	schedule.RotationOffset = ((index-schedule.RotationPeriods(now))%n + n) % n
+
+	return nil
+}
+
+// seedRotation sets the anchor and handoff of the schedule so the rotation
+// hands over at the given instant, which makes the rotation period running
+// at now end there. It reproduces the position of a rotation imported from
+// another system exactly: the current member stays on duty until the
+// instant, in the middle of a shift if need be, and the next members take
+// over every week from then. The instant has to be on a whole minute, after
+// now and at most a rotation period away, and on a day of the schedule or
+// its handoff if it has one.
+func seedRotation(schedule *storage.Schedule, until, now time.Time) error {
+	until = until.UTC()
+
+	if !until.After(now) {
+		return fmt.Errorf("current_until must be in the future")
+	}
+	if until.Sub(now) > storage.RotationPeriod {
+		return fmt.Errorf("current_until must be at most %d days away", int(storage.RotationPeriod.Hours()/24))
+	}
+	if until.Second() != 0 || until.Nanosecond() != 0 {
+		return fmt.Errorf("current_until must be on a whole minute")
+	}
+
+	// Like given handoffs, the derived one is on a day the schedule covers
+	handoff, err := parseHandoff(&Handoff{Day: until.Weekday().String(), Time: until.Format(time.Kitchen)}, *schedule)
+	if err != nil {
+		return fmt.Errorf("current_until must be on one of the days of the schedule")
+	}
+	if schedule.Handoff != nil && (schedule.Handoff.Day != handoff.Day || !schedule.Handoff.Time.Equal(handoff.Time)) {
+		return fmt.Errorf("current_until must be at the handoff of the schedule")
+	}
+
+	// The periods are counted from the first handoff at or after midnight
+	// of the anchor, which is a week before the instant
+	schedule.Handoff = &handoff
+	schedule.Anchor = storage.PinDate(until).AddDate(0, 0, -7)
+
+	return nil
+}
+
+// setDayAssignments sets the fixed assignments of the schedule from the
+// request. The days default to the assigned ones, and days that are given
+// must all have an assignee. The members are the assignees, in the order of
+// their first day.
+func setDayAssignments(schedule *storage.Schedule, req *Request) error {
+	assignments := make(map[time.Weekday]string, len(req.DayAssignments))
+	for _, key := range slices.Sorted(maps.Keys(req.DayAssignments)) {
+		day, err := parseWeekday(strings.TrimSpace(key))
+		if err != nil {
+			return fmt.Errorf("invalid day: %s", key)
+		}
+		if _, ok := assignments[day]; ok {
+			return fmt.Errorf("day %s is assigned more than once", day)
+		}
+
+		member := strings.TrimSpace(req.DayAssignments[key])
+		if member == "" {
+			return fmt.Errorf("day %s has no assignee", day)
+		}
+		assignments[day] = member
+	}
+
+	if len(schedule.Days) == 0 {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if _, ok := assignments[day]; ok {
+				schedule.Days = append(schedule.Days, day)
+			}
+		}
+	}
+
+	for _, day := range schedule.Days {
+		if _, ok := assignments[day]; !ok {
+			return fmt.Errorf("day %s has no assignee", day)
+		}
+	}
+	for day := range assignments {
+		if !slices.Contains(schedule.Days, day) {
+			return fmt.Errorf("day %s is assigned but not listed in days", day)
+		}
+	}
+
+	schedule.Members = nil
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if member, ok := assignments[day]; ok && !slices.Contains(schedule.Members, member) {
+			schedule.Members = append(schedule.Members, member)
+		}
+	}
+	schedule.DayAssignments = assignments
+
+	return nil
 }
 
 // validateRequest validates the schedule creation request.
@@ -144,11 +839,107 @@ func (h *Handler) validateRequest(req *Request) error {
 		return fmt.Errorf("team is required")
 	}
 
-	if len(req.Members) == 0 {
-		return fmt.Errorf("at least one member is required")
+	switch req.Assignment {
+	case "", AssignmentRotation:
+		if req.UseTeamMembers && len(req.Members) > 0 {
+			return fmt.Errorf("members cannot be combined with use_team_members")
+		}
+		if !req.UseTeamMembers && len(req.Members) == 0 {
+			return fmt.Errorf("at least one member is required")
+		}
+		if len(req.DayAssignments) > 0 {
+			return fmt.Errorf("day_assignments requires fixed assignment")
+		}
+		for _, member := range req.Members {
+			if name, ok := storage.GroupRef(member); ok && name == "" {
+				return fmt.Errorf("invalid group reference %s", member)
+			}
+		}
+		// The number of members of the team or of groups changes, the parts
+		// wrap around it
+		if req.Split < 0 || !req.UseTeamMembers && !storage.HasGroupRefs(req.Members) && req.Split > len(req.Members) {
+			return fmt.Errorf("split must be between 0 and the number of members")
+		}
+		if req.RequiredCount < 0 || !req.UseTeamMembers && !storage.HasGroupRefs(req.Members) && req.RequiredCount > len(req.Members) {
+			return fmt.Errorf("required_count must be between 1 and the number of members")
+		}
+		if req.CurrentUntil != "" {
+			switch {
+			case req.CurrentMember == "":
+				return fmt.Errorf("current_until requires current_member")
+			case req.Anchor != "":
+				return fmt.Errorf("anchor and current_until are mutually exclusive")
+			case req.RRule != "":
+				return fmt.Errorf("current_until cannot be combined with rrule, which starts at the anchor")
+			case req.Rotation == RotationManual:
+				return fmt.Errorf("current_until cannot be combined with manual rotation")
+			}
+		}
+	case AssignmentFixed:
+		if len(req.Members) > 0 {
+			return fmt.Errorf("members cannot be combined with fixed assignment, use day_assignments")
+		}
+		if req.UseTeamMembers {
+			return fmt.Errorf("use_team_members cannot be combined with fixed assignment, use day_assignments")
+		}
+		if len(req.DayAssignments) == 0 {
+			return fmt.Errorf("at least one day assignment is required")
+		}
+		if req.Cron != "" || req.RRule != "" {
+			return fmt.Errorf("fixed assignment requires days instead of cron or rrule")
+		}
+		if req.Rotation != "" {
+			return fmt.Errorf("rotation cannot be combined with fixed assignment")
+		}
+		if req.Split > 1 {
+			return fmt.Errorf("split cannot be combined with fixed assignment")
+		}
+		if req.RequiredCount > 1 {
+			return fmt.Errorf("required_count cannot be combined with fixed assignment")
+		}
+		if req.CurrentUntil != "" {
+			return fmt.Errorf("current_until cannot be combined with fixed assignment")
+		}
+		if req.Handoff != nil {
+			return fmt.Errorf("handoff cannot be combined with fixed assignment")
+		}
+	default:
+		return fmt.Errorf("assignment must be %s or %s", AssignmentRotation, AssignmentFixed)
+	}
+
+	if req.Rotation != "" && req.Rotation != RotationAutomatic && req.Rotation != RotationManual {
+		return fmt.Errorf("rotation must be %s or %s", RotationAutomatic, RotationManual)
+	}
+
+	if utf8.RuneCountInString(req.Description) > MaxDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", MaxDescriptionLength)
+	}
+
+	if utf8.RuneCountInString(req.Notes) > MaxNotesLength {
+		return fmt.Errorf("notes must be at most %d characters", MaxNotesLength)
+	}
+
+	if err := h.validateRouting(req.Routing); err != nil {
+		return err
+	}
+
+	if err := validateCompensation(req.Compensation); err != nil {
+		return err
+	}
+
+	recurrences := 0
+	// The days of fixed assignment default to the assigned ones
+	for _, set := range []bool{len(req.Days) > 0 || len(req.DayAssignments) > 0, req.Cron != "", req.RRule != ""} {
+		if set {
+			recurrences++
+		}
+	}
+
+	if recurrences > 1 {
+		return fmt.Errorf("days, cron and rrule are mutually exclusive")
 	}
 
-	if len(req.Days) == 0 {
+	if recurrences == 0 {
 		return fmt.Errorf("at least one day is required")
 	}
 
@@ -163,17 +954,124 @@ func (h *Handler) validateRequest(req *Request) error {
 	return nil
 }
 
-// Health handles health check requests.
+// Health handles health check requests. It fails with a 503 once the server
+// starts draining, so load balancers stop routing traffic to it. A database
+// whose migrations are dirty, or not at the version the binary expects, is
+// reported as degraded without failing the check.
 func (h *Handler) Health(c echo.Context) error {
-	return c.JSON(http.StatusOK, map[string]string{
-		"status": "healthy",
+	readOnly, reason := h.readOnly.State()
+
+	status, code := "healthy", http.StatusOK
+
+	var migrations *MigrationHealth
+	if h.migrations != nil {
+		migrations = &MigrationHealth{Expected: h.expectedMigration}
+
+		version, dirty, err := h.migrations.MigrationStatus(c.Request().Context())
+		if err != nil {
+			h.logger.Warn("failed to read migration status", zap.Error(err))
+			migrations.Error = "failed to read migration status"
+		}
+		migrations.Version, migrations.Dirty = version, dirty
+
+		if err != nil || dirty || version != h.expectedMigration {
+			status = "degraded"
+		}
+	}
+
+	if !h.drain.Ready() {
+		status, code = "draining", http.StatusServiceUnavailable
+	}
+
+	return c.JSON(code, HealthResponse{
+		Status:         status,
+		ReadOnly:       readOnly,
+		ReadOnlyReason: reason,
+		Migrations:     migrations,
 	})
 }
 
-// parseWeekday parses a weekday string into time.Weekday.
+// parseLocation parses the tz query parameter as an IANA zone name.
+// An empty value means UTC.
+func parseLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tz query parameter: unknown time zone %q", tz)
+	}
+
+	return loc, nil
+}
+
+// parseDays parses the days of a request. Each entry is either a single
+// weekday, an inclusive range such as "Mon-Fri", which wraps around the end
+// of the week ("Fri-Mon" is Friday through Monday), or one of the keywords
+// "weekdays" and "weekends", which expand to the days outside and on the
+// weekend of the team's week. Duplicates are dropped.
+func parseDays(entries []string, wk week.Week) ([]time.Weekday, error) {
+	var days []time.Weekday
+	seen := make(map[time.Weekday]bool)
+
+	for _, entry := range entries {
+		var keyword []time.Weekday
+		switch strings.ToLower(strings.TrimSpace(entry)) {
+		case DaysWeekdays:
+			keyword = wk.Weekdays()
+		case DaysWeekends:
+			keyword = wk.WeekendDays()
+		}
+		if keyword != nil {
+			for _, wd := range keyword {
+				if !seen[wd] {
+					seen[wd] = true
+					days = append(days, wd)
+				}
+			}
+			continue
+		}
+
+		from, to, isRange := strings.Cut(entry, "-")
+		if !isRange {
+			to = from
+		}
+
+		first, err := parseWeekday(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("invalid day: %s", entry)
+		}
+		last, err := parseWeekday(strings.TrimSpace(to))
+		if err != nil {
+			return nil, fmt.Errorf("invalid day: %s", entry)
+		}
+
+		for wd := first; ; wd = (wd + 1) % 7 {
+			if !seen[wd] {
+				seen[wd] = true
+				days = append(days, wd)
+			}
+			if wd == last {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// parseWeekday parses a weekday string into time.Weekday. It accepts full
+// English names and three-letter abbreviations (case-insensitive), and the
+// numbers 0-6 where 0 is Sunday, matching time.Weekday. Two-letter forms are
+// rejected on purpose.
 func parseWeekday(day string) (time.Weekday, error) {
+	if len(day) == 1 && day[0] >= '0' && day[0] <= '6' {
+		return time.Weekday(day[0] - '0'), nil
+	}
+
 	for wd := time.Sunday; wd <= time.Saturday; wd++ {
-		if strings.EqualFold(day, wd.String()) {
+		if strings.EqualFold(day, wd.String()) || strings.EqualFold(day, wd.String()[:3]) {
 			return wd, nil
 		}
 	}
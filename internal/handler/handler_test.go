@@ -2,13 +2,17 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/week"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -46,12 +50,19 @@ func TestCreateSchedule_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusCreated, rec.Code)
 
+	var resp CreateScheduleResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.ID)
+	assert.Equal(t, "backend-team", resp.Team)
+	assert.Equal(t, "Weekday Coverage", resp.Name)
+	assert.Equal(t, "/schedule/"+resp.ID, rec.Header().Get(echo.HeaderLocation))
+
 	// Verify schedule was created
-	team, ok, err := store.GetTeam("backend-team")
+	team, err := store.GetTeam(context.Background(), "backend-team")
 	require.NoError(t, err)
-	assert.True(t, ok)
-	assert.Len(t, team.Schedules, 1)
+	require.Len(t, team.Schedules, 1)
 	assert.Equal(t, "Weekday Coverage", team.Schedules[0].Name)
+	assert.Equal(t, resp.ID, team.Schedules[0].ID)
 }
 
 func TestCreateSchedule_InvalidJSON(t *testing.T) {
@@ -169,6 +180,307 @@ func TestCreateSchedule_MissingFields(t *testing.T) {
 	}
 }
 
+func TestCreateSchedule_DescriptionAndNotes(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	logger, _ := zap.NewDevelopment()
+	h := New(store, logger)
+
+	create := func(description, notes string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(Request{
+			Name:        "Weekday",
+			Description: description,
+			Notes:       notes,
+			Team:        "backend-team",
+			Members:     []string{"Alice"},
+			Days:        []string{"Monday"},
+			Start:       "9:00AM",
+			End:         "5:00PM",
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/schedule", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		require.NoError(t, h.CreateSchedule(echo.New().NewContext(req, rec)))
+
+		return rec
+	}
+
+	// Limits count characters, not bytes
+	description := strings.Repeat("ü", MaxDescriptionLength)
+	notes := "# Escalation\n\n- ping *#sre-eu*\n"
+	require.Equal(t, http.StatusCreated, create(description, notes).Code)
+
+	team, err := store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+	assert.Equal(t, description, team.Schedules[0].Description)
+	assert.Equal(t, notes, team.Schedules[0].Notes)
+
+	rec := create(description+"ü", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "description must be at most 256 characters")
+
+	rec = create("", strings.Repeat("a", MaxNotesLength+1))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "notes must be at most 4096 characters")
+}
+
+func TestCreateSchedule_RotationOffset(t *testing.T) {
+	// Noon today, the anchor week starts today
+	now := time.Now().UTC().Truncate(24 * time.Hour).Add(12 * time.Hour)
+
+	newRequest := func() Request {
+		return Request{
+			Name:    "Weekly",
+			Team:    "backend-team",
+			Members: []string{"Alice", "Bob", "Charlie", "Dana"},
+			Days:    []string{"Sun-Sat"},
+			Anchor:  now.Format(time.DateOnly),
+			Start:   "12:00AM",
+			End:     "11:59PM",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Request)
+	}{
+		{"rotation offset", func(r *Request) { r.RotationOffset = 2 }},
+		{"current member", func(r *Request) { r.CurrentMember = "Charlie" }},
+		// Two weeks into the rotation, the offset still puts Charlie on duty now
+		{"current member after anchor", func(r *Request) {
+			r.Anchor = now.AddDate(0, 0, -14).Format(time.DateOnly)
+			r.CurrentMember = "Charlie"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage()
+			h := New(store, zap.NewNop())
+
+			req := newRequest()
+			tt.modify(&req)
+
+			schedule, err := h.parseRequest(&req)
+			require.NoError(t, err)
+			_, err = store.AddSchedule(context.Background(), req.Team, schedule)
+			require.NoError(t, err)
+
+			for at, want := range map[time.Time]string{now: "Charlie", now.AddDate(0, 0, 7): "Dana", now.AddDate(0, 0, 14): "Alice"} {
+				got, err := store.GetCurrentOncall(context.Background(), "backend-team", at)
+				require.NoError(t, err)
+				assert.Equal(t, want, got, at.String())
+			}
+		})
+	}
+
+	invalid := []struct {
+		name   string
+		modify func(*Request)
+		err    string
+	}{
+		{"negative offset", func(r *Request) { r.RotationOffset = -1 }, "rotation_offset must be between 0 and 3"},
+		{"offset out of range", func(r *Request) { r.RotationOffset = 4 }, "rotation_offset must be between 0 and 3"},
+		{"unknown current member", func(r *Request) { r.CurrentMember = "Erin" }, "current_member Erin is not a member of the schedule"},
+		{"both", func(r *Request) { r.RotationOffset, r.CurrentMember = 1, "Bob" }, "rotation_offset and current_member are mutually exclusive"},
+	}
+
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest()
+			tt.modify(&req)
+
+			_, err := h.parseRequest(&req)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestCreateSchedule_CurrentUntil(t *testing.T) {
+	// Monday, Charlie is on call in the legacy system until Thursday 09:00 in Tehran
+	now := time.Date(2025, 9, 15, 10, 0, 0, 0, time.UTC)
+	until := time.Date(2025, 9, 18, 5, 30, 0, 0, time.UTC)
+
+	newRequest := func() Request {
+		return Request{
+			Name:          "Weekly",
+			Team:          "backend-team",
+			Members:       []string{"Alice", "Bob", "Charlie", "Dana"},
+			Days:          []string{"Sun-Sat"},
+			Start:         "12:00AM",
+			End:           "11:59PM",
+			CurrentMember: "Charlie",
+			CurrentUntil:  "2025-09-18T09:00:00+03:30",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Request)
+		until  time.Time
+	}{
+		{"whole days", func(*Request) {}, until},
+		// The handover cuts the shift running at the instant
+		{"mid-shift", func(r *Request) {
+			r.Days = []string{"Mon-Fri"}
+			r.Start, r.End = "9:00AM", "5:00PM"
+			r.CurrentUntil = "2025-09-18T13:00:00Z"
+		}, time.Date(2025, 9, 18, 13, 0, 0, 0, time.UTC)},
+		{"matching handoff", func(r *Request) { r.Handoff = &Handoff{Day: "Thursday", Time: "5:30AM"} }, until},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := storage.NewMemoryStorage()
+			h := New(store, zap.NewNop())
+			h.now = func() time.Time { return now }
+
+			req := newRequest()
+			tt.modify(&req)
+
+			schedule, err := h.parseRequest(&req)
+			require.NoError(t, err)
+			_, err = store.AddSchedule(context.Background(), req.Team, schedule)
+			require.NoError(t, err)
+
+			// Charlie until the instant, then the order goes on, around the
+			// whole rotation back to Charlie
+			rotation := 4 * storage.RotationPeriod
+			for at, want := range map[time.Time]string{
+				now:                                  "Charlie",
+				tt.until.Add(-time.Second):           "Charlie",
+				tt.until:                             "Dana",
+				tt.until.Add(storage.RotationPeriod): "Alice",
+				tt.until.Add(rotation - time.Second): "Charlie",
+				tt.until.Add(rotation):               "Dana",
+			} {
+				got, err := store.GetCurrentOncall(context.Background(), "backend-team", at)
+				require.NoError(t, err)
+				assert.Equal(t, want, got, at.String())
+			}
+		})
+	}
+
+	invalid := []struct {
+		name   string
+		modify func(*Request)
+		err    string
+	}{
+		{"past", func(r *Request) { r.CurrentUntil = "2025-09-15T09:00:00Z" }, "current_until must be in the future"},
+		{"now", func(r *Request) { r.CurrentUntil = "2025-09-15T10:00:00Z" }, "current_until must be in the future"},
+		{"too far", func(r *Request) { r.CurrentUntil = "2025-09-23T09:00:00Z" }, "current_until must be at most 7 days away"},
+		{"seconds", func(r *Request) { r.CurrentUntil = "2025-09-18T05:30:10Z" }, "current_until must be on a whole minute"},
+		{"invalid format", func(r *Request) { r.CurrentUntil = "2025-09-18" }, "invalid current_until format, use RFC3339 format"},
+		{"unknown member", func(r *Request) { r.CurrentMember = "Erin" }, "current_member Erin is not a member of the schedule"},
+		{"without member", func(r *Request) { r.CurrentMember = "" }, "current_until requires current_member"},
+		{"with anchor", func(r *Request) { r.Anchor = "2025-09-01" }, "anchor and current_until are mutually exclusive"},
+		{"manual", func(r *Request) { r.Rotation = RotationManual }, "current_until cannot be combined with manual rotation"},
+		{"other handoff", func(r *Request) { r.Handoff = &Handoff{Day: "Monday", Time: "9:00AM"} }, "current_until must be at the handoff of the schedule"},
+		{"day without shifts", func(r *Request) {
+			r.Days = []string{"Mon-Fri"}
+			r.Start, r.End = "9:00AM", "5:00PM"
+			r.CurrentUntil = "2025-09-20T09:00:00Z"
+		}, "current_until must be on one of the days of the schedule"},
+	}
+
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.now = func() time.Time { return now }
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest()
+			tt.modify(&req)
+
+			_, err := h.parseRequest(&req)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
+func TestCreateSchedule_FixedAssignment(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	req := Request{
+		Name:           "Fixed",
+		Team:           "backend-team",
+		Assignment:     AssignmentFixed,
+		DayAssignments: map[string]string{"Monday": "Alice", "tue": "Bob", "3": "Alice"},
+		Anchor:         "2025-04-28",
+		Start:          "9:00AM",
+		End:            "5:00PM",
+	}
+
+	schedule, err := h.parseRequest(&req)
+	require.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday}, schedule.Days)
+	assert.Equal(t, []string{"Alice", "Bob"}, schedule.Members)
+	_, err = store.AddSchedule(context.Background(), req.Team, schedule)
+	require.NoError(t, err)
+
+	// The same member is on duty on a weekday every week
+	for week := range 4 {
+		for day, want := range map[int]string{0: "Alice", 1: "Bob", 2: "Alice"} {
+			at := time.Date(2025, 4, 28+7*week+day, 10, 0, 0, 0, time.UTC)
+			got, err := store.GetCurrentOncall(context.Background(), req.Team, at)
+			require.NoError(t, err)
+			assert.Equal(t, want, got, at.String())
+		}
+	}
+
+	// Listings render the assignments back
+	team, err := store.GetTeam(context.Background(), req.Team)
+	require.NoError(t, err)
+	listed := scheduleRequest(req.Team, team.Schedules[0])
+	assert.Equal(t, AssignmentFixed, listed.Assignment)
+	assert.Empty(t, listed.Members)
+	assert.Equal(t, map[string]string{"Monday": "Alice", "Tuesday": "Bob", "Wednesday": "Alice"}, listed.DayAssignments)
+
+	_, err = h.parseRequest(&listed)
+	require.NoError(t, err)
+}
+
+func TestCreateSchedule_FixedAssignmentValidation(t *testing.T) {
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	tests := []struct {
+		name   string
+		modify func(*Request)
+		err    string
+	}{
+		{"unknown assignment", func(r *Request) { r.Assignment = "random" }, "assignment must be rotation or fixed"},
+		{"members", func(r *Request) { r.Members = []string{"Alice"} }, "members cannot be combined with fixed assignment, use day_assignments"},
+		{"no assignments", func(r *Request) { r.DayAssignments = nil }, "at least one day assignment is required"},
+		{"cron", func(r *Request) { r.Cron = "0 9 * * MON" }, "fixed assignment requires days instead of cron or rrule"},
+		{"invalid day", func(r *Request) { r.DayAssignments["Someday"] = "Carol" }, "invalid day: Someday"},
+		{"day assigned twice", func(r *Request) { r.DayAssignments["Mon"] = "Carol" }, "day Monday is assigned more than once"},
+		{"empty assignee", func(r *Request) { r.DayAssignments["Friday"] = " " }, "day Friday has no assignee"},
+		{"listed day without assignee", func(r *Request) { r.Days = []string{"Mon-Wed"} }, "day Wednesday has no assignee"},
+		{"assigned day not listed", func(r *Request) { r.Days = []string{"Mon"} }, "day Tuesday is assigned but not listed in days"},
+		{"rotation offset", func(r *Request) { r.RotationOffset = 1 }, "rotation_offset and current_member cannot be combined with fixed assignment"},
+		{"rotation with assignments", func(r *Request) { r.Assignment = "" }, "at least one member is required"},
+		{"rotation with members and assignments", func(r *Request) { r.Assignment, r.Members = AssignmentRotation, []string{"Alice"} }, "day_assignments requires fixed assignment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := Request{
+				Name:           "Fixed",
+				Team:           "backend-team",
+				Assignment:     AssignmentFixed,
+				DayAssignments: map[string]string{"Monday": "Alice", "Tuesday": "Bob"},
+				Start:          "9:00AM",
+				End:            "5:00PM",
+			}
+			tt.modify(&req)
+
+			_, err := h.parseRequest(&req)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
+
 func TestCreateSchedule_InvalidDay(t *testing.T) {
 	e := echo.New()
 	store := storage.NewMemoryStorage()
@@ -203,6 +515,123 @@ func TestCreateSchedule_InvalidDay(t *testing.T) {
 	assert.Contains(t, errResp.Error, "invalid day")
 }
 
+func TestCreateSchedule_Recurrence(t *testing.T) {
+	tests := []struct {
+		name         string
+		days         []string
+		cron         string
+		rrule        string
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:         "cron only",
+			cron:         "0 9 1-7 * MON",
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "days and cron",
+			days:         []string{"Monday"},
+			cron:         "0 9 1-7 * MON",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "days, cron and rrule are mutually exclusive",
+		},
+		{
+			name:         "seconds granularity",
+			cron:         "0 0 9 1-7 * MON",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid cron expression",
+		},
+		{
+			name:         "descriptor",
+			cron:         "@every 1h",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid cron expression",
+		},
+		{
+			name:         "rrule only",
+			rrule:        "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "rrule with prefix and count",
+			rrule:        "RRULE:FREQ=DAILY;COUNT=10",
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "cron and rrule",
+			cron:         "0 9 * * MON",
+			rrule:        "FREQ=WEEKLY;BYDAY=MO",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "days, cron and rrule are mutually exclusive",
+		},
+		{
+			name:         "rrule with byhour",
+			rrule:        "FREQ=DAILY;BYHOUR=9",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "unsupported rrule part BYHOUR",
+		},
+		{
+			name:         "rrule with complex bysetpos",
+			rrule:        "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=1,-1",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "unsupported rrule part BYSETPOS",
+		},
+		{
+			name:         "rrule with hourly frequency",
+			rrule:        "FREQ=HOURLY",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "unsupported rrule part FREQ",
+		},
+		{
+			name:         "malformed rrule",
+			rrule:        "FREQ=WEEKLY;BYDAY=XX",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid rrule",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			store := storage.NewMemoryStorage()
+			logger, _ := zap.NewDevelopment()
+			h := New(store, logger)
+
+			reqBody := Request{
+				Name:    "First Monday",
+				Team:    "team",
+				Members: []string{"Alice"},
+				Days:    tt.days,
+				Cron:    tt.cron,
+				RRule:   tt.rrule,
+				Start:   "9:00AM",
+				End:     "5:00PM",
+			}
+
+			body, err := json.Marshal(reqBody)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/schedule", bytes.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err = h.CreateSchedule(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			if tt.expectedErr != "" {
+				var errResp ErrorResponse
+				err = json.Unmarshal(rec.Body.Bytes(), &errResp)
+				require.NoError(t, err)
+				assert.Contains(t, errResp.Error, tt.expectedErr)
+			}
+		})
+	}
+}
+
 func TestCreateSchedule_InvalidTimeFormat(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -295,6 +724,31 @@ func TestCreateSchedule_StartAfterEnd(t *testing.T) {
 	assert.Contains(t, errResp.Error, "start time must be before end time")
 }
 
+func TestCreateSchedule_Duplicate(t *testing.T) {
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	e.POST("/schedule", h.CreateSchedule)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// Other teams may use the name
+	rec = serveJSON(e, http.MethodPost, "/schedule", quotaRequest("frontend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+
+	var errResp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+	assert.Equal(t, "schedule already exists", errResp.Error)
+
+	team, err := store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+	assert.Len(t, team.Schedules, 1)
+}
+
 func TestGetSchedule_Success(t *testing.T) {
 	e := echo.New()
 	store := storage.NewMemoryStorage()
@@ -309,7 +763,7 @@ func TestGetSchedule_Success(t *testing.T) {
 		Start:   parseTime(t, "9:00AM"),
 		End:     parseTime(t, "5:00PM"),
 	}
-	err := store.AddSchedule("backend-team", schedule)
+	_, err := store.AddSchedule(context.Background(), "backend-team", schedule)
 	require.NoError(t, err)
 
 	// Query for oncall member on Monday at 10:00 AM
@@ -411,7 +865,7 @@ func TestGetSchedule_TeamNotFound(t *testing.T) {
 	var errResp ErrorResponse
 	err = json.Unmarshal(rec.Body.Bytes(), &errResp)
 	require.NoError(t, err)
-	assert.Contains(t, errResp.Error, "no oncall member found")
+	assert.Equal(t, "team not found", errResp.Error)
 }
 
 func TestGetSchedule_NoMatchingSchedule(t *testing.T) {
@@ -428,7 +882,7 @@ func TestGetSchedule_NoMatchingSchedule(t *testing.T) {
 		Start:   parseTime(t, "9:00AM"),
 		End:     parseTime(t, "5:00PM"),
 	}
-	err := store.AddSchedule("backend-team", schedule)
+	_, err := store.AddSchedule(context.Background(), "backend-team", schedule)
 	require.NoError(t, err)
 
 	// Query for Saturday (no schedule)
@@ -441,6 +895,148 @@ func TestGetSchedule_NoMatchingSchedule(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// The team exists, nobody covers it at the time
+	var errResp ErrorResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &errResp)
+	require.NoError(t, err)
+	assert.Equal(t, "no oncall member found for the given time", errResp.Error)
+}
+
+func TestGetSchedule_Timezone(t *testing.T) {
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	logger, _ := zap.NewDevelopment()
+	h := New(store, logger)
+
+	schedule := storage.Schedule{
+		Name:    "Weekend Coverage",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Saturday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+	_, err := store.AddSchedule(context.Background(), "backend-team", schedule)
+	require.NoError(t, err)
+
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	require.NoError(t, err)
+
+	// The same instant (Saturday 13:30 UTC) given in two different offsets
+	instant := time.Date(2025, 4, 26, 13, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		time          string
+		tz            string
+		expectedTime  string
+		expectedLocal string
+	}{
+		{
+			name:         "utc input without tz",
+			time:         instant.Format(time.RFC3339),
+			expectedTime: "2025-04-26T13:30:00Z",
+		},
+		{
+			name:         "tehran input without tz",
+			time:         instant.In(tehran).Format(time.RFC3339),
+			expectedTime: "2025-04-26T13:30:00Z",
+		},
+		{
+			name:          "utc input rendered in tehran",
+			time:          instant.Format(time.RFC3339),
+			tz:            "Asia/Tehran",
+			expectedTime:  "2025-04-26T17:00:00+03:30",
+			expectedLocal: "17:00 Sat",
+		},
+		{
+			name:          "tehran input rendered in utc",
+			time:          instant.In(tehran).Format(time.RFC3339),
+			tz:            "UTC",
+			expectedTime:  "2025-04-26T13:30:00Z",
+			expectedLocal: "13:30 Sat",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := url.Values{}
+			q.Set("team", "backend-team")
+			q.Set("time", tt.time)
+			if tt.tz != "" {
+				q.Set("tz", tt.tz)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/schedule?"+q.Encode(), nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := h.GetSchedule(c)
+
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var response OncallResponse
+			err = json.Unmarshal(rec.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Equal(t, "Alice", response.Oncall)
+			assert.Equal(t, tt.expectedTime, response.Time)
+			assert.Equal(t, tt.expectedLocal, response.Local)
+		})
+	}
+}
+
+func TestGetSchedule_InvalidTimezone(t *testing.T) {
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	logger, _ := zap.NewDevelopment()
+	h := New(store, logger)
+
+	req := httptest.NewRequest(http.MethodGet, "/schedule?team=backend-team&time=2025-04-26T09:00:00Z&tz=Mars/Olympus", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := h.GetSchedule(c)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var errResp ErrorResponse
+	err = json.Unmarshal(rec.Body.Bytes(), &errResp)
+	require.NoError(t, err)
+	assert.Contains(t, errResp.Error, "tz")
+}
+
+// staleStorage answers every on-call lookup from a stale cache.
+type staleStorage struct {
+	storage.Storage
+}
+
+func (staleStorage) GetCurrentOncall(context.Context, string, time.Time) (string, error) {
+	return "Alice", storage.ErrStale
+}
+
+func TestGetSchedule_Stale(t *testing.T) {
+	e := echo.New()
+	logger, _ := zap.NewDevelopment()
+	h := New(staleStorage{Storage: storage.NewMemoryStorage()}, logger)
+
+	q := make(url.Values)
+	q.Set("team", "backend-team")
+	q.Set("time", "2025-04-28T10:00:00Z")
+
+	req := httptest.NewRequest(http.MethodGet, "/schedule?"+q.Encode(), nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	require.NoError(t, h.GetSchedule(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "true", rec.Header().Get(HeaderOncallStale))
+
+	var resp OncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Alice", resp.Oncall)
+	assert.True(t, resp.Stale)
 }
 
 func TestParseWeekday(t *testing.T) {
@@ -455,8 +1051,20 @@ func TestParseWeekday(t *testing.T) {
 		{"Tuesday", time.Tuesday, false},
 		{"Sunday", time.Sunday, false},
 		{"Saturday", time.Saturday, false},
+		{"Mon", time.Monday, false},
+		{"tue", time.Tuesday, false},
+		{"SAT", time.Saturday, false},
+		{"0", time.Sunday, false},
+		{"1", time.Monday, false},
+		{"6", time.Saturday, false},
 		{"InvalidDay", time.Sunday, true},
 		{"", time.Sunday, true},
+		{"Mo", time.Sunday, true},
+		{"tu", time.Sunday, true},
+		{"Mond", time.Sunday, true},
+		{"7", time.Sunday, true},
+		{"-1", time.Sunday, true},
+		{"01", time.Sunday, true},
 	}
 
 	for _, tt := range tests {
@@ -472,6 +1080,78 @@ func TestParseWeekday(t *testing.T) {
 	}
 }
 
+func TestParseDays(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		expected []time.Weekday
+		wantErr  bool
+	}{
+		{
+			name:     "single names",
+			input:    []string{"Monday", "wed"},
+			expected: []time.Weekday{time.Monday, time.Wednesday},
+		},
+		{
+			name:     "abbreviated range",
+			input:    []string{"Mon-Fri"},
+			expected: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		},
+		{
+			name:     "numeric range",
+			input:    []string{"1-3"},
+			expected: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday},
+		},
+		{
+			name:     "mixed range",
+			input:    []string{"saturday-0"},
+			expected: []time.Weekday{time.Saturday, time.Sunday},
+		},
+		{
+			name:     "wrapping range",
+			input:    []string{"Fri-Mon"},
+			expected: []time.Weekday{time.Friday, time.Saturday, time.Sunday, time.Monday},
+		},
+		{
+			name:     "duplicates are dropped",
+			input:    []string{"Mon-Wed", "tuesday", "2"},
+			expected: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday},
+		},
+		{
+			name:    "two-letter form in range",
+			input:   []string{"Mo-Fr"},
+			wantErr: true,
+		},
+		{
+			name:    "open range",
+			input:   []string{"Mon-"},
+			wantErr: true,
+		},
+		{
+			name:     "weekdays keyword",
+			input:    []string{"Weekdays"},
+			expected: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		},
+		{
+			name:     "weekends keyword with a day",
+			input:    []string{"weekends", "Mon"},
+			expected: []time.Weekday{time.Saturday, time.Sunday, time.Monday},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseDays(tt.input, week.Default)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, result)
+			}
+		})
+	}
+}
+
 // parseTime is a helper function to parse time strings in tests
 func parseTime(t *testing.T, timeStr string) time.Time {
 	t.Helper()
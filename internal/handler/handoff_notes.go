@@ -0,0 +1,309 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// maxHandoffNoteLength is the longest text of a handoff note, in bytes.
+const maxHandoffNoteLength = 4096
+
+// Statuses of a handoff note: pending while its shift runs, shown from the
+// end of its shift until it expires.
+const (
+	HandoffNotePending = "pending"
+	HandoffNoteShown   = "shown"
+	HandoffNoteExpired = "expired"
+)
+
+// HandoffNoteRequest represents a request leaving or changing a handoff note.
+type HandoffNoteRequest struct {
+	Text string `json:"text"`
+}
+
+// HandoffNoteResponse represents a handoff note of a team. Member is the
+// member on duty for the shift the note was left on.
+type HandoffNoteResponse struct {
+	ID         int64  `json:"id"`
+	Team       string `json:"team"`
+	ScheduleID string `json:"schedule_id"`
+	Member     string `json:"member"`
+	Author     string `json:"author"`
+	Text       string `json:"text"`
+	ShiftStart string `json:"shift_start"`
+	ShiftEnd   string `json:"shift_end"`
+	ExpiresAt  string `json:"expires_at"`
+	Status     string `json:"status"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+// SetHandoffNoteTTL sets the longest a handoff note is shown for once its
+// shift ended.
+func (h *Handler) SetHandoffNoteTTL(ttl time.Duration) {
+	h.handoffNoteTTL = ttl
+}
+
+// CreateHandoffNote handles requests leaving a note to whoever takes over
+// from the member on call now. The note is attached to the running shift of
+// the team and shown in the on-call lookup from its end, until the end of the
+// next shift or for the TTL, whichever comes first. The expiry is set when
+// the note is left, later changes of the schedules do not move it. A shift
+// has a single note, which is updated instead.
+func (h *Handler) CreateHandoffNote(c echo.Context) error {
+	teamName := c.Param("team")
+
+	text, err := bindHandoffNote(c, h.logger)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to create handoff note")
+	}
+
+	now := h.now()
+
+	sched, ok := storage.ScheduleAt(team.Schedules, now)
+	var duty storage.Duty
+	if ok {
+		duty, ok = sched.DutyAt(now)
+	}
+	if !ok {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "nobody is on call for the team to hand off from"})
+	}
+
+	note, found, err := h.storage.AddHandoffNote(ctx, teamName, storage.HandoffNote{
+		ScheduleID: sched.ID,
+		Member:     duty.Member,
+		ShiftStart: duty.Start,
+		ShiftEnd:   duty.End,
+		Author:     storage.ActorFrom(ctx),
+		Text:       text,
+		ExpiresAt:  h.handoffNoteExpiry(team.Schedules, duty.End),
+	})
+	if errors.Is(err, storage.ErrHandoffNoteExists) {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "the shift has a handoff note already, update it instead"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add handoff note to team %q: %w", teamName, err), "failed to create handoff note")
+	}
+	// The team may have been deleted in the meantime
+	if !found {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+
+	h.logger.Info("handoff note added",
+		zap.String("team", teamName),
+		zap.Int64("id", note.ID),
+		zap.String("member", note.Member),
+		zap.String("actor", note.Author),
+	)
+
+	return c.JSON(http.StatusCreated, newHandoffNoteResponse(teamName, note, now))
+}
+
+// ListHandoffNotes handles requests for the handoff notes of a team ordered
+// by ID, expired ones included.
+func (h *Handler) ListHandoffNotes(c echo.Context) error {
+	team := c.Param("team")
+
+	notes, err := h.storage.ListHandoffNotes(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list handoff notes of team %q: %w", team, err), "failed to list handoff notes")
+	}
+
+	now := h.now()
+
+	resp := make([]HandoffNoteResponse, 0, len(notes))
+	for _, note := range notes {
+		resp = append(resp, newHandoffNoteResponse(team, note, now))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// UpdateHandoffNote handles requests replacing the text of a handoff note,
+// which only its author may do until it expires.
+func (h *Handler) UpdateHandoffNote(c echo.Context) error {
+	team := c.Param("team")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid handoff note id"})
+	}
+
+	text, err := bindHandoffNote(c, h.logger)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	if rejected, err := h.rejectHandoffNoteChange(c, team, id); rejected {
+		return err
+	}
+
+	note, found, err := h.storage.UpdateHandoffNote(ctx, team, id, text)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("update handoff note %d of team %q: %w", id, team, err), "failed to update handoff note")
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "handoff note not found"})
+	}
+
+	h.logger.Info("handoff note updated", zap.String("team", team), zap.Int64("id", id), zap.String("actor", storage.ActorFrom(ctx)))
+
+	return c.JSON(http.StatusOK, newHandoffNoteResponse(team, note, h.now()))
+}
+
+// DeleteHandoffNote handles requests deleting a handoff note, which only its
+// author may do until it expires.
+func (h *Handler) DeleteHandoffNote(c echo.Context) error {
+	team := c.Param("team")
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid handoff note id"})
+	}
+
+	ctx := c.Request().Context()
+
+	if rejected, err := h.rejectHandoffNoteChange(c, team, id); rejected {
+		return err
+	}
+
+	deleted, err := h.storage.DeleteHandoffNote(ctx, team, id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("delete handoff note %d of team %q: %w", id, team, err), "failed to delete handoff note")
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "handoff note not found"})
+	}
+
+	h.logger.Info("handoff note deleted", zap.String("team", team), zap.Int64("id", id), zap.String("actor", storage.ActorFrom(ctx)))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// rejectHandoffNoteChange answers the request when the handoff note does not
+// exist, is not the caller's, or expired, and reports whether it did.
+func (h *Handler) rejectHandoffNoteChange(c echo.Context, team string, id int64) (bool, error) {
+	ctx := c.Request().Context()
+
+	notes, err := h.storage.ListHandoffNotes(ctx, team)
+	if err != nil {
+		return true, h.storageFailure(c, fmt.Errorf("list handoff notes of team %q: %w", team, err), "failed to retrieve handoff note")
+	}
+
+	for _, note := range notes {
+		if note.ID != id {
+			continue
+		}
+
+		if note.Author != storage.ActorFrom(ctx) {
+			return true, c.JSON(http.StatusForbidden, ErrorResponse{Error: "only the author of a handoff note can change it"})
+		}
+		if note.ExpiredAt(h.now()) {
+			return true, c.JSON(http.StatusConflict, ErrorResponse{Error: "handoff note has expired"})
+		}
+
+		return false, nil
+	}
+
+	return true, c.JSON(http.StatusNotFound, ErrorResponse{Error: "handoff note not found"})
+}
+
+// visibleHandoffNote returns the handoff note of the team shown at the given
+// instant, nil when none is.
+func (h *Handler) visibleHandoffNote(ctx context.Context, team string, at time.Time) (*HandoffNoteResponse, error) {
+	notes, err := h.storage.ListHandoffNotes(ctx, team)
+	if err != nil {
+		return nil, fmt.Errorf("list handoff notes of team %q: %w", team, err)
+	}
+
+	note, ok := storage.VisibleHandoffNote(notes, at)
+	if !ok {
+		return nil, nil
+	}
+
+	resp := newHandoffNoteResponse(team, note, at)
+	return &resp, nil
+}
+
+// handoffNoteExpiry returns when a note left on a shift ending at shiftEnd
+// expires: at the end of the next shift of the schedules, or once it was
+// shown for the TTL when that comes first or no shift follows.
+func (h *Handler) handoffNoteExpiry(schedules []storage.Schedule, shiftEnd time.Time) time.Time {
+	expires := shiftEnd.Add(h.handoffNoteTTL)
+
+	for _, duty := range storage.Duties(schedules, shiftEnd, expires) {
+		if !duty.Start.Before(shiftEnd) {
+			return earlier(duty.End, expires)
+		}
+	}
+
+	return expires
+}
+
+// bindHandoffNote binds a handoff note request and returns its trimmed text.
+// The returned errors are meant for the client.
+func bindHandoffNote(c echo.Context, logger *zap.Logger) (string, error) {
+	var req HandoffNoteRequest
+	if err := c.Bind(&req); err != nil {
+		logger.Error("failed to bind request", zap.Error(err))
+		return "", errors.New("invalid request body")
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		return "", errors.New("text is required")
+	}
+	if len(text) > maxHandoffNoteLength {
+		return "", fmt.Errorf("text must be at most %d bytes", maxHandoffNoteLength)
+	}
+
+	return text, nil
+}
+
+// newHandoffNoteResponse converts a handoff note of the team, with its
+// status at the given instant.
+func newHandoffNoteResponse(team string, note storage.HandoffNote, at time.Time) HandoffNoteResponse {
+	status := HandoffNotePending
+	switch {
+	case note.ExpiredAt(at):
+		status = HandoffNoteExpired
+	case note.VisibleAt(at):
+		status = HandoffNoteShown
+	}
+
+	return HandoffNoteResponse{
+		ID:         note.ID,
+		Team:       team,
+		ScheduleID: note.ScheduleID,
+		Member:     note.Member,
+		Author:     note.Author,
+		Text:       note.Text,
+		ShiftStart: note.ShiftStart.UTC().Format(time.RFC3339),
+		ShiftEnd:   note.ShiftEnd.UTC().Format(time.RFC3339),
+		ExpiresAt:  note.ExpiresAt.UTC().Format(time.RFC3339),
+		Status:     status,
+		CreatedAt:  note.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:  note.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newHandoffNoteServer returns a server whose support team has Alice on call
+// from 9:00AM to 5:00PM UTC and Carol from 5:00PM to 10:00PM on weekdays, on
+// Monday March 2, 2026 at 10:00 UTC. Alice and Carol sign in with their own
+// API keys.
+func newHandoffNoteServer(t *testing.T) (*echo.Echo, *Handler, *fakeClock) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)}
+	h.now = clock.Now
+	h.SetAPIKeys([]config.APIKeyConfig{
+		{Name: "alice", Key: "alice-key", Role: "reader"},
+		{Name: "carol", Key: "carol-key", Role: "reader"},
+	})
+
+	e := echo.New()
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.POST("/teams/:team/handoff-notes", h.CreateHandoffNote, h.Authenticate(auth.RoleReader, "secret"))
+	e.GET("/teams/:team/handoff-notes", h.ListHandoffNotes)
+	e.PUT("/teams/:team/handoff-notes/:id", h.UpdateHandoffNote, h.Authenticate(auth.RoleReader, "secret"))
+	e.DELETE("/teams/:team/handoff-notes/:id", h.DeleteHandoffNote, h.Authenticate(auth.RoleReader, "secret"))
+
+	evenings := weekRequest("support", "Evenings", "Carol", "Mon-Fri")
+	evenings.Start = "5:00PM"
+	evenings.End = "10:00PM"
+
+	for _, req := range []Request{weekRequest("support", "Days", "Alice", "Mon-Fri"), evenings} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	return e, h, clock
+}
+
+func createHandoffNote(t *testing.T, e *echo.Echo, token, text string) HandoffNoteResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/teams/support/handoff-notes", HandoffNoteRequest{Text: text}, token)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var note HandoffNoteResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &note))
+
+	return note
+}
+
+// lookupNote returns the handoff note of the on-call lookup of the support
+// team at the given instant.
+func lookupNote(t *testing.T, e *echo.Echo, at string) (string, *HandoffNoteResponse) {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodGet, "/schedule?team=support&time="+at, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp OncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp.Oncall, resp.HandoffNote
+}
+
+func TestHandoffNote_VisibleForTheNextShift(t *testing.T) {
+	e, _, _ := newHandoffNoteServer(t)
+
+	note := createHandoffNote(t, e, "alice-key", "  deploy freeze until Monday, watch the payments queue ")
+	assert.Equal(t, HandoffNoteResponse{
+		ID:         note.ID,
+		Team:       "support",
+		ScheduleID: note.ScheduleID,
+		Member:     "Alice",
+		Author:     "apikey:alice",
+		Text:       "deploy freeze until Monday, watch the payments queue",
+		ShiftStart: "2026-03-02T09:00:00Z",
+		ShiftEnd:   "2026-03-02T17:00:00Z",
+		ExpiresAt:  "2026-03-02T22:00:00Z",
+		Status:     HandoffNotePending,
+		CreatedAt:  note.CreatedAt,
+		UpdatedAt:  note.UpdatedAt,
+	}, note)
+
+	// Alice does not see her own note, Carol sees it for her whole shift
+	for _, tc := range []struct {
+		at     string
+		oncall string
+		shown  bool
+	}{
+		{at: "2026-03-02T16:59:59Z", oncall: "Alice"},
+		{at: "2026-03-02T17:00:00Z", oncall: "Carol", shown: true},
+		{at: "2026-03-02T21:59:59Z", oncall: "Carol", shown: true},
+		{at: "2026-03-03T09:00:00Z", oncall: "Alice"},
+	} {
+		oncall, shown := lookupNote(t, e, tc.at)
+		assert.Equal(t, tc.oncall, oncall, tc.at)
+		if !tc.shown {
+			assert.Nil(t, shown, tc.at)
+			continue
+		}
+		require.NotNil(t, shown, tc.at)
+		assert.Equal(t, note.ID, shown.ID)
+		assert.Equal(t, "deploy freeze until Monday, watch the payments queue", shown.Text)
+		assert.Equal(t, HandoffNoteShown, shown.Status)
+	}
+
+	// The shift has a note already
+	rec := serveJSON(e, http.MethodPost, "/teams/support/handoff-notes", HandoffNoteRequest{Text: "again"}, "carol-key")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestHandoffNote_TTL(t *testing.T) {
+	e, h, _ := newHandoffNoteServer(t)
+	h.SetHandoffNoteTTL(2 * time.Hour)
+
+	note := createHandoffNote(t, e, "alice-key", "the queue is draining")
+	assert.Equal(t, "2026-03-02T19:00:00Z", note.ExpiresAt)
+
+	_, shown := lookupNote(t, e, "2026-03-02T18:59:00Z")
+	assert.NotNil(t, shown)
+	_, shown = lookupNote(t, e, "2026-03-02T19:00:00Z")
+	assert.Nil(t, shown)
+}
+
+func TestHandoffNote_OnlyTheAuthorChangesIt(t *testing.T) {
+	e, _, clock := newHandoffNoteServer(t)
+
+	note := createHandoffNote(t, e, "alice-key", "deploy freeze until Monday")
+	target := fmt.Sprintf("/teams/support/handoff-notes/%d", note.ID)
+
+	rec := serveJSON(e, http.MethodPut, target, HandoffNoteRequest{Text: "nothing to see"}, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serveJSON(e, http.MethodPut, target, HandoffNoteRequest{Text: "nothing to see"}, "carol-key")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = serveJSON(e, http.MethodDelete, target, nil, "carol-key")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveJSON(e, http.MethodPut, target, HandoffNoteRequest{Text: ""}, "alice-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = serveJSON(e, http.MethodPut, target, HandoffNoteRequest{Text: strings.Repeat("x", maxHandoffNoteLength+1)}, "alice-key")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSON(e, http.MethodPut, target, HandoffNoteRequest{Text: "deploy freeze lifted"}, "alice-key")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	_, shown := lookupNote(t, e, "2026-03-02T18:00:00Z")
+	require.NotNil(t, shown)
+	assert.Equal(t, "deploy freeze lifted", shown.Text)
+
+	// Once expired the note stays as it was shown
+	clock.now = time.Date(2026, 3, 2, 22, 0, 0, 0, time.UTC)
+	rec = serveJSON(e, http.MethodDelete, target, nil, "alice-key")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serveJSON(e, http.MethodGet, "/teams/support/handoff-notes", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var notes []HandoffNoteResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &notes))
+	require.Len(t, notes, 1)
+	assert.Equal(t, HandoffNoteExpired, notes[0].Status)
+	assert.Equal(t, "deploy freeze lifted", notes[0].Text)
+}
+
+func TestHandoffNote_Delete(t *testing.T) {
+	e, _, _ := newHandoffNoteServer(t)
+
+	note := createHandoffNote(t, e, "alice-key", "deploy freeze until Monday")
+	target := fmt.Sprintf("/teams/support/handoff-notes/%d", note.ID)
+
+	rec := serveJSON(e, http.MethodDelete, target, nil, "alice-key")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	rec = serveJSON(e, http.MethodDelete, target, nil, "alice-key")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	_, shown := lookupNote(t, e, "2026-03-02T18:00:00Z")
+	assert.Nil(t, shown)
+
+	// A new note can be left on the shift again
+	createHandoffNote(t, e, "alice-key", "never mind")
+}
+
+func TestHandoffNote_NobodyOnCall(t *testing.T) {
+	e, _, clock := newHandoffNoteServer(t)
+
+	clock.now = time.Date(2026, 3, 2, 23, 0, 0, 0, time.UTC)
+	rec := serveJSON(e, http.MethodPost, "/teams/support/handoff-notes", HandoffNoteRequest{Text: "hello"}, "alice-key")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/teams/missing/handoff-notes", HandoffNoteRequest{Text: "hello"}, "alice-key")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newHandoffServer creates a schedule of Alice, Bob and Charlie around the
+// clock every day, anchored on Monday 2026-03-02 and handing over on Mondays
+// at 9:00AM.
+func newHandoffServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+
+	req := quotaRequest("backend-team")
+	req.Members = []string{"Alice", "Bob", "Charlie"}
+	req.Days = []string{"Monday-Sunday"}
+	req.Start, req.End = "12:00AM", "11:59PM"
+	req.Anchor = "2026-03-02"
+	req.Handoff = &Handoff{Day: "Monday", Time: "9:00AM"}
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, store
+}
+
+func TestHandoff_Oncall(t *testing.T) {
+	e, store := newHandoffServer(t)
+
+	handoff := time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "Alice", oncallAt(t, e, handoff.Add(-time.Second)))
+	assert.Equal(t, "Bob", oncallAt(t, e, handoff))
+	assert.Equal(t, "Bob", oncallAt(t, e, handoff.Add(time.Second)))
+	// Until the next handoff, not the next midnight
+	assert.Equal(t, "Bob", oncallAt(t, e, handoff.AddDate(0, 0, 7).Add(-time.Minute)))
+	assert.Equal(t, "Charlie", oncallAt(t, e, handoff.AddDate(0, 0, 7)))
+
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+
+	req := scheduleRequest("backend-team", team.Schedules[0])
+	assert.Equal(t, &Handoff{Day: "Monday", Time: "9:00AM"}, req.Handoff)
+}
+
+func TestHandoff_Validation(t *testing.T) {
+	e, _ := newHandoffServer(t)
+
+	tests := []struct {
+		name string
+		req  func(*Request)
+		code int
+	}{
+		{"covered day", func(r *Request) { r.Handoff = &Handoff{Day: "Fri", Time: "5:00PM"} }, http.StatusCreated},
+		{"day not covered", func(r *Request) { r.Handoff = &Handoff{Day: "Saturday", Time: "9:00AM"} }, http.StatusBadRequest},
+		{"any day when all day", func(r *Request) {
+			r.Days = []string{"Monday"}
+			r.Start, r.End = "12:00AM", "11:59PM"
+			r.Handoff = &Handoff{Day: "Saturday", Time: "9:00AM"}
+		}, http.StatusCreated},
+		{"invalid day", func(r *Request) { r.Handoff = &Handoff{Day: "Someday", Time: "9:00AM"} }, http.StatusBadRequest},
+		{"invalid time", func(r *Request) { r.Handoff = &Handoff{Day: "Monday", Time: "09:00"} }, http.StatusBadRequest},
+		{"fixed assignment", func(r *Request) {
+			r.Members = nil
+			r.Days = nil
+			r.Assignment = AssignmentFixed
+			r.DayAssignments = map[string]string{"Monday": "Alice"}
+			r.Handoff = &Handoff{Day: "Monday", Time: "9:00AM"}
+		}, http.StatusBadRequest},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := quotaRequest("frontend-team")
+			req.Name = string(rune('A' + i))
+			tt.req(&req)
+			rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
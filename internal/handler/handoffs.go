@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// The handoff listing returns defaultHandoffCount handoffs unless asked for
+// more, and walks the rotations forward in handoffWalkStep windows for at
+// most maxHandoffWalk, so sparse schedules cannot keep it walking.
+const (
+	defaultHandoffCount = 5
+	maxHandoffCount     = 50
+	handoffWalkStep     = 7 * 24 * time.Hour
+	maxHandoffWalk      = 180 * 24 * time.Hour
+)
+
+// TeamHandoff represents the on-call lookup of a team moving from one member
+// to another. FromMember is empty when nobody was on call before, and
+// ToMembers are all the members taking over for schedules requiring more
+// than one, ToMember first.
+type TeamHandoff struct {
+	At         string   `json:"at"`
+	Schedule   string   `json:"schedule"`
+	ScheduleID string   `json:"schedule_id"`
+	FromMember string   `json:"from_member,omitempty"`
+	ToMember   string   `json:"to_member"`
+	ToMembers  []string `json:"to_members,omitempty"`
+}
+
+// TeamHandoffsResponse represents the next handoffs of a team, ordered by
+// time.
+type TeamHandoffsResponse struct {
+	Team     string        `json:"team"`
+	From     string        `json:"from"`
+	Handoffs []TeamHandoff `json:"handoffs"`
+}
+
+// TeamHandoffs handles next handoff requests. It walks the timeline of the
+// team forward from the from query parameter, now by default, and returns
+// the first count instants at which the on-call lookup answers with another
+// member, or with another one of the members of multi-person shifts, across
+// all of the schedules of the team. Gaps do not hand off, so
+// a member taking over after one hands off from the last member on call, and
+// time while the team is paused is left out like in the timeline.
+func (h *Handler) TeamHandoffs(c echo.Context) error {
+	teamName := c.Param("team")
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	from := h.now()
+	if value := c.QueryParam("from"); value != "" {
+		if from, err = h.parseTime(value, "from", loc); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	count := defaultHandoffCount
+	if value := c.QueryParam("count"); value != "" {
+		count, err = strconv.Atoi(value)
+		if err != nil || count < 1 || count > maxHandoffCount {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Error: fmt.Sprintf("count must be between 1 and %d", maxHandoffCount),
+			})
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	pause, paused, err := h.storage.GetPause(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get pause of team %q: %w", teamName, err), "failed to retrieve team")
+	}
+	if !paused {
+		pause = storage.Pause{}
+	}
+
+	names := make(map[string]string, len(team.Schedules))
+	for _, sched := range team.Schedules {
+		names[sched.ID] = sched.Name
+	}
+
+	resp := TeamHandoffsResponse{
+		Team:     teamName,
+		From:     from.In(loc).Format(time.RFC3339),
+		Handoffs: make([]TeamHandoff, 0, count),
+	}
+
+	// previous is the member the lookup last answered with, among previousMembers
+	var previous string
+	var previousMembers []string
+	limit := from.Add(maxHandoffWalk)
+
+walk:
+	for start := from; start.Before(limit); start = start.Add(handoffWalkStep) {
+		for _, duty := range storage.DutyTimeline(team.Schedules, start, earlier(start.Add(handoffWalkStep), limit)) {
+			for _, stretch := range outsidePause(duty.Shift, pause) {
+				// The member on call at from is who the first handoff is from
+				if !stretch.Start.After(from) || slices.Equal(duty.Members(), previousMembers) {
+					previous, previousMembers = duty.Member, duty.Members()
+					continue
+				}
+
+				handoff := TeamHandoff{
+					At:         stretch.Start.In(loc).Format(time.RFC3339),
+					Schedule:   names[duty.ScheduleID],
+					ScheduleID: duty.ScheduleID,
+					FromMember: previous,
+					ToMember:   duty.Member,
+				}
+				if len(duty.Others) > 0 {
+					handoff.ToMembers = duty.Members()
+				}
+				resp.Handoffs = append(resp.Handoffs, handoff)
+				previous, previousMembers = duty.Member, duty.Members()
+
+				if len(resp.Handoffs) == count {
+					break walk
+				}
+			}
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newHandoffsHandler interleaves a weekday rotation of Alice and Carol during
+// the day with Bob in the evening.
+func newHandoffsHandler(t *testing.T) (*Handler, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	weekdays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	_, err := store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Day",
+		Members: []string{"Alice", "Carol"},
+		Days:    weekdays,
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Evening",
+		Members: []string{"Bob"},
+		Days:    weekdays,
+		Start:   parseTime(t, "5:00PM"),
+		End:     parseTime(t, "11:00PM"),
+	})
+	require.NoError(t, err)
+
+	return New(store, zap.NewNop()), store
+}
+
+func getTeamHandoffs(t *testing.T, h *Handler, team, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/teams/"+team+"/handoffs?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("team")
+	c.SetParamValues(team)
+
+	require.NoError(t, h.TeamHandoffs(c))
+
+	return rec
+}
+
+func TestTeamHandoffs(t *testing.T) {
+	h, store := newHandoffsHandler(t)
+
+	rec := getTeamHandoffs(t, h, "backend-team", "from=2025-05-02T12:00:00Z&count=4")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TeamHandoffsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "backend-team", resp.Team)
+	assert.Equal(t, "2025-05-02T12:00:00Z", resp.From)
+
+	type handoff struct{ at, schedule, from, to string }
+
+	var got []handoff
+	for _, entry := range resp.Handoffs {
+		got = append(got, handoff{entry.At, entry.Schedule, entry.FromMember, entry.ToMember})
+	}
+	// The weekend gap does not hand off, and the day rotation moves on to
+	// Carol in the second week
+	assert.Equal(t, []handoff{
+		{"2025-05-02T17:00:00Z", "Evening", "Alice", "Bob"},
+		{"2025-05-05T09:00:00Z", "Day", "Bob", "Carol"},
+		{"2025-05-05T17:00:00Z", "Evening", "Carol", "Bob"},
+		{"2025-05-06T09:00:00Z", "Day", "Bob", "Carol"},
+	}, got)
+
+	// Nobody is on call before the first handoff of the week
+	rec = getTeamHandoffs(t, h, "backend-team", "from=2025-05-04&count=1&tz=Asia/Tehran")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	resp = TeamHandoffsResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Handoffs, 1)
+	assert.Equal(t, "2025-05-05T12:30:00+03:30", resp.Handoffs[0].At)
+	assert.Empty(t, resp.Handoffs[0].FromMember)
+	assert.Equal(t, "Carol", resp.Handoffs[0].ToMember)
+
+	// A paused Monday is skipped
+	_, err := store.PauseTeam(context.Background(), "backend-team", storage.Pause{
+		Since: time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC),
+		Until: time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	rec = getTeamHandoffs(t, h, "backend-team", "from=2025-05-02T18:00:00Z&count=1")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Handoffs, 1)
+	assert.Equal(t, "2025-05-06T09:00:00Z", resp.Handoffs[0].At)
+	assert.Equal(t, "Bob", resp.Handoffs[0].FromMember)
+}
+
+func TestTeamHandoffs_BoundedWalk(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	_, err := store.AddSchedule(context.Background(), "backend-team", storage.Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	h := New(store, zap.NewNop())
+
+	// Alice never hands over, so the walk stops at its bound
+	rec := getTeamHandoffs(t, h, "backend-team", "from=2025-05-03&count=50")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TeamHandoffsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Handoffs, 1)
+	assert.Equal(t, "2025-05-05T09:00:00Z", resp.Handoffs[0].At)
+}
+
+func TestTeamHandoffs_InvalidRequests(t *testing.T) {
+	h, _ := newHandoffsHandler(t)
+
+	tests := []struct {
+		name  string
+		team  string
+		query string
+		code  int
+	}{
+		{"zero count", "backend-team", "count=0", http.StatusBadRequest},
+		{"count too high", "backend-team", "count=51", http.StatusBadRequest},
+		{"count not a number", "backend-team", "count=five", http.StatusBadRequest},
+		{"invalid from", "backend-team", "from=soon", http.StatusBadRequest},
+		{"invalid tz", "backend-team", "tz=Mars/Base", http.StatusBadRequest},
+		{"unknown team", "frontend-team", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getTeamHandoffs(t, h, tt.team, tt.query)
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
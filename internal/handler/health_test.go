@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubMigrations reports a fixed migration status.
+type stubMigrations struct {
+	version uint
+	dirty   bool
+	err     error
+}
+
+func (m stubMigrations) MigrationStatus(context.Context) (uint, bool, error) {
+	return m.version, m.dirty, m.err
+}
+
+func getHealth(t *testing.T, h *Handler) (int, HealthResponse) {
+	t.Helper()
+
+	e := echo.New()
+	e.GET("/health", h.Health)
+
+	rec := serveJSON(e, http.MethodGet, "/health", nil, "")
+
+	var resp HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return rec.Code, resp
+}
+
+func TestHealth_Migrations(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations stubMigrations
+		status     string
+		error      bool
+	}{
+		{name: "clean", migrations: stubMigrations{version: 25}, status: "healthy"},
+		{name: "dirty", migrations: stubMigrations{version: 25, dirty: true}, status: "degraded"},
+		{name: "behind", migrations: stubMigrations{version: 24}, status: "degraded"},
+		{name: "ahead", migrations: stubMigrations{version: 26}, status: "degraded"},
+		{name: "unreadable", migrations: stubMigrations{err: errors.New("connection refused")}, status: "degraded", error: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(storage.NewMemoryStorage(), zap.NewNop())
+			h.SetMigrations(tt.migrations, 25)
+
+			// Degraded is reported without failing the check
+			code, resp := getHealth(t, h)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, tt.status, resp.Status)
+
+			require.NotNil(t, resp.Migrations)
+			assert.Equal(t, tt.migrations.version, resp.Migrations.Version)
+			assert.Equal(t, tt.migrations.dirty, resp.Migrations.Dirty)
+			assert.Equal(t, uint(25), resp.Migrations.Expected)
+			if tt.error {
+				assert.NotEmpty(t, resp.Migrations.Error)
+				assert.NotContains(t, resp.Migrations.Error, "connection refused")
+			} else {
+				assert.Empty(t, resp.Migrations.Error)
+			}
+		})
+	}
+}
+
+func TestHealth_MemoryOmitsMigrations(t *testing.T) {
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	code, resp := getHealth(t, h)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", resp.Status)
+	assert.Nil(t, resp.Migrations)
+}
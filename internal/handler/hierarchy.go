@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CodeParentCycle is the code of the error answering a parent that would
+// make a team its own ancestor.
+const CodeParentCycle = "PARENT_CYCLE"
+
+// TeamSettingsRequest represents a change of the settings of a team, the
+// fields left out are kept.
+type TeamSettingsRequest struct {
+	// Parent is the team whose schedules the team inherits when none of its
+	// own matches, an empty string clears it.
+	Parent *string `json:"parent"`
+	// PublicCoverageEnabled serves the anonymized coverage of the team to
+	// public status pages.
+	PublicCoverageEnabled *bool `json:"public_coverage_enabled"`
+	// GoogleCalendarID is the Google calendar the shifts of the team are
+	// pushed to, an empty string clears it.
+	GoogleCalendarID *string `json:"google_calendar_id"`
+}
+
+// TeamSettingsResponse represents the settings of a team.
+type TeamSettingsResponse struct {
+	Team                  string `json:"team"`
+	Parent                string `json:"parent,omitempty"`
+	PublicCoverageEnabled bool   `json:"public_coverage_enabled,omitempty"`
+	GoogleCalendarID      string `json:"google_calendar_id,omitempty"`
+}
+
+// SetHierarchyDepth sets how many parents up on-call lookups go for a team
+// with nothing matching of its own.
+func (h *Handler) SetHierarchyDepth(depth int) {
+	h.hierarchyDepth = depth
+}
+
+// GetTeamSettings handles requests for the settings of a team. Teams without
+// schedules have settings too, e.g. the sub-teams living off their parent.
+func (h *Handler) GetTeamSettings(c echo.Context) error {
+	team := c.Param("team")
+
+	parent, _, err := h.storage.TeamParent(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get parent of team %q: %w", team, err), "failed to retrieve team settings")
+	}
+
+	public, err := h.storage.PublicCoverage(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get public coverage of team %q: %w", team, err), "failed to retrieve team settings")
+	}
+
+	calendarID, _, err := h.storage.GoogleCalendar(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get google calendar of team %q: %w", team, err), "failed to retrieve team settings")
+	}
+
+	return c.JSON(http.StatusOK, TeamSettingsResponse{
+		Team:                  team,
+		Parent:                parent,
+		PublicCoverageEnabled: public,
+		GoogleCalendarID:      calendarID,
+	})
+}
+
+// UpdateTeamSettings handles requests changing the settings of a team.
+func (h *Handler) UpdateTeamSettings(c echo.Context) error {
+	team := c.Param("team")
+
+	var req TeamSettingsRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	ctx := c.Request().Context()
+
+	if req.Parent != nil {
+		parent := strings.TrimSpace(*req.Parent)
+
+		if frozen, err := h.rejectFrozen(c, team, "set parent of "+team); frozen {
+			return err
+		}
+
+		err := h.storage.SetTeamParent(ctx, team, parent)
+		if errors.Is(err, storage.ErrParentCycle) {
+			return c.JSON(http.StatusConflict, ErrorResponse{
+				Error: fmt.Sprintf("%s cannot be the parent of %s, %s would be its own ancestor", parent, team, team),
+				Code:  CodeParentCycle,
+			})
+		}
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("set parent of team %q: %w", team, err), "failed to update team settings")
+		}
+
+		h.logger.Info("team parent set",
+			zap.String("team", team),
+			zap.String("parent", parent),
+			zap.String("actor", storage.ActorFrom(ctx)),
+		)
+	}
+
+	if req.PublicCoverageEnabled != nil {
+		if err := h.storage.SetPublicCoverage(ctx, team, *req.PublicCoverageEnabled); err != nil {
+			return h.storageFailure(c, fmt.Errorf("set public coverage of team %q: %w", team, err), "failed to update team settings")
+		}
+
+		h.logger.Info("team public coverage set",
+			zap.String("team", team),
+			zap.Bool("enabled", *req.PublicCoverageEnabled),
+			zap.String("actor", storage.ActorFrom(ctx)),
+		)
+	}
+
+	if req.GoogleCalendarID != nil {
+		calendarID := strings.TrimSpace(*req.GoogleCalendarID)
+
+		if err := h.storage.SetGoogleCalendar(ctx, team, calendarID); err != nil {
+			return h.storageFailure(c, fmt.Errorf("set google calendar of team %q: %w", team, err), "failed to update team settings")
+		}
+
+		h.logger.Info("team google calendar set",
+			zap.String("team", team),
+			zap.String("calendar_id", calendarID),
+			zap.String("actor", storage.ActorFrom(ctx)),
+		)
+	}
+
+	return h.GetTeamSettings(c)
+}
+
+// ancestors returns the parent of the team, the parent of that one and so on,
+// nearest first and no more than the configured depth.
+func (h *Handler) ancestors(ctx context.Context, team string) ([]string, error) {
+	var ancestors []string
+	for range h.hierarchyDepth {
+		parent, found, err := h.storage.TeamParent(ctx, team)
+		if err != nil {
+			return nil, fmt.Errorf("get parent of team %q: %w", team, err)
+		}
+		if !found {
+			break
+		}
+
+		ancestors = append(ancestors, parent)
+		team = parent
+	}
+
+	return ancestors, nil
+}
+
+// inheritedOncall looks the member on call at the time up in the ancestors
+// of a team with nothing matching of its own, one level at a time. Paused
+// ancestors are skipped. The schedules are the effective ones up to the
+// ancestor the member comes from, the own schedules of the team first, which
+// is the order they take precedence in.
+func (h *Handler) inheritedOncall(ctx context.Context, team string, at time.Time) (
+	oncall string, schedules []storage.Schedule, from string, found bool, err error,
+) {
+	ancestors, err := h.ancestors(ctx, team)
+	if err != nil || len(ancestors) == 0 {
+		return "", nil, "", false, err
+	}
+
+	own, err := h.storage.GetTeam(ctx, team)
+	if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+		return "", nil, "", false, fmt.Errorf("get team %q: %w", team, err)
+	}
+	schedules = own.Schedules
+
+	for _, ancestor := range ancestors {
+		_, paused, err := h.activePause(ctx, ancestor, at)
+		if err != nil {
+			return "", nil, "", false, fmt.Errorf("get pause of team %q: %w", ancestor, err)
+		}
+		if paused {
+			continue
+		}
+
+		t, err := h.storage.GetTeam(ctx, ancestor)
+		if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+			return "", nil, "", false, fmt.Errorf("get team %q: %w", ancestor, err)
+		}
+		schedules = append(schedules, t.Schedules...)
+
+		if oncall, found := storage.OncallAt(t.Schedules, at); found {
+			return oncall, schedules, ancestor, true, nil
+		}
+	}
+
+	return "", nil, "", false, nil
+}
+
+// parseEffective reads the effective query parameter of listings, which adds
+// the schedules a team inherits to its own.
+func parseEffective(c echo.Context) (bool, error) {
+	value := c.QueryParam("effective")
+	if value == "" {
+		return false, nil
+	}
+
+	effective, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid effective, use true or false")
+	}
+
+	return effective, nil
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newHierarchyServer returns a server whose platform team has a day and a
+// night schedule, and whose payments team, a sub-team of it, has a day
+// schedule of its own.
+func newHierarchyServer(t *testing.T) (*echo.Echo, *Handler) {
+	t.Helper()
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.GET("/teams/:team/schedules", h.ListTeamSchedules)
+	e.GET("/teams/:team/settings", h.GetTeamSettings)
+	e.PATCH("/teams/:team/settings", h.UpdateTeamSettings)
+
+	platformDays := weekRequest("platform", "Platform Days", "Bob", "Mon-Fri")
+	nights := weekRequest("platform", "Nights", "Nina", "Mon-Fri")
+	nights.Start = "10:00PM"
+	nights.End = "11:59PM"
+
+	for _, req := range []Request{platformDays, nights, weekRequest("payments", "Days", "Alice", "Mon-Fri")} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	setParent(t, e, "payments", "platform")
+
+	return e, h
+}
+
+func setParent(t *testing.T, e *echo.Echo, team, parent string) {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPatch, "/teams/"+team+"/settings", TeamSettingsRequest{Parent: &parent}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TeamSettingsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, TeamSettingsResponse{Team: team, Parent: parent}, resp)
+}
+
+// lookup returns the on-call answer of the team on Monday March 2, 2026 at
+// the time, nil when nobody is on call.
+func lookup(t *testing.T, e *echo.Echo, team, at string) *OncallResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodGet, "/schedule?team="+team+"&time=2026-03-02T"+at+":00Z", nil, "")
+	if rec.Code == http.StatusNotFound {
+		return nil
+	}
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp OncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return &resp
+}
+
+func TestGetSchedule_OwnScheduleWins(t *testing.T) {
+	e, _ := newHierarchyServer(t)
+
+	resp := lookup(t, e, "payments", "10:00")
+	require.NotNil(t, resp)
+	assert.Equal(t, "Alice", resp.Oncall)
+	assert.Empty(t, resp.InheritedFrom)
+}
+
+func TestGetSchedule_Inherited(t *testing.T) {
+	e, h := newHierarchyServer(t)
+
+	resp := lookup(t, e, "payments", "22:30")
+	require.NotNil(t, resp)
+	assert.Equal(t, "Nina", resp.Oncall)
+	assert.Equal(t, "platform", resp.InheritedFrom)
+
+	// Teams without schedules of their own live off their ancestors, one
+	// level at a time
+	setParent(t, e, "payments-edge", "payments")
+
+	resp = lookup(t, e, "payments-edge", "10:00")
+	require.NotNil(t, resp)
+	assert.Equal(t, "Alice", resp.Oncall)
+	assert.Equal(t, "payments", resp.InheritedFrom)
+
+	resp = lookup(t, e, "payments-edge", "22:30")
+	require.NotNil(t, resp)
+	assert.Equal(t, "Nina", resp.Oncall)
+	assert.Equal(t, "platform", resp.InheritedFrom)
+
+	assert.Nil(t, lookup(t, e, "payments-edge", "20:00"))
+
+	h.SetHierarchyDepth(1)
+	assert.Nil(t, lookup(t, e, "payments-edge", "22:30"))
+
+	// Clearing the parent stops the inheritance
+	setParent(t, e, "payments", "")
+	assert.Nil(t, lookup(t, e, "payments", "22:30"))
+}
+
+func TestUpdateTeamSettings_RejectsCycles(t *testing.T) {
+	e, _ := newHierarchyServer(t)
+	setParent(t, e, "payments-edge", "payments")
+
+	for _, tt := range []struct{ team, parent string }{
+		{"platform", "platform"},
+		{"platform", "payments"},
+		{"platform", "payments-edge"},
+	} {
+		parent := tt.parent
+		rec := serveJSON(e, http.MethodPatch, "/teams/"+tt.team+"/settings", TeamSettingsRequest{Parent: &parent}, "")
+		require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, CodeParentCycle, resp.Code)
+	}
+
+	rec := serveJSON(e, http.MethodGet, "/teams/platform/settings", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"team": "platform"}`, rec.Body.String())
+}
+
+func TestListTeamSchedules_Effective(t *testing.T) {
+	e, _ := newHierarchyServer(t)
+	setParent(t, e, "payments-edge", "payments")
+
+	list := func(target string) []Request {
+		t.Helper()
+
+		rec := serveJSON(e, http.MethodGet, target, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp SchedulesResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+		return resp.Schedules
+	}
+
+	schedules := list("/teams/payments/schedules")
+	require.Len(t, schedules, 1)
+	assert.Empty(t, schedules[0].InheritedFrom)
+
+	schedules = list("/teams/payments-edge/schedules?effective=true")
+	require.Len(t, schedules, 3)
+	assert.Equal(t, []string{"Days", "Platform Days", "Nights"}, []string{schedules[0].Name, schedules[1].Name, schedules[2].Name})
+	assert.Equal(t, []string{"payments", "platform", "platform"}, []string{
+		schedules[0].InheritedFrom, schedules[1].InheritedFrom, schedules[2].InheritedFrom,
+	})
+
+	// Only effective listings know the team through its ancestors
+	rec := serveJSON(e, http.MethodGet, "/teams/payments-edge/schedules", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = serveJSON(e, http.MethodGet, "/teams/payments/schedules?effective=maybe", nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
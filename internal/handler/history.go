@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// HeaderOncallWarning carries the warning of an answer that is not what the
+// client asked for, e.g. an as_configured answer from the current schedules.
+const HeaderOncallWarning = "X-Oncall-Warning"
+
+// parseAsConfigured parses the as_configured query parameter, which asks to
+// answer from the schedules as they were configured at the queried time
+// instead of as they are now.
+func parseAsConfigured(c echo.Context) (bool, error) {
+	value := c.QueryParam("as_configured")
+	if value == "" {
+		return false, nil
+	}
+
+	asConfigured, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid as_configured, use true or false")
+	}
+
+	return asConfigured, nil
+}
+
+// teamHistory returns the versions of the team during [from, to]. When its
+// history does not reach back to from, the current schedules stand in for
+// the whole range along with a warning saying so. It returns
+// storage.ErrTeamNotFound when the team does not exist.
+func (h *Handler) teamHistory(ctx context.Context, team string, from, to time.Time) ([]storage.TeamVersion, string, error) {
+	history, err := h.storage.TeamHistory(ctx, team, from, to)
+	if err == nil {
+		return history, "", nil
+	}
+	if !errors.Is(err, storage.ErrNoHistory) {
+		return nil, "", fmt.Errorf("get history of team %q: %w", team, err)
+	}
+
+	current, err := h.storage.GetTeam(ctx, team)
+	if err != nil {
+		return nil, "", fmt.Errorf("get team %q: %w", team, err)
+	}
+
+	warning := fmt.Sprintf("schedule history does not reach back to %s, answering from the current schedules", from.UTC().Format(time.RFC3339))
+	h.logger.Warn("schedule history does not reach back", zap.String("team", team), zap.Time("from", from))
+
+	return []storage.TeamVersion{{Since: from, Team: current}}, warning, nil
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// historylessStorage is a storage whose schedule history never reaches back.
+type historylessStorage struct {
+	storage.Storage
+}
+
+func (historylessStorage) TeamHistory(context.Context, string, time.Time, time.Time) ([]storage.TeamVersion, error) {
+	return nil, storage.ErrNoHistory
+}
+
+// newHistoryServer creates a manual schedule of Alice and Bob starting a shift
+// every hour, then advances it to Bob and creates the late-team, returning
+// instants before and after the changes.
+func newHistoryServer(t *testing.T, store storage.Storage) (*echo.Echo, time.Time, time.Time) {
+	t.Helper()
+
+	e := echo.New()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.GET("/teams/:team/timeline", h.TeamTimeline)
+	e.GET("/teams/:team/oncall/export.csv", h.ExportOncall)
+
+	req := hourlyRequest("backend-team")
+	req.Rotation = RotationManual
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	time.Sleep(10 * time.Millisecond)
+	before := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+	rotation := team.Schedules[0].Rotation()
+	rotation.Offset = 1
+	_, err = store.SetRotation(t.Context(), "backend-team", team.Schedules[0].ID, rotation)
+	require.NoError(t, err)
+
+	// A team created afterwards has no schedules before
+	rec = serveJSON(e, http.MethodPost, "/schedule", hourlyRequest("late-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	time.Sleep(10 * time.Millisecond)
+	after := time.Now()
+
+	return e, before, after
+}
+
+// hourlyRequest is a schedule request of Alice and Bob starting an hour long
+// shift every hour, so someone is on call at any instant.
+func hourlyRequest(team string) Request {
+	req := quotaRequest(team)
+	req.Days = nil
+	req.Cron = "0 * * * *"
+	req.Start, req.End = "12:00AM", "1:00AM"
+
+	return req
+}
+
+// oncallQuery asks the on-call lookup of the team at the instant.
+func oncallQuery(e *echo.Echo, team string, at time.Time, asConfigured bool) *httptest.ResponseRecorder {
+	query := url.Values{}
+	query.Set("team", team)
+	query.Set("time", at.UTC().Format(time.RFC3339Nano))
+	if asConfigured {
+		query.Set("as_configured", "true")
+	}
+
+	return serveJSON(e, http.MethodGet, "/schedule?"+query.Encode(), nil, "")
+}
+
+func TestAsConfigured_Oncall(t *testing.T) {
+	e, before, after := newHistoryServer(t, storage.NewMemoryStorage())
+
+	tests := []struct {
+		name         string
+		at           time.Time
+		asConfigured bool
+		want         string
+	}{
+		{"current before", before, false, "Bob"},
+		{"configured before", before, true, "Alice"},
+		{"current after", after, false, "Bob"},
+		{"configured after", after, true, "Bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := oncallQuery(e, "backend-team", tt.at, tt.asConfigured)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var resp OncallResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.want, resp.Oncall)
+			assert.Empty(t, resp.Warning)
+		})
+	}
+
+	// Schedules created later do not match
+	rec := oncallQuery(e, "late-team", before, true)
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+	rec = oncallQuery(e, "late-team", after, true)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=2026-03-02T10:00:00Z&as_configured=maybe", nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAsConfigured_Timeline(t *testing.T) {
+	e, before, after := newHistoryServer(t, storage.NewMemoryStorage())
+
+	query := url.Values{}
+	query.Set("from", before.UTC().Format(time.RFC3339Nano))
+	query.Set("to", after.UTC().Format(time.RFC3339Nano))
+	query.Set("granularity", GranularityHour)
+
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/timeline?"+query.Encode(), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TimelineResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Lanes, 1)
+	for _, segment := range resp.Lanes[0].Segments {
+		assert.Equal(t, "Bob", segment.Member)
+	}
+
+	query.Set("as_configured", "true")
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team/timeline?"+query.Encode(), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	resp = TimelineResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Lanes, 1)
+	segments := resp.Lanes[0].Segments
+	require.NotEmpty(t, segments)
+	assert.Equal(t, "Alice", segments[0].Member)
+	assert.Equal(t, "Bob", segments[len(segments)-1].Member)
+	assert.Empty(t, resp.Warning)
+
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team/oncall/export.csv?"+query.Encode(), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	require.GreaterOrEqual(t, len(lines), 3)
+	assert.Equal(t, "Alice", strings.Split(lines[1], ",")[4], lines[1])
+	assert.Equal(t, "Bob", strings.Split(lines[len(lines)-1], ",")[4], lines[len(lines)-1])
+}
+
+func TestAsConfigured_Fallback(t *testing.T) {
+	e, before, _ := newHistoryServer(t, historylessStorage{storage.NewMemoryStorage()})
+
+	rec := oncallQuery(e, "backend-team", before, true)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotEmpty(t, rec.Header().Get(HeaderOncallWarning))
+
+	var resp OncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Bob", resp.Oncall)
+	assert.Contains(t, resp.Warning, "current schedules")
+
+	rec = oncallQuery(e, "no-such-team", before, true)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/integrity"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// IntegrityFinding represents a member of a schedule who cannot be paged,
+// with the reason as a code and a message saying what to fix.
+type IntegrityFinding struct {
+	Team       string `json:"team"`
+	ScheduleID string `json:"schedule_id"`
+	Schedule   string `json:"schedule"`
+	Member     string `json:"member"`
+	Reason     string `json:"reason"`
+	Message    string `json:"message"`
+}
+
+// IntegrityResponse represents an integrity report.
+type IntegrityResponse struct {
+	Total    int                `json:"total"`
+	Findings []IntegrityFinding `json:"findings"`
+}
+
+// SetIntegrity sets the checker the integrity reports come from.
+func (h *Handler) SetIntegrity(checker *integrity.Checker) {
+	h.integrity = checker
+}
+
+// TeamIntegrity handles requests for the integrity report of a team.
+func (h *Handler) TeamIntegrity(c echo.Context) error {
+	team := c.Param("team")
+
+	findings, err := h.integrity.Team(c.Request().Context(), team)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, team, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("check integrity of team %q: %w", team, err), "failed to check team integrity")
+	}
+
+	return c.JSON(http.StatusOK, integrityReport(findings))
+}
+
+// Integrity handles requests for the integrity report of every team. It
+// updates the findings metric too.
+func (h *Handler) Integrity(c echo.Context) error {
+	findings, err := h.integrity.All(c.Request().Context())
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("check integrity: %w", err), "failed to check integrity")
+	}
+
+	return c.JSON(http.StatusOK, integrityReport(findings))
+}
+
+// integrityReport renders the findings of a check.
+func integrityReport(findings []integrity.Finding) IntegrityResponse {
+	resp := IntegrityResponse{Total: len(findings), Findings: make([]IntegrityFinding, 0, len(findings))}
+	for _, finding := range findings {
+		resp.Findings = append(resp.Findings, IntegrityFinding{
+			Team:       finding.Team,
+			ScheduleID: finding.ScheduleID,
+			Schedule:   finding.Schedule,
+			Member:     finding.Member,
+			Reason:     finding.Reason,
+			Message:    integrityMessage(finding),
+		})
+	}
+
+	return resp
+}
+
+// integrityMessage says what is wrong with the member of the finding.
+func integrityMessage(finding integrity.Finding) string {
+	switch finding.Reason {
+	case integrity.ReasonDeactivated:
+		return fmt.Sprintf("%s is deactivated, remove them from %s", finding.Member, finding.Schedule)
+	case integrity.ReasonNotInTeam:
+		return fmt.Sprintf("%s is not a member of %s, add them to the team or remove them from %s",
+			finding.Member, finding.Team, finding.Schedule)
+	case integrity.ReasonNoContact:
+		return fmt.Sprintf("%s has no email to be reminded of their shifts at", finding.Member)
+	default:
+		return finding.Reason
+	}
+}
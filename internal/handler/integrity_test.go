@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/integrity"
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newIntegrityServer returns a server whose payments team has schedules with
+// a deactivated member, a member who left the team and a member without an
+// email, while shift reminders are sent.
+func newIntegrityServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	cfg := &config.Config{Notify: config.NotifyConfig{
+		Interval:  time.Minute,
+		Reminders: config.RemindersConfig{LeadTime: 30 * time.Minute},
+	}}
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	h.SetIntegrity(integrity.New(store, notify.NewDispatcher([]notify.Notifier{discardNotifier{}}, cfg, zap.NewNop()), cfg, zap.NewNop()))
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team/integrity", h.TeamIntegrity)
+	e.GET("/admin/integrity", h.Integrity)
+
+	ctx := context.Background()
+	for _, user := range []storage.User{
+		{UserName: "Alice", Emails: []string{"alice@example.com"}, Active: true},
+		{UserName: "Bob", Emails: []string{"bob@example.com"}, Active: false},
+		{UserName: "Carol", Active: true},
+	} {
+		_, _, err := store.AddUser(ctx, user)
+		require.NoError(t, err)
+	}
+
+	req := weekRequest("payments", "Days", "Alice", "Mon-Fri")
+	req.Members = []string{"Alice", "Bob", "Carol"}
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// Dave was never provisioned and left the team, the schedule stayed
+	rec = serveJSON(e, http.MethodPost, "/schedule", weekRequest("payments", "Nights", "Dave", "Mon-Fri"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	removed, err := store.RemoveTeamMember(ctx, "payments", "Dave")
+	require.NoError(t, err)
+	require.True(t, removed)
+
+	return e
+}
+
+func getIntegrity(t *testing.T, e *echo.Echo, target string) IntegrityResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodGet, target, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp IntegrityResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestTeamIntegrity(t *testing.T) {
+	e := newIntegrityServer(t)
+
+	resp := getIntegrity(t, e, "/teams/payments/integrity")
+	assert.Equal(t, 4, resp.Total)
+	require.Len(t, resp.Findings, 4)
+
+	type finding struct{ schedule, member, reason string }
+	var got []finding
+	for _, f := range resp.Findings {
+		assert.Equal(t, "payments", f.Team)
+		assert.NotEmpty(t, f.ScheduleID)
+		got = append(got, finding{f.Schedule, f.Member, f.Reason})
+	}
+	assert.Equal(t, []finding{
+		{"Days", "Bob", integrity.ReasonDeactivated},
+		{"Days", "Carol", integrity.ReasonNoContact},
+		{"Nights", "Dave", integrity.ReasonNotInTeam},
+		{"Nights", "Dave", integrity.ReasonNoContact},
+	}, got)
+
+	assert.Equal(t, "Bob is deactivated, remove them from Days", resp.Findings[0].Message)
+	assert.Equal(t, "Dave is not a member of payments, add them to the team or remove them from Nights", resp.Findings[2].Message)
+
+	rec := serveJSON(e, http.MethodGet, "/teams/unknown/integrity", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestIntegrity_AcrossTeams(t *testing.T) {
+	e := newIntegrityServer(t)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", weekRequest("billing", "Days", "Bob", "Mon-Fri"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	resp := getIntegrity(t, e, "/admin/integrity")
+	assert.Equal(t, 5, resp.Total)
+	assert.Equal(t, "billing", resp.Findings[0].Team)
+	assert.Equal(t, integrity.ReasonDeactivated, resp.Findings[0].Reason)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// WeekDay represents the members of a schedule on duty during a day of an
+// ISO week.
+type WeekDay struct {
+	Date    string   `json:"date"`
+	Members []string `json:"members"`
+}
+
+// WeekSchedule represents who a schedule has on duty during an ISO week.
+// Member is set when a single member has every shift of the week, as with
+// weekly rotations, otherwise Days breaks the week down by day.
+type WeekSchedule struct {
+	ScheduleID string    `json:"schedule_id"`
+	Schedule   string    `json:"schedule"`
+	Member     string    `json:"member,omitempty"`
+	Days       []WeekDay `json:"days,omitempty"`
+}
+
+// WeekOncallResponse represents who is on call during an ISO week. Start and
+// End are the Monday and the Sunday of the week.
+type WeekOncallResponse struct {
+	Team      string         `json:"team"`
+	Year      int            `json:"year"`
+	Week      int            `json:"week"`
+	Start     string         `json:"start"`
+	End       string         `json:"end"`
+	Schedules []WeekSchedule `json:"schedules"`
+}
+
+// WeekOncall handles requests for who is on call during an ISO 8601 week,
+// e.g. to answer who has week 34. Weeks start on Monday in UTC, which the
+// schedules are defined in, and the first week of a year is the one holding
+// its first Thursday, so early January days may belong to the last week of
+// the previous year. Every schedule of the team reports the members its
+// shifts resolve to, pins included, as if it were the only one.
+func (h *Handler) WeekOncall(c echo.Context) error {
+	teamName := c.Param("team")
+
+	year, err := strconv.Atoi(c.QueryParam("year"))
+	if err != nil || year < 1 || year > 9999 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "year must be between 1 and 9999"})
+	}
+
+	weeks := isoWeeks(year)
+	week, err := strconv.Atoi(c.QueryParam("week"))
+	if err != nil || week < 1 || week > weeks {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("week must be between 1 and %d in %d", weeks, year),
+		})
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	from := isoWeekStart(year, week)
+	to := from.AddDate(0, 0, 7)
+
+	resp := WeekOncallResponse{
+		Team:      teamName,
+		Year:      year,
+		Week:      week,
+		Start:     from.Format(time.DateOnly),
+		End:       to.AddDate(0, 0, -1).Format(time.DateOnly),
+		Schedules: make([]WeekSchedule, 0, len(team.Schedules)),
+	}
+
+	for _, sched := range team.Schedules {
+		duties := storage.DutyTimeline([]storage.Schedule{sched}, from, to)
+
+		entry := WeekSchedule{ScheduleID: sched.ID, Schedule: sched.Name}
+
+		var members []string
+		for _, duty := range duties {
+			if !slices.Contains(members, duty.Member) {
+				members = append(members, duty.Member)
+			}
+		}
+
+		switch len(members) {
+		case 0:
+		case 1:
+			entry.Member = members[0]
+		default:
+			for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+				next := day.AddDate(0, 0, 1)
+
+				// Shifts crossing midnight count on both days
+				onDuty := []string{}
+				for _, duty := range duties {
+					if duty.Start.Before(next) && day.Before(duty.End) && !slices.Contains(onDuty, duty.Member) {
+						onDuty = append(onDuty, duty.Member)
+					}
+				}
+
+				entry.Days = append(entry.Days, WeekDay{Date: day.Format(time.DateOnly), Members: onDuty})
+			}
+		}
+
+		resp.Schedules = append(resp.Schedules, entry)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// isoWeekStart returns midnight UTC of the Monday of the ISO week of the year.
+// The first week is the one holding January 4th.
+func isoWeekStart(year, week int) time.Time {
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+
+	return monday.AddDate(0, 0, 7*(week-1))
+}
+
+// isoWeeks returns the number of ISO weeks of the year, 52 or 53. December
+// 28th is always in the last week.
+func isoWeeks(year int) int {
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+
+	return week
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newWeekOncallServer returns a server whose backend-team rotates weekly
+// through Alice, Bob and Carol from Monday December 30, 2024, the first day
+// of ISO week 1 of 2025, and has a fixed schedule with a member per day.
+func newWeekOncallServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team/oncall/week", h.WeekOncall)
+
+	for _, req := range []Request{
+		{
+			Name:    "Weekly",
+			Team:    "backend-team",
+			Members: []string{"Alice", "Bob", "Carol"},
+			Days:    []string{"Mon-Fri"},
+			Start:   "9:00AM",
+			End:     "5:00PM",
+			Anchor:  "2024-12-30",
+		},
+		{
+			Name:           "Nights",
+			Team:           "backend-team",
+			Assignment:     AssignmentFixed,
+			DayAssignments: map[string]string{"Monday": "Dave", "Tuesday": "Erin", "Wednesday": "Dave"},
+			Start:          "10:00PM",
+			End:            "11:59PM",
+		},
+	} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	return e
+}
+
+func getWeekOncall(t *testing.T, e *echo.Echo, query string) WeekOncallResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/oncall/week?"+query, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp WeekOncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestWeekOncall(t *testing.T) {
+	e := newWeekOncallServer(t)
+
+	tests := []struct {
+		year, week int
+		start, end string
+		member     string
+	}{
+		// The first week of 2025 starts in December 2024
+		{2025, 1, "2024-12-30", "2025-01-05", "Alice"},
+		{2025, 2, "2025-01-06", "2025-01-12", "Bob"},
+		{2025, 34, "2025-08-18", "2025-08-24", "Alice"},
+		{2025, 52, "2025-12-22", "2025-12-28", "Alice"},
+		// 2026 has 53 weeks, the last one ends in January 2027
+		{2026, 1, "2025-12-29", "2026-01-04", "Bob"},
+		{2026, 53, "2026-12-28", "2027-01-03", "Carol"},
+		{2027, 1, "2027-01-04", "2027-01-10", "Alice"},
+	}
+
+	for _, tt := range tests {
+		resp := getWeekOncall(t, e, fmt.Sprintf("year=%d&week=%d", tt.year, tt.week))
+
+		assert.Equal(t, tt.start, resp.Start, "%d-W%d", tt.year, tt.week)
+		assert.Equal(t, tt.end, resp.End, "%d-W%d", tt.year, tt.week)
+		require.Len(t, resp.Schedules, 2)
+		assert.Equal(t, "Weekly", resp.Schedules[0].Schedule)
+		assert.Equal(t, tt.member, resp.Schedules[0].Member, "%d-W%d", tt.year, tt.week)
+		assert.Empty(t, resp.Schedules[0].Days)
+	}
+}
+
+func TestWeekOncall_PerDay(t *testing.T) {
+	e := newWeekOncallServer(t)
+
+	resp := getWeekOncall(t, e, "year=2025&week=34")
+	require.Len(t, resp.Schedules, 2)
+
+	nights := resp.Schedules[1]
+	assert.Empty(t, nights.Member)
+	assert.Equal(t, []WeekDay{
+		{Date: "2025-08-18", Members: []string{"Dave"}},
+		{Date: "2025-08-19", Members: []string{"Erin"}},
+		{Date: "2025-08-20", Members: []string{"Dave"}},
+		{Date: "2025-08-21", Members: []string{}},
+		{Date: "2025-08-22", Members: []string{}},
+		{Date: "2025-08-23", Members: []string{}},
+		{Date: "2025-08-24", Members: []string{}},
+	}, nights.Days)
+}
+
+func TestWeekOncall_Errors(t *testing.T) {
+	e := newWeekOncallServer(t)
+
+	for _, tt := range []struct {
+		target string
+		code   int
+	}{
+		{"/teams/backend-team/oncall/week?week=34", http.StatusBadRequest},
+		{"/teams/backend-team/oncall/week?year=2025", http.StatusBadRequest},
+		{"/teams/backend-team/oncall/week?year=2025&week=0", http.StatusBadRequest},
+		{"/teams/backend-team/oncall/week?year=2025&week=53", http.StatusBadRequest},
+		{"/teams/backend-team/oncall/week?year=2026&week=54", http.StatusBadRequest},
+		{"/teams/other-team/oncall/week?year=2025&week=34", http.StatusNotFound},
+	} {
+		rec := serveJSON(e, http.MethodGet, tt.target, nil, "")
+		assert.Equal(t, tt.code, rec.Code, tt.target)
+	}
+}
+
+func TestISOWeekStart(t *testing.T) {
+	// Every week starts on the Monday Go numbers with the same ISO week
+	for year := 2020; year <= 2030; year++ {
+		for week := 1; week <= isoWeeks(year); week++ {
+			start := isoWeekStart(year, week)
+			assert.Equal(t, time.Monday, start.Weekday())
+
+			gotYear, gotWeek := start.ISOWeek()
+			assert.Equal(t, []int{year, week}, []int{gotYear, gotWeek})
+		}
+	}
+
+	assert.Equal(t, 53, isoWeeks(2020))
+	assert.Equal(t, 52, isoWeeks(2025))
+	assert.Equal(t, 53, isoWeeks(2026))
+}
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"go.uber.org/zap"
+)
+
+// Listener serves the server on one of its addresses, with TLS when the
+// address has a certificate, or on its unix domain socket. Every listener
+// serves the same handler and is started and shut down on its own.
+type Listener struct {
+	config   config.ListenerConfig
+	socket   config.SocketConfig
+	server   *http.Server
+	listener net.Listener
+	logger   *zap.Logger
+}
+
+// NewListener creates a listener serving next. Plain listeners redirecting to
+// HTTPS redirect to httpsPort instead.
+func NewListener(cfg config.ListenerConfig, next http.Handler, httpsPort int, logger *zap.Logger) *Listener {
+	if cfg.RedirectToHTTPS {
+		next = RedirectToHTTPS(next, httpsPort)
+	}
+
+	return &Listener{
+		config: cfg,
+		server: &http.Server{Handler: next},
+		logger: logger,
+	}
+}
+
+// NewSocketListener creates a listener serving next on the unix domain
+// socket.
+func NewSocketListener(cfg config.SocketConfig, next http.Handler, logger *zap.Logger) *Listener {
+	return &Listener{
+		socket: cfg,
+		server: &http.Server{Handler: next},
+		logger: logger,
+	}
+}
+
+// Start binds the address of the listener and serves it in the background.
+// Failing to bind or to load the certificate fails the start.
+func (l *Listener) Start() error {
+	if l.socket.Enabled() {
+		return l.startSocket()
+	}
+
+	addr := net.JoinHostPort(l.config.Address, strconv.Itoa(l.config.Port))
+
+	if l.config.TLS.Enabled() {
+		cert, err := tls.LoadX509KeyPair(l.config.TLS.CertFile, l.config.TLS.KeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load certificate of %s: %w", addr, err)
+		}
+
+		l.server.TLSConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		}
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	l.listener = listener
+
+	l.logger.Info("starting server", zap.String("address", listener.Addr().String()), zap.Bool("tls", l.config.TLS.Enabled()))
+
+	l.serve(listener, l.config.TLS.Enabled())
+
+	return nil
+}
+
+// startSocket binds the unix domain socket of the listener, replacing the
+// one a previous server left behind, and gives it the mode of the socket.
+func (l *Listener) startSocket() error {
+	path := l.socket.Path
+
+	mode, err := l.socket.FileMode()
+	if err != nil {
+		return err
+	}
+
+	if err := removeStaleSocket(path); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("failed to set the mode of %s: %w", path, err)
+	}
+	l.listener = listener
+
+	l.logger.Info("starting server", zap.String("socket", path), zap.Stringer("mode", mode))
+
+	l.serve(listener, false)
+
+	return nil
+}
+
+// serve serves the listener in the background.
+func (l *Listener) serve(listener net.Listener, withTLS bool) {
+	go func() {
+		var err error
+		if withTLS {
+			// The certificate is already in the TLS config
+			err = l.server.ServeTLS(listener, "", "")
+		} else {
+			err = l.server.Serve(listener)
+		}
+
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			l.logger.Error("server failed", zap.String("address", listener.Addr().String()), zap.Error(err))
+		}
+	}()
+}
+
+// removeStaleSocket removes the socket file at path when no server accepts
+// connections on it anymore. Files that are not sockets are left alone.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Addr returns the address the listener is bound to, nil before it starts.
+func (l *Listener) Addr() net.Addr {
+	if l.listener == nil {
+		return nil
+	}
+
+	return l.listener.Addr()
+}
+
+// Shutdown stops accepting connections and waits up to timeout for in-flight
+// requests before closing the remaining connections forcefully. The server
+// is expected to be drained already, see Drain.Begin.
+func (l *Listener) Shutdown(ctx context.Context, timeout time.Duration) error {
+	err := closeServer(ctx, timeout, l.server.Shutdown, l.server.Close)
+
+	// Closing the listener unlinks the socket, unless closing it failed
+	if l.socket.Enabled() && l.listener != nil {
+		if rerr := os.Remove(l.socket.Path); rerr != nil && !errors.Is(rerr, fs.ErrNotExist) {
+			err = errors.Join(err, fmt.Errorf("failed to remove socket %s: %w", l.socket.Path, rerr))
+		}
+	}
+
+	return err
+}
+
+// RedirectToHTTPS redirects every request but health probes to the same
+// host on port with a 308, which keeps the method and body of the request.
+func RedirectToHTTPS(next http.Handler, port int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if probePaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		switch {
+		case port != 443:
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		case strings.Contains(host, ":"):
+			// IPv6 literals keep their brackets without a port
+			host = "[" + host + "]"
+		}
+
+		target := url.URL{
+			Scheme:   "https",
+			Host:     host,
+			Path:     r.URL.Path,
+			RawPath:  r.URL.RawPath,
+			RawQuery: r.URL.RawQuery,
+		}
+
+		http.Redirect(w, r, target.String(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"io/fs"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 and its key
+// and returns their paths along with a pool trusting it.
+func writeCertificate(t *testing.T) (string, string, *x509.CertPool) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "oncall-test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return certFile, keyFile, pool
+}
+
+// startListener starts a listener of the handler on an ephemeral port and
+// returns its port.
+func startListener(t *testing.T, cfg config.ListenerConfig, e *echo.Echo, httpsPort int) int {
+	t.Helper()
+
+	cfg.Address = "127.0.0.1"
+	listener := NewListener(cfg, e, httpsPort, zap.NewNop())
+	require.NoError(t, listener.Start())
+	t.Cleanup(func() { _ = listener.Shutdown(t.Context(), time.Second) })
+
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestListener_PlainAndTLS(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	e.GET("/health", h.Health)
+	e.GET("/schedule", h.GetSchedule)
+
+	certFile, keyFile, pool := writeCertificate(t)
+
+	httpsPort := startListener(t, config.ListenerConfig{TLS: config.TLSConfig{CertFile: certFile, KeyFile: keyFile}}, e, 0)
+	plainPort := startListener(t, config.ListenerConfig{}, e, 0)
+	redirectPort := startListener(t, config.ListenerConfig{RedirectToHTTPS: true}, e, httpsPort)
+
+	client := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	get := func(url string) *http.Response {
+		resp, err := client.Get(url)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		require.NoError(t, resp.Body.Close())
+
+		return resp
+	}
+
+	https := "https://127.0.0.1:" + strconv.Itoa(httpsPort)
+	resp := get(https + "/health")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotNil(t, resp.TLS)
+
+	resp = get("http://127.0.0.1:" + strconv.Itoa(plainPort) + "/health")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Nil(t, resp.TLS)
+
+	// Health probes are answered on the redirecting listener too
+	redirect := "http://127.0.0.1:" + strconv.Itoa(redirectPort)
+	resp = get(redirect + "/health")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = get(redirect + "/schedule?team=backend-team")
+	assert.Equal(t, http.StatusPermanentRedirect, resp.StatusCode)
+	assert.Equal(t, https+"/schedule?team=backend-team", resp.Header.Get(echo.HeaderLocation))
+
+	// Following the redirect reaches the TLS listener, which answers like the plain one
+	plain := get("http://127.0.0.1:" + strconv.Itoa(plainPort) + "/schedule?team=backend-team")
+	resp = get(resp.Header.Get(echo.HeaderLocation))
+	assert.Equal(t, plain.StatusCode, resp.StatusCode)
+	assert.NotEqual(t, http.StatusPermanentRedirect, resp.StatusCode)
+	require.NotNil(t, resp.TLS)
+}
+
+func TestListener_StartFailures(t *testing.T) {
+	e := echo.New()
+
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+
+	listener := NewListener(config.ListenerConfig{Address: "127.0.0.1", Port: taken.Addr().(*net.TCPAddr).Port}, e, 0, zap.NewNop())
+	err = listener.Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to listen")
+
+	listener = NewListener(config.ListenerConfig{
+		Address: "127.0.0.1",
+		TLS:     config.TLSConfig{CertFile: "missing.pem", KeyFile: "missing.key"},
+	}, e, 0, zap.NewNop())
+	err = listener.Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load certificate")
+	assert.Nil(t, listener.Addr())
+}
+
+// leaveStaleSocket leaves a socket file no server accepts connections on
+// behind at path, like a server that crashed.
+func leaveStaleSocket(t *testing.T, path string) {
+	t.Helper()
+
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+}
+
+func TestListener_Socket(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	e.GET("/health", h.Health)
+
+	path := filepath.Join(t.TempDir(), "oncall.sock")
+	leaveStaleSocket(t, path)
+
+	listener := NewSocketListener(config.SocketConfig{Path: path, Mode: "0640"}, e, zap.NewNop())
+	require.NoError(t, listener.Start())
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSocket)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	// A second server cannot take the socket over while it is in use
+	err = NewSocketListener(config.SocketConfig{Path: path, Mode: "0640"}, e, zap.NewNop()).Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "in use")
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://localhost/health")
+	require.NoError(t, err)
+	_, _ = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, listener.Shutdown(t.Context(), time.Second))
+	_, err = os.Lstat(path)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestListener_SocketStartFailures(t *testing.T) {
+	e := echo.New()
+	dir := t.TempDir()
+
+	// Files that are not sockets are never removed
+	path := filepath.Join(dir, "oncall.sock")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+	err := NewSocketListener(config.SocketConfig{Path: path, Mode: "0660"}, e, zap.NewNop()).Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a socket")
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	listener := NewSocketListener(config.SocketConfig{Path: filepath.Join(dir, "other.sock"), Mode: "rw"}, e, zap.NewNop())
+	err = listener.Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid socket mode")
+	assert.Nil(t, listener.Addr())
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+
+	tests := []struct {
+		host     string
+		port     int
+		location string
+	}{
+		{"oncall.example.com:1373", 8443, "https://oncall.example.com:8443/a%2Fb?x=1"},
+		{"oncall.example.com", 443, "https://oncall.example.com/a%2Fb?x=1"},
+		{"[::1]:1373", 443, "https://[::1]/a%2Fb?x=1"},
+		{"[::1]:1373", 8443, "https://[::1]:8443/a%2Fb?x=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+"->"+strconv.Itoa(tt.port), func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "http://"+tt.host+"/a%2Fb?x=1", strings.NewReader("{}"))
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			RedirectToHTTPS(next, tt.port).ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusPermanentRedirect, rec.Code)
+			assert.Equal(t, tt.location, rec.Header().Get(echo.HeaderLocation))
+		})
+	}
+}
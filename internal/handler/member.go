@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// defaultMemberShiftsRange is the range of a member shift listing without a
+// to query parameter, and maxMemberShiftsRange bounds it.
+const (
+	defaultMemberShiftsRange = 28 * 24 * time.Hour
+	maxMemberShiftsRange     = 366 * 24 * time.Hour
+)
+
+// MemberShift represents a shift a member is on duty for. LocalStart and
+// LocalEnd render it in the zone of the member, when they have one.
+type MemberShift struct {
+	Team       string `json:"team"`
+	Schedule   string `json:"schedule"`
+	ScheduleID string `json:"schedule_id"`
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	LocalStart string `json:"local_start,omitempty"`
+	LocalEnd   string `json:"local_end,omitempty"`
+}
+
+// MemberShiftsResponse represents the shifts of a member across all teams,
+// ordered by start. Timezone is the zone of the member, if any.
+type MemberShiftsResponse struct {
+	Member   string        `json:"member"`
+	Timezone string        `json:"timezone,omitempty"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Shifts   []MemberShift `json:"shifts"`
+}
+
+// MemberShifts handles member shift listing requests. It returns the shifts
+// overlapping the range the member is on duty for in every team, resolved
+// like the on-call lookup so pins and day assignments are respected. Shifts
+// starting while their team is paused are left out. The range defaults to
+// the next four weeks. Members with a zone get their shifts rendered in it
+// too, the schedules are still evaluated in their own zone.
+func (h *Handler) MemberShifts(c echo.Context) error {
+	member := strings.TrimSpace(c.Param("name"))
+	if member == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "member is required"})
+	}
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	from, to, err := h.parseMemberRange(c, loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	teams, err := h.memberTeams(ctx, member)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get teams of member %q: %w", member, err), "failed to retrieve teams")
+	}
+
+	memberLoc, local, err := storage.MemberLocation(ctx, h.storage, member)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get zone of member %q: %w", member, err), "failed to retrieve member")
+	}
+
+	type teamDuty struct {
+		team string
+		storage.Duty
+	}
+
+	var duties []teamDuty
+	for _, team := range teams {
+		for _, duty := range storage.Duties(team.schedules, from, to) {
+			if duty.Member == member && !team.paused(duty.Start) {
+				duties = append(duties, teamDuty{team: team.name, Duty: duty})
+			}
+		}
+	}
+
+	// Teams come in order, so shifts starting together stay ordered by team
+	slices.SortStableFunc(duties, func(a, b teamDuty) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	resp := MemberShiftsResponse{
+		Member: member,
+		From:   from.In(loc).Format(time.RFC3339),
+		To:     to.In(loc).Format(time.RFC3339),
+		Shifts: make([]MemberShift, 0, len(duties)),
+	}
+	if local {
+		resp.Timezone = memberLoc.String()
+	}
+	for _, duty := range duties {
+		shift := MemberShift{
+			Team:       duty.team,
+			Schedule:   duty.Schedule,
+			ScheduleID: duty.ScheduleID,
+			Start:      duty.Start.In(loc).Format(time.RFC3339),
+			End:        duty.End.In(loc).Format(time.RFC3339),
+		}
+		if local {
+			shift.LocalStart = duty.Start.In(memberLoc).Format(time.RFC3339)
+			shift.LocalEnd = duty.End.In(memberLoc).Format(time.RFC3339)
+		}
+		resp.Shifts = append(resp.Shifts, shift)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// memberLocation returns the zone of the member their times are rendered
+// in, UTC when it cannot be looked up.
+func (h *Handler) memberLocation(ctx context.Context, member string) *time.Location {
+	loc, _, err := storage.MemberLocation(ctx, h.storage, member)
+	if err != nil {
+		h.logger.Warn("failed to get zone of member, rendering in UTC", zap.String("member", member), zap.Error(err))
+	}
+
+	return loc
+}
+
+// AvailabilityInterval represents a stretch of the availability of a member.
+// Busy stretches carry the team and schedule the member is on call for.
+type AvailabilityInterval struct {
+	Team     string `json:"team,omitempty"`
+	Schedule string `json:"schedule,omitempty"`
+	Start    string `json:"start"`
+	End      string `json:"end"`
+}
+
+// AvailabilityResponse represents the availability of a member within a
+// window. Busy stretches of different teams may overlap, and Free holds the
+// rest of the window, both ordered by start.
+type AvailabilityResponse struct {
+	Member string                 `json:"member"`
+	From   string                 `json:"from"`
+	To     string                 `json:"to"`
+	Busy   []AvailabilityInterval `json:"busy"`
+	Free   []AvailabilityInterval `json:"free"`
+}
+
+// OncallStatusResponse represents whether a member is on call at an instant
+// and for which teams.
+type OncallStatusResponse struct {
+	Member string   `json:"member"`
+	At     string   `json:"at"`
+	Oncall bool     `json:"oncall"`
+	Teams  []string `json:"teams"`
+}
+
+// MemberAvailability handles member free/busy requests. With the at query
+// parameter it reports whether the member is on call at the instant in any
+// team. Otherwise it returns the stretches of the window the member is on
+// call, clipped to it, and the free rest. Both are resolved like the on-call
+// lookup, and time while a team is paused is free.
+func (h *Handler) MemberAvailability(c echo.Context) error {
+	member := strings.TrimSpace(c.Param("name"))
+	if member == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "member is required"})
+	}
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	var at time.Time
+	if value := c.QueryParam("at"); value != "" {
+		if c.QueryParam("from") != "" || c.QueryParam("to") != "" {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "at cannot be combined with from or to"})
+		}
+		if at, err = h.parseTime(value, "at", loc); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	var from, to time.Time
+	if at.IsZero() {
+		if from, to, err = h.parseMemberRange(c, loc); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	teams, err := h.memberTeams(c.Request().Context(), member)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get teams of member %q: %w", member, err), "failed to retrieve teams")
+	}
+
+	if !at.IsZero() {
+		resp := OncallStatusResponse{Member: member, At: at.In(loc).Format(time.RFC3339), Teams: []string{}}
+		for _, team := range teams {
+			if team.paused(at) {
+				continue
+			}
+			// The stretch running at the instant is the answer of the lookup
+			for _, duty := range storage.DutyTimeline(team.schedules, at, at.Add(time.Nanosecond)) {
+				if duty.Member == member {
+					resp.Teams = append(resp.Teams, team.name)
+				}
+			}
+		}
+		resp.Oncall = len(resp.Teams) > 0
+
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	type teamStretch struct {
+		team string
+		storage.Shift
+	}
+
+	var busy []teamStretch
+	for _, team := range teams {
+		for _, duty := range storage.DutyTimeline(team.schedules, from, to) {
+			if duty.Member != member {
+				continue
+			}
+			for _, stretch := range outsidePause(duty.Shift, team.pause) {
+				busy = append(busy, teamStretch{team: team.name, Shift: stretch})
+			}
+		}
+	}
+
+	// Teams come in order, so stretches starting together stay ordered by team
+	slices.SortStableFunc(busy, func(a, b teamStretch) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	resp := AvailabilityResponse{
+		Member: member,
+		From:   from.In(loc).Format(time.RFC3339),
+		To:     to.In(loc).Format(time.RFC3339),
+		Busy:   make([]AvailabilityInterval, 0, len(busy)),
+		Free:   []AvailabilityInterval{},
+	}
+
+	// covered is the end of the busy stretch starting at from
+	covered := from
+	free := func(end time.Time) {
+		if end.After(covered) {
+			resp.Free = append(resp.Free, AvailabilityInterval{
+				Start: covered.In(loc).Format(time.RFC3339),
+				End:   end.In(loc).Format(time.RFC3339),
+			})
+		}
+	}
+
+	for _, stretch := range busy {
+		resp.Busy = append(resp.Busy, AvailabilityInterval{
+			Team:     stretch.team,
+			Schedule: stretch.Schedule,
+			Start:    stretch.Start.In(loc).Format(time.RFC3339),
+			End:      stretch.End.In(loc).Format(time.RFC3339),
+		})
+
+		free(stretch.Start)
+		covered = later(covered, stretch.End)
+	}
+	free(to)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// parseMemberRange parses the from and to query parameters of member
+// listings, which default to now and four weeks later.
+func (h *Handler) parseMemberRange(c echo.Context, loc *time.Location) (time.Time, time.Time, error) {
+	var err error
+
+	from := h.now()
+	if value := c.QueryParam("from"); value != "" {
+		if from, err = h.parseTime(value, "from", loc); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	to := from.Add(defaultMemberShiftsRange)
+	if value := c.QueryParam("to"); value != "" {
+		if to, err = h.parseTime(value, "to", loc); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, fmt.Errorf("from must be before to")
+	}
+	if to.Sub(from) > maxMemberShiftsRange {
+		return time.Time{}, time.Time{}, fmt.Errorf("range must not exceed a year")
+	}
+
+	return from, to, nil
+}
+
+// memberTeam is a team with a schedule listing or pinning a member, along
+// with its pause, which is zero when the team is not paused.
+type memberTeam struct {
+	name      string
+	schedules []storage.Schedule
+	pause     storage.Pause
+}
+
+// paused reports whether the team is paused at the given instant.
+func (t memberTeam) paused(at time.Time) bool {
+	return !t.pause.Since.IsZero() && t.pause.Active(at)
+}
+
+// memberTeams loads the teams with a schedule listing or pinning the member,
+// in sorted order. The whole teams are loaded, as other schedules may take
+// precedence over the ones of the member.
+func (h *Handler) memberTeams(ctx context.Context, member string) ([]memberTeam, error) {
+	names, err := h.storage.FindTeamsByMember(ctx, member)
+	if err != nil {
+		return nil, err
+	}
+
+	teams := make([]memberTeam, 0, len(names))
+	for _, name := range names {
+		// The team may have been deleted in the meantime
+		team, err := h.storage.GetTeam(ctx, name)
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		pause, paused, err := h.storage.GetPause(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if !paused {
+			pause = storage.Pause{}
+		}
+
+		teams = append(teams, memberTeam{name: name, schedules: team.Schedules, pause: pause})
+	}
+
+	return teams, nil
+}
@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func getMemberAvailability(t *testing.T, h *Handler, name, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/members/"+name+"/availability?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("name")
+	c.SetParamValues(name)
+
+	require.NoError(t, h.MemberAvailability(c))
+
+	return rec
+}
+
+func getMemberShifts(t *testing.T, h *Handler, name, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/members/"+name+"/shifts?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("name")
+	c.SetParamValues(name)
+
+	require.NoError(t, h.MemberShifts(c))
+
+	return rec
+}
+
+// newMemberHandler adds Alice to two teams with rotations of different
+// lengths, and pins her to a date of a third team.
+func newMemberHandler(t *testing.T) (*Handler, storage.Team) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	anchor := time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+
+	// Alice takes every other week of the backend rotation
+	_, err := store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice", "Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Anchor:  anchor,
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	// and every third week of the frontend one
+	_, err = store.AddSchedule(ctx, "frontend-team", storage.Schedule{
+		Name:    "Evening",
+		Members: []string{"Carol", "Alice", "Dana"},
+		Days:    []time.Weekday{time.Monday},
+		Anchor:  anchor,
+		Start:   parseTime(t, "6:00PM"),
+		End:     parseTime(t, "10:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "mobile-team", storage.Schedule{
+		Name:    "Weekday",
+		Members: []string{"Erin"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+
+	backend, err := store.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	mobile, err := store.GetTeam(ctx, "mobile-team")
+	require.NoError(t, err)
+
+	// Bob covers one of Alice's backend weeks, Alice covers a mobile day
+	_, _, err = store.AddPin(ctx, "backend-team", backend.Schedules[0].ID, storage.Pin{Date: time.Date(2025, 5, 12, 0, 0, 0, 0, time.UTC), Member: "Bob"})
+	require.NoError(t, err)
+	_, _, err = store.AddPin(ctx, "mobile-team", mobile.Schedules[0].ID, storage.Pin{Date: time.Date(2025, 5, 19, 0, 0, 0, 0, time.UTC), Member: "Alice"})
+	require.NoError(t, err)
+
+	// The frontend team is paused during its last Monday
+	_, err = store.PauseTeam(ctx, "frontend-team", storage.Pause{
+		Since: time.Date(2025, 5, 26, 12, 0, 0, 0, time.UTC),
+		Until: time.Date(2025, 5, 27, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	return New(store, zap.NewNop()), backend
+}
+
+func TestMemberShifts(t *testing.T) {
+	h, backend := newMemberHandler(t)
+
+	rec := getMemberShifts(t, h, "Alice", "from=2025-04-28&to=2025-06-01")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp MemberShiftsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Alice", resp.Member)
+	assert.Equal(t, "2025-04-28T00:00:00Z", resp.From)
+
+	type shift struct{ team, start, end string }
+
+	var got []shift
+	for _, s := range resp.Shifts {
+		got = append(got, shift{s.Team, s.Start, s.End})
+	}
+	assert.Equal(t, []shift{
+		{"backend-team", "2025-04-28T09:00:00Z", "2025-04-28T17:00:00Z"},
+		{"frontend-team", "2025-05-05T18:00:00Z", "2025-05-05T22:00:00Z"},
+		{"mobile-team", "2025-05-19T09:00:00Z", "2025-05-19T17:00:00Z"},
+		{"backend-team", "2025-05-26T09:00:00Z", "2025-05-26T17:00:00Z"},
+	}, got)
+	assert.Equal(t, backend.Schedules[0].ID, resp.Shifts[0].ScheduleID)
+	assert.Equal(t, "Weekday", resp.Shifts[0].Schedule)
+
+	// Shifts are rendered in the tz zone
+	rec = getMemberShifts(t, h, "Alice", "from=2025-04-28&to=2025-04-29&tz=Asia/Tehran")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Shifts, 1)
+	assert.Equal(t, "2025-04-28T12:30:00+03:30", resp.Shifts[0].Start)
+
+	assert.Empty(t, resp.Timezone)
+	assert.Empty(t, resp.Shifts[0].LocalStart)
+
+	rec = getMemberShifts(t, h, "Zed", "from=2025-04-28&to=2025-06-01")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Shifts)
+}
+
+func TestMemberShifts_MemberTimezone(t *testing.T) {
+	h, _ := newMemberHandler(t)
+
+	_, _, err := h.storage.AddUser(context.Background(), storage.User{UserName: "Alice", Active: true, Timezone: "Asia/Tehran"})
+	require.NoError(t, err)
+
+	rec := getMemberShifts(t, h, "Alice", "from=2025-04-28&to=2025-04-29")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp MemberShiftsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Asia/Tehran", resp.Timezone)
+	require.Len(t, resp.Shifts, 1)
+
+	// The shift keeps its UTC times next to the local ones
+	assert.Equal(t, "2025-04-28T09:00:00Z", resp.Shifts[0].Start)
+	assert.Equal(t, "2025-04-28T12:30:00+03:30", resp.Shifts[0].LocalStart)
+	assert.Equal(t, "2025-04-28T20:30:00+03:30", resp.Shifts[0].LocalEnd)
+}
+
+func TestMemberShifts_InvalidRequests(t *testing.T) {
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"invalid from", "from=yesterday", "invalid from format, " + timeFormats},
+		{"invalid to", "to=tomorrow", "invalid to format, " + timeFormats},
+		{"empty range", "from=2025-05-01&to=2025-05-01", "from must be before to"},
+		{"range too long", "from=2025-01-01&to=2026-06-01", "range must not exceed a year"},
+		{"invalid tz", "tz=Mars/Base", `invalid tz query parameter: unknown time zone "Mars/Base"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getMemberShifts(t, h, "Alice", tt.query)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.err, resp.Error)
+		})
+	}
+}
+
+func TestMemberAvailability_ClipsToWindow(t *testing.T) {
+	h, _ := newMemberHandler(t)
+
+	// The window starts and ends in the middle of a shift
+	rec := getMemberAvailability(t, h, "Alice", "from=2025-04-28T12:00:00Z&to=2025-05-05T20:00:00Z")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp AvailabilityResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, []AvailabilityInterval{
+		{Team: "backend-team", Schedule: "Weekday", Start: "2025-04-28T12:00:00Z", End: "2025-04-28T17:00:00Z"},
+		{Team: "frontend-team", Schedule: "Evening", Start: "2025-05-05T18:00:00Z", End: "2025-05-05T20:00:00Z"},
+	}, resp.Busy)
+	assert.Equal(t, []AvailabilityInterval{
+		{Start: "2025-04-28T17:00:00Z", End: "2025-05-05T18:00:00Z"},
+	}, resp.Free)
+
+	// A member on call nowhere is free for the whole window
+	rec = getMemberAvailability(t, h, "Zed", "from=2025-04-28T12:00:00Z&to=2025-05-05T20:00:00Z")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Empty(t, resp.Busy)
+	assert.Equal(t, []AvailabilityInterval{{Start: "2025-04-28T12:00:00Z", End: "2025-05-05T20:00:00Z"}}, resp.Free)
+}
+
+func TestMemberAvailability_At(t *testing.T) {
+	h, _ := newMemberHandler(t)
+
+	tests := []struct {
+		at    string
+		teams []string
+	}{
+		{"2025-05-05T19:00:00Z", []string{"frontend-team"}},
+		{"2025-05-19T10:00:00Z", []string{"mobile-team"}},
+		{"2025-05-05T10:00:00Z", []string{}},
+		// The frontend team is paused
+		{"2025-05-26T19:00:00Z", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.at, func(t *testing.T) {
+			rec := getMemberAvailability(t, h, "Alice", "at="+tt.at)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var resp OncallStatusResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.at, resp.At)
+			assert.Equal(t, len(tt.teams) > 0, resp.Oncall)
+			assert.Equal(t, tt.teams, resp.Teams)
+		})
+	}
+
+	rec := getMemberAvailability(t, h, "Alice", "at=2025-05-05T19:00:00Z&from=2025-05-05")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "at cannot be combined with from or to")
+
+	rec = getMemberAvailability(t, h, "Alice", "at=noon")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid at format")
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CodeTeamMerged is the code of the error answering requests for a team
+// that was merged into another.
+const CodeTeamMerged = "TEAM_MERGED"
+
+// MergeRequest represents the team merge request.
+type MergeRequest struct {
+	Source string `json:"source"`
+	// Conflict is what happens to the schedules of the source named like
+	// schedules of the target, "fail" when empty.
+	Conflict string `json:"conflict,omitempty"`
+}
+
+// MergeResponse represents a merge of two teams.
+type MergeResponse struct {
+	Team      string `json:"team"`
+	Source    string `json:"source"`
+	Schedules int    `json:"schedules"`
+	// Renamed maps the schedules of the source that were renamed to their new name.
+	Renamed map[string]string `json:"renamed,omitempty"`
+}
+
+// MergeConflictResponse answers a merge rejected for schedules both teams have.
+type MergeConflictResponse struct {
+	ErrorResponse
+	Schedules []string `json:"schedules"`
+}
+
+// TeamMergedResponse answers requests for a team that was merged into another.
+type TeamMergedResponse struct {
+	ErrorResponse
+	MergedInto string `json:"merged_into"`
+}
+
+// MergeTeams handles requests merging a team into the one of the path, e.g.
+// after a reorg. Everything of the source moves over at once, and requests
+// for the source are answered with a pointer to the target from then on.
+func (h *Handler) MergeTeams(c echo.Context) error {
+	target := c.Param("team")
+
+	var req MergeRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	source := strings.TrimSpace(req.Source)
+	switch {
+	case source == "":
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "source is required"})
+	case source == target:
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "source must differ from the team"})
+	}
+
+	policy := req.Conflict
+	if policy == "" {
+		policy = storage.MergeFail
+	}
+	if policy != storage.MergeFail && policy != storage.MergeRename {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid conflict, use fail or rename"})
+	}
+
+	change := fmt.Sprintf("merge %s into %s", source, target)
+	for _, team := range []string{target, source} {
+		if frozen, err := h.rejectFrozen(c, team, change); frozen {
+			return err
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	merge, found, err := h.storage.MergeTeams(ctx, target, source, policy)
+	var conflictErr *storage.MergeConflictError
+	if errors.As(err, &conflictErr) {
+		return c.JSON(http.StatusConflict, MergeConflictResponse{
+			ErrorResponse: ErrorResponse{Error: conflictErr.Error()},
+			Schedules:     conflictErr.Schedules,
+		})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("merge team %q into %q: %w", source, target, err), "failed to merge teams")
+	}
+
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "team not found"})
+	}
+
+	h.logger.Info("teams merged",
+		zap.String("team", target),
+		zap.String("source", source),
+		zap.Int("schedules", merge.Schedules),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	return c.JSON(http.StatusOK, MergeResponse{
+		Team:      target,
+		Source:    source,
+		Schedules: merge.Schedules,
+		Renamed:   merge.Renamed,
+	})
+}
+
+// teamNotFound answers a request for a team that does not exist with the
+// message, or with a 410 pointing at the team it was merged into.
+func (h *Handler) teamNotFound(c echo.Context, team, message string) error {
+	into, merged, err := h.storage.MergedInto(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get merge of team %q: %w", team, err), "failed to retrieve team")
+	}
+
+	if merged {
+		return c.JSON(http.StatusGone, TeamMergedResponse{
+			ErrorResponse: ErrorResponse{Error: fmt.Sprintf("team %s was merged into %s", team, into), Code: CodeTeamMerged},
+			MergedInto:    into,
+		})
+	}
+
+	return c.JSON(http.StatusNotFound, ErrorResponse{Error: message})
+}
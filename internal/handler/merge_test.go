@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newMergeServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.GET("/teams/:team/timeline", h.TeamTimeline)
+	e.POST("/teams/:team/merge", h.MergeTeams, h.Authenticate(auth.RoleAdmin, "secret"))
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// The schedule of the merged team has the name of the one of the target
+	rec = serveJSON(e, http.MethodPost, "/schedule", Request{
+		Name:    "Weekday",
+		Team:    "payments-edge",
+		Members: []string{"Carol"},
+		Days:    []string{"Saturday-Sunday"},
+		Start:   "9:00AM",
+		End:     "5:00PM",
+	}, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e
+}
+
+func TestMergeTeams(t *testing.T) {
+	e := newMergeServer(t)
+
+	monday := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	saturday := time.Date(2026, 3, 7, 10, 0, 0, 0, time.UTC)
+	before := oncallAt(t, e, monday)
+
+	rec := serveJSON(e, http.MethodPost, "/teams/backend-team/merge", MergeRequest{Source: "payments-edge"}, "")
+	require.Equal(t, http.StatusUnauthorized, rec.Code, rec.Body.String())
+
+	// Colliding names fail the merge unless renaming is asked for
+	rec = serveJSON(e, http.MethodPost, "/teams/backend-team/merge", MergeRequest{Source: "payments-edge"}, "secret")
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+
+	var conflict MergeConflictResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &conflict))
+	assert.Equal(t, []string{"Weekday"}, conflict.Schedules)
+
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=payments-edge&time="+saturday.Format(time.RFC3339), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodPost, "/teams/backend-team/merge", MergeRequest{Source: "payments-edge", Conflict: storage.MergeRename}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp MergeResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, MergeResponse{
+		Team:      "backend-team",
+		Source:    "payments-edge",
+		Schedules: 1,
+		Renamed:   map[string]string{"Weekday": "Weekday (payments-edge)"},
+	}, resp)
+
+	// The target answers for both teams
+	assert.Equal(t, before, oncallAt(t, e, monday))
+	assert.Equal(t, "Carol", oncallAt(t, e, saturday))
+
+	// Requests for the merged team point at the target
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=payments-edge&time="+saturday.Format(time.RFC3339), nil, "")
+	require.Equal(t, http.StatusGone, rec.Code, rec.Body.String())
+
+	var gone TeamMergedResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &gone))
+	assert.Equal(t, CodeTeamMerged, gone.Code)
+	assert.Equal(t, "backend-team", gone.MergedInto)
+	assert.Equal(t, "team payments-edge was merged into backend-team", gone.Error)
+
+	query := url.Values{
+		"from": {saturday.Format(time.RFC3339)},
+		"to":   {saturday.Add(24 * time.Hour).Format(time.RFC3339)},
+	}
+	rec = serveJSON(e, http.MethodGet, "/teams/payments-edge/timeline?"+query.Encode(), nil, "")
+	assert.Equal(t, http.StatusGone, rec.Code, rec.Body.String())
+
+	// Teams that never existed are still not found
+	rec = serveJSON(e, http.MethodGet, "/teams/nonexistent/timeline?"+query.Encode(), nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+}
+
+func TestMergeTeams_Errors(t *testing.T) {
+	e := newMergeServer(t)
+
+	tests := []struct {
+		name string
+		team string
+		req  MergeRequest
+		code int
+	}{
+		{name: "missing source", team: "backend-team", req: MergeRequest{}, code: http.StatusBadRequest},
+		{name: "itself", team: "backend-team", req: MergeRequest{Source: "backend-team"}, code: http.StatusBadRequest},
+		{name: "invalid conflict", team: "backend-team", req: MergeRequest{Source: "payments-edge", Conflict: "skip"}, code: http.StatusBadRequest},
+		{name: "unknown source", team: "backend-team", req: MergeRequest{Source: "nonexistent"}, code: http.StatusNotFound},
+		{name: "unknown target", team: "nonexistent", req: MergeRequest{Source: "payments-edge"}, code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPost, "/teams/"+tt.team+"/merge", tt.req, "secret")
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
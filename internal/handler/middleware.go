@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// timeoutMessage is returned when a request runs past its deadline.
+const timeoutMessage = "request timed out"
+
+// probePaths are the routes of health probes, which bypass load shedding.
+var probePaths = map[string]bool{
+	"/health":  true,
+	"/healthz": true,
+	"/readyz":  true,
+}
+
+var (
+	inFlightRequests = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "oncall_http_in_flight_requests",
+		Help: "Number of requests currently being served.",
+	})
+	rejectedRequests = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "oncall_http_rejected_requests_total",
+		Help: "Number of requests rejected because too many were in flight.",
+	})
+)
+
+// Timeout bounds request handling by attaching a deadline to the request
+// context, which storage calls honor so a stuck query is canceled with the
+// request. A request that runs past the deadline gets a 504 in the usual error
+// format. Streaming requests (server-sent events and WebSocket upgrades) are
+// exempt, and a non-positive timeout disables the middleware.
+func Timeout(timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if timeout <= 0 || isStreaming(c.Request()) {
+				return next(c)
+			}
+
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			err := next(c)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Response().Committed {
+				return c.JSON(http.StatusGatewayTimeout, ErrorResponse{Error: timeoutMessage})
+			}
+
+			return err
+		}
+	}
+}
+
+// Limit caps the number of requests served at the same time to maxInFlight.
+// Up to queue more requests wait for at most wait to get a slot, the rest are
+// rejected with a 503 and a Retry-After header. Health probes are never
+// limited, and a non-positive maxInFlight disables the middleware.
+func Limit(maxInFlight, queue int, wait time.Duration) echo.MiddlewareFunc {
+	slots := make(chan struct{}, max(maxInFlight, 0))
+	waiting := make(chan struct{}, max(queue, 0))
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if maxInFlight <= 0 || probePaths[c.Path()] {
+				return next(c)
+			}
+
+			if !acquire(c.Request().Context(), slots, waiting, wait) {
+				rejectedRequests.Inc()
+				c.Response().Header().Set(echo.HeaderRetryAfter, "1")
+				return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: "server is overloaded, retry later"})
+			}
+
+			inFlightRequests.Inc()
+			defer func() {
+				inFlightRequests.Dec()
+				<-slots
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// acquire takes a slot, queueing for up to wait when all slots are taken and
+// the queue has room. It reports whether a slot was taken.
+func acquire(ctx context.Context, slots, waiting chan struct{}, wait time.Duration) bool {
+	select {
+	case slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	select {
+	case waiting <- struct{}{}:
+	default:
+		return false
+	}
+	defer func() { <-waiting }()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// AdminActor is the audit log actor of requests authenticated with the admin token.
+const AdminActor = "admin"
+
+// Admin guards the admin routes with a static bearer token. An empty token
+// disables the admin API, every request is then rejected. Changes made by
+// admin requests are attributed to AdminActor.
+func Admin(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !bearerMatches(c, token) {
+				return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "admin authentication required"})
+			}
+
+			c.SetRequest(c.Request().WithContext(storage.WithActor(c.Request().Context(), AdminActor)))
+
+			return next(c)
+		}
+	}
+}
+
+// bearerMatches reports whether the request carries the token as its bearer
+// token. An empty token matches nothing.
+func bearerMatches(c echo.Context, token string) bool {
+	given, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+
+	return token != "" && ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// isStreaming reports whether the request opens a long-lived stream.
+func isStreaming(r *http.Request) bool {
+	return strings.Contains(r.Header.Get(echo.HeaderAccept), "text/event-stream") ||
+		strings.EqualFold(r.Header.Get(echo.HeaderUpgrade), "websocket")
+}
+
+// storageFailure responds to a failed storage call. Calls that failed because
+// the request deadline passed get a 504, calls rejected by an open circuit
+// breaker a 503, writes rejected by a quota a 422, everything else a 500
+// with message. The error, wrapped with the failed operation, is logged
+// along with the request ID but never returned.
+func (h *Handler) storageFailure(c echo.Context, err error, message string) error {
+	var quotaErr *storage.QuotaError
+	if errors.As(err, &quotaErr) {
+		return quotaExceeded(c, quotaErr)
+	}
+
+	h.log(c).Error(message, zap.Error(err))
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return c.JSON(http.StatusGatewayTimeout, ErrorResponse{Error: timeoutMessage})
+	}
+
+	if errors.Is(err, storage.ErrCircuitOpen) {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: storageUnavailableMessage, Code: CodeStorageUnavailable})
+	}
+
+	return c.JSON(http.StatusInternalServerError, ErrorResponse{Error: message})
+}
@@ -0,0 +1,489 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// blockingStorage blocks every call until its context is canceled.
+type blockingStorage struct {
+	canceled chan struct{}
+}
+
+func (s *blockingStorage) wait(ctx context.Context) error {
+	<-ctx.Done()
+	close(s.canceled)
+
+	return ctx.Err()
+}
+
+func (s *blockingStorage) AddSchedule(ctx context.Context, _ string, _ storage.Schedule) (string, error) {
+	return "", s.wait(ctx)
+}
+
+func (s *blockingStorage) GetTeam(ctx context.Context, _ string) (storage.Team, error) {
+	return storage.Team{}, s.wait(ctx)
+}
+
+func (s *blockingStorage) GetCurrentOncall(ctx context.Context, _ string, _ time.Time) (string, error) {
+	return "", s.wait(ctx)
+}
+
+func (s *blockingStorage) ListTeams(ctx context.Context) ([]string, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) DeleteTeam(ctx context.Context, _ string) error {
+	return s.wait(ctx)
+}
+
+func (s *blockingStorage) PauseTeam(ctx context.Context, _ string, _ storage.Pause) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) UnpauseTeam(ctx context.Context, _ string) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) GetPause(ctx context.Context, _ string) (storage.Pause, bool, error) {
+	return storage.Pause{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) AuditLog(ctx context.Context, _ string) ([]storage.AuditEntry, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) DeleteExpiredSchedules(ctx context.Context, _ time.Time) (int, error) {
+	return 0, s.wait(ctx)
+}
+
+func (s *blockingStorage) PurgeExpiredPauses(ctx context.Context, _ time.Time) (int, error) {
+	return 0, s.wait(ctx)
+}
+
+func (s *blockingStorage) TrimAuditLog(ctx context.Context, _ time.Time) (int, error) {
+	return 0, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddWebhook(ctx context.Context, _ storage.Webhook) (storage.Webhook, error) {
+	return storage.Webhook{}, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListWebhooks(ctx context.Context) ([]storage.Webhook, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) DeleteWebhook(ctx context.Context, _ int64) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) MarkReminderSent(ctx context.Context, _ string) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddCalendarToken(ctx context.Context, _ storage.CalendarToken) (storage.CalendarToken, error) {
+	return storage.CalendarToken{}, s.wait(ctx)
+}
+
+func (s *blockingStorage) GetCalendarToken(ctx context.Context, _ string) (storage.CalendarToken, bool, error) {
+	return storage.CalendarToken{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) DeleteCalendarToken(ctx context.Context, _ string, _ int64) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddAPIKey(ctx context.Context, _ storage.APIKey) (storage.APIKey, error) {
+	return storage.APIKey{}, s.wait(ctx)
+}
+
+func (s *blockingStorage) GetAPIKey(ctx context.Context, _ string) (storage.APIKey, bool, error) {
+	return storage.APIKey{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListAPIKeys(ctx context.Context) ([]storage.APIKey, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) RevokeAPIKey(ctx context.Context, _ int64, _ time.Time) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddScopedToken(ctx context.Context, _ storage.ScopedToken) (storage.ScopedToken, error) {
+	return storage.ScopedToken{}, s.wait(ctx)
+}
+
+func (s *blockingStorage) GetScopedToken(ctx context.Context, _ string) (storage.ScopedToken, bool, error) {
+	return storage.ScopedToken{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListScopedTokens(ctx context.Context) ([]storage.ScopedToken, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) RevokeScopedToken(ctx context.Context, _ int64, _ time.Time) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) RecordScopedTokenUse(ctx context.Context, _ int64, _ time.Time) error {
+	return s.wait(ctx)
+}
+
+func (s *blockingStorage) FindSchedulesByTags(ctx context.Context, _ []string) ([]storage.TeamSchedule, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) FindTeamsByMember(ctx context.Context, _ string) ([]string, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddUser(ctx context.Context, _ storage.User) (storage.User, bool, error) {
+	return storage.User{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) GetUser(ctx context.Context, _ string) (storage.User, bool, error) {
+	return storage.User{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) FindUsers(ctx context.Context, _ string) ([]storage.User, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) SetUserActive(ctx context.Context, _ string, _ bool) (storage.User, bool, error) {
+	return storage.User{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) SetUserTimezone(ctx context.Context, _, _ string) (storage.User, bool, error) {
+	return storage.User{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) SetTeamMember(ctx context.Context, _ string, _ storage.TeamMember) (storage.TeamMember, bool, error) {
+	return storage.TeamMember{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListTeamMembers(ctx context.Context, _ string) ([]storage.TeamMember, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) RemoveTeamMember(ctx context.Context, _, _ string) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) SetGroup(ctx context.Context, _ string, _ storage.Group) (storage.Group, bool, error) {
+	return storage.Group{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListGroups(ctx context.Context, _ string) ([]storage.Group, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) DeleteGroup(ctx context.Context, _, _ string) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddFreeze(ctx context.Context, _ string, _ storage.Freeze) (storage.Freeze, bool, error) {
+	return storage.Freeze{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListFreezes(ctx context.Context, _ string) ([]storage.Freeze, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) CancelFreeze(ctx context.Context, _ string, _ int64) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddBlackout(ctx context.Context, _ string, _ storage.Blackout) (storage.Blackout, bool, error) {
+	return storage.Blackout{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListBlackouts(ctx context.Context, _ string) ([]storage.Blackout, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) CancelBlackout(ctx context.Context, _ string, _ int64) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddHandoffNote(ctx context.Context, _ string, _ storage.HandoffNote) (storage.HandoffNote, bool, error) {
+	return storage.HandoffNote{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListHandoffNotes(ctx context.Context, _ string) ([]storage.HandoffNote, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) UpdateHandoffNote(ctx context.Context, _ string, _ int64, _ string) (storage.HandoffNote, bool, error) {
+	return storage.HandoffNote{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) DeleteHandoffNote(ctx context.Context, _ string, _ int64) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) RenameMember(ctx context.Context, _, _ string) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) MergeTeams(ctx context.Context, _, _, _ string) (storage.TeamMerge, bool, error) {
+	return storage.TeamMerge{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) MergedInto(ctx context.Context, _ string) (string, bool, error) {
+	return "", false, s.wait(ctx)
+}
+
+func (s *blockingStorage) SetTeamParent(ctx context.Context, _, _ string) error {
+	return s.wait(ctx)
+}
+
+func (s *blockingStorage) TeamParent(ctx context.Context, _ string) (string, bool, error) {
+	return "", false, s.wait(ctx)
+}
+
+func (s *blockingStorage) SetPublicCoverage(ctx context.Context, _ string, _ bool) error {
+	return s.wait(ctx)
+}
+
+func (s *blockingStorage) PublicCoverage(ctx context.Context, _ string) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) SetGoogleCalendar(ctx context.Context, _, _ string) error {
+	return s.wait(ctx)
+}
+
+func (s *blockingStorage) GoogleCalendar(ctx context.Context, _ string) (string, bool, error) {
+	return "", false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListGoogleCalendars(ctx context.Context) (map[string]string, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) RecordAudit(ctx context.Context, _, _, _ string) error {
+	return s.wait(ctx)
+}
+
+func (s *blockingStorage) SetRotation(ctx context.Context, _, _ string, _ storage.Rotation) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) UpdateScheduleFields(ctx context.Context, _, _ string, _ storage.ScheduleUpdate) (storage.Schedule, error) {
+	return storage.Schedule{}, s.wait(ctx)
+}
+
+func (s *blockingStorage) TeamHistory(ctx context.Context, _ string, _, _ time.Time) ([]storage.TeamVersion, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) FindSchedulesByMembers(ctx context.Context, _ []string) ([]storage.MemberSchedule, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddSwapRequest(ctx context.Context, _ string, _ storage.SwapRequest) (storage.SwapRequest, bool, error) {
+	return storage.SwapRequest{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) GetSwapRequest(ctx context.Context, _ int64) (storage.SwapRequest, bool, error) {
+	return storage.SwapRequest{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListSwapRequests(ctx context.Context, _, _ string) ([]storage.SwapRequest, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) DecideSwapRequest(ctx context.Context, _ int64, _ bool, _ time.Time) (storage.SwapRequest, bool, error) {
+	return storage.SwapRequest{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddUnavailability(ctx context.Context, _ storage.Unavailability) (storage.Unavailability, error) {
+	return storage.Unavailability{}, s.wait(ctx)
+}
+
+func (s *blockingStorage) ListUnavailability(ctx context.Context, _ string) ([]storage.Unavailability, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) CancelUnavailability(ctx context.Context, _ string, _ int64) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func (s *blockingStorage) UnavailableMembers(ctx context.Context, _ []string, _ time.Time) ([]string, error) {
+	return nil, s.wait(ctx)
+}
+
+func (s *blockingStorage) GetSchedule(ctx context.Context, _ string) (storage.TeamSchedule, error) {
+	return storage.TeamSchedule{}, s.wait(ctx)
+}
+
+func (s *blockingStorage) HistoryStart(ctx context.Context, _ string) (time.Time, bool, error) {
+	return time.Time{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) ScheduleVersions(ctx context.Context, _ string) ([]storage.ScheduleVersion, bool, error) {
+	return nil, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) AddPin(ctx context.Context, _, _ string, _ storage.Pin) (storage.Pin, bool, error) {
+	return storage.Pin{}, false, s.wait(ctx)
+}
+
+func (s *blockingStorage) DeletePin(ctx context.Context, _, _ string, _ int64) (bool, error) {
+	return false, s.wait(ctx)
+}
+
+func TestTimeout_CancelsStorage(t *testing.T) {
+	e := echo.New()
+	store := &blockingStorage{canceled: make(chan struct{})}
+	logger, _ := zap.NewDevelopment()
+	h := New(store, logger)
+
+	e.Use(Timeout(50 * time.Millisecond))
+	e.GET("/schedule", h.GetSchedule)
+
+	q := make(url.Values)
+	q.Set("team", "backend-team")
+	q.Set("time", "2025-04-28T10:00:00Z")
+
+	req := httptest.NewRequest(http.MethodGet, "/schedule?"+q.Encode(), nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "request timed out", resp.Error)
+
+	select {
+	case <-store.canceled:
+	default:
+		t.Fatal("storage call was not canceled")
+	}
+}
+
+func TestTimeout_SkipsStreaming(t *testing.T) {
+	e := echo.New()
+	e.Use(Timeout(time.Millisecond))
+
+	var deadline bool
+	e.GET("/events", func(c echo.Context) error {
+		_, deadline = c.Request().Context().Deadline()
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/events", nil)
+	req.Header.Set(echo.HeaderAccept, "text/event-stream")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, deadline)
+}
+
+func TestTimeout_Disabled(t *testing.T) {
+	e := echo.New()
+	e.Use(Timeout(0))
+
+	var deadline bool
+	e.GET("/health", func(c echo.Context) error {
+		_, deadline = c.Request().Context().Deadline()
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, deadline)
+}
+
+func TestLimit_RejectsOverCapacity(t *testing.T) {
+	e := echo.New()
+	e.Use(Limit(1, 1, 20*time.Millisecond))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e.GET("/schedule", func(c echo.Context) error {
+		started <- struct{}{}
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/health", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	// Hold the only slot
+	held := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schedule", nil))
+		held <- rec
+	}()
+	<-started
+
+	// Queued for the wait timeout, then rejected
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schedule", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(echo.HeaderRetryAfter))
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.Error)
+
+	// Probes bypass the limiter
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusOK, (<-held).Code)
+
+	// The slot is free again
+	go func() { <-started }()
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schedule", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestLimit_QueuedRequestGetsSlot(t *testing.T) {
+	e := echo.New()
+	e.Use(Limit(1, 1, time.Second))
+
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	e.GET("/schedule", func(c echo.Context) error {
+		started <- struct{}{}
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+
+	codes := make(chan int, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/schedule", nil))
+			codes <- rec.Code
+		}()
+	}
+
+	<-started
+	close(release)
+
+	assert.Equal(t, http.StatusOK, <-codes)
+	assert.Equal(t, http.StatusOK, <-codes)
+}
@@ -0,0 +1,373 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// maxOpsgenieSize bounds the size of an imported Opsgenie document.
+const maxOpsgenieSize = 1 << 20
+
+// Opsgenie time restriction types, rotations without one are on call all
+// day, every day.
+const (
+	opsgenieTimeOfDay        = "time-of-day"
+	opsgenieWeekdayTimeOfDay = "weekday-and-time-of-day"
+)
+
+// opsgenieUnits are the lengths of the units Opsgenie rotations hand over in,
+// by rotation type.
+var opsgenieUnits = map[string]time.Duration{
+	"hourly": time.Hour,
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// OpsgenieImportResponse reports the outcome of an Opsgenie import, by
+// rotation.
+type OpsgenieImportResponse struct {
+	Imported []OpsgenieImported `json:"imported"`
+	Failed   []OpsgenieFailure  `json:"failed"`
+}
+
+// OpsgenieImported is a schedule created from an Opsgenie rotation.
+type OpsgenieImported struct {
+	Schedule   string `json:"schedule"`
+	Rotation   string `json:"rotation"`
+	RotationID string `json:"rotation_id,omitempty"`
+	Name       string `json:"name"`
+}
+
+// OpsgenieFailure is an Opsgenie rotation that could not be imported.
+type OpsgenieFailure struct {
+	Schedule   string `json:"schedule"`
+	Rotation   string `json:"rotation"`
+	RotationID string `json:"rotation_id,omitempty"`
+	Error      string `json:"error"`
+}
+
+// opsgenieSchedule is a schedule of the Opsgenie schedule API, with its
+// rotations expanded.
+type opsgenieSchedule struct {
+	ID        string             `json:"id"`
+	Name      string             `json:"name"`
+	Timezone  string             `json:"timezone"`
+	Rotations []opsgenieRotation `json:"rotations"`
+}
+
+type opsgenieRotation struct {
+	ID              string                   `json:"id"`
+	Name            string                   `json:"name"`
+	StartDate       string                   `json:"startDate"`
+	EndDate         string                   `json:"endDate"`
+	Type            string                   `json:"type"`
+	Length          int                      `json:"length"`
+	Participants    []opsgenieParticipant    `json:"participants"`
+	TimeRestriction *opsgenieTimeRestriction `json:"timeRestriction"`
+}
+
+type opsgenieParticipant struct {
+	Type     string `json:"type"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+type opsgenieTimeRestriction struct {
+	Type         string                `json:"type"`
+	Restriction  *opsgenieRestriction  `json:"restriction"`
+	Restrictions []opsgenieRestriction `json:"restrictions"`
+}
+
+type opsgenieRestriction struct {
+	StartDay  string `json:"startDay"`
+	EndDay    string `json:"endDay"`
+	StartHour int    `json:"startHour"`
+	StartMin  int    `json:"startMin"`
+	EndHour   int    `json:"endHour"`
+	EndMin    int    `json:"endMin"`
+}
+
+// ImportOpsgenie handles Opsgenie imports. The body is what the Opsgenie
+// schedule API returns with the rotations expanded, a schedule or a list of
+// them under data, and every rotation becomes a schedule of the team given
+// by the team query parameter. Rotations that cannot be translated are
+// reported without stopping the others.
+//
+// Participants are users, by username. The repeatable member query parameter
+// maps usernames to members as "username:Member", like the calendar import.
+// Schedules here hand over every week, so only rotations handing over every
+// seven days translate, and their restrictions must have the same hours on
+// every day they cover, converted to UTC from the timezone of the schedule.
+func (h *Handler) ImportOpsgenie(c echo.Context) error {
+	team := c.QueryParam("team")
+	if team == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "team is required"})
+	}
+
+	mapping, err := memberMapping(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	schedules, err := parseOpsgenie(io.LimitReader(c.Request().Body, maxOpsgenieSize+1))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	if frozen, err := h.rejectFrozen(c, team, "import Opsgenie schedules"); frozen {
+		return err
+	}
+
+	resp := OpsgenieImportResponse{Imported: []OpsgenieImported{}, Failed: []OpsgenieFailure{}}
+	ctx := h.withQuota(c.Request().Context(), storage.QuotaSchedules, team)
+
+	for _, sched := range schedules {
+		for i, rotation := range sched.Rotations {
+			name := rotation.Name
+			if name == "" {
+				name = fmt.Sprintf("Rotation %d", i+1)
+			}
+			fail := func(err error) {
+				resp.Failed = append(resp.Failed, OpsgenieFailure{
+					Schedule: sched.Name, Rotation: name, RotationID: rotation.ID, Error: err.Error(),
+				})
+			}
+
+			req, err := rotationRequest(sched, rotation, name, team, mapping)
+			if err != nil {
+				fail(err)
+				continue
+			}
+
+			rejected, err := h.importSchedule(ctx, team, req)
+			if err != nil {
+				return h.storageFailure(c, fmt.Errorf("add imported rotation %q for team %q: %w", name, team, err), "failed to create schedule")
+			}
+			if rejected != nil {
+				fail(rejected)
+				continue
+			}
+
+			resp.Imported = append(resp.Imported, OpsgenieImported{
+				Schedule: sched.Name, Rotation: name, RotationID: rotation.ID, Name: req.Name,
+			})
+		}
+	}
+
+	h.logger.Info("opsgenie schedules imported",
+		zap.String("team", team),
+		zap.Int("imported", len(resp.Imported)),
+		zap.Int("failed", len(resp.Failed)),
+	)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// parseOpsgenie returns the schedules of an Opsgenie document, whose data is
+// either a schedule or a list of them.
+func parseOpsgenie(r io.Reader) ([]opsgenieSchedule, error) {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	if len(body) > maxOpsgenieSize {
+		return nil, fmt.Errorf("document is larger than %d bytes", maxOpsgenieSize)
+	}
+
+	var doc struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil || len(doc.Data) == 0 {
+		return nil, errors.New("body is not an Opsgenie schedule document")
+	}
+
+	if data := bytes.TrimSpace(doc.Data); len(data) > 0 && data[0] == '[' {
+		var schedules []opsgenieSchedule
+		if err := json.Unmarshal(data, &schedules); err != nil {
+			return nil, errors.New("body is not an Opsgenie schedule document")
+		}
+		return schedules, nil
+	}
+
+	var sched opsgenieSchedule
+	if err := json.Unmarshal(doc.Data, &sched); err != nil {
+		return nil, errors.New("body is not an Opsgenie schedule document")
+	}
+
+	return []opsgenieSchedule{sched}, nil
+}
+
+// rotationRequest translates an Opsgenie rotation into a schedule creation
+// request named after the schedule and the rotation. The rotation starts at
+// its start date, which is the weekly handoff unless it is at midnight UTC.
+func rotationRequest(sched opsgenieSchedule, rotation opsgenieRotation, name, team string, mapping map[string]string) (Request, error) {
+	req := Request{Team: team, Name: sched.Name + " - " + name}
+
+	unit, ok := opsgenieUnits[rotation.Type]
+	if !ok {
+		return Request{}, fmt.Errorf("unknown rotation type %q", rotation.Type)
+	}
+	length := max(rotation.Length, 1)
+	if time.Duration(length)*unit != storage.RotationPeriod {
+		return Request{}, fmt.Errorf("%s rotations of length %d are not supported, schedules hand over every 7 days", rotation.Type, length)
+	}
+
+	loc := time.UTC
+	if sched.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(sched.Timezone); err != nil {
+			return Request{}, fmt.Errorf("unknown timezone %q", sched.Timezone)
+		}
+	}
+
+	start, err := time.Parse(time.RFC3339, rotation.StartDate)
+	if err != nil {
+		return Request{}, errors.New("invalid startDate, use RFC3339 format")
+	}
+	start = start.UTC()
+
+	if rotation.EndDate != "" {
+		end, err := time.Parse(time.RFC3339, rotation.EndDate)
+		if err != nil {
+			return Request{}, errors.New("invalid endDate, use RFC3339 format")
+		}
+		req.ValidUntil = end.UTC().Format(time.RFC3339)
+	}
+
+	if err := setRestriction(&req, rotation.TimeRestriction, start.In(loc)); err != nil {
+		return Request{}, err
+	}
+
+	req.Anchor = start.Format(time.DateOnly)
+	if start.Hour() != 0 || start.Minute() != 0 {
+		req.Handoff = &Handoff{Day: start.Weekday().String(), Time: start.Format(time.Kitchen)}
+	}
+
+	for _, participant := range rotation.Participants {
+		switch participant.Type {
+		case "user":
+		case "none":
+			return Request{}, errors.New("empty participant slots cannot be imported")
+		default:
+			return Request{}, fmt.Errorf("%s participants cannot be imported, list their users instead", participant.Type)
+		}
+
+		member := participant.Username
+		if member == "" {
+			member = participant.Name
+		}
+		if mapped, ok := mapping[strings.ToLower(member)]; ok {
+			member = mapped
+		}
+		if member == "" {
+			return Request{}, errors.New("user participants need a username")
+		}
+		req.Members = append(req.Members, member)
+	}
+	if len(req.Members) == 0 {
+		return Request{}, errors.New("rotation has no participants")
+	}
+
+	return req, nil
+}
+
+// setRestriction sets the days and hours of the request from the time
+// restriction of a rotation, in the timezone of on. Hours are converted to UTC
+// on the date of on, and must neither cross midnight there nor differ
+// between the days of the restriction.
+func setRestriction(req *Request, restriction *opsgenieTimeRestriction, on time.Time) error {
+	allDays := []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+	if restriction == nil {
+		req.Days = allDays
+		req.Start, req.End = "12:00AM", "11:59PM"
+		return nil
+	}
+
+	var (
+		restrictions []opsgenieRestriction
+		days         []time.Weekday
+	)
+
+	switch restriction.Type {
+	case opsgenieTimeOfDay:
+		if restriction.Restriction == nil {
+			return errors.New("time-of-day restriction without hours")
+		}
+		restrictions = []opsgenieRestriction{*restriction.Restriction}
+	case opsgenieWeekdayTimeOfDay:
+		restrictions = restriction.Restrictions
+		if len(restrictions) == 0 {
+			return errors.New("weekday-and-time-of-day restriction without restrictions")
+		}
+	default:
+		return fmt.Errorf("unknown time restriction type %q", restriction.Type)
+	}
+
+	first := restrictions[0]
+	for _, r := range restrictions {
+		if r.StartHour != first.StartHour || r.StartMin != first.StartMin || r.EndHour != first.EndHour || r.EndMin != first.EndMin {
+			return errors.New("restrictions with different hours on different days are not supported")
+		}
+
+		if restriction.Type == opsgenieTimeOfDay {
+			continue
+		}
+		if !strings.EqualFold(r.StartDay, r.EndDay) {
+			return fmt.Errorf("restrictions from %s to %s are not supported, they must start and end on the same day", r.StartDay, r.EndDay)
+		}
+		day, err := parseWeekday(r.StartDay)
+		if err != nil {
+			return fmt.Errorf("invalid restriction day %q", r.StartDay)
+		}
+		if !slices.Contains(days, day) {
+			days = append(days, day)
+		}
+	}
+
+	local := func(hour, minute int) time.Time {
+		return time.Date(on.Year(), on.Month(), on.Day(), hour, minute, 0, 0, on.Location())
+	}
+	start, end := local(first.StartHour, first.StartMin), local(first.EndHour, first.EndMin)
+	// Restrictions ending at midnight end a minute before it, like whole days here
+	if first.EndHour == 24 || (first.EndHour == 0 && first.EndMin == 0) {
+		end = local(23, 59)
+	}
+	if !start.Before(end) {
+		return errors.New("restrictions crossing midnight are not supported")
+	}
+
+	utcStart, utcEnd := start.UTC(), end.UTC()
+	if dayShift(utcStart, utcEnd) != 0 {
+		return errors.New("restrictions must not cross midnight in UTC")
+	}
+	req.Start, req.End = utcStart.Format(time.Kitchen), utcEnd.Format(time.Kitchen)
+
+	// The days of the restriction are in the timezone of the schedule
+	shift := dayShift(start, utcStart)
+	if restriction.Type == opsgenieTimeOfDay {
+		req.Days = allDays
+		return nil
+	}
+	for i, day := range days {
+		days[i] = time.Weekday((int(day) + shift + 7) % 7)
+	}
+	slices.Sort(days)
+	for _, day := range days {
+		req.Days = append(req.Days, day.String())
+	}
+
+	return nil
+}
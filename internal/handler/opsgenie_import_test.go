@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func importOpsgenie(t *testing.T, h *Handler, target, body string) (*httptest.ResponseRecorder, OpsgenieImportResponse) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	require.NoError(t, h.ImportOpsgenie(echo.New().NewContext(req, rec)))
+
+	var resp OpsgenieImportResponse
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+
+	return rec, resp
+}
+
+func TestImportOpsgenie_Export(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	body, err := os.ReadFile(filepath.Join("testdata", "opsgenie_schedule.json"))
+	require.NoError(t, err)
+
+	rec, resp := importOpsgenie(t, h, "/schedules/import/opsgenie?team=payments&member=carol@example.com:Carol", string(body))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	assert.Equal(t, []OpsgenieImported{
+		{Schedule: "Payments On-Call", Rotation: "Business Hours", RotationID: "a47alp93-0541-4aa3-bac6-4084cbd27e8b", Name: "Payments On-Call - Business Hours"},
+		{Schedule: "Payments On-Call", Rotation: "Secondary", RotationID: "7d4alp6b-2a9c-4e3f-9b1d-0c8e5f2a7b63", Name: "Payments On-Call - Secondary"},
+	}, resp.Imported)
+
+	failures := make(map[string]string)
+	for _, failure := range resp.Failed {
+		assert.Equal(t, "Payments On-Call", failure.Schedule)
+		assert.NotEmpty(t, failure.RotationID)
+		failures[failure.Rotation] = failure.Error
+	}
+	assert.Equal(t, map[string]string{
+		"Follow The Sun":     "daily rotations of length 1 are not supported, schedules hand over every 7 days",
+		"Weekend Escalation": "escalation participants cannot be imported, list their users instead",
+		"Nights":             "restrictions crossing midnight are not supported",
+	}, failures)
+
+	team, err := store.GetTeam(context.Background(), "payments")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 2)
+
+	// 09:00 to 17:00 in Berlin is 08:00 to 16:00 UTC in March, handing over
+	// on Mondays at the start of the rotation
+	business := team.Schedules[0]
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, business.Members)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, business.Days)
+	assert.Equal(t, "8:00AM", business.Start.Format(time.Kitchen))
+	assert.Equal(t, "4:00PM", business.End.Format(time.Kitchen))
+	require.NotNil(t, business.Handoff)
+	assert.Equal(t, time.Monday, business.Handoff.Day)
+	assert.Equal(t, "8:00AM", business.Handoff.Time.Format(time.Kitchen))
+
+	monday := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	member, ok := business.MemberOnDuty(monday)
+	require.True(t, ok)
+	assert.Equal(t, "alice@example.com", member)
+	member, ok = business.MemberOnDuty(monday.AddDate(0, 0, 7))
+	require.True(t, ok)
+	assert.Equal(t, "bob@example.com", member)
+
+	// Seven days of a daily rotation are a week, on call all day
+	secondary := team.Schedules[1]
+	assert.Equal(t, []string{"Carol", "dave@example.com"}, secondary.Members)
+	assert.Len(t, secondary.Days, 7)
+	assert.Equal(t, "12:00AM", secondary.Start.Format(time.Kitchen))
+	assert.Equal(t, "11:59PM", secondary.End.Format(time.Kitchen))
+	assert.Nil(t, secondary.Handoff)
+	assert.Equal(t, time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC), secondary.ValidUntil)
+}
+
+func TestImportOpsgenie_ScheduleList(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	// Restrictions are converted with the offset of the schedule, Tehran
+	// mornings start on the day before in UTC
+	body := `{"data": [{
+		"name": "Tehran",
+		"timezone": "Asia/Tehran",
+		"rotations": [{
+			"name": "Mornings",
+			"startDate": "2026-03-01T00:00:00Z",
+			"type": "hourly",
+			"length": 168,
+			"participants": [{"type": "user", "username": "sara@example.com"}],
+			"timeRestriction": {
+				"type": "weekday-and-time-of-day",
+				"restrictions": [
+					{"startDay": "saturday", "startHour": 1, "startMin": 0, "endDay": "saturday", "endHour": 3, "endMin": 0},
+					{"startDay": "sunday", "startHour": 1, "startMin": 0, "endDay": "sunday", "endHour": 3, "endMin": 0}
+				]
+			}
+		}, {
+			"name": "Split",
+			"startDate": "2026-03-01T00:00:00Z",
+			"type": "weekly",
+			"participants": [{"type": "user", "username": "sara@example.com"}],
+			"timeRestriction": {
+				"type": "weekday-and-time-of-day",
+				"restrictions": [
+					{"startDay": "monday", "startHour": 9, "startMin": 0, "endDay": "monday", "endHour": 12, "endMin": 0},
+					{"startDay": "tuesday", "startHour": 13, "startMin": 0, "endDay": "tuesday", "endHour": 17, "endMin": 0}
+				]
+			}
+		}]
+	}]}`
+
+	rec, resp := importOpsgenie(t, h, "/schedules/import/opsgenie?team=tehran", body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Len(t, resp.Imported, 1)
+	require.Len(t, resp.Failed, 1)
+	assert.Equal(t, "restrictions with different hours on different days are not supported", resp.Failed[0].Error)
+
+	team, err := store.GetTeam(context.Background(), "tehran")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 1)
+	assert.Equal(t, []time.Weekday{time.Friday, time.Saturday}, team.Schedules[0].Days)
+	assert.Equal(t, "9:30PM", team.Schedules[0].Start.Format(time.Kitchen))
+	assert.Equal(t, "11:30PM", team.Schedules[0].End.Format(time.Kitchen))
+}
+
+func TestImportOpsgenie_InvalidRequests(t *testing.T) {
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"missing team", "/schedules/import/opsgenie", `{"data": {"name": "Payments"}}`},
+		{"not a document", "/schedules/import/opsgenie?team=payments", "BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"},
+		{"no data", "/schedules/import/opsgenie?team=payments", `{"took": 0.1}`},
+		{"invalid mapping", "/schedules/import/opsgenie?team=payments&member=alice", `{"data": {"name": "Payments"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, _ := importOpsgenie(t, h, tt.target, tt.body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// TeamOncall represents who is on call for a team now, Oncall is empty when
+// nobody is. Schedule is the name of the schedule answering, which belongs to
+// the ancestor for inherited answers.
+type TeamOncall struct {
+	Team          string `json:"team"`
+	Oncall        string `json:"oncall,omitempty"`
+	Schedule      string `json:"schedule,omitempty"`
+	InheritedFrom string `json:"inherited_from,omitempty"`
+	Paused        bool   `json:"paused,omitempty"`
+	Stale         bool   `json:"stale,omitempty"`
+}
+
+// AllOncallResponse represents who is on call for every team, ordered by team.
+type AllOncallResponse struct {
+	Time  string       `json:"time"`
+	Teams []TeamOncall `json:"teams"`
+}
+
+// AllOncall handles requests for who is on call now for every team. Each team
+// is answered like the on-call lookup: paused teams have nobody on call,
+// teams with nothing matching fall back on their ancestors, and members who
+// are out are substituted.
+func (h *Handler) AllOncall(c echo.Context) error {
+	ctx := c.Request().Context()
+	now := h.now()
+
+	teams, err := h.storage.ListTeams(ctx)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list teams: %w", err), "failed to list teams")
+	}
+
+	resp := AllOncallResponse{
+		Time:  now.UTC().Format(time.RFC3339),
+		Teams: make([]TeamOncall, 0, len(teams)),
+	}
+
+	for _, team := range teams {
+		oncall, err := h.teamOncall(ctx, team, now)
+		if err != nil {
+			return h.storageFailure(c, err, "failed to retrieve oncall information")
+		}
+		resp.Teams = append(resp.Teams, oncall)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Oncall handles requests for who is on call across every team, now or at
+// the instant of the optional time query parameter. Teams are answered like
+// in AllOncall, and those with nobody on call, such as paused teams and teams
+// whose schedules have no members, are left out.
+func (h *Handler) Oncall(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	at := h.now()
+	if value := c.QueryParam("time"); value != "" {
+		var err error
+		if at, err = h.parseTime(value, "time", time.UTC); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	teams, err := h.storage.ListTeams(ctx)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list teams: %w", err), "failed to list teams")
+	}
+
+	resp := make([]TeamOncall, 0, len(teams))
+	for _, team := range teams {
+		oncall, err := h.teamOncall(ctx, team, at)
+		if err != nil {
+			return h.storageFailure(c, err, "failed to retrieve oncall information")
+		}
+		if oncall.Oncall == "" {
+			continue
+		}
+		resp = append(resp, oncall)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// teamOncall returns who is on call for the team at the instant.
+func (h *Handler) teamOncall(ctx context.Context, team string, at time.Time) (TeamOncall, error) {
+	result := TeamOncall{Team: team}
+
+	_, paused, err := h.activePause(ctx, team, at)
+	if err != nil {
+		return result, fmt.Errorf("get pause of team %q: %w", team, err)
+	}
+	if paused {
+		result.Paused = true
+		return result, nil
+	}
+
+	oncall, err := h.storage.GetCurrentOncall(ctx, team, at)
+	result.Stale = errors.Is(err, storage.ErrStale)
+	if err != nil && !result.Stale && !storage.NobodyOnCall(err) {
+		return result, fmt.Errorf("get current oncall of team %q: %w", team, err)
+	}
+	// A stale answer means storage is failing, it is served as it is
+	if result.Stale {
+		result.Oncall = oncall
+		return result, nil
+	}
+
+	found := err == nil
+
+	var schedules []storage.Schedule
+	if found {
+		t, err := h.storage.GetTeam(ctx, team)
+		if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+			return result, fmt.Errorf("get team %q: %w", team, err)
+		}
+		schedules = t.Schedules
+	} else {
+		oncall, schedules, result.InheritedFrom, found, err = h.inheritedOncall(ctx, team, at)
+		if err != nil {
+			return result, fmt.Errorf("get inherited oncall of team %q: %w", team, err)
+		}
+		if !found {
+			return result, nil
+		}
+	}
+
+	member, err := storage.Substitute(ctx, h.storage, schedules, oncall, at)
+	if errors.Is(err, storage.ErrAllUnavailable) {
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("substitute oncall %q of team %q: %w", oncall, team, err)
+	}
+	result.Oncall = member
+	if sched, ok := storage.ScheduleAt(schedules, at); ok {
+		result.Schedule = sched.Name
+	}
+
+	return result, nil
+}
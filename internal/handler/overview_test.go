@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newOverviewServer serves who is on call across teams, at 10:00 UTC on
+// Monday 2026-03-02.
+func newOverviewServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	h.now = (&fakeClock{now: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)}).Now
+
+	e := echo.New()
+	e.GET("/oncall", h.Oncall)
+
+	return e, store
+}
+
+func TestOncall(t *testing.T) {
+	e, store := newOverviewServer(t)
+	ctx := context.Background()
+
+	_, err := store.AddSchedule(ctx, "payments", storage.Schedule{
+		Name:    "Days",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "billing", storage.Schedule{
+		Name:    "Nights",
+		Members: []string{"Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "8:00PM"),
+		End:     parseTime(t, "11:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "search", storage.Schedule{
+		Name:  "Empty",
+		Days:  []time.Weekday{time.Monday},
+		Start: parseTime(t, "9:00AM"),
+		End:   parseTime(t, "11:00PM"),
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		target string
+		want   []TeamOncall
+	}{
+		{"now", "/oncall", []TeamOncall{{Team: "payments", Oncall: "Alice", Schedule: "Days"}}},
+		{"historical", "/oncall?time=2026-03-02T21:00:00Z", []TeamOncall{{Team: "billing", Oncall: "Bob", Schedule: "Nights"}}},
+		{"nobody", "/oncall?time=2026-03-03T10:00:00Z", []TeamOncall{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodGet, tt.target, nil, "")
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var resp []TeamOncall
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.want, resp)
+		})
+	}
+
+	rec := serveJSON(e, http.MethodGet, "/oncall?time=noon", nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CodeTeamPaused is the error code of lookups for a paused team.
+const CodeTeamPaused = "TEAM_PAUSED"
+
+// PauseRequest represents the team pause request.
+type PauseRequest struct {
+	Until  string `json:"until,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PauseResponse represents the pause of a team.
+type PauseResponse struct {
+	Team   string `json:"team"`
+	Reason string `json:"reason,omitempty"`
+	Since  string `json:"since"`
+	Until  string `json:"until,omitempty"`
+}
+
+// PausedResponse is returned instead of an on-call member while a team is paused.
+type PausedResponse struct {
+	ErrorResponse
+	Reason string `json:"reason,omitempty"`
+	Until  string `json:"until,omitempty"`
+}
+
+// PauseTeam handles team pause requests. Without an until timestamp the team
+// stays paused until it is unpaused.
+func (h *Handler) PauseTeam(c echo.Context) error {
+	team := c.Param("team")
+
+	var req PauseRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	pause := storage.Pause{
+		Reason: req.Reason,
+		Since:  time.Now().UTC(),
+	}
+
+	if req.Until != "" {
+		until, err := time.Parse(time.RFC3339, req.Until)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid until format, use RFC3339 format"})
+		}
+		if !until.After(pause.Since) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "until must be in the future"})
+		}
+		pause.Until = until.UTC()
+	}
+
+	found, err := h.storage.PauseTeam(c.Request().Context(), team, pause)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("pause team %q: %w", team, err), "failed to pause team")
+	}
+
+	if !found {
+		return h.teamNotFound(c, team, "team not found")
+	}
+
+	h.logger.Info("team paused",
+		zap.String("team", team),
+		zap.String("reason", pause.Reason),
+		zap.Time("until", pause.Until),
+	)
+
+	return c.JSON(http.StatusOK, newPauseResponse(team, pause))
+}
+
+// UnpauseTeam handles team unpause requests. Unpausing a team that is not
+// paused is a no-op.
+func (h *Handler) UnpauseTeam(c echo.Context) error {
+	team := c.Param("team")
+
+	found, err := h.storage.UnpauseTeam(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("unpause team %q: %w", team, err), "failed to unpause team")
+	}
+
+	if !found {
+		return h.teamNotFound(c, team, "team not found")
+	}
+
+	h.logger.Info("team unpaused", zap.String("team", team))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// activePause returns the pause of a team covering the given instant. A
+// failed pause lookup is only fatal when the request itself is done, other
+// failures fall through to the on-call lookup so its stale fallback still applies.
+func (h *Handler) activePause(ctx context.Context, team string, at time.Time) (storage.Pause, bool, error) {
+	pause, found, err := h.storage.GetPause(ctx, team)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return storage.Pause{}, false, err
+		}
+
+		h.logger.Warn("failed to get team pause, assuming it is not paused", zap.String("team", team), zap.Error(err))
+		return storage.Pause{}, false, nil
+	}
+
+	return pause, found && pause.Active(at), nil
+}
+
+// pausedResponse builds the response of a lookup for a paused team.
+func pausedResponse(pause storage.Pause, loc *time.Location) PausedResponse {
+	resp := PausedResponse{
+		ErrorResponse: ErrorResponse{Error: "team is paused", Code: CodeTeamPaused},
+		Reason:        pause.Reason,
+	}
+	if !pause.Until.IsZero() {
+		resp.Until = pause.Until.In(loc).Format(time.RFC3339)
+	}
+
+	return resp
+}
+
+// newPauseResponse renders a pause of a team.
+func newPauseResponse(team string, pause storage.Pause) PauseResponse {
+	resp := PauseResponse{
+		Team:   team,
+		Reason: pause.Reason,
+		Since:  pause.Since.Format(time.RFC3339),
+	}
+	if !pause.Until.IsZero() {
+		resp.Until = pause.Until.Format(time.RFC3339)
+	}
+
+	return resp
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newPauseServer wires the pause routes in front of a team with one schedule.
+func newPauseServer(t *testing.T) (*echo.Echo, *storage.MemoryStorage) {
+	t.Helper()
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	logger, _ := zap.NewDevelopment()
+	h := New(store, logger)
+
+	e.GET("/schedule", h.GetSchedule)
+	e.POST("/teams/:team/pause", h.PauseTeam)
+	e.POST("/teams/:team/unpause", h.UnpauseTeam)
+
+	schedule := storage.Schedule{
+		Name:    "Every Day",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+		Start:   parseTime(t, "12:00AM"),
+		End:     parseTime(t, "11:59PM"),
+	}
+	_, err := store.AddSchedule(context.Background(), "backend-team", schedule)
+	require.NoError(t, err)
+
+	return e, store
+}
+
+// lookupTomorrow looks up the on-call member at noon tomorrow.
+func lookupTomorrow(e *echo.Echo) *httptest.ResponseRecorder {
+	at := time.Now().UTC().Truncate(24 * time.Hour).Add(36 * time.Hour)
+	return serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time="+at.Format(time.RFC3339), nil, "")
+}
+
+func TestPauseTeam_LookupReportsPause(t *testing.T) {
+	e, store := newPauseServer(t)
+
+	until := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Second)
+	rec := serveJSON(e, http.MethodPost, "/teams/backend-team/pause",
+		PauseRequest{Until: until.Format(time.RFC3339), Reason: "database migration"}, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var pause PauseResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &pause))
+	assert.Equal(t, "database migration", pause.Reason)
+	assert.Equal(t, until.Format(time.RFC3339), pause.Until)
+
+	rec = lookupTomorrow(e)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	var resp PausedResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, CodeTeamPaused, resp.Code)
+	assert.Equal(t, "database migration", resp.Reason)
+	assert.Equal(t, until.Format(time.RFC3339), resp.Until)
+
+	rec = serveJSON(e, http.MethodPost, "/teams/backend-team/unpause", nil, "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = lookupTomorrow(e)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	entries, err := store.AuditLog(context.Background(), "backend-team")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, storage.AuditPauseTeam, entries[0].Action)
+	assert.Equal(t, storage.AuditUnpauseTeam, entries[1].Action)
+}
+
+func TestPauseTeam_ExpiresLazily(t *testing.T) {
+	e, store := newPauseServer(t)
+
+	// A pause that ended an hour ago no longer hides the on-call member
+	pause := storage.Pause{
+		Reason: "database migration",
+		Since:  time.Now().Add(-48 * time.Hour),
+		Until:  time.Now().Add(-time.Hour),
+	}
+	_, err := store.PauseTeam(context.Background(), "backend-team", pause)
+	require.NoError(t, err)
+
+	rec := serveJSON(e, http.MethodGet,
+		"/schedule?team=backend-team&time="+time.Now().UTC().Format(time.RFC3339), nil, "")
+	assert.NotEqual(t, http.StatusConflict, rec.Code)
+
+	// Lookups during the pause still report it
+	during := pause.Until.Add(-time.Hour).UTC().Format(time.RFC3339)
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time="+during, nil, "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestPauseTeam_Validation(t *testing.T) {
+	e, _ := newPauseServer(t)
+
+	rec := serveJSON(e, http.MethodPost, "/teams/backend-team/pause", PauseRequest{Until: "tomorrow"}, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	rec = serveJSON(e, http.MethodPost, "/teams/backend-team/pause", PauseRequest{Until: past}, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/teams/unknown-team/pause", PauseRequest{}, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/teams/unknown-team/unpause", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// PinRequest represents a pin request, putting the member on duty for the
+// shifts of a schedule starting on the date in UTC.
+type PinRequest struct {
+	Date   string `json:"date"`
+	Member string `json:"member"`
+}
+
+// PinResponse represents a pin of a schedule.
+type PinResponse struct {
+	ID         int64  `json:"id"`
+	ScheduleID string `json:"schedule_id"`
+	Team       string `json:"team"`
+	Date       string `json:"date"`
+	// ShiftStart is set for pins limited to a single shift, see ForceNext.
+	ShiftStart string `json:"shift_start,omitempty"`
+	Member     string `json:"member"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// PinsResponse represents the pins of a schedule, ordered by date.
+type PinsResponse struct {
+	Pins []PinResponse `json:"pins"`
+}
+
+// CreatePin handles pin requests. The member has to be in the schedule unless
+// the external query parameter is true. A pin replaces any earlier pin of the
+// date, and only ever changes the member of that date.
+func (h *Handler) CreatePin(c echo.Context) error {
+	var req PinRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	date, err := time.Parse(time.DateOnly, req.Date)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid date format, use '2006-01-02' format"})
+	}
+	if date.Before(storage.PinDate(time.Now())) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "date must not be in the past"})
+	}
+
+	member := strings.TrimSpace(req.Member)
+	if member == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "member is required"})
+	}
+
+	external := false
+	if value := c.QueryParam("external"); value != "" {
+		if external, err = strconv.ParseBool(value); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid external query parameter"})
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	if !external && !slices.Contains(sched.Schedule.Members, member) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("%s is not a member of the schedule, pass external=true to pin them anyway", member),
+		})
+	}
+
+	if frozen, err := h.rejectFrozen(c, sched.Team, fmt.Sprintf("pin %s to %s on schedule %s", member, req.Date, sched.Schedule.Name)); frozen {
+		return err
+	}
+
+	observer, found, err := h.observerIn(ctx, sched.Team, []string{member})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list members of team %q: %w", sched.Team, err), "failed to create pin")
+	}
+	if found {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: observerMessage(observer, sched.Team)})
+	}
+
+	pin, found, err := h.storage.AddPin(h.withQuota(ctx, storage.QuotaPins, sched.Team), sched.Team, sched.Schedule.ID, storage.Pin{Date: date, Member: member})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add pin to schedule %q of team %q: %w", sched.Schedule.ID, sched.Team, err), "failed to create pin")
+	}
+	// The schedule may have been deleted in the meantime
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+
+	h.logger.Info("pin created",
+		zap.String("team", sched.Team),
+		zap.String("schedule", sched.Schedule.Name),
+		zap.String("date", req.Date),
+		zap.String("member", member),
+	)
+
+	return c.JSON(http.StatusCreated, newPinResponse(sched.Team, sched.Schedule.ID, pin))
+}
+
+// ListPins handles pin listing requests.
+func (h *Handler) ListPins(c echo.Context) error {
+	sched, err := h.storage.GetSchedule(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	resp := PinsResponse{Pins: make([]PinResponse, 0, len(sched.Schedule.Pins))}
+	for _, pin := range sched.Schedule.Pins {
+		resp.Pins = append(resp.Pins, newPinResponse(sched.Team, sched.Schedule.ID, pin))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeletePin handles pin removal requests.
+func (h *Handler) DeletePin(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("pin"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid pin id"})
+	}
+
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	if frozen, err := h.rejectFrozen(c, sched.Team, fmt.Sprintf("delete pin %d of schedule %s", id, sched.Schedule.Name)); frozen {
+		return err
+	}
+
+	deleted, err := h.storage.DeletePin(ctx, sched.Team, sched.Schedule.ID, id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("delete pin %d of schedule %q: %w", id, sched.Schedule.ID, err), "failed to delete pin")
+	}
+
+	if !deleted {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "pin not found"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// newPinResponse renders a pin of a schedule.
+func newPinResponse(team, scheduleID string, pin storage.Pin) PinResponse {
+	resp := PinResponse{
+		ID:         pin.ID,
+		ScheduleID: scheduleID,
+		Team:       team,
+		Date:       pin.Date.Format(time.DateOnly),
+		Member:     pin.Member,
+		CreatedAt:  pin.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !pin.ShiftStart.IsZero() {
+		resp.ShiftStart = pin.ShiftStart.UTC().Format(time.RFC3339)
+	}
+
+	return resp
+}
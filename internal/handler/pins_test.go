@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func postPin(t *testing.T, h *Handler, id, query string, body PinRequest) *httptest.ResponseRecorder {
+	t.Helper()
+
+	payload, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/schedule/"+id+"/pins?"+query, bytes.NewReader(payload))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+
+	require.NoError(t, h.CreatePin(c))
+
+	return rec
+}
+
+func listPins(t *testing.T, h *Handler, id string) PinsResponse {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/schedule/"+id+"/pins", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+
+	require.NoError(t, h.ListPins(c))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp PinsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func deletePin(t *testing.T, h *Handler, id string, pin int64) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id", "pin")
+	c.SetParamValues(id, strconv.FormatInt(pin, 10))
+
+	require.NoError(t, h.DeletePin(c))
+
+	return rec
+}
+
+// newPinHandler adds a daily schedule whose rotation starts today.
+func newPinHandler(t *testing.T) (*Handler, storage.Storage, string) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	_, err := store.AddSchedule(context.Background(), "backend-team", storage.Schedule{
+		Name:    "Daily",
+		Members: []string{"Alice", "Bob", "Charlie"},
+		Days: []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday,
+			time.Thursday, time.Friday, time.Saturday},
+		Anchor: storage.PinDate(time.Now()),
+		Start:  parseTime(t, "9:00AM"),
+		End:    parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+
+	team, err := store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+
+	return New(store, zap.NewNop()), store, team.Schedules[0].ID
+}
+
+func TestPins_Lifecycle(t *testing.T) {
+	h, store, id := newPinHandler(t)
+	ctx := context.Background()
+
+	tomorrow := storage.PinDate(time.Now()).AddDate(0, 0, 1)
+	// The second week of the rotation is Bob's, a pin on its first day leaves the rest to him
+	boundary := storage.PinDate(time.Now()).AddDate(0, 0, 7)
+
+	rec := postPin(t, h, id, "", PinRequest{Date: boundary.Format(time.DateOnly), Member: "Charlie"})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var pin PinResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &pin))
+	assert.Equal(t, id, pin.ScheduleID)
+	assert.Equal(t, "backend-team", pin.Team)
+	assert.Equal(t, "Charlie", pin.Member)
+
+	rec = postPin(t, h, id, "", PinRequest{Date: tomorrow.Format(time.DateOnly), Member: "Dana"})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = postPin(t, h, id, "external=true", PinRequest{Date: tomorrow.Format(time.DateOnly), Member: "Dana"})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	for at, want := range map[time.Time]string{
+		tomorrow.Add(10 * time.Hour):                  "Dana",
+		tomorrow.AddDate(0, 0, 1).Add(10 * time.Hour): "Alice",
+		boundary.Add(10 * time.Hour):                  "Charlie",
+		boundary.AddDate(0, 0, 1).Add(10 * time.Hour): "Bob",
+		boundary.AddDate(0, 0, 7).Add(10 * time.Hour): "Charlie",
+	} {
+		got, err := store.GetCurrentOncall(ctx, "backend-team", at)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, at.String())
+	}
+
+	pins := listPins(t, h, id)
+	require.Len(t, pins.Pins, 2)
+	assert.Equal(t, "Dana", pins.Pins[0].Member)
+	assert.Equal(t, "Charlie", pins.Pins[1].Member)
+
+	// Pinned occurrences show up in calendar feeds
+	team, err := store.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+
+	var ics bytes.Buffer
+	require.NoError(t, writeCalendar(&ics, "backend-team", team.Schedules, "", time.Now()))
+	assert.Contains(t, ics.String(), "RECURRENCE-ID:"+boundary.Add(9*time.Hour).Format(icsTimeLayout)+"\r\n")
+	assert.Contains(t, ics.String(), "DESCRIPTION:Pinned: Charlie\r\n")
+
+	// Importing the feed skips the pinned occurrences of the series
+	_, imported := importCalendar(t, h, "/schedules/import/ics?team=imported-team", ics.String())
+	assert.Len(t, imported.Imported, 1)
+	assert.Empty(t, imported.Failed)
+
+	assert.Equal(t, http.StatusNoContent, deletePin(t, h, id, pin.ID).Code)
+	assert.Equal(t, http.StatusNotFound, deletePin(t, h, id, pin.ID).Code)
+	assert.Len(t, listPins(t, h, id).Pins, 1)
+}
+
+func TestPins_InvalidRequests(t *testing.T) {
+	h, _, id := newPinHandler(t)
+
+	tomorrow := storage.PinDate(time.Now()).AddDate(0, 0, 1).Format(time.DateOnly)
+	yesterday := storage.PinDate(time.Now()).AddDate(0, 0, -1).Format(time.DateOnly)
+
+	tests := []struct {
+		name  string
+		id    string
+		query string
+		body  PinRequest
+		code  int
+		err   string
+	}{
+		{"invalid date", id, "", PinRequest{Date: "25/12/2025", Member: "Alice"}, http.StatusBadRequest, "invalid date format"},
+		{"past date", id, "", PinRequest{Date: yesterday, Member: "Alice"}, http.StatusBadRequest, "date must not be in the past"},
+		{"missing member", id, "", PinRequest{Date: tomorrow, Member: " "}, http.StatusBadRequest, "member is required"},
+		{"not a member", id, "", PinRequest{Date: tomorrow, Member: "Dana"}, http.StatusBadRequest, "Dana is not a member of the schedule"},
+		{"invalid external", id, "external=maybe", PinRequest{Date: tomorrow, Member: "Dana"}, http.StatusBadRequest, "invalid external query parameter"},
+		{"unknown schedule", "999", "", PinRequest{Date: tomorrow, Member: "Alice"}, http.StatusNotFound, "schedule not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := postPin(t, h, tt.id, tt.query, tt.body)
+			assert.Equal(t, tt.code, rec.Code)
+
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.True(t, strings.HasPrefix(resp.Error, tt.err), resp.Error)
+		})
+	}
+
+	assert.Equal(t, http.StatusNotFound, deletePin(t, h, id, 12345).Code)
+	assert.Equal(t, http.StatusNotFound, deletePin(t, h, "999", 1).Code)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// readinessTimeout bounds how long readiness waits on the database, so a
+// hanging connection fails the probe instead of the probe timing out.
+const readinessTimeout = 2 * time.Second
+
+// Statuses of the dependencies of readiness.
+const (
+	DependencyUp   = "up"
+	DependencyDown = "down"
+)
+
+// Pinger pings the database the storage runs on. *db.DB implements it.
+type Pinger interface {
+	Health(ctx context.Context) error
+}
+
+// SetPinger sets the database readiness pings. Without one, as with the
+// memory storage, the server is ready as soon as it is up.
+func (h *Handler) SetPinger(p Pinger) {
+	h.pinger = p
+}
+
+// LivenessResponse represents the liveness probe response.
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadinessResponse represents the readiness probe response, along with the
+// status of every dependency checked.
+type ReadinessResponse struct {
+	Status       string             `json:"status"`
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+}
+
+// DependencyStatus represents the status of a dependency of readiness.
+type DependencyStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Liveness handles liveness probes, which succeed as long as the process is
+// up to answer them.
+func (h *Handler) Liveness(c echo.Context) error {
+	return c.JSON(http.StatusOK, LivenessResponse{Status: "ok"})
+}
+
+// Readiness handles readiness probes. It pings the database, failing with a
+// 503 naming it when it is unreachable, and fails once the server starts
+// draining like Health does.
+func (h *Handler) Readiness(c echo.Context) error {
+	resp, code := ReadinessResponse{Status: "ready"}, http.StatusOK
+
+	if h.pinger != nil {
+		ctx, cancel := context.WithTimeout(c.Request().Context(), readinessTimeout)
+		defer cancel()
+
+		database := DependencyStatus{Name: "database", Status: DependencyUp}
+		if err := h.pinger.Health(ctx); err != nil {
+			h.logger.Warn("database is not ready", zap.Error(err))
+			database.Status, database.Error = DependencyDown, "database is unreachable"
+			resp.Status, code = "not_ready", http.StatusServiceUnavailable
+		}
+		resp.Dependencies = append(resp.Dependencies, database)
+	}
+
+	if !h.drain.Ready() {
+		resp.Status, code = "draining", http.StatusServiceUnavailable
+	}
+
+	return c.JSON(code, resp)
+}
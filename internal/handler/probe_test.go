@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// stubPinger fails its pings with err, recording whether they were bounded.
+type stubPinger struct {
+	err      error
+	deadline bool
+}
+
+func (p *stubPinger) Health(ctx context.Context) error {
+	_, p.deadline = ctx.Deadline()
+	return p.err
+}
+
+func newProbeServer(pinger Pinger) (*echo.Echo, *Handler) {
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.SetPinger(pinger)
+
+	e := echo.New()
+	e.GET("/healthz", h.Liveness)
+	e.GET("/readyz", h.Readiness)
+
+	return e, h
+}
+
+func getReadiness(t *testing.T, e *echo.Echo) (int, ReadinessResponse) {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodGet, "/readyz", nil, "")
+
+	var resp ReadinessResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return rec.Code, resp
+}
+
+func TestReadiness_Memory(t *testing.T) {
+	e, _ := newProbeServer(nil)
+
+	code, resp := getReadiness(t, e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ReadinessResponse{Status: "ready"}, resp)
+}
+
+func TestReadiness_Database(t *testing.T) {
+	pinger := &stubPinger{}
+	e, _ := newProbeServer(pinger)
+
+	code, resp := getReadiness(t, e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, ReadinessResponse{
+		Status:       "ready",
+		Dependencies: []DependencyStatus{{Name: "database", Status: DependencyUp}},
+	}, resp)
+	assert.True(t, pinger.deadline, "pings are bounded")
+
+	// The failure is reported without its internal error text
+	pinger.err = errors.New("dial tcp 127.0.0.1:5432: connection refused")
+
+	code, resp = getReadiness(t, e)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", resp.Status)
+	require.Len(t, resp.Dependencies, 1)
+	assert.Equal(t, "database", resp.Dependencies[0].Name)
+	assert.Equal(t, DependencyDown, resp.Dependencies[0].Status)
+	assert.NotEmpty(t, resp.Dependencies[0].Error)
+	assert.NotContains(t, resp.Dependencies[0].Error, "connection refused")
+
+	// Liveness does not depend on the database
+	rec := serveJSON(e, http.MethodGet, "/healthz", nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestReadiness_Draining(t *testing.T) {
+	e, h := newProbeServer(&stubPinger{})
+
+	h.Drain().Begin(t.Context(), 0)
+
+	code, resp := getReadiness(t, e)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "draining", resp.Status)
+
+	// The process is still up to answer
+	rec := serveJSON(e, http.MethodGet, "/healthz", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var live LivenessResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &live))
+	assert.Equal(t, "ok", live.Status)
+}
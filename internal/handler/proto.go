@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/1995parham-learning/oncall-schedule/pkg/oncallpb"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/protobuf/proto"
+)
+
+// MIMEApplicationProtobuf is the media type of protobuf requests and responses.
+const MIMEApplicationProtobuf = "application/x-protobuf"
+
+// Binder binds protobuf request bodies to the messages they mirror and
+// leaves everything else to the default binder, so handlers share their
+// validation whatever the format.
+type Binder struct {
+	echo.DefaultBinder
+}
+
+// Bind binds the request to i, which must be a *Request for protobuf bodies.
+func (b *Binder) Bind(i any, c echo.Context) error {
+	if !isProtobuf(c.Request().Header.Get(echo.HeaderContentType)) {
+		return b.DefaultBinder.Bind(i, c)
+	}
+
+	req, ok := i.(*Request)
+	if !ok {
+		return echo.ErrUnsupportedMediaType
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+
+	var msg oncallpb.ScheduleRequest
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid protobuf body: %v", err)).SetInternal(err)
+	}
+	*req = requestFromProto(&msg)
+
+	return nil
+}
+
+// Serializer encodes responses as protobuf for requests sending or accepting
+// it, and as JSON otherwise. Values without a protobuf mirror, see toProto,
+// are always encoded as JSON. Errors of 5xx responses get the request ID as
+// their correlation ID.
+type Serializer struct {
+	echo.DefaultJSONSerializer
+}
+
+// Serialize encodes i, the content type set by c.JSON is replaced for
+// protobuf as nothing is written yet.
+func (s Serializer) Serialize(c echo.Context, i any, indent string) error {
+	if resp, ok := i.(ErrorResponse); ok && c.Response().Status >= http.StatusInternalServerError {
+		resp.CorrelationID = c.Response().Header().Get(echo.HeaderXRequestID)
+		i = resp
+	}
+
+	msg, ok := toProto(i)
+	if !ok || !acceptsProtobuf(c.Request()) {
+		return s.DefaultJSONSerializer.Serialize(c, i, indent)
+	}
+
+	body, err := proto.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode protobuf response: %w", err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationProtobuf)
+	_, err = c.Response().Write(body)
+
+	return err
+}
+
+// acceptsProtobuf reports whether the response to the request is encoded as
+// protobuf, when it is sent as protobuf or asks for it in its Accept header.
+func acceptsProtobuf(r *http.Request) bool {
+	if isProtobuf(r.Header.Get(echo.HeaderContentType)) {
+		return true
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get(echo.HeaderAccept), ",") {
+		if isProtobuf(accepted) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isProtobuf reports whether a media type, which may carry parameters, is
+// the protobuf one.
+func isProtobuf(value string) bool {
+	mediaType, _, err := mime.ParseMediaType(value)
+
+	return err == nil && mediaType == MIMEApplicationProtobuf
+}
+
+// toProto converts the responses with a protobuf mirror.
+func toProto(i any) (proto.Message, bool) {
+	switch v := i.(type) {
+	case ErrorResponse:
+		return &oncallpb.ErrorResponse{Error: v.Error, Code: v.Code, CorrelationId: v.CorrelationID}, true
+	case OncallResponse:
+		return &oncallpb.OncallResponse{
+			Oncall: v.Oncall, Time: v.Time, Local: v.Local, Stale: v.Stale, Routing: v.Routing, SubstitutedFor: v.SubstitutedFor,
+			Oncalls: v.Oncalls, Warning: v.Warning, InheritedFrom: v.InheritedFrom, HandoffNote: handoffNoteToProto(v.HandoffNote),
+		}, true
+	default:
+		return nil, false
+	}
+}
+
+// requestFromProto converts a protobuf schedule request.
+func requestFromProto(msg *oncallpb.ScheduleRequest) Request {
+	return Request{
+		ID:             msg.GetId(),
+		Name:           msg.GetName(),
+		Description:    msg.GetDescription(),
+		Notes:          msg.GetNotes(),
+		Team:           msg.GetTeam(),
+		Members:        msg.GetMembers(),
+		Days:           msg.GetDays(),
+		Cron:           msg.GetCron(),
+		RRule:          msg.GetRrule(),
+		Anchor:         msg.GetAnchor(),
+		Start:          msg.GetStart(),
+		End:            msg.GetEnd(),
+		ValidUntil:     msg.GetValidUntil(),
+		Tags:           msg.GetTags(),
+		Routing:        msg.GetRouting(),
+		RotationOffset: int(msg.GetRotationOffset()),
+		CurrentMember:  msg.GetCurrentMember(),
+		Assignment:     msg.GetAssignment(),
+		DayAssignments: msg.GetDayAssignments(),
+		Rotation:       msg.GetRotation(),
+		Split:          int(msg.GetSplit()),
+		Handoff:        handoffFromProto(msg.GetHandoff()),
+		UseTeamMembers: msg.GetUseTeamMembers(),
+		CurrentUntil:   msg.GetCurrentUntil(),
+		RequiredCount:  int(msg.GetRequiredCount()),
+		Compensation:   compensationFromProto(msg.GetCompensation()),
+	}
+}
+
+// handoffNoteToProto converts a handoff note, nil when there is none.
+func handoffNoteToProto(note *HandoffNoteResponse) *oncallpb.HandoffNote {
+	if note == nil {
+		return nil
+	}
+
+	return &oncallpb.HandoffNote{
+		Id:         note.ID,
+		Team:       note.Team,
+		ScheduleId: note.ScheduleID,
+		Member:     note.Member,
+		Author:     note.Author,
+		Text:       note.Text,
+		ShiftStart: note.ShiftStart,
+		ShiftEnd:   note.ShiftEnd,
+		ExpiresAt:  note.ExpiresAt,
+		Status:     note.Status,
+		CreatedAt:  note.CreatedAt,
+		UpdatedAt:  note.UpdatedAt,
+	}
+}
+
+// compensationFromProto converts a protobuf compensation, nil when unset.
+func compensationFromProto(msg *oncallpb.Compensation) *Compensation {
+	if msg == nil {
+		return nil
+	}
+
+	return &Compensation{
+		RateClass:         msg.GetRateClass(),
+		Multiplier:        msg.GetMultiplier(),
+		WeekendRateClass:  msg.GetWeekendRateClass(),
+		WeekendMultiplier: msg.GetWeekendMultiplier(),
+	}
+}
+
+// handoffFromProto converts a protobuf handoff, nil when unset.
+func handoffFromProto(msg *oncallpb.Handoff) *Handoff {
+	if msg == nil {
+		return nil
+	}
+
+	return &Handoff{Day: msg.GetDay(), Time: msg.GetTime()}
+}
@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/pkg/oncallpb"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+func newProtoServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	e := echo.New()
+	e.Binder = &Binder{}
+	e.JSONSerializer = Serializer{}
+
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+
+	return e
+}
+
+// serveProto sends a protobuf request, or one without a body accepting
+// protobuf when msg is nil.
+func serveProto(t *testing.T, e *echo.Echo, method, target string, msg proto.Message) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body []byte
+	if msg != nil {
+		var err error
+		body, err = proto.Marshal(msg)
+		require.NoError(t, err)
+	}
+
+	req := httptest.NewRequest(method, target, bytes.NewReader(body))
+	if msg != nil {
+		req.Header.Set(echo.HeaderContentType, MIMEApplicationProtobuf)
+	} else {
+		req.Header.Set(echo.HeaderAccept, "application/json;q=0.5, "+MIMEApplicationProtobuf)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+// protoError decodes the protobuf error of a response.
+func protoError(t *testing.T, rec *httptest.ResponseRecorder) *oncallpb.ErrorResponse {
+	t.Helper()
+
+	require.Equal(t, MIMEApplicationProtobuf, rec.Header().Get(echo.HeaderContentType))
+
+	var resp oncallpb.ErrorResponse
+	require.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return &resp
+}
+
+func TestProto_CreateAndLookup(t *testing.T) {
+	e := newProtoServer(t)
+
+	rec := serveProto(t, e, http.MethodPost, "/schedule", &oncallpb.ScheduleRequest{
+		Name:           "Weekday Coverage",
+		Team:           "backend-team",
+		Members:        []string{"Alice", "Bob"},
+		Days:           []string{"Monday-Friday"},
+		Anchor:         "2025-04-28",
+		Start:          "9:00AM",
+		End:            "5:00PM",
+		RotationOffset: 1,
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	target := "/schedule?team=backend-team&tz=UTC&time=" + time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC).Format(time.RFC3339)
+
+	rec = serveProto(t, e, http.MethodGet, target, nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMEApplicationProtobuf, rec.Header().Get(echo.HeaderContentType))
+
+	var resp oncallpb.OncallResponse
+	require.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Bob", resp.GetOncall())
+	assert.Equal(t, "2025-04-28T10:00:00Z", resp.GetTime())
+	assert.Equal(t, "10:00 Mon", resp.GetLocal())
+
+	// JSON clients see the same schedule
+	rec = serveJSON(e, http.MethodGet, target, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+
+	var jsonResp OncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jsonResp))
+	assert.Equal(t, resp.GetOncall(), jsonResp.Oncall)
+}
+
+func TestProto_FixedAssignment(t *testing.T) {
+	e := newProtoServer(t)
+
+	rec := serveProto(t, e, http.MethodPost, "/schedule", &oncallpb.ScheduleRequest{
+		Name:           "Fixed",
+		Team:           "backend-team",
+		Days:           []string{"Monday", "Tuesday"},
+		Start:          "9:00AM",
+		End:            "5:00PM",
+		Assignment:     AssignmentFixed,
+		DayAssignments: map[string]string{"Monday": "Alice", "Tuesday": "Bob"},
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveProto(t, e, http.MethodGet, "/schedule?team=backend-team&time=2025-04-29T10:00:00Z", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp oncallpb.OncallResponse
+	require.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Bob", resp.GetOncall())
+}
+
+func TestProto_StaffingAndCompensation(t *testing.T) {
+	e := echo.New()
+	e.Binder = &Binder{}
+	e.JSONSerializer = Serializer{}
+
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+
+	rec := serveProto(t, e, http.MethodPost, "/schedule", &oncallpb.ScheduleRequest{
+		Name:          "Pairs",
+		Team:          "backend-team",
+		Members:       []string{"Alice", "Bob", "Carol"},
+		Days:          []string{"Monday-Friday"},
+		Anchor:        "2025-04-28",
+		Start:         "9:00AM",
+		End:           "5:00PM",
+		RequiredCount: 2,
+		Compensation:  &oncallpb.Compensation{RateClass: "standard", Multiplier: 1.5},
+	})
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 1)
+	assert.Equal(t, 2, team.Schedules[0].RequiredCount)
+	assert.Equal(t, &storage.Compensation{RateClass: "standard", Multiplier: 1.5}, team.Schedules[0].Compensation)
+
+	rec = serveProto(t, e, http.MethodGet, "/schedule?team=backend-team&time=2025-04-28T10:00:00Z", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp oncallpb.OncallResponse
+	require.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Alice", resp.GetOncall())
+	assert.Equal(t, []string{"Alice", "Bob"}, resp.GetOncalls())
+}
+
+func TestRequestFromProto(t *testing.T) {
+	req := requestFromProto(&oncallpb.ScheduleRequest{
+		Name:           "Legacy",
+		Team:           "backend-team",
+		UseTeamMembers: true,
+		CurrentMember:  "Bob",
+		CurrentUntil:   "2025-05-02T09:00:00Z",
+		RequiredCount:  2,
+		Compensation: &oncallpb.Compensation{
+			RateClass: "standard", Multiplier: 1, WeekendRateClass: "weekend", WeekendMultiplier: 2,
+		},
+	})
+
+	assert.True(t, req.UseTeamMembers)
+	assert.Equal(t, "Bob", req.CurrentMember)
+	assert.Equal(t, "2025-05-02T09:00:00Z", req.CurrentUntil)
+	assert.Equal(t, 2, req.RequiredCount)
+	assert.Equal(t, &Compensation{RateClass: "standard", Multiplier: 1, WeekendRateClass: "weekend", WeekendMultiplier: 2}, req.Compensation)
+
+	// Unset messages stay unset
+	assert.Nil(t, requestFromProto(&oncallpb.ScheduleRequest{}).Compensation)
+}
+
+func TestToProto_OncallResponse(t *testing.T) {
+	msg, ok := toProto(OncallResponse{
+		Oncall:        "Alice",
+		Oncalls:       []string{"Alice", "Bob"},
+		Time:          "2025-04-28T10:00:00Z",
+		Warning:       "schedule history does not reach back to 2025-01-01T00:00:00Z, answering from the current schedules",
+		InheritedFrom: "platform",
+		HandoffNote: &HandoffNoteResponse{
+			ID: 1, Team: "platform", ScheduleID: "3", Member: "Carol", Author: "Carol", Text: "Disk alerts are noisy",
+			ShiftStart: "2025-04-21T09:00:00Z", ShiftEnd: "2025-04-25T17:00:00Z", ExpiresAt: "2025-04-29T17:00:00Z",
+			Status: "active", CreatedAt: "2025-04-25T16:00:00Z", UpdatedAt: "2025-04-25T16:00:00Z",
+		},
+	})
+	require.True(t, ok)
+
+	body, err := proto.Marshal(msg)
+	require.NoError(t, err)
+
+	var resp oncallpb.OncallResponse
+	require.NoError(t, proto.Unmarshal(body, &resp))
+	assert.Equal(t, []string{"Alice", "Bob"}, resp.GetOncalls())
+	assert.Equal(t, "platform", resp.GetInheritedFrom())
+	assert.Contains(t, resp.GetWarning(), "does not reach back")
+	assert.Equal(t, int64(1), resp.GetHandoffNote().GetId())
+	assert.Equal(t, "3", resp.GetHandoffNote().GetScheduleId())
+	assert.Equal(t, "Disk alerts are noisy", resp.GetHandoffNote().GetText())
+	assert.Equal(t, "2025-04-29T17:00:00Z", resp.GetHandoffNote().GetExpiresAt())
+	assert.Equal(t, "active", resp.GetHandoffNote().GetStatus())
+
+	// Responses without a note leave it unset
+	msg, ok = toProto(OncallResponse{Oncall: "Alice"})
+	require.True(t, ok)
+	assert.Nil(t, msg.(*oncallpb.OncallResponse).GetHandoffNote())
+}
+
+func TestProto_Errors(t *testing.T) {
+	e := newProtoServer(t)
+
+	t.Run("validation failure", func(t *testing.T) {
+		msg := &oncallpb.ScheduleRequest{Name: "Weekday", Team: "backend-team", Days: []string{"Monday"}, Start: "9:00AM", End: "5:00PM"}
+
+		rec := serveProto(t, e, http.MethodPost, "/schedule", msg)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		protoErr := protoError(t, rec)
+
+		// The JSON request fails the same way
+		jsonRec := serveJSON(e, http.MethodPost, "/schedule", requestFromProto(msg), "")
+		require.Equal(t, http.StatusBadRequest, jsonRec.Code)
+		var jsonErr ErrorResponse
+		require.NoError(t, json.Unmarshal(jsonRec.Body.Bytes(), &jsonErr))
+		assert.Equal(t, jsonErr.Error, protoErr.GetError())
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/schedule", bytes.NewReader([]byte{0xff, 0xff}))
+		req.Header.Set(echo.HeaderContentType, MIMEApplicationProtobuf)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "invalid request body", protoError(t, rec).GetError())
+	})
+
+	t.Run("unknown team", func(t *testing.T) {
+		rec := serveProto(t, e, http.MethodGet, "/schedule?team=unknown-team&time=2025-04-28T10:00:00Z", nil)
+		require.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "team not found", protoError(t, rec).GetError())
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		rec := serveProto(t, e, http.MethodGet, "/schedule?team=backend-team", nil)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "time query parameter is required", protoError(t, rec).GetError())
+	})
+}
+
+func TestAcceptsProtobuf(t *testing.T) {
+	tests := []struct {
+		contentType string
+		accept      string
+		want        bool
+	}{
+		{"", "", false},
+		{"", echo.MIMEApplicationJSON, false},
+		{"", MIMEApplicationProtobuf, true},
+		{"", "text/html, application/x-protobuf; q=0.9", true},
+		{MIMEApplicationProtobuf + "; charset=binary", "", true},
+		{echo.MIMEApplicationJSON, "", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderContentType, tt.contentType)
+		req.Header.Set(echo.HeaderAccept, tt.accept)
+
+		assert.Equal(t, tt.want, acceptsProtobuf(req), "%q %q", tt.contentType, tt.accept)
+	}
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// CoverageWindow represents a stretch of time somebody is on call for a team.
+type CoverageWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// DayCoverage represents the coverage of a team on a weekday, with the
+// windows as times of day and the end of the day as 24:00.
+type DayCoverage struct {
+	Day     string           `json:"day"`
+	Windows []CoverageWindow `json:"windows"`
+}
+
+// PublicCoverageResponse represents the coverage of a team for public status
+// pages. It tells when somebody is on call, never who.
+type PublicCoverageResponse struct {
+	Team     string `json:"team"`
+	Time     string `json:"time"`
+	Timezone string `json:"timezone"`
+	Covered  bool   `json:"covered"`
+	// AroundTheClock is set when every day of the week is covered around the
+	// clock, all-day shifts end at 11:59PM.
+	AroundTheClock bool             `json:"around_the_clock"`
+	Today          []CoverageWindow `json:"today"`
+	Weekly         []DayCoverage    `json:"weekly"`
+}
+
+// PublicCoverage handles requests for the anonymized coverage of a team, for
+// public status pages. It answers 404 unless the public coverage of the team
+// is enabled, whether the team exists or not. The coverage comes from the
+// timeline of the schedules: whether somebody is on call now, the windows of
+// today in the tz zone, and the windows of each weekday over the coming week.
+// Time while the team is paused is uncovered now and today, the weekly
+// pattern leaves pauses out.
+func (h *Handler) PublicCoverage(c echo.Context) error {
+	teamName := c.Param("team")
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	enabled, err := h.storage.PublicCoverage(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get public coverage of team %q: %w", teamName, err), "failed to retrieve coverage")
+	}
+	if !enabled {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "public coverage is not enabled for this team"})
+	}
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve coverage")
+	}
+
+	pause, paused, err := h.storage.GetPause(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get pause of team %q: %w", teamName, err), "failed to retrieve coverage")
+	}
+	if !paused {
+		pause = storage.Pause{}
+	}
+
+	now := h.now()
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	resp := PublicCoverageResponse{
+		Team:     teamName,
+		Time:     local.Format(time.RFC3339),
+		Timezone: loc.String(),
+		Today:    []CoverageWindow{},
+		Weekly:   make([]DayCoverage, 0, 7),
+	}
+
+	var stretches []storage.Shift
+	for _, stretch := range coverage(team.Schedules, today, tomorrow) {
+		stretches = append(stretches, outsidePause(stretch, pause)...)
+	}
+	for _, stretch := range stretches {
+		if !now.Before(stretch.Start) && now.Before(stretch.End) {
+			resp.Covered = true
+		}
+		resp.Today = append(resp.Today, CoverageWindow{
+			Start: stretch.Start.In(loc).Format(time.RFC3339),
+			End:   stretch.End.In(loc).Format(time.RFC3339),
+		})
+	}
+
+	// Every weekday once, in the order of the week, from the coming seven days
+	days := make(map[time.Weekday]DayCoverage, 7)
+	resp.AroundTheClock = true
+	for day := today; day.Before(today.AddDate(0, 0, 7)); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+
+		pattern := DayCoverage{Day: strings.ToLower(day.Weekday().String()), Windows: []CoverageWindow{}}
+		var covered time.Duration
+		for _, stretch := range coverage(team.Schedules, day, next) {
+			covered += stretch.End.Sub(stretch.Start)
+			pattern.Windows = append(pattern.Windows, CoverageWindow{
+				Start: stretch.Start.In(loc).Format("15:04"),
+				End:   windowEnd(stretch.End, next, loc),
+			})
+		}
+		if covered < aroundTheClock {
+			resp.AroundTheClock = false
+		}
+
+		days[day.Weekday()] = pattern
+	}
+	for _, day := range []time.Weekday{
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday,
+	} {
+		resp.Weekly = append(resp.Weekly, days[day])
+	}
+
+	h.cacheOncall(c, team.Schedules, now)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// coverage returns the stretches of [from, to) somebody is on call for the
+// schedules, joining the ones handing over from a shift to the next.
+func coverage(schedules []storage.Schedule, from, to time.Time) []storage.Shift {
+	var stretches []storage.Shift
+
+	for _, shift := range storage.Timeline(schedules, from, to) {
+		if n := len(stretches); n > 0 && stretches[n-1].End.Equal(shift.Start) {
+			stretches[n-1].End = shift.End
+			continue
+		}
+		stretches = append(stretches, storage.Shift{Start: shift.Start, End: shift.End})
+	}
+
+	return stretches
+}
+
+// windowEnd renders the end of a window as a time of day in loc, the end of
+// the day as 24:00.
+func windowEnd(end, midnight time.Time, loc *time.Location) string {
+	if !end.Before(midnight) {
+		return "24:00"
+	}
+
+	return end.In(loc).Format("15:04")
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newCoverageServer returns a server whose support team is covered from
+// 9:00AM to 10:00PM UTC on weekdays, by Alice and Bob during the day and
+// Carol in the evening, on Monday March 2, 2026 at 10:00 UTC.
+func newCoverageServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	h.now = (&fakeClock{now: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)}).Now
+
+	e := echo.New()
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team/settings", h.GetTeamSettings)
+	e.PATCH("/teams/:team/settings", h.UpdateTeamSettings)
+	e.GET("/teams/:team/coverage/public", h.PublicCoverage)
+
+	days := weekRequest("support", "Days", "Alice", "Mon-Fri")
+	days.Members = []string{"Alice", "Bob"}
+	evenings := weekRequest("support", "Evenings", "Carol", "Mon-Fri")
+	evenings.Start = "5:00PM"
+	evenings.End = "10:00PM"
+
+	for _, req := range []Request{days, evenings} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		_, _, err := store.AddUser(context.Background(), storage.User{
+			UserName: name,
+			Emails:   []string{strings.ToLower(name) + "@example.com"},
+			Active:   true,
+		})
+		require.NoError(t, err)
+	}
+
+	return e, store
+}
+
+func enablePublicCoverage(t *testing.T, e *echo.Echo, team string) {
+	t.Helper()
+
+	enabled := true
+	rec := serveJSON(e, http.MethodPatch, "/teams/"+team+"/settings", TeamSettingsRequest{PublicCoverageEnabled: &enabled}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TeamSettingsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, TeamSettingsResponse{Team: team, PublicCoverageEnabled: true}, resp)
+}
+
+func TestPublicCoverage_DisabledByDefault(t *testing.T) {
+	e, _ := newCoverageServer(t)
+
+	// Teams that do not exist look the same as teams that did not opt in
+	for _, team := range []string{"support", "missing"} {
+		rec := serveJSON(e, http.MethodGet, "/teams/"+team+"/coverage/public", nil, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, team)
+	}
+
+	// Disabling again hides it
+	enablePublicCoverage(t, e, "support")
+	disabled := false
+	rec := serveJSON(e, http.MethodPatch, "/teams/support/settings", TeamSettingsRequest{PublicCoverageEnabled: &disabled}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/teams/support/coverage/public", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPublicCoverage(t *testing.T) {
+	e, _ := newCoverageServer(t)
+	enablePublicCoverage(t, e, "support")
+
+	// Tehran is 3:30 ahead, so the evenings run into the next day there
+	rec := serveJSON(e, http.MethodGet, "/teams/support/coverage/public?tz=Asia/Tehran", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	for _, identifying := range []string{"Alice", "Bob", "Carol", "@example.com", "Days", "Evenings"} {
+		assert.NotContains(t, strings.ToLower(rec.Body.String()), strings.ToLower(identifying))
+	}
+
+	var resp PublicCoverageResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	weekday := []CoverageWindow{{Start: "00:00", End: "01:30"}, {Start: "12:30", End: "24:00"}}
+	assert.Equal(t, PublicCoverageResponse{
+		Team:     "support",
+		Time:     "2026-03-02T13:30:00+03:30",
+		Timezone: "Asia/Tehran",
+		Covered:  true,
+		Today:    []CoverageWindow{{Start: "2026-03-02T12:30:00+03:30", End: "2026-03-03T00:00:00+03:30"}},
+		Weekly: []DayCoverage{
+			{Day: "monday", Windows: []CoverageWindow{{Start: "12:30", End: "24:00"}}},
+			{Day: "tuesday", Windows: weekday},
+			{Day: "wednesday", Windows: weekday},
+			{Day: "thursday", Windows: weekday},
+			{Day: "friday", Windows: weekday},
+			{Day: "saturday", Windows: []CoverageWindow{{Start: "00:00", End: "01:30"}}},
+			{Day: "sunday", Windows: []CoverageWindow{}},
+		},
+	}, resp)
+}
+
+func TestPublicCoverage_Paused(t *testing.T) {
+	e, store := newCoverageServer(t)
+	enablePublicCoverage(t, e, "support")
+
+	_, err := store.PauseTeam(context.Background(), "support", storage.Pause{
+		Reason: "Alice is moving the team",
+		Since:  time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC),
+		Until:  time.Date(2026, 3, 2, 18, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	rec := serveJSON(e, http.MethodGet, "/teams/support/coverage/public", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "Alice")
+
+	var resp PublicCoverageResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	// The pause is left out of the weekly pattern
+	assert.False(t, resp.Covered)
+	assert.Equal(t, []CoverageWindow{{Start: "2026-03-02T18:00:00Z", End: "2026-03-02T22:00:00Z"}}, resp.Today)
+	assert.Equal(t, DayCoverage{Day: "monday", Windows: []CoverageWindow{{Start: "09:00", End: "22:00"}}}, resp.Weekly[0])
+	assert.False(t, resp.AroundTheClock)
+}
+
+func TestPublicCoverage_AroundTheClock(t *testing.T) {
+	e, _ := newCoverageServer(t)
+
+	req := weekRequest("platform", "Around the clock", "Dave", "Mon-Sun")
+	req.Start = "12:00AM"
+	req.End = "11:59PM"
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	enablePublicCoverage(t, e, "platform")
+
+	rec = serveJSON(e, http.MethodGet, "/teams/platform/coverage/public?tz=Asia/Tehran", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp PublicCoverageResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.True(t, resp.Covered)
+	assert.True(t, resp.AroundTheClock)
+	assert.NotContains(t, rec.Body.String(), "Dave")
+}
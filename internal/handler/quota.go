@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// CodeQuotaExceeded marks writes rejected because the team reached its quota.
+const CodeQuotaExceeded = "QUOTA_EXCEEDED"
+
+// QuotaErrorResponse represents a write rejected by a quota.
+type QuotaErrorResponse struct {
+	Error    string `json:"error"`
+	Code     string `json:"code"`
+	Resource string `json:"resource"`
+	Current  int    `json:"current"`
+	Limit    int    `json:"limit"`
+}
+
+// SetQuotas sets the limits on the resources of the teams.
+func (h *Handler) SetQuotas(quotas config.QuotaConfig) {
+	h.quotas = quotas
+}
+
+// withQuota returns a context limiting the resource of the team, which the
+// storage counts along with the write so concurrent requests cannot overrun it.
+func (h *Handler) withQuota(ctx context.Context, resource, team string) context.Context {
+	override := h.quotas.Teams[team]
+
+	var limit int
+	switch resource {
+	case storage.QuotaSchedules:
+		limit = cmp.Or(override.Schedules, h.quotas.Schedules)
+	case storage.QuotaPins:
+		limit = cmp.Or(override.Pins, h.quotas.Pins)
+	case storage.QuotaWebhooks:
+		limit = cmp.Or(override.Webhooks, h.quotas.Webhooks)
+	}
+
+	return storage.WithQuota(ctx, resource, limit)
+}
+
+// quotaExceeded responds to a write rejected by a quota.
+func quotaExceeded(c echo.Context, err *storage.QuotaError) error {
+	return c.JSON(http.StatusUnprocessableEntity, QuotaErrorResponse{
+		Error:    fmt.Sprintf("the team has reached its quota of %d %s", err.Limit, err.Resource),
+		Code:     CodeQuotaExceeded,
+		Resource: err.Resource,
+		Current:  err.Current,
+		Limit:    err.Limit,
+	})
+}
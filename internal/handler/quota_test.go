@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newQuotaServer(t *testing.T) (*echo.Echo, *Handler, storage.Storage) {
+	t.Helper()
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	h.AllowUnsignedWebhooks(true)
+	h.SetQuotas(config.QuotaConfig{
+		Schedules: 2,
+		Pins:      1,
+		Webhooks:  1,
+		Teams: map[string]config.QuotaTeamConfig{
+			"platform-team": {Schedules: 3},
+		},
+	})
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.POST("/schedule/:id/pins", h.CreatePin)
+	e.Group("/admin", Admin("secret")).POST("/webhooks", h.CreateWebhook)
+
+	return e, h, store
+}
+
+func quotaRequest(team string) Request {
+	return Request{
+		Name:    "Weekday",
+		Team:    team,
+		Members: []string{"Alice", "Bob"},
+		Days:    []string{"Monday-Friday"},
+		Start:   "9:00AM",
+		End:     "5:00PM",
+	}
+}
+
+// requireQuotaExceeded checks the response of a write rejected by a quota.
+func requireQuotaExceeded(t *testing.T, rec *httptest.ResponseRecorder, resource string, current, limit int) {
+	t.Helper()
+
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+
+	var resp QuotaErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, CodeQuotaExceeded, resp.Code)
+	assert.Equal(t, resource, resp.Resource)
+	assert.Equal(t, current, resp.Current)
+	assert.Equal(t, limit, resp.Limit)
+	assert.NotEmpty(t, resp.Error)
+}
+
+func TestQuota_Schedules(t *testing.T) {
+	e, _, _ := newQuotaServer(t)
+
+	// The schedules of a team have different names
+	named := func(team string, i int) Request {
+		req := quotaRequest(team)
+		req.Name = fmt.Sprintf("Weekday %d", i)
+		return req
+	}
+
+	// Exactly at the limit
+	for i := range 2 {
+		rec := serveJSON(e, http.MethodPost, "/schedule", named("backend-team", i), "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	// One past it
+	rec := serveJSON(e, http.MethodPost, "/schedule", named("backend-team", 2), "")
+	requireQuotaExceeded(t, rec, storage.QuotaSchedules, 2, 2)
+
+	// Other teams have their own count, and teams may override the limit
+	rec = serveJSON(e, http.MethodPost, "/schedule", quotaRequest("frontend-team"), "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	for i := range 3 {
+		rec = serveJSON(e, http.MethodPost, "/schedule", named("platform-team", i), "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	rec = serveJSON(e, http.MethodPost, "/schedule", named("platform-team", 3), "")
+	requireQuotaExceeded(t, rec, storage.QuotaSchedules, 3, 3)
+}
+
+func TestQuota_Pins(t *testing.T) {
+	e, _, store := newQuotaServer(t)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+	target := "/schedule/" + team.Schedules[0].ID + "/pins"
+
+	tomorrow := storage.PinDate(time.Now()).AddDate(0, 0, 1)
+
+	rec = serveJSON(e, http.MethodPost, target, PinRequest{Date: tomorrow.Format(time.DateOnly), Member: "Bob"}, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// Replacing the pin of the date stays within the limit
+	rec = serveJSON(e, http.MethodPost, target, PinRequest{Date: tomorrow.Format(time.DateOnly), Member: "Alice"}, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodPost, target, PinRequest{Date: tomorrow.AddDate(0, 0, 1).Format(time.DateOnly), Member: "Bob"}, "")
+	requireQuotaExceeded(t, rec, storage.QuotaPins, 1, 1)
+}
+
+func TestQuota_Webhooks(t *testing.T) {
+	e, _, _ := newQuotaServer(t)
+
+	rec := serveJSON(e, http.MethodPost, "/admin/webhooks", WebhookRequest{URL: "https://hooks.test/a", Team: "backend-team"}, "secret")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodPost, "/admin/webhooks", WebhookRequest{URL: "https://hooks.test/b", Team: "backend-team"}, "secret")
+	requireQuotaExceeded(t, rec, storage.QuotaWebhooks, 1, 1)
+
+	rec = serveJSON(e, http.MethodPost, "/admin/webhooks", WebhookRequest{URL: "https://hooks.test/b", Team: "frontend-team"}, "secret")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestQuota_ImportCalendar(t *testing.T) {
+	_, h, store := newQuotaServer(t)
+
+	event := func(uid string) []string {
+		return []string{
+			"BEGIN:VEVENT",
+			"UID:" + uid,
+			"SUMMARY:Alice " + uid,
+			"ATTENDEE;CN=Alice:mailto:alice@example.com",
+			"DTSTART:20250113T090000Z",
+			"DTEND:20250113T170000Z",
+			"RRULE:FREQ=WEEKLY;BYDAY=MO",
+			"END:VEVENT",
+		}
+	}
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0"}
+	for _, uid := range []string{"first", "second", "third"} {
+		lines = append(lines, event(uid)...)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	// The events past the quota fail, the ones before it are kept
+	rec, resp := importCalendar(t, h, "/schedules/import/ics?team=backend-team", strings.Join(lines, "\r\n"))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Len(t, resp.Imported, 2)
+	require.Len(t, resp.Failed, 1)
+	assert.Equal(t, "third", resp.Failed[0].UID)
+	assert.Contains(t, resp.Failed[0].Error, "quota")
+
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+	assert.Len(t, team.Schedules, 2)
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CodeReadOnly is the error code of mutations rejected in read-only mode.
+const CodeReadOnly = "READ_ONLY"
+
+// readOnlyPath is the route of the read-only toggle, which stays writable so
+// the mode can be turned off again.
+const readOnlyPath = "/admin/readonly"
+
+// ReadOnly holds the read-only mode of the server. The mode starts from the
+// configuration, and once it is toggled at runtime later configuration values
+// are ignored, so a reload does not undo an operator's decision.
+type ReadOnly struct {
+	mu      sync.RWMutex
+	enabled bool
+	reason  string
+	toggled bool
+}
+
+// ReadOnlyRequest represents the read-only toggle request.
+type ReadOnlyRequest struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ReadOnlyResponse represents the read-only mode of the server.
+type ReadOnlyResponse struct {
+	Enabled bool   `json:"enabled"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Configure applies the configured mode unless it was toggled at runtime.
+func (r *ReadOnly) Configure(enabled bool, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.toggled {
+		return
+	}
+
+	r.enabled = enabled
+	r.reason = reason
+}
+
+// Set toggles the mode at runtime. The reason is dropped when disabling.
+func (r *ReadOnly) Set(enabled bool, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !enabled {
+		reason = ""
+	}
+
+	r.enabled = enabled
+	r.reason = reason
+	r.toggled = true
+}
+
+// State returns whether read-only mode is enabled and why.
+func (r *ReadOnly) State() (bool, string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.enabled, r.reason
+}
+
+// Middleware rejects mutating requests with a 503 while read-only mode is
+// enabled. Safe methods, the toggle itself, and signing out and notification
+// dry runs, which change no data, are always let through.
+func (r *ReadOnly) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !isMutation(c.Request().Method) || c.Path() == readOnlyPath || c.Path() == logoutPath || c.Path() == dryRunPath {
+				return next(c)
+			}
+
+			enabled, reason := r.State()
+			if !enabled {
+				return next(c)
+			}
+
+			message := "server is in read-only mode"
+			if reason != "" {
+				message += ": " + reason
+			}
+
+			return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: message, Code: CodeReadOnly})
+		}
+	}
+}
+
+// SetReadOnly handles read-only mode toggle requests.
+func (h *Handler) SetReadOnly(c echo.Context) error {
+	var req ReadOnlyRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	h.readOnly.Set(req.Enabled, req.Reason)
+
+	h.logger.Warn("read-only mode toggled",
+		zap.Bool("enabled", req.Enabled),
+		zap.String("reason", req.Reason),
+	)
+
+	enabled, reason := h.readOnly.State()
+	return c.JSON(http.StatusOK, ReadOnlyResponse{Enabled: enabled, Reason: reason})
+}
+
+// isMutation reports whether a request with the given method may change state.
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newReadOnlyServer wires the routes touched by read-only mode.
+func newReadOnlyServer(t *testing.T) (*echo.Echo, *Handler) {
+	t.Helper()
+
+	e := echo.New()
+	logger, _ := zap.NewDevelopment()
+	h := New(storage.NewMemoryStorage(), logger)
+
+	e.Use(h.ReadOnly().Middleware())
+	e.GET("/health", h.Health)
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.Group("/admin", Admin("secret")).PUT("/readonly", h.SetReadOnly)
+
+	return e, h
+}
+
+func serveJSON(e *echo.Echo, method, target string, body any, token string) *httptest.ResponseRecorder {
+	var payload []byte
+	if body != nil {
+		payload, _ = json.Marshal(body)
+	}
+
+	req := httptest.NewRequest(method, target, bytes.NewReader(payload))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestReadOnly_BlocksMutationsAndTogglesBack(t *testing.T) {
+	e, _ := newReadOnlyServer(t)
+
+	schedule := Request{
+		Name:    "Weekday Coverage",
+		Team:    "backend-team",
+		Members: []string{"Alice"},
+		Days:    []string{"Monday"},
+		Start:   "9:00AM",
+		End:     "5:00PM",
+	}
+	require.Equal(t, http.StatusCreated, serveJSON(e, http.MethodPost, "/schedule", schedule, "").Code)
+
+	rec := serveJSON(e, http.MethodPut, "/admin/readonly", ReadOnlyRequest{Enabled: true, Reason: "database maintenance"}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Mutations are refused with the reason
+	schedule.Name = "Another"
+	rec = serveJSON(e, http.MethodPost, "/schedule", schedule, "")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var errResp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+	assert.Equal(t, CodeReadOnly, errResp.Code)
+	assert.Contains(t, errResp.Error, "database maintenance")
+
+	// Lookups keep working
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=2025-04-28T10:00:00Z", nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// The mode is reported by the health check
+	rec = serveJSON(e, http.MethodGet, "/health", nil, "")
+	var health HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &health))
+	assert.True(t, health.ReadOnly)
+	assert.Equal(t, "database maintenance", health.ReadOnlyReason)
+
+	// Toggling back allows mutations again
+	rec = serveJSON(e, http.MethodPut, "/admin/readonly", ReadOnlyRequest{Enabled: false}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/schedule", schedule, "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestReadOnly_ToggleRequiresAdmin(t *testing.T) {
+	e, h := newReadOnlyServer(t)
+
+	for _, token := range []string{"", "wrong"} {
+		rec := serveJSON(e, http.MethodPut, "/admin/readonly", ReadOnlyRequest{Enabled: true}, token)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+
+	enabled, _ := h.ReadOnly().State()
+	assert.False(t, enabled)
+}
+
+func TestReadOnly_RuntimeToggleWinsOverConfig(t *testing.T) {
+	var r ReadOnly
+
+	r.Configure(true, "from config")
+	enabled, reason := r.State()
+	assert.True(t, enabled)
+	assert.Equal(t, "from config", reason)
+
+	r.Set(false, "")
+
+	// A later reload of the config does not undo the runtime toggle
+	r.Configure(true, "from config")
+	enabled, _ = r.State()
+	assert.False(t, enabled)
+}
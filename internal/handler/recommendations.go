@@ -0,0 +1,412 @@
+package handler
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/week"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// defaultRecommendationPeriod is how far back and ahead the load of the
+	// members is looked at when no period is given.
+	defaultRecommendationPeriod = 90 * 24 * time.Hour
+	// maxRecommendations bounds the recommendations of a single request.
+	maxRecommendations = 10
+	// nightStart and nightEnd are the hours of the day in the tz zone time
+	// on call counts as night time between.
+	nightStart = 22
+	nightEnd   = 6
+)
+
+// RecommendationPin is the recommendation kind moving the shifts of a
+// schedule on a date to another member with a pin.
+const RecommendationPin = "PIN"
+
+// MemberLoad represents the time a member is on call within the period of the
+// recommendations. Load adds the night and weekend hours to the hours, so they
+// count twice, and an hour on a weekend night three times.
+type MemberLoad struct {
+	Member       string  `json:"member"`
+	Hours        float64 `json:"hours"`
+	NightHours   float64 `json:"night_hours"`
+	WeekendHours float64 `json:"weekend_hours"`
+	Load         float64 `json:"load"`
+}
+
+// RecommendationCall represents the request applying a recommendation.
+type RecommendationCall struct {
+	Method string     `json:"method"`
+	Path   string     `json:"path"`
+	Body   PinRequest `json:"body"`
+}
+
+// Recommendation represents a change evening out the load of the members of
+// a team. Projected is the load of the members once the recommendation and
+// the ones before it are applied, ordered by member.
+type Recommendation struct {
+	Kind       string             `json:"kind"`
+	ScheduleID string             `json:"schedule_id"`
+	Schedule   string             `json:"schedule"`
+	Date       string             `json:"date"`
+	From       string             `json:"from"`
+	To         string             `json:"to"`
+	Moved      MemberLoad         `json:"moved"`
+	Apply      RecommendationCall `json:"apply"`
+	Projected  []MemberLoad       `json:"projected"`
+}
+
+// RecommendationsResponse represents the load of the members of a team around
+// now and the recommendations evening it out, in the order to apply them.
+type RecommendationsResponse struct {
+	Team            string           `json:"team"`
+	From            string           `json:"from"`
+	To              string           `json:"to"`
+	Distribution    []MemberLoad     `json:"distribution"`
+	Recommendations []Recommendation `json:"recommendations"`
+}
+
+// occurrence is the shifts of a schedule starting on a date in UTC, which a
+// pin moves to another member as a whole.
+type occurrence struct {
+	schedule storage.Schedule
+	order    int
+	date     time.Time
+	member   string
+	load     load
+}
+
+// load is the time a member is on call, kept as durations until rendered so
+// sums of hours do not pick up rounding errors.
+type load struct {
+	hours, night, weekend time.Duration
+}
+
+func (l load) add(other load) load {
+	return load{hours: l.hours + other.hours, night: l.night + other.night, weekend: l.weekend + other.weekend}
+}
+
+func (l load) sub(other load) load {
+	return load{hours: l.hours - other.hours, night: l.night - other.night, weekend: l.weekend - other.weekend}
+}
+
+// total is the load counting night and weekend time twice.
+func (l load) total() float64 {
+	return (l.hours + l.night + l.weekend).Hours()
+}
+
+func (l load) render(member string) MemberLoad {
+	return MemberLoad{
+		Member:       member,
+		Hours:        l.hours.Hours(),
+		NightHours:   l.night.Hours(),
+		WeekendHours: l.weekend.Hours(),
+		Load:         l.total(),
+	}
+}
+
+// TeamRecommendations handles requests for recommendations evening out the
+// on-call load of the members of a team. The load is counted like the
+// statistics over the period before and after now, 90d by default, with the
+// hours between 22:00 and 06:00 in the tz zone counted as night hours.
+//
+// Each recommendation pins a single future date of a schedule to another of
+// its members, the one that evens out the load the most, until no pin does.
+// Observers and deactivated members are never recommended, and neither are
+// members on call elsewhere in the team during the moved shifts. Nothing is changed,
+// the recommendations are applied with the pins endpoint.
+func (h *Handler) TeamRecommendations(c echo.Context) error {
+	teamName := c.Param("team")
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	period := defaultRecommendationPeriod
+	if value := c.QueryParam("period"); value != "" {
+		if period, err = parsePeriod(value); err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	pause, paused, err := h.storage.GetPause(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get pause of team %q: %w", teamName, err), "failed to retrieve team")
+	}
+	if !paused {
+		pause = storage.Pause{}
+	}
+
+	roster, err := h.storage.ListTeamMembers(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list members of team %q: %w", teamName, err), "failed to retrieve team")
+	}
+	users, err := h.storage.FindUsers(ctx, "")
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list users: %w", err), "failed to retrieve users")
+	}
+
+	// Observers and deactivated members are never put on call
+	excluded := make(map[string]bool)
+	for _, member := range roster {
+		if member.Role == storage.MemberRoleObserver {
+			excluded[member.Name] = true
+		}
+	}
+	for _, user := range users {
+		if !user.Active {
+			excluded[user.UserName] = true
+		}
+	}
+
+	now := h.now().UTC()
+	from, to := now.Add(-period), now.Add(period)
+	wk := h.weeks.Of(teamName)
+
+	loads := make(map[string]load)
+
+	for _, sched := range team.Schedules {
+		if sched.ValidUntil.IsZero() || sched.ValidUntil.After(from) {
+			for _, member := range sched.Members {
+				if !excluded[member] {
+					loads[member] = load{}
+				}
+			}
+		}
+	}
+
+	timeline := storage.DutyTimeline(team.Schedules, from, to)
+	for _, duty := range timeline {
+		for _, member := range duty.Members() {
+			loads[member] = loads[member].add(dutyLoad(duty.Shift, pause, wk, loc))
+		}
+	}
+
+	// Excluded members the rotation still puts on duty carry the load all the same
+	occurrences := movable(team.Schedules, now, to, pause, wk, loc)
+	for _, o := range occurrences {
+		loads[o.member] = loads[o.member].add(load{})
+	}
+
+	resp := RecommendationsResponse{
+		Team:            teamName,
+		From:            from.In(loc).Format(time.RFC3339),
+		To:              to.In(loc).Format(time.RFC3339),
+		Distribution:    sortedLoads(loads),
+		Recommendations: []Recommendation{},
+	}
+
+	for len(resp.Recommendations) < maxRecommendations {
+		best, target, ok := bestMove(occurrences, loads, timeline, excluded)
+		if !ok {
+			break
+		}
+
+		date := best.date.Format(time.DateOnly)
+		resp.Recommendations = append(resp.Recommendations, Recommendation{
+			Kind:       RecommendationPin,
+			ScheduleID: best.schedule.ID,
+			Schedule:   best.schedule.Name,
+			Date:       date,
+			From:       best.member,
+			To:         target,
+			Moved:      best.load.render(best.member),
+			Apply: RecommendationCall{
+				Method: http.MethodPost,
+				Path:   "/schedule/" + best.schedule.ID + "/pins",
+				Body:   PinRequest{Date: date, Member: target},
+			},
+		})
+
+		loads[best.member] = loads[best.member].sub(best.load)
+		loads[target] = loads[target].add(best.load)
+		resp.Recommendations[len(resp.Recommendations)-1].Projected = sortedLoads(loads)
+
+		// A date is moved once, and the moved shifts are on call for the new member
+		occurrences = slices.DeleteFunc(occurrences, func(o occurrence) bool {
+			return o.schedule.ID == best.schedule.ID && o.date.Equal(best.date)
+		})
+		for _, duty := range storage.Duties([]storage.Schedule{best.schedule}, best.date, best.date.AddDate(0, 0, 1)) {
+			if storage.PinDate(duty.Start).Equal(best.date) {
+				duty.Member = target
+				timeline = append(timeline, duty)
+			}
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// parsePeriod parses a period given in days such as 90d or as a duration.
+func parsePeriod(value string) (time.Duration, error) {
+	var (
+		period time.Duration
+		err    error
+	)
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		period = time.Duration(n) * 24 * time.Hour
+	} else {
+		period, err = time.ParseDuration(value)
+	}
+
+	if err != nil || period <= 0 || period > maxStatsRange {
+		return 0, fmt.Errorf("invalid period, use a number of days such as '90d' of at most %d days", int(maxStatsRange.Hours()/24))
+	}
+
+	return period, nil
+}
+
+// movable returns the future occurrences of the schedules between now and
+// to, ordered by date and the position of the schedule in the team. Occurrences already pinned, running, or
+// with the shifts of several members, like split shifts, stay as they are.
+func movable(schedules []storage.Schedule, now, to time.Time, pause storage.Pause, wk week.Week, loc *time.Location) []occurrence {
+	var result []occurrence
+	skipped := make(map[string]bool)
+	key := func(scheduleID string, date time.Time) string {
+		return scheduleID + "/" + date.Format(time.DateOnly)
+	}
+
+	for _, duty := range storage.Duties(schedules, now, to) {
+		date := storage.PinDate(duty.Start)
+		k := key(duty.ScheduleID, date)
+		if skipped[k] {
+			continue
+		}
+
+		i := slices.IndexFunc(result, func(o occurrence) bool {
+			return o.schedule.ID == duty.ScheduleID && o.date.Equal(date)
+		})
+
+		if duty.Pinned || !duty.Start.After(now) || (i >= 0 && result[i].member != duty.Member) {
+			skipped[k] = true
+			if i >= 0 {
+				result = slices.Delete(result, i, i+1)
+			}
+			continue
+		}
+
+		if i < 0 {
+			j := slices.IndexFunc(schedules, func(s storage.Schedule) bool { return s.ID == duty.ScheduleID })
+			result = append(result, occurrence{schedule: schedules[j], order: j, date: date, member: duty.Member})
+			i = len(result) - 1
+		}
+		result[i].load = result[i].load.add(dutyLoad(duty.Shift, pause, wk, loc))
+	}
+
+	slices.SortStableFunc(result, func(a, b occurrence) int {
+		return cmp.Or(a.date.Compare(b.date), cmp.Compare(a.order, b.order))
+	})
+
+	return slices.DeleteFunc(result, func(o occurrence) bool { return o.load.hours == 0 })
+}
+
+// bestMove returns the occurrence and the member to move it to lowering the
+// sum of the squared loads the most, which moving a load x from a member with
+// load a to a member with load b changes by 2x(x+b-a). The earliest date, then
+// the first member by name, wins ties.
+func bestMove(occurrences []occurrence, loads map[string]load, timeline []storage.Duty, excluded map[string]bool) (occurrence, string, bool) {
+	var (
+		best   occurrence
+		target string
+		change = -1e-9
+	)
+
+	for _, o := range occurrences {
+		candidates := slices.Clone(o.schedule.Members)
+		slices.Sort(candidates)
+
+		for _, member := range slices.Compact(candidates) {
+			if member == o.member || excluded[member] || onDuty(timeline, member, o) {
+				continue
+			}
+
+			x := o.load.total()
+			if delta := 2 * x * (x + loads[member].total() - loads[o.member].total()); delta < change {
+				best, target, change = o, member, delta
+			}
+		}
+	}
+
+	return best, target, target != ""
+}
+
+// onDuty reports whether the member is on call during any shift of the
+// occurrence.
+func onDuty(timeline []storage.Duty, member string, o occurrence) bool {
+	for _, shift := range storage.Duties([]storage.Schedule{o.schedule}, o.date, o.date.AddDate(0, 0, 1)) {
+		if !storage.PinDate(shift.Start).Equal(o.date) {
+			continue
+		}
+		for _, duty := range timeline {
+			if duty.Member == member && duty.Start.Before(shift.End) && shift.Start.Before(duty.End) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// dutyLoad returns the load of a stretch on call, the time while the team is
+// paused aside.
+func dutyLoad(shift storage.Shift, pause storage.Pause, wk week.Week, loc *time.Location) load {
+	var result load
+	for _, stretch := range outsidePause(shift, pause) {
+		start, end := stretch.Start.In(loc), stretch.End.In(loc)
+		result = result.add(load{hours: end.Sub(start), night: nightTime(start, end), weekend: wk.WeekendTime(start, end)})
+	}
+
+	return result
+}
+
+// nightTime returns the part of [start, end) between nightStart and nightEnd
+// in the zone of start.
+func nightTime(start, end time.Time) time.Duration {
+	var total time.Duration
+
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location()).AddDate(0, 0, -1)
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		night := day.Add(nightStart * time.Hour)
+		morning := day.AddDate(0, 0, 1).Add(nightEnd * time.Hour)
+		if s, e := later(start, night), earlier(end, morning); s.Before(e) {
+			total += e.Sub(s)
+		}
+	}
+
+	return total
+}
+
+// sortedLoads returns the loads ordered by member.
+func sortedLoads(loads map[string]load) []MemberLoad {
+	result := make([]MemberLoad, 0, len(loads))
+	for member, l := range loads {
+		result = append(result, l.render(member))
+	}
+	slices.SortFunc(result, func(a, b MemberLoad) int {
+		return cmp.Compare(a.Member, b.Member)
+	})
+
+	return result
+}
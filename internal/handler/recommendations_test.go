@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newRecommendationsServer returns a server whose payments team is skewed:
+// Alice and Bob share the weekends Carol is spared, and Alice alone covers
+// the Friday nights.
+func newRecommendationsServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	anchor := time.Date(2026, 2, 2, 0, 0, 0, 0, time.UTC)
+
+	for _, sched := range []storage.Schedule{
+		{
+			Name:    "Days",
+			Members: []string{"Alice", "Bob", "Carol"},
+			Days:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+			Anchor:  anchor,
+			Start:   parseTime(t, "9:00AM"),
+			End:     parseTime(t, "5:00PM"),
+		},
+		{
+			Name:    "Weekend",
+			Members: []string{"Alice", "Bob"},
+			Days:    []time.Weekday{time.Saturday, time.Sunday},
+			Anchor:  anchor,
+			Start:   parseTime(t, "9:00AM"),
+			End:     parseTime(t, "5:00PM"),
+		},
+		{
+			Name:    "Friday Nights",
+			Members: []string{"Alice"},
+			Days:    []time.Weekday{time.Friday},
+			Anchor:  anchor,
+			Start:   parseTime(t, "10:00PM"),
+			End:     parseTime(t, "11:59PM"),
+		},
+	} {
+		_, err := store.AddSchedule(ctx, "payments", sched)
+		require.NoError(t, err)
+	}
+
+	h := New(store, zap.NewNop())
+	h.now = (&fakeClock{now: time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)}).Now
+
+	e := echo.New()
+	e.GET("/teams/:team/recommendations", h.TeamRecommendations)
+
+	return e, store
+}
+
+func TestTeamRecommendations_Golden(t *testing.T) {
+	e, store := newRecommendationsServer(t)
+
+	rec := serveJSON(e, http.MethodGet, "/teams/payments/recommendations?period=21d", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var got bytes.Buffer
+	require.NoError(t, json.Indent(&got, rec.Body.Bytes(), "", "  "))
+	got.WriteByte('\n')
+
+	assertGolden(t, "recommendations.json", numberSchedules(t, store, "payments", got.Bytes()))
+
+	// The output only depends on the schedules and the clock
+	again := serveJSON(e, http.MethodGet, "/teams/payments/recommendations?period=21d", nil, "")
+	assert.Equal(t, rec.Body.String(), again.Body.String())
+}
+
+func TestTeamRecommendations_EvensOutLoad(t *testing.T) {
+	e, _ := newRecommendationsServer(t)
+
+	rec := serveJSON(e, http.MethodGet, "/teams/payments/recommendations", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp RecommendationsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.Recommendations)
+
+	spread := func(loads []MemberLoad) float64 {
+		lowest, highest := loads[0].Load, loads[0].Load
+		for _, load := range loads {
+			lowest, highest = min(lowest, load.Load), max(highest, load.Load)
+		}
+		return highest - lowest
+	}
+
+	previous := spread(resp.Distribution)
+	for _, r := range resp.Recommendations {
+		assert.Equal(t, RecommendationPin, r.Kind)
+		assert.Equal(t, "/schedule/"+r.ScheduleID+"/pins", r.Apply.Path)
+		assert.Equal(t, PinRequest{Date: r.Date, Member: r.To}, r.Apply.Body)
+		assert.Greater(t, r.Date, "2026-03-02")
+		assert.LessOrEqual(t, spread(r.Projected), previous)
+		previous = spread(r.Projected)
+	}
+	assert.Less(t, previous, spread(resp.Distribution))
+}
+
+func TestTeamRecommendations_InvalidRequests(t *testing.T) {
+	e, _ := newRecommendationsServer(t)
+
+	for _, query := range []string{"period=0d", "period=400d", "period=soon", "tz=Nowhere/City"} {
+		rec := serveJSON(e, http.MethodGet, "/teams/payments/recommendations?"+query, nil, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+
+	rec := serveJSON(e, http.MethodGet, "/teams/unknown/recommendations", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
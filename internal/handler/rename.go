@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// RenameRequest represents the member rename request.
+type RenameRequest struct {
+	Name string `json:"name"`
+}
+
+// RenameResponse represents a renamed member.
+type RenameResponse struct {
+	Name         string `json:"name"`
+	PreviousName string `json:"previous_name"`
+}
+
+// RenameMember handles requests renaming a user or member everywhere they
+// are referenced, e.g. after a change of username. The schedule history
+// keeps the former name, which resolves to the new one from then on.
+func (h *Handler) RenameMember(c echo.Context) error {
+	from := c.Param("name")
+
+	var req RenameRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	to := strings.TrimSpace(req.Name)
+	switch {
+	case to == "":
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "name is required"})
+	case len(to) > maxTeamMemberNameLength:
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("name must be at most %d bytes", maxTeamMemberNameLength)})
+	case strings.HasPrefix(to, "@"):
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "name must not start with @"})
+	case to == from:
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "name must differ from the current one"})
+	}
+
+	ctx := c.Request().Context()
+
+	renamed, err := h.storage.RenameMember(ctx, from, to)
+	if errors.Is(err, storage.ErrMemberExists) {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("member %s already exists", to)})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("rename member %q to %q: %w", from, to, err), "failed to rename member")
+	}
+
+	if !renamed {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "member not found"})
+	}
+
+	h.logger.Info("member renamed",
+		zap.String("from", from),
+		zap.String("to", to),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	return c.JSON(http.StatusOK, RenameResponse{Name: to, PreviousName: from})
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newRenameServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.GET("/teams/:team/timeline", h.TeamTimeline)
+	e.POST("/users/:name/rename", h.RenameMember, h.Authenticate(auth.RoleAdmin, "secret"))
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e
+}
+
+func TestRenameMember(t *testing.T) {
+	e := newRenameServer(t)
+
+	// The schedule rotates weekly between Alice and Bob
+	monday := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	first, second := oncallAt(t, e, monday), oncallAt(t, e, monday.AddDate(0, 0, 7))
+	assert.ElementsMatch(t, []string{"Alice", "Bob"}, []string{first, second})
+
+	rec := serveJSON(e, http.MethodPost, "/users/Alice/rename", RenameRequest{Name: "Alicia"}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp RenameResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, RenameResponse{Name: "Alicia", PreviousName: "Alice"}, resp)
+
+	rename := func(member string) string {
+		if member == "Alice" {
+			return "Alicia"
+		}
+		return member
+	}
+	assert.Equal(t, rename(first), oncallAt(t, e, monday))
+	assert.Equal(t, rename(second), oncallAt(t, e, monday.AddDate(0, 0, 7)))
+
+	// The history recorded before the rename answers with the new name
+	from := time.Now().UTC()
+	query := url.Values{
+		"from":          {from.Format(time.RFC3339Nano)},
+		"to":            {from.Add(14 * 24 * time.Hour).Format(time.RFC3339)},
+		"as_configured": {"true"},
+	}
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team/timeline?"+query.Encode(), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var timeline TimelineResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &timeline))
+	require.Len(t, timeline.Lanes, 1)
+
+	members := make(map[string]bool)
+	for _, segment := range timeline.Lanes[0].Segments {
+		members[segment.Member] = true
+	}
+	assert.Equal(t, map[string]bool{"Alicia": true, "Bob": true}, members)
+}
+
+func TestRenameMember_Errors(t *testing.T) {
+	e := newRenameServer(t)
+
+	tests := []struct {
+		name   string
+		target string
+		req    RenameRequest
+		token  string
+		code   int
+	}{
+		{"no name", "/users/Alice/rename", RenameRequest{}, "secret", http.StatusBadRequest},
+		{"same name", "/users/Alice/rename", RenameRequest{Name: "Alice"}, "secret", http.StatusBadRequest},
+		{"group reference", "/users/Alice/rename", RenameRequest{Name: "@group:storage"}, "secret", http.StatusBadRequest},
+		{"taken", "/users/Alice/rename", RenameRequest{Name: "Bob"}, "secret", http.StatusConflict},
+		{"unknown member", "/users/Nobody/rename", RenameRequest{Name: "Zed"}, "secret", http.StatusNotFound},
+		{"no token", "/users/Alice/rename", RenameRequest{Name: "Alicia"}, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPost, tt.target, tt.req, tt.token)
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newRequiredCountServer creates a weekday schedule of Alice, Bob and
+// Charlie anchored on Monday 2026-03-02 putting two of them on duty for
+// every shift.
+func newRequiredCountServer(t *testing.T) (*echo.Echo, *Handler, storage.Storage) {
+	t.Helper()
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.GET("/teams/:team/timeline", h.TeamTimeline)
+	e.GET("/teams/:team/stats", h.TeamStats)
+	e.GET("/teams/:team/handoffs", h.TeamHandoffs)
+
+	req := quotaRequest("backend-team")
+	req.Members = []string{"Alice", "Bob", "Charlie"}
+	req.Anchor = "2026-03-02"
+	req.RequiredCount = 2
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, h, store
+}
+
+func TestRequiredCount_Oncall(t *testing.T) {
+	e, _, _ := newRequiredCountServer(t)
+
+	// The pair moves on by one member every week, across several cycles
+	want := [][]string{
+		{"Alice", "Bob"},
+		{"Bob", "Charlie"},
+		{"Charlie", "Alice"},
+		{"Alice", "Bob"},
+		{"Bob", "Charlie"},
+		{"Charlie", "Alice"},
+		{"Alice", "Bob"},
+	}
+	for week, pair := range want {
+		at := time.Date(2026, 3, 2+7*week, 10, 0, 0, 0, time.UTC)
+
+		rec := serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time="+at.Format(time.RFC3339), nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp OncallResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, pair[0], resp.Oncall, at.String())
+		assert.Equal(t, pair, resp.Oncalls, at.String())
+	}
+
+	// Single member schedules keep answering with oncall alone
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("frontend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=frontend-team&time=2026-03-02T10:00:00Z", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "oncalls")
+}
+
+func TestRequiredCount_Fairness(t *testing.T) {
+	e, _, _ := newRequiredCountServer(t)
+
+	// Over a full cycle every member is on call for two weeks of 40 hours
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/stats?from=2026-03-02&to=2026-03-23", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp StatsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Weeks, 3)
+
+	hours := make(map[string]float64)
+	for _, week := range resp.Weeks {
+		require.Len(t, week.Members, 2)
+		for _, stats := range week.Members {
+			hours[stats.Member] += stats.Hours
+		}
+	}
+	assert.Equal(t, map[string]float64{"Alice": 80, "Bob": 80, "Charlie": 80}, hours)
+}
+
+func TestRequiredCount_TimelineAndHandoffs(t *testing.T) {
+	e, _, store := newRequiredCountServer(t)
+
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/timeline?from=2026-03-06&to=2026-03-10", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var timeline TimelineResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &timeline))
+	require.Len(t, timeline.Lanes, 1)
+	assert.Equal(t, []TimelineSegment{
+		{Member: "Alice", Members: []string{"Alice", "Bob"}, Start: "2026-03-06T09:00:00Z", End: "2026-03-06T17:00:00Z"},
+		{Member: "Bob", Members: []string{"Bob", "Charlie"}, Start: "2026-03-09T09:00:00Z", End: "2026-03-09T17:00:00Z"},
+	}, timeline.Lanes[0].Segments)
+
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team/handoffs?from=2026-03-06T10:00:00Z&count=2", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var handoffs TeamHandoffsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &handoffs))
+	assert.Equal(t, []TeamHandoff{
+		{At: "2026-03-09T09:00:00Z", Schedule: "Weekday", ScheduleID: id, FromMember: "Alice", ToMember: "Bob", ToMembers: []string{"Bob", "Charlie"}},
+		{At: "2026-03-16T09:00:00Z", Schedule: "Weekday", ScheduleID: id, FromMember: "Bob", ToMember: "Charlie", ToMembers: []string{"Charlie", "Alice"}},
+	}, handoffs.Handoffs)
+}
+
+func TestRequiredCount_CalendarRoundTrip(t *testing.T) {
+	_, h, store := newRequiredCountServer(t)
+
+	team, err := store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+
+	var ics bytes.Buffer
+	require.NoError(t, writeCalendar(&ics, "backend-team", team.Schedules, "", time.Now()))
+	assert.Contains(t, ics.String(), icsRequiredCountProperty+":2")
+
+	rec, resp := importCalendar(t, h, "/schedules/import/ics?team=imported-team", ics.String())
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, resp.Imported, 1)
+
+	imported, err := store.GetTeam(context.Background(), "imported-team")
+	require.NoError(t, err)
+	require.Len(t, imported.Schedules, 1)
+	assert.Equal(t, 2, imported.Schedules[0].RequiredCount)
+}
+
+func TestRequiredCount_Grafana(t *testing.T) {
+	_, _, store := newRequiredCountServer(t)
+
+	team, err := store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+
+	// Every turn of the rotation is a group of the members on duty together
+	result := grafanaSchedule("backend-team", team.Schedules, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.Len(t, result.Shifts, 1)
+	assert.Equal(t, [][]string{{"Alice", "Bob"}, {"Bob", "Charlie"}, {"Charlie", "Alice"}}, result.Shifts[0].RollingUsers)
+}
+
+func TestRequiredCount_Validation(t *testing.T) {
+	e, _, _ := newRequiredCountServer(t)
+
+	tests := []struct {
+		name string
+		req  func(*Request)
+	}{
+		{"negative", func(r *Request) { r.RequiredCount = -1 }},
+		{"more than members", func(r *Request) { r.RequiredCount = 3 }},
+		{"fixed assignment", func(r *Request) {
+			r.Members = nil
+			r.Assignment = AssignmentFixed
+			r.DayAssignments = map[string]string{"Monday": "Alice"}
+			r.Days = nil
+			r.RequiredCount = 2
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := quotaRequest("frontend-team")
+			tt.req(&req)
+			rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		})
+	}
+
+	// As many as there are members is fine
+	req := quotaRequest("frontend-team")
+	req.RequiredCount = 2
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// RotationRequest represents the rotation mode change request.
+type RotationRequest struct {
+	// Rotation is either RotationAutomatic or RotationManual.
+	Rotation string `json:"rotation"`
+}
+
+// AdvanceRequest represents the manual handoff request.
+type AdvanceRequest struct {
+	// Member takes over, the next active member in the rotation when empty.
+	Member string `json:"member,omitempty"`
+}
+
+// RotationResponse represents the rotation of a schedule. Oncall is the
+// member the rotation puts on duty now, pins aside, empty when all of its
+// members are deactivated.
+type RotationResponse struct {
+	ScheduleID string `json:"schedule_id"`
+	Team       string `json:"team"`
+	Schedule   string `json:"schedule"`
+	Rotation   string `json:"rotation"`
+	Anchor     string `json:"anchor,omitempty"`
+	Oncall     string `json:"oncall,omitempty"`
+}
+
+// SetRotation handles requests switching a schedule between automatic and
+// manual rotation. Whoever the rotation puts on duty at the switch stays on
+// duty: a manual schedule keeps them until it is advanced, and an automatic
+// one starts over from midnight UTC of the switch day as its new anchor with
+// them on duty for the first week, or until the first handoff for schedules
+// with one.
+func (h *Handler) SetRotation(c echo.Context) error {
+	var req RotationRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	if req.Rotation != RotationAutomatic && req.Rotation != RotationManual {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("rotation must be %s or %s", RotationAutomatic, RotationManual),
+		})
+	}
+
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	if len(sched.Schedule.DayAssignments) > 0 {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "schedules with fixed assignment do not rotate"})
+	}
+
+	manual := req.Rotation == RotationManual
+	if sched.Schedule.Manual == manual {
+		return c.JSON(http.StatusOK, h.newRotationResponse(sched))
+	}
+
+	if frozen, err := h.rejectFrozen(c, sched.Team, fmt.Sprintf("set rotation of schedule %s to %s", sched.Schedule.Name, req.Rotation)); frozen {
+		return err
+	}
+
+	now := h.now()
+	rotation := sched.Schedule.Rotation()
+	rotation.Manual = manual
+	index, ok := sched.Schedule.RotationIndex(now)
+	if ok {
+		rotation.Offset = index
+	}
+	if !manual {
+		rotation.Anchor = storage.PinDate(now)
+
+		// With a handoff the first week starts at the first handoff of the
+		// switch day, the member keeps their duty until then
+		switched := sched.Schedule
+		switched.Manual, switched.Anchor, switched.RotationOffset = false, rotation.Anchor, 0
+		if turns := switched.Turns(now); ok {
+			n := len(turns)
+			rotation.Offset = ((slices.Index(turns, index)-switched.RotationPeriods(now))%n + n) % n
+		}
+	}
+
+	return h.setRotation(c, sched, rotation)
+}
+
+// AdvanceRotation handles manual handoff requests of manual schedules,
+// putting the given member, or the next one in the rotation, on duty until
+// the schedule is advanced again.
+func (h *Handler) AdvanceRotation(c echo.Context) error {
+	var req AdvanceRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	if !sched.Schedule.Manual {
+		return c.JSON(http.StatusConflict, ErrorResponse{
+			Error: "schedule rotates automatically, set its rotation to manual to advance it",
+		})
+	}
+
+	members := sched.Schedule.Members
+	current, ok := sched.Schedule.RotationIndex(h.now())
+	if !ok {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "all members of the schedule are deactivated"})
+	}
+
+	next := current
+	if member := strings.TrimSpace(req.Member); member != "" {
+		next = slices.Index(members, member)
+		if next == -1 {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("%s is not a member of the schedule", member)})
+		}
+		if slices.Contains(sched.Schedule.Inactive, member) {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("%s is deactivated", member)})
+		}
+	} else {
+		for i := 1; i <= len(members); i++ {
+			if index := (current + i) % len(members); !slices.Contains(sched.Schedule.Inactive, members[index]) {
+				next = index
+				break
+			}
+		}
+	}
+
+	if frozen, err := h.rejectFrozen(c, sched.Team, fmt.Sprintf("hand schedule %s over to %s", sched.Schedule.Name, members[next])); frozen {
+		return err
+	}
+
+	rotation := sched.Schedule.Rotation()
+	rotation.Offset = next
+
+	return h.setRotation(c, sched, rotation)
+}
+
+// setRotation stores the rotation of the schedule and responds with it.
+func (h *Handler) setRotation(c echo.Context, sched storage.TeamSchedule, rotation storage.Rotation) error {
+	ctx := c.Request().Context()
+
+	found, err := h.storage.SetRotation(ctx, sched.Team, sched.Schedule.ID, rotation)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("set rotation of schedule %q of team %q: %w", sched.Schedule.ID, sched.Team, err), "failed to set rotation")
+	}
+	// The schedule may have been deleted in the meantime
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+
+	sched.Schedule.Manual = rotation.Manual
+	sched.Schedule.Anchor = rotation.Anchor
+	sched.Schedule.RotationOffset = rotation.Offset
+	resp := h.newRotationResponse(sched)
+
+	h.logger.Info("rotation set",
+		zap.String("team", sched.Team),
+		zap.String("schedule", sched.Schedule.Name),
+		zap.String("rotation", resp.Rotation),
+		zap.String("oncall", resp.Oncall),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// newRotationResponse converts the rotation of a schedule.
+func (h *Handler) newRotationResponse(sched storage.TeamSchedule) RotationResponse {
+	resp := RotationResponse{
+		ScheduleID: sched.Schedule.ID,
+		Team:       sched.Team,
+		Schedule:   sched.Schedule.Name,
+		Rotation:   RotationAutomatic,
+	}
+
+	if sched.Schedule.Manual {
+		resp.Rotation = RotationManual
+	}
+	if !sched.Schedule.Anchor.IsZero() {
+		resp.Anchor = sched.Schedule.Anchor.UTC().Format(time.DateOnly)
+	}
+	if index, ok := sched.Schedule.RotationIndex(h.now()); ok {
+		resp.Oncall = sched.Schedule.Members[index]
+	}
+
+	return resp
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newRotationServer creates a weekday schedule of Alice, Bob and Charlie
+// anchored on Monday 2026-03-02 and returns its rotation target.
+func newRotationServer(t *testing.T, rotation string) (*echo.Echo, storage.Storage, *fakeClock, string) {
+	t.Helper()
+
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	h.now = clock.Now
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.PUT("/schedule/:id/rotation", h.SetRotation)
+	e.POST("/schedule/:id/advance", h.AdvanceRotation)
+
+	req := quotaRequest("backend-team")
+	req.Members = []string{"Alice", "Bob", "Charlie"}
+	req.Anchor = "2026-03-02"
+	req.Rotation = rotation
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+
+	return e, store, clock, "/schedule/" + team.Schedules[0].ID
+}
+
+// oncallAt returns the member of the backend team on call at the instant.
+func oncallAt(t *testing.T, e *echo.Echo, at time.Time) string {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time="+at.Format(time.RFC3339), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp OncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp.Oncall
+}
+
+// setRotation sends a rotation request and decodes its response.
+func setRotation(t *testing.T, e *echo.Echo, method, target string, body any) RotationResponse {
+	t.Helper()
+
+	rec := serveJSON(e, method, target, body, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp RotationResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestRotation_Manual(t *testing.T) {
+	e, store, _, target := newRotationServer(t, RotationManual)
+
+	monday := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	// The first member stays on call however much time passes
+	for _, weeks := range []int{0, 1, 5} {
+		assert.Equal(t, "Alice", oncallAt(t, e, monday.AddDate(0, 0, 7*weeks)))
+	}
+
+	resp := setRotation(t, e, http.MethodPost, target+"/advance", nil)
+	assert.Equal(t, RotationManual, resp.Rotation)
+	assert.Equal(t, "Bob", resp.Oncall)
+	assert.Equal(t, "Bob", oncallAt(t, e, monday.AddDate(0, 0, 21)))
+
+	resp = setRotation(t, e, http.MethodPost, target+"/advance", AdvanceRequest{Member: "Alice"})
+	assert.Equal(t, "Alice", resp.Oncall)
+
+	// Handoffs are recorded in the audit log
+	entries, err := store.AuditLog(t.Context(), "backend-team")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, storage.AuditSetRotation, entries[0].Action)
+	assert.Equal(t, "Weekday: manual, Bob on duty", entries[0].Detail)
+	assert.Equal(t, "Weekday: manual, Alice on duty", entries[1].Detail)
+
+	rec := serveJSON(e, http.MethodPost, target+"/advance", AdvanceRequest{Member: "Dave"}, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = serveJSON(e, http.MethodPost, "/schedule/999/advance", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestRotation_Advance_Automatic(t *testing.T) {
+	e, _, _, target := newRotationServer(t, "")
+
+	rec := serveJSON(e, http.MethodPost, target+"/advance", nil, "")
+	assert.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+}
+
+func TestRotation_SwitchToAutomatic(t *testing.T) {
+	e, _, clock, target := newRotationServer(t, RotationManual)
+
+	setRotation(t, e, http.MethodPost, target+"/advance", AdvanceRequest{Member: "Charlie"})
+
+	// Switching on Wednesday 2026-03-25 anchors the rotation on that day,
+	// with the member on duty at the switch keeping the first week
+	clock.now = time.Date(2026, 3, 25, 15, 0, 0, 0, time.UTC)
+	resp := setRotation(t, e, http.MethodPut, target+"/rotation", RotationRequest{Rotation: RotationAutomatic})
+	assert.Equal(t, RotationAutomatic, resp.Rotation)
+	assert.Equal(t, "2026-03-25", resp.Anchor)
+	assert.Equal(t, "Charlie", resp.Oncall)
+
+	assert.Equal(t, "Charlie", oncallAt(t, e, time.Date(2026, 3, 31, 10, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "Alice", oncallAt(t, e, time.Date(2026, 4, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "Bob", oncallAt(t, e, time.Date(2026, 4, 8, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestRotation_SwitchToManual(t *testing.T) {
+	e, _, clock, target := newRotationServer(t, "")
+
+	// Bob is on duty during the second week of the rotation and keeps it
+	clock.now = time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	resp := setRotation(t, e, http.MethodPut, target+"/rotation", RotationRequest{Rotation: RotationManual})
+	assert.Equal(t, RotationManual, resp.Rotation)
+	assert.Equal(t, "Bob", resp.Oncall)
+
+	assert.Equal(t, "Bob", oncallAt(t, e, time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC)))
+
+	// Setting the same mode again changes nothing
+	resp = setRotation(t, e, http.MethodPut, target+"/rotation", RotationRequest{Rotation: RotationManual})
+	assert.Equal(t, "Bob", resp.Oncall)
+
+	rec := serveJSON(e, http.MethodPut, target+"/rotation", RotationRequest{Rotation: "weekly"}, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRotation_Create_Validation(t *testing.T) {
+	e, _, _, _ := newRotationServer(t, "")
+
+	req := quotaRequest("backend-team")
+	req.Rotation = "weekly"
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	fixed := Request{
+		Team:           "backend-team",
+		Assignment:     AssignmentFixed,
+		DayAssignments: map[string]string{"Monday": "Alice"},
+		Rotation:       RotationManual,
+		Start:          "9:00AM",
+		End:            "5:00PM",
+	}
+	rec = serveJSON(e, http.MethodPost, "/schedule", fixed, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+)
+
+// MaxRoutingValueLength bounds the values of the routing of a schedule, in
+// characters.
+const MaxRoutingValueLength = 255
+
+// SetRoutingKeys sets the keys schedules may set in their routing.
+func (h *Handler) SetRoutingKeys(keys []string) {
+	h.routingKeys = keys
+}
+
+// validateRouting checks the routing of a schedule only uses allowed keys,
+// with values that are not blank.
+func (h *Handler) validateRouting(routing map[string]string) error {
+	keys := make([]string, 0, len(routing))
+	for key := range routing {
+		keys = append(keys, key)
+	}
+	// Report the same key first whatever order the map iterates in
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if !slices.Contains(h.routingKeys, key) {
+			return fmt.Errorf("routing key %q is not allowed, use one of: %s", key, strings.Join(h.routingKeys, ", "))
+		}
+
+		value := routing[key]
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("routing %s must not be empty", key)
+		}
+		if utf8.RuneCountInString(value) > MaxRoutingValueLength {
+			return fmt.Errorf("routing %s must be at most %d characters", key, MaxRoutingValueLength)
+		}
+	}
+
+	return nil
+}
+
+// scheduleRouting returns the routing of the schedule the lookup answers
+// from at the given instant, nil when there is none.
+func scheduleRouting(schedules []storage.Schedule, at time.Time) map[string]string {
+	sched, ok := storage.ScheduleAt(schedules, at)
+	if !ok {
+		return nil
+	}
+
+	return sched.Routing
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRouting_Lookup(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+
+	req := quotaRequest("backend-team")
+	req.Routing = map[string]string{"pagerduty_service_id": "PXXXXXX", "slack_channel": "#backend"}
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=2025-04-28T10:00:00Z", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp OncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, req.Routing, resp.Routing)
+
+	// Schedules without routing leave it out
+	req = quotaRequest("frontend-team")
+	rec = serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=frontend-team&time=2025-04-28T10:00:00Z", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "routing")
+}
+
+func TestRouting_Validation(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.SetRoutingKeys([]string{"opsgenie_team", "slack_channel"})
+	e.POST("/schedule", h.CreateSchedule)
+
+	req := quotaRequest("backend-team")
+	req.Routing = map[string]string{"pagerduty_service_id": "PXXXXXX"}
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, `routing key "pagerduty_service_id" is not allowed, use one of: opsgenie_team, slack_channel`, resp.Error)
+
+	req.Routing = map[string]string{"slack_channel": "  "}
+	rec = serveJSON(e, http.MethodPost, "/schedule", req, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req.Routing = map[string]string{"opsgenie_team": "backend"}
+	rec = serveJSON(e, http.MethodPost, "/schedule", req, "")
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+}
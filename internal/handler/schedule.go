@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// SchedulePatch represents a partial update of a schedule, the fields left
+// out are kept as they are. The fields are written like in Request, and an
+// empty description, notes or tags clears them.
+type SchedulePatch struct {
+	Name        *string   `json:"name"`
+	Description *string   `json:"description"`
+	Notes       *string   `json:"notes"`
+	Members     *[]string `json:"members"`
+	Days        *[]string `json:"days"`
+	Start       *string   `json:"start"`
+	End         *string   `json:"end"`
+	Tags        *[]string `json:"tags"`
+}
+
+// UpdateScheduleResponse represents an accepted schedule update, the schedule
+// as it is now along with the warnings of the advisory checks.
+type UpdateScheduleResponse struct {
+	Request
+	Warnings []Warning `json:"warnings"`
+}
+
+// GetScheduleByID handles requests for a single schedule, in the request
+// format like the schedules of GetTeamSchedules.
+func (h *Handler) GetScheduleByID(c echo.Context) error {
+	id := c.Param("id")
+
+	sched, err := h.storage.GetSchedule(c.Request().Context(), id)
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", id, err), "failed to retrieve schedule")
+	}
+
+	return c.JSON(http.StatusOK, listedSchedule(sched.Team, sched.Schedule))
+}
+
+// UpdateSchedule handles requests changing some fields of a schedule. The
+// schedule with the fields changed is validated like on creation, and only
+// the changed fields are written, so concurrent updates of the others are
+// kept.
+func (h *Handler) UpdateSchedule(c echo.Context) error {
+	id := c.Param("id")
+
+	var patch SchedulePatch
+	if err := c.Bind(&patch); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, id)
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", id, err), "failed to update schedule")
+	}
+
+	// Team members are resolved on reads, so an empty list would pass for them
+	if patch.Members != nil && len(*patch.Members) == 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "at least one member is required"})
+	}
+
+	req := scheduleRequest(sched.Team, sched.Schedule)
+	if patch.Name != nil {
+		req.Name = *patch.Name
+	}
+	if patch.Description != nil {
+		req.Description = *patch.Description
+	}
+	if patch.Notes != nil {
+		req.Notes = *patch.Notes
+	}
+	if patch.Members != nil {
+		req.Members = *patch.Members
+		// The offset is kept, it wraps around fewer members
+		req.RotationOffset %= len(req.Members)
+	}
+	if patch.Days != nil {
+		req.Days = *patch.Days
+	}
+	if patch.Start != nil {
+		req.Start = *patch.Start
+	}
+	if patch.End != nil {
+		req.End = *patch.End
+	}
+	if patch.Tags != nil {
+		req.Tags = *patch.Tags
+	}
+
+	schedule, err := h.parseRequest(&req)
+	if err != nil {
+		h.logger.Warn("invalid request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	if frozen, err := h.rejectFrozen(c, sched.Team, "update schedule "+sched.Schedule.Name); frozen {
+		return err
+	}
+
+	var update storage.ScheduleUpdate
+	if patch.Name != nil {
+		update.Name = &schedule.Name
+	}
+	if patch.Description != nil {
+		update.Description = &schedule.Description
+	}
+	if patch.Notes != nil {
+		update.Notes = &schedule.Notes
+	}
+	if patch.Members != nil {
+		observer, found, err := h.observerIn(ctx, sched.Team, schedule.Members)
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("list members of team %q: %w", sched.Team, err), "failed to update schedule")
+		}
+		if found {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: observerMessage(observer, sched.Team)})
+		}
+
+		group, found, err := h.unknownGroup(ctx, sched.Team, schedule.MemberRefs)
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("list groups of team %q: %w", sched.Team, err), "failed to update schedule")
+		}
+		if found {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: unknownGroupMessage(group, sched.Team)})
+		}
+
+		update.Members, update.MemberRefs = schedule.Members, schedule.MemberRefs
+		if schedule.RotationOffset != sched.Schedule.RotationOffset {
+			update.RotationOffset = &schedule.RotationOffset
+		}
+	}
+	if patch.Days != nil {
+		update.Days, update.DayAssignments = schedule.Days, schedule.DayAssignments
+	}
+	if patch.Start != nil {
+		update.Start = &schedule.Start
+	}
+	if patch.End != nil {
+		update.End = &schedule.End
+	}
+	if patch.Tags != nil {
+		// Non-nil even when empty, so the tags are cleared
+		update.Tags = append([]string{}, schedule.Tags...)
+	}
+
+	updated, err := h.storage.UpdateScheduleFields(ctx, sched.Team, id, update)
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if errors.Is(err, storage.ErrScheduleTimes) {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: err.Error()})
+	}
+	if errors.Is(err, storage.ErrScheduleExists) {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "schedule already exists"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("update schedule %q of team %q: %w", id, sched.Team, err), "failed to update schedule")
+	}
+
+	// The schedule is left out of the checks, so it does not overlap itself
+	warnings, err := h.adviseSchedule(ctx, sched.Team, updated, id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("check schedule %q of team %q: %w", id, sched.Team, err), "failed to update schedule")
+	}
+
+	h.logger.Info("schedule updated",
+		zap.String("id", id),
+		zap.String("team", sched.Team),
+		zap.String("name", updated.Name),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	event := notify.NewEvent(notify.KindScheduleChange, sched.Team, time.Now())
+	event.Schedule = updated.Name
+	event.Change = notify.ChangeUpdated
+	h.events.Publish(event)
+
+	return c.JSON(http.StatusOK, UpdateScheduleResponse{Request: listedSchedule(sched.Team, updated), Warnings: warnings})
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/diff"
+	"github.com/labstack/echo/v4"
+)
+
+// ScheduleVersionRef identifies a version of a schedule by its number, the
+// first version being the one the schedule was added with, along with when
+// it took effect.
+type ScheduleVersionRef struct {
+	Version int    `json:"version"`
+	Since   string `json:"since"`
+}
+
+// ScheduleDiffResponse represents the changes between two versions of a
+// schedule.
+type ScheduleDiffResponse struct {
+	ScheduleID string             `json:"schedule_id"`
+	From       ScheduleVersionRef `json:"from"`
+	To         ScheduleVersionRef `json:"to"`
+	Changes    []diff.Change      `json:"changes"`
+}
+
+// ScheduleDiff handles schedule diff requests. It compares the from and to
+// versions of the schedule, numbered from 1 in the order they were recorded,
+// and returns the changes from one to the other. Either may be the later
+// one.
+func (h *Handler) ScheduleDiff(c echo.Context) error {
+	from, err := parseVersion(c.QueryParam("from"), "from")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	to, err := parseVersion(c.QueryParam("to"), "to")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	id := c.Param("id")
+
+	versions, found, err := h.storage.ScheduleVersions(c.Request().Context(), id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get versions of schedule %q: %w", id, err), "failed to retrieve schedule")
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+
+	for _, version := range []int{from, to} {
+		if version > len(versions) {
+			return c.JSON(http.StatusNotFound, ErrorResponse{
+				Error: fmt.Sprintf("version %d not found, the schedule has %d", version, len(versions)),
+			})
+		}
+	}
+
+	older, newer := versions[from-1], versions[to-1]
+
+	resp := ScheduleDiffResponse{
+		ScheduleID: id,
+		From:       ScheduleVersionRef{Version: from, Since: older.Since.UTC().Format(time.RFC3339)},
+		To:         ScheduleVersionRef{Version: to, Since: newer.Since.UTC().Format(time.RFC3339)},
+		Changes:    diff.Schedules(older.Schedule, newer.Schedule),
+	}
+	if resp.Changes == nil {
+		resp.Changes = []diff.Change{}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// parseVersion parses a version number of a schedule.
+func parseVersion(value, name string) (int, error) {
+	if value == "" {
+		return 0, fmt.Errorf("%s query parameter is required", name)
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid %s version, use a number starting at 1", name)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/diff"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func getScheduleDiff(t *testing.T, h *Handler, id, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/schedule/"+id+"/diff?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+
+	require.NoError(t, h.ScheduleDiff(c))
+
+	return rec
+}
+
+// newDiffHandler adds a schedule and records two more versions of it by
+// setting its rotation.
+func newDiffHandler(t *testing.T) (*Handler, string) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+	anchor := time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+
+	_, err := store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice", "Bob", "Carol"},
+		Days:    []time.Weekday{time.Monday},
+		Anchor:  anchor,
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	team, err := store.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+
+	for _, offset := range []int{1, 2} {
+		found, err := store.SetRotation(ctx, "backend-team", id, storage.Rotation{Manual: true, Anchor: anchor, Offset: offset})
+		require.NoError(t, err)
+		require.True(t, found)
+	}
+
+	return New(store, zap.NewNop()), id
+}
+
+func TestScheduleDiff(t *testing.T) {
+	h, id := newDiffHandler(t)
+
+	rec := getScheduleDiff(t, h, id, "from=1&to=3")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp ScheduleDiffResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, id, resp.ScheduleID)
+	assert.Equal(t, 1, resp.From.Version)
+	assert.Equal(t, 3, resp.To.Version)
+	assert.NotEmpty(t, resp.From.Since)
+
+	var summaries []string
+	for _, change := range resp.Changes {
+		summaries = append(summaries, change.Summary)
+	}
+	assert.Equal(t, []string{"rotation_offset changed from 0 to 2", "manual changed from false to true"}, summaries)
+
+	// Versions may be compared backwards
+	rec = getScheduleDiff(t, h, id, "from=3&to=2")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Changes, 1)
+	assert.Equal(t, diff.Change{Field: "rotation_offset", Kind: diff.KindChanged, Old: float64(2), New: float64(1), Summary: "rotation_offset changed from 2 to 1"}, resp.Changes[0])
+
+	// Identical versions have no changes
+	rec = getScheduleDiff(t, h, id, "from=2&to=2")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"changes":[]`)
+}
+
+func TestScheduleDiff_InvalidRequests(t *testing.T) {
+	h, id := newDiffHandler(t)
+
+	tests := []struct {
+		name  string
+		id    string
+		query string
+		code  int
+		err   string
+	}{
+		{"missing from", id, "to=2", http.StatusBadRequest, "from query parameter is required"},
+		{"missing to", id, "from=1", http.StatusBadRequest, "to query parameter is required"},
+		{"zero version", id, "from=0&to=2", http.StatusBadRequest, "invalid from version, use a number starting at 1"},
+		{"not a number", id, "from=1&to=latest", http.StatusBadRequest, "invalid to version, use a number starting at 1"},
+		{"unknown version", id, "from=1&to=4", http.StatusNotFound, "version 4 not found, the schedule has 3"},
+		{"unknown schedule", "404", "from=1&to=2", http.StatusNotFound, "schedule not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getScheduleDiff(t, h, tt.id, tt.query)
+			assert.Equal(t, tt.code, rec.Code)
+
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.err, resp.Error)
+		})
+	}
+}
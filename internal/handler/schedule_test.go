@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newScheduleServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	e := echo.New()
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule/:id", h.GetScheduleByID)
+	e.PATCH("/schedule/:id", h.UpdateSchedule)
+
+	return e, store
+}
+
+// createSchedule creates the schedule and returns the Location of it.
+func createSchedule(t *testing.T, e *echo.Echo, req Request) string {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return rec.Header().Get(echo.HeaderLocation)
+}
+
+func TestGetScheduleByID(t *testing.T) {
+	e, _ := newScheduleServer(t)
+
+	createSchedule(t, e, quotaRequest("frontend-team"))
+	location := createSchedule(t, e, quotaRequest("backend-team"))
+
+	rec := serveJSON(e, http.MethodGet, location, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var sched Request
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sched))
+	assert.Equal(t, "/schedule/"+sched.ID, location)
+	assert.Equal(t, "backend-team", sched.Team)
+	assert.Equal(t, "Weekday", sched.Name)
+	assert.Equal(t, []string{"Alice", "Bob"}, sched.Members)
+	assert.Equal(t, []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}, sched.Days)
+	assert.Equal(t, "9:00AM", sched.Start)
+	assert.Equal(t, "5:00PM", sched.End)
+}
+
+func TestGetScheduleByID_NotFound(t *testing.T) {
+	e, _ := newScheduleServer(t)
+
+	rec := serveJSON(e, http.MethodGet, "/schedule/404", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "schedule not found", resp.Error)
+}
+
+func TestUpdateSchedule(t *testing.T) {
+	e, store := newScheduleServer(t)
+
+	location := createSchedule(t, e, quotaRequest("backend-team"))
+
+	rec := serveJSON(e, http.MethodPatch, location, map[string]any{"members": []string{"Alice", "Bob", "Carol"}, "end": "8:00PM"}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var sched Request
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sched))
+	assert.Equal(t, "/schedule/"+sched.ID, location)
+	assert.Equal(t, "Weekday", sched.Name)
+	assert.Equal(t, []string{"Alice", "Bob", "Carol"}, sched.Members)
+	assert.Equal(t, []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}, sched.Days)
+	assert.Equal(t, "9:00AM", sched.Start)
+	assert.Equal(t, "8:00PM", sched.End)
+
+	rec = serveJSON(e, http.MethodGet, location, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sched))
+	assert.Equal(t, []string{"Alice", "Bob", "Carol"}, sched.Members)
+	assert.Equal(t, "8:00PM", sched.End)
+
+	entries, err := store.AuditLog(t.Context(), "backend-team")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, storage.AuditUpdateSchedule, entries[len(entries)-1].Action)
+	assert.Equal(t, "Weekday: members, end", entries[len(entries)-1].Detail)
+}
+
+func TestUpdateSchedule_DescriptionNotesTags(t *testing.T) {
+	e, _ := newScheduleServer(t)
+
+	req := quotaRequest("backend-team")
+	req.Description = "Business hours"
+	req.Notes = "Escalate to the platform team"
+	req.Tags = []string{"payments", "tier-1"}
+	location := createSchedule(t, e, req)
+
+	patch := func(t *testing.T, body map[string]any) Request {
+		t.Helper()
+
+		rec := serveJSON(e, http.MethodPatch, location, body, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		rec = serveJSON(e, http.MethodGet, location, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var sched Request
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sched))
+
+		return sched
+	}
+
+	t.Run("left out", func(t *testing.T) {
+		sched := patch(t, map[string]any{"name": "Business Hours"})
+		assert.Equal(t, "Business hours", sched.Description)
+		assert.Equal(t, "Escalate to the platform team", sched.Notes)
+		assert.Equal(t, []string{"payments", "tier-1"}, sched.Tags)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		sched := patch(t, map[string]any{"description": "Office hours", "tags": []string{"tier-2"}})
+		assert.Equal(t, "Office hours", sched.Description)
+		assert.Equal(t, "Escalate to the platform team", sched.Notes)
+		assert.Equal(t, []string{"tier-2"}, sched.Tags)
+	})
+
+	t.Run("cleared", func(t *testing.T) {
+		sched := patch(t, map[string]any{"description": "", "notes": "", "tags": []string{}})
+		assert.Empty(t, sched.Description)
+		assert.Empty(t, sched.Notes)
+		assert.Empty(t, sched.Tags)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		rec := serveJSON(e, http.MethodPatch, location, map[string]any{"tags": []string{"Tier 1"}}, "")
+		require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Contains(t, resp.Error, `invalid tag "Tier 1"`)
+	})
+}
+
+func TestUpdateSchedule_RotationOffset(t *testing.T) {
+	e, _ := newScheduleServer(t)
+
+	req := quotaRequest("backend-team")
+	req.Members = []string{"Alice", "Bob", "Carol", "Dana"}
+	req.RotationOffset = 3
+	location := createSchedule(t, e, req)
+
+	// The offset wraps around the fewer members and is kept that way
+	rec := serveJSON(e, http.MethodPatch, location, map[string]any{"members": []string{"Alice", "Bob"}}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, location, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var sched Request
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sched))
+	assert.Equal(t, []string{"Alice", "Bob"}, sched.Members)
+	assert.Equal(t, 1, sched.RotationOffset)
+
+	// Changing another field keeps the schedule valid
+	rec = serveJSON(e, http.MethodPatch, location, map[string]any{"end": "6:00PM"}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestUpdateSchedule_Warnings(t *testing.T) {
+	e, _ := newScheduleServer(t)
+
+	createSchedule(t, e, quotaRequest("backend-team"))
+	req := quotaRequest("backend-team")
+	req.Name = "Evening"
+	req.Start, req.End = "6:00PM", "10:00PM"
+	location := createSchedule(t, e, req)
+
+	// The schedule being updated is not checked against itself
+	rec := serveJSON(e, http.MethodPatch, location, map[string]any{"end": "11:00PM"}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp UpdateScheduleResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "11:00PM", resp.End)
+	assert.Empty(t, resp.Warnings)
+
+	rec = serveJSON(e, http.MethodPatch, location, map[string]any{"start": "4:00PM"}, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Warnings, 1)
+	assert.Equal(t, WarningScheduleOverlap, resp.Warnings[0].Code)
+	assert.Equal(t, "shifts overlap schedule Weekday, whose member is on call during the overlap", resp.Warnings[0].Message)
+}
+
+func TestUpdateSchedule_Invalid(t *testing.T) {
+	e, _ := newScheduleServer(t)
+
+	location := createSchedule(t, e, quotaRequest("backend-team"))
+
+	tests := []struct {
+		name  string
+		patch map[string]any
+		want  string
+	}{
+		{"no members", map[string]any{"members": []string{}}, "at least one member is required"},
+		{"no days", map[string]any{"days": []string{}}, "at least one day is required"},
+		{"start after end", map[string]any{"start": "6:00PM"}, "start time must be before end time"},
+		{"invalid end", map[string]any{"end": "17:00"}, "invalid end time format, use '3:04PM' format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPatch, location, tt.patch, "")
+			require.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.want, resp.Error)
+		})
+	}
+
+	// Nothing changed
+	rec := serveJSON(e, http.MethodGet, location, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var sched Request
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sched))
+	assert.Equal(t, []string{"Alice", "Bob"}, sched.Members)
+	assert.Equal(t, "9:00AM", sched.Start)
+	assert.Equal(t, "5:00PM", sched.End)
+}
+
+func TestUpdateSchedule_NameTaken(t *testing.T) {
+	e, _ := newScheduleServer(t)
+
+	createSchedule(t, e, quotaRequest("backend-team"))
+	req := quotaRequest("backend-team")
+	req.Name = "Evening"
+	location := createSchedule(t, e, req)
+
+	rec := serveJSON(e, http.MethodPatch, location, map[string]any{"name": "Weekday"}, "")
+	require.Equal(t, http.StatusConflict, rec.Code, rec.Body.String())
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "schedule already exists", resp.Error)
+}
+
+func TestUpdateSchedule_NotFound(t *testing.T) {
+	e, _ := newScheduleServer(t)
+
+	rec := serveJSON(e, http.MethodPatch, "/schedule/404", map[string]any{"name": "Nightly"}, "")
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "schedule not found", resp.Error)
+}
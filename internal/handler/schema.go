@@ -0,0 +1,374 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SchemaDialect is the JSON Schema draft the served schemas follow.
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// MIMEApplicationSchemaJSON is the media type of JSON Schema documents.
+const MIMEApplicationSchemaJSON = "application/schema+json"
+
+// kitchenPattern matches the times time.Kitchen parses, e.g. "9:00AM".
+const kitchenPattern = `^(0?[0-9]|1[0-2]):[0-5][0-9](AM|PM)$`
+
+// requiredRequestFields are the fields validateRequest rejects requests without.
+// Members or day assignments, and the recurrence, depend on the assignment,
+// see assignmentSchema.
+var requiredRequestFields = []string{"team", "start", "end"}
+
+// requestFieldSchemas holds the keywords reflection cannot infer, by JSON
+// field name. They are merged over the reflected type of the field.
+var requestFieldSchemas = map[string]map[string]any{
+	"id": {
+		"description": "Identifier of the schedule, set in listings and ignored on creation",
+		"readOnly":    true,
+	},
+	"inherited_from": {
+		"description": "Ancestor of the team the schedule belongs to, set in effective listings and ignored on creation",
+		"readOnly":    true,
+	},
+	"name": {
+		"description": "Name of the schedule, unique within the team",
+	},
+	"description": {
+		"description": "Short summary of what the schedule covers",
+		"maxLength":   MaxDescriptionLength,
+	},
+	"notes": {
+		"description": "Longer context of the schedule, markdown is allowed and stored verbatim",
+		"maxLength":   MaxNotesLength,
+	},
+	"team": {
+		"description": "Team the schedule belongs to",
+		"minLength":   1,
+	},
+	"members": {
+		"description": "Members in rotation, in order. Entries like @group:storage take turns through the members " +
+			"of a group of the team, as they are at each handoff. Exclusive with day_assignments and use_team_members",
+	},
+	"use_team_members": {
+		"description": "Rotate through the members of the team in the order they joined it, resolved whenever " +
+			"the schedule is read, in place of members. Members joining take turns from the next handoff on",
+	},
+	"days": {
+		"description": "Weekdays the shift happens on, as names, three-letter abbreviations, " +
+			"numbers where 0 is Sunday, inclusive ranges such as Mon-Fri, or weekdays and weekends, " +
+			"which follow the week of the team. Exclusive with cron and rrule",
+		"items": map[string]any{
+			"type": "string",
+			"anyOf": []any{
+				map[string]any{"enum": weekdayNames()},
+				map[string]any{"pattern": daysPattern()},
+			},
+		},
+	},
+	"cron": {
+		"description": "Cron expression of the shift starts. Exclusive with days and rrule",
+	},
+	"rrule": {
+		"description": "RFC 5545 recurrence rule of the shifts. Exclusive with days and cron",
+	},
+	"anchor": {
+		"description": "Date the rotation starts at, defaults to the creation day",
+		"format":      "date",
+		"pattern":     `^[0-9]{4}-[0-9]{2}-[0-9]{2}$`,
+	},
+	"start": {
+		"description": "Start time of the shift in UTC",
+		"pattern":     kitchenPattern,
+		"examples":    []any{"9:00AM"},
+	},
+	"end": {
+		"description": "End time of the shift in UTC, after the start time",
+		"pattern":     kitchenPattern,
+		"examples":    []any{"5:00PM"},
+	},
+	"tags": {
+		"description": "Tags grouping schedules across teams, duplicates are dropped",
+		"maxItems":    MaxTags,
+		"items": map[string]any{
+			"type":      "string",
+			"pattern":   tagPattern.String(),
+			"maxLength": MaxTagLength,
+		},
+	},
+	"rotation_offset": {
+		"description": "Index of the member on duty during the first week of the rotation, which starts at the anchor",
+		"minimum":     0,
+	},
+	"current_member": {
+		"description": "Member on duty now, sets rotation_offset accordingly. Exclusive with rotation_offset",
+	},
+	"current_until": {
+		"description": "Instant current_member stays on duty until, as in the system the rotation is imported from. " +
+			"The anchor and handoff are derived from it, so it has to be on a whole minute, in the next week and on the handoff if one is given",
+		"format": "date-time",
+	},
+	"assignment": {
+		"description": "Whether members rotate or are assigned to fixed days, defaults to rotation",
+		"enum":        []any{AssignmentRotation, AssignmentFixed},
+	},
+	"rotation": {
+		"description": "Whether members take turns every week from the anchor or only when the schedule is advanced, " +
+			"defaults to automatic. Manual schedules start with the member at rotation_offset",
+		"enum": []any{RotationAutomatic, RotationManual},
+	},
+	"split": {
+		"description": "Number of consecutive members of the rotation sharing every shift evenly, " +
+			"at most the number of members unless they come from the team or groups. 0 and 1 leave shifts whole",
+		"minimum": 0,
+	},
+	"required_count": {
+		"description": "Number of consecutive members of the rotation on duty together for every shift, " +
+			"at most the number of members unless they come from the team or groups. 0 and 1 put a single member on duty",
+		"minimum": 0,
+	},
+	"compensation": {
+		"description": "Rate class the hours on duty are paid at, weighed by the multiplier, " +
+			"and the one of the hours on the weekend days of the team if they differ",
+		"type":     "object",
+		"required": []any{"rate_class", "multiplier"},
+		"properties": map[string]any{
+			"rate_class":         map[string]any{"type": "string", "pattern": `\S`, "maxLength": MaxRateClassLength},
+			"multiplier":         map[string]any{"type": "number", "exclusiveMinimum": 0},
+			"weekend_rate_class": map[string]any{"type": "string", "pattern": `\S`, "maxLength": MaxRateClassLength},
+			"weekend_multiplier": map[string]any{"type": "number", "exclusiveMinimum": 0},
+		},
+		"dependentRequired": map[string]any{
+			"weekend_rate_class": []any{"weekend_multiplier"},
+			"weekend_multiplier": []any{"weekend_rate_class"},
+		},
+		"additionalProperties": false,
+	},
+	"handoff": {
+		"description": "Weekly instant in UTC the rotation moves on to the next member at, " +
+			"instead of the start of the shifts. The day has to be one of days unless shifts last the whole day",
+		"type":     "object",
+		"required": []any{"day", "time"},
+		"properties": map[string]any{
+			"day":  map[string]any{"type": "string", "pattern": `^\s*` + dayPattern() + `\s*$`},
+			"time": map[string]any{"type": "string", "pattern": kitchenPattern},
+		},
+		"additionalProperties": false,
+	},
+	"day_assignments": {
+		"description": "Member on duty on each weekday for fixed assignment, in place of members. " +
+			"Days default to the assigned ones, and listed days must all have an assignee",
+		"propertyNames": map[string]any{"pattern": `^\s*` + dayPattern() + `\s*$`},
+		"additionalProperties": map[string]any{
+			"type":    "string",
+			"pattern": `\S`,
+		},
+	},
+	"routing": {
+		"description": "Where alerts page the member on duty, e.g. the PagerDuty service or Slack channel, " +
+			"by the keys the server allows",
+		"additionalProperties": map[string]any{
+			"type":      "string",
+			"pattern":   `\S`,
+			"maxLength": MaxRoutingValueLength,
+		},
+	},
+	"valid_until": {
+		"description": "Instant the schedule ends at, it never ends when empty",
+		"format":      "date-time",
+	},
+}
+
+// ScheduleRequestSchema handles schedule request schema requests.
+func (h *Handler) ScheduleRequestSchema(c echo.Context) error {
+	body, err := json.Marshal(RequestSchema(h.routingKeys))
+	if err != nil {
+		return h.internalError(c, fmt.Errorf("marshal schedule request schema: %w", err), "failed to generate schema")
+	}
+
+	return c.Blob(http.StatusOK, MIMEApplicationSchemaJSON, body)
+}
+
+// RequestSchema returns the JSON Schema of Request, allowing the given keys
+// in the routing. The properties are reflected from its fields, so new fields
+// show up without changes here.
+func RequestSchema(routingKeys []string) map[string]any {
+	properties := make(map[string]any)
+
+	t := reflect.TypeFor[Request]()
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := reflectSchema(field.Type, strings.Contains(options, "omitempty"))
+		maps.Copy(property, requestFieldSchemas[name])
+		properties[name] = property
+	}
+
+	// The keys are configured, so the shared field schemas cannot hold them
+	routing := maps.Clone(properties["routing"].(map[string]any))
+	routing["propertyNames"] = map[string]any{"enum": routingKeys}
+	properties["routing"] = routing
+
+	return map[string]any{
+		"$schema":    SchemaDialect,
+		"title":      "Schedule request",
+		"type":       "object",
+		"properties": properties,
+		"required":   requiredRequestFields,
+		"if":         fixedAssignmentCondition(),
+		"then":       fixedAssignmentSchema(),
+		"else":       rotationSchema(),
+	}
+}
+
+// fixedAssignmentCondition matches requests for fixed assignment.
+func fixedAssignmentCondition() map[string]any {
+	return map[string]any{
+		"required":   []string{"assignment"},
+		"properties": map[string]any{"assignment": map[string]any{"const": AssignmentFixed}},
+	}
+}
+
+// fixedAssignmentSchema requires day assignments in place of members, and
+// leaves the days optional since they default to the assigned ones.
+func fixedAssignmentSchema() map[string]any {
+	return map[string]any{
+		"required": []string{"day_assignments"},
+		"properties": map[string]any{
+			"day_assignments":  map[string]any{"minProperties": 1},
+			"members":          map[string]any{"maxItems": 0},
+			"cron":             map[string]any{"maxLength": 0},
+			"rrule":            map[string]any{"maxLength": 0},
+			"rotation_offset":  map[string]any{"const": 0},
+			"current_member":   map[string]any{"maxLength": 0},
+			"current_until":    map[string]any{"maxLength": 0},
+			"use_team_members": map[string]any{"const": false},
+		},
+	}
+}
+
+// rotationSchema requires members, unless the schedule uses the members of
+// its team, and one recurrence for rotation schedules.
+func rotationSchema() map[string]any {
+	return map[string]any{
+		"properties": map[string]any{
+			"day_assignments": map[string]any{"maxProperties": 0},
+		},
+		"if": map[string]any{
+			"required":   []string{"use_team_members"},
+			"properties": map[string]any{"use_team_members": map[string]any{"const": true}},
+		},
+		"then": map[string]any{
+			"properties": map[string]any{"members": map[string]any{"maxItems": 0}},
+		},
+		"else": map[string]any{
+			"required":   []string{"members"},
+			"properties": map[string]any{"members": map[string]any{"type": "array", "minItems": 1}},
+		},
+		"oneOf": recurrenceSchema(),
+	}
+}
+
+// recurrenceSchema requires exactly one of days, cron and rrule to be set.
+// Empty values count as unset, as they do in validateRequest.
+func recurrenceSchema() []any {
+	return []any{
+		map[string]any{
+			"required":   []string{"days"},
+			"properties": map[string]any{"days": map[string]any{"type": "array", "minItems": 1}},
+		},
+		map[string]any{
+			"required":   []string{"cron"},
+			"properties": map[string]any{"cron": map[string]any{"minLength": 1}},
+		},
+		map[string]any{
+			"required":   []string{"rrule"},
+			"properties": map[string]any{"rrule": map[string]any{"minLength": 1}},
+		},
+	}
+}
+
+// reflectSchema returns the schema of a Go type as encoding/json encodes it.
+// Nil slices and maps are encoded as null unless omitted.
+func reflectSchema(t reflect.Type, omitempty bool) map[string]any {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return reflectSchema(t.Elem(), omitempty)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		schema := map[string]any{"type": "array", "items": reflectSchema(t.Elem(), false)}
+		if !omitempty && t.Kind() == reflect.Slice {
+			schema["type"] = []any{"array", "null"}
+		}
+		return schema
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": reflectSchema(t.Elem(), false)}
+	default:
+		return map[string]any{}
+	}
+}
+
+// weekdayNames returns the day names parseWeekday accepts in their usual case.
+func weekdayNames() []any {
+	var names []any
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		names = append(names, wd.String(), wd.String()[:3])
+	}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		names = append(names, fmt.Sprint(int(wd)))
+	}
+
+	return names
+}
+
+// daysPattern matches the day entries parseDays accepts, in any case.
+// JSON Schema patterns have no case-insensitive flag, so every letter
+// becomes a character class.
+func daysPattern() string {
+	day := dayPattern()
+
+	return `^\s*(` + caseless(DaysWeekdays) + "|" + caseless(DaysWeekends) + "|" + day + `\s*(-\s*` + day + `)?)\s*$`
+}
+
+// dayPattern matches a single weekday parseWeekday accepts, in any case.
+func dayPattern() string {
+	var days []string
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := wd.String()
+		days = append(days, caseless(name[:3])+"("+caseless(name[3:])+")?")
+	}
+
+	return "([0-6]|" + strings.Join(days, "|") + ")"
+}
+
+// caseless returns a pattern matching s in any case.
+func caseless(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		fmt.Fprintf(&b, "[%s%s]", strings.ToUpper(string(r)), strings.ToLower(string(r)))
+	}
+
+	return b.String()
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+)
+
+// servedSchema fetches the schedule request schema and compiles it.
+func servedSchema(t *testing.T) (*jsonschema.Schema, map[string]any) {
+	t.Helper()
+
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	req := httptest.NewRequest(http.MethodGet, "/schema/schedule-request", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, h.ScheduleRequestSchema(echo.New().NewContext(req, rec)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMEApplicationSchemaJSON, rec.Header().Get(echo.HeaderContentType))
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(rec.Body.Bytes()))
+	require.NoError(t, err)
+
+	compiler := jsonschema.NewCompiler()
+	require.NoError(t, compiler.AddResource("schedule-request.json", doc))
+	schema, err := compiler.Compile("schedule-request.json")
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &raw))
+
+	return schema, raw
+}
+
+// validateInstance validates the JSON encoding of v against the schema.
+func validateInstance(t *testing.T, schema *jsonschema.Schema, v any) error {
+	t.Helper()
+
+	body, err := json.Marshal(v)
+	require.NoError(t, err)
+
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(body))
+	require.NoError(t, err)
+
+	return schema.Validate(inst)
+}
+
+func TestScheduleRequestSchema_SeedFixture(t *testing.T) {
+	schema, _ := servedSchema(t)
+
+	f, err := os.Open("testdata/seed.yaml")
+	require.NoError(t, err)
+	defer f.Close()
+
+	var doc SeedDocument
+	require.NoError(t, yaml.NewDecoder(f).Decode(&doc))
+
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	for _, team := range doc.Teams {
+		for _, req := range team.Schedules {
+			req.Team = team.Team
+
+			t.Run(req.Name, func(t *testing.T) {
+				_, parseErr := h.parseRequest(&req)
+				assert.Equal(t, parseErr == nil, validateInstance(t, schema, req) == nil, parseErr)
+			})
+		}
+	}
+}
+
+func TestScheduleRequestSchema_MatchesValidation(t *testing.T) {
+	schema, _ := servedSchema(t)
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	valid := Request{
+		Name:    "Weekday",
+		Team:    "backend-team",
+		Members: []string{"Alice", "Bob"},
+		Days:    []string{"Monday", "tue", "3", "fri - SUN"},
+		Start:   "9:00AM",
+		End:     "05:00PM",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Request)
+	}{
+		{"valid", func(*Request) {}},
+		{"cron", func(r *Request) { r.Days, r.Cron = nil, "0 9 * * MON" }},
+		{"rrule", func(r *Request) { r.Days, r.RRule, r.Anchor = nil, "FREQ=WEEKLY;BYDAY=MO", "2025-04-28" }},
+		{"valid until", func(r *Request) { r.ValidUntil = "2099-01-01T00:00:00Z" }},
+		{"missing team", func(r *Request) { r.Team = "" }},
+		{"missing members", func(r *Request) { r.Members = nil }},
+		{"no recurrence", func(r *Request) { r.Days = nil }},
+		{"days and cron", func(r *Request) { r.Cron = "0 9 * * MON" }},
+		{"invalid day", func(r *Request) { r.Days = []string{"Someday"} }},
+		{"two-letter day", func(r *Request) { r.Days = []string{"Mo"} }},
+		{"missing start", func(r *Request) { r.Start = "" }},
+		{"24-hour time", func(r *Request) { r.End = "17:00" }},
+		{"lowercase meridiem", func(r *Request) { r.Start = "9:00am" }},
+		{"invalid anchor", func(r *Request) { r.Anchor = "28/04/2025" }},
+		{"fixed", func(r *Request) {
+			r.Members, r.Days = nil, nil
+			r.Assignment, r.DayAssignments = AssignmentFixed, map[string]string{"Monday": "Alice", "tue": "Bob"}
+		}},
+		{"fixed with members", func(r *Request) {
+			r.Days = nil
+			r.Assignment, r.DayAssignments = AssignmentFixed, map[string]string{"Monday": "Alice"}
+		}},
+		{"fixed with cron", func(r *Request) {
+			r.Members, r.Days, r.Cron = nil, nil, "0 9 * * MON"
+			r.Assignment, r.DayAssignments = AssignmentFixed, map[string]string{"Monday": "Alice"}
+		}},
+		{"fixed without assignments", func(r *Request) { r.Members, r.Assignment = nil, AssignmentFixed }},
+		{"fixed with invalid day", func(r *Request) {
+			r.Members, r.Days = nil, nil
+			r.Assignment, r.DayAssignments = AssignmentFixed, map[string]string{"Mo": "Alice"}
+		}},
+		{"assignments without fixed", func(r *Request) { r.DayAssignments = map[string]string{"Monday": "Alice"} }},
+		{"unknown assignment", func(r *Request) { r.Assignment = "random" }},
+		{"team members", func(r *Request) { r.Members, r.UseTeamMembers = nil, true }},
+		{"team members with members", func(r *Request) { r.UseTeamMembers = true }},
+		{"fixed with team members", func(r *Request) {
+			r.Members, r.Days, r.UseTeamMembers = nil, nil, true
+			r.Assignment, r.DayAssignments = AssignmentFixed, map[string]string{"Monday": "Alice"}
+		}},
+		{"routing", func(r *Request) {
+			r.Routing = map[string]string{"pagerduty_service_id": "PXXXXXX", "slack_channel": "#backend"}
+		}},
+		{"unknown routing key", func(r *Request) { r.Routing = map[string]string{"pager": "PXXXXXX"} }},
+		{"blank routing value", func(r *Request) { r.Routing = map[string]string{"opsgenie_team": " "} }},
+		{"long routing value", func(r *Request) {
+			r.Routing = map[string]string{"slack_channel": strings.Repeat("a", MaxRoutingValueLength+1)}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+
+			_, parseErr := h.parseRequest(&req)
+			assert.Equal(t, parseErr == nil, validateInstance(t, schema, req) == nil, parseErr)
+		})
+	}
+}
+
+func TestScheduleRequestSchema_ReflectsFields(t *testing.T) {
+	_, raw := servedSchema(t)
+
+	assert.Equal(t, SchemaDialect, raw["$schema"])
+
+	properties, ok := raw["properties"].(map[string]any)
+	require.True(t, ok)
+
+	typ := reflect.TypeFor[Request]()
+	for i := range typ.NumField() {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		assert.Contains(t, properties, name)
+	}
+
+	// Every required field is one validateRequest rejects requests without
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	for _, field := range raw["required"].([]any) {
+		req := Request{Team: "backend-team", Members: []string{"Alice"}, Days: []string{"Mon"}, Start: "9:00AM", End: "5:00PM"}
+		require.NoError(t, h.validateRequest(&req))
+
+		body, err := json.Marshal(req)
+		require.NoError(t, err)
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(body, &fields))
+		delete(fields, field.(string))
+		body, err = json.Marshal(fields)
+		require.NoError(t, err)
+
+		var without Request
+		require.NoError(t, json.Unmarshal(body, &without))
+		assert.Error(t, h.validateRequest(&without), field)
+	}
+}
@@ -0,0 +1,440 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// MIMEApplicationSCIM is the media type of SCIM requests and responses.
+const MIMEApplicationSCIM = "application/scim+json"
+
+// SCIM message schemas.
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimListSchema  = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// SCIM error types.
+const (
+	scimInvalidFilter = "invalidFilter"
+	scimInvalidSyntax = "invalidSyntax"
+	scimInvalidPath   = "invalidPath"
+	scimInvalidValue  = "invalidValue"
+	scimUniqueness    = "uniqueness"
+)
+
+// maxSCIMCount bounds the page size of user listings.
+const maxSCIMCount = 100
+
+// SCIMActor is the audit log actor of requests authenticated with the SCIM token.
+const SCIMActor = "scim"
+
+// scimFilter matches the only supported filter, an exact userName match.
+var scimFilter = regexp.MustCompile(`(?i)^\s*userName\s+eq\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// SCIMEmail represents an email address of a SCIM user.
+type SCIMEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// SCIMMeta represents the metadata of a SCIM resource.
+type SCIMMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created"`
+	LastModified string `json:"lastModified"`
+	Location     string `json:"location"`
+}
+
+// SCIMUser represents a provisioned user as a SCIM user resource.
+type SCIMUser struct {
+	Schemas  []string    `json:"schemas"`
+	ID       string      `json:"id"`
+	UserName string      `json:"userName"`
+	Active   bool        `json:"active"`
+	Emails   []SCIMEmail `json:"emails"`
+	Timezone string      `json:"timezone,omitempty"`
+	Meta     SCIMMeta    `json:"meta"`
+}
+
+// SCIMUserRequest represents the user creation request. Users are active
+// unless active is false. Timezone is the IANA zone the times of the user
+// are rendered in, UTC when empty.
+type SCIMUserRequest struct {
+	UserName string      `json:"userName"`
+	Emails   []SCIMEmail `json:"emails"`
+	Active   *bool       `json:"active"`
+	Timezone string      `json:"timezone"`
+}
+
+// SCIMListResponse represents a page of SCIM users.
+type SCIMListResponse struct {
+	Schemas      []string   `json:"schemas"`
+	TotalResults int        `json:"totalResults"`
+	StartIndex   int        `json:"startIndex"`
+	ItemsPerPage int        `json:"itemsPerPage"`
+	Resources    []SCIMUser `json:"Resources"`
+}
+
+// SCIMPatchOperation represents a single operation of a SCIM patch.
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// SCIMPatchRequest represents the user patch request.
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMError represents a SCIM error response, the status is a string as the
+// protocol requires.
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+// SCIM guards the SCIM routes with a static bearer token, rejecting requests
+// with a SCIM error. An empty token disables provisioning, every request is
+// then rejected. Changes made by SCIM requests are attributed to SCIMActor.
+func SCIM(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !bearerMatches(c, token) {
+				return scimError(c, http.StatusUnauthorized, "", "scim authentication required")
+			}
+
+			c.SetRequest(c.Request().WithContext(storage.WithActor(c.Request().Context(), SCIMActor)))
+
+			return next(c)
+		}
+	}
+}
+
+// CreateSCIMUser handles SCIM user creation requests. A user name already
+// provisioned is a conflict.
+func (h *Handler) CreateSCIMUser(c echo.Context) error {
+	var req SCIMUserRequest
+
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return scimError(c, http.StatusBadRequest, scimInvalidSyntax, "invalid request body")
+	}
+
+	req.UserName = strings.TrimSpace(req.UserName)
+	if req.UserName == "" {
+		return scimError(c, http.StatusBadRequest, scimInvalidValue, "userName is required")
+	}
+
+	timezone, err := parseSCIMTimezone(req.Timezone)
+	if err != nil {
+		return scimError(c, http.StatusBadRequest, scimInvalidValue, err.Error())
+	}
+
+	user := storage.User{UserName: req.UserName, Active: req.Active == nil || *req.Active, Timezone: timezone}
+	for _, email := range req.Emails {
+		if email.Value == "" {
+			continue
+		}
+		// The primary address goes first
+		if email.Primary {
+			user.Emails = append([]string{email.Value}, user.Emails...)
+		} else {
+			user.Emails = append(user.Emails, email.Value)
+		}
+	}
+
+	user, added, err := h.storage.AddUser(c.Request().Context(), user)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add user %q: %w", user.UserName, err), "failed to create user")
+	}
+	if !added {
+		return scimError(c, http.StatusConflict, scimUniqueness, fmt.Sprintf("user %s already exists", req.UserName))
+	}
+
+	h.logger.Info("user provisioned", zap.String("id", user.ID), zap.String("user_name", user.UserName))
+
+	resp := newSCIMUser(c, user)
+	c.Response().Header().Set(echo.HeaderLocation, resp.Meta.Location)
+
+	return scimJSON(c, http.StatusCreated, resp)
+}
+
+// ListSCIMUsers handles SCIM user listing requests. The only supported
+// filter is userName eq, and pages are selected with startIndex and count.
+func (h *Handler) ListSCIMUsers(c echo.Context) error {
+	var userName string
+	if filter := c.QueryParam("filter"); filter != "" {
+		match := scimFilter.FindStringSubmatch(filter)
+		if match == nil {
+			return scimError(c, http.StatusBadRequest, scimInvalidFilter, "only filters of the form userName eq \"name\" are supported")
+		}
+		name, err := strconv.Unquote(match[1])
+		if err != nil {
+			return scimError(c, http.StatusBadRequest, scimInvalidFilter, "invalid userName in filter")
+		}
+		userName = name
+	}
+
+	// Out of range values are clamped as the protocol requires
+	startIndex := 1
+	if value := c.QueryParam("startIndex"); value != "" {
+		index, err := strconv.Atoi(value)
+		if err != nil {
+			return scimError(c, http.StatusBadRequest, scimInvalidValue, "invalid startIndex")
+		}
+		startIndex = max(index, 1)
+	}
+
+	count := maxSCIMCount
+	if value := c.QueryParam("count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return scimError(c, http.StatusBadRequest, scimInvalidValue, "invalid count")
+		}
+		count = min(max(n, 0), maxSCIMCount)
+	}
+
+	// An empty user name matches nobody, FindUsers would list everyone for it
+	if userName == "" && c.QueryParam("filter") != "" {
+		return scimJSON(c, http.StatusOK, SCIMListResponse{
+			Schemas:    []string{scimListSchema},
+			StartIndex: startIndex,
+			Resources:  []SCIMUser{},
+		})
+	}
+
+	users, err := h.storage.FindUsers(c.Request().Context(), userName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("find users %q: %w", userName, err), "failed to retrieve users")
+	}
+
+	page := users[min(startIndex-1, len(users)):]
+	page = page[:min(count, len(page))]
+
+	resp := SCIMListResponse{
+		Schemas:      []string{scimListSchema},
+		TotalResults: len(users),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(page),
+		Resources:    make([]SCIMUser, 0, len(page)),
+	}
+	for _, user := range page {
+		resp.Resources = append(resp.Resources, newSCIMUser(c, user))
+	}
+
+	return scimJSON(c, http.StatusOK, resp)
+}
+
+// GetSCIMUser handles SCIM user retrieval requests.
+func (h *Handler) GetSCIMUser(c echo.Context) error {
+	user, found, err := h.storage.GetUser(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get user %q: %w", c.Param("id"), err), "failed to retrieve user")
+	}
+	if !found {
+		return scimError(c, http.StatusNotFound, "", "user not found")
+	}
+
+	return scimJSON(c, http.StatusOK, newSCIMUser(c, user))
+}
+
+// PatchSCIMUser handles SCIM user patch requests. Only the active and
+// timezone attributes can be changed, either through their path or a value
+// object. Active may be a boolean or a "True" or "False" string as some
+// providers send. Deactivated users are skipped by rotations.
+func (h *Handler) PatchSCIMUser(c echo.Context) error {
+	var req SCIMPatchRequest
+
+	if err := json.NewDecoder(c.Request().Body).Decode(&req); err != nil {
+		return scimError(c, http.StatusBadRequest, scimInvalidSyntax, "invalid request body")
+	}
+	if len(req.Operations) == 0 {
+		return scimError(c, http.StatusBadRequest, scimInvalidSyntax, "at least one operation is required")
+	}
+
+	var (
+		active   *bool
+		timezone *string
+	)
+	for _, op := range req.Operations {
+		if kind := strings.ToLower(op.Op); kind != "replace" && kind != "add" {
+			return scimError(c, http.StatusBadRequest, scimInvalidSyntax, fmt.Sprintf("unsupported operation %q", op.Op))
+		}
+
+		attributes := map[string]json.RawMessage{op.Path: op.Value}
+		if op.Path == "" {
+			attributes = nil
+			if err := json.Unmarshal(op.Value, &attributes); err != nil {
+				return scimError(c, http.StatusBadRequest, scimInvalidValue, "value must be an object without a path")
+			}
+		}
+
+		changed := false
+		for name, value := range attributes {
+			switch {
+			case strings.EqualFold(name, "active"):
+				v, err := parseSCIMBool(value)
+				if err != nil {
+					return scimError(c, http.StatusBadRequest, scimInvalidValue, err.Error())
+				}
+				active, changed = &v, true
+			case strings.EqualFold(name, "timezone"):
+				var raw string
+				if err := json.Unmarshal(value, &raw); err != nil {
+					return scimError(c, http.StatusBadRequest, scimInvalidValue, "timezone must be a string")
+				}
+				v, err := parseSCIMTimezone(raw)
+				if err != nil {
+					return scimError(c, http.StatusBadRequest, scimInvalidValue, err.Error())
+				}
+				timezone, changed = &v, true
+			case op.Path != "":
+				return scimError(c, http.StatusBadRequest, scimInvalidPath, "only the active and timezone attributes can be changed")
+			}
+		}
+		if !changed {
+			return scimError(c, http.StatusBadRequest, scimInvalidPath, "only the active and timezone attributes can be changed")
+		}
+	}
+
+	if timezone != nil {
+		user, found, err := h.storage.SetUserTimezone(c.Request().Context(), c.Param("id"), *timezone)
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("set timezone of user %q: %w", c.Param("id"), err), "failed to update user")
+		}
+		if !found {
+			return scimError(c, http.StatusNotFound, "", "user not found")
+		}
+
+		h.logger.Info("user updated",
+			zap.String("id", user.ID),
+			zap.String("user_name", user.UserName),
+			zap.String("timezone", user.Timezone),
+		)
+
+		if active == nil {
+			return scimJSON(c, http.StatusOK, newSCIMUser(c, user))
+		}
+	}
+
+	return h.setSCIMUserActive(c, *active, http.StatusOK)
+}
+
+// DeleteSCIMUser handles SCIM user deletion requests. Users are only
+// deactivated, as their name may still be referenced by the schedules and
+// the history.
+func (h *Handler) DeleteSCIMUser(c echo.Context) error {
+	return h.setSCIMUserActive(c, false, http.StatusNoContent)
+}
+
+// setSCIMUserActive activates or deactivates the user of the request and
+// responds with the user, or without a body on a no content status.
+func (h *Handler) setSCIMUserActive(c echo.Context, active bool, status int) error {
+	user, found, err := h.storage.SetUserActive(c.Request().Context(), c.Param("id"), active)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("set active of user %q: %w", c.Param("id"), err), "failed to update user")
+	}
+	if !found {
+		return scimError(c, http.StatusNotFound, "", "user not found")
+	}
+
+	h.logger.Info("user updated",
+		zap.String("id", user.ID),
+		zap.String("user_name", user.UserName),
+		zap.Bool("active", user.Active),
+	)
+
+	if status == http.StatusNoContent {
+		return c.NoContent(status)
+	}
+
+	return scimJSON(c, status, newSCIMUser(c, user))
+}
+
+// parseSCIMTimezone validates the zone of a user, which is empty for UTC.
+func parseSCIMTimezone(timezone string) (string, error) {
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		return "", nil
+	}
+
+	// Local is the zone of the server, not one of a user
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return "", fmt.Errorf("unknown timezone %q", timezone)
+	}
+
+	return timezone, nil
+}
+
+// parseSCIMBool parses a boolean sent either as a JSON boolean or as a string.
+func parseSCIMBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+	}
+
+	return false, fmt.Errorf("active must be a boolean")
+}
+
+// newSCIMUser converts a user to its SCIM resource, located under the host
+// of the request.
+func newSCIMUser(c echo.Context, user storage.User) SCIMUser {
+	emails := make([]SCIMEmail, 0, len(user.Emails))
+	for i, email := range user.Emails {
+		emails = append(emails, SCIMEmail{Value: email, Type: "work", Primary: i == 0})
+	}
+
+	return SCIMUser{
+		Schemas:  []string{scimUserSchema},
+		ID:       user.ID,
+		UserName: user.UserName,
+		Active:   user.Active,
+		Emails:   emails,
+		Timezone: user.Timezone,
+		Meta: SCIMMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt.UTC().Format(time.RFC3339),
+			LastModified: user.UpdatedAt.UTC().Format(time.RFC3339),
+			Location:     fmt.Sprintf("%s://%s/scim/v2/Users/%s", c.Scheme(), c.Request().Host, user.ID),
+		},
+	}
+}
+
+// scimJSON responds with the SCIM media type.
+func scimJSON(c echo.Context, status int, v any) error {
+	c.Response().Header().Set(echo.HeaderContentType, MIMEApplicationSCIM)
+
+	return c.JSON(status, v)
+}
+
+// scimError responds with a SCIM error, scimType is left out when empty.
+func scimError(c echo.Context, status int, scimType, detail string) error {
+	return scimJSON(c, status, SCIMError{
+		Schemas:  []string{scimErrorSchema},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newSCIMServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	scim := e.Group("/scim/v2", SCIM("secret"))
+	scim.POST("/Users", h.CreateSCIMUser)
+	scim.GET("/Users", h.ListSCIMUsers)
+	scim.GET("/Users/:id", h.GetSCIMUser)
+	scim.PATCH("/Users/:id", h.PatchSCIMUser)
+	scim.DELETE("/Users/:id", h.DeleteSCIMUser)
+
+	return e, store
+}
+
+// serveSCIM sends a raw SCIM body with the SCIM media type.
+func serveSCIM(e *echo.Echo, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, MIMEApplicationSCIM)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func createSCIMUser(t *testing.T, e *echo.Echo, userName string) SCIMUser {
+	t.Helper()
+
+	rec := serveSCIM(e, http.MethodPost, "/scim/v2/Users", `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "`+userName+`",
+		"emails": [{"value": "other@example.org"}, {"value": "primary@example.org", "primary": true}]
+	}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var user SCIMUser
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+
+	return user
+}
+
+func TestSCIM_Authentication(t *testing.T) {
+	e, _ := newSCIMServer(t)
+
+	for _, header := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+		if header != "" {
+			req.Header.Set(echo.HeaderAuthorization, header)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Equal(t, MIMEApplicationSCIM, rec.Header().Get(echo.HeaderContentType))
+
+		var resp SCIMError
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, []string{scimErrorSchema}, resp.Schemas)
+		assert.Equal(t, "401", resp.Status)
+	}
+
+	// An empty token disables provisioning
+	disabled := echo.New()
+	disabled.GET("/scim/v2/Users", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, SCIM(""))
+	req := httptest.NewRequest(http.MethodGet, "/scim/v2/Users", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer ")
+	rec := httptest.NewRecorder()
+	disabled.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestSCIM_CreateUser(t *testing.T) {
+	e, _ := newSCIMServer(t)
+
+	user := createSCIMUser(t, e, "Alice")
+	assert.Equal(t, []string{scimUserSchema}, user.Schemas)
+	assert.NotEmpty(t, user.ID)
+	assert.Equal(t, "Alice", user.UserName)
+	assert.True(t, user.Active)
+	assert.Equal(t, []SCIMEmail{
+		{Value: "primary@example.org", Type: "work", Primary: true},
+		{Value: "other@example.org", Type: "work"},
+	}, user.Emails)
+	assert.Equal(t, "User", user.Meta.ResourceType)
+	assert.Equal(t, "http://example.com/scim/v2/Users/"+user.ID, user.Meta.Location)
+
+	rec := serveSCIM(e, http.MethodPost, "/scim/v2/Users", `{"userName": "Alice"}`)
+	require.Equal(t, http.StatusConflict, rec.Code)
+	var conflict SCIMError
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &conflict))
+	assert.Equal(t, scimUniqueness, conflict.ScimType)
+
+	rec = serveSCIM(e, http.MethodPost, "/scim/v2/Users", `{"userName": "  "}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = serveSCIM(e, http.MethodPost, "/scim/v2/Users", `{"userName":`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Users may be provisioned deactivated
+	rec = serveSCIM(e, http.MethodPost, "/scim/v2/Users", `{"userName": "Bob", "active": false}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Header().Get(echo.HeaderLocation), "/scim/v2/Users/")
+	assert.Contains(t, rec.Body.String(), `"active":false`)
+
+	rec = serveSCIM(e, http.MethodPost, "/scim/v2/Users", `{"userName": "Carol", "timezone": "Asia/Tehran"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"timezone":"Asia/Tehran"`)
+
+	for _, timezone := range []string{"Mars/Base", "Local"} {
+		rec = serveSCIM(e, http.MethodPost, "/scim/v2/Users", `{"userName": "Dana", "timezone": "`+timezone+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, timezone)
+	}
+}
+
+func TestSCIM_GetUser(t *testing.T) {
+	e, _ := newSCIMServer(t)
+	created := createSCIMUser(t, e, "Alice")
+
+	rec := serveSCIM(e, http.MethodGet, "/scim/v2/Users/"+created.ID, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMEApplicationSCIM, rec.Header().Get(echo.HeaderContentType))
+
+	var user SCIMUser
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &user))
+	assert.Equal(t, created, user)
+
+	rec = serveSCIM(e, http.MethodGet, "/scim/v2/Users/404", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"status":"404"`)
+}
+
+func TestSCIM_ListUsers(t *testing.T) {
+	e, _ := newSCIMServer(t)
+	for _, name := range []string{"Charlie", "Alice", "Bob"} {
+		createSCIMUser(t, e, name)
+	}
+
+	list := func(query string) SCIMListResponse {
+		t.Helper()
+
+		rec := serveSCIM(e, http.MethodGet, "/scim/v2/Users"+query, "")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var resp SCIMListResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, []string{scimListSchema}, resp.Schemas)
+
+		return resp
+	}
+	names := func(resp SCIMListResponse) []string {
+		result := []string{}
+		for _, user := range resp.Resources {
+			result = append(result, user.UserName)
+		}
+		return result
+	}
+
+	resp := list("")
+	assert.Equal(t, 3, resp.TotalResults)
+	assert.Equal(t, 1, resp.StartIndex)
+	assert.Equal(t, []string{"Alice", "Bob", "Charlie"}, names(resp))
+
+	resp = list("?startIndex=2&count=1")
+	assert.Equal(t, 3, resp.TotalResults)
+	assert.Equal(t, 1, resp.ItemsPerPage)
+	assert.Equal(t, []string{"Bob"}, names(resp))
+
+	resp = list("?startIndex=5")
+	assert.Equal(t, 3, resp.TotalResults)
+	assert.Empty(t, names(resp))
+
+	resp = list("?filter=" + url.QueryEscape(`userName eq "Bob"`))
+	assert.Equal(t, 1, resp.TotalResults)
+	assert.Equal(t, []string{"Bob"}, names(resp))
+
+	// Providers look users up before creating them
+	resp = list("?filter=" + url.QueryEscape(`username EQ "Dana"`))
+	assert.Equal(t, 0, resp.TotalResults)
+	assert.Empty(t, names(resp))
+
+	resp = list("?filter=" + url.QueryEscape(`userName eq ""`))
+	assert.Equal(t, 0, resp.TotalResults)
+
+	for _, query := range []string{
+		"?filter=" + url.QueryEscape(`userName sw "A"`),
+		"?filter=" + url.QueryEscape(`emails.value eq "alice@example.org"`),
+		"?startIndex=first",
+		"?count=all",
+	} {
+		rec := serveSCIM(e, http.MethodGet, "/scim/v2/Users"+query, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestSCIM_PatchUser(t *testing.T) {
+	e, store := newSCIMServer(t)
+	ctx := context.Background()
+	monday := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)
+
+	_, err := store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice", "Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Anchor:  monday.Truncate(24 * time.Hour),
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	user := createSCIMUser(t, e, "Alice")
+
+	oncall := func() string {
+		member, err := store.GetCurrentOncall(ctx, "backend-team", monday)
+		require.NoError(t, err)
+		return member
+	}
+	assert.Equal(t, "Alice", oncall())
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		return serveSCIM(e, http.MethodPatch, "/scim/v2/Users/"+user.ID, body)
+	}
+
+	// Okta replaces the attribute through a value object
+	rec := patch(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "replace", "value": {"active": false}}]
+	}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var patched SCIMUser
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &patched))
+	assert.False(t, patched.Active)
+	assert.Equal(t, "Bob", oncall())
+
+	// Azure AD sends a path and a string value
+	rec = patch(`{
+		"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"],
+		"Operations": [{"op": "Replace", "path": "active", "value": "True"}]
+	}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"active":true`)
+	assert.Equal(t, "Alice", oncall())
+
+	// The zone of the user is replaced the same ways
+	rec = patch(`{"Operations": [{"op": "replace", "path": "timezone", "value": "Asia/Tehran"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), `"timezone":"Asia/Tehran"`)
+
+	rec = patch(`{"Operations": [{"op": "replace", "value": {"active": true, "timezone": "Europe/Berlin"}}]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	users, err := store.FindUsers(ctx, "Alice")
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "Europe/Berlin", users[0].Timezone)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"malformed", `{"Operations": [`},
+		{"invalid timezone", `{"Operations": [{"op": "replace", "path": "timezone", "value": "Mars/Base"}]}`},
+		{"timezone not a string", `{"Operations": [{"op": "replace", "path": "timezone", "value": 42}]}`},
+		{"no operations", `{"Operations": []}`},
+		{"remove", `{"Operations": [{"op": "remove", "path": "active"}]}`},
+		{"other path", `{"Operations": [{"op": "replace", "path": "userName", "value": "Alicia"}]}`},
+		{"other attribute", `{"Operations": [{"op": "replace", "value": {"userName": "Alicia"}}]}`},
+		{"invalid value", `{"Operations": [{"op": "replace", "path": "active", "value": "maybe"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := patch(tt.body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), scimErrorSchema)
+		})
+	}
+
+	rec = serveSCIM(e, http.MethodPatch, "/scim/v2/Users/404", `{"Operations": [{"op": "replace", "path": "active", "value": false}]}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestSCIM_DeleteUser(t *testing.T) {
+	e, store := newSCIMServer(t)
+	user := createSCIMUser(t, e, "Alice")
+
+	rec := serveSCIM(e, http.MethodDelete, "/scim/v2/Users/"+user.ID, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// The user is kept, deactivated
+	got, found, err := store.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.False(t, got.Active)
+
+	rec = serveSCIM(e, http.MethodDelete, "/scim/v2/Users/404", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
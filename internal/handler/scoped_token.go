@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// ScopeOncallRead grants the on-call lookups.
+const ScopeOncallRead = "oncall:read"
+
+// scopedTokenPrefix starts every scoped token, so requests with the admin
+// token or an API key never look one up.
+const scopedTokenPrefix = "scoped_"
+
+// defaultTokenRateLimit is the number of requests a minute scoped tokens are
+// allowed when created without a rate limit.
+const defaultTokenRateLimit = 60
+
+// tokenScopes are the routes each scope grants, by method and route.
+var tokenScopes = map[string][]string{
+	ScopeOncallRead: {
+		http.MethodGet + " /schedule",
+		http.MethodGet + " /oncall",
+		http.MethodGet + " /oncall/all",
+	},
+}
+
+// ScopedTokenRequest represents a scoped token request.
+type ScopedTokenRequest struct {
+	Name  string   `json:"name"`
+	Scope []string `json:"scope"`
+	// Teams limits the token to the on-call lookups of these teams, it is
+	// allowed for every team when empty.
+	Teams []string `json:"teams,omitempty"`
+	// RateLimit is the number of requests a minute the token is allowed, 60
+	// by default.
+	RateLimit int `json:"rate_limit,omitempty"`
+}
+
+// ScopedTokenResponse represents a scoped token. The token itself is only
+// returned once, on creation.
+type ScopedTokenResponse struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Scope      []string `json:"scope"`
+	Teams      []string `json:"teams,omitempty"`
+	RateLimit  int      `json:"rate_limit"`
+	Token      string   `json:"token,omitempty"`
+	Uses       int64    `json:"uses"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+// CreateScopedToken handles scoped token requests. Only the hash of the
+// generated token is stored.
+func (h *Handler) CreateScopedToken(c echo.Context) error {
+	var req ScopedTokenRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "name is required"})
+	}
+	if len(req.Name) > maxAPIKeyNameLength {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("name must be at most %d bytes", maxAPIKeyNameLength)})
+	}
+	if len(req.Scope) == 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "scope is required"})
+	}
+	for _, scope := range req.Scope {
+		if _, ok := tokenScopes[scope]; !ok {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("unknown scope %q, use %s", scope, ScopeOncallRead)})
+		}
+	}
+	for i, team := range req.Teams {
+		req.Teams[i] = strings.TrimSpace(team)
+		if req.Teams[i] == "" {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "teams cannot be empty"})
+		}
+	}
+	if req.RateLimit < 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "rate_limit must be positive"})
+	}
+	if req.RateLimit == 0 {
+		req.RateLimit = defaultTokenRateLimit
+	}
+
+	ctx := c.Request().Context()
+
+	tokens, err := h.storage.ListScopedTokens(ctx)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list scoped tokens to create %q: %w", req.Name, err), "failed to create token")
+	}
+	for _, token := range tokens {
+		if token.Name == req.Name && token.Valid() {
+			return c.JSON(http.StatusConflict, ErrorResponse{Error: "a token with the name already exists"})
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return h.internalError(c, fmt.Errorf("generate scoped token %q: %w", req.Name, err), "failed to generate token")
+	}
+	raw := scopedTokenPrefix + hex.EncodeToString(secret)
+
+	token, err := h.storage.AddScopedToken(ctx, storage.ScopedToken{
+		Name:      req.Name,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(req.Scope))),
+		Teams:     slices.Compact(slices.Sorted(slices.Values(req.Teams))),
+		RateLimit: req.RateLimit,
+		Hash:      hashToken(raw),
+	})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add scoped token %q: %w", req.Name, err), "failed to create token")
+	}
+
+	h.logger.Info("scoped token created",
+		zap.Int64("id", token.ID),
+		zap.String("name", token.Name),
+		zap.Strings("scopes", token.Scopes),
+		zap.Strings("teams", token.Teams),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	resp := newScopedTokenResponse(token)
+	resp.Token = raw
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+// ListScopedTokens handles scoped token listing requests, along with how much
+// each is used. The tokens themselves are never returned.
+func (h *Handler) ListScopedTokens(c echo.Context) error {
+	tokens, err := h.storage.ListScopedTokens(c.Request().Context())
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list scoped tokens: %w", err), "failed to list tokens")
+	}
+
+	resp := make([]ScopedTokenResponse, 0, len(tokens))
+	for _, token := range tokens {
+		resp = append(resp, newScopedTokenResponse(token))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// RevokeScopedToken handles scoped token revocation requests.
+func (h *Handler) RevokeScopedToken(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid token id"})
+	}
+
+	ctx := c.Request().Context()
+
+	revoked, err := h.storage.RevokeScopedToken(ctx, id, h.now().UTC())
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("revoke scoped token %d: %w", id, err), "failed to revoke token")
+	}
+
+	if !revoked {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "token not found"})
+	}
+
+	h.logger.Info("scoped token revoked", zap.Int64("id", id), zap.String("actor", storage.ActorFrom(ctx)))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ScopedTokens guards every route against requests made with a scoped token.
+// They are only let through to the routes their scopes grant, for their
+// teams, and within their rate limit, which is counted apart from the limit
+// on requests in flight. Other requests are passed through untouched.
+func (h *Handler) ScopedTokens() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			raw, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || !strings.HasPrefix(raw, scopedTokenPrefix) {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+
+			token, found, err := h.storage.GetScopedToken(ctx, hashToken(raw))
+			if err != nil {
+				return h.storageFailure(c, fmt.Errorf("look up scoped token: %w", err), "failed to authenticate")
+			}
+			if !found || !token.Valid() {
+				return c.JSON(http.StatusUnauthorized, ErrorResponse{Error: "authentication required"})
+			}
+
+			if !scopesGrant(token.Scopes, c.Request().Method+" "+c.Path()) {
+				return c.JSON(http.StatusForbidden, ErrorResponse{Error: "the token is not allowed to use this endpoint"})
+			}
+			if len(token.Teams) > 0 && !slices.Contains(token.Teams, requestTeam(c)) {
+				return c.JSON(http.StatusForbidden, ErrorResponse{Error: "the token is not allowed for the team"})
+			}
+
+			now := h.now()
+			if reset, ok := h.tokenLimits.allow(token.ID, token.RateLimit, now); !ok {
+				seconds := max(int(math.Ceil(reset.Sub(now).Seconds())), 1)
+				c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(seconds))
+				return c.JSON(http.StatusTooManyRequests, ErrorResponse{Error: "rate limit of the token exceeded, retry later"})
+			}
+
+			// Usage is only kept for cleanup, so failing to record it does
+			// not fail the request
+			if err := h.storage.RecordScopedTokenUse(ctx, token.ID, now.UTC()); err != nil {
+				h.log(c).Warn("failed to record scoped token use", zap.Int64("id", token.ID), zap.Error(err))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// scopesGrant reports whether any of the scopes grants the route.
+func scopesGrant(scopes []string, route string) bool {
+	for _, scope := range scopes {
+		if slices.Contains(tokenScopes[scope], route) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// requestTeam returns the team a request is about, from its path or its
+// query, or an empty string for requests about no single team.
+func requestTeam(c echo.Context) string {
+	if team := c.Param("team"); team != "" {
+		return team
+	}
+
+	return c.QueryParam("team")
+}
+
+// tokenLimiter counts the requests of each scoped token in one-minute
+// windows.
+type tokenLimiter struct {
+	mu      sync.Mutex
+	windows map[int64]tokenWindow
+}
+
+// tokenWindow is the count of requests of a token since start.
+type tokenWindow struct {
+	start time.Time
+	count int
+}
+
+// allow counts a request of the token at the instant. It reports whether
+// the token is still within limit requests of the current window, along with
+// the end of the window. A non-positive limit allows every request.
+func (l *tokenLimiter) allow(id int64, limit int, at time.Time) (time.Time, bool) {
+	start := at.Truncate(time.Minute)
+	end := start.Add(time.Minute)
+	if limit <= 0 {
+		return end, true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.windows == nil {
+		l.windows = make(map[int64]tokenWindow)
+	}
+
+	window := l.windows[id]
+	if !window.start.Equal(start) {
+		window = tokenWindow{start: start}
+	}
+	if window.count >= limit {
+		return end, false
+	}
+
+	window.count++
+	l.windows[id] = window
+
+	return end, true
+}
+
+// newScopedTokenResponse converts a scoped token without the token itself.
+func newScopedTokenResponse(token storage.ScopedToken) ScopedTokenResponse {
+	resp := ScopedTokenResponse{
+		ID:        token.ID,
+		Name:      token.Name,
+		Scope:     token.Scopes,
+		Teams:     token.Teams,
+		RateLimit: token.RateLimit,
+		Uses:      token.Uses,
+		CreatedAt: token.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !token.LastUsedAt.IsZero() {
+		resp.LastUsedAt = token.LastUsedAt.UTC().Format(time.RFC3339)
+	}
+	if !token.RevokedAt.IsZero() {
+		resp.RevokedAt = token.RevokedAt.UTC().Format(time.RFC3339)
+	}
+
+	return resp
+}
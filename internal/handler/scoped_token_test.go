@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newScopedTokenServer returns a server guarded by scoped tokens with a
+// backend and a frontend team, on Monday March 2, 2026 at 10:00:30 UTC.
+func newScopedTokenServer(t *testing.T) (*echo.Echo, *fakeClock) {
+	t.Helper()
+
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 10, 0, 30, 0, time.UTC)}
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.now = clock.Now
+
+	e.Use(h.ScopedTokens())
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.GET("/oncall/all", h.AllOncall)
+	e.GET("/teams/:team/stats", h.TeamStats)
+
+	admin := e.Group("/admin", h.Authenticate(auth.RoleAdmin, "secret"))
+	admin.POST("/tokens", h.CreateScopedToken)
+	admin.GET("/tokens", h.ListScopedTokens)
+	admin.DELETE("/tokens/:id", h.RevokeScopedToken)
+
+	for _, team := range []string{"backend-team", "frontend-team"} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest(team), "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	return e, clock
+}
+
+// createScopedToken creates a scoped token with the admin token and returns it.
+func createScopedToken(t *testing.T, e *echo.Echo, req ScopedTokenRequest) ScopedTokenResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/admin/tokens", req, "secret")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp ScopedTokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+const scopedLookup = "/schedule?team=backend-team&time=2026-03-02T10:00:00Z"
+
+func TestScopedToken_Scope(t *testing.T) {
+	e, clock := newScopedTokenServer(t)
+
+	token := createScopedToken(t, e, ScopedTokenRequest{Name: "dashboard", Scope: []string{ScopeOncallRead}})
+	assert.Equal(t, "dashboard", token.Name)
+	assert.Equal(t, []string{ScopeOncallRead}, token.Scope)
+	assert.Equal(t, defaultTokenRateLimit, token.RateLimit)
+	assert.Len(t, token.Token, len(scopedTokenPrefix)+64)
+
+	rec := serveJSON(e, http.MethodGet, scopedLookup, nil, token.Token)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rec = serveJSON(e, http.MethodGet, "/oncall/all", nil, token.Token)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Mutations and other reads, open ones included, are not in scope
+	rec = serveJSON(e, http.MethodPost, "/schedule", quotaRequest("payments-team"), token.Token)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team/stats", nil, token.Token)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = serveJSON(e, http.MethodGet, "/admin/tokens", nil, token.Token)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Uses are tracked for cleanup
+	rec = serveJSON(e, http.MethodGet, "/admin/tokens", nil, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var tokens []ScopedTokenResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &tokens))
+	require.Len(t, tokens, 1)
+	assert.Empty(t, tokens[0].Token)
+	assert.Equal(t, int64(2), tokens[0].Uses)
+	assert.Equal(t, "2026-03-02T10:00:30Z", tokens[0].LastUsedAt)
+
+	// Revoked tokens stop working
+	rec = serveJSON(e, http.MethodDelete, fmt.Sprintf("/admin/tokens/%d", token.ID), nil, "secret")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	rec = serveJSON(e, http.MethodDelete, fmt.Sprintf("/admin/tokens/%d", token.ID), nil, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	clock.now = clock.now.Add(time.Hour)
+	rec = serveJSON(e, http.MethodGet, scopedLookup, nil, token.Token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = serveJSON(e, http.MethodGet, scopedLookup, nil, scopedTokenPrefix+"unknown")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestScopedToken_TeamRestriction(t *testing.T) {
+	e, _ := newScopedTokenServer(t)
+
+	token := createScopedToken(t, e, ScopedTokenRequest{
+		Name:  "backend-dashboard",
+		Scope: []string{ScopeOncallRead},
+		Teams: []string{"backend-team"},
+	})
+	assert.Equal(t, []string{"backend-team"}, token.Teams)
+
+	rec := serveJSON(e, http.MethodGet, scopedLookup, nil, token.Token)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=frontend-team&time=2026-03-02T10:00:00Z", nil, token.Token)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// The lookup of every team is about more than the allowed one
+	rec = serveJSON(e, http.MethodGet, "/oncall/all", nil, token.Token)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestScopedToken_RateLimit(t *testing.T) {
+	e, clock := newScopedTokenServer(t)
+
+	limited := createScopedToken(t, e, ScopedTokenRequest{Name: "script", Scope: []string{ScopeOncallRead}, RateLimit: 2})
+	other := createScopedToken(t, e, ScopedTokenRequest{Name: "dashboard", Scope: []string{ScopeOncallRead}, RateLimit: 2})
+
+	for range 2 {
+		rec := serveJSON(e, http.MethodGet, scopedLookup, nil, limited.Token)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	rec := serveJSON(e, http.MethodGet, scopedLookup, nil, limited.Token)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get(echo.HeaderRetryAfter))
+
+	// Each token has a limit of its own, and requests without one have none
+	rec = serveJSON(e, http.MethodGet, scopedLookup, nil, other.Token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	for range 3 {
+		rec = serveJSON(e, http.MethodGet, scopedLookup, nil, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	// The limit is counted again the next minute
+	clock.now = clock.now.Add(30 * time.Second)
+	rec = serveJSON(e, http.MethodGet, scopedLookup, nil, limited.Token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestScopedToken_Validation(t *testing.T) {
+	e, _ := newScopedTokenServer(t)
+
+	for name, req := range map[string]ScopedTokenRequest{
+		"no name":       {Scope: []string{ScopeOncallRead}},
+		"no scope":      {Name: "dashboard"},
+		"unknown scope": {Name: "dashboard", Scope: []string{"schedule:write"}},
+		"empty team":    {Name: "dashboard", Scope: []string{ScopeOncallRead}, Teams: []string{" "}},
+		"negative rate": {Name: "dashboard", Scope: []string{ScopeOncallRead}, RateLimit: -1},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPost, "/admin/tokens", req, "secret")
+			assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		})
+	}
+
+	createScopedToken(t, e, ScopedTokenRequest{Name: "dashboard", Scope: []string{ScopeOncallRead}})
+	rec := serveJSON(e, http.MethodPost, "/admin/tokens", ScopedTokenRequest{Name: "dashboard", Scope: []string{ScopeOncallRead}}, "secret")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
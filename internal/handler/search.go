@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// defaultSearchLimit is the page size of a schedule search without a limit
+// query parameter, and maxSearchLimit bounds it.
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 200
+)
+
+// searchDayWindow is how far ahead the shifts of cron and RRULE schedules
+// are looked at to tell the weekdays they fall on.
+const searchDayWindow = 5 * 7 * 24 * time.Hour
+
+// ScheduleMatch represents a schedule listing the searched member, with the
+// name it is listed under and its position among the members.
+type ScheduleMatch struct {
+	Team       string `json:"team"`
+	ScheduleID string `json:"schedule_id"`
+	Schedule   string `json:"schedule"`
+	Member     string `json:"member"`
+	Position   int    `json:"position"`
+}
+
+// ScheduleSearchResponse represents a page of the schedules listing a member
+// under any of its aliases. NextOffset is set when there are more.
+type ScheduleSearchResponse struct {
+	Member     string          `json:"member"`
+	Aliases    []string        `json:"aliases"`
+	Total      int             `json:"total"`
+	Schedules  []ScheduleMatch `json:"schedules"`
+	NextOffset int             `json:"next_offset,omitempty"`
+}
+
+// SearchSchedules handles schedule searches by member across teams. The
+// member is matched under its aliases, and the optional day and repeated tag
+// query parameters only keep the schedules with shifts on the weekday and
+// carrying all of the tags. Results are ordered by team and paginated with
+// the offset and limit query parameters.
+func (h *Handler) SearchSchedules(c echo.Context) error {
+	member := strings.TrimSpace(c.QueryParam("member"))
+	if member == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "member query parameter is required"})
+	}
+
+	var day *time.Weekday
+	if value := strings.TrimSpace(c.QueryParam("day")); value != "" {
+		weekday, err := parseWeekday(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+		}
+		day = &weekday
+	}
+
+	tags, err := queryTags(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	offset, limit, err := parsePage(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	aliases, err := h.memberAliases(ctx, member)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get aliases of member %q: %w", member, err), "failed to search schedules")
+	}
+
+	found, err := h.storage.FindSchedulesByMembers(ctx, aliases)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("find schedules of member %q: %w", member, err), "failed to search schedules")
+	}
+
+	now := h.now()
+	matches := make([]ScheduleMatch, 0, len(found))
+	for _, m := range found {
+		if !m.Schedule.HasTags(tags) || (day != nil && !shiftsOnDay(m.Schedule, *day, now)) {
+			continue
+		}
+
+		matches = append(matches, ScheduleMatch{
+			Team:       m.Team,
+			ScheduleID: m.Schedule.ID,
+			Schedule:   m.Schedule.Name,
+			Member:     m.Member,
+			Position:   m.Position,
+		})
+	}
+
+	resp := ScheduleSearchResponse{
+		Member:    member,
+		Aliases:   aliases,
+		Total:     len(matches),
+		Schedules: matches[min(offset, len(matches)):min(offset+limit, len(matches))],
+	}
+	if offset+limit < len(matches) {
+		resp.NextOffset = offset + limit
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// memberAliases returns the names a member may be listed under: the member
+// itself and, when it is the user name or one of the emails of provisioned
+// users, their user names and emails. Emails are compared case-insensitively.
+func (h *Handler) memberAliases(ctx context.Context, member string) ([]string, error) {
+	users, err := h.storage.FindUsers(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	aliases := []string{member}
+	for _, user := range users {
+		if user.UserName != member && !slices.ContainsFunc(user.Emails, func(email string) bool {
+			return strings.EqualFold(email, member)
+		}) {
+			continue
+		}
+
+		for _, alias := range append([]string{user.UserName}, user.Emails...) {
+			if !slices.Contains(aliases, alias) {
+				aliases = append(aliases, alias)
+			}
+		}
+	}
+
+	return aliases, nil
+}
+
+// shiftsOnDay reports whether the schedule has shifts starting on the
+// weekday. Schedules listing days are checked against them, cron and RRULE
+// ones against their shifts in the weeks after from.
+func shiftsOnDay(sched storage.Schedule, day time.Weekday, from time.Time) bool {
+	if sched.Cron == "" && sched.RRule == "" {
+		return slices.Contains(sched.Days, day)
+	}
+
+	for at := from; ; {
+		shift, ok := sched.NextShift(at)
+		if !ok || shift.Start.Sub(from) > searchDayWindow {
+			return false
+		}
+		if shift.Start.Weekday() == day {
+			return true
+		}
+		at = shift.Start
+	}
+}
+
+// parsePage parses the offset and limit query parameters of a paginated
+// listing.
+func parsePage(c echo.Context) (int, int, error) {
+	offset, limit := 0, defaultSearchLimit
+
+	if value := c.QueryParam("offset"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid offset, use a non-negative integer")
+		}
+		offset = n
+	}
+
+	if value := c.QueryParam("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			return 0, 0, fmt.Errorf("invalid limit, use an integer from 1 to %d", maxSearchLimit)
+		}
+		limit = n
+	}
+
+	return offset, limit, nil
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newSearchServer creates schedules listing Bob in the backend and frontend
+// teams, one of them under his email, and provisions Bob with that email.
+func newSearchServer(t *testing.T) *echo.Echo {
+	t.Helper()
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedules/search", h.SearchSchedules)
+
+	add := func(team, name string, members []string, days []string, tags []string) {
+		req := quotaRequest(team)
+		req.Name = name
+		req.Members = members
+		req.Days = days
+		req.Tags = tags
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+	add("backend-team", "Weekday", []string{"Alice", "Bob"}, []string{"Monday-Friday"}, []string{"prod"})
+	add("backend-team", "Weekend", []string{"Charlie"}, []string{"Saturday", "Sunday"}, nil)
+	add("frontend-team", "Weekend", []string{"bob@example.com", "Dana"}, []string{"Saturday"}, nil)
+
+	_, _, err := store.AddUser(t.Context(), storage.User{UserName: "Bob", Emails: []string{"bob@example.com"}, Active: true})
+	require.NoError(t, err)
+
+	return e
+}
+
+func searchSchedules(t *testing.T, e *echo.Echo, query string) ScheduleSearchResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodGet, "/schedules/search?"+query, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp ScheduleSearchResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+func TestSearchSchedules(t *testing.T) {
+	e := newSearchServer(t)
+
+	resp := searchSchedules(t, e, "member=Bob")
+	assert.Equal(t, []string{"Bob", "bob@example.com"}, resp.Aliases)
+	assert.Equal(t, 2, resp.Total)
+	require.Len(t, resp.Schedules, 2)
+	assert.Equal(t, ScheduleMatch{Team: "backend-team", ScheduleID: resp.Schedules[0].ScheduleID, Schedule: "Weekday", Member: "Bob", Position: 1}, resp.Schedules[0])
+	assert.Equal(t, ScheduleMatch{Team: "frontend-team", ScheduleID: resp.Schedules[1].ScheduleID, Schedule: "Weekend", Member: "bob@example.com", Position: 0}, resp.Schedules[1])
+	assert.Zero(t, resp.NextOffset)
+
+	// The email resolves to the same user
+	resp = searchSchedules(t, e, "member=BOB@example.com")
+	assert.Equal(t, 2, resp.Total)
+
+	resp = searchSchedules(t, e, "member=Bob&day=Saturday")
+	require.Len(t, resp.Schedules, 1)
+	assert.Equal(t, "frontend-team", resp.Schedules[0].Team)
+
+	resp = searchSchedules(t, e, "member=Bob&tag=prod")
+	require.Len(t, resp.Schedules, 1)
+	assert.Equal(t, "backend-team", resp.Schedules[0].Team)
+
+	resp = searchSchedules(t, e, "member=Zed")
+	assert.Equal(t, []string{"Zed"}, resp.Aliases)
+	assert.Zero(t, resp.Total)
+	assert.Empty(t, resp.Schedules)
+	assert.NotNil(t, resp.Schedules)
+}
+
+func TestSearchSchedules_Pagination(t *testing.T) {
+	e := newSearchServer(t)
+
+	resp := searchSchedules(t, e, "member=Bob&limit=1")
+	assert.Equal(t, 2, resp.Total)
+	require.Len(t, resp.Schedules, 1)
+	assert.Equal(t, "backend-team", resp.Schedules[0].Team)
+	assert.Equal(t, 1, resp.NextOffset)
+
+	resp = searchSchedules(t, e, "member=Bob&limit=1&offset=1")
+	require.Len(t, resp.Schedules, 1)
+	assert.Equal(t, "frontend-team", resp.Schedules[0].Team)
+	assert.Zero(t, resp.NextOffset)
+
+	resp = searchSchedules(t, e, "member=Bob&offset=5")
+	assert.Equal(t, 2, resp.Total)
+	assert.Empty(t, resp.Schedules)
+}
+
+func TestSearchSchedules_Validation(t *testing.T) {
+	e := newSearchServer(t)
+
+	for _, query := range []string{"", "member=+", "member=Bob&day=Someday", "member=Bob&tag=Prod", "member=Bob&limit=0", "member=Bob&limit=201", "member=Bob&offset=-1"} {
+		rec := serveJSON(e, http.MethodGet, "/schedules/search?"+query, nil, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"go.uber.org/zap"
+	"go.yaml.in/yaml/v3"
+)
+
+// TeamDocument is a team with its schedules in the request format, as found
+// in seed files and backups. Seeding ignores the pause.
+type TeamDocument struct {
+	Team      string         `json:"team" yaml:"team"`
+	Schedules []Request      `json:"schedules" yaml:"schedules"`
+	Groups    []GroupRequest `json:"groups,omitempty" yaml:"groups,omitempty"`
+	Pause     *PauseDocument `json:"pause,omitempty" yaml:"pause,omitempty"`
+}
+
+// SeedDocument is the document of a seed file.
+type SeedDocument struct {
+	Teams []TeamDocument `json:"teams" yaml:"teams"`
+}
+
+// SeedSummary counts the schedules of a seed document by outcome.
+type SeedSummary struct {
+	Created int
+	Skipped int
+	Failed  int
+}
+
+// Seed loads the YAML seed document from r, creating each schedule through
+// the same validation as CreateSchedule. Schedules whose team already has a
+// schedule with the same name are skipped, so seeding is idempotent. Unless
+// strict is set, a malformed document or schedule is logged and skipped,
+// otherwise the first one aborts seeding with an error.
+func (h *Handler) Seed(ctx context.Context, r io.Reader, strict bool) (SeedSummary, error) {
+	var summary SeedSummary
+
+	var doc SeedDocument
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil && err != io.EOF {
+		if strict {
+			return summary, fmt.Errorf("failed to parse seed document: %w", err)
+		}
+		h.logger.Warn("failed to parse seed document, skipping it", zap.Error(err))
+		return summary, nil
+	}
+
+	for _, team := range doc.Teams {
+		existing, err := h.storage.GetTeam(ctx, team.Team)
+		if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+			return summary, fmt.Errorf("failed to get team %s: %w", team.Team, err)
+		}
+
+		names := make(map[string]bool)
+		for _, sched := range existing.Schedules {
+			names[sched.Name] = true
+		}
+
+		for _, req := range team.Schedules {
+			req.Team = team.Team
+
+			if names[req.Name] {
+				summary.Skipped++
+				continue
+			}
+
+			if err := h.seedSchedule(ctx, &req); err != nil {
+				if strict {
+					return summary, err
+				}
+				h.logger.Warn("failed to seed schedule, skipping it", zap.Error(err))
+				summary.Failed++
+				continue
+			}
+
+			names[req.Name] = true
+			summary.Created++
+		}
+
+		// Groups need the team, which the schedules create
+		if err := h.seedGroups(ctx, team); err != nil {
+			if strict {
+				return summary, err
+			}
+			h.logger.Warn("failed to seed groups, skipping them", zap.Error(err))
+		}
+	}
+
+	h.logger.Info("seed loaded",
+		zap.Int("created", summary.Created),
+		zap.Int("skipped", summary.Skipped),
+		zap.Int("failed", summary.Failed),
+	)
+
+	return summary, nil
+}
+
+// seedGroups creates the groups of a seed document team that it does not
+// have yet.
+func (h *Handler) seedGroups(ctx context.Context, team TeamDocument) error {
+	if len(team.Groups) == 0 {
+		return nil
+	}
+
+	existing, err := h.storage.ListGroups(ctx, team.Team)
+	if err != nil {
+		return fmt.Errorf("failed to list groups of team %s: %w", team.Team, err)
+	}
+
+	for _, req := range team.Groups {
+		if slices.ContainsFunc(existing, func(g storage.Group) bool { return g.Name == req.Name }) {
+			continue
+		}
+
+		group, err := parseGroup(req.Name, req.Members)
+		if err != nil {
+			return fmt.Errorf("invalid group %q of team %q: %w", req.Name, team.Team, err)
+		}
+		if _, _, err := h.storage.SetGroup(ctx, team.Team, group); err != nil {
+			return fmt.Errorf("failed to add group %q of team %q: %w", req.Name, team.Team, err)
+		}
+	}
+
+	return nil
+}
+
+// seedSchedule validates and creates a single schedule of a seed document.
+func (h *Handler) seedSchedule(ctx context.Context, req *Request) error {
+	schedule, err := h.parseRequest(req)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q of team %q: %w", req.Name, req.Team, err)
+	}
+
+	if _, err := h.storage.AddSchedule(ctx, req.Team, schedule); err != nil {
+		return fmt.Errorf("failed to add schedule %q of team %q: %w", req.Name, req.Team, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func seedFixture(t *testing.T, h *Handler, strict bool) (SeedSummary, error) {
+	t.Helper()
+
+	f, err := os.Open("testdata/seed.yaml")
+	require.NoError(t, err)
+	defer f.Close()
+
+	return h.Seed(context.Background(), f, strict)
+}
+
+func TestSeed_LoadsFixture(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	logger, _ := zap.NewDevelopment()
+	h := New(store, logger)
+
+	summary, err := seedFixture(t, h, false)
+	require.NoError(t, err)
+	assert.Equal(t, SeedSummary{Created: 3, Failed: 1}, summary)
+
+	team, err := store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 2)
+	assert.Len(t, team.Schedules[0].Days, 5)
+
+	oncall, err := store.GetCurrentOncall(context.Background(), "frontend-team", time.Date(2025, 4, 26, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Dana", oncall)
+
+	// Seeding again skips the schedules that already exist
+	summary, err = seedFixture(t, h, false)
+	require.NoError(t, err)
+	assert.Equal(t, SeedSummary{Skipped: 3, Failed: 1}, summary)
+
+	team, err = store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+	assert.Len(t, team.Schedules, 2)
+}
+
+func TestSeed_Strict(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	h := New(storage.NewMemoryStorage(), logger)
+
+	_, err := seedFixture(t, h, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Broken")
+}
+
+func TestSeed_MalformedDocument(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	h := New(storage.NewMemoryStorage(), logger)
+
+	summary, err := h.Seed(context.Background(), strings.NewReader("teams: [oops"), false)
+	require.NoError(t, err)
+	assert.Equal(t, SeedSummary{}, summary)
+
+	_, err = h.Seed(context.Background(), strings.NewReader("teams: [oops"), true)
+	assert.Error(t, err)
+}
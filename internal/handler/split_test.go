@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newSplitServer creates a weekday schedule of Alice, Bob and Charlie
+// anchored on Monday 2026-03-02 sharing each shift between split members.
+func newSplitServer(t *testing.T, split int) *echo.Echo {
+	t.Helper()
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.GET("/teams/:team/timeline", h.TeamTimeline)
+
+	req := quotaRequest("backend-team")
+	req.Members = []string{"Alice", "Bob", "Charlie"}
+	req.Anchor = "2026-03-02"
+	req.Split = split
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e
+}
+
+func TestSplit_Oncall(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 2, hour, minute, 0, 0, time.UTC)
+	}
+
+	t.Run("two members", func(t *testing.T) {
+		e := newSplitServer(t, 2)
+
+		assert.Equal(t, "Alice", oncallAt(t, e, at(9, 0)))
+		assert.Equal(t, "Alice", oncallAt(t, e, at(12, 59)))
+		assert.Equal(t, "Bob", oncallAt(t, e, at(13, 0)))
+		assert.Equal(t, "Bob", oncallAt(t, e, at(16, 59)))
+		// The next week starts with Bob, followed by Charlie
+		assert.Equal(t, "Charlie", oncallAt(t, e, at(13, 0).AddDate(0, 0, 7)))
+	})
+
+	t.Run("three members", func(t *testing.T) {
+		e := newSplitServer(t, 3)
+
+		assert.Equal(t, "Alice", oncallAt(t, e, at(11, 39)))
+		assert.Equal(t, "Bob", oncallAt(t, e, at(11, 40)))
+		assert.Equal(t, "Charlie", oncallAt(t, e, at(14, 20)))
+	})
+}
+
+func TestSplit_Timeline(t *testing.T) {
+	e := newSplitServer(t, 3)
+
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/timeline?from=2026-03-02&to=2026-03-03", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TimelineResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Lanes, 1)
+	assert.Equal(t, []TimelineSegment{
+		{Member: "Alice", Start: "2026-03-02T09:00:00Z", End: "2026-03-02T11:40:00Z"},
+		{Member: "Bob", Start: "2026-03-02T11:40:00Z", End: "2026-03-02T14:20:00Z"},
+		{Member: "Charlie", Start: "2026-03-02T14:20:00Z", End: "2026-03-02T17:00:00Z"},
+	}, resp.Lanes[0].Segments)
+}
+
+func TestSplit_Validation(t *testing.T) {
+	e := newSplitServer(t, 0)
+
+	tests := []struct {
+		name string
+		req  func(*Request)
+	}{
+		{"negative", func(r *Request) { r.Split = -1 }},
+		{"more than members", func(r *Request) { r.Split = 3 }},
+		{"more than minutes", func(r *Request) {
+			r.Members = []string{"Alice", "Bob", "Charlie"}
+			r.Start, r.End = "9:00AM", "9:02AM"
+			r.Split = 3
+		}},
+		{"fixed assignment", func(r *Request) {
+			r.Members = nil
+			r.Assignment = AssignmentFixed
+			r.DayAssignments = map[string]string{"Monday": "Alice"}
+			r.Days = nil
+			r.Split = 2
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := quotaRequest("frontend-team")
+			tt.req(&req)
+			rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+		})
+	}
+}
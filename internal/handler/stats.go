@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// maxStatsRange bounds the range of the statistics of a team.
+const maxStatsRange = 366 * 24 * time.Hour
+
+// MemberStats represents the time a member was on call during a week.
+type MemberStats struct {
+	Member       string  `json:"member"`
+	Hours        float64 `json:"hours"`
+	WeekendHours float64 `json:"weekend_hours"`
+}
+
+// StatsWeek represents a week of the statistics, from midnight of the first
+// day of the team's week. Members are ordered by name.
+type StatsWeek struct {
+	Start   string        `json:"start"`
+	End     string        `json:"end"`
+	Members []MemberStats `json:"members"`
+}
+
+// StatsResponse represents the on-call time of the members of a team, by
+// week of the team's week convention.
+type StatsResponse struct {
+	Team      string      `json:"team"`
+	From      string      `json:"from"`
+	To        string      `json:"to"`
+	WeekStart string      `json:"week_start"`
+	Weekend   []string    `json:"weekend"`
+	Weeks     []StatsWeek `json:"weeks"`
+}
+
+// TeamStats handles requests for the on-call time of the members of a team
+// between from and to, bucketed by the weeks of the team in the tz zone.
+// Weeks start on the week_start of the team and the time on its weekend_days
+// is counted apart. The stretches are resolved like the timeline, so time
+// while the team is paused is not counted, and the first and last weeks only
+// count the time within the range.
+func (h *Handler) TeamStats(c echo.Context) error {
+	teamName := c.Param("team")
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	from, err := h.parseTime(c.QueryParam("from"), "from", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	to, err := h.parseTime(c.QueryParam("to"), "to", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	if !from.Before(to) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must be before to"})
+	}
+	if to.Sub(from) > maxStatsRange {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("range must not exceed %d days", int(maxStatsRange.Hours()/24)),
+		})
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	pause, paused, err := h.storage.GetPause(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get pause of team %q: %w", teamName, err), "failed to retrieve team")
+	}
+	if !paused {
+		pause = storage.Pause{}
+	}
+
+	wk := h.weeks.Of(teamName)
+
+	resp := StatsResponse{
+		Team:      teamName,
+		From:      from.In(loc).Format(time.RFC3339),
+		To:        to.In(loc).Format(time.RFC3339),
+		WeekStart: wk.Start.String(),
+		Weekend:   []string{},
+		Weeks:     []StatsWeek{},
+	}
+	for _, day := range wk.WeekendDays() {
+		resp.Weekend = append(resp.Weekend, day.String())
+	}
+
+	// Every week overlapping the range gets a bucket, even when nobody was on call
+	var starts []time.Time
+	for start := wk.StartOf(from.In(loc)); start.Before(to); start = start.AddDate(0, 0, 7) {
+		starts = append(starts, start)
+	}
+
+	hours := make([]map[string]*MemberStats, len(starts))
+	for i := range hours {
+		hours[i] = make(map[string]*MemberStats)
+	}
+
+	for _, duty := range storage.DutyTimeline(team.Schedules, from, to) {
+		for _, stretch := range outsidePause(duty.Shift, pause) {
+			for i, start := range starts {
+				end := start.AddDate(0, 0, 7)
+				if !stretch.Start.Before(end) || !start.Before(stretch.End) {
+					continue
+				}
+
+				// The stretch is cut at the boundaries of the week
+				part := storage.Shift{Start: later(stretch.Start, start).In(loc), End: earlier(stretch.End, end).In(loc)}
+
+				// Every member of a multi-person shift is on call for all of it
+				for _, member := range duty.Members() {
+					stats, ok := hours[i][member]
+					if !ok {
+						stats = &MemberStats{Member: member}
+						hours[i][member] = stats
+					}
+					stats.Hours += part.End.Sub(part.Start).Hours()
+					stats.WeekendHours += wk.WeekendTime(part.Start, part.End).Hours()
+				}
+			}
+		}
+	}
+
+	for i, start := range starts {
+		week := StatsWeek{
+			Start:   start.Format(time.RFC3339),
+			End:     start.AddDate(0, 0, 7).Format(time.RFC3339),
+			Members: make([]MemberStats, 0, len(hours[i])),
+		}
+		for _, stats := range hours[i] {
+			week.Members = append(week.Members, *stats)
+		}
+		slices.SortFunc(week.Members, func(a, b MemberStats) int {
+			return cmp.Compare(a.Member, b.Member)
+		})
+
+		resp.Weeks = append(resp.Weeks, week)
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
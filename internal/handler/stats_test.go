@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/week"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newWeekServer returns a server whose gulf-team has a week starting on
+// Saturday with a Thursday and Friday weekend.
+func newWeekServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	weeks, err := week.New(&config.Config{Week: config.WeekConfig{
+		Start:       "monday",
+		WeekendDays: []string{"saturday", "sunday"},
+		Teams: map[string]config.WeekTeamConfig{
+			"gulf-team": {Start: "saturday", WeekendDays: []string{"thursday", "friday"}},
+		},
+	}})
+	require.NoError(t, err)
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	h.SetWeeks(weeks)
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team/stats", h.TeamStats)
+
+	return e, store
+}
+
+// weekRequest is a 9 to 5 schedule of the member on the days.
+func weekRequest(team, name, member string, days ...string) Request {
+	return Request{
+		Name:    name,
+		Team:    team,
+		Members: []string{member},
+		Days:    days,
+		Start:   "9:00AM",
+		End:     "5:00PM",
+	}
+}
+
+func TestCreateSchedule_WeekKeywords(t *testing.T) {
+	e, store := newWeekServer(t)
+
+	for _, req := range []Request{
+		weekRequest("gulf-team", "Weekdays", "Alice", "weekdays"),
+		weekRequest("gulf-team", "Weekends", "Bob", "Weekends"),
+		weekRequest("backend-team", "Weekdays", "Alice", "weekdays"),
+	} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	team, err := store.GetTeam(context.Background(), "gulf-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 2)
+	assert.Equal(t, []time.Weekday{time.Saturday, time.Sunday, time.Monday, time.Tuesday, time.Wednesday}, team.Schedules[0].Days)
+	assert.Equal(t, []time.Weekday{time.Thursday, time.Friday}, team.Schedules[1].Days)
+
+	// Teams without a convention of their own have the configured one
+	team, err = store.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+	assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, team.Schedules[0].Days)
+}
+
+func TestTeamStats_WeekBuckets(t *testing.T) {
+	e, _ := newWeekServer(t)
+
+	for _, req := range []Request{
+		weekRequest("gulf-team", "Weekdays", "Alice", "weekdays"),
+		weekRequest("gulf-team", "Weekends", "Bob", "weekends"),
+	} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	// Thursday March 5 to Sunday March 15, 2026
+	rec := serveJSON(e, http.MethodGet, "/teams/gulf-team/stats?from=2026-03-05&to=2026-03-15", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp StatsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Saturday", resp.WeekStart)
+	assert.Equal(t, []string{"Thursday", "Friday"}, resp.Weekend)
+
+	// Weeks run from Saturday to Saturday, the first and last are cut at the range
+	assert.Equal(t, []StatsWeek{
+		{
+			Start:   "2026-02-28T00:00:00Z",
+			End:     "2026-03-07T00:00:00Z",
+			Members: []MemberStats{{Member: "Bob", Hours: 16, WeekendHours: 16}},
+		},
+		{
+			Start: "2026-03-07T00:00:00Z",
+			End:   "2026-03-14T00:00:00Z",
+			Members: []MemberStats{
+				{Member: "Alice", Hours: 40},
+				{Member: "Bob", Hours: 16, WeekendHours: 16},
+			},
+		},
+		{
+			Start:   "2026-03-14T00:00:00Z",
+			End:     "2026-03-21T00:00:00Z",
+			Members: []MemberStats{{Member: "Alice", Hours: 8}},
+		},
+	}, resp.Weeks)
+}
+
+func TestTeamStats_Errors(t *testing.T) {
+	e, _ := newWeekServer(t)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", weekRequest("gulf-team", "Weekdays", "Alice", "weekdays"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	for _, tt := range []struct {
+		target string
+		code   int
+	}{
+		{"/teams/gulf-team/stats?to=2026-03-15", http.StatusBadRequest},
+		{"/teams/gulf-team/stats?from=2026-03-15&to=2026-03-05", http.StatusBadRequest},
+		{"/teams/gulf-team/stats?from=2025-01-01&to=2026-03-05", http.StatusBadRequest},
+		{"/teams/gulf-team/stats?from=2026-03-05&to=2026-03-15&tz=Mars/Olympus", http.StatusBadRequest},
+		{"/teams/other-team/stats?from=2026-03-05&to=2026-03-15", http.StatusNotFound},
+	} {
+		rec := serveJSON(e, http.MethodGet, tt.target, nil, "")
+		assert.Equal(t, tt.code, rec.Code, tt.target)
+	}
+}
+
+func TestCreateSchedule_WeekendUncovered(t *testing.T) {
+	e, _, _ := newWarningsServer(t)
+
+	// Business hours leave weekends out on purpose
+	assert.Empty(t, createWarnings(t, e, quotaRequest("backend-team")))
+
+	req := quotaRequest("frontend-team")
+	req.Days = []string{"weekdays"}
+	req.Start = "12:00AM"
+	req.End = "11:59PM"
+	assert.Equal(t, []string{WarningWeekendUncovered}, createWarnings(t, e, req))
+
+	// Another schedule of the team covering the weekend answers the warning
+	req = quotaRequest("mobile-team")
+	req.Days = []string{"weekends"}
+	assert.Empty(t, createWarnings(t, e, req))
+
+	req.Name = "Around the clock"
+	req.Days = []string{"weekdays"}
+	req.Start = "12:00AM"
+	req.End = "11:59PM"
+	assert.Empty(t, createWarnings(t, e, req))
+}
+
+func TestCreateSchedule_WeekendUncoveredByWeek(t *testing.T) {
+	create := func(t *testing.T, days ...string) []Warning {
+		t.Helper()
+
+		e, _ := newWeekServer(t)
+		req := weekRequest("gulf-team", "Around the clock", "Alice", days...)
+		req.Start = "12:00AM"
+		req.End = "11:59PM"
+
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+		var resp CreateScheduleResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+		return resp.Warnings
+	}
+
+	// Monday to Friday covers the Thursday and Friday weekend of the team
+	assert.Empty(t, create(t, "Mon-Fri"))
+
+	warnings := create(t, "weekdays")
+	require.Len(t, warnings, 1)
+	assert.Equal(t, WarningWeekendUncovered, warnings[0].Code)
+	assert.Contains(t, warnings[0].Message, "nobody is on call on Thursday and Friday")
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CodeStorageUnavailable is the error code of requests refused while storage
+// is known to be unavailable.
+const CodeStorageUnavailable = "STORAGE_UNAVAILABLE"
+
+// storageUnavailableMessage is returned when storage is unavailable.
+const storageUnavailableMessage = "storage is unavailable"
+
+// storageBypassRoutes are the routes besides health probes served while
+// storage is unavailable: metrics, and the on-call lookup, which falls back
+// to the last known on-call member of the team.
+var storageBypassRoutes = map[string]bool{
+	http.MethodGet + " /metrics":  true,
+	http.MethodGet + " /schedule": true,
+}
+
+// StorageHealth reports whether storage is known to be unavailable and when
+// it is tried again, without calling it. *storage.BreakerStorage implements
+// it with its circuit breaker.
+type StorageHealth interface {
+	RetryAt() (time.Time, bool)
+}
+
+// SetStorageHealth sets the source StorageGuard consults. Without one, as
+// with the memory storage, every request reaches the storage.
+func (h *Handler) SetStorageHealth(s StorageHealth) {
+	h.storageHealth = s
+}
+
+// StorageGuard refuses requests with a 503 while storage is known to be
+// unavailable, instead of having each of them wait for the storage to fail.
+// Retry-After is the time left until storage is tried again, after which
+// requests go through on their own.
+func (h *Handler) StorageGuard() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if h.storageHealth == nil || probePaths[c.Path()] || storageBypassRoutes[c.Request().Method+" "+c.Path()] {
+				return next(c)
+			}
+
+			retryAt, unavailable := h.storageHealth.RetryAt()
+			if !unavailable {
+				return next(c)
+			}
+
+			// Rounded up, so clients do not come back before storage is tried
+			seconds := max(int(math.Ceil(retryAt.Sub(h.now()).Seconds())), 1)
+			c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(seconds))
+
+			return c.JSON(http.StatusServiceUnavailable, ErrorResponse{Error: storageUnavailableMessage, Code: CodeStorageUnavailable})
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeStorageHealth reports storage as unavailable until retryAt while down
+// is set.
+type fakeStorageHealth struct {
+	down    bool
+	retryAt time.Time
+}
+
+func (s *fakeStorageHealth) RetryAt() (time.Time, bool) {
+	return s.retryAt, s.down
+}
+
+func newStorageGuardServer(store storage.Storage, health StorageHealth, clock *fakeClock) *echo.Echo {
+	e := echo.New()
+	h := New(store, zap.NewNop())
+	h.now = clock.Now
+	h.SetStorageHealth(health)
+
+	e.Use(h.StorageGuard())
+	e.GET("/health", h.Health)
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.GET("/teams/:team/schedules", h.ListTeamSchedules)
+
+	return e
+}
+
+func TestStorageGuard_FailsFast(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)}
+	health := &fakeStorageHealth{down: true, retryAt: clock.now.Add(2500 * time.Millisecond)}
+
+	// Any call reaching the storage would block the test
+	blocking := &blockingStorage{canceled: make(chan struct{})}
+	e := newStorageGuardServer(blocking, health, clock)
+
+	for _, target := range []struct{ method, path string }{
+		{http.MethodGet, "/teams/backend-team/schedules"},
+		{http.MethodPost, "/schedule"},
+	} {
+		rec := serveJSON(e, target.method, target.path, quotaRequest("backend-team"), "")
+		require.Equal(t, http.StatusServiceUnavailable, rec.Code, target.path)
+		assert.Equal(t, "3", rec.Header().Get(echo.HeaderRetryAfter), target.path)
+
+		var resp ErrorResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, CodeStorageUnavailable, resp.Code)
+	}
+
+	// Past the next probe clients are told to retry right away
+	clock.now = health.retryAt.Add(time.Second)
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/schedules", nil, "")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get(echo.HeaderRetryAfter))
+
+	rec = serveJSON(e, http.MethodGet, "/health", nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(echo.HeaderRetryAfter))
+
+	select {
+	case <-blocking.canceled:
+		assert.Fail(t, "storage was called")
+	default:
+	}
+}
+
+func TestStorageGuard_Recovery(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)}
+	health := &fakeStorageHealth{}
+	e := newStorageGuardServer(storage.NewMemoryStorage(), health, clock)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	health.down = true
+	health.retryAt = clock.now.Add(10 * time.Second)
+
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team/schedules", nil, "")
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "10", rec.Header().Get(echo.HeaderRetryAfter))
+
+	// The on-call lookup keeps its fallback to the last known member
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=2030-01-07T10:00:00Z", nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Requests go through again once the breaker half-opens
+	health.down = false
+
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team/schedules", nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, rec.Header().Get(echo.HeaderRetryAfter))
+}
+
+func TestStorageGuard_WithoutSource(t *testing.T) {
+	// As with the memory storage, nothing is refused
+	clock := &fakeClock{now: time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)}
+	e := newStorageGuardServer(storage.NewMemoryStorage(), nil, clock)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+}
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// SwapLinkActor prefixes the audit log actor of swap requests decided with
+// the link sent to their target, followed by the target.
+const SwapLinkActor = "swap-link"
+
+// swapTokenParam is the query parameter the link of a swap request passes
+// its token in.
+const swapTokenParam = "token"
+
+// SwapRequestRequest represents a request to hand the shift of a schedule
+// starting at ShiftStart over to To. From defaults to the member on duty.
+type SwapRequestRequest struct {
+	ShiftStart string `json:"shift_start"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to"`
+}
+
+// SwapRequestResponse represents a swap request. It expires at the start of
+// its shift while it is pending. The token of its link is never returned.
+type SwapRequestResponse struct {
+	ID         int64  `json:"id"`
+	ScheduleID string `json:"schedule_id"`
+	Team       string `json:"team"`
+	ShiftStart string `json:"shift_start"`
+	ShiftEnd   string `json:"shift_end"`
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Status     string `json:"status"`
+	CreatedAt  string `json:"created_at"`
+	DecidedAt  string `json:"decided_at,omitempty"`
+}
+
+// SwapRequestsResponse represents the swap requests of a schedule, ordered by
+// ID.
+type SwapRequestsResponse struct {
+	SwapRequests []SwapRequestResponse `json:"swap_requests"`
+}
+
+// CreateSwapRequest handles swap requests for an upcoming shift of a
+// schedule. Nothing changes until the target accepts it, who is notified
+// with a link to accept or decline it with.
+func (h *Handler) CreateSwapRequest(c echo.Context) error {
+	var req SwapRequestRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	start, err := time.Parse(time.RFC3339, req.ShiftStart)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid shift_start format, use RFC3339 format"})
+	}
+	if !start.After(h.now()) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "shift_start must be in the future"})
+	}
+
+	to := strings.TrimSpace(req.To)
+	if to == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "to is required"})
+	}
+
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	duty, ok := sched.Schedule.DutyAt(start)
+	if !ok || !duty.Start.Equal(start) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "no shift of the schedule starts at shift_start"})
+	}
+
+	from := strings.TrimSpace(req.From)
+	if from == "" {
+		from = duty.Member
+	}
+	if from != duty.Member {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("%s is not on duty for the shift, %s is", from, duty.Member)})
+	}
+
+	if to == from || !slices.Contains(sched.Schedule.Members, to) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("%s is not another member of the schedule", to)})
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return h.internalError(c, fmt.Errorf("generate swap request token for schedule %q: %w", sched.Schedule.ID, err), "failed to generate swap request token")
+	}
+	raw := hex.EncodeToString(secret)
+
+	request, found, err := h.storage.AddSwapRequest(ctx, sched.Team, storage.SwapRequest{
+		ScheduleID: sched.Schedule.ID,
+		ShiftStart: duty.Start,
+		ShiftEnd:   duty.End,
+		From:       from,
+		To:         to,
+		TokenHash:  hashToken(raw),
+	})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add swap request to schedule %q of team %q: %w", sched.Schedule.ID, sched.Team, err), "failed to create swap request")
+	}
+	// The schedule may have been deleted in the meantime
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+
+	h.logger.Info("swap request created",
+		zap.Int64("id", request.ID),
+		zap.String("team", sched.Team),
+		zap.String("schedule", sched.Schedule.Name),
+		zap.String("from", from),
+		zap.String("to", to),
+	)
+
+	event := h.swapEvent(ctx, request, sched.Schedule.Name, notify.ChangeRequested)
+	event.Link = fmt.Sprintf("%s://%s/swap-requests/%d/accept?%s=%s", c.Scheme(), c.Request().Host, request.ID, swapTokenParam, raw)
+	h.events.Publish(event)
+
+	return c.JSON(http.StatusCreated, h.newSwapRequestResponse(request))
+}
+
+// ListSwapRequests handles swap request listing requests of a schedule,
+// with their status as of now.
+func (h *Handler) ListSwapRequests(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	requests, err := h.storage.ListSwapRequests(ctx, sched.Team, sched.Schedule.ID)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list swap requests of schedule %q: %w", sched.Schedule.ID, err), "failed to list swap requests")
+	}
+
+	resp := SwapRequestsResponse{SwapRequests: make([]SwapRequestResponse, 0, len(requests))}
+	for _, request := range requests {
+		resp.SwapRequests = append(resp.SwapRequests, h.newSwapRequestResponse(request))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// AcceptSwapRequest handles the acceptance of a swap request by its target,
+// which pins them to the date of the shift.
+func (h *Handler) AcceptSwapRequest(c echo.Context) error {
+	return h.decideSwapRequest(c, true)
+}
+
+// DeclineSwapRequest handles the refusal of a swap request by its target.
+func (h *Handler) DeclineSwapRequest(c echo.Context) error {
+	return h.decideSwapRequest(c, false)
+}
+
+// decideSwapRequest decides a pending swap request. Only its target may,
+// either with the token of the link they were sent or signed in under a
+// name or email the target resolves to. Accepting is a change of the
+// schedule, so it is rejected while the team is frozen.
+func (h *Handler) decideSwapRequest(c echo.Context, accept bool) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid swap request id"})
+	}
+
+	ctx := c.Request().Context()
+
+	request, found, err := h.storage.GetSwapRequest(ctx, id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get swap request %d: %w", id, err), "failed to retrieve swap request")
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "swap request not found"})
+	}
+
+	actor, err := h.swapTarget(c, request)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get aliases of member %q: %w", request.To, err), "failed to decide swap request")
+	}
+	if actor == "" {
+		return c.JSON(http.StatusForbidden, ErrorResponse{Error: fmt.Sprintf("only %s can decide the swap request, sign in or use the link they were sent", request.To)})
+	}
+	ctx = storage.WithActor(ctx, actor)
+	c.SetRequest(c.Request().WithContext(ctx))
+
+	if status := request.StatusAt(h.now()); status != storage.SwapPending {
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: fmt.Sprintf("swap request is %s", status)})
+	}
+
+	sched, err := h.storage.GetSchedule(ctx, request.ScheduleID)
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", request.ScheduleID, err), "failed to retrieve schedule")
+	}
+
+	if accept {
+		change := fmt.Sprintf("accept swap request %d handing the shift of %s to %s on schedule %s", request.ID, request.From, request.To, sched.Schedule.Name)
+		if frozen, err := h.rejectFrozen(c, sched.Team, change); frozen {
+			return err
+		}
+	}
+
+	decided, found, err := h.storage.DecideSwapRequest(ctx, id, accept, h.now())
+	if errors.Is(err, storage.ErrSwapDecided) {
+		// Decided in the meantime or expired since it was read
+		return c.JSON(http.StatusConflict, ErrorResponse{Error: "swap request is not pending anymore"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("decide swap request %d: %w", id, err), "failed to decide swap request")
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "swap request not found"})
+	}
+
+	h.logger.Info("swap request decided",
+		zap.Int64("id", decided.ID),
+		zap.String("team", decided.Team),
+		zap.String("status", decided.Status),
+		zap.String("actor", actor),
+	)
+
+	h.events.Publish(h.swapEvent(ctx, decided, sched.Schedule.Name, decided.Status))
+
+	return c.JSON(http.StatusOK, h.newSwapRequestResponse(decided))
+}
+
+// swapTarget returns the audit log actor of a request deciding the swap
+// request when it comes from its target, and an empty one otherwise.
+func (h *Handler) swapTarget(c echo.Context, request storage.SwapRequest) (string, error) {
+	if raw := c.QueryParam(swapTokenParam); raw != "" {
+		if subtle.ConstantTimeCompare([]byte(hashToken(raw)), []byte(request.TokenHash)) == 1 {
+			return SwapLinkActor + ":" + request.To, nil
+		}
+
+		return "", nil
+	}
+
+	session, ok := h.session(c)
+	if !ok {
+		return "", nil
+	}
+
+	for _, name := range []string{session.Name, session.Email} {
+		if name == "" {
+			continue
+		}
+
+		aliases, err := h.memberAliases(c.Request().Context(), name)
+		if err != nil {
+			return "", err
+		}
+		if slices.Contains(aliases, request.To) {
+			return session.Actor(), nil
+		}
+	}
+
+	return "", nil
+}
+
+// swapEvent returns the notification of a change of a swap request, in the
+// zone of its target.
+func (h *Handler) swapEvent(ctx context.Context, request storage.SwapRequest, schedule, change string) notify.Event {
+	event := notify.NewEvent(notify.KindSwapRequest, request.Team, h.now())
+	event.Schedule = schedule
+	event.Change = change
+	event.Previous = request.From
+	event.Current = request.To
+	event.ShiftStart = request.ShiftStart
+	event.ShiftEnd = request.ShiftEnd
+	event.Location = h.memberLocation(ctx, request.To)
+
+	return event
+}
+
+// newSwapRequestResponse renders a swap request with its status as of now.
+func (h *Handler) newSwapRequestResponse(request storage.SwapRequest) SwapRequestResponse {
+	resp := SwapRequestResponse{
+		ID:         request.ID,
+		ScheduleID: request.ScheduleID,
+		Team:       request.Team,
+		ShiftStart: request.ShiftStart.UTC().Format(time.RFC3339),
+		ShiftEnd:   request.ShiftEnd.UTC().Format(time.RFC3339),
+		From:       request.From,
+		To:         request.To,
+		Status:     request.StatusAt(h.now()),
+		CreatedAt:  request.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	if !request.DecidedAt.IsZero() {
+		resp.DecidedAt = request.DecidedAt.UTC().Format(time.RFC3339)
+	}
+
+	return resp
+}
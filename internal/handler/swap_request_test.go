@@ -0,0 +1,264 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// eventNotifier hands the events it is notified of over to the test.
+type eventNotifier struct {
+	events chan notify.Event
+}
+
+func (eventNotifier) Name() string { return "events" }
+
+func (n eventNotifier) Notify(_ context.Context, event notify.Event) error {
+	n.events <- event
+	return nil
+}
+
+// swapShift is the start of a shift of Alice in the schedule created by
+// newSwapServer, the first Monday of her rotation.
+var swapShift = time.Date(2030, 1, 7, 9, 0, 0, 0, time.UTC)
+
+// newSwapServer creates the weekday schedule of Alice and Bob for the
+// backend team, with Alice on duty during the week of swapShift, a day
+// before it.
+func newSwapServer(t *testing.T, e *echo.Echo, h *Handler) (string, *fakeClock, chan notify.Event) {
+	t.Helper()
+
+	clock := &fakeClock{now: swapShift.Add(-24 * time.Hour)}
+	h.now = clock.Now
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.POST("/schedule/:id/swap-requests", h.CreateSwapRequest)
+	e.GET("/schedule/:id/swap-requests", h.ListSwapRequests)
+	e.POST("/swap-requests/:id/accept", h.AcceptSwapRequest)
+	e.POST("/swap-requests/:id/decline", h.DeclineSwapRequest)
+
+	req := quotaRequest("backend-team")
+	req.Anchor = swapShift.Format(time.DateOnly)
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// Installed once the schedule exists, its creation is not one of the events
+	events := make(chan notify.Event, 10)
+	h.SetDispatcher(notify.NewDispatcher([]notify.Notifier{eventNotifier{events: events}}, &config.Config{}, zap.NewNop()))
+
+	team, err := h.storage.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+
+	return team.Schedules[0].ID, clock, events
+}
+
+// requestSwap asks Bob to take the shift of Alice starting at the given
+// instant over, and returns the request along with the link Bob was sent.
+func requestSwap(t *testing.T, e *echo.Echo, id string, start time.Time, events chan notify.Event) (SwapRequestResponse, string) {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/schedule/"+id+"/swap-requests",
+		SwapRequestRequest{ShiftStart: start.Format(time.RFC3339), To: "Bob"}, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "token")
+
+	var resp SwapRequestResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	var event notify.Event
+	select {
+	case event = <-events:
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "swap request was not notified")
+	}
+	assert.Equal(t, notify.KindSwapRequest, event.Kind)
+	assert.Equal(t, notify.ChangeRequested, event.Change)
+	assert.Equal(t, "Alice", event.Previous)
+	assert.Equal(t, "Bob", event.Current)
+	assert.True(t, event.ShiftStart.Equal(start))
+
+	link, err := url.Parse(event.Link)
+	require.NoError(t, err)
+	assert.Equal(t, "/swap-requests/"+strconv.FormatInt(resp.ID, 10)+"/accept", link.Path)
+
+	return resp, link.RequestURI()
+}
+
+// swapOncall returns the member on call during the shift starting at the
+// given instant.
+func swapOncall(t *testing.T, h *Handler, start time.Time) string {
+	t.Helper()
+
+	member, err := h.storage.GetCurrentOncall(t.Context(), "backend-team", start.Add(time.Hour))
+	require.NoError(t, err)
+
+	return member
+}
+
+func TestSwapRequest_Accept(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	id, _, events := newSwapServer(t, e, h)
+
+	resp, link := requestSwap(t, e, id, swapShift, events)
+	assert.Equal(t, "Alice", resp.From)
+	assert.Equal(t, "Bob", resp.To)
+	assert.Equal(t, storage.SwapPending, resp.Status)
+	assert.Equal(t, "2030-01-07T17:00:00Z", resp.ShiftEnd)
+
+	// Nothing changes until Bob accepts
+	assert.Equal(t, "Alice", swapOncall(t, h, swapShift))
+
+	rec := serveJSON(e, http.MethodPost, "/swap-requests/"+strconv.FormatInt(resp.ID, 10)+"/accept?token=wrong", nil, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = serveJSON(e, http.MethodPost, "/swap-requests/"+strconv.FormatInt(resp.ID, 10)+"/accept", nil, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, link, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, storage.SwapAccepted, resp.Status)
+	assert.NotEmpty(t, resp.DecidedAt)
+
+	event := <-events
+	assert.Equal(t, notify.ChangeAccepted, event.Change)
+	assert.Empty(t, event.Link)
+
+	assert.Equal(t, "Bob", swapOncall(t, h, swapShift))
+	// Only the requested shift is handed over
+	assert.Equal(t, "Alice", swapOncall(t, h, swapShift.AddDate(0, 0, 1)))
+
+	rec = serveJSON(e, http.MethodPost, link, nil, "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	entries, err := h.storage.AuditLog(t.Context(), "backend-team")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, storage.AuditAcceptSwap, entries[len(entries)-1].Action)
+	assert.Equal(t, SwapLinkActor+":Bob", entries[len(entries)-1].Actor)
+}
+
+func TestSwapRequest_Decline(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	id, _, events := newSwapServer(t, e, h)
+
+	resp, link := requestSwap(t, e, id, swapShift, events)
+
+	decline, err := url.Parse(link)
+	require.NoError(t, err)
+	decline.Path = "/swap-requests/" + strconv.FormatInt(resp.ID, 10) + "/decline"
+
+	rec := serveJSON(e, http.MethodPost, decline.RequestURI(), nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, storage.SwapDeclined, resp.Status)
+	assert.Equal(t, notify.ChangeDeclined, (<-events).Change)
+
+	assert.Equal(t, "Alice", swapOncall(t, h, swapShift))
+
+	// A declined request cannot be accepted anymore
+	rec = serveJSON(e, http.MethodPost, link, nil, "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestSwapRequest_Expiry(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	id, clock, events := newSwapServer(t, e, h)
+
+	_, link := requestSwap(t, e, id, swapShift, events)
+
+	// The shift starting ends the request
+	clock.now = swapShift.Add(time.Minute)
+
+	rec := serveJSON(e, http.MethodPost, link, nil, "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "Alice", swapOncall(t, h, swapShift))
+
+	rec = serveJSON(e, http.MethodGet, "/schedule/"+id+"/swap-requests", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp SwapRequestsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.SwapRequests, 1)
+	assert.Equal(t, storage.SwapExpired, resp.SwapRequests[0].Status)
+
+	// Shifts that already started cannot be requested
+	rec = serveJSON(e, http.MethodPost, "/schedule/"+id+"/swap-requests",
+		SwapRequestRequest{ShiftStart: swapShift.Format(time.RFC3339), To: "Bob"}, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSwapRequest_Validation(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	id, _, _ := newSwapServer(t, e, h)
+
+	for name, req := range map[string]SwapRequestRequest{
+		"no shift start":  {To: "Bob"},
+		"no shift":        {ShiftStart: swapShift.Add(time.Hour).Format(time.RFC3339), To: "Bob"},
+		"not on duty":     {ShiftStart: swapShift.Format(time.RFC3339), From: "Bob", To: "Alice"},
+		"to itself":       {ShiftStart: swapShift.Format(time.RFC3339), To: "Alice"},
+		"not a member":    {ShiftStart: swapShift.Format(time.RFC3339), To: "Zed"},
+		"no target":       {ShiftStart: swapShift.Format(time.RFC3339)},
+		"weekend is free": {ShiftStart: swapShift.AddDate(0, 0, -2).Format(time.RFC3339), To: "Bob"},
+	} {
+		rec := serveJSON(e, http.MethodPost, "/schedule/"+id+"/swap-requests", req, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, name)
+	}
+
+	rec := serveJSON(e, http.MethodPost, "/schedule/999/swap-requests",
+		SwapRequestRequest{ShiftStart: swapShift.Format(time.RFC3339), To: "Bob"}, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/swap-requests/999/accept", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestSwapRequest_AcceptSignedIn(t *testing.T) {
+	e, h, _ := newAuthServer(t)
+	id, _, events := newSwapServer(t, e, h)
+
+	_, _, err := h.storage.AddUser(t.Context(), storage.User{UserName: "Bob", Emails: []string{"bob@example.org"}, Active: true})
+	require.NoError(t, err)
+
+	resp, _ := requestSwap(t, e, id, swapShift, events)
+	target := "/swap-requests/" + strconv.FormatInt(resp.ID, 10) + "/accept"
+
+	signedIn := func(email string) *http.Cookie {
+		value, err := h.oidc.Seal(sessionCookie, auth.Session{
+			Subject: email,
+			Email:   email,
+			Role:    auth.RoleReader,
+			Expiry:  time.Now().Add(time.Hour),
+		})
+		require.NoError(t, err)
+
+		return &http.Cookie{Name: sessionCookie, Value: value}
+	}
+
+	rec := serveCookies(e, http.MethodPost, target, "", signedIn("alice@example.org"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = serveCookies(e, http.MethodPost, target, "", signedIn("bob@example.org"))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "Bob", swapOncall(t, h, swapShift))
+
+	entries, err := h.storage.AuditLog(t.Context(), "backend-team")
+	require.NoError(t, err)
+	assert.Equal(t, "oidc:bob@example.org", entries[len(entries)-1].Actor)
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// swapLoadWindow is how far around a shift the shifts of a swap candidate
+// are counted, and maxMinRest bounds the min_rest query parameter.
+const (
+	swapLoadWindow = 14 * 24 * time.Hour
+	maxMinRest     = 7 * 24 * time.Hour
+)
+
+// SwapShift represents the shift a swap is suggested for.
+type SwapShift struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Member string `json:"member"`
+}
+
+// SwapCandidate represents a member who could take a shift over. Shifts is
+// how many shifts the member has within two weeks of it, each part of a
+// split shift counting as one, and Hours how long they last in total,
+// rounded to two decimals. Reasons explain why the member is or is not
+// suitable.
+type SwapCandidate struct {
+	Member   string   `json:"member"`
+	Suitable bool     `json:"suitable"`
+	Shifts   int      `json:"shifts"`
+	Hours    float64  `json:"hours"`
+	Reasons  []string `json:"reasons"`
+}
+
+// SwapSuggestionsResponse represents the members of a schedule ranked by
+// how suitable they are to take one of its shifts over.
+type SwapSuggestionsResponse struct {
+	ScheduleID string          `json:"schedule_id"`
+	Team       string          `json:"team"`
+	Shift      SwapShift       `json:"shift"`
+	Candidates []SwapCandidate `json:"candidates"`
+}
+
+// SwapSuggestions handles swap suggestion requests for the shift of a
+// schedule starting at shift_start. The other members of the schedule are
+// ranked with suitable ones first, those not on call anywhere during the
+// shift and keeping min_rest between it and their own shifts, then by the
+// fewest hours on call around it, so the parts of split shifts count for
+// what they last. Shifts starting at a part of a split shift are the part.
+// Nothing is changed.
+func (h *Handler) SwapSuggestions(c echo.Context) error {
+	value := c.QueryParam("shift_start")
+	if value == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "shift_start query parameter is required"})
+	}
+	start, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid shift_start format, use RFC3339 format"})
+	}
+
+	var minRest time.Duration
+	if value := c.QueryParam("min_rest"); value != "" {
+		if minRest, err = time.ParseDuration(value); err != nil || minRest < 0 || minRest > maxMinRest {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid min_rest, use a duration such as '12h' of at most a week"})
+		}
+	}
+
+	ctx := c.Request().Context()
+
+	sched, err := h.storage.GetSchedule(ctx, c.Param("id"))
+	if errors.Is(err, storage.ErrScheduleNotFound) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "schedule not found"})
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get schedule %q: %w", c.Param("id"), err), "failed to retrieve schedule")
+	}
+
+	duty, ok := sched.Schedule.DutyAt(start)
+	if !ok || !duty.Start.Equal(start) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "no shift of the schedule starts at shift_start"})
+	}
+	shift, member := duty.Shift, duty.Member
+
+	resp := SwapSuggestionsResponse{
+		ScheduleID: sched.Schedule.ID,
+		Team:       sched.Team,
+		Shift: SwapShift{
+			Start:  shift.Start.Format(time.RFC3339),
+			End:    shift.End.Format(time.RFC3339),
+			Member: member,
+		},
+		Candidates: []SwapCandidate{},
+	}
+
+	for _, candidate := range sched.Schedule.Members {
+		if candidate == member || slices.ContainsFunc(resp.Candidates, func(c SwapCandidate) bool { return c.Member == candidate }) {
+			continue
+		}
+
+		suggestion, err := h.swapCandidate(ctx, candidate, shift, minRest)
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("get teams of swap candidate %q: %w", candidate, err), "failed to retrieve teams")
+		}
+		resp.Candidates = append(resp.Candidates, suggestion)
+	}
+
+	// Members keep their order in the schedule when they are equally suitable
+	slices.SortStableFunc(resp.Candidates, func(a, b SwapCandidate) int {
+		if a.Suitable != b.Suitable {
+			if a.Suitable {
+				return -1
+			}
+			return 1
+		}
+
+		return cmp.Compare(a.Hours, b.Hours)
+	})
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// swapCandidate checks a member against the shift across all of their
+// teams, resolved like the on-call lookup. Time while a team is paused does
+// not count.
+func (h *Handler) swapCandidate(ctx context.Context, member string, shift storage.Shift, minRest time.Duration) (SwapCandidate, error) {
+	teams, err := h.memberTeams(ctx, member)
+	if err != nil {
+		return SwapCandidate{}, err
+	}
+
+	candidate := SwapCandidate{Member: member, Suitable: true}
+
+	// stretches returns the stretches of [from, to) the member is on call
+	stretches := func(team memberTeam, from, to time.Time) []storage.Shift {
+		var result []storage.Shift
+		for _, duty := range storage.DutyTimeline(team.schedules, from, to) {
+			if duty.Member == member {
+				result = append(result, outsidePause(duty.Shift, team.pause)...)
+			}
+		}
+		return result
+	}
+
+	for _, team := range teams {
+		for _, stretch := range stretches(team, shift.Start, shift.End) {
+			candidate.Suitable = false
+			candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("on call for %s/%s from %s to %s",
+				team.name, stretch.Schedule, stretch.Start.Format(time.RFC3339), stretch.End.Format(time.RFC3339)))
+		}
+
+		if minRest > 0 {
+			for _, stretch := range stretches(team, shift.Start.Add(-minRest), shift.Start) {
+				candidate.Suitable = false
+				candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("only %s of rest after %s/%s ending at %s",
+					shift.Start.Sub(stretch.End), team.name, stretch.Schedule, stretch.End.Format(time.RFC3339)))
+			}
+			for _, stretch := range stretches(team, shift.End, shift.End.Add(minRest)) {
+				candidate.Suitable = false
+				candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("only %s of rest before %s/%s starting at %s",
+					stretch.Start.Sub(shift.End), team.name, stretch.Schedule, stretch.Start.Format(time.RFC3339)))
+			}
+		}
+
+		for _, duty := range storage.Duties(team.schedules, shift.Start.Add(-swapLoadWindow), shift.Start.Add(swapLoadWindow)) {
+			if duty.Member == member && !team.paused(duty.Start) {
+				candidate.Shifts++
+				candidate.Hours += duty.End.Sub(duty.Start).Hours()
+			}
+		}
+	}
+	candidate.Hours = math.Round(candidate.Hours*100) / 100
+
+	if candidate.Suitable {
+		candidate.Reasons = append(candidate.Reasons, "not on call during the shift")
+	}
+	candidate.Reasons = append(candidate.Reasons, fmt.Sprintf("%d shifts of %s hours within two weeks of the shift",
+		candidate.Shifts, strconv.FormatFloat(candidate.Hours, 'f', -1, 64)))
+
+	return candidate, nil
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func getSwapSuggestions(t *testing.T, h *Handler, id, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/schedule/"+id+"/swaps/suggestions?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+
+	require.NoError(t, h.SwapSuggestions(c))
+
+	return rec
+}
+
+// newSwapHandler adds a daily schedule Alice is always on duty for, whose
+// other members are busy to different degrees in other teams.
+func newSwapHandler(t *testing.T) (*Handler, string) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+
+	daily := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+
+	_, err := store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Daily",
+		Members: []string{"Alice", "Bob", "Carol", "Dana", "Erin"},
+		Days:    daily,
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	// Bob is on call elsewhere during Monday shifts
+	_, err = store.AddSchedule(ctx, "frontend-team", storage.Schedule{
+		Name:    "Weekday",
+		Members: []string{"Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	// Carol is overloaded with a nightly shift
+	_, err = store.AddSchedule(ctx, "ops-team", storage.Schedule{
+		Name:    "Night",
+		Members: []string{"Carol"},
+		Days:    daily,
+		Start:   parseTime(t, "10:00PM"),
+		End:     parseTime(t, "11:00PM"),
+	})
+	require.NoError(t, err)
+	// Dana ends an early shift shortly before Monday shifts
+	_, err = store.AddSchedule(ctx, "infra-team", storage.Schedule{
+		Name:    "Early",
+		Members: []string{"Dana"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "6:00AM"),
+		End:     parseTime(t, "8:00AM"),
+	})
+	require.NoError(t, err)
+
+	team, err := store.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+
+	return New(store, zap.NewNop()), team.Schedules[0].ID
+}
+
+func TestSwapSuggestions(t *testing.T) {
+	h, id := newSwapHandler(t)
+
+	rec := getSwapSuggestions(t, h, id, "shift_start=2025-04-28T09:00:00Z&min_rest=2h")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp SwapSuggestionsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "backend-team", resp.Team)
+	assert.Equal(t, SwapShift{Start: "2025-04-28T09:00:00Z", End: "2025-04-28T17:00:00Z", Member: "Alice"}, resp.Shift)
+
+	assert.Equal(t, []SwapCandidate{
+		{Member: "Erin", Suitable: true, Reasons: []string{
+			"not on call during the shift",
+			"0 shifts of 0 hours within two weeks of the shift",
+		}},
+		{Member: "Carol", Suitable: true, Shifts: 28, Hours: 28, Reasons: []string{
+			"not on call during the shift",
+			"28 shifts of 28 hours within two weeks of the shift",
+		}},
+		// As many shifts as Bob, but the early ones are shorter
+		{Member: "Dana", Shifts: 4, Hours: 8, Reasons: []string{
+			"only 1h0m0s of rest after infra-team/Early ending at 2025-04-28T08:00:00Z",
+			"4 shifts of 8 hours within two weeks of the shift",
+		}},
+		{Member: "Bob", Shifts: 4, Hours: 32, Reasons: []string{
+			"on call for frontend-team/Weekday from 2025-04-28T09:00:00Z to 2025-04-28T17:00:00Z",
+			"4 shifts of 32 hours within two weeks of the shift",
+		}},
+	}, resp.Candidates)
+
+	// Without min_rest Dana is suitable again
+	rec = getSwapSuggestions(t, h, id, "shift_start=2025-04-28T09:00:00Z")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "Erin", resp.Candidates[0].Member)
+	assert.Equal(t, "Dana", resp.Candidates[1].Member)
+	assert.True(t, resp.Candidates[1].Suitable)
+}
+
+func TestSwapSuggestions_InvalidRequests(t *testing.T) {
+	h, id := newSwapHandler(t)
+
+	tests := []struct {
+		name  string
+		id    string
+		query string
+		code  int
+		err   string
+	}{
+		{"missing shift start", id, "", http.StatusBadRequest, "shift_start query parameter is required"},
+		{"invalid shift start", id, "shift_start=2025-04-28", http.StatusBadRequest, "invalid shift_start format, use RFC3339 format"},
+		{"not a shift start", id, "shift_start=2025-04-28T10:00:00Z", http.StatusBadRequest, "no shift of the schedule starts at shift_start"},
+		{"invalid min rest", id, "shift_start=2025-04-28T09:00:00Z&min_rest=-1h", http.StatusBadRequest, "invalid min_rest, use a duration such as '12h' of at most a week"},
+		{"unknown schedule", "999", "shift_start=2025-04-28T09:00:00Z", http.StatusNotFound, "schedule not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getSwapSuggestions(t, h, tt.id, tt.query)
+			assert.Equal(t, tt.code, rec.Code)
+
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.err, resp.Error)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// MaxTags bounds the tags of a schedule, and MaxTagLength a single tag.
+const (
+	MaxTags      = 10
+	MaxTagLength = 32
+)
+
+// tagPattern matches a valid tag: lowercase letters, digits, dashes and
+// underscores, starting with a letter or digit.
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// SchedulesResponse represents a list of schedules in the request format.
+type SchedulesResponse struct {
+	Schedules []Request `json:"schedules"`
+}
+
+// ListTeamSchedules handles team schedule listing requests. Repeated tag query
+// parameters only keep the schedules carrying all of them.
+func (h *Handler) ListTeamSchedules(c echo.Context) error {
+	teamName := c.Param("team")
+
+	tags, err := queryTags(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	effective, err := parseEffective(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	found := !errors.Is(err, storage.ErrTeamNotFound)
+	if err != nil && found {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	// Effective listings go on with the schedules of the ancestors, which
+	// is all a team without schedules of its own has
+	var ancestors []string
+	if effective {
+		ancestors, err = h.ancestors(ctx, teamName)
+		if err != nil {
+			return h.storageFailure(c, err, "failed to retrieve team")
+		}
+	}
+
+	if !found && len(ancestors) == 0 {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+
+	resp := SchedulesResponse{Schedules: []Request{}}
+	for _, sched := range team.Schedules {
+		if sched.HasTags(tags) {
+			resp.Schedules = append(resp.Schedules, listedSchedule(teamName, sched))
+		}
+	}
+
+	for _, ancestor := range ancestors {
+		inherited, err := h.storage.GetTeam(ctx, ancestor)
+		if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+			return h.storageFailure(c, fmt.Errorf("get team %q: %w", ancestor, err), "failed to retrieve team")
+		}
+
+		for _, sched := range inherited.Schedules {
+			if sched.HasTags(tags) {
+				req := listedSchedule(ancestor, sched)
+				req.InheritedFrom = ancestor
+				resp.Schedules = append(resp.Schedules, req)
+			}
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// FindSchedules handles schedule lookups across teams. It requires at least
+// one tag query parameter and keeps the schedules carrying all of them.
+func (h *Handler) FindSchedules(c echo.Context) error {
+	tags, err := queryTags(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	if len(tags) == 0 {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "at least one tag query parameter is required"})
+	}
+
+	schedules, err := h.storage.FindSchedulesByTags(c.Request().Context(), tags)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("find schedules by tags %q: %w", tags, err), "failed to retrieve schedules")
+	}
+
+	resp := SchedulesResponse{Schedules: make([]Request, 0, len(schedules))}
+	for _, s := range schedules {
+		resp.Schedules = append(resp.Schedules, listedSchedule(s.Team, s.Schedule))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// listedSchedule renders a schedule for listings, which unlike backups carry
+// the ID of the schedule.
+func listedSchedule(team string, sched storage.Schedule) Request {
+	req := scheduleRequest(team, sched)
+	req.ID = sched.ID
+
+	return req
+}
+
+// queryTags parses the repeated tag query parameters.
+func queryTags(c echo.Context) ([]string, error) {
+	tags := c.QueryParams()["tag"]
+	for _, tag := range tags {
+		if err := validateTag(tag); err != nil {
+			return nil, err
+		}
+	}
+
+	return slices.Compact(slices.Sorted(slices.Values(tags))), nil
+}
+
+// parseTags validates the tags of a request. Duplicates are dropped, the
+// order is kept otherwise.
+func parseTags(tags []string) ([]string, error) {
+	var result []string
+	for _, tag := range tags {
+		if err := validateTag(tag); err != nil {
+			return nil, err
+		}
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+
+	if len(result) > MaxTags {
+		return nil, fmt.Errorf("at most %d tags are allowed", MaxTags)
+	}
+
+	return result, nil
+}
+
+// validateTag checks a single tag.
+func validateTag(tag string) error {
+	if len(tag) > MaxTagLength || !tagPattern.MatchString(tag) {
+		return fmt.Errorf("invalid tag %q: use at most %d lowercase letters, digits, dashes and underscores", tag, MaxTagLength)
+	}
+
+	return nil
+}
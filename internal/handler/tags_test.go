@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTagsHandler(t *testing.T) *Handler {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	add := func(team, name string, tags ...string) {
+		schedule, err := h.parseRequest(&Request{
+			Name:    name,
+			Team:    team,
+			Members: []string{"Alice"},
+			Days:    []string{"Monday"},
+			Start:   "9:00AM",
+			End:     "5:00PM",
+			Tags:    tags,
+		})
+		require.NoError(t, err)
+		_, err = store.AddSchedule(context.Background(), team, schedule)
+		require.NoError(t, err)
+	}
+	add("backend-team", "EU Prod", "prod", "eu", "tier1")
+	add("backend-team", "US Prod", "prod", "us", "tier1")
+	add("backend-team", "EU Staging", "staging", "eu")
+	add("frontend-team", "Web", "prod", "tier1")
+
+	return h
+}
+
+func listSchedules(t *testing.T, handle echo.HandlerFunc, team, query string) (*httptest.ResponseRecorder, []string) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/schedules?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("team")
+	c.SetParamValues(team)
+
+	require.NoError(t, handle(c))
+
+	var names []string
+	if rec.Code == http.StatusOK {
+		var resp SchedulesResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		for _, sched := range resp.Schedules {
+			names = append(names, sched.Team+"/"+sched.Name)
+		}
+	}
+
+	return rec, names
+}
+
+func TestListTeamSchedules_TagFilter(t *testing.T) {
+	h := newTagsHandler(t)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"backend-team/EU Prod", "backend-team/US Prod", "backend-team/EU Staging"}},
+		{"tag=eu", []string{"backend-team/EU Prod", "backend-team/EU Staging"}},
+		// Repeated tags are combined with AND
+		{"tag=eu&tag=prod", []string{"backend-team/EU Prod"}},
+		{"tag=prod&tag=tier1&tag=prod", []string{"backend-team/EU Prod", "backend-team/US Prod"}},
+		{"tag=eu&tag=us", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec, names := listSchedules(t, h.ListTeamSchedules, "backend-team", tt.query)
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tt.want, names)
+		})
+	}
+
+	rec, _ := listSchedules(t, h.ListTeamSchedules, "ops-team", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec, _ = listSchedules(t, h.ListTeamSchedules, "backend-team", "tag=Prod")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestFindSchedules_AcrossTeams(t *testing.T) {
+	h := newTagsHandler(t)
+
+	rec, names := listSchedules(t, h.FindSchedules, "", "tag=tier1")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"backend-team/EU Prod", "backend-team/US Prod", "frontend-team/Web"}, names)
+
+	rec, names = listSchedules(t, h.FindSchedules, "", "tag=tier1&tag=us")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"backend-team/US Prod"}, names)
+
+	rec, _ = listSchedules(t, h.FindSchedules, "", "tag=tier1&tag=staging")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"schedules": []}`, rec.Body.String())
+
+	rec, _ = listSchedules(t, h.FindSchedules, "", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestParseTags(t *testing.T) {
+	tags, err := parseTags([]string{"prod", "eu", "prod", "tier_1", "on-call"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"prod", "eu", "tier_1", "on-call"}, tags)
+
+	tooMany := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"}
+	_, err = parseTags(tooMany)
+	assert.EqualError(t, err, "at most 10 tags are allowed")
+
+	for _, tag := range []string{"Prod", "", "-prod", "eu west", "tier.1", "a123456789012345678901234567890bc"} {
+		_, err := parseTags([]string{tag})
+		assert.Error(t, err, tag)
+	}
+}
+
+func TestExportCalendar_Categories(t *testing.T) {
+	h := newTagsHandler(t)
+
+	team, err := h.storage.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, writeCalendar(rec, "backend-team", team.Schedules, "", time.Now()))
+	assert.Contains(t, rec.Body.String(), "CATEGORIES:prod,eu,tier1\r\n")
+}
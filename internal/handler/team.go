@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// TeamResponse represents a team with its schedules in the request format,
+// so a schedule can be edited and created again as it is.
+type TeamResponse struct {
+	Team      string    `json:"team"`
+	Schedules []Request `json:"schedules"`
+}
+
+// GetTeamSchedules handles requests for a team along with all of its
+// schedules, in the order they take precedence in.
+func (h *Handler) GetTeamSchedules(c echo.Context) error {
+	teamName := c.Param("team")
+
+	team, err := h.storage.GetTeam(c.Request().Context(), teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	resp := TeamResponse{Team: teamName, Schedules: make([]Request, 0, len(team.Schedules))}
+	for _, sched := range team.Schedules {
+		resp.Schedules = append(resp.Schedules, listedSchedule(teamName, sched))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteTeam handles requests decommissioning a team, which removes it along
+// with all of its schedules. Frozen teams are only deleted when forced.
+func (h *Handler) DeleteTeam(c echo.Context) error {
+	teamName := c.Param("team")
+
+	if frozen, err := h.rejectFrozen(c, teamName, "delete team "+teamName); frozen {
+		return err
+	}
+
+	ctx := c.Request().Context()
+
+	err := h.storage.DeleteTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("delete team %q: %w", teamName, err), "failed to delete team")
+	}
+
+	h.logger.Info("team deleted", zap.String("team", teamName), zap.String("actor", storage.ActorFrom(ctx)))
+
+	return c.NoContent(http.StatusNoContent)
+}
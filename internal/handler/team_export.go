@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// CodeHistoryUnavailable is the error code of snapshots asked for an instant
+// the schedule history of the team does not reach back to.
+const CodeHistoryUnavailable = "HISTORY_UNAVAILABLE"
+
+// TeamExport is the export of a team, in the format of the teams of a backup
+// document. AsOf is set on snapshots of the team at an earlier instant.
+type TeamExport struct {
+	TeamDocument
+	AsOf string `json:"as_of,omitempty"`
+}
+
+// HistoryErrorResponse represents a snapshot rejected because the history
+// does not reach back to it. Earliest is the earliest instant a snapshot can
+// be taken at, empty when the history of some schedules is missing.
+type HistoryErrorResponse struct {
+	Error    string `json:"error"`
+	Code     string `json:"code"`
+	Earliest string `json:"earliest,omitempty"`
+}
+
+// ExportTeam handles export requests of a team. Given as_of, the schedules
+// are reconstructed as they were at that instant from their versions,
+// including the ones deleted since, and the export carries the as_of
+// marker. Groups and pauses are not versioned, so snapshots leave them out.
+func (h *Handler) ExportTeam(c echo.Context) error {
+	teamName := c.Param("team")
+	ctx := c.Request().Context()
+
+	if c.QueryParam("as_of") == "" {
+		doc, err := h.teamDocument(ctx, teamName)
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			return h.teamNotFound(c, teamName, "team not found")
+		}
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("export team %q: %w", teamName, err), "failed to export team")
+		}
+
+		return c.JSON(http.StatusOK, TeamExport{TeamDocument: doc})
+	}
+
+	asOf, err := h.parseTime(c.QueryParam("as_of"), "as_of", time.UTC)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	if asOf.After(h.now()) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "as_of must not be in the future"})
+	}
+
+	start, reaches, err := h.storage.HistoryStart(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get history start of team %q: %w", teamName, err), "failed to export team")
+	}
+	if !reaches {
+		_, err := h.storage.GetTeam(ctx, teamName)
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			return h.teamNotFound(c, teamName, "team not found")
+		}
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to export team")
+		}
+
+		return c.JSON(http.StatusUnprocessableEntity, HistoryErrorResponse{
+			Error: "the schedule history of the team is incomplete, snapshots cannot be reconstructed",
+			Code:  CodeHistoryUnavailable,
+		})
+	}
+	if asOf.Before(start) {
+		earliest := start.UTC().Format(time.RFC3339)
+		return c.JSON(http.StatusUnprocessableEntity, HistoryErrorResponse{
+			Error:    fmt.Sprintf("the schedule history of the team reaches back to %s", earliest),
+			Code:     CodeHistoryUnavailable,
+			Earliest: earliest,
+		})
+	}
+
+	// The team was deleted since its history start was read
+	history, err := h.storage.TeamHistory(ctx, teamName, asOf, asOf)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get history of team %q: %w", teamName, err), "failed to export team")
+	}
+	if len(history) == 0 {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+
+	doc := TeamDocument{
+		Team:      teamName,
+		Schedules: make([]Request, 0, len(history[0].Team.Schedules)),
+	}
+	for _, sched := range history[0].Team.Schedules {
+		doc.Schedules = append(doc.Schedules, scheduleRequest(teamName, sched))
+	}
+
+	return c.JSON(http.StatusOK, TeamExport{TeamDocument: doc, AsOf: asOf.UTC().Format(time.RFC3339)})
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// shortHistoryStorage is a storage whose schedule history reaches back to
+// start only, or not at all when start is zero.
+type shortHistoryStorage struct {
+	storage.Storage
+	start time.Time
+}
+
+func (s shortHistoryStorage) HistoryStart(ctx context.Context, team string) (time.Time, bool, error) {
+	_, err := s.GetTeam(ctx, team)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return s.start, !s.start.IsZero(), nil
+}
+
+// exportQuery asks the export of the team as of the instant.
+func exportQuery(e *echo.Echo, team string, asOf time.Time) (TeamExport, int, string) {
+	query := url.Values{}
+	query.Set("as_of", asOf.UTC().Format(time.RFC3339Nano))
+
+	rec := serveJSON(e, http.MethodGet, "/teams/"+team+"/export?"+query.Encode(), nil, "")
+
+	var resp TeamExport
+	if rec.Code == http.StatusOK {
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	}
+
+	return resp, rec.Code, rec.Body.String()
+}
+
+func TestExportTeam_AsOf(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	e := echo.New()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team/export", h.ExportTeam)
+
+	legacy := quotaRequest("backend-team")
+	legacy.Name = "Legacy"
+	legacy.ValidUntil = time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	for _, req := range []Request{quotaRequest("backend-team"), legacy} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	first := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	// The Weekday rotation is advanced and the Evening schedule is added
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+	rotation := team.Schedules[0].Rotation()
+	rotation.Offset = 1
+	_, err = store.SetRotation(t.Context(), "backend-team", team.Schedules[0].ID, rotation)
+	require.NoError(t, err)
+
+	evening := quotaRequest("backend-team")
+	evening.Name = "Evening"
+	rec := serveJSON(e, http.MethodPost, "/schedule", evening, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	time.Sleep(10 * time.Millisecond)
+	second := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	// The Legacy schedule expires and is deleted
+	deleted, err := store.DeleteExpiredSchedules(t.Context(), time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+
+	time.Sleep(10 * time.Millisecond)
+	third := time.Now()
+
+	type schedule struct {
+		Name   string
+		Offset int
+	}
+	tests := []struct {
+		name string
+		asOf time.Time
+		want []schedule
+	}{
+		{"before the changes", first, []schedule{{"Weekday", 0}, {"Legacy", 0}}},
+		{"after the changes", second, []schedule{{"Weekday", 1}, {"Legacy", 0}, {"Evening", 0}}},
+		{"after the deletion", third, []schedule{{"Weekday", 1}, {"Evening", 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, code, body := exportQuery(e, "backend-team", tt.asOf)
+			require.Equal(t, http.StatusOK, code, body)
+
+			assert.Equal(t, "backend-team", resp.Team)
+			assert.Equal(t, tt.asOf.UTC().Format(time.RFC3339), resp.AsOf)
+
+			got := make([]schedule, 0, len(resp.Schedules))
+			for _, sched := range resp.Schedules {
+				got = append(got, schedule{sched.Name, sched.RotationOffset})
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Without as_of the export is the current team
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team/export", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var current TeamExport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &current))
+	assert.Empty(t, current.AsOf)
+	require.Len(t, current.Schedules, 2)
+	assert.Equal(t, "Weekday", current.Schedules[0].Name)
+	assert.Equal(t, "Evening", current.Schedules[1].Name)
+}
+
+func TestExportTeam_HistoryUnavailable(t *testing.T) {
+	start := time.Date(2025, time.May, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		earliest string
+	}{
+		{"before the history start", start, "2025-05-01T00:00:00Z"},
+		{"incomplete history", time.Time{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			h := New(shortHistoryStorage{Storage: storage.NewMemoryStorage(), start: tt.start}, zap.NewNop())
+
+			e.POST("/schedule", h.CreateSchedule)
+			e.GET("/teams/:team/export", h.ExportTeam)
+
+			rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+			require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+			rec = serveJSON(e, http.MethodGet, "/teams/backend-team/export?as_of=2025-04-01T00:00:00Z", nil, "")
+			require.Equal(t, http.StatusUnprocessableEntity, rec.Code, rec.Body.String())
+
+			var resp HistoryErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, CodeHistoryUnavailable, resp.Code)
+			assert.Equal(t, tt.earliest, resp.Earliest)
+		})
+	}
+}
+
+func TestExportTeam_Invalid(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team/export", h.ExportTeam)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	tests := []struct {
+		name   string
+		target string
+		code   int
+	}{
+		{"unknown team", "/teams/frontend-team/export", http.StatusNotFound},
+		{"unknown team as of", "/teams/frontend-team/export?as_of=2025-04-01T00:00:00Z", http.StatusNotFound},
+		{"invalid as of", "/teams/backend-team/export?as_of=yesterday", http.StatusBadRequest},
+		{"future as of", "/teams/backend-team/export?as_of=now%2B1h", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodGet, tt.target, nil, "")
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+}
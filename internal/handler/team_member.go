@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// maxTeamMemberNameLength bounds member names, like the user names they are matched to.
+const maxTeamMemberNameLength = 255
+
+// TeamMemberRequest represents a team membership request.
+type TeamMemberRequest struct {
+	// Role is member, the default, or observer.
+	Role string `json:"role,omitempty"`
+}
+
+// TeamMemberResponse represents a member of a team roster.
+type TeamMemberResponse struct {
+	Team      string `json:"team"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+// ListTeamMembers handles team roster requests, observers included.
+func (h *Handler) ListTeamMembers(c echo.Context) error {
+	team := c.Param("team")
+
+	members, err := h.storage.ListTeamMembers(c.Request().Context(), team)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list members of team %q: %w", team, err), "failed to list team members")
+	}
+
+	resp := make([]TeamMemberResponse, 0, len(members))
+	for _, member := range members {
+		resp = append(resp, newTeamMemberResponse(team, member))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// SetTeamMember handles requests adding a member to a team roster or
+// changing its role. Members on a schedule cannot become observers, they
+// are removed from their schedules first.
+func (h *Handler) SetTeamMember(c echo.Context) error {
+	team := c.Param("team")
+
+	name := strings.TrimSpace(c.Param("name"))
+	if name == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "name is required"})
+	}
+	if len(name) > maxTeamMemberNameLength {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: fmt.Sprintf("name must be at most %d bytes", maxTeamMemberNameLength)})
+	}
+
+	var req TeamMemberRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	if req.Role == "" {
+		req.Role = storage.MemberRoleMember
+	}
+	if req.Role != storage.MemberRoleMember && req.Role != storage.MemberRoleObserver {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "role must be member or observer"})
+	}
+
+	ctx := c.Request().Context()
+
+	if req.Role == storage.MemberRoleObserver {
+		t, err := h.storage.GetTeam(ctx, team)
+		if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+			return h.storageFailure(c, fmt.Errorf("get team %q: %w", team, err), "failed to set team member")
+		}
+		if schedule, ok := scheduleOf(t.Schedules, name, h.now()); ok {
+			return c.JSON(http.StatusConflict, ErrorResponse{
+				Error: fmt.Sprintf("%s is on schedule %s, remove them from it before making them an observer", name, schedule),
+			})
+		}
+
+		groups, err := h.storage.ListGroups(ctx, team)
+		if err != nil {
+			return h.storageFailure(c, fmt.Errorf("list groups of team %q: %w", team, err), "failed to set team member")
+		}
+		if group, ok := groupOf(groups, name); ok {
+			return c.JSON(http.StatusConflict, ErrorResponse{
+				Error: fmt.Sprintf("%s is in group %s, remove them from it before making them an observer", name, group),
+			})
+		}
+	}
+
+	member, found, err := h.storage.SetTeamMember(ctx, team, storage.TeamMember{Name: name, Role: req.Role})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("set member %q of team %q as %s: %w", name, team, req.Role, err), "failed to set team member")
+	}
+
+	if !found {
+		return h.teamNotFound(c, team, "team not found")
+	}
+
+	h.logger.Info("team member set",
+		zap.String("team", team),
+		zap.String("name", name),
+		zap.String("role", member.Role),
+		zap.String("actor", storage.ActorFrom(ctx)),
+	)
+
+	return c.JSON(http.StatusOK, newTeamMemberResponse(team, member))
+}
+
+// RemoveTeamMember handles requests removing a member from a team roster.
+// Their schedules are left as they are.
+func (h *Handler) RemoveTeamMember(c echo.Context) error {
+	team, name := c.Param("team"), c.Param("name")
+
+	removed, err := h.storage.RemoveTeamMember(c.Request().Context(), team, name)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("remove member %q of team %q: %w", name, team, err), "failed to remove team member")
+	}
+
+	if !removed {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "team member not found"})
+	}
+
+	h.logger.Info("team member removed", zap.String("team", team), zap.String("name", name))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// observerIn returns the first of the members who is an observer of the
+// team, observers are never put on call.
+func (h *Handler) observerIn(ctx context.Context, team string, members []string) (string, bool, error) {
+	roster, err := h.storage.ListTeamMembers(ctx, team)
+	if err != nil {
+		return "", false, err
+	}
+
+	for _, member := range roster {
+		if member.Role == storage.MemberRoleObserver && slices.Contains(members, member.Name) {
+			return member.Name, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// observerMessage is the validation error of an observer put on call.
+func observerMessage(member, team string) string {
+	return fmt.Sprintf("%s is an observer of team %s and cannot be on call, promote them to member first", member, team)
+}
+
+// scheduleOf returns the name of the first schedule listing the member or
+// pinning them to a date from the given instant on. Schedules using the
+// members of the team do not list them, observers drop out of those, and
+// neither do groups the schedules reference.
+func scheduleOf(schedules []storage.Schedule, member string, from time.Time) (string, bool) {
+	today := storage.PinDate(from)
+
+	for _, schedule := range schedules {
+		listed := schedule.Members
+		if len(schedule.MemberRefs) > 0 {
+			listed = schedule.MemberRefs
+		}
+		if !schedule.TeamMembers && slices.Contains(listed, member) {
+			return schedule.Name, true
+		}
+		for _, pin := range schedule.Pins {
+			if pin.Member == member && !pin.Date.Before(today) {
+				return schedule.Name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// newTeamMemberResponse converts a member of the roster of the team.
+func newTeamMemberResponse(team string, member storage.TeamMember) TeamMemberResponse {
+	return TeamMemberResponse{
+		Team:      team,
+		Name:      member.Name,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
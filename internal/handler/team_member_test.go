@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTeamMemberServer(t *testing.T) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.POST("/schedule/:id/pins", h.CreatePin)
+	e.GET("/teams/:team/members", h.ListTeamMembers)
+	e.PUT("/teams/:team/members/:name", h.SetTeamMember, h.Authenticate(auth.RoleAdmin, "secret"))
+	e.DELETE("/teams/:team/members/:name", h.RemoveTeamMember, h.Authenticate(auth.RoleAdmin, "secret"))
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, store
+}
+
+// listTeamMembers returns the roster of the backend team.
+func listTeamMembers(t *testing.T, e *echo.Echo) []TeamMemberResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodGet, "/teams/backend-team/members", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var members []TeamMemberResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &members))
+
+	return members
+}
+
+func TestTeamMembers_Observer(t *testing.T) {
+	e, store := newTeamMemberServer(t)
+
+	rec := serveJSON(e, http.MethodPut, "/teams/backend-team/members/Carol", TeamMemberRequest{Role: storage.MemberRoleObserver}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// Observers are listed along with the members of the schedules
+	members := listTeamMembers(t, e)
+	require.Len(t, members, 3)
+	assert.Equal(t, "Alice", members[0].Name)
+	assert.Equal(t, storage.MemberRoleMember, members[0].Role)
+	assert.Equal(t, "Carol", members[2].Name)
+	assert.Equal(t, storage.MemberRoleObserver, members[2].Role)
+
+	// Observers are never put on call
+	req := quotaRequest("backend-team")
+	req.Name = "Evening"
+	req.Members = []string{"Alice", "Carol"}
+	rec = serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Carol is an observer of team backend-team")
+
+	team, err := store.GetTeam(t.Context(), "backend-team")
+	require.NoError(t, err)
+	target := "/schedule/" + team.Schedules[0].ID + "/pins?external=true"
+	tomorrow := storage.PinDate(time.Now()).AddDate(0, 0, 1).Format(time.DateOnly)
+
+	rec = serveJSON(e, http.MethodPost, target, PinRequest{Date: tomorrow, Member: "Carol"}, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Until they are promoted
+	rec = serveJSON(e, http.MethodPut, "/teams/backend-team/members/Carol", TeamMemberRequest{Role: storage.MemberRoleMember}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveJSON(e, http.MethodPost, "/schedule", req, "")
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodPost, target, PinRequest{Date: tomorrow, Member: "Carol"}, "")
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+}
+
+func TestTeamMembers_Set_Validation(t *testing.T) {
+	e, _ := newTeamMemberServer(t)
+
+	tests := []struct {
+		name   string
+		target string
+		req    TeamMemberRequest
+		token  string
+		code   int
+	}{
+		{"unknown role", "/teams/backend-team/members/Carol", TeamMemberRequest{Role: "lead"}, "secret", http.StatusBadRequest},
+		{"unknown team", "/teams/frontend-team/members/Carol", TeamMemberRequest{}, "secret", http.StatusNotFound},
+		{"scheduled member", "/teams/backend-team/members/Alice", TeamMemberRequest{Role: storage.MemberRoleObserver}, "secret", http.StatusConflict},
+		{"no token", "/teams/backend-team/members/Carol", TeamMemberRequest{}, "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveJSON(e, http.MethodPut, tt.target, tt.req, tt.token)
+			assert.Equal(t, tt.code, rec.Code, rec.Body.String())
+		})
+	}
+
+	// The role defaults to member
+	rec := serveJSON(e, http.MethodPut, "/teams/backend-team/members/Carol", TeamMemberRequest{}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var member TeamMemberResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &member))
+	assert.Equal(t, storage.MemberRoleMember, member.Role)
+	assert.Equal(t, "backend-team", member.Team)
+}
+
+func TestTeamMembers_Remove(t *testing.T) {
+	e, _ := newTeamMemberServer(t)
+
+	rec := serveJSON(e, http.MethodPut, "/teams/backend-team/members/Carol", TeamMemberRequest{Role: storage.MemberRoleObserver}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/members/Carol", nil, "secret")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Len(t, listTeamMembers(t, e), 2)
+
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team/members/Carol", nil, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestTeamMembers_Schedule(t *testing.T) {
+	e, store := newTeamMemberServer(t)
+
+	req := quotaRequest("backend-team")
+	req.Name, req.Members, req.UseTeamMembers, req.Days = "Everyone", nil, true, []string{"Saturday"}
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	members := func() storage.Schedule {
+		t.Helper()
+
+		team, err := store.GetTeam(t.Context(), "backend-team")
+		require.NoError(t, err)
+		require.Len(t, team.Schedules, 2)
+		require.True(t, team.Schedules[1].TeamMembers)
+
+		return team.Schedules[1]
+	}
+	assert.Equal(t, []string{"Alice", "Bob"}, members().Members)
+
+	rec = serveJSON(e, http.MethodPut, "/teams/backend-team/members/Carol", TeamMemberRequest{}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"Alice", "Bob", "Carol"}, members().Members)
+
+	// The schedule does not keep members from becoming observers, they drop out of it
+	rec = serveJSON(e, http.MethodPut, "/teams/backend-team/members/Carol", TeamMemberRequest{Role: storage.MemberRoleObserver}, "secret")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []string{"Alice", "Bob"}, members().Members)
+
+	// Backups and calendars carry the flag rather than the members
+	backup := scheduleRequest("backend-team", members())
+	assert.True(t, backup.UseTeamMembers)
+	assert.Empty(t, backup.Members)
+
+	var ics bytes.Buffer
+	require.NoError(t, writeCalendar(&ics, "backend-team", []storage.Schedule{members()}, "", time.Now()))
+	assert.Contains(t, ics.String(), "X-ONCALL-TEAM-MEMBERS:TRUE\r\n")
+
+	events, err := parseCalendar(&ics)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	imported, err := eventRequest(events[0], "imported-team", nil)
+	require.NoError(t, err)
+	assert.True(t, imported.UseTeamMembers)
+	assert.Empty(t, imported.Members)
+
+	tests := []struct {
+		name   string
+		modify func(*Request)
+		err    string
+	}{
+		{"members", func(r *Request) { r.Members = []string{"Alice"} }, "members cannot be combined with use_team_members"},
+		{"current member", func(r *Request) { r.CurrentMember = "Alice" }, "current_member cannot be combined with use_team_members, use rotation_offset"},
+		{"fixed assignment", func(r *Request) {
+			r.Assignment, r.DayAssignments = AssignmentFixed, map[string]string{"Saturday": "Alice"}
+		}, "use_team_members cannot be combined with fixed assignment, use day_assignments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invalid := req
+			tt.modify(&invalid)
+
+			rec := serveJSON(e, http.MethodPost, "/schedule", invalid, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.err)
+		})
+	}
+}
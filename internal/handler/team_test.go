@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetTeamSchedules(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team", h.GetTeamSchedules)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TeamResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "backend-team", resp.Team)
+	require.Len(t, resp.Schedules, 1)
+
+	sched := resp.Schedules[0]
+	assert.NotEmpty(t, sched.ID)
+	assert.Equal(t, "Weekday", sched.Name)
+	assert.Equal(t, []string{"Alice", "Bob"}, sched.Members)
+	assert.Equal(t, []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday"}, sched.Days)
+	assert.Equal(t, "9:00AM", sched.Start)
+	assert.Equal(t, "5:00PM", sched.End)
+
+	// The schedule is created again as it is once edited
+	sched.Name = "Weekday Copy"
+	sched.Members = []string{"Bob", "Charlie"}
+	rec = serveJSON(e, http.MethodPost, "/schedule", sched, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	resp = TeamResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Schedules, 2)
+
+	copied := resp.Schedules[1]
+	assert.NotEqual(t, sched.ID, copied.ID)
+	copied.ID = sched.ID
+	assert.Equal(t, sched, copied)
+}
+
+func TestGetTeamSchedules_NotFound(t *testing.T) {
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	e.GET("/teams/:team", h.GetTeamSchedules)
+
+	rec := serveJSON(e, http.MethodGet, "/teams/frontend-team", nil, "")
+	require.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	var resp ErrorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "team not found", resp.Error)
+}
+
+func TestDeleteTeam(t *testing.T) {
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/teams/:team", h.GetTeamSchedules)
+	e.DELETE("/teams/:team", h.DeleteTeam)
+
+	weekend := quotaRequest("backend-team")
+	weekend.Name = "Weekend"
+	weekend.Days = []string{"Saturday", "Sunday"}
+
+	for _, req := range []Request{quotaRequest("backend-team"), weekend, quotaRequest("frontend-team")} {
+		rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	rec := serveJSON(e, http.MethodDelete, "/teams/backend-team", nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	assert.Empty(t, rec.Body.String())
+
+	_, err := store.GetTeam(t.Context(), "backend-team")
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+
+	rec = serveJSON(e, http.MethodGet, "/teams/backend-team", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	// Other teams are left as they are
+	team, err := store.GetTeam(t.Context(), "frontend-team")
+	require.NoError(t, err)
+	assert.Len(t, team.Schedules, 1)
+
+	// The team is gone, deleting it again is not found
+	rec = serveJSON(e, http.MethodDelete, "/teams/backend-team", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+}
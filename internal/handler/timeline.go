@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+)
+
+// Timeline granularities, segments are split at every midnight or at every
+// hour in the tz zone so each one fits a single column.
+const (
+	GranularityDay  = "day"
+	GranularityHour = "hour"
+)
+
+// Timeline segment flags.
+const (
+	FlagPinned   = "pinned"
+	FlagGap      = "gap"
+	FlagBlackout = "blackout"
+)
+
+// maxTimelineRange bounds the range of a timeline by granularity.
+var maxTimelineRange = map[string]time.Duration{
+	GranularityDay:  366 * 24 * time.Hour,
+	GranularityHour: 31 * 24 * time.Hour,
+}
+
+// TimelineSegment represents a stretch of a timeline lane. Flags mark
+// segments whose member is pinned and, in the uncovered row, gaps and the
+// expected uncovered time inside blackouts.
+type TimelineSegment struct {
+	Member string `json:"member,omitempty"`
+	// Members are all the members on duty for schedules requiring more than
+	// one, Member first.
+	Members []string `json:"members,omitempty"`
+	Start   string   `json:"start"`
+	End     string   `json:"end"`
+	Flags   []string `json:"flags,omitempty"`
+}
+
+// TimelineLane represents the segments during which the on-call lookup
+// answers from a schedule.
+type TimelineLane struct {
+	ScheduleID string            `json:"schedule_id"`
+	Schedule   string            `json:"schedule"`
+	Segments   []TimelineSegment `json:"segments"`
+}
+
+// TimelineResponse represents the timeline of a team. Lanes follow the order
+// of the schedules, and Uncovered holds the stretches nobody is on call.
+type TimelineResponse struct {
+	Team        string            `json:"team"`
+	From        string            `json:"from"`
+	To          string            `json:"to"`
+	Granularity string            `json:"granularity"`
+	Lanes       []TimelineLane    `json:"lanes"`
+	Uncovered   []TimelineSegment `json:"uncovered"`
+	Warning     string            `json:"warning,omitempty"`
+}
+
+// TeamTimeline handles timeline requests. Every schedule of the team gets a
+// lane holding the stretches the on-call lookup answers from it, resolved
+// like the export, and time while the team is paused is left uncovered.
+// Uncovered time inside a blackout of the team is flagged as such instead of
+// as a gap. With
+// as_configured every stretch is resolved against the schedules live at it,
+// and the lanes are those of the schedules live during the range.
+func (h *Handler) TeamTimeline(c echo.Context) error {
+	teamName := c.Param("team")
+
+	loc, err := parseLocation(c.QueryParam("tz"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	granularity := c.QueryParam("granularity")
+	if granularity == "" {
+		granularity = GranularityDay
+	}
+	maxRange, ok := maxTimelineRange[granularity]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid granularity, use 'day' or 'hour'"})
+	}
+
+	from, err := h.parseTime(c.QueryParam("from"), "from", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+	to, err := h.parseTime(c.QueryParam("to"), "to", loc)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	if !from.Before(to) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "from must be before to"})
+	}
+	if to.Sub(from) > maxRange {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error: fmt.Sprintf("range must not exceed %d days with %s granularity", int(maxRange.Hours()/24), granularity),
+		})
+	}
+
+	asConfigured, err := parseAsConfigured(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	team, err := h.storage.GetTeam(ctx, teamName)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return h.teamNotFound(c, teamName, "team not found")
+	}
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	pause, paused, err := h.storage.GetPause(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("get pause of team %q: %w", teamName, err), "failed to retrieve team")
+	}
+	if !paused {
+		pause = storage.Pause{}
+	}
+
+	blackouts, err := h.storage.ListBlackouts(ctx, teamName)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list blackouts of team %q: %w", teamName, err), "failed to retrieve team")
+	}
+
+	var history []storage.TeamVersion
+	var warning string
+	if asConfigured {
+		history, warning, err = h.teamHistory(ctx, teamName, from, to)
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			return h.teamNotFound(c, teamName, "team not found")
+		}
+		if err != nil {
+			return h.storageFailure(c, err, "failed to retrieve team")
+		}
+	}
+
+	resp := TimelineResponse{
+		Team:        teamName,
+		From:        from.In(loc).Format(time.RFC3339),
+		To:          to.In(loc).Format(time.RFC3339),
+		Granularity: granularity,
+		Lanes:       make([]TimelineLane, 0, len(team.Schedules)),
+		Uncovered:   []TimelineSegment{},
+	}
+
+	lanes := make(map[string]int, len(team.Schedules))
+	addLane := func(sched storage.Schedule) {
+		if _, ok := lanes[sched.ID]; ok {
+			return
+		}
+
+		lanes[sched.ID] = len(resp.Lanes)
+		resp.Lanes = append(resp.Lanes, TimelineLane{
+			ScheduleID: sched.ID,
+			Schedule:   sched.Name,
+			Segments:   []TimelineSegment{},
+		})
+	}
+
+	duties := storage.DutyTimeline(team.Schedules, from, to)
+	if asConfigured {
+		duties = storage.HistoryTimeline(history, from, to)
+		for _, version := range history {
+			for _, sched := range version.Team.Schedules {
+				addLane(sched)
+			}
+		}
+	} else {
+		for _, sched := range team.Schedules {
+			addLane(sched)
+		}
+	}
+	if warning != "" {
+		c.Response().Header().Set(HeaderOncallWarning, warning)
+		resp.Warning = warning
+	}
+
+	// covered is the end of the covered stretch starting at from
+	covered := from
+	uncover := func(end time.Time) {
+		if !end.After(covered) {
+			return
+		}
+
+		// The stretches between the gaps left out of the blackouts are blackout time
+		start := covered
+		for _, gap := range storage.WithoutBlackouts([]storage.Gap{{Start: covered, End: end}}, blackouts) {
+			if gap.Start.After(start) {
+				resp.Uncovered = append(resp.Uncovered, splitSegments(storage.Shift{Start: start, End: gap.Start}, "", []string{FlagBlackout}, granularity, loc)...)
+			}
+			resp.Uncovered = append(resp.Uncovered, splitSegments(storage.Shift{Start: gap.Start, End: gap.End}, "", []string{FlagGap}, granularity, loc)...)
+			start = gap.End
+		}
+		if end.After(start) {
+			resp.Uncovered = append(resp.Uncovered, splitSegments(storage.Shift{Start: start, End: end}, "", []string{FlagBlackout}, granularity, loc)...)
+		}
+	}
+
+	for _, duty := range duties {
+		var flags []string
+		if duty.Pinned {
+			flags = append(flags, FlagPinned)
+		}
+
+		for _, stretch := range outsidePause(duty.Shift, pause) {
+			uncover(stretch.Start)
+			covered = stretch.End
+
+			segments := splitSegments(stretch, duty.Member, flags, granularity, loc)
+			if len(duty.Others) > 0 {
+				for i := range segments {
+					segments[i].Members = duty.Members()
+				}
+			}
+
+			lane := &resp.Lanes[lanes[duty.ScheduleID]]
+			lane.Segments = append(lane.Segments, segments...)
+		}
+	}
+	uncover(to)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// splitSegments renders a stretch as segments, split at the boundaries of
+// the granularity in loc.
+func splitSegments(stretch storage.Shift, member string, flags []string, granularity string, loc *time.Location) []TimelineSegment {
+	var segments []TimelineSegment
+
+	for start := stretch.Start.In(loc); start.Before(stretch.End); {
+		var boundary time.Time
+		if granularity == GranularityHour {
+			boundary = time.Date(start.Year(), start.Month(), start.Day(), start.Hour()+1, 0, 0, 0, loc)
+		} else {
+			boundary = time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, loc)
+		}
+		end := earlier(boundary, stretch.End).In(loc)
+
+		segments = append(segments, TimelineSegment{
+			Member: member,
+			Start:  start.Format(time.RFC3339),
+			End:    end.Format(time.RFC3339),
+			Flags:  flags,
+		})
+		start = end
+	}
+
+	return segments
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTimelineHandler(t *testing.T) (*Handler, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+
+	_, err := store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Day",
+		Members: []string{"Alice", "Bob"},
+		Days:    []time.Weekday{time.Monday, time.Tuesday},
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	// 20:00 to 23:00 UTC is 23:30 to 02:30 in Tehran
+	_, err = store.AddSchedule(ctx, "backend-team", storage.Schedule{
+		Name:    "Evening",
+		Members: []string{"Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "8:00PM"),
+		End:     parseTime(t, "11:00PM"),
+	})
+	require.NoError(t, err)
+
+	team, err := store.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+
+	// Carol covers the Tuesday of Alice's week
+	_, _, err = store.AddPin(ctx, "backend-team", team.Schedules[0].ID, storage.Pin{Date: time.Date(2025, 4, 29, 0, 0, 0, 0, time.UTC), Member: "Carol"})
+	require.NoError(t, err)
+
+	return New(store, zap.NewNop()), store
+}
+
+func getTimeline(t *testing.T, h *Handler, team, query string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/teams/"+team+"/timeline?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("team")
+	c.SetParamValues(team)
+
+	require.NoError(t, h.TeamTimeline(c))
+
+	return rec
+}
+
+func TestTeamTimeline_Golden(t *testing.T) {
+	h, store := newTimelineHandler(t)
+
+	rec := getTimeline(t, h, "backend-team", "from=2025-04-28&to=2025-04-30&tz=Asia/Tehran")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var got bytes.Buffer
+	require.NoError(t, json.Indent(&got, rec.Body.Bytes(), "", "  "))
+	got.WriteByte('\n')
+
+	assertGolden(t, "timeline.json", numberSchedules(t, store, "backend-team", got.Bytes()))
+}
+
+func TestTeamTimeline_HourGranularity(t *testing.T) {
+	h, store := newTimelineHandler(t)
+
+	_, err := store.PauseTeam(context.Background(), "backend-team", storage.Pause{
+		Since: time.Date(2025, 4, 28, 10, 30, 0, 0, time.UTC),
+		Until: time.Date(2025, 4, 28, 16, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	rec := getTimeline(t, h, "backend-team", "from=2025-04-28T08:00:00Z&to=2025-04-28T17:00:00Z&granularity=hour")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TimelineResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Lanes, 2)
+	assert.Empty(t, resp.Lanes[1].Segments)
+
+	segment := func(member, start, end string, flags ...string) TimelineSegment {
+		return TimelineSegment{Member: member, Start: "2025-04-28T" + start + ":00Z", End: "2025-04-28T" + end + ":00Z", Flags: flags}
+	}
+	assert.Equal(t, []TimelineSegment{
+		segment("Alice", "09:00", "10:00"),
+		segment("Alice", "10:00", "10:30"),
+		segment("Alice", "16:00", "17:00"),
+	}, resp.Lanes[0].Segments)
+
+	// The pause is uncovered like the time before the first shift
+	assert.Equal(t, []TimelineSegment{
+		segment("", "08:00", "09:00", FlagGap),
+		segment("", "10:30", "11:00", FlagGap),
+		segment("", "11:00", "12:00", FlagGap),
+		segment("", "12:00", "13:00", FlagGap),
+		segment("", "13:00", "14:00", FlagGap),
+		segment("", "14:00", "15:00", FlagGap),
+		segment("", "15:00", "16:00", FlagGap),
+	}, resp.Uncovered)
+}
+
+func TestTeamTimeline_Blackouts(t *testing.T) {
+	h, store := newTimelineHandler(t)
+
+	// The blackout covers the start of the gap between the Day and Evening shifts
+	_, _, err := store.AddBlackout(context.Background(), "backend-team", storage.Blackout{
+		Start: time.Date(2025, 4, 28, 16, 0, 0, 0, time.UTC),
+		End:   time.Date(2025, 4, 28, 19, 30, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	rec := getTimeline(t, h, "backend-team", "from=2025-04-28T16:00:00Z&to=2025-04-28T21:00:00Z&granularity=hour")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp TimelineResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	segment := func(member, start, end string, flags ...string) TimelineSegment {
+		return TimelineSegment{Member: member, Start: "2025-04-28T" + start + ":00Z", End: "2025-04-28T" + end + ":00Z", Flags: flags}
+	}
+
+	// Shifts inside the blackout are kept
+	require.Len(t, resp.Lanes, 2)
+	assert.Equal(t, []TimelineSegment{segment("Alice", "16:00", "17:00")}, resp.Lanes[0].Segments)
+	assert.Equal(t, []TimelineSegment{segment("Bob", "20:00", "21:00")}, resp.Lanes[1].Segments)
+
+	assert.Equal(t, []TimelineSegment{
+		segment("", "17:00", "18:00", FlagBlackout),
+		segment("", "18:00", "19:00", FlagBlackout),
+		segment("", "19:00", "19:30", FlagBlackout),
+		segment("", "19:30", "20:00", FlagGap),
+	}, resp.Uncovered)
+}
+
+func TestTeamTimeline_InvalidRequests(t *testing.T) {
+	h, _ := newTimelineHandler(t)
+
+	tests := []struct {
+		name   string
+		team   string
+		query  string
+		status int
+		err    string
+	}{
+		{"unknown team", "frontend-team", "from=2025-04-28&to=2025-04-30", http.StatusNotFound, "team not found"},
+		{"missing from", "backend-team", "to=2025-04-30", http.StatusBadRequest, "from query parameter is required"},
+		{"empty range", "backend-team", "from=2025-04-30&to=2025-04-28", http.StatusBadRequest, "from must be before to"},
+		{"invalid granularity", "backend-team", "from=2025-04-28&to=2025-04-30&granularity=week", http.StatusBadRequest, "invalid granularity, use 'day' or 'hour'"},
+		{"range too long", "backend-team", "from=2025-04-01&to=2025-05-15&granularity=hour", http.StatusBadRequest, "range must not exceed 31 days with hour granularity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := getTimeline(t, h, tt.team, tt.query)
+			assert.Equal(t, tt.status, rec.Code)
+
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.err, resp.Error)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timeFormats enumerates the forms parseTime accepts, for its errors.
+const timeFormats = "use RFC3339, 'now', 'now+<duration>', 'now-<duration>' or '2006-01-02' format"
+
+// parseTime parses a time query parameter: an RFC3339 instant, now, now
+// shifted by a Go duration such as now+36h or now-90m, or a date, which
+// starts at midnight in loc. Anything else is rejected.
+func (h *Handler) parseTime(value, name string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("%s query parameter is required", name)
+	}
+
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, nil
+	}
+
+	if at, err := time.ParseInLocation(time.DateOnly, value, loc); err == nil {
+		return at, nil
+	}
+
+	if rest, ok := strings.CutPrefix(value, "now"); ok {
+		if rest == "" {
+			return h.now(), nil
+		}
+
+		// The sign is the one after now, the duration itself must not carry
+		// one. An unescaped + in a query string arrives as a space.
+		sign, duration := rest[:1], rest[1:]
+		if (sign == "+" || sign == " " || sign == "-") && duration != "" && duration[0] != '+' && duration[0] != '-' {
+			if d, err := time.ParseDuration(duration); err == nil {
+				if sign == "-" {
+					d = -d
+				}
+				return h.now().Add(d), nil
+			}
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid %s format, %s", name, timeFormats)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestParseTime(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)}
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.now = clock.Now
+
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	require.NoError(t, err)
+
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2026-03-05T10:00:00Z", time.Date(2026, 3, 5, 10, 0, 0, 0, time.UTC)},
+		{"now", clock.now},
+		{"now+2h", clock.now.Add(2 * time.Hour)},
+		{"now 2h", clock.now.Add(2 * time.Hour)},
+		{"now+36h", clock.now.Add(36 * time.Hour)},
+		{"now-1h30m", clock.now.Add(-90 * time.Minute)},
+		{"2026-03-05", time.Date(2026, 3, 5, 0, 0, 0, 0, tehran)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			at, err := h.parseTime(tt.value, "time", tehran)
+			require.NoError(t, err)
+			assert.True(t, tt.want.Equal(at), "got %s", at)
+		})
+	}
+
+	for _, value := range []string{"tomorrow", "now+", "now  2h", "now + 2h", "now +2h", "now+-2h", "now--2h", "now+2", "Now", "2026-3-5", "now+2h "} {
+		t.Run(value, func(t *testing.T) {
+			_, err := h.parseTime(value, "time", tehran)
+			require.EqualError(t, err, "invalid time format, "+timeFormats)
+		})
+	}
+
+	_, err = h.parseTime("", "from", tehran)
+	require.EqualError(t, err, "from query parameter is required")
+}
+
+func TestGetSchedule_RelativeTime(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)}
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.now = clock.Now
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", quotaRequest("backend-team"), "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// Midnight on Monday plus 36 hours is noon on Tuesday
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=now%2B36h", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp OncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, oncallAt(t, e, time.Date(2026, 3, 3, 12, 0, 0, 0, time.UTC)), resp.Oncall)
+
+	// An unescaped + arrives as a space
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=now+36h", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=now", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code, rec.Body.String())
+
+	rec = serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time=tomorrow", nil, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+}
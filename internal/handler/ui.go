@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/labstack/echo/v4"
+)
+
+// uiAssetMaxAge is how long browsers keep the assets of the web UI, which
+// the index references by the version of their content.
+const uiAssetMaxAge = "public, max-age=31536000, immutable"
+
+//go:embed ui
+var uiFiles embed.FS
+
+var (
+	uiIndex = template.Must(template.ParseFS(uiFiles, "ui/index.html"))
+	// uiVersion changes with the content of the assets, so references to
+	// them can be cached for good.
+	uiVersion = assetsVersion()
+)
+
+// SetUI sets the configuration of the web UI.
+func (h *Handler) SetUI(cfg config.UIConfig) {
+	h.ui = cfg
+}
+
+// UI handles requests for the web UI showing who is on call for every team,
+// its index at /ui and its assets below it. It answers 404 unless enabled.
+// The index is rendered with the base path the requests of the UI go to and
+// is never cached, the assets are cached for good when asked for with the
+// version the index references them with.
+func (h *Handler) UI(c echo.Context) error {
+	if !h.ui.Enabled {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "web UI is disabled"})
+	}
+
+	name := c.Param("*")
+	if name == "" || name == "index.html" {
+		var buf bytes.Buffer
+		if err := uiIndex.Execute(&buf, struct{ BasePath, Version string }{h.ui.BasePath, uiVersion}); err != nil {
+			return err
+		}
+
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+
+		return c.HTMLBlob(http.StatusOK, buf.Bytes())
+	}
+
+	content, err := uiFiles.ReadFile(path.Join("ui", path.Clean("/"+name)))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "not found"})
+	}
+
+	if c.QueryParam("v") == uiVersion {
+		c.Response().Header().Set(echo.HeaderCacheControl, uiAssetMaxAge)
+	} else {
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+	}
+
+	return c.Blob(http.StatusOK, mime.TypeByExtension(path.Ext(name)), content)
+}
+
+// assetsVersion returns a digest of the assets of the web UI.
+func assetsVersion() string {
+	digest := sha256.New()
+	err := fs.WalkDir(uiFiles, "ui", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasSuffix(name, ".html") {
+			return err
+		}
+
+		content, err := uiFiles.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		digest.Write([]byte(name))
+		digest.Write(content)
+
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(digest.Sum(nil))[:12]
+}
@@ -0,0 +1,88 @@
+// Lists the teams with who is on call for them now, polling the server every
+// refreshInterval. Every request goes to the server the page came from, under
+// the base path the page was rendered with.
+"use strict";
+
+const refreshInterval = 30000;
+
+const basePath = document.querySelector('meta[name="oncall-base-path"]').content;
+
+function api(path) {
+  return basePath + path;
+}
+
+async function getJSON(path) {
+  const response = await fetch(api(path), { headers: { Accept: "application/json" } });
+  if (!response.ok) {
+    throw new Error(`${path} answered ${response.status}`);
+  }
+  return response.json();
+}
+
+async function nextHandoff(team) {
+  try {
+    const resp = await getJSON(`/teams/${encodeURIComponent(team)}/handoffs?count=1`);
+    return resp.handoffs.length > 0 ? resp.handoffs[0] : null;
+  } catch {
+    return null;
+  }
+}
+
+function cell(row, text, className) {
+  const td = row.insertCell();
+  td.textContent = text;
+  if (className) {
+    td.className = className;
+  }
+  return td;
+}
+
+function oncallText(team) {
+  if (team.paused) {
+    return ["paused", "paused"];
+  }
+  if (!team.oncall) {
+    return ["nobody", "uncovered"];
+  }
+  if (team.inherited_from) {
+    return [`${team.oncall} (from ${team.inherited_from})`, "oncall"];
+  }
+  return [team.oncall, "oncall"];
+}
+
+async function refresh() {
+  const error = document.getElementById("error");
+
+  try {
+    const resp = await getJSON("/oncall/all");
+    const handoffs = await Promise.all(resp.teams.map((team) => nextHandoff(team.team)));
+
+    const body = document.getElementById("teams");
+    body.replaceChildren();
+
+    resp.teams.forEach((team, i) => {
+      const row = body.insertRow();
+      cell(row, team.team);
+
+      const [text, className] = oncallText(team);
+      cell(row, text, className);
+
+      const handoff = handoffs[i];
+      cell(row, handoff ? `${new Date(handoff.at).toLocaleString()} to ${handoff.to_member}` : "none");
+
+      const link = document.createElement("a");
+      link.href = api(`/teams/${encodeURIComponent(team.team)}/timeline?from=now&to=now%2B24h`);
+      link.textContent = "timeline";
+      row.insertCell().append(link);
+    });
+
+    document.getElementById("updated").textContent = `Updated ${new Date(resp.time).toLocaleTimeString()}`;
+    error.hidden = true;
+  } catch (err) {
+    error.textContent = `Failed to refresh: ${err.message}`;
+    error.hidden = false;
+  }
+}
+
+refresh();
+setInterval(refresh, refreshInterval);
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newUIServer(t *testing.T, cfg config.UIConfig) (*echo.Echo, storage.Storage) {
+	t.Helper()
+
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+	h.now = (&fakeClock{now: time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)}).Now
+	h.SetUI(cfg)
+
+	e := echo.New()
+	e.GET("/oncall/all", h.AllOncall)
+	e.GET("/ui", h.UI)
+	e.GET("/ui/*", h.UI)
+
+	return e, store
+}
+
+func TestUI_ServesAssets(t *testing.T) {
+	e, _ := newUIServer(t, config.UIConfig{Enabled: true})
+
+	for _, target := range []string{"/ui", "/ui/", "/ui/index.html"} {
+		rec := serveJSON(e, http.MethodGet, target, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, target)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), "text/html")
+		assert.Equal(t, "no-cache", rec.Header().Get(echo.HeaderCacheControl))
+		assert.Contains(t, rec.Body.String(), "Who is on call")
+	}
+
+	for target, contentType := range map[string]string{
+		"/ui/app.js":    "javascript",
+		"/ui/style.css": "text/css",
+	} {
+		rec := serveJSON(e, http.MethodGet, target+"?v="+uiVersion, nil, "")
+		require.Equal(t, http.StatusOK, rec.Code, target)
+		assert.Contains(t, rec.Header().Get(echo.HeaderContentType), contentType)
+		assert.Equal(t, uiAssetMaxAge, rec.Header().Get(echo.HeaderCacheControl))
+		assert.NotEmpty(t, rec.Body.String())
+
+		// Only versioned references are cached for good
+		rec = serveJSON(e, http.MethodGet, target, nil, "")
+		assert.Equal(t, "no-cache", rec.Header().Get(echo.HeaderCacheControl))
+	}
+
+	for _, target := range []string{"/ui/missing.js", "/ui/../ui.go"} {
+		rec := serveJSON(e, http.MethodGet, target, nil, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, target)
+	}
+}
+
+func TestUI_Disabled(t *testing.T) {
+	e, _ := newUIServer(t, config.UIConfig{})
+
+	for _, target := range []string{"/ui", "/ui/", "/ui/app.js"} {
+		rec := serveJSON(e, http.MethodGet, target, nil, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, target)
+	}
+}
+
+func TestUI_SameOriginOnly(t *testing.T) {
+	e, _ := newUIServer(t, config.UIConfig{Enabled: true, BasePath: "/oncall"})
+
+	rec := serveJSON(e, http.MethodGet, "/ui", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	index := rec.Body.String()
+
+	assert.Contains(t, index, `<meta name="oncall-base-path" content="/oncall">`)
+	assert.Contains(t, index, `src="/oncall/ui/app.js?v=`+uiVersion+`"`)
+
+	// Every reference is a path of this server, under the base path
+	for _, match := range regexp.MustCompile(`(?:src|href)="([^"]*)"`).FindAllStringSubmatch(index, -1) {
+		assert.Regexp(t, `^/oncall/ui/`, match[1])
+	}
+
+	script, err := uiFiles.ReadFile("ui/app.js")
+	require.NoError(t, err)
+	assert.NotRegexp(t, `(?i)(https?:)?//[a-z0-9.-]+\.[a-z]`, string(script))
+	assert.NotRegexp(t, `(?i)(https?:)?//`, index)
+}
+
+func TestAllOncall(t *testing.T) {
+	e, store := newUIServer(t, config.UIConfig{})
+	ctx := context.Background()
+
+	_, err := store.AddSchedule(ctx, "payments", storage.Schedule{
+		Name:    "Days",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "billing", storage.Schedule{
+		Name:    "Nights",
+		Members: []string{"Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "8:00PM"),
+		End:     parseTime(t, "11:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.AddSchedule(ctx, "search", storage.Schedule{
+		Name:    "Days",
+		Members: []string{"Carol"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	})
+	require.NoError(t, err)
+	_, err = store.PauseTeam(ctx, "search", storage.Pause{Since: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+
+	rec := serveJSON(e, http.MethodGet, "/oncall/all", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var resp AllOncallResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, AllOncallResponse{
+		Time: "2026-03-02T10:00:00Z",
+		Teams: []TeamOncall{
+			{Team: "billing"},
+			{Team: "payments", Oncall: "Alice", Schedule: "Days"},
+			{Team: "search", Paused: true},
+		},
+	}, resp)
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// CodeAllUnavailable is the error code of lookups whose member on call and
+// every member who could take over from them are unavailable.
+const CodeAllUnavailable = "ALL_UNAVAILABLE"
+
+// UnavailabilityRequest represents a request marking a member unavailable.
+type UnavailabilityRequest struct {
+	// Start is the RFC3339 instant the window starts at, now when empty.
+	Start  string `json:"start,omitempty"`
+	End    string `json:"end"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// UnavailabilityResponse represents an unavailability window of a member.
+type UnavailabilityResponse struct {
+	ID        int64  `json:"id"`
+	Member    string `json:"member"`
+	Reason    string `json:"reason,omitempty"`
+	Start     string `json:"start"`
+	End       string `json:"end"`
+	CreatedAt string `json:"created_at"`
+}
+
+// AddUnavailability handles requests marking a member unavailable. While
+// the window is active, lookups hand the pages of the member over to the
+// next available member of the schedule.
+func (h *Handler) AddUnavailability(c echo.Context) error {
+	member := strings.TrimSpace(c.Param("name"))
+	if member == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "member is required"})
+	}
+
+	var req UnavailabilityRequest
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	now := h.now().UTC()
+	window := storage.Unavailability{Member: member, Reason: req.Reason, Start: now}
+
+	if req.Start != "" {
+		start, err := time.Parse(time.RFC3339, req.Start)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid start format, use RFC3339 format"})
+		}
+		window.Start = start.UTC()
+	}
+
+	if req.End == "" {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end is required"})
+	}
+	end, err := time.Parse(time.RFC3339, req.End)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid end format, use RFC3339 format"})
+	}
+	if !end.After(window.Start) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end must be after start"})
+	}
+	if !end.After(now) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "end must be in the future"})
+	}
+	window.End = end.UTC()
+
+	window, err = h.storage.AddUnavailability(c.Request().Context(), window)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add unavailability of member %q: %w", member, err), "failed to mark member unavailable")
+	}
+
+	h.logger.Info("member marked unavailable",
+		zap.String("member", member),
+		zap.String("reason", window.Reason),
+		zap.Time("start", window.Start),
+		zap.Time("end", window.End),
+	)
+
+	return c.JSON(http.StatusCreated, newUnavailabilityResponse(window))
+}
+
+// ListUnavailability handles requests listing the unavailability windows of
+// a member, ended ones included.
+func (h *Handler) ListUnavailability(c echo.Context) error {
+	member := strings.TrimSpace(c.Param("name"))
+
+	windows, err := h.storage.ListUnavailability(c.Request().Context(), member)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list unavailability of member %q: %w", member, err), "failed to list unavailability")
+	}
+
+	resp := make([]UnavailabilityResponse, 0, len(windows))
+	for _, window := range windows {
+		resp = append(resp, newUnavailabilityResponse(window))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CancelUnavailability handles requests canceling an unavailability window
+// of a member, whether or not it started already.
+func (h *Handler) CancelUnavailability(c echo.Context) error {
+	member := strings.TrimSpace(c.Param("name"))
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid unavailability id"})
+	}
+
+	canceled, err := h.storage.CancelUnavailability(c.Request().Context(), member, id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("cancel unavailability %d of member %q: %w", id, member, err), "failed to cancel unavailability")
+	}
+
+	if !canceled {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "unavailability not found"})
+	}
+
+	h.logger.Info("unavailability canceled", zap.String("member", member), zap.Int64("id", id))
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// newUnavailabilityResponse renders an unavailability window.
+func newUnavailabilityResponse(window storage.Unavailability) UnavailabilityResponse {
+	return UnavailabilityResponse{
+		ID:        window.ID,
+		Member:    window.Member,
+		Reason:    window.Reason,
+		Start:     window.Start.UTC().Format(time.RFC3339),
+		End:       window.End.UTC().Format(time.RFC3339),
+		CreatedAt: window.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
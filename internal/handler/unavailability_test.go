@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// unavailabilityMonday is during the first week of the rotation created by
+// newUnavailabilityServer, which Alice is on duty for.
+var unavailabilityMonday = time.Date(2030, 1, 7, 10, 0, 0, 0, time.UTC)
+
+// newUnavailabilityServer creates the weekday rotation of Alice, Bob and
+// Charlie for the backend team, an hour after the lookup instant.
+func newUnavailabilityServer(t *testing.T) (*echo.Echo, *Handler) {
+	t.Helper()
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.now = (&fakeClock{now: unavailabilityMonday.Add(-time.Hour)}).Now
+
+	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/schedule", h.GetSchedule)
+	e.POST("/members/:name/unavailability", h.AddUnavailability)
+	e.GET("/members/:name/unavailability", h.ListUnavailability)
+	e.DELETE("/members/:name/unavailability/:id", h.CancelUnavailability)
+
+	req := quotaRequest("backend-team")
+	req.Members = []string{"Alice", "Bob", "Charlie"}
+	req.Anchor = unavailabilityMonday.Format(time.DateOnly)
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	return e, h
+}
+
+// markUnavailable marks the member unavailable from now until after the
+// lookup instant.
+func markUnavailable(t *testing.T, e *echo.Echo, member string) UnavailabilityResponse {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/members/"+member+"/unavailability",
+		UnavailabilityRequest{End: unavailabilityMonday.Add(time.Hour).Format(time.RFC3339), Reason: "sick"}, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp UnavailabilityResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp
+}
+
+// lookupOncall looks up the backend team at the lookup instant.
+func lookupOncall(t *testing.T, e *echo.Echo) (int, OncallResponse, ErrorResponse) {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodGet, "/schedule?team=backend-team&time="+unavailabilityMonday.Format(time.RFC3339), nil, "")
+
+	var resp OncallResponse
+	var errResp ErrorResponse
+	if rec.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	} else {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errResp))
+	}
+
+	return rec.Code, resp, errResp
+}
+
+func TestUnavailability_SingleSkip(t *testing.T) {
+	e, _ := newUnavailabilityServer(t)
+
+	code, resp, _ := lookupOncall(t, e)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Alice", resp.Oncall)
+	assert.Empty(t, resp.SubstitutedFor)
+
+	window := markUnavailable(t, e, "Alice")
+	assert.Equal(t, "Alice", window.Member)
+	assert.Equal(t, "sick", window.Reason)
+
+	code, resp, _ = lookupOncall(t, e)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Bob", resp.Oncall)
+	assert.Equal(t, "Alice", resp.SubstitutedFor)
+
+	// Canceling the window gives the pages back
+	rec := serveJSON(e, http.MethodDelete, "/members/Alice/unavailability/"+strconv.FormatInt(window.ID, 10), nil, "")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	code, resp, _ = lookupOncall(t, e)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Alice", resp.Oncall)
+	assert.Empty(t, resp.SubstitutedFor)
+}
+
+func TestUnavailability_MultiSkip(t *testing.T) {
+	e, _ := newUnavailabilityServer(t)
+
+	markUnavailable(t, e, "Alice")
+	markUnavailable(t, e, "Bob")
+
+	code, resp, _ := lookupOncall(t, e)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "Charlie", resp.Oncall)
+	assert.Equal(t, "Alice", resp.SubstitutedFor)
+
+	rec := serveJSON(e, http.MethodGet, "/members/Bob/unavailability", nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var windows []UnavailabilityResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &windows))
+	require.Len(t, windows, 1)
+	assert.Equal(t, "Bob", windows[0].Member)
+}
+
+func TestUnavailability_AllUnavailable(t *testing.T) {
+	e, _ := newUnavailabilityServer(t)
+
+	markUnavailable(t, e, "Alice")
+	markUnavailable(t, e, "Bob")
+	markUnavailable(t, e, "Charlie")
+
+	code, _, errResp := lookupOncall(t, e)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, CodeAllUnavailable, errResp.Code)
+}
+
+func TestUnavailability_Validation(t *testing.T) {
+	e, _ := newUnavailabilityServer(t)
+
+	for name, req := range map[string]UnavailabilityRequest{
+		"no end":        {},
+		"invalid end":   {End: "tomorrow"},
+		"invalid start": {Start: "today", End: unavailabilityMonday.Format(time.RFC3339)},
+		"reversed":      {Start: unavailabilityMonday.Format(time.RFC3339), End: unavailabilityMonday.Add(-time.Minute).Format(time.RFC3339)},
+		"ended":         {Start: unavailabilityMonday.Add(-48 * time.Hour).Format(time.RFC3339), End: unavailabilityMonday.Add(-24 * time.Hour).Format(time.RFC3339)},
+	} {
+		rec := serveJSON(e, http.MethodPost, "/members/Alice/unavailability", req, "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code, name)
+	}
+
+	rec := serveJSON(e, http.MethodDelete, "/members/Alice/unavailability/999", nil, "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
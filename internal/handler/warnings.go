@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/week"
+)
+
+// Codes of the warnings returned along with accepted schedules.
+const (
+	WarningScheduleOverlap  = "SCHEDULE_OVERLAP"
+	WarningMemberNoEmail    = "MEMBER_NO_EMAIL"
+	WarningAnchorInFuture   = "ANCHOR_IN_FUTURE"
+	WarningWeekendUncovered = "WEEKEND_UNCOVERED"
+)
+
+// aroundTheClock is how long a stretch of shifts has to last for the team to
+// count as on call around the clock, all-day shifts end at 11:59PM.
+const aroundTheClock = 23 * time.Hour
+
+// overlapWindow is how far ahead shifts are compared to find overlapping
+// schedules.
+const overlapWindow = 5 * 7 * 24 * time.Hour
+
+// Warning represents a condition worth knowing about that does not keep a
+// schedule from being accepted.
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// CreateScheduleResponse represents an accepted schedule creation request,
+// identifying the created schedule.
+type CreateScheduleResponse struct {
+	ID       string    `json:"id"`
+	Team     string    `json:"team"`
+	Name     string    `json:"name"`
+	Warnings []Warning `json:"warnings"`
+}
+
+// adviseSchedule runs the advisory checks on a schedule of the team that
+// passed validation, the schedule with the given ID aside when it is being
+// updated. It only reads, and the warnings come in the order of the checks,
+// each in the order of the schedules and members, so the same schedule
+// always gets the same warnings.
+func (h *Handler) adviseSchedule(ctx context.Context, team string, schedule storage.Schedule, id string) ([]Warning, error) {
+	warnings := []Warning{}
+	now := h.now()
+
+	t, err := h.storage.GetTeam(ctx, team)
+	if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+		return nil, fmt.Errorf("get team %q: %w", team, err)
+	}
+
+	// Overlaps are allowed, the lookup answers from the earlier schedule
+	shifts := storage.UpcomingShifts([]storage.Schedule{schedule}, now, now.Add(overlapWindow))
+	for _, other := range t.Schedules {
+		if other.ID == id {
+			continue
+		}
+
+		for _, shift := range storage.UpcomingShifts([]storage.Schedule{other}, now, now.Add(overlapWindow)) {
+			if slices.ContainsFunc(shifts, func(s storage.Shift) bool {
+				return s.Start.Before(shift.End) && shift.Start.Before(s.End)
+			}) {
+				warnings = append(warnings, Warning{
+					Code:    WarningScheduleOverlap,
+					Message: fmt.Sprintf("shifts overlap schedule %s, whose member is on call during the overlap", other.Name),
+				})
+				break
+			}
+		}
+	}
+
+	if h.events != nil && h.events.Enabled() {
+		for _, member := range schedule.Members {
+			users, err := h.storage.FindUsers(ctx, member)
+			if err != nil {
+				return nil, fmt.Errorf("get user %q: %w", member, err)
+			}
+
+			if !slices.ContainsFunc(users, func(u storage.User) bool { return len(u.Emails) > 0 }) {
+				warnings = append(warnings, Warning{
+					Code:    WarningMemberNoEmail,
+					Message: fmt.Sprintf("%s has no email on file to be notified at", member),
+				})
+			}
+		}
+	}
+
+	if schedule.Anchor.After(storage.PinDate(now)) {
+		warnings = append(warnings, Warning{
+			Code:    WarningAnchorInFuture,
+			Message: fmt.Sprintf("rotation anchor %s is in the future, the weeks until then are counted back from it", schedule.Anchor.Format(time.DateOnly)),
+		})
+	}
+
+	schedules := []storage.Schedule{schedule}
+	for _, other := range t.Schedules {
+		if other.ID != id {
+			schedules = append(schedules, other)
+		}
+	}
+	if wk := h.weeks.Of(team); weekendUncovered(schedules, wk, now) {
+		days := make([]string, 0, len(wk.Weekend))
+		for _, day := range wk.WeekendDays() {
+			days = append(days, day.String())
+		}
+
+		warnings = append(warnings, Warning{
+			Code:    WarningWeekendUncovered,
+			Message: fmt.Sprintf("this schedule leaves weekends uncovered, the team is on call around the clock on weekdays but nobody is on call on %s", strings.Join(days, " and ")),
+		})
+	}
+
+	return warnings, nil
+}
+
+// weekendUncovered reports whether the schedules keep the team on call
+// around the clock, for a stretch of a whole day, while leaving every weekend
+// day of its week uncovered over the weeks after now. Business hours
+// schedules are expected to leave weekends out, so they are not reported.
+// Days are told apart in UTC, which schedules are defined in.
+func weekendUncovered(schedules []storage.Schedule, wk week.Week, now time.Time) bool {
+	shifts := storage.UpcomingShifts(schedules, now, now.Add(overlapWindow))
+	for _, shift := range shifts {
+		if wk.WeekendTime(shift.Start.UTC(), shift.End.UTC()) > 0 {
+			return false
+		}
+	}
+
+	slices.SortFunc(shifts, func(a, b storage.Shift) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	// Adjacent and overlapping shifts are merged into stretches
+	for i := 0; i < len(shifts); {
+		start, end := shifts[i].Start, shifts[i].End
+		for i++; i < len(shifts) && !shifts[i].Start.After(end); i++ {
+			end = later(end, shifts[i].End)
+		}
+
+		if end.Sub(start) >= aroundTheClock {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// discardNotifier accepts every event, enabling notifications.
+type discardNotifier struct{}
+
+func (discardNotifier) Name() string { return "discard" }
+
+func (discardNotifier) Notify(context.Context, notify.Event) error { return nil }
+
+func newWarningsServer(t *testing.T) (*echo.Echo, *Handler, storage.Storage) {
+	t.Helper()
+
+	e := echo.New()
+	store := storage.NewMemoryStorage()
+	h := New(store, zap.NewNop())
+
+	e.POST("/schedule", h.CreateSchedule)
+
+	return e, h, store
+}
+
+// createWarnings creates the schedule and returns the codes of its warnings.
+func createWarnings(t *testing.T, e *echo.Echo, req Request) []string {
+	t.Helper()
+
+	rec := serveJSON(e, http.MethodPost, "/schedule", req, "")
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp CreateScheduleResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Warnings)
+
+	codes := []string{}
+	for _, warning := range resp.Warnings {
+		assert.NotEmpty(t, warning.Message)
+		codes = append(codes, warning.Code)
+	}
+
+	return codes
+}
+
+func TestCreateSchedule_Warnings(t *testing.T) {
+	t.Run("clean", func(t *testing.T) {
+		e, _, _ := newWarningsServer(t)
+
+		assert.Empty(t, createWarnings(t, e, quotaRequest("backend-team")))
+	})
+
+	t.Run("overlap", func(t *testing.T) {
+		e, _, store := newWarningsServer(t)
+
+		assert.Empty(t, createWarnings(t, e, quotaRequest("backend-team")))
+
+		req := quotaRequest("backend-team")
+		req.Name = "Afternoon"
+		req.Days = []string{"Friday"}
+		req.Start, req.End = "3:00PM", "8:00PM"
+		assert.Equal(t, []string{WarningScheduleOverlap}, createWarnings(t, e, req))
+
+		// Warnings never keep the schedule from being stored as given
+		team, err := store.GetTeam(t.Context(), "backend-team")
+		require.NoError(t, err)
+		require.Len(t, team.Schedules, 2)
+		assert.Equal(t, "Afternoon", team.Schedules[1].Name)
+
+		req.Name = "Weekend"
+		req.Days = []string{"Saturday", "Sunday"}
+		assert.Empty(t, createWarnings(t, e, req))
+	})
+
+	t.Run("member without email", func(t *testing.T) {
+		e, h, store := newWarningsServer(t)
+		h.SetDispatcher(notify.NewDispatcher([]notify.Notifier{discardNotifier{}}, &config.Config{}, zap.NewNop()))
+
+		_, _, err := store.AddUser(t.Context(), storage.User{UserName: "Alice", Emails: []string{"alice@example.com"}, Active: true})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{WarningMemberNoEmail}, createWarnings(t, e, quotaRequest("backend-team")))
+	})
+
+	t.Run("member without email, notifications disabled", func(t *testing.T) {
+		e, h, _ := newWarningsServer(t)
+		h.SetDispatcher(notify.NewDispatcher(nil, &config.Config{}, zap.NewNop()))
+
+		assert.Empty(t, createWarnings(t, e, quotaRequest("backend-team")))
+	})
+
+	t.Run("anchor in future", func(t *testing.T) {
+		e, _, _ := newWarningsServer(t)
+
+		req := quotaRequest("backend-team")
+		req.Anchor = time.Now().UTC().AddDate(0, 0, 7).Format(time.DateOnly)
+		assert.Equal(t, []string{WarningAnchorInFuture}, createWarnings(t, e, req))
+
+		req = quotaRequest("frontend-team")
+		req.Anchor = time.Now().UTC().AddDate(0, 0, -7).Format(time.DateOnly)
+		assert.Empty(t, createWarnings(t, e, req))
+	})
+
+	t.Run("deterministic", func(t *testing.T) {
+		e, h, _ := newWarningsServer(t)
+		h.SetDispatcher(notify.NewDispatcher([]notify.Notifier{discardNotifier{}}, &config.Config{}, zap.NewNop()))
+
+		req := quotaRequest("backend-team")
+		req.Anchor = time.Now().UTC().AddDate(0, 1, 0).Format(time.DateOnly)
+		assert.Equal(t, []string{WarningMemberNoEmail, WarningMemberNoEmail, WarningAnchorInFuture}, createWarnings(t, e, req))
+
+		req.Name = "Again"
+		assert.Equal(t, []string{WarningScheduleOverlap, WarningMemberNoEmail, WarningMemberNoEmail, WarningAnchorInFuture}, createWarnings(t, e, req))
+	})
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// WebhookRequest represents the webhook subscription request. An empty team
+// subscribes to every team and empty events to every event kind.
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Team   string   `json:"team,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// WebhookResponse represents a webhook subscription. The secret is never returned.
+type WebhookResponse struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Team      string   `json:"team,omitempty"`
+	Events    []string `json:"events,omitempty"`
+	Signed    bool     `json:"signed"`
+	CreatedAt string   `json:"created_at"`
+}
+
+// AllowUnsignedWebhooks sets whether subscriptions may be created without a secret.
+func (h *Handler) AllowUnsignedWebhooks(allow bool) {
+	h.allowUnsignedWebhooks = allow
+}
+
+// CreateWebhook handles webhook subscription requests.
+func (h *Handler) CreateWebhook(c echo.Context) error {
+	var req WebhookRequest
+
+	if err := c.Bind(&req); err != nil {
+		h.logger.Error("failed to bind request", zap.Error(err))
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid request body"})
+	}
+
+	if err := h.validateWebhook(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: err.Error()})
+	}
+
+	webhook, err := h.storage.AddWebhook(h.withQuota(c.Request().Context(), storage.QuotaWebhooks, req.Team), storage.Webhook{
+		Team:   req.Team,
+		URL:    req.URL,
+		Secret: req.Secret,
+		Events: req.Events,
+	})
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("add webhook for team %q: %w", req.Team, err), "failed to create webhook")
+	}
+
+	h.logger.Info("webhook created",
+		zap.Int64("id", webhook.ID),
+		zap.String("team", webhook.Team),
+		zap.Bool("signed", webhook.Secret != ""),
+	)
+
+	return c.JSON(http.StatusCreated, newWebhookResponse(webhook))
+}
+
+// ListWebhooks handles webhook subscription listing requests.
+func (h *Handler) ListWebhooks(c echo.Context) error {
+	webhooks, err := h.storage.ListWebhooks(c.Request().Context())
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("list webhooks: %w", err), "failed to list webhooks")
+	}
+
+	resp := make([]WebhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		resp = append(resp, newWebhookResponse(webhook))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// DeleteWebhook handles webhook subscription removal requests.
+func (h *Handler) DeleteWebhook(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{Error: "invalid webhook id"})
+	}
+
+	deleted, err := h.storage.DeleteWebhook(c.Request().Context(), id)
+	if err != nil {
+		return h.storageFailure(c, fmt.Errorf("delete webhook %d: %w", id, err), "failed to delete webhook")
+	}
+
+	if !deleted {
+		return c.JSON(http.StatusNotFound, ErrorResponse{Error: "webhook not found"})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// validateWebhook validates the webhook subscription request.
+func (h *Handler) validateWebhook(req *WebhookRequest) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https url")
+	}
+
+	if req.Secret == "" && !h.allowUnsignedWebhooks {
+		return fmt.Errorf("secret is required to sign the deliveries")
+	}
+
+	for _, event := range req.Events {
+		if !slices.Contains(notify.Kinds, notify.Kind(event)) {
+			return fmt.Errorf("invalid event: %s", event)
+		}
+	}
+
+	return nil
+}
+
+// newWebhookResponse converts a stored webhook subscription to its response.
+func newWebhookResponse(webhook storage.Webhook) WebhookResponse {
+	return WebhookResponse{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Team:      webhook.Team,
+		Events:    webhook.Events,
+		Signed:    webhook.Secret != "",
+		CreatedAt: webhook.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
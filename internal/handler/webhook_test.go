@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newWebhookServer(t *testing.T, allowUnsigned bool) *echo.Echo {
+	t.Helper()
+
+	e := echo.New()
+	h := New(storage.NewMemoryStorage(), zap.NewNop())
+	h.AllowUnsignedWebhooks(allowUnsigned)
+
+	admin := e.Group("/admin", Admin("secret"))
+	admin.POST("/webhooks", h.CreateWebhook)
+	admin.GET("/webhooks", h.ListWebhooks)
+	admin.DELETE("/webhooks/:id", h.DeleteWebhook)
+
+	return e
+}
+
+func TestWebhook_CreateListDelete(t *testing.T) {
+	e := newWebhookServer(t, false)
+
+	rec := serveJSON(e, http.MethodPost, "/admin/webhooks", WebhookRequest{
+		URL:    "https://hooks.test/oncall",
+		Secret: "s3cret",
+		Team:   "backend-team",
+		Events: []string{"handoff", "gap"},
+	}, "secret")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "s3cret")
+
+	var created WebhookResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.True(t, created.Signed)
+	assert.Equal(t, "backend-team", created.Team)
+
+	rec = serveJSON(e, http.MethodGet, "/admin/webhooks", nil, "secret")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "s3cret")
+
+	var listed []WebhookResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed, 1)
+	assert.Equal(t, created.ID, listed[0].ID)
+
+	target := fmt.Sprintf("/admin/webhooks/%d", created.ID)
+	rec = serveJSON(e, http.MethodDelete, target, nil, "secret")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serveJSON(e, http.MethodDelete, target, nil, "secret")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestWebhook_Validation(t *testing.T) {
+	tests := []struct {
+		name          string
+		allowUnsigned bool
+		req           WebhookRequest
+		code          int
+	}{
+		{"missing secret", false, WebhookRequest{URL: "https://hooks.test"}, http.StatusBadRequest},
+		{"unsigned allowed", true, WebhookRequest{URL: "https://hooks.test"}, http.StatusCreated},
+		{"relative url", false, WebhookRequest{URL: "/oncall", Secret: "s"}, http.StatusBadRequest},
+		{"unsupported scheme", false, WebhookRequest{URL: "ftp://hooks.test", Secret: "s"}, http.StatusBadRequest},
+		{"unknown event", false, WebhookRequest{URL: "https://hooks.test", Secret: "s", Events: []string{"incident"}}, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newWebhookServer(t, tt.allowUnsigned)
+
+			rec := serveJSON(e, http.MethodPost, "/admin/webhooks", tt.req, "secret")
+			assert.Equal(t, tt.code, rec.Code)
+		})
+	}
+}
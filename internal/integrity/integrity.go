@@ -0,0 +1,251 @@
+// Package integrity reports schedules referencing members who can no longer
+// be paged, e.g. after people leave, before a page goes nowhere.
+package integrity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// Reasons a schedule member is reported for.
+const (
+	// ReasonDeactivated is a member whose provisioned user is deactivated.
+	ReasonDeactivated = "DEACTIVATED"
+	// ReasonNotInTeam is a member missing from the roster of the team, or
+	// on it as an observer. Schedules put their members on the roster, but
+	// keep them when they are removed from it, e.g. when they leave.
+	ReasonNotInTeam = "NOT_IN_TEAM"
+	// ReasonNoContact is a member without a provisioned user with an email
+	// while the reminders of the team are sent.
+	ReasonNoContact = "NO_CONTACT"
+)
+
+// Reasons are the reasons members are reported for, in the order they are checked.
+var Reasons = []string{ReasonDeactivated, ReasonNotInTeam, ReasonNoContact}
+
+var findings = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "oncall_integrity_findings",
+	Help: "Number of schedule members reported by the last integrity check of every team, by reason.",
+}, []string{"reason"})
+
+// Finding is a member of a schedule reported for the reason.
+type Finding struct {
+	Team       string
+	ScheduleID string
+	Schedule   string
+	Member     string
+	Reason     string
+}
+
+// Checker checks the members of the schedules against the users and the
+// rosters of their teams.
+type Checker struct {
+	storage storage.Storage
+	logger  *zap.Logger
+	now     func() time.Time
+
+	// notifying is whether notifications are delivered at all, the
+	// reminders tell the teams whose members are reminded of their shifts.
+	notifying bool
+	reminders config.RemindersConfig
+}
+
+// New creates a checker.
+func New(s storage.Storage, d *notify.Dispatcher, cfg *config.Config, logger *zap.Logger) *Checker {
+	return &Checker{
+		storage:   s,
+		logger:    logger.Named("integrity"),
+		now:       time.Now,
+		notifying: d.Enabled() && cfg.Notify.Interval > 0,
+		reminders: cfg.Notify.Reminders,
+	}
+}
+
+// Team checks the schedules of a team. It returns storage.ErrTeamNotFound
+// when the team does not exist.
+func (c *Checker) Team(ctx context.Context, team string) ([]Finding, error) {
+	t, err := c.storage.GetTeam(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := c.users(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.check(ctx, team, t.Schedules, users)
+}
+
+// All checks the schedules of every team and updates the findings metric.
+// A failing team does not stop the others.
+func (c *Checker) All(ctx context.Context) ([]Finding, error) {
+	teams, err := c.storage.ListTeams(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	users, err := c.users(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result []Finding
+		errs   []error
+	)
+
+	for _, team := range teams {
+		// A team deleted since it was listed has nothing to check
+		t, err := c.storage.GetTeam(ctx, team)
+		if errors.Is(err, storage.ErrTeamNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("team %s: failed to get team: %w", team, err))
+			continue
+		}
+
+		teamFindings, err := c.check(ctx, team, t.Schedules, users)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("team %s: %w", team, err))
+			continue
+		}
+		result = append(result, teamFindings...)
+	}
+
+	counts := make(map[string]int, len(Reasons))
+	for _, finding := range result {
+		counts[finding.Reason]++
+	}
+	for _, reason := range Reasons {
+		findings.WithLabelValues(reason).Set(float64(counts[reason]))
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// Loop checks every team every interval until ctx is done, keeping the
+// findings metric current.
+func (c *Checker) Loop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := c.All(ctx)
+			if err != nil {
+				c.logger.Error("integrity check failed", zap.Error(err))
+			}
+			if len(result) > 0 {
+				c.logger.Warn("schedules reference members who cannot be paged", zap.Int("findings", len(result)))
+			}
+		}
+	}
+}
+
+// users returns the provisioned users by name.
+func (c *Checker) users(ctx context.Context) (map[string]storage.User, error) {
+	list, err := c.storage.FindUsers(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	users := make(map[string]storage.User, len(list))
+	for _, user := range list {
+		users[user.UserName] = user
+	}
+
+	return users, nil
+}
+
+// check reports the members of the schedules of a team. Deactivated members
+// are reported for that alone, the other reasons may add up. Ended schedules
+// page nobody and are left out.
+func (c *Checker) check(ctx context.Context, team string, schedules []storage.Schedule, users map[string]storage.User) ([]Finding, error) {
+	roster, err := c.storage.ListTeamMembers(ctx, team)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list team members: %w", err)
+	}
+
+	// Observers are part of the team but are never put on call
+	onRoster := make(map[string]bool, len(roster))
+	for _, member := range roster {
+		if member.Role == storage.MemberRoleMember {
+			onRoster[member.Name] = true
+		}
+	}
+
+	now := c.now()
+	reminded := c.reminded(team)
+
+	var result []Finding
+	for _, sched := range schedules {
+		if !sched.ValidUntil.IsZero() && !sched.ValidUntil.After(now) {
+			continue
+		}
+
+		var seen []string
+		for _, member := range sched.Members {
+			if slices.Contains(seen, member) {
+				continue
+			}
+			seen = append(seen, member)
+
+			report := func(reason string) {
+				result = append(result, Finding{
+					Team:       team,
+					ScheduleID: sched.ID,
+					Schedule:   sched.Name,
+					Member:     member,
+					Reason:     reason,
+				})
+			}
+
+			user, provisioned := users[member]
+			if provisioned && !user.Active {
+				report(ReasonDeactivated)
+				continue
+			}
+
+			// Schedules of the team members follow the roster already
+			if !sched.TeamMembers && !onRoster[member] {
+				report(ReasonNotInTeam)
+			}
+
+			if reminded && (!provisioned || len(user.Emails) == 0) {
+				report(ReasonNoContact)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// reminded reports whether the members of the team are reminded of their
+// shifts.
+func (c *Checker) reminded(team string) bool {
+	if !c.notifying {
+		return false
+	}
+
+	lead, ok := c.reminders.Teams[team]
+	if !ok {
+		lead = c.reminders.LeadTime
+	}
+
+	return lead > 0
+}
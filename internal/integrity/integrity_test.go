@@ -0,0 +1,135 @@
+package integrity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage/storagetest"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type discardNotifier struct{}
+
+func (discardNotifier) Name() string { return "discard" }
+
+func (discardNotifier) Notify(context.Context, notify.Event) error { return nil }
+
+var now = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+// seed creates users and schedules of payments and billing with every kind
+// of finding.
+func seed(t *testing.T, s storage.Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, user := range []storage.User{
+		{UserName: "Alice", Emails: []string{"alice@example.com"}, Active: true},
+		{UserName: "Bob", Emails: []string{"bob@example.com"}, Active: false},
+		{UserName: "Carol", Active: true},
+	} {
+		_, _, err := s.AddUser(ctx, user)
+		require.NoError(t, err)
+	}
+
+	days := storagetest.Schedule(t, "Days", []string{"Alice", "Bob", "Carol", "Dave", "Erin", "Alice"}, "9:00AM", "5:00PM", time.Monday)
+	ended := storagetest.Schedule(t, "Ended", []string{"Dave"}, "5:00PM", "11:00PM", time.Monday)
+	ended.ValidUntil = now.Add(-time.Hour)
+	_, err := s.AddSchedule(ctx, "payments", days)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "payments", ended)
+	require.NoError(t, err)
+
+	nights := storagetest.Schedule(t, "Nights", []string{"Dave", "Bob"}, "5:00PM", "11:00PM", time.Monday)
+	_, err = s.AddSchedule(ctx, "billing", nights)
+	require.NoError(t, err)
+
+	// Schedules keep the members who left the team, and observers are never
+	// put on call, the roster may have changed since
+	removed, err := s.RemoveTeamMember(ctx, "payments", "Erin")
+	require.NoError(t, err)
+	require.True(t, removed)
+	_, _, err = s.SetTeamMember(ctx, "payments", storage.TeamMember{Name: "Dave", Role: storage.MemberRoleObserver})
+	require.NoError(t, err)
+}
+
+// newChecker returns a checker reminding the members of payments only.
+func newChecker(t *testing.T) (*Checker, storage.Storage) {
+	t.Helper()
+
+	cfg := &config.Config{Notify: config.NotifyConfig{
+		Interval:  time.Minute,
+		Reminders: config.RemindersConfig{Teams: map[string]time.Duration{"payments": 30 * time.Minute}},
+	}}
+
+	s := storage.NewMemoryStorage()
+	seed(t, s)
+
+	c := New(s, notify.NewDispatcher([]notify.Notifier{discardNotifier{}}, cfg, zap.NewNop()), cfg, zap.NewNop())
+	c.now = func() time.Time { return now }
+
+	return c, s
+}
+
+// reasons returns the members of the findings of a schedule by reason.
+func reasons(findings []Finding, schedule string) map[string][]string {
+	result := make(map[string][]string)
+	for _, finding := range findings {
+		if finding.Schedule == schedule {
+			result[finding.Reason] = append(result[finding.Reason], finding.Member)
+		}
+	}
+
+	return result
+}
+
+func TestChecker_Team(t *testing.T) {
+	c, _ := newChecker(t)
+
+	findings, err := c.Team(context.Background(), "payments")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		ReasonDeactivated: {"Bob"},
+		ReasonNotInTeam:   {"Dave", "Erin"},
+		ReasonNoContact:   {"Carol", "Dave", "Erin"},
+	}, reasons(findings, "Days"))
+
+	for _, finding := range findings {
+		assert.Equal(t, "payments", finding.Team)
+		assert.NotEmpty(t, finding.ScheduleID)
+	}
+
+	_, err = c.Team(context.Background(), "unknown")
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+}
+
+func TestChecker_All(t *testing.T) {
+	c, _ := newChecker(t)
+
+	result, err := c.All(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, result, 7)
+
+	// Billing members are not reminded, so they need no contact
+	assert.Equal(t, map[string][]string{ReasonDeactivated: {"Bob"}}, reasons(result, "Nights"))
+
+	assert.InDelta(t, 2, testutil.ToFloat64(findings.WithLabelValues(ReasonDeactivated)), 0)
+	assert.InDelta(t, 2, testutil.ToFloat64(findings.WithLabelValues(ReasonNotInTeam)), 0)
+	assert.InDelta(t, 3, testutil.ToFloat64(findings.WithLabelValues(ReasonNoContact)), 0)
+}
+
+func TestChecker_WithoutNotifications(t *testing.T) {
+	c, _ := newChecker(t)
+	c.notifying = false
+
+	findings, err := c.Team(context.Background(), "payments")
+	require.NoError(t, err)
+	assert.Empty(t, reasons(findings, "Days")[ReasonNoContact])
+}
@@ -0,0 +1,143 @@
+package janitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// Actor is the audit log actor of the changes made by the janitor.
+const Actor = "system/janitor"
+
+// LockKey is the advisory lock key that elects the instance running the janitor.
+const LockKey int64 = 0x6f6e63616c6c
+
+// Cleanup categories, used as the category label of the removed metric.
+const (
+	CategorySchedules = "schedules"
+	CategoryPauses    = "pauses"
+	CategoryHistory   = "history"
+)
+
+var removedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "oncall_janitor_removed_total",
+	Help: "Number of expired or stale records removed by the janitor, by category.",
+}, []string{"category"})
+
+// Elector elects the single instance that runs the janitor when several
+// instances share a database. TryLock must not wait for the lock, ok is false
+// when another instance holds it.
+type Elector interface {
+	TryLock(ctx context.Context, key int64) (release func(), ok bool, err error)
+}
+
+// Result holds the number of records removed by a janitor run, by category.
+type Result struct {
+	Schedules int
+	Pauses    int
+	History   int
+}
+
+// Janitor periodically removes expired schedules, expired pauses, and audit
+// history beyond retention. Every removal goes through the storage layer so
+// it is recorded in the audit log.
+type Janitor struct {
+	storage   storage.Storage
+	elector   Elector
+	logger    *zap.Logger
+	grace     time.Duration
+	retention time.Duration
+	now       func() time.Time
+}
+
+// New creates a janitor. A nil elector means the instance runs alone and
+// always does the cleanup.
+func New(s storage.Storage, elector Elector, cfg *config.Config, logger *zap.Logger) *Janitor {
+	return &Janitor{
+		storage:   s,
+		elector:   elector,
+		logger:    logger.Named("janitor"),
+		grace:     cfg.Janitor.ScheduleGrace,
+		retention: cfg.Janitor.HistoryRetention,
+		now:       time.Now,
+	}
+}
+
+// Run does a single cleanup. It returns a zero result without touching the
+// storage when another instance holds the janitor lock. The categories are
+// independent, a failing one does not stop the others.
+func (j *Janitor) Run(ctx context.Context) (Result, error) {
+	if j.elector != nil {
+		release, ok, err := j.elector.TryLock(ctx, LockKey)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to elect janitor: %w", err)
+		}
+		if !ok {
+			j.logger.Debug("another instance is running the janitor")
+			return Result{}, nil
+		}
+		defer release()
+	}
+
+	ctx = storage.WithActor(ctx, Actor)
+	now := j.now()
+
+	var (
+		result Result
+		errs   []error
+		err    error
+	)
+
+	result.Schedules, err = j.storage.DeleteExpiredSchedules(ctx, now.Add(-j.grace))
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to delete expired schedules: %w", err))
+	}
+
+	result.Pauses, err = j.storage.PurgeExpiredPauses(ctx, now)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to purge expired pauses: %w", err))
+	}
+
+	if j.retention > 0 {
+		result.History, err = j.storage.TrimAuditLog(ctx, now.Add(-j.retention))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to trim audit log: %w", err))
+		}
+	}
+
+	removedTotal.WithLabelValues(CategorySchedules).Add(float64(result.Schedules))
+	removedTotal.WithLabelValues(CategoryPauses).Add(float64(result.Pauses))
+	removedTotal.WithLabelValues(CategoryHistory).Add(float64(result.History))
+
+	j.logger.Info("janitor run finished",
+		zap.Int(CategorySchedules, result.Schedules),
+		zap.Int(CategoryPauses, result.Pauses),
+		zap.Int(CategoryHistory, result.History),
+	)
+
+	return result, errors.Join(errs...)
+}
+
+// Loop runs the janitor every interval until ctx is done.
+func (j *Janitor) Loop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := j.Run(ctx); err != nil {
+				j.logger.Error("janitor run failed", zap.Error(err))
+			}
+		}
+	}
+}
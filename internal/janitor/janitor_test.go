@@ -0,0 +1,187 @@
+package janitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// fakeElector grants the lock only while held is false.
+type fakeElector struct {
+	held     bool
+	released int
+}
+
+func (e *fakeElector) TryLock(context.Context, int64) (func(), bool, error) {
+	if e.held {
+		return nil, false, nil
+	}
+	return func() { e.released++ }, true, nil
+}
+
+func newTestJanitor(t *testing.T, elector Elector) (*Janitor, *storage.MemoryStorage, *fakeClock) {
+	t.Helper()
+
+	cfg := &config.Config{Janitor: config.JanitorConfig{
+		ScheduleGrace:    time.Hour,
+		HistoryRetention: 30 * 24 * time.Hour,
+	}}
+
+	s := storage.NewMemoryStorage()
+	clock := &fakeClock{now: time.Now()}
+
+	j := New(s, elector, cfg, zap.NewNop())
+	j.now = clock.Now
+
+	return j, s, clock
+}
+
+func schedule(t *testing.T, name string, validUntil time.Time) storage.Schedule {
+	t.Helper()
+
+	start, err := time.Parse(time.Kitchen, "9:00AM")
+	require.NoError(t, err)
+	end, err := time.Parse(time.Kitchen, "5:00PM")
+	require.NoError(t, err)
+
+	return storage.Schedule{
+		Name:       name,
+		Members:    []string{"Alice"},
+		Days:       []time.Weekday{time.Monday},
+		Start:      start,
+		End:        end,
+		ValidUntil: validUntil,
+	}
+}
+
+func TestJanitor_DeletesExpiredSchedulesAfterGrace(t *testing.T) {
+	j, s, clock := newTestJanitor(t, nil)
+	ctx := context.Background()
+
+	_, err := s.AddSchedule(ctx, "backend-team", schedule(t, "Temporary", clock.Now().Add(24*time.Hour)))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", schedule(t, "Permanent", time.Time{}))
+	require.NoError(t, err)
+
+	result, err := j.Run(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.Schedules)
+
+	// Ended, but still within the grace period
+	clock.Advance(24*time.Hour + 30*time.Minute)
+	result, err = j.Run(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.Schedules)
+
+	clock.Advance(time.Hour)
+	result, err = j.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Schedules)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 1)
+	assert.Equal(t, "Permanent", team.Schedules[0].Name)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, Actor, entries[0].Actor)
+	assert.Equal(t, storage.AuditDeleteSchedule, entries[0].Action)
+	assert.Equal(t, "Temporary", entries[0].Detail)
+}
+
+func TestJanitor_PurgesExpiredPauses(t *testing.T) {
+	j, s, clock := newTestJanitor(t, nil)
+	ctx := context.Background()
+
+	for _, team := range []string{"backend-team", "frontend-team"} {
+		_, err := s.AddSchedule(ctx, team, schedule(t, "Weekday", time.Time{}))
+		require.NoError(t, err)
+	}
+
+	pause := storage.Pause{Reason: "offsite", Since: clock.Now(), Until: clock.Now().Add(2 * time.Hour)}
+	_, err := s.PauseTeam(ctx, "backend-team", pause)
+	require.NoError(t, err)
+	_, err = s.PauseTeam(ctx, "frontend-team", storage.Pause{Reason: "freeze", Since: clock.Now()})
+	require.NoError(t, err)
+
+	clock.Advance(2 * time.Hour)
+	result, err := j.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Pauses)
+
+	_, paused, err := s.GetPause(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.False(t, paused)
+
+	// A pause without an end is never purged
+	_, paused, err = s.GetPause(ctx, "frontend-team")
+	require.NoError(t, err)
+	assert.True(t, paused)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, Actor, entries[1].Actor)
+	assert.Equal(t, storage.AuditUnpauseTeam, entries[1].Action)
+}
+
+func TestJanitor_TrimsHistoryBeyondRetention(t *testing.T) {
+	j, s, clock := newTestJanitor(t, nil)
+	ctx := context.Background()
+
+	_, err := s.AddSchedule(ctx, "backend-team", schedule(t, "Weekday", time.Time{}))
+	require.NoError(t, err)
+	_, err = s.PauseTeam(ctx, "backend-team", storage.Pause{Since: clock.Now()})
+	require.NoError(t, err)
+
+	clock.Advance(29 * 24 * time.Hour)
+	result, err := j.Run(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.History)
+
+	clock.Advance(2 * 24 * time.Hour)
+	result, err = j.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.History)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestJanitor_SkipsWithoutLeadership(t *testing.T) {
+	elector := &fakeElector{held: true}
+	j, s, clock := newTestJanitor(t, elector)
+	ctx := context.Background()
+
+	_, err := s.AddSchedule(ctx, "backend-team", schedule(t, "Temporary", clock.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	clock.Advance(3 * time.Hour)
+
+	result, err := j.Run(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, result.Schedules)
+
+	elector.held = false
+	result, err = j.Run(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Schedules)
+	assert.Equal(t, 1, elector.released)
+}
@@ -0,0 +1,68 @@
+// Package logging builds the logger of the application from its
+// configuration.
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Encodings are the encodings of log entries.
+var Encodings = []string{"json", "console"}
+
+// New validates the configuration and builds the logger it describes, which
+// is the production logger of zap with the defaults of config.Load.
+func New(cfg config.LoggingConfig, opts ...zap.Option) (*zap.Logger, error) {
+	level, err := zap.ParseAtomicLevel(cfg.Level)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logging level %q: %w", cfg.Level, err)
+	}
+
+	stacktrace, err := zapcore.ParseLevel(cfg.StacktraceLevel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logging stacktrace_level %q: %w", cfg.StacktraceLevel, err)
+	}
+
+	if !slices.Contains(Encodings, cfg.Encoding) {
+		return nil, fmt.Errorf("invalid logging encoding %q, use one of %v", cfg.Encoding, Encodings)
+	}
+
+	if len(cfg.OutputPaths) == 0 {
+		return nil, errors.New("logging output_paths must not be empty")
+	}
+	if len(cfg.ErrorOutputPaths) == 0 {
+		return nil, errors.New("logging error_output_paths must not be empty")
+	}
+
+	var sampling *zap.SamplingConfig
+	switch {
+	case cfg.Sampling.Initial < 0 || cfg.Sampling.Thereafter < 0:
+		return nil, fmt.Errorf("logging sampling must not be negative, got initial %d and thereafter %d",
+			cfg.Sampling.Initial, cfg.Sampling.Thereafter)
+	case cfg.Sampling.Initial > 0:
+		// Thereafter zero drops every entry past the initial ones
+		sampling = &zap.SamplingConfig{Initial: cfg.Sampling.Initial, Thereafter: cfg.Sampling.Thereafter}
+	}
+
+	zcfg := zap.NewProductionConfig()
+	zcfg.Level = level
+	zcfg.Encoding = cfg.Encoding
+	zcfg.OutputPaths = cfg.OutputPaths
+	zcfg.ErrorOutputPaths = cfg.ErrorOutputPaths
+	zcfg.Sampling = sampling
+	// zap.Config only knows the stacktrace levels of its presets, so the
+	// configured one is added as an option instead
+	zcfg.DisableStacktrace = true
+
+	logger, err := zcfg.Build(append([]zap.Option{zap.AddStacktrace(stacktrace)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build logger: %w", err)
+	}
+
+	return logger, nil
+}
@@ -0,0 +1,155 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaults is the logging configuration config.Load defaults to.
+func defaults(t *testing.T) config.LoggingConfig {
+	t.Helper()
+
+	return config.LoggingConfig{
+		Level:            "info",
+		Encoding:         "json",
+		OutputPaths:      []string{filepath.Join(t.TempDir(), "oncall.log")},
+		ErrorOutputPaths: []string{"stderr"},
+		Sampling:         config.SamplingConfig{Initial: 100, Thereafter: 100},
+		StacktraceLevel:  "error",
+		AccessLog:        true,
+	}
+}
+
+// observe builds the logger of the configuration along with the entries it
+// writes, observed after the level and the sampling are applied.
+func observe(t *testing.T, cfg config.LoggingConfig) (*zap.Logger, *[]zapcore.Entry) {
+	t.Helper()
+
+	entries := &[]zapcore.Entry{}
+	logger, err := New(cfg, zap.Hooks(func(entry zapcore.Entry) error {
+		*entries = append(*entries, entry)
+		return nil
+	}))
+	require.NoError(t, err)
+
+	return logger, entries
+}
+
+func TestNew_Level(t *testing.T) {
+	cfg := defaults(t)
+	cfg.Level = "warn"
+
+	logger, entries := observe(t, cfg)
+	logger.Debug("debug")
+	logger.Info("info")
+	logger.Warn("warn")
+	logger.Error("error")
+
+	require.Len(t, *entries, 2)
+	assert.Equal(t, zapcore.WarnLevel, (*entries)[0].Level)
+	assert.Equal(t, zapcore.ErrorLevel, (*entries)[1].Level)
+}
+
+func TestNew_Sampling(t *testing.T) {
+	cfg := defaults(t)
+	cfg.Sampling = config.SamplingConfig{Initial: 2, Thereafter: 5}
+
+	logger, entries := observe(t, cfg)
+	for range 12 {
+		logger.Info("request")
+	}
+	logger.Info("other")
+
+	// The first two, then the 5th and 10th of the rest in the same second
+	assert.Len(t, *entries, 5)
+
+	cfg.Sampling = config.SamplingConfig{}
+
+	logger, entries = observe(t, cfg)
+	for range 12 {
+		logger.Info("request")
+	}
+
+	assert.Len(t, *entries, 12)
+}
+
+func TestNew_StacktraceLevel(t *testing.T) {
+	cfg := defaults(t)
+	cfg.StacktraceLevel = "warn"
+
+	logger, entries := observe(t, cfg)
+	logger.Info("info")
+	logger.Warn("warn")
+
+	require.Len(t, *entries, 2)
+	assert.Empty(t, (*entries)[0].Stack)
+	assert.NotEmpty(t, (*entries)[1].Stack)
+
+	cfg.StacktraceLevel = "fatal"
+
+	logger, entries = observe(t, cfg)
+	logger.Error("error")
+
+	require.Len(t, *entries, 1)
+	assert.Empty(t, (*entries)[0].Stack)
+}
+
+func TestNew_OutputPaths(t *testing.T) {
+	cfg := defaults(t)
+	cfg.OutputPaths = append(cfg.OutputPaths, filepath.Join(t.TempDir(), "copy.log"))
+
+	logger, err := New(cfg)
+	require.NoError(t, err)
+	logger.Info("written", zap.String("team", "backend-team"))
+	require.NoError(t, logger.Sync())
+
+	for _, path := range cfg.OutputPaths {
+		file, err := os.Open(path)
+		require.NoError(t, err)
+
+		scanner := bufio.NewScanner(file)
+		require.True(t, scanner.Scan(), path)
+
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		assert.Equal(t, "written", line["msg"])
+		assert.Equal(t, "backend-team", line["team"])
+		require.NoError(t, file.Close())
+	}
+}
+
+func TestNew_Validation(t *testing.T) {
+	tests := map[string]func(*config.LoggingConfig){
+		"level":              func(cfg *config.LoggingConfig) { cfg.Level = "loud" },
+		"stacktrace level":   func(cfg *config.LoggingConfig) { cfg.StacktraceLevel = "always" },
+		"encoding":           func(cfg *config.LoggingConfig) { cfg.Encoding = "xml" },
+		"no output paths":    func(cfg *config.LoggingConfig) { cfg.OutputPaths = nil },
+		"no error paths":     func(cfg *config.LoggingConfig) { cfg.ErrorOutputPaths = nil },
+		"negative sampling":  func(cfg *config.LoggingConfig) { cfg.Sampling.Thereafter = -1 },
+		"missing output dir": func(cfg *config.LoggingConfig) { cfg.OutputPaths = []string{"/nonexistent/oncall.log"} },
+	}
+
+	for name, mutate := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg := defaults(t)
+			mutate(&cfg)
+
+			_, err := New(cfg)
+			assert.Error(t, err)
+		})
+	}
+
+	cfg := defaults(t)
+	cfg.Encoding = "console"
+	_, err := New(cfg)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,316 @@
+package notify
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/week"
+	"go.uber.org/zap"
+)
+
+// DigestInterval is how often the digest checks whether a team is due.
+const DigestInterval = time.Minute
+
+// digestGrace is how late a digest is still sent, e.g. after a restart, so
+// a deploy in the afternoon does not send the morning digest.
+const digestGrace = time.Hour
+
+// DefaultDigestTemplate is the default digest. It is executed with DigestData.
+const DefaultDigestTemplate = `{{.Team}} on call from {{.From.Format "Mon Jan 2 15:04"}} to {{.To.Format "Mon Jan 2 15:04 MST"}}:
+{{range .Shifts}}- {{.Start.Format "Mon 15:04"}} to {{.End.Format "Mon 15:04"}}: {{.Member}} ({{.Schedule}}{{if .Weekend}}, weekend{{end}})
+{{else}}Nobody is on call.
+{{end}}`
+
+// DigestData is what the digest template is executed with. All the times are
+// in the timezone of the team.
+type DigestData struct {
+	Team   string
+	From   time.Time
+	To     time.Time
+	Shifts []DigestShift
+}
+
+// DigestShift is a single shift of the digest. Weekend is set for shifts
+// that fall on the weekend of the team's week, in whole or in part.
+type DigestShift struct {
+	Schedule string
+	Member   string
+	Start    time.Time
+	End      time.Time
+	Weekend  bool
+}
+
+// digestSettings are the resolved digest settings of a team.
+type digestSettings struct {
+	hour     int
+	minute   int
+	horizon  time.Duration
+	location *time.Location
+}
+
+// Digest sends every team a daily summary of its upcoming shifts at its
+// configured time. Sent digests are tracked in storage, so each is sent once
+// even across restarts and instances. Teams without schedules and paused
+// teams are skipped.
+type Digest struct {
+	storage    storage.Storage
+	dispatcher *Dispatcher
+	template   *template.Template
+	defaults   *digestSettings
+	teams      map[string]*digestSettings
+	weeks      *week.Conventions
+	logger     *zap.Logger
+	now        func() time.Time
+}
+
+// NewDigest creates a digest from the configuration, marking weekend shifts
+// by the week conventions of the teams. It fails on an invalid time, timezone
+// or template.
+func NewDigest(s storage.Storage, dispatcher *Dispatcher, weeks *week.Conventions, cfg *config.Config, logger *zap.Logger) (*Digest, error) {
+	dc := cfg.Notify.Digest
+
+	text := dc.Template
+	if text == "" {
+		text = DefaultDigestTemplate
+	}
+
+	tmpl, err := template.New("digest").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest template: %w", err)
+	}
+
+	defaults, err := parseDigestSettings(dc.Time, dc.Horizon, dc.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("digest: %w", err)
+	}
+
+	teams := make(map[string]*digestSettings, len(dc.Teams))
+	for team, tc := range dc.Teams {
+		settings, err := parseDigestSettings(
+			cmp.Or(tc.Time, dc.Time),
+			cmp.Or(tc.Horizon, dc.Horizon),
+			cmp.Or(tc.Timezone, dc.Timezone),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("digest of %s: %w", team, err)
+		}
+		teams[team] = settings
+	}
+
+	return &Digest{
+		storage:    s,
+		dispatcher: dispatcher,
+		template:   tmpl,
+		defaults:   defaults,
+		teams:      teams,
+		weeks:      weeks,
+		logger:     logger.Named("notify"),
+		now:        time.Now,
+	}, nil
+}
+
+// parseDigestSettings parses the settings of a digest, which are nil when it has no send time.
+func parseDigestSettings(at string, horizon time.Duration, tz string) (*digestSettings, error) {
+	if at == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time %q, expected HH:MM", at)
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", tz, err)
+	}
+
+	return &digestSettings{hour: t.Hour(), minute: t.Minute(), horizon: horizon, location: loc}, nil
+}
+
+// Enabled reports whether any team gets a digest.
+func (d *Digest) Enabled() bool {
+	return d.defaults != nil || len(d.teams) > 0
+}
+
+// settings returns the digest settings of a team, nil when it gets no digest.
+func (d *Digest) settings(team string) *digestSettings {
+	if settings, ok := d.teams[team]; ok {
+		return settings
+	}
+
+	return d.defaults
+}
+
+// Check sends the digest of every team that is due. A failing team does not
+// stop the others.
+func (d *Digest) Check(ctx context.Context) error {
+	teams, err := d.storage.ListTeams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	now := d.now()
+
+	var errs []error
+	for _, team := range teams {
+		if err := d.check(ctx, team, now); err != nil {
+			errs = append(errs, fmt.Errorf("team %s: %w", team, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// check sends the digest of a single team when it is due.
+func (d *Digest) check(ctx context.Context, team string, now time.Time) error {
+	settings := d.settings(team)
+	if settings == nil {
+		return nil
+	}
+
+	local := now.In(settings.location)
+	due := time.Date(local.Year(), local.Month(), local.Day(), settings.hour, settings.minute, 0, 0, settings.location)
+	if now.Before(due) || !now.Before(due.Add(digestGrace)) {
+		return nil
+	}
+
+	t, ok, err := d.team(ctx, team, now)
+	if err != nil || !ok {
+		return err
+	}
+
+	key := fmt.Sprintf("digest/%s/%s", team, due.Format(time.DateOnly))
+
+	first, err := d.storage.MarkReminderSent(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to mark digest %s: %w", key, err)
+	}
+	if !first {
+		return nil
+	}
+
+	event, err := d.digest(ctx, team, t.Schedules, settings, due, now)
+	if err != nil {
+		return err
+	}
+
+	return d.dispatcher.Dispatch(ctx, event)
+}
+
+// team returns the team when it gets a digest at the given instant. Teams
+// without schedules and paused teams get none.
+func (d *Digest) team(ctx context.Context, team string, now time.Time) (storage.Team, bool, error) {
+	t, err := d.storage.GetTeam(ctx, team)
+	if err != nil && !errors.Is(err, storage.ErrTeamNotFound) {
+		return storage.Team{}, false, fmt.Errorf("failed to get team: %w", err)
+	}
+	if len(t.Schedules) == 0 {
+		d.logger.Info("skipping digest of team without schedules", zap.String("team", team))
+		return storage.Team{}, false, nil
+	}
+
+	pause, paused, err := d.storage.GetPause(ctx, team)
+	if err != nil {
+		return storage.Team{}, false, fmt.Errorf("failed to get pause: %w", err)
+	}
+	if paused && pause.Active(now) {
+		d.logger.Info("skipping digest of paused team", zap.String("team", team))
+		return storage.Team{}, false, nil
+	}
+
+	return t, true, nil
+}
+
+// digest returns the digest of the team due at the given instant.
+func (d *Digest) digest(ctx context.Context, team string, schedules []storage.Schedule, settings *digestSettings, due, now time.Time) (Event, error) {
+	summary, err := d.Render(ctx, team, schedules, due, due.Add(settings.horizon))
+	if err != nil {
+		return Event{}, err
+	}
+
+	event := NewEvent(KindDigest, team, now)
+	event.Summary = summary
+
+	return event, nil
+}
+
+// Render renders the digest of the team's shifts overlapping [from, to), in
+// the timezone of from.
+func (d *Digest) Render(ctx context.Context, team string, schedules []storage.Schedule, from, to time.Time) (string, error) {
+	loc := from.Location()
+	data := DigestData{Team: team, From: from, To: to.In(loc)}
+	wk := d.weeks.Of(team)
+
+	// Schedules are expanded in UTC like the on-call lookup does
+	from, to = from.UTC(), to.UTC()
+
+	shifts := storage.UpcomingShifts(schedules, from, to)
+	if shift, ok := storage.CurrentShift(schedules, from); ok {
+		shifts = append([]storage.Shift{shift}, shifts...)
+	}
+
+	for _, shift := range shifts {
+		// Shifts ending exactly at to are in the window, ones starting at it are not
+		if !shift.Start.Before(to) {
+			continue
+		}
+
+		// The member of a shift that is already running is the one on call at from
+		member, err := d.storage.GetCurrentOncall(ctx, team, later(shift.Start, from))
+		if storage.NobodyOnCall(err) {
+			continue
+		}
+		if err != nil && !errors.Is(err, storage.ErrStale) {
+			return "", fmt.Errorf("failed to get oncall at %s: %w", shift.Start, err)
+		}
+
+		data.Shifts = append(data.Shifts, DigestShift{
+			Schedule: shift.Schedule,
+			Member:   member,
+			Start:    shift.Start.In(loc),
+			End:      shift.End.In(loc),
+			Weekend:  wk.WeekendTime(shift.Start.In(loc), shift.End.In(loc)) > 0,
+		})
+	}
+
+	var b strings.Builder
+	if err := d.template.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	return b.String(), nil
+}
+
+// later returns the later of the two instants.
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
+
+// Loop sends the due digests every interval until ctx is done.
+func (d *Digest) Loop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.Check(ctx); err != nil {
+				d.logger.Error("digest check failed", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/week"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestDigest(t *testing.T, s storage.Storage, n Notifier, clock *fakeClock) *Digest {
+	t.Helper()
+
+	d, _ := newTestDispatcher(n)
+	cfg := &config.Config{Notify: config.NotifyConfig{Digest: config.DigestConfig{
+		Time:     "08:00",
+		Horizon:  24 * time.Hour,
+		Timezone: "Europe/Berlin",
+	}}}
+
+	digest, err := NewDigest(s, d, nil, cfg, zap.NewNop())
+	require.NoError(t, err)
+	digest.now = clock.Now
+
+	return digest
+}
+
+func TestDigest_SendsOncePerDay(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	n := &recordingNotifier{}
+	// 08:00 in Berlin is 06:00 UTC in summer
+	clock := &fakeClock{now: time.Date(2025, 4, 28, 5, 59, 0, 0, time.UTC)}
+	digest := newTestDigest(t, s, n, clock)
+	ctx := context.Background()
+
+	addShift(t, s, "Weekday Coverage", "Alice", "9:00AM", "5:00PM")
+	addShift(t, s, "Evening Coverage", "Bob", "5:00PM", "11:00PM")
+
+	require.NoError(t, digest.Check(ctx))
+	assert.Empty(t, n.events)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, digest.Check(ctx))
+	require.Len(t, n.events, 1)
+	assert.Equal(t, KindDigest, n.events[0].Kind)
+	assert.Equal(t, "backend-team", n.events[0].Team)
+	assertGolden(t, "digest.txt", []byte(n.events[0].Summary))
+
+	clock.Advance(time.Minute)
+	require.NoError(t, digest.Check(ctx))
+	assert.Len(t, n.events, 1)
+
+	// A restarted digest finds the sent one in storage
+	restarted := newTestDigest(t, s, n, clock)
+	require.NoError(t, restarted.Check(ctx))
+	assert.Len(t, n.events, 1)
+}
+
+func TestDigest_SkipsPausedTeams(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	n := &recordingNotifier{}
+	clock := &fakeClock{now: time.Date(2025, 4, 28, 6, 0, 0, 0, time.UTC)}
+	digest := newTestDigest(t, s, n, clock)
+	ctx := context.Background()
+
+	addShift(t, s, "Weekday Coverage", "Alice", "9:00AM", "5:00PM")
+	_, err := s.PauseTeam(ctx, "backend-team", storage.Pause{Since: clock.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+
+	require.NoError(t, digest.Check(ctx))
+	assert.Empty(t, n.events)
+}
+
+func TestDigest_SkipsLateDigests(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	n := &recordingNotifier{}
+	clock := &fakeClock{now: time.Date(2025, 4, 28, 7, 0, 0, 0, time.UTC)}
+	digest := newTestDigest(t, s, n, clock)
+
+	addShift(t, s, "Weekday Coverage", "Alice", "9:00AM", "5:00PM")
+
+	require.NoError(t, digest.Check(context.Background()))
+	assert.Empty(t, n.events)
+}
+
+func TestNewDigest_InvalidConfig(t *testing.T) {
+	d, _ := newTestDispatcher()
+
+	tests := []struct {
+		name   string
+		digest config.DigestConfig
+	}{
+		{"time", config.DigestConfig{Time: "8am", Timezone: "UTC"}},
+		{"timezone", config.DigestConfig{Time: "08:00", Timezone: "Mars/Olympus"}},
+		{"template", config.DigestConfig{Template: "{{.Team"}},
+		{"team", config.DigestConfig{Timezone: "UTC", Teams: map[string]config.DigestTeamConfig{"backend-team": {Time: "25:00"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDigest(storage.NewMemoryStorage(), d, nil, &config.Config{Notify: config.NotifyConfig{Digest: tt.digest}}, zap.NewNop())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDigest_MarksWeekendShifts(t *testing.T) {
+	s := storage.NewMemoryStorage()
+	ctx := context.Background()
+	d, _ := newTestDispatcher(&recordingNotifier{})
+
+	weeks, err := week.New(&config.Config{Week: config.WeekConfig{
+		Start:       "monday",
+		WeekendDays: []string{"saturday", "sunday"},
+		Teams: map[string]config.WeekTeamConfig{
+			"gulf-team": {Start: "saturday", WeekendDays: []string{"thursday", "friday"}},
+		},
+	}})
+	require.NoError(t, err)
+
+	digest, err := NewDigest(s, d, weeks, &config.Config{}, zap.NewNop())
+	require.NoError(t, err)
+
+	schedule := storage.Schedule{
+		Name:    "Coverage",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Wednesday, time.Thursday},
+		Start:   time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+		End:     time.Date(0, 1, 1, 17, 0, 0, 0, time.UTC),
+	}
+	for _, team := range []string{"gulf-team", "backend-team"} {
+		_, err := s.AddSchedule(ctx, team, schedule)
+		require.NoError(t, err)
+	}
+
+	// Wednesday March 4 and Thursday March 5, 2026
+	from := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	for team, want := range map[string]string{
+		"gulf-team": "- Wed 09:00 to Wed 17:00: Alice (Coverage)\n" +
+			"- Thu 09:00 to Thu 17:00: Alice (Coverage, weekend)\n",
+		"backend-team": "- Wed 09:00 to Wed 17:00: Alice (Coverage)\n" +
+			"- Thu 09:00 to Thu 17:00: Alice (Coverage)\n",
+	} {
+		t.Run(team, func(t *testing.T) {
+			got, err := s.GetTeam(ctx, team)
+			require.NoError(t, err)
+
+			summary, err := digest.Render(ctx, team, got.Schedules, from, from.Add(48*time.Hour))
+			require.NoError(t, err)
+			assert.Contains(t, summary, want)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var deliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "oncall_notifications_total",
+	Help: "Number of notification deliveries by notifier and result.",
+}, []string{"notifier", "result"})
+
+// publishTimeout bounds the delivery of a published event, retries included.
+const publishTimeout = time.Minute
+
+// Dispatcher delivers events to every notifier, retrying failed deliveries
+// with exponential backoff. It is the single place retries are implemented,
+// notifiers only make one attempt per call.
+type Dispatcher struct {
+	notifiers []Notifier
+	attempts  int
+	backoff   time.Duration
+	logger    *zap.Logger
+	sleep     func(ctx context.Context, d time.Duration) error
+}
+
+// NewDispatcher creates a dispatcher for the given notifiers.
+func NewDispatcher(notifiers []Notifier, cfg *config.Config, logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		notifiers: notifiers,
+		attempts:  max(cfg.Notify.Attempts, 1),
+		backoff:   cfg.Notify.Backoff,
+		logger:    logger.Named("notify"),
+		sleep:     sleep,
+	}
+}
+
+// Enabled reports whether any notifier is configured.
+func (d *Dispatcher) Enabled() bool {
+	return len(d.notifiers) > 0
+}
+
+// Dispatch delivers the event to every notifier. A failing notifier does not
+// keep the event from the others, their errors are joined.
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event) error {
+	var errs []error
+
+	for _, n := range d.notifiers {
+		if err := d.deliver(ctx, n, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Preview renders the deliveries of the event by every notifier without
+// sending any of them. Notifiers that do not render their deliveries apart
+// from sending them are left out. A failing notifier does not keep the
+// deliveries of the others, their errors are joined.
+func (d *Dispatcher) Preview(ctx context.Context, event Event) ([]Delivery, error) {
+	var (
+		deliveries []Delivery
+		errs       []error
+	)
+
+	for _, n := range d.notifiers {
+		r, ok := n.(Renderer)
+		if !ok {
+			continue
+		}
+
+		rendered, err := r.Render(ctx, event)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", n.Name(), err))
+			continue
+		}
+		deliveries = append(deliveries, rendered...)
+	}
+
+	return deliveries, errors.Join(errs...)
+}
+
+// Publish dispatches the event in the background, so the caller is not held
+// up by retries. Failures are only logged. A nil dispatcher drops the event.
+func (d *Dispatcher) Publish(event Event) {
+	if d == nil || !d.Enabled() {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), publishTimeout)
+		defer cancel()
+
+		// The failure of each notifier is already logged by deliver
+		_ = d.Dispatch(ctx, event)
+	}()
+}
+
+// deliver sends the event through a single notifier, retrying transient failures.
+func (d *Dispatcher) deliver(ctx context.Context, n Notifier, event Event) error {
+	backoff := d.backoff
+
+	var err error
+	for attempt := 1; attempt <= d.attempts; attempt++ {
+		err = n.Notify(ctx, event)
+		if err == nil {
+			deliveries.WithLabelValues(n.Name(), "success").Inc()
+			return nil
+		}
+
+		if IsPermanent(err) || attempt == d.attempts {
+			break
+		}
+
+		d.logger.Warn("notification failed, retrying",
+			zap.String("notifier", n.Name()),
+			zap.String("event", event.ID),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		if sleepErr := d.sleep(ctx, backoff); sleepErr != nil {
+			err = sleepErr
+			break
+		}
+		backoff *= 2
+	}
+
+	deliveries.WithLabelValues(n.Name(), "failure").Inc()
+	d.logger.Error("notification failed",
+		zap.String("notifier", n.Name()),
+		zap.String("event", event.ID),
+		zap.String("kind", string(event.Kind)),
+		zap.String("team", event.Team),
+		zap.Error(err),
+	)
+
+	return err
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
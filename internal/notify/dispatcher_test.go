@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestDispatcher(notifiers ...Notifier) (*Dispatcher, *[]time.Duration) {
+	cfg := &config.Config{Notify: config.NotifyConfig{Attempts: 3, Backoff: time.Second}}
+	d := NewDispatcher(notifiers, cfg, zap.NewNop())
+
+	var waits []time.Duration
+	d.sleep = func(_ context.Context, wait time.Duration) error {
+		waits = append(waits, wait)
+		return nil
+	}
+
+	return d, &waits
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	n := &recordingNotifier{failures: 2}
+	d, waits := newTestDispatcher(n)
+
+	require.NoError(t, d.Dispatch(context.Background(), NewEvent(KindHandoff, "backend-team", time.Now())))
+
+	assert.Len(t, n.events, 3)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, *waits)
+}
+
+func TestDispatcher_GivesUpAfterAttempts(t *testing.T) {
+	n := &recordingNotifier{failures: 5}
+	d, _ := newTestDispatcher(n)
+
+	err := d.Dispatch(context.Background(), NewEvent(KindHandoff, "backend-team", time.Now()))
+	require.ErrorIs(t, err, errUnavailable)
+	assert.Len(t, n.events, 3)
+}
+
+func TestDispatcher_PermanentErrorsAreNotRetried(t *testing.T) {
+	failing := &recordingNotifier{failures: 1, err: Permanent(errUnavailable)}
+	healthy := &recordingNotifier{}
+	d, waits := newTestDispatcher(failing, healthy)
+
+	err := d.Dispatch(context.Background(), NewEvent(KindGap, "backend-team", time.Now()))
+	require.ErrorIs(t, err, errUnavailable)
+	assert.Len(t, failing.events, 1)
+	assert.Empty(t, *waits)
+
+	// A failing notifier does not keep the event from the others
+	assert.Len(t, healthy.events, 1)
+}
@@ -0,0 +1,181 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+)
+
+// DryRun computes the notifications the workers would send for a team over
+// a window and renders their deliveries, without sending any of them or
+// recording any of them as sent. Handoffs and gaps are looked up wherever a
+// duty or the pause of the team starts or ends, reminders are due the lead
+// time ahead of their shift and digests at their time of day, with the same
+// computation the watcher and the digest run. Coverage gap alerts are left
+// out.
+type DryRun struct {
+	watcher    *Watcher
+	digest     *Digest
+	dispatcher *Dispatcher
+}
+
+// Message is a delivery of an event found by a dry run.
+type Message struct {
+	Event    Event
+	Delivery Delivery
+}
+
+// NewDryRun creates a dry run of the watcher and the digest, rendering the
+// deliveries of the notifiers of the dispatcher.
+func NewDryRun(watcher *Watcher, digest *Digest, dispatcher *Dispatcher) *DryRun {
+	return &DryRun{
+		watcher:    watcher,
+		digest:     digest,
+		dispatcher: dispatcher,
+	}
+}
+
+// Run returns the messages the team would get over [from, to), ordered by
+// the instant of their event. The members on call at from are the ones the
+// first handoff is from.
+func (r *DryRun) Run(ctx context.Context, team string, from, to time.Time) ([]Message, error) {
+	events, err := r.watcher.simulate(ctx, team, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	digests, err := r.digest.simulate(ctx, team, from, to)
+	if err != nil {
+		return nil, err
+	}
+	events = append(events, digests...)
+
+	slices.SortStableFunc(events, func(a, b Event) int { return a.At.Compare(b.At) })
+
+	var messages []Message
+	for _, event := range events {
+		deliveries, err := r.dispatcher.Preview(ctx, event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render %s at %s: %w", event.Kind, event.At.Format(time.RFC3339), err)
+		}
+
+		for _, delivery := range deliveries {
+			messages = append(messages, Message{Event: event, Delivery: delivery})
+		}
+	}
+
+	return messages, nil
+}
+
+// simulate returns the handoffs, gaps and reminders the watcher would
+// dispatch for the team over [from, to), without recording anything.
+func (w *Watcher) simulate(ctx context.Context, team string, from, to time.Time) ([]Event, error) {
+	t, err := w.storage.GetTeam(ctx, team)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	pause, paused, err := w.storage.GetPause(ctx, team)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pause: %w", err)
+	}
+
+	// Who is on call only changes where a duty or the pause starts or ends
+	var instants []time.Time
+	for _, duty := range storage.DutyTimeline(t.Schedules, from, to) {
+		instants = append(instants, duty.Start, duty.End)
+	}
+	if paused {
+		instants = append(instants, pause.Since, pause.Until)
+	}
+	instants = slices.DeleteFunc(instants, func(at time.Time) bool { return !at.After(from) || !at.Before(to) })
+	slices.SortFunc(instants, time.Time.Compare)
+	instants = slices.CompactFunc(instants, time.Time.Equal)
+
+	previous, err := w.lookup(ctx, team, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, at := range instants {
+		current, err := w.lookup(ctx, team, at)
+		if err != nil {
+			return nil, err
+		}
+
+		if current.member != previous.member || !slices.Equal(current.others, previous.others) {
+			events = append(events, w.change(ctx, team, current, previous.member, at))
+		}
+		previous = current
+	}
+
+	lead := w.leadTime(team)
+	if lead <= 0 {
+		return events, nil
+	}
+
+	for _, shift := range storage.UpcomingShifts(t.Schedules, from.Add(lead), to.Add(lead)) {
+		// Nobody goes on call while the team is paused
+		if paused && pause.Active(shift.Start) {
+			continue
+		}
+
+		member, err := w.storage.GetCurrentOncall(ctx, team, shift.Start)
+		if storage.NobodyOnCall(err) {
+			continue
+		}
+		if err != nil && !errors.Is(err, storage.ErrStale) {
+			return nil, fmt.Errorf("failed to get oncall at %s: %w", shift.Start, err)
+		}
+
+		events = append(events, w.reminder(ctx, team, shift, member, shift.Start.Add(-lead)))
+	}
+
+	return events, nil
+}
+
+// simulate returns the digests the team would get over [from, to), without
+// recording them as sent.
+func (d *Digest) simulate(ctx context.Context, team string, from, to time.Time) ([]Event, error) {
+	settings := d.settings(team)
+	if settings == nil {
+		return nil, nil
+	}
+
+	local := from.In(settings.location)
+
+	var events []Event
+	for day := 0; ; day++ {
+		due := time.Date(local.Year(), local.Month(), local.Day()+day, settings.hour, settings.minute, 0, 0, settings.location)
+		if !due.Before(to) {
+			break
+		}
+		if due.Before(from) {
+			continue
+		}
+
+		t, ok, err := d.team(ctx, team, due)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		event, err := d.digest(ctx, team, t.Schedules, settings, due, due)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun_RendersWithoutSending(t *testing.T) {
+	w, n, s, clock := newTestWatcher(t)
+	w.reminders = config.RemindersConfig{LeadTime: 30 * time.Minute}
+	digest := newTestDigest(t, s, n, clock)
+	ctx := context.Background()
+
+	client := &fakeClient{}
+	d, _ := newTestDispatcher(newTestTelegram(t, client, nil), newTestMSTeams(client))
+	dryRun := NewDryRun(w, digest, d)
+
+	from := time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	messages, err := dryRun.Run(ctx, "backend-team", from, from.AddDate(0, 0, 1))
+	require.NoError(t, err)
+
+	// The digest at 08:00 in Berlin, the reminder, the handoff to Alice and
+	// the gap after her shift, each on both channels
+	var got strings.Builder
+	for _, message := range messages {
+		fmt.Fprintf(&got, "%s %s %s %s\n%s\n%s\n\n",
+			message.Event.At.UTC().Format(time.RFC3339), message.Event.Kind,
+			message.Delivery.Channel, message.Delivery.Recipient,
+			message.Delivery.Subject, message.Delivery.Body)
+	}
+	require.Len(t, messages, 8)
+	assertGolden(t, "dry_run.txt", []byte(got.String()))
+
+	assert.Empty(t, client.requests)
+	assert.Empty(t, n.events)
+
+	// Nothing was recorded as sent, so the workers still send them
+	clock.now = time.Date(2025, 4, 28, 6, 0, 0, 0, time.UTC)
+	require.NoError(t, digest.Check(ctx))
+	clock.now = time.Date(2025, 4, 28, 8, 30, 0, 0, time.UTC)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 2)
+	assert.Equal(t, KindDigest, n.events[0].Kind)
+	assert.Equal(t, KindReminder, n.events[1].Kind)
+}
+
+func TestDryRun_UnknownTeam(t *testing.T) {
+	w, n, s, clock := newTestWatcher(t)
+	digest := newTestDigest(t, s, n, clock)
+
+	client := &fakeClient{}
+	d, _ := newTestDispatcher(newTestTelegram(t, client, nil))
+
+	from := time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	messages, err := NewDryRun(w, digest, d).Run(context.Background(), "frontend-team", from, from.AddDate(0, 0, 7))
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+	assert.Empty(t, client.requests)
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// maxResponseBody bounds how much of a channel's response is read.
+const maxResponseBody = 1 << 16
+
+// post posts an encoded JSON payload with the given extra headers to target
+// and returns the response body. The target URL may hold a secret, so it is
+// never part of the returned error.
+func post(ctx context.Context, client HTTPClient, target string, payload []byte, header http.Header) (int, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return 0, nil, Permanent(errors.New("failed to create request, check the url"))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return 0, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return resp.StatusCode, respBody, nil
+}
+
+// statusError returns the error of a failed call. Rate limits and server
+// errors may pass, so they are retried, anything else is permanent.
+func statusError(status int, message string) error {
+	err := fmt.Errorf("returned %d: %s", status, message)
+
+	if status == http.StatusTooManyRequests || status >= http.StatusInternalServerError {
+		return err
+	}
+
+	return Permanent(err)
+}
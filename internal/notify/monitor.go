@@ -0,0 +1,222 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var coverageGap = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "oncall_coverage_gap",
+	Help: "Whether the team has a coverage gap within the monitored horizon, 1 when it has.",
+}, []string{"team"})
+
+// MonitorLockKey is the advisory lock key that elects the instance monitoring the coverage.
+const MonitorLockKey int64 = 0x67617073
+
+// Monitor periodically evaluates the coverage of every team from now to the
+// horizon and alerts on the gaps in it. A gap is alerted once, and resolved
+// when it is closed before it is over. Gaps while the team is paused or
+// inside one of its blackouts are left out.
+type Monitor struct {
+	storage    storage.Storage
+	dispatcher *Dispatcher
+	elector    Elector
+	horizon    time.Duration
+	logger     *zap.Logger
+	now        func() time.Time
+
+	// alerted holds the gaps of every team that were alerted at the previous check.
+	alerted map[string][]storage.Gap
+}
+
+// NewMonitor creates a coverage monitor. A nil elector means the instance
+// runs alone and always does the checks.
+func NewMonitor(s storage.Storage, dispatcher *Dispatcher, elector Elector, cfg *config.Config, logger *zap.Logger) *Monitor {
+	return &Monitor{
+		storage:    s,
+		dispatcher: dispatcher,
+		elector:    elector,
+		horizon:    cfg.Notify.Gaps.Horizon,
+		logger:     logger.Named("notify"),
+		now:        time.Now,
+		alerted:    make(map[string][]storage.Gap),
+	}
+}
+
+// Check evaluates the coverage of every team once. A failing team does not
+// stop the others.
+func (m *Monitor) Check(ctx context.Context) error {
+	if m.elector != nil {
+		release, ok, err := m.elector.TryLock(ctx, MonitorLockKey)
+		if err != nil {
+			return fmt.Errorf("failed to elect monitor: %w", err)
+		}
+		if !ok {
+			return nil
+		}
+		defer release()
+	}
+
+	teams, err := m.storage.ListTeams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	now := m.now()
+	current := make(map[string]bool, len(teams))
+
+	var errs []error
+	for _, team := range teams {
+		current[team] = true
+
+		if err := m.check(ctx, team, now); err != nil {
+			errs = append(errs, fmt.Errorf("team %s: %w", team, err))
+		}
+	}
+
+	// Forget deleted teams along with their metric
+	for team := range m.alerted {
+		if !current[team] {
+			delete(m.alerted, team)
+			coverageGap.DeleteLabelValues(team)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// check evaluates the coverage of a single team and dispatches its alerts.
+func (m *Monitor) check(ctx context.Context, team string, now time.Time) error {
+	t, err := m.storage.GetTeam(ctx, team)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get team: %w", err)
+	}
+
+	pause, paused, err := m.storage.GetPause(ctx, team)
+	if err != nil {
+		return fmt.Errorf("failed to get pause: %w", err)
+	}
+
+	blackouts, err := m.storage.ListBlackouts(ctx, team)
+	if err != nil {
+		return fmt.Errorf("failed to list blackouts: %w", err)
+	}
+
+	gaps := storage.Gaps(t.Schedules, now, now.Add(m.horizon))
+	if paused {
+		gaps = withoutPause(gaps, pause)
+	}
+	gaps = storage.WithoutBlackouts(gaps, blackouts)
+
+	if len(gaps) > 0 {
+		coverageGap.WithLabelValues(team).Set(1)
+	} else {
+		coverageGap.WithLabelValues(team).Set(0)
+	}
+
+	previous := m.alerted[team]
+
+	var (
+		alerted []storage.Gap
+		errs    []error
+	)
+
+	for _, gap := range gaps {
+		// A gap overlapping an alerted one is the same gap seen a tick later
+		if overlapsAny(gap, previous) {
+			alerted = append(alerted, gap)
+			continue
+		}
+
+		event := NewEvent(KindCoverageGap, team, now)
+		event.GapStart = gap.Start
+		event.GapEnd = gap.End
+
+		// A failed alert is not recorded, so it is tried again on the next check
+		if err := m.dispatcher.Dispatch(ctx, event); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		alerted = append(alerted, gap)
+	}
+
+	for _, gap := range previous {
+		// A gap that is over has not been closed, it is simply forgotten
+		if !gap.End.After(now) || overlapsAny(gap, gaps) {
+			continue
+		}
+
+		event := NewEvent(KindCoverageResolved, team, now)
+		event.GapStart = gap.Start
+		event.GapEnd = gap.End
+
+		if err := m.dispatcher.Dispatch(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	m.alerted[team] = alerted
+
+	return errors.Join(errs...)
+}
+
+// Loop evaluates the coverage every interval until ctx is done.
+func (m *Monitor) Loop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Check(ctx); err != nil {
+				m.logger.Error("coverage check failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// overlapsAny reports whether gap overlaps any of the others.
+func overlapsAny(gap storage.Gap, others []storage.Gap) bool {
+	for _, other := range others {
+		if gap.Overlaps(other) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutPause cuts the pause out of the gaps, nobody is expected on call
+// while the team is paused.
+func withoutPause(gaps []storage.Gap, pause storage.Pause) []storage.Gap {
+	var result []storage.Gap
+
+	for _, gap := range gaps {
+		if !gap.End.After(pause.Since) || (!pause.Until.IsZero() && !gap.Start.Before(pause.Until)) {
+			result = append(result, gap)
+			continue
+		}
+
+		if gap.Start.Before(pause.Since) {
+			result = append(result, storage.Gap{Start: gap.Start, End: pause.Since})
+		}
+		if !pause.Until.IsZero() && pause.Until.Before(gap.End) {
+			result = append(result, storage.Gap{Start: pause.Until, End: gap.End})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// addShift adds a Monday schedule of a single member to the backend team.
+func addShift(t *testing.T, s storage.Storage, name, member, start, end string) {
+	t.Helper()
+
+	startTime, err := time.Parse(time.Kitchen, start)
+	require.NoError(t, err)
+	endTime, err := time.Parse(time.Kitchen, end)
+	require.NoError(t, err)
+
+	_, err = s.AddSchedule(context.Background(), "backend-team", storage.Schedule{
+		Name:    name,
+		Members: []string{member},
+		Days:    []time.Weekday{time.Monday},
+		Start:   startTime,
+		End:     endTime,
+	})
+	require.NoError(t, err)
+}
+
+func newTestMonitor(t *testing.T) (*Monitor, *recordingNotifier, *storage.MemoryStorage, *fakeClock) {
+	t.Helper()
+
+	s := storage.NewMemoryStorage()
+	n := &recordingNotifier{}
+	d, _ := newTestDispatcher(n)
+	clock := &fakeClock{now: time.Date(2025, 4, 28, 8, 0, 0, 0, time.UTC)}
+
+	cfg := &config.Config{Notify: config.NotifyConfig{Gaps: config.GapsConfig{Horizon: 4 * time.Hour}}}
+	m := NewMonitor(s, d, nil, cfg, zap.NewNop())
+	m.now = clock.Now
+
+	// Nobody is on call between 10:00 and 11:00
+	addShift(t, s, "Morning", "Alice", "8:00AM", "10:00AM")
+	addShift(t, s, "Midday", "Bob", "11:00AM", "5:00PM")
+
+	return m, n, s, clock
+}
+
+func TestMonitor_AlertsAndResolvesGap(t *testing.T) {
+	m, n, s, clock := newTestMonitor(t)
+	ctx := context.Background()
+
+	require.NoError(t, m.Check(ctx))
+	require.Len(t, n.events, 1)
+	assert.Equal(t, KindCoverageGap, n.events[0].Kind)
+	assert.Equal(t, "backend-team", n.events[0].Team)
+	assert.Equal(t, time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC), n.events[0].GapStart)
+	assert.Equal(t, time.Date(2025, 4, 28, 11, 0, 0, 0, time.UTC), n.events[0].GapEnd)
+	assert.InDelta(t, 1, testutil.ToFloat64(coverageGap.WithLabelValues("backend-team")), 0)
+
+	// The same gap is not alerted again
+	clock.Advance(time.Minute)
+	require.NoError(t, m.Check(ctx))
+	assert.Len(t, n.events, 1)
+
+	// Covering the gap resolves it
+	addShift(t, s, "Fill-in", "Carol", "10:00AM", "11:00AM")
+
+	clock.Advance(time.Minute)
+	require.NoError(t, m.Check(ctx))
+	require.Len(t, n.events, 2)
+	assert.Equal(t, KindCoverageResolved, n.events[1].Kind)
+	assert.Equal(t, time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC), n.events[1].GapStart)
+	assert.InDelta(t, 0, testutil.ToFloat64(coverageGap.WithLabelValues("backend-team")), 0)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, m.Check(ctx))
+	assert.Len(t, n.events, 2)
+}
+
+func TestMonitor_SkipsPausedGaps(t *testing.T) {
+	m, n, s, clock := newTestMonitor(t)
+	ctx := context.Background()
+
+	_, err := s.PauseTeam(ctx, "backend-team", storage.Pause{
+		Since: clock.Now().Add(90 * time.Minute),
+		Until: clock.Now().Add(3 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Check(ctx))
+	assert.Empty(t, n.events)
+	assert.InDelta(t, 0, testutil.ToFloat64(coverageGap.WithLabelValues("backend-team")), 0)
+}
+
+func TestMonitor_AlertsGapsOutsideBlackouts(t *testing.T) {
+	m, n, s, clock := newTestMonitor(t)
+	ctx := context.Background()
+
+	// The blackout covers the first half of the gap only
+	_, found, err := s.AddBlackout(ctx, "backend-team", storage.Blackout{
+		Reason: "shutdown",
+		Start:  clock.Now().Add(time.Hour),
+		End:    clock.Now().Add(150 * time.Minute),
+	})
+	require.NoError(t, err)
+	require.True(t, found)
+
+	require.NoError(t, m.Check(ctx))
+	require.Len(t, n.events, 1)
+	assert.Equal(t, KindCoverageGap, n.events[0].Kind)
+	assert.Equal(t, time.Date(2025, 4, 28, 10, 30, 0, 0, time.UTC), n.events[0].GapStart)
+	assert.Equal(t, time.Date(2025, 4, 28, 11, 0, 0, 0, time.UTC), n.events[0].GapEnd)
+
+	// A blackout over the rest of the gap resolves it
+	_, _, err = s.AddBlackout(ctx, "backend-team", storage.Blackout{
+		Start: clock.Now().Add(150 * time.Minute),
+		End:   clock.Now().Add(4 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, m.Check(ctx))
+	require.Len(t, n.events, 2)
+	assert.Equal(t, KindCoverageResolved, n.events[1].Kind)
+	assert.InDelta(t, 0, testutil.ToFloat64(coverageGap.WithLabelValues("backend-team")), 0)
+}
+
+func TestMonitor_RetriesFailedAlerts(t *testing.T) {
+	m, n, _, clock := newTestMonitor(t)
+	ctx := context.Background()
+
+	// Every attempt of the first check fails
+	n.failures = 3
+	require.Error(t, m.Check(ctx))
+
+	clock.Advance(time.Minute)
+	require.NoError(t, m.Check(ctx))
+	require.Len(t, n.events, 4)
+	assert.Equal(t, KindCoverageGap, n.events[3].Kind)
+}
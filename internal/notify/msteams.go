@@ -0,0 +1,252 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+)
+
+// adaptiveCardSchema is the JSON schema of the generated Adaptive Cards.
+const adaptiveCardSchema = "http://adaptivecards.io/schemas/adaptive-card.json"
+
+// cardTimeLayout is the layout of the times shown on a card.
+const cardTimeLayout = "Mon Jan 2 15:04 MST"
+
+// MSTeams posts events as Adaptive Cards to Microsoft Teams incoming webhooks.
+type MSTeams struct {
+	client         HTTPClient
+	webhooks       map[string]string
+	defaultWebhook string
+}
+
+// teamsMessage is the incoming-webhook payload wrapping a single card.
+type teamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+type teamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	ContentURL  *string      `json:"contentUrl"`
+	Content     adaptiveCard `json:"content"`
+}
+
+type adaptiveCard struct {
+	Schema  string          `json:"$schema"`
+	Type    string          `json:"type"`
+	Version string          `json:"version"`
+	Body    []adaptiveBlock `json:"body"`
+}
+
+// adaptiveBlock is either a TextBlock or a FactSet.
+type adaptiveBlock struct {
+	Type   string         `json:"type"`
+	Text   string         `json:"text,omitempty"`
+	Size   string         `json:"size,omitempty"`
+	Weight string         `json:"weight,omitempty"`
+	Color  string         `json:"color,omitempty"`
+	Wrap   bool           `json:"wrap,omitempty"`
+	Facts  []adaptiveFact `json:"facts,omitempty"`
+}
+
+type adaptiveFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+// NewMSTeams creates a Microsoft Teams notifier from the configuration.
+func NewMSTeams(client HTTPClient, cfg config.MSTeamsConfig) *MSTeams {
+	return &MSTeams{
+		client:         client,
+		webhooks:       cfg.Webhooks,
+		defaultWebhook: cfg.DefaultWebhook,
+	}
+}
+
+// Name returns the name of the notifier.
+func (m *MSTeams) Name() string {
+	return "msteams"
+}
+
+// Render renders the card of the event for the webhook of its team. Teams
+// without a webhook and event kinds that are not posted have none. The
+// webhook URL holds a secret, so only its host is the recipient.
+func (m *MSTeams) Render(_ context.Context, event Event) ([]Delivery, error) {
+	webhook, ok := m.webhooks[event.Team]
+	if !ok {
+		webhook = m.defaultWebhook
+	}
+	if webhook == "" {
+		return nil, nil
+	}
+
+	card, ok := teamsCard(event)
+	if !ok {
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(card)
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("failed to encode request: %w", err))
+	}
+
+	var host string
+	if u, err := url.Parse(webhook); err == nil {
+		host = u.Host
+	}
+
+	return []Delivery{{
+		Channel:   m.Name(),
+		Recipient: host,
+		Subject:   card.Attachments[0].Content.Body[0].Text,
+		Body:      string(payload),
+		target:    webhook,
+		payload:   payload,
+	}}, nil
+}
+
+// Notify posts the card of the event to the webhook of its team, see Render.
+func (m *MSTeams) Notify(ctx context.Context, event Event) error {
+	deliveries, err := m.Render(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		status, body, err := post(ctx, m.client, delivery.target, delivery.payload, nil)
+		if err != nil {
+			return fmt.Errorf("webhook: %w", err)
+		}
+
+		if status < 200 || status > 299 {
+			return fmt.Errorf("webhook: %w", statusError(status, strings.TrimSpace(string(body))))
+		}
+	}
+
+	return nil
+}
+
+// teamsCard builds the message of an event. It reports false for event kinds
+// that are not posted.
+func teamsCard(event Event) (teamsMessage, bool) {
+	var (
+		title string
+		color string
+		text  string
+		facts []adaptiveFact
+	)
+
+	facts = append(facts, adaptiveFact{Title: "Team", Value: event.Team})
+
+	switch event.Kind {
+	case KindHandoff:
+		title = fmt.Sprintf("%s is now on call", event.Current)
+		color = "Good"
+
+		if event.Schedule != "" {
+			facts = append(facts, adaptiveFact{Title: "Schedule", Value: event.Schedule})
+		}
+		facts = append(facts, adaptiveFact{Title: "On call", Value: event.Current})
+		if len(event.Others) > 0 {
+			facts = append(facts, adaptiveFact{Title: "On call with", Value: strings.Join(event.Others, ", ")})
+		}
+		if event.Previous != "" {
+			facts = append(facts, adaptiveFact{Title: "Previous", Value: event.Previous})
+		}
+		if !event.ShiftEnd.IsZero() {
+			facts = append(facts, adaptiveFact{Title: "Shift ends", Value: event.Local(event.ShiftEnd).Format(cardTimeLayout)})
+		}
+		if len(event.Observers) > 0 {
+			facts = append(facts, adaptiveFact{Title: "Observers", Value: strings.Join(event.Observers, ", ")})
+		}
+		if event.Note != "" {
+			facts = append(facts, adaptiveFact{Title: "Handoff note", Value: event.Note})
+		}
+	case KindGap:
+		title = "Nobody is on call"
+		color = "Attention"
+
+		if event.Previous != "" {
+			facts = append(facts, adaptiveFact{Title: "Previous", Value: event.Previous})
+		}
+		facts = append(facts, adaptiveFact{Title: "Since", Value: event.At.UTC().Format(cardTimeLayout)})
+	case KindReminder:
+		title = fmt.Sprintf("%s goes on call soon", event.Current)
+		color = "Accent"
+
+		facts = append(facts,
+			adaptiveFact{Title: "Schedule", Value: event.Schedule},
+			adaptiveFact{Title: "On call", Value: event.Current},
+			adaptiveFact{Title: "Shift starts", Value: event.Local(event.ShiftStart).Format(cardTimeLayout)},
+			adaptiveFact{Title: "Shift ends", Value: event.Local(event.ShiftEnd).Format(cardTimeLayout)},
+		)
+	case KindCoverageGap, KindCoverageResolved:
+		title = "Upcoming coverage gap"
+		color = "Warning"
+		if event.Kind == KindCoverageResolved {
+			title = "Coverage gap closed"
+			color = "Good"
+		}
+
+		facts = append(facts,
+			adaptiveFact{Title: "From", Value: event.GapStart.UTC().Format(cardTimeLayout)},
+			adaptiveFact{Title: "To", Value: event.GapEnd.UTC().Format(cardTimeLayout)},
+		)
+	case KindDigest:
+		title = "On-call digest"
+		color = "Accent"
+
+		text = event.Summary
+	case KindSwapRequest:
+		title = fmt.Sprintf("%s asks %s to take a shift over", event.Previous, event.Current)
+		color = "Accent"
+		if event.Change != ChangeRequested {
+			title = fmt.Sprintf("%s %s to take a shift over from %s", event.Current, event.Change, event.Previous)
+			color = "Good"
+			if event.Change == ChangeDeclined {
+				color = "Warning"
+			}
+		}
+
+		facts = append(facts,
+			adaptiveFact{Title: "Schedule", Value: event.Schedule},
+			adaptiveFact{Title: "Shift starts", Value: event.Local(event.ShiftStart).Format(cardTimeLayout)},
+			adaptiveFact{Title: "Shift ends", Value: event.Local(event.ShiftEnd).Format(cardTimeLayout)},
+		)
+		if event.Link != "" {
+			facts = append(facts, adaptiveFact{Title: "Accept", Value: event.Link})
+		}
+	case KindScheduleChange:
+		title = fmt.Sprintf("Schedule %s", event.Change)
+		color = "Accent"
+
+		facts = append(facts, adaptiveFact{Title: "Schedule", Value: event.Schedule})
+	default:
+		return teamsMessage{}, false
+	}
+
+	body := []adaptiveBlock{
+		{Type: "TextBlock", Text: title, Size: "Medium", Weight: "Bolder", Color: color, Wrap: true},
+	}
+	if text != "" {
+		body = append(body, adaptiveBlock{Type: "TextBlock", Text: text, Wrap: true})
+	}
+	body = append(body, adaptiveBlock{Type: "FactSet", Facts: facts})
+
+	return teamsMessage{
+		Type: "message",
+		Attachments: []teamsAttachment{{
+			ContentType: "application/vnd.microsoft.card.adaptive",
+			Content: adaptiveCard{
+				Schema:  adaptiveCardSchema,
+				Type:    "AdaptiveCard",
+				Version: "1.4",
+				Body:    body,
+			},
+		}},
+	}, true
+}
@@ -0,0 +1,115 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// assertGolden compares got with the golden file, or rewrites it with -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+func newTestMSTeams(client HTTPClient) *MSTeams {
+	return NewMSTeams(client, config.MSTeamsConfig{
+		Webhooks: map[string]string{"backend-team": "https://example.webhook.office.com/webhookb2/backend"},
+	})
+}
+
+func TestMSTeams_Cards(t *testing.T) {
+	at := time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC)
+
+	gap := NewEvent(KindGap, "backend-team", at)
+	gap.Previous = "Bob"
+
+	change := NewEvent(KindScheduleChange, "backend-team", at)
+	change.Schedule = "Weekend Coverage"
+	change.Change = ChangeCreated
+
+	reminder := NewEvent(KindReminder, "backend-team", at)
+	reminder.Schedule = "Weekday Coverage"
+	reminder.Current = "Bob"
+	reminder.ShiftStart = time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC)
+	reminder.ShiftEnd = time.Date(2025, 5, 5, 17, 0, 0, 0, time.UTC)
+
+	coverage := NewEvent(KindCoverageGap, "backend-team", at)
+	coverage.GapStart = time.Date(2025, 4, 28, 22, 0, 0, 0, time.UTC)
+	coverage.GapEnd = time.Date(2025, 4, 29, 6, 0, 0, 0, time.UTC)
+
+	digest := NewEvent(KindDigest, "backend-team", at)
+	digest.Summary = "backend-team on call from Mon Apr 28 08:00 to Tue Apr 29 08:00 UTC:\n- Mon 09:00 to Mon 17:00: Alice (Weekday Coverage)\n"
+
+	tests := []struct {
+		name  string
+		event Event
+	}{
+		{"handoff", handoff()},
+		{"gap", gap},
+		{"schedule_change", change},
+		{"reminder", reminder},
+		{"coverage_gap", coverage},
+		{"digest", digest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{}
+			require.NoError(t, newTestMSTeams(client).Notify(context.Background(), tt.event))
+
+			require.Len(t, client.requests, 1)
+			assert.Equal(t, http.MethodPost, client.requests[0].Method)
+			assert.Equal(t, "https://example.webhook.office.com/webhookb2/backend", client.requests[0].URL.String())
+
+			var body bytes.Buffer
+			require.NoError(t, json.Indent(&body, []byte(client.bodies[0]), "", "  "))
+			body.WriteByte('\n')
+
+			assertGolden(t, "msteams_"+tt.name+".json", body.Bytes())
+		})
+	}
+}
+
+func TestMSTeams_SkipsTeamsWithoutWebhook(t *testing.T) {
+	client := &fakeClient{}
+
+	event := handoff()
+	event.Team = "frontend-team"
+	require.NoError(t, newTestMSTeams(client).Notify(context.Background(), event))
+
+	assert.Empty(t, client.requests)
+}
+
+func TestMSTeams_Errors(t *testing.T) {
+	client := &fakeClient{responses: []fakeResponse{{status: http.StatusBadRequest, body: "Bad payload received by generic incoming webhook."}}}
+	err := newTestMSTeams(client).Notify(context.Background(), handoff())
+	require.Error(t, err)
+	assert.True(t, IsPermanent(err))
+	assert.NotContains(t, err.Error(), "webhookb2")
+
+	client = &fakeClient{responses: []fakeResponse{{status: http.StatusTooManyRequests}}}
+	err = newTestMSTeams(client).Notify(context.Background(), handoff())
+	require.Error(t, err)
+	assert.False(t, IsPermanent(err))
+}
@@ -0,0 +1,189 @@
+// Package notify sends on-call events, such as handoffs and coverage gaps,
+// to the configured notification channels.
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+)
+
+// Kind is the kind of an on-call event.
+type Kind string
+
+// Event kinds.
+const (
+	// KindHandoff is sent when a new member goes on call.
+	KindHandoff Kind = "handoff"
+	// KindGap is sent when a team is left without anybody on call.
+	KindGap Kind = "gap"
+	// KindScheduleChange is sent when a schedule of a team is changed.
+	KindScheduleChange Kind = "schedule_change"
+	// KindReminder is sent ahead of a shift to the member who is going on call.
+	KindReminder Kind = "reminder"
+	// KindCoverageGap is sent when an upcoming stretch without anybody on call is found.
+	KindCoverageGap Kind = "coverage_gap"
+	// KindCoverageResolved is sent when an alerted coverage gap is closed before it is over.
+	KindCoverageResolved Kind = "coverage_resolved"
+	// KindDigest is the daily summary of the upcoming shifts of a team.
+	KindDigest Kind = "digest"
+	// KindSwapRequest is sent when a member is asked to take a shift over,
+	// and when they accept or decline.
+	KindSwapRequest Kind = "swap_request"
+)
+
+// Kinds lists every event kind.
+var Kinds = []Kind{KindHandoff, KindGap, KindScheduleChange, KindReminder, KindCoverageGap, KindCoverageResolved, KindDigest, KindSwapRequest}
+
+// Schedule changes.
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+)
+
+// Swap request changes, the latter two being the statuses of decided swap
+// requests.
+const (
+	ChangeRequested = "requested"
+	ChangeAccepted  = storage.SwapAccepted
+	ChangeDeclined  = storage.SwapDeclined
+)
+
+// Event is an on-call event. Previous is empty when the team was uncovered
+// before, Current and Schedule are empty for a gap, and ShiftEnd is zero when
+// the end of the shift is unknown. Change is only set for schedule changes and
+// swap requests, ShiftStart only for reminders and swap requests, GapStart and
+// GapEnd only for coverage gaps, Summary only for digests, Observers only for
+// the handoffs of teams notifying their observers, Note only for handoffs
+// with a handoff note shown, Others only for handoffs to schedules requiring
+// more than one member, with the members on call along with Current, and
+// Link only for new swap requests. Swap requests hand the shift of Previous
+// over to Current.
+// Location is the zone of Current the shift times are rendered in, nil for
+// UTC.
+type Event struct {
+	ID         string
+	Kind       Kind
+	Team       string
+	Schedule   string
+	Change     string
+	Previous   string
+	Current    string
+	ShiftStart time.Time
+	ShiftEnd   time.Time
+	GapStart   time.Time
+	GapEnd     time.Time
+	Summary    string
+	Observers  []string
+	Note       string
+	Others     []string
+	Link       string
+	Location   *time.Location
+	At         time.Time
+}
+
+// Local returns the instant in the zone of the member the event is for, see
+// Location.
+func (e Event) Local(t time.Time) time.Time {
+	if e.Location == nil {
+		return t.UTC()
+	}
+
+	return t.In(e.Location)
+}
+
+// NewEvent returns an event of the given kind with a fresh random ID.
+func NewEvent(kind Kind, team string, at time.Time) Event {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return Event{
+		ID:   hex.EncodeToString(id),
+		Kind: kind,
+		Team: team,
+		At:   at,
+	}
+}
+
+// Notifier delivers events to a single channel. Notify returns nil for events
+// the channel is not configured for, e.g. a team without a chat.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event Event) error
+}
+
+// Delivery is a message of a notifier for an event, as it is sent: the
+// Channel it goes through, its Recipient, and its Subject and Body. Recipients
+// holding a secret, such as Microsoft Teams webhook URLs, are only named by
+// their host.
+type Delivery struct {
+	Channel   string
+	Recipient string
+	Subject   string
+	Body      string
+
+	// target is where payload is posted to, along with header.
+	target  string
+	payload []byte
+	header  http.Header
+}
+
+// Renderer is implemented by the notifiers that render their deliveries
+// apart from sending them, so they can be previewed. Render returns no
+// deliveries for events the channel is not configured for.
+type Renderer interface {
+	Render(ctx context.Context, event Event) ([]Delivery, error)
+}
+
+// HTTPClient is the part of *http.Client the notifiers use, so tests can
+// replace it and assert the exact calls.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// NewNotifiers creates the notifiers enabled in the configuration, along with
+// the webhook notifier for the subscriptions in storage.
+func NewNotifiers(cfg *config.Config, client HTTPClient, s storage.Storage) ([]Notifier, error) {
+	// Subscriptions are created at runtime, so webhooks are always enabled
+	notifiers := []Notifier{NewWebhooks(client, s)}
+
+	if cfg.Notify.Telegram.Token != "" {
+		telegram, err := NewTelegram(client, cfg.Notify.Telegram)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, telegram)
+	}
+
+	if cfg.Notify.MSTeams.DefaultWebhook != "" || len(cfg.Notify.MSTeams.Webhooks) > 0 {
+		notifiers = append(notifiers, NewMSTeams(client, cfg.Notify.MSTeams))
+	}
+
+	return notifiers, nil
+}
+
+// permanentError marks an error that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying, e.g. a request the channel rejected as invalid.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
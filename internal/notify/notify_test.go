@@ -0,0 +1,85 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeClient records the requests it gets and answers them from responses,
+// repeating the last one.
+type fakeClient struct {
+	mu        sync.Mutex
+	requests  []*http.Request
+	bodies    []string
+	responses []fakeResponse
+}
+
+type fakeResponse struct {
+	status int
+	body   string
+	err    error
+}
+
+func (c *fakeClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	c.requests = append(c.requests, req)
+	c.bodies = append(c.bodies, string(body))
+
+	resp := fakeResponse{status: http.StatusOK, body: `{"ok":true}`}
+	if len(c.responses) > 0 {
+		resp = c.responses[0]
+		if len(c.responses) > 1 {
+			c.responses = c.responses[1:]
+		}
+	}
+
+	if resp.err != nil {
+		return nil, resp.err
+	}
+
+	return &http.Response{
+		StatusCode: resp.status,
+		Body:       io.NopCloser(strings.NewReader(resp.body)),
+		Header:     make(http.Header),
+	}, nil
+}
+
+// recordingNotifier records the events it gets and fails the first failures calls.
+type recordingNotifier struct {
+	events   []Event
+	failures int
+	err      error
+}
+
+var errUnavailable = errors.New("service unavailable")
+
+func (n *recordingNotifier) Name() string { return "recording" }
+
+func (n *recordingNotifier) Notify(_ context.Context, event Event) error {
+	n.events = append(n.events, event)
+	if n.failures > 0 {
+		n.failures--
+		if n.err != nil {
+			return n.err
+		}
+		return errUnavailable
+	}
+	return nil
+}
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
@@ -0,0 +1,110 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+)
+
+// Telegram sends events as messages through the Telegram Bot API.
+type Telegram struct {
+	client      HTTPClient
+	apiURL      string
+	token       string
+	chats       map[string]string
+	defaultChat string
+	templates   Templates
+}
+
+// telegramMessage is the body of a sendMessage call.
+type telegramMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// telegramResponse is the envelope of every Bot API response.
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// NewTelegram creates a Telegram notifier from the configuration.
+func NewTelegram(client HTTPClient, cfg config.TelegramConfig) (*Telegram, error) {
+	templates, err := ParseTemplates(cfg.Templates)
+	if err != nil {
+		return nil, fmt.Errorf("telegram: %w", err)
+	}
+
+	return &Telegram{
+		client:      client,
+		apiURL:      strings.TrimSuffix(cfg.APIURL, "/"),
+		token:       cfg.Token,
+		chats:       cfg.Chats,
+		defaultChat: cfg.DefaultChat,
+		templates:   templates,
+	}, nil
+}
+
+// Name returns the name of the notifier.
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+// Render renders the message of the event for the chat of its team. Teams
+// without a chat and event kinds without a template have none.
+func (t *Telegram) Render(_ context.Context, event Event) ([]Delivery, error) {
+	chat, ok := t.chats[event.Team]
+	if !ok {
+		chat = t.defaultChat
+	}
+	if chat == "" {
+		return nil, nil
+	}
+
+	text, ok, err := t.templates.Render(event)
+	if err != nil {
+		return nil, Permanent(err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(telegramMessage{ChatID: chat, Text: text})
+	if err != nil {
+		return nil, Permanent(fmt.Errorf("failed to encode request: %w", err))
+	}
+
+	return []Delivery{{
+		Channel:   t.Name(),
+		Recipient: chat,
+		Body:      text,
+		target:    t.apiURL + "/bot" + t.token + "/sendMessage",
+		payload:   payload,
+	}}, nil
+}
+
+// Notify sends the message of the event to the chat of its team, see Render.
+func (t *Telegram) Notify(ctx context.Context, event Event) error {
+	deliveries, err := t.Render(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		status, body, err := post(ctx, t.client, delivery.target, delivery.payload, nil)
+		if err != nil {
+			return fmt.Errorf("bot api: %w", err)
+		}
+
+		var result telegramResponse
+		if err := json.Unmarshal(body, &result); err != nil || !result.OK {
+			return fmt.Errorf("bot api: %w", statusError(status, result.Description))
+		}
+	}
+
+	return nil
+}
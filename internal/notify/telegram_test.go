@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTelegram(t *testing.T, client HTTPClient, templates map[string]string) *Telegram {
+	t.Helper()
+
+	telegram, err := NewTelegram(client, config.TelegramConfig{
+		Token:     "123:secret",
+		APIURL:    "https://telegram.test/",
+		Chats:     map[string]string{"backend-team": "-1001"},
+		Templates: templates,
+	})
+	require.NoError(t, err)
+
+	return telegram
+}
+
+func handoff() Event {
+	event := NewEvent(KindHandoff, "backend-team", time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC))
+	event.Schedule = "Weekday Coverage"
+	event.Previous = "Alice"
+	event.Current = "Bob"
+	event.ShiftEnd = time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC)
+	return event
+}
+
+func TestTelegram_SendsMessage(t *testing.T) {
+	client := &fakeClient{}
+	telegram := newTestTelegram(t, client, nil)
+
+	require.NoError(t, telegram.Notify(context.Background(), handoff()))
+
+	require.Len(t, client.requests, 1)
+	req := client.requests[0]
+	assert.Equal(t, http.MethodPost, req.Method)
+	assert.Equal(t, "https://telegram.test/bot123:secret/sendMessage", req.URL.String())
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"chat_id": "-1001",
+		"text": "backend-team: Bob is now on call for Weekday Coverage, taking over from Alice until Mon 17:00 UTC."
+	}`, client.bodies[0])
+}
+
+func TestTelegram_HandoffObservers(t *testing.T) {
+	client := &fakeClient{}
+	telegram := newTestTelegram(t, client, nil)
+
+	event := handoff()
+	event.Observers = []string{"Carol", "Dave"}
+	require.NoError(t, telegram.Notify(context.Background(), event))
+
+	require.Len(t, client.bodies, 1)
+	assert.JSONEq(t, `{
+		"chat_id": "-1001",
+		"text": "backend-team: Bob is now on call for Weekday Coverage, taking over from Alice until Mon 17:00 UTC. cc Carol, Dave"
+	}`, client.bodies[0])
+}
+
+func TestTelegram_HandoffOthers(t *testing.T) {
+	client := &fakeClient{}
+	telegram := newTestTelegram(t, client, nil)
+
+	event := handoff()
+	event.Others = []string{"Charlie"}
+	require.NoError(t, telegram.Notify(context.Background(), event))
+
+	require.Len(t, client.bodies, 1)
+	assert.JSONEq(t, `{
+		"chat_id": "-1001",
+		"text": "backend-team: Bob is now on call with Charlie for Weekday Coverage, taking over from Alice until Mon 17:00 UTC."
+	}`, client.bodies[0])
+}
+
+func TestTelegram_HandoffNote(t *testing.T) {
+	client := &fakeClient{}
+	telegram := newTestTelegram(t, client, nil)
+
+	event := handoff()
+	event.Note = "deploy freeze until Monday, watch the payments queue"
+	require.NoError(t, telegram.Notify(context.Background(), event))
+
+	require.Len(t, client.bodies, 1)
+	assert.JSONEq(t, `{
+		"chat_id": "-1001",
+		"text": "backend-team: Bob is now on call for Weekday Coverage, taking over from Alice until Mon 17:00 UTC. Handoff note: deploy freeze until Monday, watch the payments queue"
+	}`, client.bodies[0])
+}
+
+func TestTelegram_MemberLocation(t *testing.T) {
+	client := &fakeClient{}
+	telegram := newTestTelegram(t, client, nil)
+
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	require.NoError(t, err)
+
+	event := NewEvent(KindReminder, "backend-team", time.Date(2025, 4, 28, 8, 30, 0, 0, time.UTC))
+	event.Schedule = "Weekday Coverage"
+	event.Current = "Bob"
+	event.ShiftStart = time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC)
+	event.ShiftEnd = time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC)
+	event.Location = tehran
+	require.NoError(t, telegram.Notify(context.Background(), event))
+
+	require.Len(t, client.bodies, 1)
+	assert.JSONEq(t, `{
+		"chat_id": "-1001",
+		"text": "backend-team: Bob, your Weekday Coverage shift starts at Mon 12:30 +0330 and ends at Mon 20:30 +0330."
+	}`, client.bodies[0])
+}
+
+func TestTelegram_GapMessage(t *testing.T) {
+	client := &fakeClient{}
+	telegram := newTestTelegram(t, client, nil)
+
+	event := NewEvent(KindGap, "backend-team", time.Now())
+	event.Previous = "Bob"
+	require.NoError(t, telegram.Notify(context.Background(), event))
+
+	require.Len(t, client.bodies, 1)
+	assert.JSONEq(t, `{"chat_id": "-1001", "text": "backend-team: nobody is on call since the shift of Bob ended."}`, client.bodies[0])
+}
+
+func TestTelegram_CustomTemplate(t *testing.T) {
+	client := &fakeClient{}
+	telegram := newTestTelegram(t, client, map[string]string{
+		"handoff": `{{.Previous}} -> {{.Current}} ({{.Schedule}}, ends {{.ShiftEnd.Format "15:04"}})`,
+	})
+
+	require.NoError(t, telegram.Notify(context.Background(), handoff()))
+
+	require.Len(t, client.bodies, 1)
+	assert.JSONEq(t, `{"chat_id": "-1001", "text": "Alice -> Bob (Weekday Coverage, ends 17:00)"}`, client.bodies[0])
+}
+
+func TestTelegram_InvalidTemplate(t *testing.T) {
+	_, err := NewTelegram(&fakeClient{}, config.TelegramConfig{Templates: map[string]string{"handoff": "{{.Current"}})
+	assert.Error(t, err)
+}
+
+func TestTelegram_SkipsTeamsWithoutChat(t *testing.T) {
+	client := &fakeClient{}
+	telegram := newTestTelegram(t, client, nil)
+
+	event := handoff()
+	event.Team = "frontend-team"
+	require.NoError(t, telegram.Notify(context.Background(), event))
+
+	assert.Empty(t, client.requests)
+}
+
+func TestTelegram_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  fakeResponse
+		permanent bool
+	}{
+		{
+			name:      "bad request",
+			response:  fakeResponse{status: http.StatusBadRequest, body: `{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`},
+			permanent: true,
+		},
+		{
+			name:     "rate limited",
+			response: fakeResponse{status: http.StatusTooManyRequests, body: `{"ok":false,"error_code":429,"description":"Too Many Requests"}`},
+		},
+		{
+			name:     "server error",
+			response: fakeResponse{status: http.StatusBadGateway, body: `bad gateway`},
+		},
+		{
+			name:     "network error",
+			response: fakeResponse{err: errors.New("connection reset")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeClient{responses: []fakeResponse{tt.response}}
+			telegram := newTestTelegram(t, client, nil)
+
+			err := telegram.Notify(context.Background(), handoff())
+			require.Error(t, err)
+			assert.Equal(t, tt.permanent, IsPermanent(err))
+			assert.NotContains(t, err.Error(), "secret")
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// DefaultTemplates are the default plain text messages by event kind. They
+// are executed with the Event, so Team, Schedule, Previous, Current,
+// Others, ShiftStart, ShiftEnd, GapStart, GapEnd, Summary, Observers, Note
+// and Link are all available. Shift times are rendered with Local, in the
+// zone of the member on call.
+var DefaultTemplates = map[Kind]string{
+	KindHandoff: `{{.Team}}: {{.Current}} is now on call` +
+		`{{if .Others}} with {{range $i, $o := .Others}}{{if $i}}, {{end}}{{$o}}{{end}}{{end}}` +
+		`{{if .Schedule}} for {{.Schedule}}{{end}}` +
+		`{{if .Previous}}, taking over from {{.Previous}}{{end}}` +
+		`{{if not .ShiftEnd.IsZero}} until {{(.Local .ShiftEnd).Format "Mon 15:04 MST"}}{{end}}.` +
+		`{{if .Observers}} cc {{range $i, $o := .Observers}}{{if $i}}, {{end}}{{$o}}{{end}}{{end}}` +
+		`{{if .Note}} Handoff note: {{.Note}}{{end}}`,
+	KindGap: `{{.Team}}: nobody is on call` +
+		`{{if .Previous}} since the shift of {{.Previous}} ended{{end}}.`,
+	KindReminder: `{{.Team}}: {{.Current}}, your {{.Schedule}} shift starts at ` +
+		`{{(.Local .ShiftStart).Format "Mon 15:04 MST"}} and ends at {{(.Local .ShiftEnd).Format "Mon 15:04 MST"}}.`,
+	KindCoverageGap: `{{.Team}}: nobody will be on call from {{.GapStart.UTC.Format "Mon 15:04 MST"}} ` +
+		`to {{.GapEnd.UTC.Format "Mon 15:04 MST"}}.`,
+	KindCoverageResolved: `{{.Team}}: the coverage gap from {{.GapStart.UTC.Format "Mon 15:04 MST"}} ` +
+		`to {{.GapEnd.UTC.Format "Mon 15:04 MST"}} is closed.`,
+	KindDigest: `{{.Summary}}`,
+	KindSwapRequest: `{{.Team}}: ` +
+		`{{if eq .Change "requested"}}{{.Previous}} asks {{.Current}} to take over their {{.Schedule}} shift` +
+		`{{else}}{{.Current}} {{.Change}} to take over the {{.Schedule}} shift of {{.Previous}}{{end}} ` +
+		`from {{(.Local .ShiftStart).Format "Mon 15:04 MST"}} to {{(.Local .ShiftEnd).Format "Mon 15:04 MST"}}.` +
+		`{{if .Link}} Accept at {{.Link}}, or decline there with /decline in place of /accept.{{end}}`,
+}
+
+// Templates renders the messages of a notifier.
+type Templates map[Kind]*template.Template
+
+// ParseTemplates parses the default templates with the given overrides, keyed
+// by event kind, taking precedence.
+func ParseTemplates(overrides map[string]string) (Templates, error) {
+	sources := make(map[Kind]string, len(DefaultTemplates))
+	for kind, text := range DefaultTemplates {
+		sources[kind] = text
+	}
+	for kind, text := range overrides {
+		sources[Kind(kind)] = text
+	}
+
+	templates := make(Templates, len(sources))
+	for kind, text := range sources {
+		t, err := template.New(string(kind)).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", kind, err)
+		}
+		templates[kind] = t
+	}
+
+	return templates, nil
+}
+
+// Render renders the message of an event. It reports false when there is no
+// template for the kind of the event.
+func (t Templates) Render(event Event) (string, bool, error) {
+	tmpl, ok := t[event.Kind]
+	if !ok {
+		return "", false, nil
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, event); err != nil {
+		return "", false, fmt.Errorf("failed to render %s message: %w", event.Kind, err)
+	}
+
+	return b.String(), true, nil
+}
@@ -0,0 +1,504 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+// Reasons of the handoffs counted by oncall_handoffs_total.
+const (
+	// HandoffAutomatic is a shift of the rotation starting.
+	HandoffAutomatic = "automatic"
+	// HandoffManual is the rotation of a schedule being set or advanced.
+	HandoffManual = "manual"
+	// HandoffOverride is a pin, or a substitute for an unavailable member.
+	HandoffOverride = "override"
+	// HandoffSwap is an accepted swap request.
+	HandoffSwap = "swap"
+)
+
+var (
+	handoffs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "oncall_handoffs_total",
+		Help: "Number of handoffs, by team, schedule and reason.",
+	}, []string{"team", "schedule", "reason"})
+	oncallCurrent = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "oncall_current",
+		Help: "The members on call for the team, 1 for every member on call and absent when the team is uncovered.",
+	}, []string{"team", "member"})
+)
+
+// LockKey is the advisory lock key that elects the instance watching the teams.
+const LockKey int64 = 0x6e6f74696679
+
+// Elector elects the single instance that watches the teams when several
+// instances share a database. TryLock must not wait for the lock, ok is false
+// when another instance holds it.
+type Elector interface {
+	TryLock(ctx context.Context, key int64) (release func(), ok bool, err error)
+}
+
+// Watcher periodically looks up who is on call for every team and dispatches
+// a handoff event when it changes, or a gap event when nobody is left on call.
+// Unavailable members are substituted like the on-call lookup does, and a
+// team whose members are all unavailable is reported as a gap. Paused teams
+// are skipped. The first check of a team only records its state,
+// so a restart does not announce every current shift again.
+//
+// When a reminder lead time is configured, the member of every shift starting
+// within it is reminded once. Sent reminders are tracked in storage, so they
+// are not sent again after a restart.
+//
+// Schedules requiring several members hand off whenever one of them changes,
+// naming the others on call along with the first one.
+//
+// Teams notifying their observers name them in their handoffs, and the
+// handoff note shown when a shift starts is passed along with its handoff.
+//
+// Handoffs are counted by their reason, and the member on call for every
+// team is exposed as a gauge, refreshed at every check by the elected
+// instance. The other instances expose none.
+type Watcher struct {
+	storage    storage.Storage
+	dispatcher *Dispatcher
+	elector    Elector
+	reminders  config.RemindersConfig
+	observers  config.ObserversConfig
+	logger     *zap.Logger
+	now        func() time.Time
+
+	// last holds the member on call at the previous check, empty when the team was uncovered.
+	last map[string]string
+	// others holds the members on call along with the one in last, for
+	// schedules requiring more than one.
+	others map[string][]string
+	// checked is the instant of the previous check.
+	checked time.Time
+}
+
+// NewWatcher creates a watcher. A nil elector means the instance runs alone
+// and always does the checks.
+func NewWatcher(s storage.Storage, dispatcher *Dispatcher, elector Elector, cfg *config.Config, logger *zap.Logger) *Watcher {
+	return &Watcher{
+		storage:    s,
+		dispatcher: dispatcher,
+		elector:    elector,
+		reminders:  cfg.Notify.Reminders,
+		observers:  cfg.Notify.Observers,
+		logger:     logger.Named("notify"),
+		now:        time.Now,
+		last:       make(map[string]string),
+		others:     make(map[string][]string),
+	}
+}
+
+// Check looks up every team once and dispatches the events of the changes
+// since the previous check. A failing team does not stop the others.
+func (w *Watcher) Check(ctx context.Context) error {
+	if w.elector != nil {
+		release, ok, err := w.elector.TryLock(ctx, LockKey)
+		if err != nil {
+			return fmt.Errorf("failed to elect watcher: %w", err)
+		}
+		if !ok {
+			// The elected instance exposes who is on call
+			oncallCurrent.Reset()
+			return nil
+		}
+		defer release()
+	}
+
+	teams, err := w.storage.ListTeams(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list teams: %w", err)
+	}
+
+	now := w.now()
+	current := make(map[string]bool, len(teams))
+
+	var errs []error
+	for _, team := range teams {
+		current[team] = true
+
+		if err := w.check(ctx, team, now); err != nil {
+			errs = append(errs, fmt.Errorf("team %s: %w", team, err))
+		}
+
+		if err := w.remind(ctx, team, now); err != nil {
+			errs = append(errs, fmt.Errorf("team %s: %w", team, err))
+		}
+	}
+
+	// Forget deleted teams, so a team created again with the same name starts fresh
+	for team, member := range w.last {
+		if !current[team] {
+			for _, member := range append([]string{member}, w.others[team]...) {
+				oncallCurrent.DeleteLabelValues(team, member)
+			}
+			delete(w.last, team)
+			delete(w.others, team)
+		}
+	}
+
+	w.checked = now
+
+	return errors.Join(errs...)
+}
+
+// record remembers the member on call for the team, empty when it is
+// uncovered, along with the others on call with them, and returns those at
+// the previous check. The series of the members no longer on call are
+// deleted, so the gauge only holds current members.
+func (w *Watcher) record(team, member string, others []string) (string, []string, bool) {
+	previous, seen := w.last[team]
+	previousOthers := w.others[team]
+	w.last[team] = member
+	w.others[team] = others
+
+	current := append([]string{member}, others...)
+	if seen {
+		for _, left := range append([]string{previous}, previousOthers...) {
+			if left != "" && !slices.Contains(current, left) {
+				oncallCurrent.DeleteLabelValues(team, left)
+			}
+		}
+	}
+	if member != "" {
+		for _, onCall := range current {
+			oncallCurrent.WithLabelValues(team, onCall).Set(1)
+		}
+	}
+
+	return previous, previousOthers, seen
+}
+
+// oncall is who is on call for a team at a check: member, empty when the
+// team is uncovered, and the others on call along with them. The team is
+// kept for the shift of the handoff, unless it failed to load with teamErr.
+type oncall struct {
+	member  string
+	others  []string
+	team    storage.Team
+	teamErr error
+}
+
+// check looks up a single team and dispatches its event, if any.
+func (w *Watcher) check(ctx context.Context, team string, now time.Time) error {
+	current, err := w.lookup(ctx, team, now)
+	if err != nil {
+		return err
+	}
+
+	previous, previousOthers, seen := w.record(team, current.member, current.others)
+
+	if !seen || current.member == previous && slices.Equal(current.others, previousOthers) {
+		return nil
+	}
+
+	event := w.change(ctx, team, current, previous, now)
+
+	if event.Kind == KindHandoff {
+		reason := HandoffAutomatic
+		if event.Schedule != "" {
+			if sched, ok := storage.ScheduleAt(current.team.Schedules, now); ok {
+				reason = w.handoffReason(ctx, team, sched, previous, current.member, now)
+			}
+		}
+		handoffs.WithLabelValues(team, event.Schedule, reason).Inc()
+	}
+
+	return w.dispatcher.Dispatch(ctx, event)
+}
+
+// lookup returns who is on call for the team at the given instant, like the
+// on-call lookup with unavailable members substituted.
+func (w *Watcher) lookup(ctx context.Context, team string, now time.Time) (oncall, error) {
+	pause, paused, err := w.storage.GetPause(ctx, team)
+	if err != nil {
+		return oncall{}, fmt.Errorf("failed to get pause: %w", err)
+	}
+
+	// Nobody is on call in a paused team on purpose, the next shift after it
+	// ends is announced as a handoff
+	if paused && pause.Active(now) {
+		return oncall{}, nil
+	}
+
+	member, err := w.storage.GetCurrentOncall(ctx, team, now)
+	stale := errors.Is(err, storage.ErrStale)
+	if err != nil && !stale && !storage.NobodyOnCall(err) {
+		return oncall{}, fmt.Errorf("failed to get current oncall: %w", err)
+	}
+
+	// The team is looked up for the substitute and the shift of the handoff
+	current := oncall{member: member}
+	if member != "" {
+		current.team, current.teamErr = w.storage.GetTeam(ctx, team)
+		if current.teamErr == nil && !stale {
+			if current.member, err = w.substitute(ctx, team, current.team.Schedules, member, now); err != nil {
+				return oncall{}, err
+			}
+		}
+	}
+
+	// Schedules requiring several members hand off whenever one of them changes
+	if current.member != "" && current.teamErr == nil && !stale {
+		current.others = othersOnCall(current.team.Schedules, current.member, now)
+	}
+
+	return current, nil
+}
+
+// change returns the event of the team going from previous to the current
+// members on call: a gap when nobody is left, a handoff otherwise.
+func (w *Watcher) change(ctx context.Context, team string, current oncall, previous string, now time.Time) Event {
+	if current.member == "" {
+		event := NewEvent(KindGap, team, now)
+		event.Previous = previous
+
+		return event
+	}
+
+	event := NewEvent(KindHandoff, team, now)
+	event.Previous = previous
+	event.Current = current.member
+	event.Others = current.others
+	event.Location = w.location(ctx, current.member)
+
+	if current.teamErr != nil {
+		w.logger.Warn("failed to get team, sending handoff without its shift", zap.String("team", team), zap.Error(current.teamErr))
+	} else if shift, ok := storage.CurrentShift(current.team.Schedules, now); ok {
+		event.Schedule = shift.Schedule
+		event.ShiftEnd = shift.End
+	}
+
+	notes, err := w.storage.ListHandoffNotes(ctx, team)
+	if err != nil {
+		w.logger.Warn("failed to list handoff notes, sending handoff without its note", zap.String("team", team), zap.Error(err))
+	} else if note, ok := storage.VisibleHandoffNote(notes, now); ok {
+		event.Note = note.Text
+	}
+
+	if w.notifyObservers(team) {
+		event.Observers, err = w.observersOf(ctx, team)
+		if err != nil {
+			w.logger.Warn("failed to get team members, sending handoff without its observers", zap.String("team", team), zap.Error(err))
+		}
+	}
+
+	return event
+}
+
+// handoffReason returns why the member took over from the previous one during
+// the running shift of the schedule. A member other than the one on duty is
+// a substitute, a pinned duty an override unless an accepted swap request
+// pinned the member, and a member replaced in a manual rotation, or during a
+// shift that was already running at the previous check, a change of the
+// rotation.
+func (w *Watcher) handoffReason(ctx context.Context, team string, sched storage.Schedule, previous, member string, now time.Time) string {
+	duty, ok := sched.DutyAt(now)
+	if !ok {
+		return HandoffAutomatic
+	}
+
+	if duty.Member != member {
+		return HandoffOverride
+	}
+
+	if duty.Pinned {
+		requests, err := w.storage.ListSwapRequests(ctx, team, sched.ID)
+		if err != nil {
+			w.logger.Warn("failed to list swap requests, counting handoff as an override", zap.String("team", team), zap.Error(err))
+			return HandoffOverride
+		}
+
+		for _, request := range requests {
+			if request.Status == storage.SwapAccepted && request.To == member &&
+				!now.Before(request.ShiftStart) && now.Before(request.ShiftEnd) {
+				return HandoffSwap
+			}
+		}
+
+		return HandoffOverride
+	}
+
+	if previous != "" && (sched.Manual || !duty.Start.After(w.checked)) {
+		return HandoffManual
+	}
+
+	return HandoffAutomatic
+}
+
+// othersOnCall returns the members on call along with the member for the
+// duty running at the given instant, for schedules requiring more than one.
+func othersOnCall(schedules []storage.Schedule, member string, now time.Time) []string {
+	sched, ok := storage.ScheduleAt(schedules, now)
+	if !ok {
+		return nil
+	}
+
+	duty, ok := sched.DutyAt(now)
+	if !ok {
+		return nil
+	}
+
+	// A substitute may be one of them already
+	return slices.DeleteFunc(slices.Clone(duty.Others), func(other string) bool { return other == member })
+}
+
+// substitute returns the member taking the pages of the member on call, or
+// an empty one when every member who could take over is unavailable too, so
+// the team is reported uncovered.
+func (w *Watcher) substitute(ctx context.Context, team string, schedules []storage.Schedule, member string, now time.Time) (string, error) {
+	substitute, err := storage.Substitute(ctx, w.storage, schedules, member, now)
+	if errors.Is(err, storage.ErrAllUnavailable) {
+		w.logger.Warn("every member is unavailable", zap.String("team", team), zap.String("oncall", member))
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to substitute unavailable member: %w", err)
+	}
+
+	return substitute, nil
+}
+
+// notifyObservers reports whether the handoffs of a team name its observers.
+func (w *Watcher) notifyObservers(team string) bool {
+	if notify, ok := w.observers.Teams[team]; ok {
+		return notify
+	}
+
+	return w.observers.Handoff
+}
+
+// observersOf returns the names of the observers of a team.
+func (w *Watcher) observersOf(ctx context.Context, team string) ([]string, error) {
+	members, err := w.storage.ListTeamMembers(ctx, team)
+	if err != nil {
+		return nil, err
+	}
+
+	var observers []string
+	for _, member := range members {
+		if member.Role == storage.MemberRoleObserver {
+			observers = append(observers, member.Name)
+		}
+	}
+
+	return observers, nil
+}
+
+// leadTime returns the reminder lead time of a team, zero when its reminders are disabled.
+func (w *Watcher) leadTime(team string) time.Duration {
+	if lead, ok := w.reminders.Teams[team]; ok {
+		return lead
+	}
+
+	return w.reminders.LeadTime
+}
+
+// remind dispatches a reminder for every shift of the team starting within
+// its lead time. A reminder is marked as sent before it is dispatched, so it
+// is sent at most once even when the dispatch fails.
+func (w *Watcher) remind(ctx context.Context, team string, now time.Time) error {
+	lead := w.leadTime(team)
+	if lead <= 0 {
+		return nil
+	}
+
+	t, err := w.storage.GetTeam(ctx, team)
+	if errors.Is(err, storage.ErrTeamNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get team: %w", err)
+	}
+
+	pause, paused, err := w.storage.GetPause(ctx, team)
+	if err != nil {
+		return fmt.Errorf("failed to get pause: %w", err)
+	}
+
+	var errs []error
+	for _, shift := range storage.UpcomingShifts(t.Schedules, now, now.Add(lead)) {
+		// Nobody goes on call while the team is paused
+		if paused && pause.Active(shift.Start) {
+			continue
+		}
+
+		member, err := w.storage.GetCurrentOncall(ctx, team, shift.Start)
+		if storage.NobodyOnCall(err) {
+			continue
+		}
+		if err != nil && !errors.Is(err, storage.ErrStale) {
+			errs = append(errs, fmt.Errorf("failed to get oncall at %s: %w", shift.Start, err))
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s/%s", team, shift.Schedule, shift.Start.UTC().Format(time.RFC3339), member)
+
+		first, err := w.storage.MarkReminderSent(ctx, key)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to mark reminder %s: %w", key, err))
+			continue
+		}
+		if !first {
+			continue
+		}
+
+		if err := w.dispatcher.Dispatch(ctx, w.reminder(ctx, team, shift, member, now)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// reminder returns the reminder of the member going on call for the shift.
+func (w *Watcher) reminder(ctx context.Context, team string, shift storage.Shift, member string, now time.Time) Event {
+	event := NewEvent(KindReminder, team, now)
+	event.Schedule = shift.Schedule
+	event.Current = member
+	event.ShiftStart = shift.Start
+	event.ShiftEnd = shift.End
+	event.Location = w.location(ctx, member)
+
+	return event
+}
+
+// location returns the zone of the member the times of their notifications
+// are rendered in, UTC when it cannot be looked up.
+func (w *Watcher) location(ctx context.Context, member string) *time.Location {
+	loc, _, err := storage.MemberLocation(ctx, w.storage, member)
+	if err != nil {
+		w.logger.Warn("failed to get zone of member, rendering in UTC", zap.String("member", member), zap.Error(err))
+	}
+
+	return loc
+}
+
+// Loop checks the teams every interval until ctx is done.
+func (w *Watcher) Loop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.Check(ctx); err != nil {
+				w.logger.Error("notification check failed", zap.Error(err))
+			}
+		}
+	}
+}
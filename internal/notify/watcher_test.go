@@ -0,0 +1,402 @@
+package notify
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestWatcher(t *testing.T) (*Watcher, *recordingNotifier, *storage.MemoryStorage, *fakeClock) {
+	t.Helper()
+
+	s := storage.NewMemoryStorage()
+	n := &recordingNotifier{}
+	d, _ := newTestDispatcher(n)
+	clock := &fakeClock{now: time.Date(2025, 4, 28, 8, 0, 0, 0, time.UTC)}
+
+	w := NewWatcher(s, d, nil, &config.Config{}, zap.NewNop())
+	w.now = clock.Now
+
+	start, err := time.Parse(time.Kitchen, "9:00AM")
+	require.NoError(t, err)
+	end, err := time.Parse(time.Kitchen, "5:00PM")
+	require.NoError(t, err)
+
+	_, err = s.AddSchedule(context.Background(), "backend-team", storage.Schedule{
+		Name:    "Weekday Coverage",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   start,
+		End:     end,
+	})
+	require.NoError(t, err)
+
+	return w, n, s, clock
+}
+
+func TestWatcher_HandoffAndGap(t *testing.T) {
+	w, n, _, clock := newTestWatcher(t)
+	ctx := context.Background()
+
+	// The first check only records the state
+	require.NoError(t, w.Check(ctx))
+	assert.Empty(t, n.events)
+
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 1)
+	assert.Equal(t, KindHandoff, n.events[0].Kind)
+	assert.Equal(t, "backend-team", n.events[0].Team)
+	assert.Equal(t, "Alice", n.events[0].Current)
+	assert.Empty(t, n.events[0].Previous)
+	assert.Equal(t, "Weekday Coverage", n.events[0].Schedule)
+	assert.Equal(t, time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC), n.events[0].ShiftEnd)
+
+	// Nothing changed, nothing is sent
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	assert.Len(t, n.events, 1)
+
+	clock.Advance(8 * time.Hour)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 2)
+	assert.Equal(t, KindGap, n.events[1].Kind)
+	assert.Equal(t, "Alice", n.events[1].Previous)
+}
+
+func TestWatcher_AllUnavailable(t *testing.T) {
+	w, n, s, clock := newTestWatcher(t)
+	ctx := context.Background()
+
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	assert.Empty(t, n.events)
+
+	// Nobody can take over from Alice, the team is uncovered
+	_, err := s.AddUnavailability(ctx, storage.Unavailability{Member: "Alice", Start: clock.Now(), End: clock.Now().Add(time.Hour)})
+	require.NoError(t, err)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 1)
+	assert.Equal(t, KindGap, n.events[0].Kind)
+	assert.Equal(t, "Alice", n.events[0].Previous)
+}
+
+func TestWatcher_SkipsPausedTeams(t *testing.T) {
+	w, n, s, clock := newTestWatcher(t)
+	ctx := context.Background()
+
+	_, err := s.PauseTeam(ctx, "backend-team", storage.Pause{Since: clock.Now(), Until: clock.Now().Add(2 * time.Hour)})
+	require.NoError(t, err)
+
+	require.NoError(t, w.Check(ctx))
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	assert.Empty(t, n.events)
+
+	// The shift running when the pause ends is announced
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 1)
+	assert.Equal(t, KindHandoff, n.events[0].Kind)
+	assert.Equal(t, "Alice", n.events[0].Current)
+}
+
+func TestWatcher_RemindsOnce(t *testing.T) {
+	w, n, s, clock := newTestWatcher(t)
+	w.reminders = config.RemindersConfig{LeadTime: 30 * time.Minute}
+	ctx := context.Background()
+
+	// The 09:00 shift is still more than 30 minutes away
+	require.NoError(t, w.Check(ctx))
+	clock.Advance(29 * time.Minute)
+	require.NoError(t, w.Check(ctx))
+	assert.Empty(t, n.events)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 1)
+	assert.Equal(t, KindReminder, n.events[0].Kind)
+	assert.Equal(t, "Alice", n.events[0].Current)
+	assert.Equal(t, "Weekday Coverage", n.events[0].Schedule)
+	assert.Equal(t, time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC), n.events[0].ShiftStart)
+	assert.Equal(t, time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC), n.events[0].ShiftEnd)
+
+	clock.Advance(time.Minute)
+	require.NoError(t, w.Check(ctx))
+	assert.Len(t, n.events, 1)
+
+	// A restarted watcher finds the reminder in storage
+	d, _ := newTestDispatcher(n)
+	restarted := NewWatcher(s, d, nil, &config.Config{Notify: config.NotifyConfig{Reminders: w.reminders}}, zap.NewNop())
+	restarted.now = clock.Now
+
+	require.NoError(t, restarted.Check(ctx))
+	assert.Len(t, n.events, 1)
+}
+
+func TestWatcher_MemberLocation(t *testing.T) {
+	w, n, s, clock := newTestWatcher(t)
+	w.reminders = config.RemindersConfig{LeadTime: 30 * time.Minute}
+	ctx := context.Background()
+
+	_, _, err := s.AddUser(ctx, storage.User{UserName: "Alice", Active: true, Timezone: "Asia/Tehran"})
+	require.NoError(t, err)
+
+	clock.Advance(30 * time.Minute)
+	require.NoError(t, w.Check(ctx))
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+
+	require.Len(t, n.events, 2)
+	assert.Equal(t, KindReminder, n.events[0].Kind)
+	assert.Equal(t, KindHandoff, n.events[1].Kind)
+	for _, event := range n.events {
+		require.NotNil(t, event.Location)
+		assert.Equal(t, "Asia/Tehran", event.Location.String())
+	}
+	assert.Equal(t, "12:30", n.events[0].Local(n.events[0].ShiftStart).Format("15:04"))
+}
+
+func TestWatcher_ReminderLeadTimeOverride(t *testing.T) {
+	w, n, _, clock := newTestWatcher(t)
+	w.reminders = config.RemindersConfig{
+		LeadTime: 2 * time.Hour,
+		Teams:    map[string]time.Duration{"backend-team": 0},
+	}
+
+	clock.Advance(30 * time.Minute)
+	require.NoError(t, w.Check(context.Background()))
+	assert.Empty(t, n.events)
+}
+
+func TestWatcher_NotifiesObservers(t *testing.T) {
+	w, n, s, clock := newTestWatcher(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Dave", "Carol"} {
+		_, _, err := s.SetTeamMember(ctx, "backend-team", storage.TeamMember{Name: name, Role: storage.MemberRoleObserver})
+		require.NoError(t, err)
+	}
+
+	// Observers are only named when the team notifies them
+	require.NoError(t, w.Check(ctx))
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 1)
+	assert.Empty(t, n.events[0].Observers)
+
+	w.observers = config.ObserversConfig{Teams: map[string]bool{"backend-team": true}}
+
+	clock.Advance(8 * time.Hour)
+	require.NoError(t, w.Check(ctx))
+	// The shift of the next Monday
+	clock.Advance(7*24*time.Hour - 8*time.Hour)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 3)
+	assert.Equal(t, KindHandoff, n.events[2].Kind)
+	assert.Equal(t, []string{"Carol", "Dave"}, n.events[2].Observers)
+}
+
+func TestWatcher_HandoffNote(t *testing.T) {
+	w, n, s, clock := newTestWatcher(t)
+	ctx := context.Background()
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+
+	// A note left on the previous Monday's shift, shown until this one ends
+	previous := time.Date(2025, 4, 21, 9, 0, 0, 0, time.UTC)
+	_, _, err = s.AddHandoffNote(ctx, "backend-team", storage.HandoffNote{
+		ScheduleID: team.Schedules[0].ID,
+		Member:     "Alice",
+		ShiftStart: previous,
+		ShiftEnd:   previous.Add(8 * time.Hour),
+		Text:       "deploy freeze until Monday",
+		ExpiresAt:  time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, w.Check(ctx))
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 1)
+	assert.Equal(t, "deploy freeze until Monday", n.events[0].Note)
+
+	// The note expired with the shift
+	clock.Advance(8 * time.Hour)
+	require.NoError(t, w.Check(ctx))
+	clock.Advance(7*24*time.Hour - 8*time.Hour)
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, n.events, 3)
+	assert.Equal(t, KindHandoff, n.events[2].Kind)
+	assert.Empty(t, n.events[2].Note)
+}
+
+func TestWatcher_RequiredCount(t *testing.T) {
+	w, n, s, clock := newTestWatcher(t)
+	ctx := context.Background()
+
+	sched := storage.Schedule{
+		Name:          "Critical",
+		Members:       []string{"Alice", "Bob", "Charlie"},
+		Days:          []time.Weekday{time.Monday},
+		Anchor:        time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		RequiredCount: 2,
+	}
+	sched.Start, _ = time.Parse(time.Kitchen, "9:00AM")
+	sched.End, _ = time.Parse(time.Kitchen, "5:00PM")
+	_, err := s.AddSchedule(ctx, "pair-team", sched)
+	require.NoError(t, err)
+
+	pairEvents := func() []Event {
+		var events []Event
+		for _, event := range n.events {
+			if event.Team == "pair-team" {
+				events = append(events, event)
+			}
+		}
+		return events
+	}
+
+	require.NoError(t, w.Check(ctx))
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+
+	events := pairEvents()
+	require.Len(t, events, 1)
+	assert.Equal(t, "Alice", events[0].Current)
+	assert.Equal(t, []string{"Bob"}, events[0].Others)
+	assert.ElementsMatch(t, []string{"Alice", "Bob"}, oncallSeries(t, "pair-team"))
+
+	// Next week the pair moves on, Bob staying on call with Charlie
+	clock.Advance(8 * time.Hour)
+	require.NoError(t, w.Check(ctx))
+	clock.Advance(7*24*time.Hour - 8*time.Hour)
+	require.NoError(t, w.Check(ctx))
+
+	events = pairEvents()
+	require.Len(t, events, 3)
+	assert.Equal(t, KindGap, events[1].Kind)
+	assert.Equal(t, KindHandoff, events[2].Kind)
+	assert.Equal(t, "Bob", events[2].Current)
+	assert.Equal(t, []string{"Charlie"}, events[2].Others)
+	assert.ElementsMatch(t, []string{"Bob", "Charlie"}, oncallSeries(t, "pair-team"))
+}
+
+// oncallSeries scrapes the members the on-call gauge holds for the team.
+func oncallSeries(t *testing.T, team string) []string {
+	t.Helper()
+
+	reg := prometheus.NewPedanticRegistry()
+	require.NoError(t, reg.Register(oncallCurrent))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	members := []string{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			if labels["team"] == team {
+				assert.InDelta(t, 1, metric.GetGauge().GetValue(), 0)
+				members = append(members, labels["member"])
+			}
+		}
+	}
+
+	return members
+}
+
+func TestWatcher_HandoffMetrics(t *testing.T) {
+	w, _, s, clock := newTestWatcher(t)
+	ctx := context.Background()
+
+	const team = "metrics-team"
+	day := storage.Schedule{
+		Name:    "Day",
+		Members: []string{"Alice", "Bob"},
+		Days:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday},
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+	}
+	day.Start, _ = time.Parse(time.Kitchen, "9:00AM")
+	day.End, _ = time.Parse(time.Kitchen, "5:00PM")
+	_, err := s.AddSchedule(ctx, team, day)
+	require.NoError(t, err)
+
+	t2, err := s.GetTeam(ctx, team)
+	require.NoError(t, err)
+	id := t2.Schedules[0].ID
+
+	count := func(reason string) float64 {
+		return testutil.ToFloat64(handoffs.WithLabelValues(team, "Day", reason))
+	}
+
+	// 08:00 Monday, nobody is on call yet
+	require.NoError(t, w.Check(ctx))
+	assert.Empty(t, oncallSeries(t, team))
+
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	assert.InDelta(t, 1, count(HandoffAutomatic), 0)
+	assert.Equal(t, []string{"Alice"}, oncallSeries(t, team))
+
+	// Bob is put on duty during the running shift
+	_, err = s.SetRotation(ctx, team, id, storage.Rotation{Anchor: day.Anchor, Offset: 1})
+	require.NoError(t, err)
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	assert.InDelta(t, 1, count(HandoffManual), 0)
+	assert.Equal(t, []string{"Bob"}, oncallSeries(t, team))
+
+	// The shift ends
+	clock.Advance(8 * time.Hour)
+	require.NoError(t, w.Check(ctx))
+	assert.Empty(t, oncallSeries(t, team))
+
+	// Alice takes the Tuesday shift of Bob over
+	request, _, err := s.AddSwapRequest(ctx, team, storage.SwapRequest{
+		ScheduleID: id,
+		ShiftStart: time.Date(2025, 4, 29, 9, 0, 0, 0, time.UTC),
+		ShiftEnd:   time.Date(2025, 4, 29, 17, 0, 0, 0, time.UTC),
+		From:       "Bob",
+		To:         "Alice",
+		TokenHash:  "hash",
+	})
+	require.NoError(t, err)
+	_, _, err = s.DecideSwapRequest(ctx, request.ID, true, clock.Now())
+	require.NoError(t, err)
+
+	clock.now = time.Date(2025, 4, 29, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, w.Check(ctx))
+	assert.InDelta(t, 1, count(HandoffSwap), 0)
+	assert.Equal(t, []string{"Alice"}, oncallSeries(t, team))
+
+	// Carol is pinned to the running shift
+	_, _, err = s.AddPin(ctx, team, id, storage.Pin{Date: time.Date(2025, 4, 29, 0, 0, 0, 0, time.UTC), Member: "Carol"})
+	require.NoError(t, err)
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	assert.InDelta(t, 1, count(HandoffOverride), 0)
+	assert.Equal(t, []string{"Carol"}, oncallSeries(t, team))
+
+	// Deleted teams leave the gauge
+	require.NoError(t, s.DeleteTeam(ctx, team))
+	clock.Advance(time.Hour)
+	require.NoError(t, w.Check(ctx))
+	assert.Empty(t, oncallSeries(t, team))
+	assert.InDelta(t, 1, count(HandoffAutomatic), 0)
+}
@@ -0,0 +1,197 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/pkg/webhook"
+)
+
+// Webhooks delivers events to the webhook subscriptions in storage. Every
+// delivery carries the event ID, which stays the same across retries, so
+// receivers can drop the duplicates a retry may cause. Deliveries of
+// subscriptions with a secret are signed, see the webhook package.
+type Webhooks struct {
+	client  HTTPClient
+	storage storage.Storage
+	now     func() time.Time
+}
+
+// webhookPayload is the body of a webhook delivery.
+type webhookPayload struct {
+	ID         string     `json:"id"`
+	Kind       Kind       `json:"kind"`
+	Team       string     `json:"team"`
+	Schedule   string     `json:"schedule,omitempty"`
+	Change     string     `json:"change,omitempty"`
+	Previous   string     `json:"previous,omitempty"`
+	Current    string     `json:"current,omitempty"`
+	Others     []string   `json:"others,omitempty"`
+	ShiftStart *time.Time `json:"shift_start,omitempty"`
+	ShiftEnd   *time.Time `json:"shift_end,omitempty"`
+	GapStart   *time.Time `json:"gap_start,omitempty"`
+	GapEnd     *time.Time `json:"gap_end,omitempty"`
+	Summary    string     `json:"summary,omitempty"`
+	Observers  []string   `json:"observers,omitempty"`
+	Note       string     `json:"note,omitempty"`
+	Link       string     `json:"link,omitempty"`
+	At         time.Time  `json:"at"`
+}
+
+// NewWebhooks creates a notifier for the webhook subscriptions in storage.
+func NewWebhooks(client HTTPClient, s storage.Storage) *Webhooks {
+	return &Webhooks{
+		client:  client,
+		storage: s,
+		now:     time.Now,
+	}
+}
+
+// Name returns the name of the notifier.
+func (w *Webhooks) Name() string {
+	return "webhook"
+}
+
+// Render renders the delivery of the event to every subscription that
+// matches it, signed like it is sent.
+func (w *Webhooks) Render(ctx context.Context, event Event) ([]Delivery, error) {
+	subscriptions, body, err := w.prepare(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]Delivery, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		deliveries = append(deliveries, w.delivery(sub, event, body))
+	}
+
+	return deliveries, nil
+}
+
+// Notify delivers the event to every subscription that matches it, see
+// Render. The result is only permanent when every failed delivery is.
+func (w *Webhooks) Notify(ctx context.Context, event Event) error {
+	subscriptions, body, err := w.prepare(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	var permanent, transient []error
+	for _, sub := range subscriptions {
+		if err := w.send(ctx, w.delivery(sub, event, body)); err != nil {
+			err = fmt.Errorf("webhook %d: %w", sub.ID, err)
+			if IsPermanent(err) {
+				permanent = append(permanent, err)
+			} else {
+				transient = append(transient, err)
+			}
+		}
+	}
+
+	if len(transient) > 0 {
+		// A permanent failure must not stop the transient ones from being retried
+		for _, err := range permanent {
+			transient = append(transient, errors.New(err.Error()))
+		}
+		return errors.Join(transient...)
+	}
+
+	if len(permanent) > 0 {
+		return Permanent(errors.Join(permanent...))
+	}
+
+	return nil
+}
+
+// prepare returns the subscriptions matching the event along with its
+// encoded payload.
+func (w *Webhooks) prepare(ctx context.Context, event Event) ([]storage.Webhook, []byte, error) {
+	subscriptions, err := w.storage.ListWebhooks(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	subscriptions = slices.DeleteFunc(subscriptions, func(sub storage.Webhook) bool {
+		return !sub.Matches(string(event.Kind), event.Team)
+	})
+
+	payload := webhookPayload{
+		ID:        event.ID,
+		Kind:      event.Kind,
+		Team:      event.Team,
+		Schedule:  event.Schedule,
+		Change:    event.Change,
+		Previous:  event.Previous,
+		Current:   event.Current,
+		Others:    event.Others,
+		Summary:   event.Summary,
+		Observers: event.Observers,
+		Note:      event.Note,
+		Link:      event.Link,
+		At:        event.At.UTC(),
+	}
+	if !event.ShiftStart.IsZero() {
+		start := event.ShiftStart.UTC()
+		payload.ShiftStart = &start
+	}
+	if !event.ShiftEnd.IsZero() {
+		end := event.ShiftEnd.UTC()
+		payload.ShiftEnd = &end
+	}
+	if !event.GapStart.IsZero() {
+		start, end := event.GapStart.UTC(), event.GapEnd.UTC()
+		payload.GapStart, payload.GapEnd = &start, &end
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, Permanent(fmt.Errorf("failed to encode event: %w", err))
+	}
+
+	return subscriptions, body, nil
+}
+
+// delivery returns the delivery of the encoded event to a single
+// subscription, with its headers.
+func (w *Webhooks) delivery(sub storage.Webhook, event Event, body []byte) Delivery {
+	timestamp := strconv.FormatInt(w.now().Unix(), 10)
+
+	header := make(http.Header)
+	header.Set(webhook.HeaderEventID, event.ID)
+	header.Set(webhook.HeaderTimestamp, timestamp)
+	if sub.Secret != "" {
+		header.Set(webhook.HeaderSignature, webhook.Sign(sub.Secret, timestamp, body))
+	}
+
+	return Delivery{
+		Channel:   w.Name(),
+		Recipient: sub.URL,
+		Subject:   string(event.Kind),
+		Body:      string(body),
+		target:    sub.URL,
+		payload:   body,
+		header:    header,
+	}
+}
+
+// send posts a delivery to its subscription.
+func (w *Webhooks) send(ctx context.Context, delivery Delivery) error {
+	status, respBody, err := post(ctx, w.client, delivery.target, delivery.payload, delivery.header)
+	if err != nil {
+		return err
+	}
+
+	if status < 200 || status > 299 {
+		return statusError(status, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWebhooks(t *testing.T, client HTTPClient, subscriptions ...storage.Webhook) (*Webhooks, time.Time) {
+	t.Helper()
+
+	s := storage.NewMemoryStorage()
+	for _, sub := range subscriptions {
+		_, err := s.AddWebhook(context.Background(), sub)
+		require.NoError(t, err)
+	}
+
+	now := time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC)
+	w := NewWebhooks(client, s)
+	w.now = func() time.Time { return now }
+
+	return w, now
+}
+
+func TestWebhooks_SignsDeliveries(t *testing.T) {
+	client := &fakeClient{}
+	w, now := newTestWebhooks(t, client, storage.Webhook{URL: "https://hooks.test/oncall", Secret: "s3cret"})
+
+	event := handoff()
+	require.NoError(t, w.Notify(context.Background(), event))
+
+	require.Len(t, client.requests, 1)
+	req := client.requests[0]
+	assert.Equal(t, "https://hooks.test/oncall", req.URL.String())
+	assert.Equal(t, event.ID, req.Header.Get(webhook.HeaderEventID))
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), req.Header.Get(webhook.HeaderTimestamp))
+
+	body := []byte(client.bodies[0])
+	signature := req.Header.Get(webhook.HeaderSignature)
+	timestamp := req.Header.Get(webhook.HeaderTimestamp)
+	require.NoError(t, webhook.VerifyAt("s3cret", timestamp, body, signature, now, webhook.DefaultTolerance))
+	assert.ErrorIs(t, webhook.VerifyAt("wrong", timestamp, body, signature, now, webhook.DefaultTolerance), webhook.ErrInvalidSignature)
+
+	assert.JSONEq(t, `{
+		"id": "`+event.ID+`",
+		"kind": "handoff",
+		"team": "backend-team",
+		"schedule": "Weekday Coverage",
+		"previous": "Alice",
+		"current": "Bob",
+		"shift_end": "2025-04-28T17:00:00Z",
+		"at": "2025-04-28T09:00:00Z"
+	}`, client.bodies[0])
+}
+
+func TestWebhooks_UnsignedDeliveries(t *testing.T) {
+	client := &fakeClient{}
+	w, _ := newTestWebhooks(t, client, storage.Webhook{URL: "https://hooks.test/oncall"})
+
+	require.NoError(t, w.Notify(context.Background(), handoff()))
+
+	require.Len(t, client.requests, 1)
+	assert.Empty(t, client.requests[0].Header.Get(webhook.HeaderSignature))
+	assert.NotEmpty(t, client.requests[0].Header.Get(webhook.HeaderEventID))
+}
+
+func TestWebhooks_OnlyMatchingSubscriptions(t *testing.T) {
+	client := &fakeClient{}
+	w, _ := newTestWebhooks(t, client,
+		storage.Webhook{URL: "https://hooks.test/all", Secret: "a"},
+		storage.Webhook{URL: "https://hooks.test/frontend", Secret: "b", Team: "frontend-team"},
+		storage.Webhook{URL: "https://hooks.test/gaps", Secret: "c", Events: []string{"gap"}},
+	)
+
+	require.NoError(t, w.Notify(context.Background(), handoff()))
+
+	require.Len(t, client.requests, 1)
+	assert.Equal(t, "https://hooks.test/all", client.requests[0].URL.String())
+}
+
+func TestWebhooks_TransientFailuresAreRetried(t *testing.T) {
+	client := &fakeClient{responses: []fakeResponse{
+		{status: http.StatusGone, body: "gone"},
+		{status: http.StatusServiceUnavailable},
+	}}
+	w, _ := newTestWebhooks(t, client,
+		storage.Webhook{URL: "https://hooks.test/gone", Secret: "a"},
+		storage.Webhook{URL: "https://hooks.test/down", Secret: "b"},
+	)
+
+	err := w.Notify(context.Background(), handoff())
+	require.Error(t, err)
+	assert.False(t, IsPermanent(err))
+
+	client = &fakeClient{responses: []fakeResponse{{status: http.StatusGone, body: "gone"}}}
+	w, _ = newTestWebhooks(t, client, storage.Webhook{URL: "https://hooks.test/gone", Secret: "a"})
+
+	err = w.Notify(context.Background(), handoff())
+	require.Error(t, err)
+	assert.True(t, IsPermanent(err))
+}
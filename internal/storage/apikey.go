@@ -0,0 +1,21 @@
+package storage
+
+import "time"
+
+// APIKey authenticates API clients with a role. Only the hex SHA-256 Hash of
+// the key is stored. A zero ExpiresAt means it never expires, revoked keys
+// are kept with RevokedAt set so the audit log can still be followed.
+type APIKey struct {
+	ID        int64
+	Name      string
+	Role      string
+	Hash      string
+	ExpiresAt time.Time
+	RevokedAt time.Time
+	CreatedAt time.Time
+}
+
+// Valid reports whether the key is neither revoked nor expired at the given instant.
+func (k APIKey) Valid(at time.Time) bool {
+	return k.RevokedAt.IsZero() && (k.ExpiresAt.IsZero() || at.Before(k.ExpiresAt))
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// AnonymousActor is the actor of changes made without an authenticated caller.
+const AnonymousActor = "anonymous"
+
+// Audit log actions.
+const (
+	AuditPauseTeam   = "team.pause"
+	AuditUnpauseTeam = "team.unpause"
+	AuditFreezeTeam  = "team.freeze"
+	// AuditCancelFreeze records a canceled freeze, with its ID as the detail.
+	AuditCancelFreeze = "team.freeze.cancel"
+	// AuditOverrideFreeze records a change forced through a freeze, with the
+	// change as the detail.
+	AuditOverrideFreeze = "team.freeze.override"
+	AuditDeleteTeam     = "team.delete"
+	// AuditMergeTeam records a team merged into another, in the audit log of
+	// both, with the teams and renamed schedules as the detail.
+	AuditMergeTeam = "team.merge"
+	// AuditSetParent records a set or cleared parent of a team, with the
+	// parent as the detail.
+	AuditSetParent = "team.parent"
+	// AuditSetPublicCoverage records enabled or disabled public coverage of
+	// a team.
+	AuditSetPublicCoverage = "team.public_coverage"
+	// AuditSetGoogleCalendar records a set or cleared Google calendar of a
+	// team, with its ID as the detail.
+	AuditSetGoogleCalendar = "team.google_calendar"
+	// AuditAddBlackout records an added blackout, with its window and reason
+	// as the detail.
+	AuditAddBlackout = "team.blackout"
+	// AuditCancelBlackout records a canceled blackout, with its ID as the detail.
+	AuditCancelBlackout = "team.blackout.cancel"
+	// AuditAddHandoffNote records an added handoff note, with its ID, member,
+	// shift and text as the detail.
+	AuditAddHandoffNote = "team.handoff_note"
+	// AuditUpdateHandoffNote records a changed handoff note, with its ID,
+	// member, shift and new text as the detail.
+	AuditUpdateHandoffNote = "team.handoff_note.update"
+	// AuditDeleteHandoffNote records a deleted handoff note, with its ID as
+	// the detail.
+	AuditDeleteHandoffNote = "team.handoff_note.delete"
+	// AuditSetTeamMember records an added team member or a changed role,
+	// with the member and role as the detail.
+	AuditSetTeamMember = "team.member.set"
+	// AuditRemoveTeamMember records a removed team member, with its name as the detail.
+	AuditRemoveTeamMember = "team.member.remove"
+	// AuditSetGroup records a created or changed group, with its name and
+	// members as the detail.
+	AuditSetGroup = "team.group.set"
+	// AuditDeleteGroup records a deleted group, with its name as the detail.
+	AuditDeleteGroup = "team.group.delete"
+	// AuditRenameMember records a renamed member, with the former and new
+	// names as the detail.
+	AuditRenameMember = "member.rename"
+	// AuditDeleteSchedule records a soft-deleted schedule, with its name as the detail.
+	AuditDeleteSchedule = "schedule.delete"
+	// AuditUpdateSchedule records changed fields of a schedule, with their
+	// names in the detail.
+	AuditUpdateSchedule = "schedule.update"
+	// AuditSetRotation records a changed rotation of a schedule, manual
+	// handoffs included, with the member it puts on duty in the detail.
+	AuditSetRotation = "schedule.rotation"
+	// AuditAcceptSwap records an accepted swap request, with the members and
+	// the shift in the detail.
+	AuditAcceptSwap = "schedule.swap.accept"
+)
+
+// AuditEntry records a change made through the storage layer.
+type AuditEntry struct {
+	At     time.Time
+	Actor  string
+	Action string
+	Team   string
+	Detail string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context that attributes the changes made with it to actor.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor of ctx, or AnonymousActor when there is none.
+func ActorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return AnonymousActor
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Blackout is a window during which nobody is expected on call for a team,
+// e.g. a new-year shutdown. Blackouts do not remove coverage, lookups still
+// answer from the schedules, they only keep uncovered time inside them from
+// counting as gaps.
+type Blackout struct {
+	ID        int64
+	Reason    string
+	Start     time.Time
+	End       time.Time
+	CreatedAt time.Time
+}
+
+// auditDetail describes the blackout for the audit log.
+func (b Blackout) auditDetail() string {
+	detail := fmt.Sprintf("from %s until %s", b.Start.UTC().Format(time.RFC3339), b.End.UTC().Format(time.RFC3339))
+	if b.Reason != "" {
+		detail += fmt.Sprintf(": %s", b.Reason)
+	}
+
+	return detail
+}
+
+// WithoutBlackouts cuts the blackouts out of the gaps, keeping their order.
+// Gaps partially inside a blackout keep the part outside it.
+func WithoutBlackouts(gaps []Gap, blackouts []Blackout) []Gap {
+	for _, blackout := range blackouts {
+		var result []Gap
+
+		for _, gap := range gaps {
+			if !gap.End.After(blackout.Start) || !gap.Start.Before(blackout.End) {
+				result = append(result, gap)
+				continue
+			}
+
+			if gap.Start.Before(blackout.Start) {
+				result = append(result, Gap{Start: gap.Start, End: blackout.Start})
+			}
+			if blackout.End.Before(gap.End) {
+				result = append(result, Gap{Start: blackout.End, End: gap.End})
+			}
+		}
+
+		gaps = result
+	}
+
+	return gaps
+}
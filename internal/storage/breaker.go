@@ -0,0 +1,1106 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// ErrCircuitOpen is returned while the breaker is open and no fallback is available.
+	ErrCircuitOpen = errors.New("storage circuit breaker is open")
+	// ErrStale is returned together with a cached answer that was served
+	// because the underlying storage is unavailable.
+	ErrStale = errors.New("storage unavailable, serving stale data")
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerHalfOpen
+	BreakerOpen
+)
+
+// String returns the state name used in logs and metrics.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerHalfOpen:
+		return "half-open"
+	case BreakerOpen:
+		return "open"
+	default:
+		return "closed"
+	}
+}
+
+var (
+	breakerState = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "oncall_storage_breaker_state",
+		Help: "State of the storage circuit breaker (0 closed, 1 half-open, 2 open).",
+	})
+	breakerTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "oncall_storage_breaker_transitions_total",
+		Help: "Number of storage circuit breaker state transitions by target state.",
+	}, []string{"state"})
+	staleResponses = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "oncall_storage_stale_responses_total",
+		Help: "Number of on-call lookups answered from the stale cache.",
+	})
+)
+
+// BreakerStorage wraps a Storage with a circuit breaker. After threshold
+// consecutive failures the breaker opens: mutations fail fast with
+// ErrCircuitOpen and GetCurrentOncall serves the last known-good answer of the
+// team along with ErrStale. Once the cooldown has passed a single probe call is
+// let through, closing the breaker on success and reopening it on failure.
+type BreakerStorage struct {
+	next      Storage
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+	// lastGood holds the last found on-call member of each team.
+	lastGood map[string]string
+}
+
+// NewBreakerStorage wraps next with a circuit breaker.
+func NewBreakerStorage(next Storage, threshold int, cooldown time.Duration) *BreakerStorage {
+	breakerState.Set(float64(BreakerClosed))
+
+	return &BreakerStorage{
+		next:      next,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+		lastGood:  make(map[string]string),
+	}
+}
+
+// State returns the current breaker state.
+func (s *BreakerStorage) State() BreakerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.current()
+}
+
+// RetryAt returns when the breaker lets the next probe through while it is
+// open, without calling the storage. It is false once the breaker is closed
+// or half-open.
+func (s *BreakerStorage) RetryAt() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current() != BreakerOpen {
+		return time.Time{}, false
+	}
+
+	return s.openedAt.Add(s.cooldown), true
+}
+
+// AddSchedule adds a schedule unless the breaker is open.
+func (s *BreakerStorage) AddSchedule(ctx context.Context, team string, schedule Schedule) (string, error) {
+	if !s.allow() {
+		return "", ErrCircuitOpen
+	}
+
+	id, err := s.next.AddSchedule(ctx, team, schedule)
+	s.record(err)
+	return id, err
+}
+
+// GetTeam retrieves a team unless the breaker is open.
+func (s *BreakerStorage) GetTeam(ctx context.Context, team string) (Team, error) {
+	if !s.allow() {
+		return Team{}, ErrCircuitOpen
+	}
+
+	t, err := s.next.GetTeam(ctx, team)
+	s.record(err)
+	return t, err
+}
+
+// ListTeams lists the teams unless the breaker is open.
+func (s *BreakerStorage) ListTeams(ctx context.Context) ([]string, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	names, err := s.next.ListTeams(ctx)
+	s.record(err)
+	return names, err
+}
+
+// DeleteTeam deletes a team unless the breaker is open.
+func (s *BreakerStorage) DeleteTeam(ctx context.Context, team string) error {
+	if !s.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := s.next.DeleteTeam(ctx, team)
+	s.record(err)
+	if err == nil {
+		// A team created with the name must not be answered for by this one
+		s.mu.Lock()
+		delete(s.lastGood, team)
+		s.mu.Unlock()
+	}
+	return err
+}
+
+// PauseTeam pauses a team unless the breaker is open.
+func (s *BreakerStorage) PauseTeam(ctx context.Context, team string, pause Pause) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	found, err := s.next.PauseTeam(ctx, team, pause)
+	s.record(err)
+	return found, err
+}
+
+// UnpauseTeam unpauses a team unless the breaker is open.
+func (s *BreakerStorage) UnpauseTeam(ctx context.Context, team string) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	found, err := s.next.UnpauseTeam(ctx, team)
+	s.record(err)
+	return found, err
+}
+
+// GetPause retrieves the pause of a team unless the breaker is open.
+func (s *BreakerStorage) GetPause(ctx context.Context, team string) (Pause, bool, error) {
+	if !s.allow() {
+		return Pause{}, false, ErrCircuitOpen
+	}
+
+	pause, found, err := s.next.GetPause(ctx, team)
+	s.record(err)
+	return pause, found, err
+}
+
+// AuditLog retrieves the audit log of a team unless the breaker is open.
+func (s *BreakerStorage) AuditLog(ctx context.Context, team string) ([]AuditEntry, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	entries, err := s.next.AuditLog(ctx, team)
+	s.record(err)
+	return entries, err
+}
+
+// DeleteExpiredSchedules deletes the expired schedules unless the breaker is open.
+func (s *BreakerStorage) DeleteExpiredSchedules(ctx context.Context, before time.Time) (int, error) {
+	if !s.allow() {
+		return 0, ErrCircuitOpen
+	}
+
+	deleted, err := s.next.DeleteExpiredSchedules(ctx, before)
+	s.record(err)
+	return deleted, err
+}
+
+// PurgeExpiredPauses deletes the expired pauses unless the breaker is open.
+func (s *BreakerStorage) PurgeExpiredPauses(ctx context.Context, before time.Time) (int, error) {
+	if !s.allow() {
+		return 0, ErrCircuitOpen
+	}
+
+	purged, err := s.next.PurgeExpiredPauses(ctx, before)
+	s.record(err)
+	return purged, err
+}
+
+// TrimAuditLog trims the audit log unless the breaker is open.
+func (s *BreakerStorage) TrimAuditLog(ctx context.Context, before time.Time) (int, error) {
+	if !s.allow() {
+		return 0, ErrCircuitOpen
+	}
+
+	trimmed, err := s.next.TrimAuditLog(ctx, before)
+	s.record(err)
+	return trimmed, err
+}
+
+// AddWebhook stores a webhook subscription unless the breaker is open.
+func (s *BreakerStorage) AddWebhook(ctx context.Context, webhook Webhook) (Webhook, error) {
+	if !s.allow() {
+		return Webhook{}, ErrCircuitOpen
+	}
+
+	added, err := s.next.AddWebhook(ctx, webhook)
+	s.record(err)
+	return added, err
+}
+
+// ListWebhooks lists the webhook subscriptions unless the breaker is open.
+func (s *BreakerStorage) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	webhooks, err := s.next.ListWebhooks(ctx)
+	s.record(err)
+	return webhooks, err
+}
+
+// DeleteWebhook removes a webhook subscription unless the breaker is open.
+func (s *BreakerStorage) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	deleted, err := s.next.DeleteWebhook(ctx, id)
+	s.record(err)
+	return deleted, err
+}
+
+// MarkReminderSent records a sent reminder unless the breaker is open.
+func (s *BreakerStorage) MarkReminderSent(ctx context.Context, key string) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	marked, err := s.next.MarkReminderSent(ctx, key)
+	s.record(err)
+	return marked, err
+}
+
+// GetCurrentOncall looks up the on-call member and remembers found answers.
+// When the lookup is rejected or fails the last known-good answer of the team,
+// if any, is returned with ErrStale. Unknown teams and uncovered times are
+// answers, returned as they are.
+func (s *BreakerStorage) GetCurrentOncall(ctx context.Context, team string, at time.Time) (string, error) {
+	if !s.allow() {
+		return s.stale(team, ErrCircuitOpen)
+	}
+
+	oncall, err := s.next.GetCurrentOncall(ctx, team, at)
+	s.record(err)
+	if absent(err) {
+		return "", err
+	}
+	if err != nil {
+		return s.stale(team, err)
+	}
+
+	s.mu.Lock()
+	s.lastGood[team] = oncall
+	s.mu.Unlock()
+
+	return oncall, nil
+}
+
+// stale returns the cached answer of a team, or err when there is none.
+func (s *BreakerStorage) stale(team string, err error) (string, error) {
+	// A canceled request says nothing about the storage, so it is not papered over
+	if errors.Is(err, context.Canceled) {
+		return "", err
+	}
+
+	s.mu.Lock()
+	oncall, ok := s.lastGood[team]
+	s.mu.Unlock()
+
+	if !ok {
+		return "", err
+	}
+
+	staleResponses.Inc()
+	return oncall, ErrStale
+}
+
+// allow reports whether a call may go through, claiming the probe slot when
+// the breaker is half-open.
+func (s *BreakerStorage) allow() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch s.current() {
+	case BreakerOpen:
+		return false
+	case BreakerHalfOpen:
+		if s.probing {
+			return false
+		}
+		s.probing = true
+	}
+
+	return true
+}
+
+// answered reports whether the error is an answer of a healthy storage: a
+// lookup finding nothing, a quota rejection, a decided swap request, a group
+// in use, a taken name or a rejected merge.
+func answered(err error) bool {
+	var quotaErr *QuotaError
+	var conflictErr *MergeConflictError
+
+	return absent(err) || errors.As(err, &quotaErr) || errors.Is(err, ErrSwapDecided) || errors.Is(err, ErrGroupInUse) ||
+		errors.Is(err, ErrMemberExists) || errors.As(err, &conflictErr) || errors.Is(err, ErrMergeSelf) ||
+		errors.Is(err, ErrParentCycle) || errors.Is(err, ErrHandoffNoteExists) || errors.Is(err, ErrScheduleTimes) ||
+		errors.Is(err, ErrScheduleExists)
+}
+
+// record updates the breaker with the outcome of a call.
+func (s *BreakerStorage) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	probe := s.probing
+	s.probing = false
+
+	// Canceled calls are neither a success nor a failure of the storage
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if err == nil || answered(err) {
+		s.failures = 0
+		s.transition(BreakerClosed)
+		return
+	}
+
+	s.failures++
+	if probe || s.failures >= s.threshold {
+		s.openedAt = s.now()
+		s.transition(BreakerOpen)
+	}
+}
+
+// current returns the state, moving an open breaker whose cooldown has passed
+// to half-open. The caller must hold mu.
+func (s *BreakerStorage) current() BreakerState {
+	if s.state == BreakerOpen && !s.now().Before(s.openedAt.Add(s.cooldown)) {
+		s.transition(BreakerHalfOpen)
+	}
+
+	return s.state
+}
+
+// transition moves the breaker to state. The caller must hold mu.
+func (s *BreakerStorage) transition(state BreakerState) {
+	if s.state == state {
+		return
+	}
+
+	breakerTransitions.WithLabelValues(state.String()).Inc()
+	s.state = state
+	breakerState.Set(float64(state))
+}
+
+// AddCalendarToken stores a calendar feed token unless the breaker is open.
+func (s *BreakerStorage) AddCalendarToken(ctx context.Context, token CalendarToken) (CalendarToken, error) {
+	if !s.allow() {
+		return CalendarToken{}, ErrCircuitOpen
+	}
+
+	added, err := s.next.AddCalendarToken(ctx, token)
+	s.record(err)
+	return added, err
+}
+
+// GetCalendarToken returns a calendar feed token unless the breaker is open.
+func (s *BreakerStorage) GetCalendarToken(ctx context.Context, hash string) (CalendarToken, bool, error) {
+	if !s.allow() {
+		return CalendarToken{}, false, ErrCircuitOpen
+	}
+
+	token, found, err := s.next.GetCalendarToken(ctx, hash)
+	s.record(err)
+	return token, found, err
+}
+
+// DeleteCalendarToken revokes a calendar feed token unless the breaker is open.
+func (s *BreakerStorage) DeleteCalendarToken(ctx context.Context, team string, id int64) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	deleted, err := s.next.DeleteCalendarToken(ctx, team, id)
+	s.record(err)
+	return deleted, err
+}
+
+// AddAPIKey stores an API key unless the breaker is open.
+func (s *BreakerStorage) AddAPIKey(ctx context.Context, key APIKey) (APIKey, error) {
+	if !s.allow() {
+		return APIKey{}, ErrCircuitOpen
+	}
+
+	added, err := s.next.AddAPIKey(ctx, key)
+	s.record(err)
+	return added, err
+}
+
+// GetAPIKey returns an API key unless the breaker is open.
+func (s *BreakerStorage) GetAPIKey(ctx context.Context, hash string) (APIKey, bool, error) {
+	if !s.allow() {
+		return APIKey{}, false, ErrCircuitOpen
+	}
+
+	key, found, err := s.next.GetAPIKey(ctx, hash)
+	s.record(err)
+	return key, found, err
+}
+
+// ListAPIKeys lists the API keys unless the breaker is open.
+func (s *BreakerStorage) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	keys, err := s.next.ListAPIKeys(ctx)
+	s.record(err)
+	return keys, err
+}
+
+// RevokeAPIKey revokes an API key unless the breaker is open.
+func (s *BreakerStorage) RevokeAPIKey(ctx context.Context, id int64, at time.Time) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	revoked, err := s.next.RevokeAPIKey(ctx, id, at)
+	s.record(err)
+	return revoked, err
+}
+
+// AddScopedToken stores a scoped token unless the breaker is open.
+func (s *BreakerStorage) AddScopedToken(ctx context.Context, token ScopedToken) (ScopedToken, error) {
+	if !s.allow() {
+		return ScopedToken{}, ErrCircuitOpen
+	}
+
+	added, err := s.next.AddScopedToken(ctx, token)
+	s.record(err)
+	return added, err
+}
+
+// GetScopedToken returns a scoped token unless the breaker is open.
+func (s *BreakerStorage) GetScopedToken(ctx context.Context, hash string) (ScopedToken, bool, error) {
+	if !s.allow() {
+		return ScopedToken{}, false, ErrCircuitOpen
+	}
+
+	token, found, err := s.next.GetScopedToken(ctx, hash)
+	s.record(err)
+	return token, found, err
+}
+
+// ListScopedTokens lists the scoped tokens unless the breaker is open.
+func (s *BreakerStorage) ListScopedTokens(ctx context.Context) ([]ScopedToken, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	tokens, err := s.next.ListScopedTokens(ctx)
+	s.record(err)
+	return tokens, err
+}
+
+// RevokeScopedToken revokes a scoped token unless the breaker is open.
+func (s *BreakerStorage) RevokeScopedToken(ctx context.Context, id int64, at time.Time) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	revoked, err := s.next.RevokeScopedToken(ctx, id, at)
+	s.record(err)
+	return revoked, err
+}
+
+// RecordScopedTokenUse counts a use of a scoped token unless the breaker is
+// open.
+func (s *BreakerStorage) RecordScopedTokenUse(ctx context.Context, id int64, at time.Time) error {
+	if !s.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := s.next.RecordScopedTokenUse(ctx, id, at)
+	s.record(err)
+	return err
+}
+
+// FindSchedulesByTags looks schedules up by tags unless the breaker is open.
+func (s *BreakerStorage) FindSchedulesByTags(ctx context.Context, tags []string) ([]TeamSchedule, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	schedules, err := s.next.FindSchedulesByTags(ctx, tags)
+	s.record(err)
+	return schedules, err
+}
+
+// FindTeamsByMember looks teams up by member unless the breaker is open.
+func (s *BreakerStorage) FindTeamsByMember(ctx context.Context, member string) ([]string, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	teams, err := s.next.FindTeamsByMember(ctx, member)
+	s.record(err)
+	return teams, err
+}
+
+// FindSchedulesByMembers looks schedules up by members unless the breaker is open.
+func (s *BreakerStorage) FindSchedulesByMembers(ctx context.Context, members []string) ([]MemberSchedule, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	schedules, err := s.next.FindSchedulesByMembers(ctx, members)
+	s.record(err)
+	return schedules, err
+}
+
+// GetSchedule looks a schedule up by ID unless the breaker is open.
+func (s *BreakerStorage) GetSchedule(ctx context.Context, id string) (TeamSchedule, error) {
+	if !s.allow() {
+		return TeamSchedule{}, ErrCircuitOpen
+	}
+
+	schedule, err := s.next.GetSchedule(ctx, id)
+	s.record(err)
+	return schedule, err
+}
+
+// HistoryStart returns when the history of a team starts unless the breaker
+// is open.
+func (s *BreakerStorage) HistoryStart(ctx context.Context, team string) (time.Time, bool, error) {
+	if !s.allow() {
+		return time.Time{}, false, ErrCircuitOpen
+	}
+
+	start, found, err := s.next.HistoryStart(ctx, team)
+	s.record(err)
+	return start, found, err
+}
+
+// ScheduleVersions lists the versions of a schedule unless the breaker is
+// open.
+func (s *BreakerStorage) ScheduleVersions(ctx context.Context, id string) ([]ScheduleVersion, bool, error) {
+	if !s.allow() {
+		return nil, false, ErrCircuitOpen
+	}
+
+	versions, found, err := s.next.ScheduleVersions(ctx, id)
+	s.record(err)
+	return versions, found, err
+}
+
+// AddPin adds a pin unless the breaker is open.
+func (s *BreakerStorage) AddPin(ctx context.Context, team, scheduleID string, pin Pin) (Pin, bool, error) {
+	if !s.allow() {
+		return Pin{}, false, ErrCircuitOpen
+	}
+
+	pin, found, err := s.next.AddPin(ctx, team, scheduleID, pin)
+	s.record(err)
+	return pin, found, err
+}
+
+// DeletePin deletes a pin unless the breaker is open.
+func (s *BreakerStorage) DeletePin(ctx context.Context, team, scheduleID string, id int64) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	deleted, err := s.next.DeletePin(ctx, team, scheduleID, id)
+	s.record(err)
+	return deleted, err
+}
+
+// AddSwapRequest adds a swap request unless the breaker is open.
+func (s *BreakerStorage) AddSwapRequest(ctx context.Context, team string, request SwapRequest) (SwapRequest, bool, error) {
+	if !s.allow() {
+		return SwapRequest{}, false, ErrCircuitOpen
+	}
+
+	request, found, err := s.next.AddSwapRequest(ctx, team, request)
+	s.record(err)
+	return request, found, err
+}
+
+// GetSwapRequest looks a swap request up unless the breaker is open.
+func (s *BreakerStorage) GetSwapRequest(ctx context.Context, id int64) (SwapRequest, bool, error) {
+	if !s.allow() {
+		return SwapRequest{}, false, ErrCircuitOpen
+	}
+
+	request, found, err := s.next.GetSwapRequest(ctx, id)
+	s.record(err)
+	return request, found, err
+}
+
+// ListSwapRequests lists swap requests unless the breaker is open.
+func (s *BreakerStorage) ListSwapRequests(ctx context.Context, team, scheduleID string) ([]SwapRequest, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	requests, err := s.next.ListSwapRequests(ctx, team, scheduleID)
+	s.record(err)
+	return requests, err
+}
+
+// DecideSwapRequest decides a swap request unless the breaker is open. A
+// request that is not pending anymore is not a storage failure.
+func (s *BreakerStorage) DecideSwapRequest(ctx context.Context, id int64, accept bool, at time.Time) (SwapRequest, bool, error) {
+	if !s.allow() {
+		return SwapRequest{}, false, ErrCircuitOpen
+	}
+
+	request, found, err := s.next.DecideSwapRequest(ctx, id, accept, at)
+	s.record(err)
+	return request, found, err
+}
+
+// AddUser provisions a user unless the breaker is open.
+func (s *BreakerStorage) AddUser(ctx context.Context, user User) (User, bool, error) {
+	if !s.allow() {
+		return User{}, false, ErrCircuitOpen
+	}
+
+	added, found, err := s.next.AddUser(ctx, user)
+	s.record(err)
+	return added, found, err
+}
+
+// GetUser looks a user up by ID unless the breaker is open.
+func (s *BreakerStorage) GetUser(ctx context.Context, id string) (User, bool, error) {
+	if !s.allow() {
+		return User{}, false, ErrCircuitOpen
+	}
+
+	user, found, err := s.next.GetUser(ctx, id)
+	s.record(err)
+	return user, found, err
+}
+
+// FindUsers looks users up by user name unless the breaker is open.
+func (s *BreakerStorage) FindUsers(ctx context.Context, userName string) ([]User, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	users, err := s.next.FindUsers(ctx, userName)
+	s.record(err)
+	return users, err
+}
+
+// SetUserActive activates or deactivates a user unless the breaker is open.
+func (s *BreakerStorage) SetUserActive(ctx context.Context, id string, active bool) (User, bool, error) {
+	if !s.allow() {
+		return User{}, false, ErrCircuitOpen
+	}
+
+	user, found, err := s.next.SetUserActive(ctx, id, active)
+	s.record(err)
+	return user, found, err
+}
+
+// SetUserTimezone sets the zone of a user unless the breaker is open.
+func (s *BreakerStorage) SetUserTimezone(ctx context.Context, id, timezone string) (User, bool, error) {
+	if !s.allow() {
+		return User{}, false, ErrCircuitOpen
+	}
+
+	user, found, err := s.next.SetUserTimezone(ctx, id, timezone)
+	s.record(err)
+	return user, found, err
+}
+
+// SetTeamMember sets a member of a team roster unless the breaker is open.
+func (s *BreakerStorage) SetTeamMember(ctx context.Context, team string, member TeamMember) (TeamMember, bool, error) {
+	if !s.allow() {
+		return TeamMember{}, false, ErrCircuitOpen
+	}
+
+	set, found, err := s.next.SetTeamMember(ctx, team, member)
+	s.record(err)
+	return set, found, err
+}
+
+// ListTeamMembers lists a team roster unless the breaker is open.
+func (s *BreakerStorage) ListTeamMembers(ctx context.Context, team string) ([]TeamMember, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	members, err := s.next.ListTeamMembers(ctx, team)
+	s.record(err)
+	return members, err
+}
+
+// RemoveTeamMember removes a member from a team roster unless the breaker is open.
+func (s *BreakerStorage) RemoveTeamMember(ctx context.Context, team, name string) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	removed, err := s.next.RemoveTeamMember(ctx, team, name)
+	s.record(err)
+	return removed, err
+}
+
+// SetGroup sets a group of a team unless the breaker is open.
+func (s *BreakerStorage) SetGroup(ctx context.Context, team string, group Group) (Group, bool, error) {
+	if !s.allow() {
+		return Group{}, false, ErrCircuitOpen
+	}
+
+	set, found, err := s.next.SetGroup(ctx, team, group)
+	s.record(err)
+	return set, found, err
+}
+
+// ListGroups lists the groups of a team unless the breaker is open.
+func (s *BreakerStorage) ListGroups(ctx context.Context, team string) ([]Group, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	groups, err := s.next.ListGroups(ctx, team)
+	s.record(err)
+	return groups, err
+}
+
+// DeleteGroup removes a group of a team unless the breaker is open.
+func (s *BreakerStorage) DeleteGroup(ctx context.Context, team, name string) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	deleted, err := s.next.DeleteGroup(ctx, team, name)
+	s.record(err)
+	return deleted, err
+}
+
+// AddFreeze freezes a team unless the breaker is open.
+func (s *BreakerStorage) AddFreeze(ctx context.Context, team string, freeze Freeze) (Freeze, bool, error) {
+	if !s.allow() {
+		return Freeze{}, false, ErrCircuitOpen
+	}
+
+	added, found, err := s.next.AddFreeze(ctx, team, freeze)
+	s.record(err)
+	return added, found, err
+}
+
+// AddUnavailability marks a member unavailable unless the breaker is open.
+func (s *BreakerStorage) AddUnavailability(ctx context.Context, unavailability Unavailability) (Unavailability, error) {
+	if !s.allow() {
+		return Unavailability{}, ErrCircuitOpen
+	}
+
+	added, err := s.next.AddUnavailability(ctx, unavailability)
+	s.record(err)
+	return added, err
+}
+
+// ListUnavailability lists the unavailability of a member unless the breaker is open.
+func (s *BreakerStorage) ListUnavailability(ctx context.Context, member string) ([]Unavailability, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	windows, err := s.next.ListUnavailability(ctx, member)
+	s.record(err)
+	return windows, err
+}
+
+// CancelUnavailability cancels an unavailability window unless the breaker is open.
+func (s *BreakerStorage) CancelUnavailability(ctx context.Context, member string, id int64) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	canceled, err := s.next.CancelUnavailability(ctx, member, id)
+	s.record(err)
+	return canceled, err
+}
+
+// UnavailableMembers looks the unavailable members up unless the breaker is open.
+func (s *BreakerStorage) UnavailableMembers(ctx context.Context, members []string, at time.Time) ([]string, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	unavailable, err := s.next.UnavailableMembers(ctx, members, at)
+	s.record(err)
+	return unavailable, err
+}
+
+// ListFreezes lists the freezes of a team unless the breaker is open.
+func (s *BreakerStorage) ListFreezes(ctx context.Context, team string) ([]Freeze, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	freezes, err := s.next.ListFreezes(ctx, team)
+	s.record(err)
+	return freezes, err
+}
+
+// CancelFreeze cancels a freeze of a team unless the breaker is open.
+func (s *BreakerStorage) CancelFreeze(ctx context.Context, team string, id int64) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	canceled, err := s.next.CancelFreeze(ctx, team, id)
+	s.record(err)
+	return canceled, err
+}
+
+// AddBlackout adds a blackout to a team unless the breaker is open.
+func (s *BreakerStorage) AddBlackout(ctx context.Context, team string, blackout Blackout) (Blackout, bool, error) {
+	if !s.allow() {
+		return Blackout{}, false, ErrCircuitOpen
+	}
+
+	added, found, err := s.next.AddBlackout(ctx, team, blackout)
+	s.record(err)
+	return added, found, err
+}
+
+// ListBlackouts lists the blackouts of a team unless the breaker is open.
+func (s *BreakerStorage) ListBlackouts(ctx context.Context, team string) ([]Blackout, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	blackouts, err := s.next.ListBlackouts(ctx, team)
+	s.record(err)
+	return blackouts, err
+}
+
+// CancelBlackout cancels a blackout of a team unless the breaker is open.
+func (s *BreakerStorage) CancelBlackout(ctx context.Context, team string, id int64) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	canceled, err := s.next.CancelBlackout(ctx, team, id)
+	s.record(err)
+	return canceled, err
+}
+
+// AddHandoffNote adds a handoff note to a team unless the breaker is open.
+func (s *BreakerStorage) AddHandoffNote(ctx context.Context, team string, note HandoffNote) (HandoffNote, bool, error) {
+	if !s.allow() {
+		return HandoffNote{}, false, ErrCircuitOpen
+	}
+
+	added, found, err := s.next.AddHandoffNote(ctx, team, note)
+	s.record(err)
+	return added, found, err
+}
+
+// ListHandoffNotes lists the handoff notes of a team unless the breaker is open.
+func (s *BreakerStorage) ListHandoffNotes(ctx context.Context, team string) ([]HandoffNote, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	notes, err := s.next.ListHandoffNotes(ctx, team)
+	s.record(err)
+	return notes, err
+}
+
+// UpdateHandoffNote updates a handoff note of a team unless the breaker is open.
+func (s *BreakerStorage) UpdateHandoffNote(ctx context.Context, team string, id int64, text string) (HandoffNote, bool, error) {
+	if !s.allow() {
+		return HandoffNote{}, false, ErrCircuitOpen
+	}
+
+	updated, found, err := s.next.UpdateHandoffNote(ctx, team, id, text)
+	s.record(err)
+	return updated, found, err
+}
+
+// DeleteHandoffNote deletes a handoff note of a team unless the breaker is open.
+func (s *BreakerStorage) DeleteHandoffNote(ctx context.Context, team string, id int64) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	deleted, err := s.next.DeleteHandoffNote(ctx, team, id)
+	s.record(err)
+	return deleted, err
+}
+
+// RenameMember renames a member unless the breaker is open.
+func (s *BreakerStorage) RenameMember(ctx context.Context, from, to string) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	renamed, err := s.next.RenameMember(ctx, from, to)
+	s.record(err)
+	return renamed, err
+}
+
+// MergeTeams merges two teams unless the breaker is open.
+func (s *BreakerStorage) MergeTeams(ctx context.Context, target, source, policy string) (TeamMerge, bool, error) {
+	if !s.allow() {
+		return TeamMerge{}, false, ErrCircuitOpen
+	}
+
+	merge, found, err := s.next.MergeTeams(ctx, target, source, policy)
+	s.record(err)
+	return merge, found, err
+}
+
+// MergedInto looks up the team a team was merged into unless the breaker is open.
+func (s *BreakerStorage) MergedInto(ctx context.Context, team string) (string, bool, error) {
+	if !s.allow() {
+		return "", false, ErrCircuitOpen
+	}
+
+	into, found, err := s.next.MergedInto(ctx, team)
+	s.record(err)
+	return into, found, err
+}
+
+// SetTeamParent sets the parent of a team unless the breaker is open.
+func (s *BreakerStorage) SetTeamParent(ctx context.Context, team, parent string) error {
+	if !s.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := s.next.SetTeamParent(ctx, team, parent)
+	s.record(err)
+	return err
+}
+
+// TeamParent looks up the parent of a team unless the breaker is open.
+func (s *BreakerStorage) TeamParent(ctx context.Context, team string) (string, bool, error) {
+	if !s.allow() {
+		return "", false, ErrCircuitOpen
+	}
+
+	parent, found, err := s.next.TeamParent(ctx, team)
+	s.record(err)
+	return parent, found, err
+}
+
+// SetPublicCoverage sets the public coverage of a team unless the breaker is
+// open.
+func (s *BreakerStorage) SetPublicCoverage(ctx context.Context, team string, enabled bool) error {
+	if !s.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := s.next.SetPublicCoverage(ctx, team, enabled)
+	s.record(err)
+	return err
+}
+
+// PublicCoverage looks up the public coverage of a team unless the breaker
+// is open.
+func (s *BreakerStorage) PublicCoverage(ctx context.Context, team string) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	enabled, err := s.next.PublicCoverage(ctx, team)
+	s.record(err)
+	return enabled, err
+}
+
+// SetGoogleCalendar sets the Google calendar of a team unless the breaker is
+// open.
+func (s *BreakerStorage) SetGoogleCalendar(ctx context.Context, team, calendarID string) error {
+	if !s.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := s.next.SetGoogleCalendar(ctx, team, calendarID)
+	s.record(err)
+	return err
+}
+
+// GoogleCalendar looks up the Google calendar of a team unless the breaker
+// is open.
+func (s *BreakerStorage) GoogleCalendar(ctx context.Context, team string) (string, bool, error) {
+	if !s.allow() {
+		return "", false, ErrCircuitOpen
+	}
+
+	calendarID, found, err := s.next.GoogleCalendar(ctx, team)
+	s.record(err)
+	return calendarID, found, err
+}
+
+// ListGoogleCalendars lists the Google calendars unless the breaker is open.
+func (s *BreakerStorage) ListGoogleCalendars(ctx context.Context) (map[string]string, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	calendars, err := s.next.ListGoogleCalendars(ctx)
+	s.record(err)
+	return calendars, err
+}
+
+// RecordAudit records an audit entry unless the breaker is open.
+func (s *BreakerStorage) RecordAudit(ctx context.Context, team, action, detail string) error {
+	if !s.allow() {
+		return ErrCircuitOpen
+	}
+
+	err := s.next.RecordAudit(ctx, team, action, detail)
+	s.record(err)
+	return err
+}
+
+// SetRotation sets the rotation of a schedule unless the breaker is open.
+func (s *BreakerStorage) SetRotation(ctx context.Context, team, scheduleID string, rotation Rotation) (bool, error) {
+	if !s.allow() {
+		return false, ErrCircuitOpen
+	}
+
+	found, err := s.next.SetRotation(ctx, team, scheduleID, rotation)
+	s.record(err)
+	return found, err
+}
+
+// UpdateScheduleFields changes the fields of a schedule unless the breaker is
+// open.
+func (s *BreakerStorage) UpdateScheduleFields(ctx context.Context, team, scheduleID string, update ScheduleUpdate) (Schedule, error) {
+	if !s.allow() {
+		return Schedule{}, ErrCircuitOpen
+	}
+
+	sched, err := s.next.UpdateScheduleFields(ctx, team, scheduleID, update)
+	s.record(err)
+	return sched, err
+}
+
+// TeamHistory returns the versions of a team unless the breaker is open.
+func (s *BreakerStorage) TeamHistory(ctx context.Context, team string, from, to time.Time) ([]TeamVersion, error) {
+	if !s.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	history, err := s.next.TeamHistory(ctx, team, from, to)
+	s.record(err)
+	return history, err
+}
@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyStorage wraps a MemoryStorage and fails every call while down is set.
+type flakyStorage struct {
+	*MemoryStorage
+	down  bool
+	calls int
+}
+
+var errDown = errors.New("connection refused")
+
+func (s *flakyStorage) AddSchedule(ctx context.Context, team string, schedule Schedule) (string, error) {
+	s.calls++
+	if s.down {
+		return "", errDown
+	}
+	return s.MemoryStorage.AddSchedule(ctx, team, schedule)
+}
+
+func (s *flakyStorage) GetTeam(ctx context.Context, team string) (Team, error) {
+	s.calls++
+	if s.down {
+		return Team{}, errDown
+	}
+	return s.MemoryStorage.GetTeam(ctx, team)
+}
+
+func (s *flakyStorage) GetCurrentOncall(ctx context.Context, team string, at time.Time) (string, error) {
+	s.calls++
+	if s.down {
+		return "", errDown
+	}
+	return s.MemoryStorage.GetCurrentOncall(ctx, team, at)
+}
+
+func (s *flakyStorage) ListTeams(ctx context.Context) ([]string, error) {
+	s.calls++
+	if s.down {
+		return nil, errDown
+	}
+	return s.MemoryStorage.ListTeams(ctx)
+}
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestBreaker(t *testing.T) (*BreakerStorage, *flakyStorage, *fakeClock) {
+	t.Helper()
+
+	flaky := &flakyStorage{MemoryStorage: NewMemoryStorage()}
+	clock := &fakeClock{now: time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)}
+
+	breaker := NewBreakerStorage(flaky, 3, 10*time.Second)
+	breaker.now = clock.Now
+
+	schedule := Schedule{
+		Name:    "Weekday Coverage",
+		Members: []string{"Alice", "Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+	_, err := breaker.AddSchedule(context.Background(), "backend-team", schedule)
+	require.NoError(t, err)
+
+	return breaker, flaky, clock
+}
+
+func TestBreakerStorage_OpensAndServesStale(t *testing.T) {
+	breaker, flaky, _ := newTestBreaker(t)
+	ctx := context.Background()
+	at := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)
+
+	oncall, err := breaker.GetCurrentOncall(ctx, "backend-team", at)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+
+	flaky.down = true
+
+	// Failures below the threshold already fall back to the cache
+	for i := 0; i < 3; i++ {
+		oncall, err = breaker.GetCurrentOncall(ctx, "backend-team", at)
+		require.ErrorIs(t, err, ErrStale)
+		assert.Equal(t, "Alice", oncall)
+	}
+	assert.Equal(t, BreakerOpen, breaker.State())
+
+	// Once open the storage is not called anymore
+	calls := flaky.calls
+	oncall, err = breaker.GetCurrentOncall(ctx, "backend-team", at)
+	require.ErrorIs(t, err, ErrStale)
+	assert.Equal(t, "Alice", oncall)
+	assert.Equal(t, calls, flaky.calls)
+
+	// Unknown teams and mutations fail fast
+	_, err = breaker.GetCurrentOncall(ctx, "frontend-team", at)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	_, err = breaker.AddSchedule(ctx, "backend-team", Schedule{})
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	_, err = breaker.GetTeam(ctx, "backend-team")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, calls, flaky.calls)
+}
+
+func TestBreakerStorage_Recovery(t *testing.T) {
+	breaker, flaky, clock := newTestBreaker(t)
+	ctx := context.Background()
+	at := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)
+
+	flaky.down = true
+	for i := 0; i < 3; i++ {
+		_, _ = breaker.GetTeam(ctx, "backend-team")
+	}
+	require.Equal(t, BreakerOpen, breaker.State())
+
+	retryAt, open := breaker.RetryAt()
+	assert.True(t, open)
+	assert.Equal(t, clock.now.Add(10*time.Second), retryAt)
+
+	// A failed probe reopens the breaker for another cooldown
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, BreakerHalfOpen, breaker.State())
+	_, open = breaker.RetryAt()
+	assert.False(t, open)
+	_, err := breaker.GetTeam(ctx, "backend-team")
+	require.ErrorIs(t, err, errDown)
+	assert.Equal(t, BreakerOpen, breaker.State())
+	retryAt, open = breaker.RetryAt()
+	assert.True(t, open)
+	assert.Equal(t, clock.now.Add(10*time.Second), retryAt)
+
+	clock.Advance(5 * time.Second)
+	assert.Equal(t, BreakerOpen, breaker.State())
+
+	// A successful probe closes it
+	flaky.down = false
+	clock.Advance(5 * time.Second)
+	oncall, err := breaker.GetCurrentOncall(ctx, "backend-team", at)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+	assert.Equal(t, BreakerClosed, breaker.State())
+	_, open = breaker.RetryAt()
+	assert.False(t, open)
+}
+
+func TestBreakerStorage_SingleProbe(t *testing.T) {
+	breaker, flaky, clock := newTestBreaker(t)
+
+	flaky.down = true
+	for i := 0; i < 3; i++ {
+		_, _ = breaker.GetTeam(context.Background(), "backend-team")
+	}
+
+	clock.Advance(10 * time.Second)
+
+	// The first call claims the probe slot, the rest are rejected until it reports back
+	require.True(t, breaker.allow())
+	assert.False(t, breaker.allow())
+	breaker.record(nil)
+	assert.True(t, breaker.allow())
+}
+
+func TestBreakerStorage_QuotaIsNotAFailure(t *testing.T) {
+	breaker, _, _ := newTestBreaker(t)
+	ctx := WithQuota(context.Background(), QuotaSchedules, 1)
+
+	// The team already has the schedule added by newTestBreaker
+	for i := 0; i < 5; i++ {
+		var quotaErr *QuotaError
+		_, err := breaker.AddSchedule(ctx, "backend-team", Schedule{})
+		require.ErrorAs(t, err, &quotaErr)
+	}
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
+
+func TestBreakerStorage_AbsentIsAnAnswer(t *testing.T) {
+	breaker, _, _ := newTestBreaker(t)
+	ctx := context.Background()
+
+	// Unknown teams and uncovered times do not count as failures
+	for i := 0; i < 3; i++ {
+		_, err := breaker.GetCurrentOncall(ctx, "frontend-team", time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC))
+		require.ErrorIs(t, err, ErrTeamNotFound)
+		_, err = breaker.GetCurrentOncall(ctx, "backend-team", time.Date(2025, 4, 29, 10, 0, 0, 0, time.UTC))
+		require.ErrorIs(t, err, ErrNoCoverage)
+		_, err = breaker.GetTeam(ctx, "frontend-team")
+		require.ErrorIs(t, err, ErrTeamNotFound)
+		require.ErrorIs(t, breaker.DeleteTeam(ctx, "frontend-team"), ErrTeamNotFound)
+		_, err = breaker.TeamHistory(ctx, "frontend-team", time.Now(), time.Now())
+		require.ErrorIs(t, err, ErrTeamNotFound)
+		_, err = breaker.UpdateScheduleFields(ctx, "backend-team", "unknown", ScheduleUpdate{})
+		require.ErrorIs(t, err, ErrScheduleNotFound)
+	}
+	assert.Equal(t, BreakerClosed, breaker.State())
+}
+
+func TestBreakerStorage_DeleteTeamForgetsStale(t *testing.T) {
+	breaker, flaky, _ := newTestBreaker(t)
+	ctx := context.Background()
+	at := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)
+
+	_, err := breaker.GetCurrentOncall(ctx, "backend-team", at)
+	require.NoError(t, err)
+
+	require.NoError(t, breaker.DeleteTeam(ctx, "backend-team"))
+
+	// The deleted team is not served from the stale cache anymore
+	flaky.down = true
+	_, err = breaker.GetCurrentOncall(ctx, "backend-team", at)
+	require.ErrorIs(t, err, errDown)
+}
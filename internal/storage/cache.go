@@ -0,0 +1,617 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CacheStorage wraps a Storage and caches teams, pauses and on-call answers
+// for ttl. Only the latest on-call answer of each team is kept, and it is
+// reused by lookups within the same minute. Any change to a team drops its
+// cached entries.
+type CacheStorage struct {
+	next Storage
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu     sync.RWMutex
+	teams  map[string]cachedTeam
+	oncall map[string]cachedOncall
+	pauses map[string]cachedPause
+}
+
+type cachedTeam struct {
+	team    Team
+	err     error
+	expires time.Time
+}
+
+type cachedPause struct {
+	pause   Pause
+	found   bool
+	expires time.Time
+}
+
+type cachedOncall struct {
+	minute time.Time
+	oncall string
+	// err is ErrTeamNotFound or ErrNoCoverage when the lookup found nobody.
+	err     error
+	expires time.Time
+}
+
+// NewCacheStorage wraps next with a cache.
+func NewCacheStorage(next Storage, ttl time.Duration) *CacheStorage {
+	return &CacheStorage{
+		next:   next,
+		ttl:    ttl,
+		now:    time.Now,
+		teams:  make(map[string]cachedTeam),
+		oncall: make(map[string]cachedOncall),
+		pauses: make(map[string]cachedPause),
+	}
+}
+
+// AddSchedule adds a schedule and invalidates the cached entries of the team.
+func (s *CacheStorage) AddSchedule(ctx context.Context, team string, schedule Schedule) (string, error) {
+	id, err := s.next.AddSchedule(ctx, team, schedule)
+	s.invalidate(team)
+	return id, err
+}
+
+// GetTeam returns the cached team or loads it.
+func (s *CacheStorage) GetTeam(ctx context.Context, team string) (Team, error) {
+	s.mu.RLock()
+	entry, ok := s.teams[team]
+	s.mu.RUnlock()
+
+	if ok && s.now().Before(entry.expires) {
+		return Team{Schedules: slices.Clone(entry.team.Schedules)}, entry.err
+	}
+
+	// A missing team is cached like an answer, failures are not
+	t, err := s.next.GetTeam(ctx, team)
+	if err != nil && !absent(err) {
+		return t, err
+	}
+
+	s.mu.Lock()
+	s.teams[team] = cachedTeam{team: Team{Schedules: slices.Clone(t.Schedules)}, err: err, expires: s.now().Add(s.ttl)}
+	s.mu.Unlock()
+
+	return t, err
+}
+
+// GetCurrentOncall returns the cached answer for the minute of at or looks it up.
+func (s *CacheStorage) GetCurrentOncall(ctx context.Context, team string, at time.Time) (string, error) {
+	minute := at.UTC().Truncate(time.Minute)
+
+	s.mu.RLock()
+	entry, ok := s.oncall[team]
+	s.mu.RUnlock()
+
+	if ok && entry.minute.Equal(minute) && s.now().Before(entry.expires) {
+		return entry.oncall, entry.err
+	}
+
+	// Finding nobody is cached like an answer, failures are not
+	oncall, err := s.next.GetCurrentOncall(ctx, team, at)
+	if err != nil && !absent(err) {
+		return oncall, err
+	}
+
+	s.mu.Lock()
+	s.oncall[team] = cachedOncall{minute: minute, oncall: oncall, err: err, expires: s.now().Add(s.ttl)}
+	s.mu.Unlock()
+
+	return oncall, err
+}
+
+// ListTeams is passed through, the list is not cached.
+func (s *CacheStorage) ListTeams(ctx context.Context) ([]string, error) {
+	return s.next.ListTeams(ctx)
+}
+
+// DeleteTeam deletes a team and invalidates the cached entries of the team.
+func (s *CacheStorage) DeleteTeam(ctx context.Context, team string) error {
+	err := s.next.DeleteTeam(ctx, team)
+	s.invalidate(team)
+	return err
+}
+
+// PauseTeam pauses a team and invalidates the cached entries of the team.
+func (s *CacheStorage) PauseTeam(ctx context.Context, team string, pause Pause) (bool, error) {
+	found, err := s.next.PauseTeam(ctx, team, pause)
+	s.invalidate(team)
+	return found, err
+}
+
+// UnpauseTeam unpauses a team and invalidates the cached entries of the team.
+func (s *CacheStorage) UnpauseTeam(ctx context.Context, team string) (bool, error) {
+	found, err := s.next.UnpauseTeam(ctx, team)
+	s.invalidate(team)
+	return found, err
+}
+
+// GetPause returns the cached pause of a team or loads it.
+func (s *CacheStorage) GetPause(ctx context.Context, team string) (Pause, bool, error) {
+	s.mu.RLock()
+	entry, ok := s.pauses[team]
+	s.mu.RUnlock()
+
+	if ok && s.now().Before(entry.expires) {
+		return entry.pause, entry.found, nil
+	}
+
+	pause, found, err := s.next.GetPause(ctx, team)
+	if err != nil {
+		return pause, found, err
+	}
+
+	s.mu.Lock()
+	s.pauses[team] = cachedPause{pause: pause, found: found, expires: s.now().Add(s.ttl)}
+	s.mu.Unlock()
+
+	return pause, found, nil
+}
+
+// AuditLog is passed through, the log is not cached.
+func (s *CacheStorage) AuditLog(ctx context.Context, team string) ([]AuditEntry, error) {
+	return s.next.AuditLog(ctx, team)
+}
+
+// DeleteExpiredSchedules deletes the expired schedules and drops the whole
+// cache, since any team may have lost schedules.
+func (s *CacheStorage) DeleteExpiredSchedules(ctx context.Context, before time.Time) (int, error) {
+	deleted, err := s.next.DeleteExpiredSchedules(ctx, before)
+	if deleted > 0 || err != nil {
+		s.reset()
+	}
+	return deleted, err
+}
+
+// PurgeExpiredPauses deletes the expired pauses and drops the whole cache.
+func (s *CacheStorage) PurgeExpiredPauses(ctx context.Context, before time.Time) (int, error) {
+	purged, err := s.next.PurgeExpiredPauses(ctx, before)
+	if purged > 0 || err != nil {
+		s.reset()
+	}
+	return purged, err
+}
+
+// TrimAuditLog is passed through, the log is not cached.
+func (s *CacheStorage) TrimAuditLog(ctx context.Context, before time.Time) (int, error) {
+	return s.next.TrimAuditLog(ctx, before)
+}
+
+// AddWebhook is passed through, webhooks are not cached.
+func (s *CacheStorage) AddWebhook(ctx context.Context, webhook Webhook) (Webhook, error) {
+	return s.next.AddWebhook(ctx, webhook)
+}
+
+// ListWebhooks is passed through, webhooks are not cached.
+func (s *CacheStorage) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	return s.next.ListWebhooks(ctx)
+}
+
+// DeleteWebhook is passed through, webhooks are not cached.
+func (s *CacheStorage) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	return s.next.DeleteWebhook(ctx, id)
+}
+
+// MarkReminderSent is passed through, reminders are not cached.
+func (s *CacheStorage) MarkReminderSent(ctx context.Context, key string) (bool, error) {
+	return s.next.MarkReminderSent(ctx, key)
+}
+
+// AddCalendarToken is passed through, calendar tokens are not cached.
+func (s *CacheStorage) AddCalendarToken(ctx context.Context, token CalendarToken) (CalendarToken, error) {
+	return s.next.AddCalendarToken(ctx, token)
+}
+
+// GetCalendarToken is passed through, so revoked tokens stop working right away.
+func (s *CacheStorage) GetCalendarToken(ctx context.Context, hash string) (CalendarToken, bool, error) {
+	return s.next.GetCalendarToken(ctx, hash)
+}
+
+// DeleteCalendarToken is passed through, calendar tokens are not cached.
+func (s *CacheStorage) DeleteCalendarToken(ctx context.Context, team string, id int64) (bool, error) {
+	return s.next.DeleteCalendarToken(ctx, team, id)
+}
+
+// AddAPIKey is passed through, API keys are not cached.
+func (s *CacheStorage) AddAPIKey(ctx context.Context, key APIKey) (APIKey, error) {
+	return s.next.AddAPIKey(ctx, key)
+}
+
+// GetAPIKey is passed through, so revoked keys stop working right away.
+func (s *CacheStorage) GetAPIKey(ctx context.Context, hash string) (APIKey, bool, error) {
+	return s.next.GetAPIKey(ctx, hash)
+}
+
+// ListAPIKeys is passed through, API keys are not cached.
+func (s *CacheStorage) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	return s.next.ListAPIKeys(ctx)
+}
+
+// RevokeAPIKey is passed through, API keys are not cached.
+func (s *CacheStorage) RevokeAPIKey(ctx context.Context, id int64, at time.Time) (bool, error) {
+	return s.next.RevokeAPIKey(ctx, id, at)
+}
+
+// AddScopedToken is passed through, scoped tokens are not cached.
+func (s *CacheStorage) AddScopedToken(ctx context.Context, token ScopedToken) (ScopedToken, error) {
+	return s.next.AddScopedToken(ctx, token)
+}
+
+// GetScopedToken is passed through, so revoked tokens stop working right away.
+func (s *CacheStorage) GetScopedToken(ctx context.Context, hash string) (ScopedToken, bool, error) {
+	return s.next.GetScopedToken(ctx, hash)
+}
+
+// ListScopedTokens is passed through, scoped tokens are not cached.
+func (s *CacheStorage) ListScopedTokens(ctx context.Context) ([]ScopedToken, error) {
+	return s.next.ListScopedTokens(ctx)
+}
+
+// RevokeScopedToken is passed through, scoped tokens are not cached.
+func (s *CacheStorage) RevokeScopedToken(ctx context.Context, id int64, at time.Time) (bool, error) {
+	return s.next.RevokeScopedToken(ctx, id, at)
+}
+
+// RecordScopedTokenUse is passed through, scoped tokens are not cached.
+func (s *CacheStorage) RecordScopedTokenUse(ctx context.Context, id int64, at time.Time) error {
+	return s.next.RecordScopedTokenUse(ctx, id, at)
+}
+
+// Warm loads every team and its current on-call member into the cache until
+// ctx is done. Teams that fail to load are logged and skipped, as are the
+// teams left when ctx ends, so a failed warm-up only leaves the cache cold.
+func (s *CacheStorage) Warm(ctx context.Context, logger *zap.Logger) {
+	names, err := s.next.ListTeams(ctx)
+	if err != nil {
+		logger.Warn("cache warm-up failed to list teams", zap.Error(err))
+		return
+	}
+
+	now := s.now()
+	warmed := 0
+
+	for i, name := range names {
+		if ctx.Err() != nil {
+			logger.Warn("cache warm-up ran out of time",
+				zap.Int("warmed", warmed),
+				zap.Strings("skipped", names[i:]),
+			)
+			return
+		}
+
+		if _, err := s.GetTeam(ctx, name); err != nil && !absent(err) {
+			logger.Warn("cache warm-up failed to load team", zap.String("team", name), zap.Error(err))
+			continue
+		}
+		if _, err := s.GetCurrentOncall(ctx, name, now); err != nil && !absent(err) {
+			logger.Warn("cache warm-up failed to load oncall", zap.String("team", name), zap.Error(err))
+			continue
+		}
+		warmed++
+	}
+
+	logger.Info("cache warmed", zap.Int("teams", warmed))
+}
+
+// reset drops every cached entry.
+func (s *CacheStorage) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.teams)
+	clear(s.oncall)
+	clear(s.pauses)
+}
+
+// invalidate drops the cached entries of a team.
+func (s *CacheStorage) invalidate(team string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.teams, team)
+	delete(s.oncall, team)
+	delete(s.pauses, team)
+}
+
+// FindSchedulesByTags is passed through, lookups across teams are not cached.
+func (s *CacheStorage) FindSchedulesByTags(ctx context.Context, tags []string) ([]TeamSchedule, error) {
+	return s.next.FindSchedulesByTags(ctx, tags)
+}
+
+// FindTeamsByMember is passed through, lookups across teams are not cached.
+func (s *CacheStorage) FindTeamsByMember(ctx context.Context, member string) ([]string, error) {
+	return s.next.FindTeamsByMember(ctx, member)
+}
+
+// FindSchedulesByMembers is passed through, lookups across teams are not cached.
+func (s *CacheStorage) FindSchedulesByMembers(ctx context.Context, members []string) ([]MemberSchedule, error) {
+	return s.next.FindSchedulesByMembers(ctx, members)
+}
+
+// GetSchedule is passed through, lookups by ID are not cached.
+func (s *CacheStorage) GetSchedule(ctx context.Context, id string) (TeamSchedule, error) {
+	return s.next.GetSchedule(ctx, id)
+}
+
+// HistoryStart is passed through, the history is not cached.
+func (s *CacheStorage) HistoryStart(ctx context.Context, team string) (time.Time, bool, error) {
+	return s.next.HistoryStart(ctx, team)
+}
+
+// ScheduleVersions is passed through, the history is not cached.
+func (s *CacheStorage) ScheduleVersions(ctx context.Context, id string) ([]ScheduleVersion, bool, error) {
+	return s.next.ScheduleVersions(ctx, id)
+}
+
+// AddPin adds a pin and invalidates the cached entries of the team.
+func (s *CacheStorage) AddPin(ctx context.Context, team, scheduleID string, pin Pin) (Pin, bool, error) {
+	pin, found, err := s.next.AddPin(ctx, team, scheduleID, pin)
+	s.invalidate(team)
+	return pin, found, err
+}
+
+// DeletePin deletes a pin and invalidates the cached entries of the team.
+func (s *CacheStorage) DeletePin(ctx context.Context, team, scheduleID string, id int64) (bool, error) {
+	deleted, err := s.next.DeletePin(ctx, team, scheduleID, id)
+	s.invalidate(team)
+	return deleted, err
+}
+
+// AddSwapRequest adds a swap request, which changes nothing cached until it
+// is accepted.
+func (s *CacheStorage) AddSwapRequest(ctx context.Context, team string, request SwapRequest) (SwapRequest, bool, error) {
+	return s.next.AddSwapRequest(ctx, team, request)
+}
+
+// GetSwapRequest looks a swap request up in the underlying storage.
+func (s *CacheStorage) GetSwapRequest(ctx context.Context, id int64) (SwapRequest, bool, error) {
+	return s.next.GetSwapRequest(ctx, id)
+}
+
+// ListSwapRequests lists swap requests from the underlying storage.
+func (s *CacheStorage) ListSwapRequests(ctx context.Context, team, scheduleID string) ([]SwapRequest, error) {
+	return s.next.ListSwapRequests(ctx, team, scheduleID)
+}
+
+// DecideSwapRequest decides a swap request and invalidates the cached
+// entries of its team, whose pins change when it is accepted.
+func (s *CacheStorage) DecideSwapRequest(ctx context.Context, id int64, accept bool, at time.Time) (SwapRequest, bool, error) {
+	request, found, err := s.next.DecideSwapRequest(ctx, id, accept, at)
+	if found {
+		s.invalidate(request.Team)
+	}
+	return request, found, err
+}
+
+// AddUser provisions a user and drops the whole cache, as a deactivated user
+// changes the rotations of every team they are a member of.
+func (s *CacheStorage) AddUser(ctx context.Context, user User) (User, bool, error) {
+	added, found, err := s.next.AddUser(ctx, user)
+	s.reset()
+	return added, found, err
+}
+
+// GetUser is passed through, users are not cached.
+func (s *CacheStorage) GetUser(ctx context.Context, id string) (User, bool, error) {
+	return s.next.GetUser(ctx, id)
+}
+
+// FindUsers is passed through, users are not cached.
+func (s *CacheStorage) FindUsers(ctx context.Context, userName string) ([]User, error) {
+	return s.next.FindUsers(ctx, userName)
+}
+
+// SetUserActive activates or deactivates a user and drops the whole cache.
+func (s *CacheStorage) SetUserActive(ctx context.Context, id string, active bool) (User, bool, error) {
+	user, found, err := s.next.SetUserActive(ctx, id, active)
+	s.reset()
+	return user, found, err
+}
+
+// RenameMember renames a member and drops the whole cache, as any team may
+// reference them.
+func (s *CacheStorage) RenameMember(ctx context.Context, from, to string) (bool, error) {
+	renamed, err := s.next.RenameMember(ctx, from, to)
+	s.reset()
+	return renamed, err
+}
+
+// MergeTeams merges two teams and drops the whole cache, as the merged
+// schedules are cached by ID too.
+func (s *CacheStorage) MergeTeams(ctx context.Context, target, source, policy string) (TeamMerge, bool, error) {
+	merge, found, err := s.next.MergeTeams(ctx, target, source, policy)
+	s.reset()
+	return merge, found, err
+}
+
+// MergedInto is passed through, merges are not cached.
+func (s *CacheStorage) MergedInto(ctx context.Context, team string) (string, bool, error) {
+	return s.next.MergedInto(ctx, team)
+}
+
+// SetTeamParent is passed through, the cached entries of a team never
+// include what it inherits.
+func (s *CacheStorage) SetTeamParent(ctx context.Context, team, parent string) error {
+	return s.next.SetTeamParent(ctx, team, parent)
+}
+
+// TeamParent is passed through, parents are not cached.
+func (s *CacheStorage) TeamParent(ctx context.Context, team string) (string, bool, error) {
+	return s.next.TeamParent(ctx, team)
+}
+
+// SetPublicCoverage is passed through, the setting is not cached.
+func (s *CacheStorage) SetPublicCoverage(ctx context.Context, team string, enabled bool) error {
+	return s.next.SetPublicCoverage(ctx, team, enabled)
+}
+
+// PublicCoverage is passed through, the setting is not cached.
+func (s *CacheStorage) PublicCoverage(ctx context.Context, team string) (bool, error) {
+	return s.next.PublicCoverage(ctx, team)
+}
+
+// SetGoogleCalendar is passed through, Google calendars are not cached.
+func (s *CacheStorage) SetGoogleCalendar(ctx context.Context, team, calendarID string) error {
+	return s.next.SetGoogleCalendar(ctx, team, calendarID)
+}
+
+// GoogleCalendar is passed through, Google calendars are not cached.
+func (s *CacheStorage) GoogleCalendar(ctx context.Context, team string) (string, bool, error) {
+	return s.next.GoogleCalendar(ctx, team)
+}
+
+// ListGoogleCalendars is passed through, Google calendars are not cached.
+func (s *CacheStorage) ListGoogleCalendars(ctx context.Context) (map[string]string, error) {
+	return s.next.ListGoogleCalendars(ctx)
+}
+
+// SetUserTimezone is passed through, users are not cached.
+func (s *CacheStorage) SetUserTimezone(ctx context.Context, id, timezone string) (User, bool, error) {
+	return s.next.SetUserTimezone(ctx, id, timezone)
+}
+
+// SetTeamMember sets a member of the roster and invalidates the cached
+// entries of the team, whose schedules may rotate through the roster.
+func (s *CacheStorage) SetTeamMember(ctx context.Context, team string, member TeamMember) (TeamMember, bool, error) {
+	member, found, err := s.next.SetTeamMember(ctx, team, member)
+	s.invalidate(team)
+	return member, found, err
+}
+
+// ListTeamMembers is passed through, rosters are not cached.
+func (s *CacheStorage) ListTeamMembers(ctx context.Context, team string) ([]TeamMember, error) {
+	return s.next.ListTeamMembers(ctx, team)
+}
+
+// RemoveTeamMember removes a member of the roster and invalidates the cached
+// entries of the team, whose schedules may rotate through the roster.
+func (s *CacheStorage) RemoveTeamMember(ctx context.Context, team, name string) (bool, error) {
+	removed, err := s.next.RemoveTeamMember(ctx, team, name)
+	s.invalidate(team)
+	return removed, err
+}
+
+// SetGroup sets a group of a team and invalidates the cached entries of the
+// team, whose schedules may reference the group.
+func (s *CacheStorage) SetGroup(ctx context.Context, team string, group Group) (Group, bool, error) {
+	group, found, err := s.next.SetGroup(ctx, team, group)
+	s.invalidate(team)
+	return group, found, err
+}
+
+// ListGroups is passed through, groups are not cached.
+func (s *CacheStorage) ListGroups(ctx context.Context, team string) ([]Group, error) {
+	return s.next.ListGroups(ctx, team)
+}
+
+// DeleteGroup is passed through, schedules cannot reference a deleted group.
+func (s *CacheStorage) DeleteGroup(ctx context.Context, team, name string) (bool, error) {
+	return s.next.DeleteGroup(ctx, team, name)
+}
+
+// AddBlackout is passed through, blackouts are not cached.
+func (s *CacheStorage) AddBlackout(ctx context.Context, team string, blackout Blackout) (Blackout, bool, error) {
+	return s.next.AddBlackout(ctx, team, blackout)
+}
+
+// ListBlackouts is passed through, blackouts are not cached.
+func (s *CacheStorage) ListBlackouts(ctx context.Context, team string) ([]Blackout, error) {
+	return s.next.ListBlackouts(ctx, team)
+}
+
+// CancelBlackout is passed through, blackouts are not cached.
+func (s *CacheStorage) CancelBlackout(ctx context.Context, team string, id int64) (bool, error) {
+	return s.next.CancelBlackout(ctx, team, id)
+}
+
+// AddHandoffNote is passed through, handoff notes are not cached.
+func (s *CacheStorage) AddHandoffNote(ctx context.Context, team string, note HandoffNote) (HandoffNote, bool, error) {
+	return s.next.AddHandoffNote(ctx, team, note)
+}
+
+// ListHandoffNotes is passed through, handoff notes are not cached.
+func (s *CacheStorage) ListHandoffNotes(ctx context.Context, team string) ([]HandoffNote, error) {
+	return s.next.ListHandoffNotes(ctx, team)
+}
+
+// UpdateHandoffNote is passed through, handoff notes are not cached.
+func (s *CacheStorage) UpdateHandoffNote(ctx context.Context, team string, id int64, text string) (HandoffNote, bool, error) {
+	return s.next.UpdateHandoffNote(ctx, team, id, text)
+}
+
+// DeleteHandoffNote is passed through, handoff notes are not cached.
+func (s *CacheStorage) DeleteHandoffNote(ctx context.Context, team string, id int64) (bool, error) {
+	return s.next.DeleteHandoffNote(ctx, team, id)
+}
+
+// AddUnavailability is passed through, the lookup substitutes unavailable
+// members after the cache.
+func (s *CacheStorage) AddUnavailability(ctx context.Context, unavailability Unavailability) (Unavailability, error) {
+	return s.next.AddUnavailability(ctx, unavailability)
+}
+
+// ListUnavailability is passed through, unavailability is not cached.
+func (s *CacheStorage) ListUnavailability(ctx context.Context, member string) ([]Unavailability, error) {
+	return s.next.ListUnavailability(ctx, member)
+}
+
+// CancelUnavailability is passed through, unavailability is not cached.
+func (s *CacheStorage) CancelUnavailability(ctx context.Context, member string, id int64) (bool, error) {
+	return s.next.CancelUnavailability(ctx, member, id)
+}
+
+// UnavailableMembers is passed through, so marks take effect right away.
+func (s *CacheStorage) UnavailableMembers(ctx context.Context, members []string, at time.Time) ([]string, error) {
+	return s.next.UnavailableMembers(ctx, members, at)
+}
+
+// AddFreeze is passed through, freezes are not cached.
+func (s *CacheStorage) AddFreeze(ctx context.Context, team string, freeze Freeze) (Freeze, bool, error) {
+	return s.next.AddFreeze(ctx, team, freeze)
+}
+
+// ListFreezes is passed through, freezes are not cached.
+func (s *CacheStorage) ListFreezes(ctx context.Context, team string) ([]Freeze, error) {
+	return s.next.ListFreezes(ctx, team)
+}
+
+// CancelFreeze is passed through, freezes are not cached.
+func (s *CacheStorage) CancelFreeze(ctx context.Context, team string, id int64) (bool, error) {
+	return s.next.CancelFreeze(ctx, team, id)
+}
+
+// RecordAudit is passed through, the audit log is not cached.
+func (s *CacheStorage) RecordAudit(ctx context.Context, team, action, detail string) error {
+	return s.next.RecordAudit(ctx, team, action, detail)
+}
+
+// SetRotation sets the rotation of a schedule and invalidates the cached entries of the team.
+func (s *CacheStorage) SetRotation(ctx context.Context, team, scheduleID string, rotation Rotation) (bool, error) {
+	found, err := s.next.SetRotation(ctx, team, scheduleID, rotation)
+	s.invalidate(team)
+	return found, err
+}
+
+// UpdateScheduleFields changes the fields of a schedule and invalidates the
+// cached entries of the team.
+func (s *CacheStorage) UpdateScheduleFields(ctx context.Context, team, scheduleID string, update ScheduleUpdate) (Schedule, error) {
+	sched, err := s.next.UpdateScheduleFields(ctx, team, scheduleID, update)
+	s.invalidate(team)
+	return sched, err
+}
+
+// TeamHistory reads the versions of a team through, history is not cached.
+func (s *CacheStorage) TeamHistory(ctx context.Context, team string, from, to time.Time) ([]TeamVersion, error) {
+	return s.next.TeamHistory(ctx, team, from, to)
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestCache(t *testing.T) (*CacheStorage, *flakyStorage, *fakeClock) {
+	t.Helper()
+
+	flaky := &flakyStorage{MemoryStorage: NewMemoryStorage()}
+	clock := &fakeClock{now: time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)}
+
+	schedule := Schedule{
+		Name:    "Weekday Coverage",
+		Members: []string{"Alice", "Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+	for _, team := range []string{"backend-team", "frontend-team"} {
+		_, err := flaky.MemoryStorage.AddSchedule(context.Background(), team, schedule)
+		require.NoError(t, err)
+	}
+
+	cache := NewCacheStorage(flaky, time.Minute)
+	cache.now = clock.Now
+
+	return cache, flaky, clock
+}
+
+func TestCacheStorage_WarmServesFirstRequest(t *testing.T) {
+	cache, flaky, clock := newTestCache(t)
+
+	cache.Warm(context.Background(), zap.NewNop())
+
+	// Every later call must be answered from the cache
+	flaky.down = true
+	calls := flaky.calls
+
+	oncall, err := cache.GetCurrentOncall(context.Background(), "backend-team", clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+
+	team, err := cache.GetTeam(context.Background(), "frontend-team")
+	require.NoError(t, err)
+	assert.Len(t, team.Schedules, 1)
+
+	assert.Equal(t, calls, flaky.calls)
+}
+
+func TestCacheStorage_WarmFailureLeavesCacheCold(t *testing.T) {
+	cache, flaky, clock := newTestCache(t)
+
+	flaky.down = true
+	cache.Warm(context.Background(), zap.NewNop())
+
+	flaky.down = false
+	calls := flaky.calls
+
+	_, err := cache.GetCurrentOncall(context.Background(), "backend-team", clock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, calls+1, flaky.calls)
+}
+
+func TestCacheStorage_WarmBudget(t *testing.T) {
+	cache, flaky, _ := newTestCache(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cache.Warm(ctx, zap.NewNop())
+
+	// Only the listing went through, no team was loaded
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestCacheStorage_ExpiryAndInvalidation(t *testing.T) {
+	cache, flaky, clock := newTestCache(t)
+	ctx := context.Background()
+
+	_, err := cache.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	_, err = cache.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Equal(t, 1, flaky.calls)
+
+	clock.Advance(time.Minute)
+	_, err = cache.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Equal(t, 2, flaky.calls)
+
+	evening := Schedule{
+		Name:    "Weekday Evening",
+		Members: []string{"Charlie"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "5:00PM"),
+		End:     parseTime(t, "11:00PM"),
+	}
+	_, err = cache.AddSchedule(ctx, "backend-team", evening)
+	require.NoError(t, err)
+
+	team, err := cache.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Len(t, team.Schedules, 2)
+}
+
+func TestCacheStorage_CachesNoCoverage(t *testing.T) {
+	cache, flaky, clock := newTestCache(t)
+	ctx := context.Background()
+	tuesday := clock.Now().AddDate(0, 0, 1)
+
+	_, err := cache.GetCurrentOncall(ctx, "backend-team", tuesday)
+	require.ErrorIs(t, err, ErrNoCoverage)
+	_, err = cache.GetCurrentOncall(ctx, "backend-team", tuesday)
+	require.ErrorIs(t, err, ErrNoCoverage)
+	assert.Equal(t, 1, flaky.calls)
+}
+
+func TestCacheStorage_CachesMissingTeam(t *testing.T) {
+	cache, flaky, _ := newTestCache(t)
+	ctx := context.Background()
+
+	_, err := cache.GetTeam(ctx, "platform")
+	require.ErrorIs(t, err, ErrTeamNotFound)
+	_, err = cache.GetTeam(ctx, "platform")
+	require.ErrorIs(t, err, ErrTeamNotFound)
+	assert.Equal(t, 1, flaky.calls)
+
+	// Failures are not cached
+	flaky.down = true
+	_, err = cache.GetTeam(ctx, "payments")
+	require.ErrorIs(t, err, errDown)
+	flaky.down = false
+	_, err = cache.GetTeam(ctx, "payments")
+	require.ErrorIs(t, err, ErrTeamNotFound)
+	assert.Equal(t, 3, flaky.calls)
+}
@@ -0,0 +1,20 @@
+package storage
+
+import "time"
+
+// CalendarToken grants access to the calendar feed of a team, or only to the
+// schedules of a single member of it when Member is set. Only the hex SHA-256
+// Hash of the token is stored. A zero ExpiresAt means it never expires.
+type CalendarToken struct {
+	ID        int64
+	Team      string
+	Member    string
+	Hash      string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Valid reports whether the token has not expired at the given instant.
+func (t CalendarToken) Valid(at time.Time) bool {
+	return t.ExpiresAt.IsZero() || at.Before(t.ExpiresAt)
+}
@@ -0,0 +1,31 @@
+package storage
+
+// UntaggedRateClass is the rate class of the hours on duty for schedules
+// without compensation, which are weighed as is.
+const UntaggedRateClass = "untagged"
+
+// Compensation tags the hours on duty for a schedule with the rate class they
+// are paid at. Rate classes are plain labels, and the multiplier weighs the
+// hours of the class without any currency.
+type Compensation struct {
+	RateClass  string  `json:"rate_class"`
+	Multiplier float64 `json:"multiplier"`
+	// WeekendRateClass and WeekendMultiplier replace the others for the hours
+	// on the weekend days of the team, when set.
+	WeekendRateClass  string  `json:"weekend_rate_class,omitempty"`
+	WeekendMultiplier float64 `json:"weekend_multiplier,omitempty"`
+}
+
+// Rate returns the rate class and multiplier of the hours on duty for a
+// schedule with the compensation, on the weekend or not. A nil compensation
+// returns UntaggedRateClass with a multiplier of one.
+func (c *Compensation) Rate(weekend bool) (string, float64) {
+	switch {
+	case c == nil:
+		return UntaggedRateClass, 1
+	case weekend && c.WeekendRateClass != "":
+		return c.WeekendRateClass, c.WeekendMultiplier
+	default:
+		return c.RateClass, c.Multiplier
+	}
+}
@@ -0,0 +1,12 @@
+package storage
+
+import "github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+
+// CronSpec is a parsed shift recurrence. Each occurrence marks the start of a shift.
+type CronSpec = rotation.CronSpec
+
+// ParseCron parses a standard 5-field cron expression evaluated in UTC, see
+// rotation.ParseCron.
+func ParseCron(expr string) (CronSpec, error) {
+	return rotation.ParseCron(expr)
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"0 9 * * MON", false},
+		{"0 9 1-7 * MON", false},
+		{"30 17 * * 1-5", false},
+		{"0 0 9 * * MON", true}, // seconds field
+		{"@every 5s", true},
+		{"@daily", true},
+		{"0 9 * *", true},
+		{"not a cron", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCronSpec_FirstMondayAcrossMonths(t *testing.T) {
+	spec, err := ParseCron("0 9 1-7 * MON")
+	require.NoError(t, err)
+
+	shift := 8 * time.Hour
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected bool
+	}{
+		{"first Monday of May", time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC), true},
+		{"second Monday of May", time.Date(2025, 5, 12, 10, 0, 0, 0, time.UTC), false},
+		{"last Saturday of May", time.Date(2025, 5, 31, 10, 0, 0, 0, time.UTC), false},
+		{"June 1st is a Sunday", time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC), false},
+		{"first Monday of June at start", time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC), true},
+		{"first Monday of June before start", time.Date(2025, 6, 2, 8, 59, 59, 0, time.UTC), false},
+		{"first Monday of June just before end", time.Date(2025, 6, 2, 16, 59, 59, 0, time.UTC), true},
+		{"first Monday of June at end", time.Date(2025, 6, 2, 17, 0, 0, 0, time.UTC), false},
+		{"Tuesday within the first week", time.Date(2025, 6, 3, 10, 0, 0, 0, time.UTC), false},
+		{"last Monday of June", time.Date(2025, 6, 30, 10, 0, 0, 0, time.UTC), false},
+		{"first Monday of July is the 7th", time.Date(2025, 7, 7, 10, 0, 0, 0, time.UTC), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, spec.Covers(tt.at, shift))
+		})
+	}
+}
+
+func TestCronSpec_Next(t *testing.T) {
+	spec, err := ParseCron("0 9 1-7 * MON")
+	require.NoError(t, err)
+
+	// From the end of June the next first Monday is July 7th
+	next := spec.Next(time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, time.Date(2025, 7, 7, 9, 0, 0, 0, time.UTC), next)
+}
@@ -0,0 +1,33 @@
+package storage
+
+import "errors"
+
+// Errors telling why a lookup or a write of a team or a schedule has nothing
+// to answer with, for callers to tell these cases apart with errors.Is.
+var (
+	// ErrTeamNotFound is returned when the team does not exist.
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrScheduleNotFound is returned when no schedule has the ID.
+	ErrScheduleNotFound = errors.New("schedule not found")
+	// ErrScheduleExists is returned when adding a schedule, or renaming one,
+	// to the name of another schedule of its team.
+	ErrScheduleExists = errors.New("schedule already exists")
+	// ErrNoCoverage is returned when the team exists but nobody is on call
+	// at the time.
+	ErrNoCoverage = errors.New("no oncall member found for the given time")
+	// ErrNoHistory is returned when the history of a team does not reach
+	// back to the instant asked for.
+	ErrNoHistory = errors.New("schedule history does not reach back")
+)
+
+// NobodyOnCall reports whether the error of GetCurrentOncall tells that
+// nobody is on call, the team not existing or not being covered at the time.
+func NobodyOnCall(err error) bool {
+	return errors.Is(err, ErrTeamNotFound) || errors.Is(err, ErrNoCoverage)
+}
+
+// absent reports whether the error tells that a lookup found nothing, which
+// is an answer of a healthy storage like any other.
+func absent(err error) bool {
+	return NobodyOnCall(err) || errors.Is(err, ErrScheduleNotFound) || errors.Is(err, ErrNoHistory)
+}
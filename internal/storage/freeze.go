@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Freeze is a window during which the schedules and pins of a team must not
+// change, e.g. during a major incident or a change freeze.
+type Freeze struct {
+	ID        int64
+	Reason    string
+	Start     time.Time
+	End       time.Time
+	CreatedAt time.Time
+}
+
+// Active reports whether the freeze covers the given instant. Freezes end
+// at End without any further call.
+func (f Freeze) Active(at time.Time) bool {
+	return !at.Before(f.Start) && at.Before(f.End)
+}
+
+// auditDetail describes the freeze for the audit log.
+func (f Freeze) auditDetail() string {
+	detail := fmt.Sprintf("from %s until %s", f.Start.UTC().Format(time.RFC3339), f.End.UTC().Format(time.RFC3339))
+	if f.Reason != "" {
+		detail += fmt.Sprintf(": %s", f.Reason)
+	}
+
+	return detail
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+)
+
+// GroupPrefix starts the members of a schedule that reference a group of its
+// team, e.g. "@group:storage", see Schedule.MemberRefs.
+const GroupPrefix = "@group:"
+
+// ErrGroupInUse is returned when deleting a group that schedules reference.
+var ErrGroupInUse = errors.New("group is referenced by a schedule")
+
+// Group is a named set of members of a team, such as a sub-team, that
+// schedules take turns through by referencing it among their members.
+// Members are in the order they were added to the group.
+type Group struct {
+	Name      string
+	Members   []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// auditDetail describes the group for the audit log.
+func (g Group) auditDetail() string {
+	return g.Name + ": " + strings.Join(g.Members, ", ")
+}
+
+// GroupRef returns the name of the group a member of a schedule references,
+// and false for members that are not references.
+func GroupRef(member string) (string, bool) {
+	return strings.CutPrefix(member, GroupPrefix)
+}
+
+// HasGroupRefs reports whether any of the members references a group.
+func HasGroupRefs(members []string) bool {
+	return slices.ContainsFunc(members, func(member string) bool {
+		_, ok := GroupRef(member)
+		return ok
+	})
+}
+
+// groupMember is a member of a group with when they were added to it, zero
+// for the members it was created with, and when they were removed from it for
+// former members.
+type groupMember struct {
+	Name    string
+	Added   time.Time
+	Removed time.Time
+}
+
+// setGroupMembers updates the members of a group to the given ones at the
+// given instant. Members who stay keep their place and when they were added,
+// new ones are added after them in the given order, and the others are
+// marked removed. Members removed more than a rotation period ago cannot be
+// on duty anymore and are dropped. The members of a new group have no added
+// time, they take turns from the start of the schedules referencing it.
+func setGroupMembers(current []groupMember, members []string, now time.Time) []groupMember {
+	added := now
+	if len(current) == 0 {
+		added = time.Time{}
+	}
+
+	var result []groupMember
+	for _, member := range current {
+		switch {
+		case member.Removed.IsZero() && !slices.Contains(members, member.Name):
+			member.Removed = now
+		case !member.Removed.IsZero() && now.Sub(member.Removed) > RotationPeriod:
+			continue
+		case !member.Removed.IsZero() && slices.Contains(members, member.Name):
+			// Members added back take turns like new ones
+			continue
+		}
+		result = append(result, member)
+	}
+
+	for _, name := range members {
+		if !slices.ContainsFunc(result, func(m groupMember) bool { return m.Name == name && m.Removed.IsZero() }) {
+			result = append(result, groupMember{Name: name, Added: added})
+		}
+	}
+
+	return result
+}
+
+// currentMembers returns the names of the members of a group who were not
+// removed, in the order they were added.
+func currentMembers(members []groupMember) []string {
+	names := make([]string, 0, len(members))
+	for _, member := range members {
+		if member.Removed.IsZero() {
+			names = append(names, member.Name)
+		}
+	}
+
+	return names
+}
+
+// expandMembers sets the members of a schedule created at the given instant
+// from its references to groups, expanding each to the members of the group
+// in the order they were added, along with when they were added after the
+// schedule was created and, for former members, removed. Members listed more
+// than once take a single turn, at their first place. Unknown groups expand
+// to nobody. Schedules without references are left as they are.
+func expandMembers(schedule *Schedule, groups map[string][]groupMember, created time.Time) {
+	if len(schedule.MemberRefs) == 0 {
+		return
+	}
+
+	schedule.Members, schedule.Joined, schedule.Left = nil, nil, nil
+	fromGroups := make(map[string]bool)
+	for _, ref := range schedule.MemberRefs {
+		name, ok := GroupRef(ref)
+		if !ok {
+			if !slices.Contains(schedule.Members, ref) {
+				schedule.Members = append(schedule.Members, ref)
+			}
+			continue
+		}
+
+		for _, member := range groups[name] {
+			if fromGroups[member.Name] || slices.Contains(schedule.Members, member.Name) {
+				continue
+			}
+			fromGroups[member.Name] = true
+			schedule.Members = append(schedule.Members, member.Name)
+
+			// Members of the group when the schedule was created take turns from the start
+			if member.Added.After(created) {
+				if schedule.Joined == nil {
+					schedule.Joined = make(map[string]time.Time)
+				}
+				schedule.Joined[member.Name] = member.Added
+			}
+			if !member.Removed.IsZero() {
+				if schedule.Left == nil {
+					schedule.Left = make(map[string]time.Time)
+				}
+				schedule.Left[member.Name] = member.Removed
+			}
+		}
+	}
+}
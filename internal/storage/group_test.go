@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetGroupMembers(t *testing.T) {
+	created := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	changed := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+
+	// The members a group is created with take turns from the start
+	members := setGroupMembers(nil, []string{"Alice", "Bob", "Carol"}, created)
+	assert.Equal(t, []groupMember{{Name: "Alice"}, {Name: "Bob"}, {Name: "Carol"}}, members)
+
+	// Members who stay keep their place, new ones come after them
+	members = setGroupMembers(members, []string{"Dave", "Carol", "Alice"}, changed)
+	assert.Equal(t, []groupMember{
+		{Name: "Alice"},
+		{Name: "Bob", Removed: changed},
+		{Name: "Carol"},
+		{Name: "Dave", Added: changed},
+	}, members)
+	assert.Equal(t, []string{"Alice", "Carol", "Dave"}, currentMembers(members))
+
+	// Members added back take turns like new ones
+	back := changed.Add(time.Hour)
+	assert.Equal(t, []groupMember{
+		{Name: "Alice"},
+		{Name: "Carol"},
+		{Name: "Dave", Added: changed},
+		{Name: "Bob", Added: back},
+	}, setGroupMembers(members, []string{"Alice", "Bob", "Carol", "Dave"}, back))
+
+	// Former members are dropped once they cannot be on duty anymore
+	later := changed.Add(RotationPeriod + time.Hour)
+	assert.Equal(t, []groupMember{
+		{Name: "Alice"},
+		{Name: "Carol"},
+		{Name: "Dave", Added: changed},
+	}, setGroupMembers(members, []string{"Alice", "Carol", "Dave"}, later))
+}
+
+func TestExpandMembers(t *testing.T) {
+	added := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	removed := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	groups := map[string][]groupMember{
+		"storage": {{Name: "Alice", Added: added}, {Name: "Bob", Added: added, Removed: removed}},
+		"network": {{Name: "Carol", Added: added}, {Name: "Alice", Added: added}},
+	}
+
+	sched := Schedule{MemberRefs: []string{"Dave", GroupPrefix + "storage", GroupPrefix + "network", "Carol", GroupPrefix + "unknown"}}
+	expandMembers(&sched, groups, added.Add(-time.Hour))
+
+	// Members listed more than once take a single turn
+	assert.Equal(t, []string{"Dave", "Alice", "Bob", "Carol"}, sched.Members)
+	assert.Equal(t, map[string]time.Time{"Alice": added, "Bob": added, "Carol": added}, sched.Joined)
+	assert.Equal(t, map[string]time.Time{"Bob": removed}, sched.Left)
+
+	// Members of the groups when the schedule was created take turns from the start
+	expandMembers(&sched, groups, added)
+	assert.Empty(t, sched.Joined)
+	assert.Equal(t, map[string]time.Time{"Bob": removed}, sched.Left)
+
+	// Schedules without references are left as they are
+	plain := Schedule{Members: []string{"Alice"}}
+	expandMembers(&plain, groups, added)
+	assert.Equal(t, []string{"Alice"}, plain.Members)
+}
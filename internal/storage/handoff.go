@@ -0,0 +1,7 @@
+package storage
+
+import "github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+
+// Handoff is the weekly instant, in UTC, at which the rotation of a schedule
+// moves on to the next member, instead of the start of the shifts.
+type Handoff = rotation.Handoff
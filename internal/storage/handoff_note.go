@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrHandoffNoteExists is returned when adding a handoff note to a shift
+// that has one already, which is updated instead.
+var ErrHandoffNoteExists = errors.New("the shift has a handoff note already")
+
+// HandoffNote is context left by the member on duty for the shift of a
+// schedule to whoever takes the next shift over, e.g. "deploy freeze until
+// Monday". It is shown from the end of its shift until it expires, which is
+// at the end of the next shift or after a while, whichever comes first.
+type HandoffNote struct {
+	ID         int64
+	ScheduleID string
+	// Member is the member on duty for the shift the note is left on.
+	Member     string
+	ShiftStart time.Time
+	ShiftEnd   time.Time
+	// Author is the actor who wrote the note, the only one allowed to
+	// change it.
+	Author    string
+	Text      string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// VisibleAt reports whether the note is shown at the given instant, which
+// is after its shift ended and before it expires.
+func (n HandoffNote) VisibleAt(at time.Time) bool {
+	return !at.Before(n.ShiftEnd) && at.Before(n.ExpiresAt)
+}
+
+// ExpiredAt reports whether the note expired at the given instant.
+func (n HandoffNote) ExpiredAt(at time.Time) bool {
+	return !at.Before(n.ExpiresAt)
+}
+
+// auditDetail describes the note for the audit log.
+func (n HandoffNote) auditDetail() string {
+	return fmt.Sprintf("%d from %s for the shift ending %s: %s",
+		n.ID, n.Member, n.ShiftEnd.UTC().Format(time.RFC3339), n.Text)
+}
+
+// VisibleHandoffNote returns the note of the notes shown at the given
+// instant, the one of the latest shift when several are. It reports false
+// when none is shown.
+func VisibleHandoffNote(notes []HandoffNote, at time.Time) (HandoffNote, bool) {
+	var visible HandoffNote
+	found := false
+
+	for _, note := range notes {
+		if note.VisibleAt(at) && (!found || !note.ShiftEnd.Before(visible.ShiftEnd)) {
+			visible, found = note, true
+		}
+	}
+
+	return visible, found
+}
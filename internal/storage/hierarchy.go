@@ -0,0 +1,52 @@
+package storage
+
+import "errors"
+
+// ErrParentCycle is returned when setting the parent of a team to the team
+// itself or to one of the teams below it.
+var ErrParentCycle = errors.New("team cannot be its own ancestor")
+
+// parentCycle reports whether making parent the parent of team would make
+// the team its own ancestor, given the parents of the teams.
+func parentCycle(parents map[string]string, team, parent string) bool {
+	seen := make(map[string]bool)
+	for parent != "" && !seen[parent] {
+		if parent == team {
+			return true
+		}
+
+		seen[parent] = true
+		parent = parents[parent]
+	}
+
+	return false
+}
+
+// parentDetail is the audit detail of setting the parent of a team.
+func parentDetail(parent string) string {
+	if parent == "" {
+		return "parent cleared"
+	}
+
+	return "parent " + parent
+}
+
+// publicCoverageDetail is the audit detail of enabling or disabling the
+// public coverage of a team.
+func publicCoverageDetail(enabled bool) string {
+	if enabled {
+		return "public coverage enabled"
+	}
+
+	return "public coverage disabled"
+}
+
+// googleCalendarDetail is the audit detail of setting the Google calendar of
+// a team.
+func googleCalendarDetail(calendarID string) string {
+	if calendarID == "" {
+		return "google calendar cleared"
+	}
+
+	return "google calendar " + calendarID
+}
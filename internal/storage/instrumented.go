@@ -0,0 +1,841 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Outcomes of the calls measured by InstrumentedStorage.
+const (
+	OutcomeOK = "ok"
+	// OutcomeRejected is a call the storage answered with a domain error,
+	// such as a quota rejection, which is not a failure of the storage.
+	OutcomeRejected = "rejected"
+	OutcomeCanceled = "canceled"
+	OutcomeError    = "error"
+)
+
+// storageMetrics are the collectors of the storage calls, shared by every
+// instrumented storage registered on the same registry.
+type storageMetrics struct {
+	duration *prometheus.HistogramVec
+	calls    *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+}
+
+// newStorageMetrics registers the collectors of the storage calls on reg,
+// reusing the ones already registered there.
+func newStorageMetrics(reg prometheus.Registerer) (*storageMetrics, error) {
+	labels := []string{"method", "backend", "outcome"}
+
+	duration, err := register(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "oncall_storage_call_duration_seconds",
+		Help:    "Latency of storage calls by method, backend and outcome.",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, labels))
+	if err != nil {
+		return nil, err
+	}
+
+	calls, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "oncall_storage_calls_total",
+		Help: "Number of storage calls by method, backend and outcome.",
+	}, labels))
+	if err != nil {
+		return nil, err
+	}
+
+	errs, err := register(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "oncall_storage_errors_total",
+		Help: "Number of storage calls returning an error by method, backend and outcome.",
+	}, labels))
+	if err != nil {
+		return nil, err
+	}
+
+	return &storageMetrics{duration: duration, calls: calls, errors: errs}, nil
+}
+
+// register registers the collector on reg, returning the one already
+// registered there when there is one.
+func register[C prometheus.Collector](reg prometheus.Registerer, collector C) (C, error) {
+	if err := reg.Register(collector); err != nil {
+		var registered prometheus.AlreadyRegisteredError
+		if errors.As(err, &registered) {
+			if existing, ok := registered.ExistingCollector.(C); ok {
+				return existing, nil
+			}
+		}
+
+		return collector, err
+	}
+
+	return collector, nil
+}
+
+// InstrumentedStorage wraps a Storage recording the latency, the number and
+// the errors of the calls of every method, labeled with the backend. It
+// measures whatever it wraps, so behind the cache and the circuit breaker it
+// only sees the calls that reach the backend.
+type InstrumentedStorage struct {
+	next    Storage
+	backend string
+	metrics *storageMetrics
+	now     func() time.Time
+}
+
+// NewInstrumentedStorage wraps next, a backend named backend, with
+// instrumentation registered on reg. Without a registerer metrics are
+// disabled, and next is returned as it is.
+func NewInstrumentedStorage(next Storage, backend string, reg prometheus.Registerer) (Storage, error) {
+	if reg == nil {
+		return next, nil
+	}
+
+	metrics, err := newStorageMetrics(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InstrumentedStorage{next: next, backend: backend, metrics: metrics, now: time.Now}, nil
+}
+
+// observe records a call of the method that started at start and returned err.
+func (s *InstrumentedStorage) observe(method string, start time.Time, err error) {
+	outcome := callOutcome(err)
+
+	s.metrics.duration.WithLabelValues(method, s.backend, outcome).Observe(s.now().Sub(start).Seconds())
+	s.metrics.calls.WithLabelValues(method, s.backend, outcome).Inc()
+	if outcome != OutcomeOK {
+		s.metrics.errors.WithLabelValues(method, s.backend, outcome).Inc()
+	}
+}
+
+// callOutcome classifies the error of a call. Lookups finding nothing are
+// ok, like the ones reporting it with a bool.
+func callOutcome(err error) string {
+	switch {
+	case err == nil || absent(err):
+		return OutcomeOK
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return OutcomeCanceled
+	case answered(err):
+		return OutcomeRejected
+	default:
+		return OutcomeError
+	}
+}
+
+// AddSchedule adds a schedule.
+func (s *InstrumentedStorage) AddSchedule(ctx context.Context, team string, schedule Schedule) (string, error) {
+	start := s.now()
+	id, err := s.next.AddSchedule(ctx, team, schedule)
+	s.observe("AddSchedule", start, err)
+
+	return id, err
+}
+
+// GetTeam retrieves a team.
+func (s *InstrumentedStorage) GetTeam(ctx context.Context, team string) (Team, error) {
+	start := s.now()
+	result, err := s.next.GetTeam(ctx, team)
+	s.observe("GetTeam", start, err)
+
+	return result, err
+}
+
+// GetCurrentOncall looks up the on-call member.
+func (s *InstrumentedStorage) GetCurrentOncall(ctx context.Context, team string, at time.Time) (string, error) {
+	start := s.now()
+	result, err := s.next.GetCurrentOncall(ctx, team, at)
+	s.observe("GetCurrentOncall", start, err)
+
+	return result, err
+}
+
+// ListTeams lists the teams.
+func (s *InstrumentedStorage) ListTeams(ctx context.Context) ([]string, error) {
+	start := s.now()
+	result, err := s.next.ListTeams(ctx)
+	s.observe("ListTeams", start, err)
+
+	return result, err
+}
+
+// DeleteTeam deletes a team.
+func (s *InstrumentedStorage) DeleteTeam(ctx context.Context, team string) error {
+	start := s.now()
+	err := s.next.DeleteTeam(ctx, team)
+	s.observe("DeleteTeam", start, err)
+
+	return err
+}
+
+// PauseTeam pauses a team.
+func (s *InstrumentedStorage) PauseTeam(ctx context.Context, team string, pause Pause) (bool, error) {
+	start := s.now()
+	found, err := s.next.PauseTeam(ctx, team, pause)
+	s.observe("PauseTeam", start, err)
+
+	return found, err
+}
+
+// UnpauseTeam unpauses a team.
+func (s *InstrumentedStorage) UnpauseTeam(ctx context.Context, team string) (bool, error) {
+	start := s.now()
+	found, err := s.next.UnpauseTeam(ctx, team)
+	s.observe("UnpauseTeam", start, err)
+
+	return found, err
+}
+
+// GetPause retrieves the pause of a team.
+func (s *InstrumentedStorage) GetPause(ctx context.Context, team string) (Pause, bool, error) {
+	start := s.now()
+	result, found, err := s.next.GetPause(ctx, team)
+	s.observe("GetPause", start, err)
+
+	return result, found, err
+}
+
+// AuditLog retrieves the audit log of a team.
+func (s *InstrumentedStorage) AuditLog(ctx context.Context, team string) ([]AuditEntry, error) {
+	start := s.now()
+	result, err := s.next.AuditLog(ctx, team)
+	s.observe("AuditLog", start, err)
+
+	return result, err
+}
+
+// DeleteExpiredSchedules deletes the expired schedules.
+func (s *InstrumentedStorage) DeleteExpiredSchedules(ctx context.Context, before time.Time) (int, error) {
+	start := s.now()
+	result, err := s.next.DeleteExpiredSchedules(ctx, before)
+	s.observe("DeleteExpiredSchedules", start, err)
+
+	return result, err
+}
+
+// PurgeExpiredPauses deletes the expired pauses.
+func (s *InstrumentedStorage) PurgeExpiredPauses(ctx context.Context, before time.Time) (int, error) {
+	start := s.now()
+	result, err := s.next.PurgeExpiredPauses(ctx, before)
+	s.observe("PurgeExpiredPauses", start, err)
+
+	return result, err
+}
+
+// TrimAuditLog trims the audit log.
+func (s *InstrumentedStorage) TrimAuditLog(ctx context.Context, before time.Time) (int, error) {
+	start := s.now()
+	result, err := s.next.TrimAuditLog(ctx, before)
+	s.observe("TrimAuditLog", start, err)
+
+	return result, err
+}
+
+// AddWebhook stores a webhook subscription.
+func (s *InstrumentedStorage) AddWebhook(ctx context.Context, webhook Webhook) (Webhook, error) {
+	start := s.now()
+	result, err := s.next.AddWebhook(ctx, webhook)
+	s.observe("AddWebhook", start, err)
+
+	return result, err
+}
+
+// ListWebhooks lists the webhook subscriptions.
+func (s *InstrumentedStorage) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	start := s.now()
+	result, err := s.next.ListWebhooks(ctx)
+	s.observe("ListWebhooks", start, err)
+
+	return result, err
+}
+
+// DeleteWebhook removes a webhook subscription.
+func (s *InstrumentedStorage) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	start := s.now()
+	found, err := s.next.DeleteWebhook(ctx, id)
+	s.observe("DeleteWebhook", start, err)
+
+	return found, err
+}
+
+// MarkReminderSent records a sent reminder.
+func (s *InstrumentedStorage) MarkReminderSent(ctx context.Context, key string) (bool, error) {
+	start := s.now()
+	found, err := s.next.MarkReminderSent(ctx, key)
+	s.observe("MarkReminderSent", start, err)
+
+	return found, err
+}
+
+// AddCalendarToken stores a calendar feed token.
+func (s *InstrumentedStorage) AddCalendarToken(ctx context.Context, token CalendarToken) (CalendarToken, error) {
+	start := s.now()
+	result, err := s.next.AddCalendarToken(ctx, token)
+	s.observe("AddCalendarToken", start, err)
+
+	return result, err
+}
+
+// GetCalendarToken returns a calendar feed token.
+func (s *InstrumentedStorage) GetCalendarToken(ctx context.Context, hash string) (CalendarToken, bool, error) {
+	start := s.now()
+	result, found, err := s.next.GetCalendarToken(ctx, hash)
+	s.observe("GetCalendarToken", start, err)
+
+	return result, found, err
+}
+
+// DeleteCalendarToken revokes a calendar feed token.
+func (s *InstrumentedStorage) DeleteCalendarToken(ctx context.Context, team string, id int64) (bool, error) {
+	start := s.now()
+	found, err := s.next.DeleteCalendarToken(ctx, team, id)
+	s.observe("DeleteCalendarToken", start, err)
+
+	return found, err
+}
+
+// AddAPIKey stores an API key.
+func (s *InstrumentedStorage) AddAPIKey(ctx context.Context, key APIKey) (APIKey, error) {
+	start := s.now()
+	result, err := s.next.AddAPIKey(ctx, key)
+	s.observe("AddAPIKey", start, err)
+
+	return result, err
+}
+
+// GetAPIKey returns an API key.
+func (s *InstrumentedStorage) GetAPIKey(ctx context.Context, hash string) (APIKey, bool, error) {
+	start := s.now()
+	result, found, err := s.next.GetAPIKey(ctx, hash)
+	s.observe("GetAPIKey", start, err)
+
+	return result, found, err
+}
+
+// ListAPIKeys lists the API keys.
+func (s *InstrumentedStorage) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	start := s.now()
+	result, err := s.next.ListAPIKeys(ctx)
+	s.observe("ListAPIKeys", start, err)
+
+	return result, err
+}
+
+// RevokeAPIKey revokes an API key.
+func (s *InstrumentedStorage) RevokeAPIKey(ctx context.Context, id int64, at time.Time) (bool, error) {
+	start := s.now()
+	found, err := s.next.RevokeAPIKey(ctx, id, at)
+	s.observe("RevokeAPIKey", start, err)
+
+	return found, err
+}
+
+// AddScopedToken stores a scoped token.
+func (s *InstrumentedStorage) AddScopedToken(ctx context.Context, token ScopedToken) (ScopedToken, error) {
+	start := s.now()
+	result, err := s.next.AddScopedToken(ctx, token)
+	s.observe("AddScopedToken", start, err)
+
+	return result, err
+}
+
+// GetScopedToken returns a scoped token.
+func (s *InstrumentedStorage) GetScopedToken(ctx context.Context, hash string) (ScopedToken, bool, error) {
+	start := s.now()
+	result, found, err := s.next.GetScopedToken(ctx, hash)
+	s.observe("GetScopedToken", start, err)
+
+	return result, found, err
+}
+
+// ListScopedTokens lists the scoped tokens.
+func (s *InstrumentedStorage) ListScopedTokens(ctx context.Context) ([]ScopedToken, error) {
+	start := s.now()
+	result, err := s.next.ListScopedTokens(ctx)
+	s.observe("ListScopedTokens", start, err)
+
+	return result, err
+}
+
+// RevokeScopedToken revokes a scoped token.
+func (s *InstrumentedStorage) RevokeScopedToken(ctx context.Context, id int64, at time.Time) (bool, error) {
+	start := s.now()
+	found, err := s.next.RevokeScopedToken(ctx, id, at)
+	s.observe("RevokeScopedToken", start, err)
+
+	return found, err
+}
+
+// RecordScopedTokenUse counts a use of a scoped token.
+func (s *InstrumentedStorage) RecordScopedTokenUse(ctx context.Context, id int64, at time.Time) error {
+	start := s.now()
+	err := s.next.RecordScopedTokenUse(ctx, id, at)
+	s.observe("RecordScopedTokenUse", start, err)
+
+	return err
+}
+
+// FindSchedulesByTags looks schedules up by tags.
+func (s *InstrumentedStorage) FindSchedulesByTags(ctx context.Context, tags []string) ([]TeamSchedule, error) {
+	start := s.now()
+	result, err := s.next.FindSchedulesByTags(ctx, tags)
+	s.observe("FindSchedulesByTags", start, err)
+
+	return result, err
+}
+
+// FindTeamsByMember looks teams up by member.
+func (s *InstrumentedStorage) FindTeamsByMember(ctx context.Context, member string) ([]string, error) {
+	start := s.now()
+	result, err := s.next.FindTeamsByMember(ctx, member)
+	s.observe("FindTeamsByMember", start, err)
+
+	return result, err
+}
+
+// FindSchedulesByMembers looks schedules up by members.
+func (s *InstrumentedStorage) FindSchedulesByMembers(ctx context.Context, members []string) ([]MemberSchedule, error) {
+	start := s.now()
+	result, err := s.next.FindSchedulesByMembers(ctx, members)
+	s.observe("FindSchedulesByMembers", start, err)
+
+	return result, err
+}
+
+// GetSchedule looks a schedule up by ID.
+func (s *InstrumentedStorage) GetSchedule(ctx context.Context, id string) (TeamSchedule, error) {
+	start := s.now()
+	result, err := s.next.GetSchedule(ctx, id)
+	s.observe("GetSchedule", start, err)
+
+	return result, err
+}
+
+// TeamHistory returns the versions of a team.
+func (s *InstrumentedStorage) TeamHistory(ctx context.Context, team string, from, to time.Time) ([]TeamVersion, error) {
+	start := s.now()
+	result, err := s.next.TeamHistory(ctx, team, from, to)
+	s.observe("TeamHistory", start, err)
+
+	return result, err
+}
+
+// HistoryStart returns when the history of a team starts.
+func (s *InstrumentedStorage) HistoryStart(ctx context.Context, team string) (time.Time, bool, error) {
+	start := s.now()
+	result, found, err := s.next.HistoryStart(ctx, team)
+	s.observe("HistoryStart", start, err)
+
+	return result, found, err
+}
+
+// ScheduleVersions lists the versions of a schedule.
+func (s *InstrumentedStorage) ScheduleVersions(ctx context.Context, id string) ([]ScheduleVersion, bool, error) {
+	start := s.now()
+	result, found, err := s.next.ScheduleVersions(ctx, id)
+	s.observe("ScheduleVersions", start, err)
+
+	return result, found, err
+}
+
+// AddPin adds a pin.
+func (s *InstrumentedStorage) AddPin(ctx context.Context, team, scheduleID string, pin Pin) (Pin, bool, error) {
+	start := s.now()
+	result, found, err := s.next.AddPin(ctx, team, scheduleID, pin)
+	s.observe("AddPin", start, err)
+
+	return result, found, err
+}
+
+// DeletePin deletes a pin.
+func (s *InstrumentedStorage) DeletePin(ctx context.Context, team, scheduleID string, id int64) (bool, error) {
+	start := s.now()
+	found, err := s.next.DeletePin(ctx, team, scheduleID, id)
+	s.observe("DeletePin", start, err)
+
+	return found, err
+}
+
+// AddSwapRequest adds a swap request.
+func (s *InstrumentedStorage) AddSwapRequest(ctx context.Context, team string, request SwapRequest) (SwapRequest, bool, error) {
+	start := s.now()
+	result, found, err := s.next.AddSwapRequest(ctx, team, request)
+	s.observe("AddSwapRequest", start, err)
+
+	return result, found, err
+}
+
+// GetSwapRequest looks a swap request up.
+func (s *InstrumentedStorage) GetSwapRequest(ctx context.Context, id int64) (SwapRequest, bool, error) {
+	start := s.now()
+	result, found, err := s.next.GetSwapRequest(ctx, id)
+	s.observe("GetSwapRequest", start, err)
+
+	return result, found, err
+}
+
+// ListSwapRequests lists swap requests.
+func (s *InstrumentedStorage) ListSwapRequests(ctx context.Context, team, scheduleID string) ([]SwapRequest, error) {
+	start := s.now()
+	result, err := s.next.ListSwapRequests(ctx, team, scheduleID)
+	s.observe("ListSwapRequests", start, err)
+
+	return result, err
+}
+
+// DecideSwapRequest decides a swap request.
+func (s *InstrumentedStorage) DecideSwapRequest(ctx context.Context, id int64, accept bool, at time.Time) (SwapRequest, bool, error) {
+	start := s.now()
+	result, found, err := s.next.DecideSwapRequest(ctx, id, accept, at)
+	s.observe("DecideSwapRequest", start, err)
+
+	return result, found, err
+}
+
+// AddUser provisions a user.
+func (s *InstrumentedStorage) AddUser(ctx context.Context, user User) (User, bool, error) {
+	start := s.now()
+	result, found, err := s.next.AddUser(ctx, user)
+	s.observe("AddUser", start, err)
+
+	return result, found, err
+}
+
+// GetUser looks a user up by ID.
+func (s *InstrumentedStorage) GetUser(ctx context.Context, id string) (User, bool, error) {
+	start := s.now()
+	result, found, err := s.next.GetUser(ctx, id)
+	s.observe("GetUser", start, err)
+
+	return result, found, err
+}
+
+// FindUsers looks users up by user name.
+func (s *InstrumentedStorage) FindUsers(ctx context.Context, userName string) ([]User, error) {
+	start := s.now()
+	result, err := s.next.FindUsers(ctx, userName)
+	s.observe("FindUsers", start, err)
+
+	return result, err
+}
+
+// SetUserActive activates or deactivates a user.
+func (s *InstrumentedStorage) SetUserActive(ctx context.Context, id string, active bool) (User, bool, error) {
+	start := s.now()
+	result, found, err := s.next.SetUserActive(ctx, id, active)
+	s.observe("SetUserActive", start, err)
+
+	return result, found, err
+}
+
+// SetUserTimezone sets the zone of a user.
+func (s *InstrumentedStorage) SetUserTimezone(ctx context.Context, id, timezone string) (User, bool, error) {
+	start := s.now()
+	result, found, err := s.next.SetUserTimezone(ctx, id, timezone)
+	s.observe("SetUserTimezone", start, err)
+
+	return result, found, err
+}
+
+// SetTeamMember sets a member of a team roster.
+func (s *InstrumentedStorage) SetTeamMember(ctx context.Context, team string, member TeamMember) (TeamMember, bool, error) {
+	start := s.now()
+	result, found, err := s.next.SetTeamMember(ctx, team, member)
+	s.observe("SetTeamMember", start, err)
+
+	return result, found, err
+}
+
+// ListTeamMembers lists a team roster.
+func (s *InstrumentedStorage) ListTeamMembers(ctx context.Context, team string) ([]TeamMember, error) {
+	start := s.now()
+	result, err := s.next.ListTeamMembers(ctx, team)
+	s.observe("ListTeamMembers", start, err)
+
+	return result, err
+}
+
+// RemoveTeamMember removes a member from a team roster.
+func (s *InstrumentedStorage) RemoveTeamMember(ctx context.Context, team, name string) (bool, error) {
+	start := s.now()
+	found, err := s.next.RemoveTeamMember(ctx, team, name)
+	s.observe("RemoveTeamMember", start, err)
+
+	return found, err
+}
+
+// SetGroup sets a group of a team.
+func (s *InstrumentedStorage) SetGroup(ctx context.Context, team string, group Group) (Group, bool, error) {
+	start := s.now()
+	result, found, err := s.next.SetGroup(ctx, team, group)
+	s.observe("SetGroup", start, err)
+
+	return result, found, err
+}
+
+// ListGroups lists the groups of a team.
+func (s *InstrumentedStorage) ListGroups(ctx context.Context, team string) ([]Group, error) {
+	start := s.now()
+	result, err := s.next.ListGroups(ctx, team)
+	s.observe("ListGroups", start, err)
+
+	return result, err
+}
+
+// DeleteGroup removes a group of a team.
+func (s *InstrumentedStorage) DeleteGroup(ctx context.Context, team, name string) (bool, error) {
+	start := s.now()
+	found, err := s.next.DeleteGroup(ctx, team, name)
+	s.observe("DeleteGroup", start, err)
+
+	return found, err
+}
+
+// AddFreeze freezes a team.
+func (s *InstrumentedStorage) AddFreeze(ctx context.Context, team string, freeze Freeze) (Freeze, bool, error) {
+	start := s.now()
+	result, found, err := s.next.AddFreeze(ctx, team, freeze)
+	s.observe("AddFreeze", start, err)
+
+	return result, found, err
+}
+
+// ListFreezes lists the freezes of a team.
+func (s *InstrumentedStorage) ListFreezes(ctx context.Context, team string) ([]Freeze, error) {
+	start := s.now()
+	result, err := s.next.ListFreezes(ctx, team)
+	s.observe("ListFreezes", start, err)
+
+	return result, err
+}
+
+// CancelFreeze cancels a freeze of a team.
+func (s *InstrumentedStorage) CancelFreeze(ctx context.Context, team string, id int64) (bool, error) {
+	start := s.now()
+	found, err := s.next.CancelFreeze(ctx, team, id)
+	s.observe("CancelFreeze", start, err)
+
+	return found, err
+}
+
+// AddBlackout adds a blackout to a team.
+func (s *InstrumentedStorage) AddBlackout(ctx context.Context, team string, blackout Blackout) (Blackout, bool, error) {
+	start := s.now()
+	result, found, err := s.next.AddBlackout(ctx, team, blackout)
+	s.observe("AddBlackout", start, err)
+
+	return result, found, err
+}
+
+// ListBlackouts lists the blackouts of a team.
+func (s *InstrumentedStorage) ListBlackouts(ctx context.Context, team string) ([]Blackout, error) {
+	start := s.now()
+	result, err := s.next.ListBlackouts(ctx, team)
+	s.observe("ListBlackouts", start, err)
+
+	return result, err
+}
+
+// CancelBlackout cancels a blackout of a team.
+func (s *InstrumentedStorage) CancelBlackout(ctx context.Context, team string, id int64) (bool, error) {
+	start := s.now()
+	found, err := s.next.CancelBlackout(ctx, team, id)
+	s.observe("CancelBlackout", start, err)
+
+	return found, err
+}
+
+// AddHandoffNote adds a handoff note to a team.
+func (s *InstrumentedStorage) AddHandoffNote(ctx context.Context, team string, note HandoffNote) (HandoffNote, bool, error) {
+	start := s.now()
+	result, found, err := s.next.AddHandoffNote(ctx, team, note)
+	s.observe("AddHandoffNote", start, err)
+
+	return result, found, err
+}
+
+// ListHandoffNotes lists the handoff notes of a team.
+func (s *InstrumentedStorage) ListHandoffNotes(ctx context.Context, team string) ([]HandoffNote, error) {
+	start := s.now()
+	result, err := s.next.ListHandoffNotes(ctx, team)
+	s.observe("ListHandoffNotes", start, err)
+
+	return result, err
+}
+
+// UpdateHandoffNote updates a handoff note of a team.
+func (s *InstrumentedStorage) UpdateHandoffNote(ctx context.Context, team string, id int64, text string) (HandoffNote, bool, error) {
+	start := s.now()
+	result, found, err := s.next.UpdateHandoffNote(ctx, team, id, text)
+	s.observe("UpdateHandoffNote", start, err)
+
+	return result, found, err
+}
+
+// DeleteHandoffNote deletes a handoff note of a team.
+func (s *InstrumentedStorage) DeleteHandoffNote(ctx context.Context, team string, id int64) (bool, error) {
+	start := s.now()
+	found, err := s.next.DeleteHandoffNote(ctx, team, id)
+	s.observe("DeleteHandoffNote", start, err)
+
+	return found, err
+}
+
+// AddUnavailability marks a member unavailable.
+func (s *InstrumentedStorage) AddUnavailability(ctx context.Context, unavailability Unavailability) (Unavailability, error) {
+	start := s.now()
+	result, err := s.next.AddUnavailability(ctx, unavailability)
+	s.observe("AddUnavailability", start, err)
+
+	return result, err
+}
+
+// ListUnavailability lists the unavailability of a member.
+func (s *InstrumentedStorage) ListUnavailability(ctx context.Context, member string) ([]Unavailability, error) {
+	start := s.now()
+	result, err := s.next.ListUnavailability(ctx, member)
+	s.observe("ListUnavailability", start, err)
+
+	return result, err
+}
+
+// CancelUnavailability cancels an unavailability window.
+func (s *InstrumentedStorage) CancelUnavailability(ctx context.Context, member string, id int64) (bool, error) {
+	start := s.now()
+	found, err := s.next.CancelUnavailability(ctx, member, id)
+	s.observe("CancelUnavailability", start, err)
+
+	return found, err
+}
+
+// UnavailableMembers looks the unavailable members up.
+func (s *InstrumentedStorage) UnavailableMembers(ctx context.Context, members []string, at time.Time) ([]string, error) {
+	start := s.now()
+	result, err := s.next.UnavailableMembers(ctx, members, at)
+	s.observe("UnavailableMembers", start, err)
+
+	return result, err
+}
+
+// SetRotation sets the rotation of a schedule.
+func (s *InstrumentedStorage) SetRotation(ctx context.Context, team, scheduleID string, rotation Rotation) (bool, error) {
+	start := s.now()
+	found, err := s.next.SetRotation(ctx, team, scheduleID, rotation)
+	s.observe("SetRotation", start, err)
+
+	return found, err
+}
+
+// UpdateScheduleFields changes the fields of a schedule.
+func (s *InstrumentedStorage) UpdateScheduleFields(ctx context.Context, team, scheduleID string, update ScheduleUpdate) (Schedule, error) {
+	start := s.now()
+	sched, err := s.next.UpdateScheduleFields(ctx, team, scheduleID, update)
+	s.observe("UpdateScheduleFields", start, err)
+
+	return sched, err
+}
+
+// RecordAudit records an audit entry.
+func (s *InstrumentedStorage) RecordAudit(ctx context.Context, team, action, detail string) error {
+	start := s.now()
+	err := s.next.RecordAudit(ctx, team, action, detail)
+	s.observe("RecordAudit", start, err)
+
+	return err
+}
+
+// RenameMember renames a member.
+func (s *InstrumentedStorage) RenameMember(ctx context.Context, from, to string) (bool, error) {
+	start := s.now()
+	found, err := s.next.RenameMember(ctx, from, to)
+	s.observe("RenameMember", start, err)
+
+	return found, err
+}
+
+// MergeTeams merges two teams.
+func (s *InstrumentedStorage) MergeTeams(ctx context.Context, target, source, policy string) (TeamMerge, bool, error) {
+	start := s.now()
+	result, found, err := s.next.MergeTeams(ctx, target, source, policy)
+	s.observe("MergeTeams", start, err)
+
+	return result, found, err
+}
+
+// MergedInto looks up the team a team was merged into.
+func (s *InstrumentedStorage) MergedInto(ctx context.Context, team string) (string, bool, error) {
+	start := s.now()
+	result, found, err := s.next.MergedInto(ctx, team)
+	s.observe("MergedInto", start, err)
+
+	return result, found, err
+}
+
+// SetTeamParent sets the parent of a team.
+func (s *InstrumentedStorage) SetTeamParent(ctx context.Context, team, parent string) error {
+	start := s.now()
+	err := s.next.SetTeamParent(ctx, team, parent)
+	s.observe("SetTeamParent", start, err)
+
+	return err
+}
+
+// TeamParent looks up the parent of a team.
+func (s *InstrumentedStorage) TeamParent(ctx context.Context, team string) (string, bool, error) {
+	start := s.now()
+	result, found, err := s.next.TeamParent(ctx, team)
+	s.observe("TeamParent", start, err)
+
+	return result, found, err
+}
+
+// SetPublicCoverage sets the public coverage of a team.
+func (s *InstrumentedStorage) SetPublicCoverage(ctx context.Context, team string, enabled bool) error {
+	start := s.now()
+	err := s.next.SetPublicCoverage(ctx, team, enabled)
+	s.observe("SetPublicCoverage", start, err)
+
+	return err
+}
+
+// PublicCoverage looks up the public coverage of a team.
+func (s *InstrumentedStorage) PublicCoverage(ctx context.Context, team string) (bool, error) {
+	start := s.now()
+	enabled, err := s.next.PublicCoverage(ctx, team)
+	s.observe("PublicCoverage", start, err)
+
+	return enabled, err
+}
+
+// SetGoogleCalendar sets the Google calendar of a team.
+func (s *InstrumentedStorage) SetGoogleCalendar(ctx context.Context, team, calendarID string) error {
+	start := s.now()
+	err := s.next.SetGoogleCalendar(ctx, team, calendarID)
+	s.observe("SetGoogleCalendar", start, err)
+
+	return err
+}
+
+// GoogleCalendar looks up the Google calendar of a team.
+func (s *InstrumentedStorage) GoogleCalendar(ctx context.Context, team string) (string, bool, error) {
+	start := s.now()
+	calendarID, found, err := s.next.GoogleCalendar(ctx, team)
+	s.observe("GoogleCalendar", start, err)
+
+	return calendarID, found, err
+}
+
+// ListGoogleCalendars lists the Google calendars.
+func (s *InstrumentedStorage) ListGoogleCalendars(ctx context.Context) (map[string]string, error) {
+	start := s.now()
+	calendars, err := s.next.ListGoogleCalendars(ctx)
+	s.observe("ListGoogleCalendars", start, err)
+
+	return calendars, err
+}
@@ -0,0 +1,189 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowStorage wraps a MemoryStorage, taking delay on the clock for every
+// call and failing with err when it is set.
+type slowStorage struct {
+	*MemoryStorage
+	clock *fakeClock
+	delay time.Duration
+	err   error
+}
+
+func (s *slowStorage) GetTeam(ctx context.Context, team string) (Team, error) {
+	s.clock.Advance(s.delay)
+	if s.err != nil {
+		return Team{}, s.err
+	}
+	return s.MemoryStorage.GetTeam(ctx, team)
+}
+
+func (s *slowStorage) AddSchedule(ctx context.Context, team string, schedule Schedule) (string, error) {
+	s.clock.Advance(s.delay)
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.MemoryStorage.AddSchedule(ctx, team, schedule)
+}
+
+func newTestInstrumented(t *testing.T, reg prometheus.Registerer) (*InstrumentedStorage, *slowStorage) {
+	t.Helper()
+
+	slow := &slowStorage{
+		MemoryStorage: NewMemoryStorage(),
+		clock:         &fakeClock{now: time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)},
+	}
+
+	s, err := NewInstrumentedStorage(slow, "stub", reg)
+	require.NoError(t, err)
+	require.IsType(t, &InstrumentedStorage{}, s)
+
+	instrumented := s.(*InstrumentedStorage)
+	instrumented.now = slow.clock.Now
+
+	return instrumented, slow
+}
+
+// storageSeries scrapes the registry for the series of the metric, keyed by
+// their method and outcome labels.
+func storageSeries(t *testing.T, reg *prometheus.Registry, name string) map[string]*dto.Metric {
+	t.Helper()
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	series := make(map[string]*dto.Metric)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			assert.Equal(t, "stub", labels["backend"])
+			series[labels["method"]+"/"+labels["outcome"]] = metric
+		}
+	}
+
+	return series
+}
+
+func TestInstrumentedStorage_RecordsCalls(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	s, slow := newTestInstrumented(t, reg)
+	ctx := context.Background()
+
+	slow.delay = 20 * time.Millisecond
+	_, err := s.AddSchedule(ctx, "backend-team", Schedule{Name: "Day", Members: []string{"Alice"}})
+	require.NoError(t, err)
+
+	slow.delay = 250 * time.Millisecond
+	for range 2 {
+		_, err := s.GetTeam(ctx, "backend-team")
+		require.NoError(t, err)
+	}
+
+	slow.delay = 2 * time.Second
+	slow.err = errDown
+	_, err = s.GetTeam(ctx, "backend-team")
+	require.ErrorIs(t, err, errDown)
+
+	slow.delay = 0
+	slow.err = &QuotaError{Resource: QuotaSchedules, Limit: 1, Current: 1}
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule{Name: "Night", Members: []string{"Bob"}})
+	require.Error(t, err)
+
+	slow.err = fmt.Errorf("query: %w", context.Canceled)
+	_, err = s.GetTeam(ctx, "backend-team")
+	require.ErrorIs(t, err, context.Canceled)
+
+	calls := storageSeries(t, reg, "oncall_storage_calls_total")
+	assert.Len(t, calls, 5)
+	assert.InDelta(t, 1, calls["AddSchedule/ok"].GetCounter().GetValue(), 0)
+	assert.InDelta(t, 1, calls["AddSchedule/rejected"].GetCounter().GetValue(), 0)
+	assert.InDelta(t, 2, calls["GetTeam/ok"].GetCounter().GetValue(), 0)
+	assert.InDelta(t, 1, calls["GetTeam/error"].GetCounter().GetValue(), 0)
+	assert.InDelta(t, 1, calls["GetTeam/canceled"].GetCounter().GetValue(), 0)
+
+	errs := storageSeries(t, reg, "oncall_storage_errors_total")
+	assert.Len(t, errs, 3)
+	assert.InDelta(t, 1, errs["AddSchedule/rejected"].GetCounter().GetValue(), 0)
+	assert.InDelta(t, 1, errs["GetTeam/error"].GetCounter().GetValue(), 0)
+	assert.InDelta(t, 1, errs["GetTeam/canceled"].GetCounter().GetValue(), 0)
+
+	durations := storageSeries(t, reg, "oncall_storage_call_duration_seconds")
+	ok := durations["GetTeam/ok"].GetHistogram()
+	assert.Equal(t, uint64(2), ok.GetSampleCount())
+	assert.InDelta(t, 0.5, ok.GetSampleSum(), 1e-9)
+	assert.InDelta(t, 0.02, durations["AddSchedule/ok"].GetHistogram().GetSampleSum(), 1e-9)
+	assert.InDelta(t, 2, durations["GetTeam/error"].GetHistogram().GetSampleSum(), 1e-9)
+
+	// Both calls of 250ms fall in the 256ms bucket and above, none below it
+	for _, bucket := range ok.GetBucket() {
+		if bucket.GetUpperBound() < 0.25 {
+			assert.Zero(t, bucket.GetCumulativeCount(), bucket.GetUpperBound())
+		} else {
+			assert.Equal(t, uint64(2), bucket.GetCumulativeCount(), bucket.GetUpperBound())
+		}
+	}
+}
+
+func TestInstrumentedStorage_SharedRegistry(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	first, _ := newTestInstrumented(t, reg)
+	second, _ := newTestInstrumented(t, reg)
+	ctx := context.Background()
+
+	// A missing team is an answer like any other
+	_, err := first.GetTeam(ctx, "backend-team")
+	require.ErrorIs(t, err, ErrTeamNotFound)
+	_, err = second.GetTeam(ctx, "backend-team")
+	require.ErrorIs(t, err, ErrTeamNotFound)
+
+	calls := storageSeries(t, reg, "oncall_storage_calls_total")
+	assert.InDelta(t, 2, calls["GetTeam/ok"].GetCounter().GetValue(), 0)
+}
+
+func TestInstrumentedStorage_Disabled(t *testing.T) {
+	memory := NewMemoryStorage()
+
+	s, err := NewInstrumentedStorage(memory, "memory", nil)
+	require.NoError(t, err)
+	assert.Same(t, memory, s)
+}
+
+func TestInstrumentedStorage_BehindBreaker(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	s, slow := newTestInstrumented(t, reg)
+	ctx := context.Background()
+
+	breaker := NewBreakerStorage(s, 1, time.Minute)
+	breaker.now = slow.clock.Now
+
+	slow.err = errDown
+	_, err := breaker.GetTeam(ctx, "backend-team")
+	require.ErrorIs(t, err, errDown)
+
+	// Calls rejected by the open breaker never reach the backend
+	_, err = breaker.GetTeam(ctx, "backend-team")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+
+	calls := storageSeries(t, reg, "oncall_storage_calls_total")
+	assert.Len(t, calls, 1)
+	assert.InDelta(t, 1, calls["GetTeam/error"].GetCounter().GetValue(), 0)
+}
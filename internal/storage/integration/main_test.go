@@ -0,0 +1,171 @@
+// Package integration runs the storage conformance suite and the cases only
+// PostgreSQL can show against a PostgreSQL started with testcontainers. The
+// tests are skipped with -short and when Docker is not available.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/1995parham-learning/oncall-schedule/internal/db"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// image is the PostgreSQL the tests run against, the one of docker-compose.yml.
+const image = "postgres:18-alpine"
+
+// lifecycle collects the hooks db.New appends, so the tests start and stop
+// the database the way the application does.
+type lifecycle []fx.Hook
+
+func (l *lifecycle) Append(hook fx.Hook) {
+	*l = append(*l, hook)
+}
+
+// The container and the database are shared by every test of the package and
+// started by the first one that needs them.
+var (
+	startOnce sync.Once
+	startErr  error
+	container *postgres.PostgresContainer
+	database  *db.DB
+	hooks     lifecycle
+)
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+
+	ctx := context.Background()
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if hooks[i].OnStop != nil {
+			if err := hooks[i].OnStop(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to stop database: %v\n", err)
+			}
+		}
+	}
+	if container != nil {
+		if err := testcontainers.TerminateContainer(container); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to terminate postgres container: %v\n", err)
+		}
+	}
+
+	os.Exit(code)
+}
+
+// start runs the container and connects to it, running the migrations.
+func start(ctx context.Context) error {
+	var err error
+
+	container, err = postgres.Run(ctx, image,
+		postgres.WithDatabase("oncall"),
+		postgres.WithUsername("oncall"),
+		postgres.WithPassword("oncall"),
+		postgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start postgres container: %w", err)
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get postgres host: %w", err)
+	}
+	port, err := container.MappedPort(ctx, "5432/tcp")
+	if err != nil {
+		return fmt.Errorf("failed to get postgres port: %w", err)
+	}
+
+	cfg := &config.Config{
+		Database: config.DatabaseConfig{
+			Host:           host,
+			Port:           port.Int(),
+			User:           "oncall",
+			Password:       "oncall",
+			Database:       "oncall",
+			SSLMode:        "disable",
+			MaxConnections: 10,
+			MinConnections: 1,
+			MigrationsPath: "../../../migrations",
+		},
+	}
+
+	database, err = db.New(&hooks, cfg, zap.NewNop())
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		if hook.OnStart != nil {
+			if err := hook.OnStart(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// newDatabase returns the shared database emptied of the data of earlier
+// tests, skipping the test when it cannot run.
+func newDatabase(t *testing.T) *db.DB {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("skipping postgres integration test in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	startOnce.Do(func() { startErr = start(context.Background()) })
+	require.NoError(t, startErr)
+
+	require.NoError(t, truncate(t.Context(), database))
+
+	return database
+}
+
+// newStorage returns a storage on an emptied database.
+func newStorage(t *testing.T) *storage.PostgresStorage {
+	t.Helper()
+
+	return storage.NewPostgresStorage(newDatabase(t), zap.NewNop())
+}
+
+// truncate empties every table but the one of the migrations, restarting
+// their sequences so IDs do not depend on earlier tests.
+func truncate(ctx context.Context, database *db.DB) error {
+	rows, err := database.Pool.Query(ctx,
+		`SELECT tablename FROM pg_tables WHERE schemaname = 'public' AND tablename <> 'schema_migrations'`,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	tables, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to scan tables: %w", err)
+	}
+	if len(tables) == 0 {
+		return nil
+	}
+
+	for i, table := range tables {
+		tables[i] = pgx.Identifier{table}.Sanitize()
+	}
+
+	if _, err := database.Pool.Exec(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE"); err != nil {
+		return fmt.Errorf("failed to truncate tables: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package integration
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/storage/storagetest"
+	"github.com/1995parham-learning/oncall-schedule/migrations"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStorage_Conformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return newStorage(t)
+	})
+}
+
+// count returns the number of rows of the table.
+func count(t *testing.T, table string) int {
+	t.Helper()
+
+	var n int
+	require.NoError(t, database.Pool.QueryRow(context.Background(), "SELECT COUNT(*) FROM "+table).Scan(&n))
+
+	return n
+}
+
+func TestPostgresStorage_AddScheduleRollback(t *testing.T) {
+	s := newStorage(t)
+	ctx := context.Background()
+
+	// The schedule_days check rejects the day after the team, the users and
+	// the schedule are inserted
+	sched := storagetest.Schedule(t, "Broken", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Monday, time.Weekday(7))
+	_, err := s.AddSchedule(ctx, "backend-team", sched)
+	require.Error(t, err)
+
+	_, err = s.GetTeam(ctx, "backend-team")
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+
+	for _, table := range []string{"teams", "users", "team_members", "schedules", "schedule_days", "schedule_members", "rotations", "schedule_versions"} {
+		assert.Zero(t, count(t, table), table)
+	}
+
+	// Nothing is left behind to trip the next schedule up
+	_, err = s.AddSchedule(ctx, "backend-team", storagetest.Schedule(t, "Day", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 1)
+	assert.Equal(t, []time.Weekday{time.Monday}, team.Schedules[0].Days)
+}
+
+func TestPostgresStorage_DuplicateMembers(t *testing.T) {
+	s := newStorage(t)
+	ctx := context.Background()
+
+	// A member appears once in the rotation of a schedule
+	_, err := s.AddSchedule(ctx, "backend-team",
+		storagetest.Schedule(t, "Twice", []string{"Alice", "Bob", "Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.Error(t, err)
+	assert.Zero(t, count(t, "schedules"))
+	assert.Zero(t, count(t, "users"))
+
+	// Members of several schedules are the same user, on the team once
+	_, err = s.AddSchedule(ctx, "backend-team",
+		storagetest.Schedule(t, "Day", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team",
+		storagetest.Schedule(t, "Night", []string{"Bob", "Alice"}, "5:00PM", "11:00PM", time.Monday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "frontend-team",
+		storagetest.Schedule(t, "Day", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, count(t, "users"))
+	assert.Equal(t, 3, count(t, "team_members"))
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 2)
+	for _, sched := range team.Schedules {
+		if sched.Name == "Night" {
+			assert.Equal(t, []string{"Bob", "Alice"}, sched.Members)
+		}
+	}
+}
+
+func TestPostgresStorage_CurrentOncallBoundaries(t *testing.T) {
+	s := newStorage(t)
+	ctx := context.Background()
+
+	_, err := s.AddSchedule(ctx, "backend-team",
+		storagetest.Schedule(t, "Day", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday, time.Wednesday))
+	require.NoError(t, err)
+
+	// 2025-04-28 is a Monday
+	monday := time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		at     time.Time
+		oncall bool
+	}{
+		{"before start", monday.Add(9*time.Hour - time.Second), false},
+		{"at start", monday.Add(9 * time.Hour), true},
+		{"before end", monday.Add(17*time.Hour - time.Nanosecond), true},
+		{"at end", monday.Add(17 * time.Hour), false},
+		{"day off", monday.AddDate(0, 0, 1).Add(12 * time.Hour), false},
+		{"other day", monday.AddDate(0, 0, 2).Add(12 * time.Hour), true},
+		{"other zone", monday.Add(9 * time.Hour).In(tehran), true},
+		{"sunday before", monday.Add(-12 * time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			member, err := s.GetCurrentOncall(ctx, "backend-team", tt.at)
+			if !tt.oncall {
+				require.ErrorIs(t, err, storage.ErrNoCoverage)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Alice", member)
+		})
+	}
+}
+
+func TestPostgresStorage_RotationInitialized(t *testing.T) {
+	s := newStorage(t)
+	ctx := context.Background()
+
+	_, err := s.AddSchedule(ctx, "backend-team",
+		storagetest.Schedule(t, "Day", []string{"Bob", "Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team",
+		storagetest.Schedule(t, "Nobody", nil, "9:00AM", "5:00PM", time.Tuesday))
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 2)
+
+	for _, sched := range team.Schedules {
+		id, err := strconv.Atoi(sched.ID)
+		require.NoError(t, err)
+
+		var (
+			member   string
+			position int
+		)
+		err = database.Pool.QueryRow(ctx,
+			`SELECT u.username, r.current_position
+			 FROM rotations r
+			 JOIN users u ON u.id = r.current_user_id
+			 WHERE r.schedule_id = $1`,
+			id,
+		).Scan(&member, &position)
+
+		switch sched.Name {
+		case "Day":
+			// The rotation starts at the first member as given
+			require.NoError(t, err)
+			assert.Equal(t, "Bob", member)
+			assert.Zero(t, position)
+		case "Nobody":
+			// Schedules without members have no rotation
+			require.ErrorIs(t, err, pgx.ErrNoRows)
+		}
+	}
+
+	assert.Equal(t, 1, count(t, "rotations"))
+}
+
+func TestDB_MigrationStatus(t *testing.T) {
+	database := newDatabase(t)
+
+	version, dirty, err := database.MigrationStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, migrations.Latest(), version)
+	assert.False(t, dirty)
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Policies for the schedules of a merged team named like a schedule of the
+// team it is merged into.
+const (
+	// MergeFail rejects the merge with a MergeConflictError.
+	MergeFail = "fail"
+	// MergeRename suffixes the schedules of the merged team with its name.
+	MergeRename = "rename"
+)
+
+// ErrMergeSelf is returned when merging a team into itself.
+var ErrMergeSelf = errors.New("cannot merge a team into itself")
+
+// MergeConflictError is returned when merging a team whose schedules are
+// named like schedules of the team it is merged into with the MergeFail policy.
+type MergeConflictError struct {
+	Schedules []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("schedules %s exist in both teams", strings.Join(e.Schedules, ", "))
+}
+
+// TeamMerge is the outcome of merging the source team into the target.
+type TeamMerge struct {
+	Source string
+	Target string
+	// Schedules is the number of schedules moved to the target.
+	Schedules int
+	// Renamed maps the schedules of the source named like schedules of the
+	// target to their new name.
+	Renamed map[string]string
+}
+
+// auditDetail describes the merge for the audit log.
+func (m TeamMerge) auditDetail() string {
+	detail := fmt.Sprintf("%s into %s", m.Source, m.Target)
+
+	names := slices.Sorted(func(yield func(string) bool) {
+		for name := range m.Renamed {
+			if !yield(name) {
+				return
+			}
+		}
+	})
+	for _, name := range names {
+		detail += fmt.Sprintf(", renamed %s to %s", name, m.Renamed[name])
+	}
+
+	return detail
+}
+
+// mergeNames returns the new names of the schedules of the source team that
+// are named like one of the taken names, by schedule ID, suffixing them with
+// the name of the source team and a counter when needed. With the MergeFail
+// policy a MergeConflictError is returned instead.
+func mergeNames(source string, schedules []Schedule, taken []string, policy string) (map[string]string, error) {
+	var conflicts []string
+	for _, sched := range schedules {
+		if slices.Contains(taken, sched.Name) {
+			conflicts = append(conflicts, sched.Name)
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return nil, nil
+	}
+	if policy != MergeRename {
+		return nil, &MergeConflictError{Schedules: conflicts}
+	}
+
+	// Schedules keep their names unless they collide, renamed ones take
+	// names neither team has
+	names := slices.Clone(taken)
+	for _, sched := range schedules {
+		names = append(names, sched.Name)
+	}
+
+	renamed := make(map[string]string)
+	for _, sched := range schedules {
+		if !slices.Contains(taken, sched.Name) {
+			continue
+		}
+
+		name := fmt.Sprintf("%s (%s)", sched.Name, source)
+		for n := 2; slices.Contains(names, name); n++ {
+			name = fmt.Sprintf("%s (%s %d)", sched.Name, source, n)
+		}
+
+		renamed[sched.ID] = name
+		names = append(names, name)
+	}
+
+	return renamed, nil
+}
+
+// newTeamMerge describes the merge of the schedules of the source, renamed
+// by ID.
+func newTeamMerge(target, source string, schedules []Schedule, renamed map[string]string) TeamMerge {
+	merge := TeamMerge{Source: source, Target: target, Schedules: len(schedules)}
+
+	for _, sched := range schedules {
+		if name, ok := renamed[sched.ID]; ok {
+			if merge.Renamed == nil {
+				merge.Renamed = make(map[string]string)
+			}
+			merge.Renamed[sched.Name] = name
+		}
+	}
+
+	return merge
+}
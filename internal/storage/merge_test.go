@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeNames(t *testing.T) {
+	schedules := []Schedule{
+		{ID: "1", Name: "Primary"},
+		{ID: "2", Name: "Primary (edge)"},
+		{ID: "3", Name: "Weekend"},
+	}
+
+	renamed, err := mergeNames("edge", schedules, []string{"Primary", "Secondary"}, MergeRename)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"1": "Primary (edge 2)"}, renamed)
+
+	renamed, err = mergeNames("edge", schedules, []string{"Secondary"}, MergeFail)
+	require.NoError(t, err)
+	assert.Empty(t, renamed)
+
+	_, err = mergeNames("edge", schedules, []string{"Primary", "Weekend"}, MergeFail)
+	var conflictErr *MergeConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, []string{"Primary", "Weekend"}, conflictErr.Schedules)
+	assert.EqualError(t, err, "schedules Primary, Weekend exist in both teams")
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pause is a maintenance window of a team, during which it has no on-call
+// member and no alerts are routed to it. A zero Until means the team stays
+// paused until it is unpaused.
+type Pause struct {
+	Reason string
+	Since  time.Time
+	Until  time.Time
+}
+
+// Active reports whether the pause covers the given instant. An expired pause
+// is simply no longer active, so teams unpause themselves without a timer.
+func (p Pause) Active(at time.Time) bool {
+	if at.Before(p.Since) {
+		return false
+	}
+
+	return p.Until.IsZero() || at.Before(p.Until)
+}
+
+// auditDetail describes the pause for the audit log.
+func (p Pause) auditDetail() string {
+	detail := "until unpaused"
+	if !p.Until.IsZero() {
+		detail = "until " + p.Until.UTC().Format(time.RFC3339)
+	}
+	if p.Reason != "" {
+		detail += fmt.Sprintf(": %s", p.Reason)
+	}
+
+	return detail
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+)
+
+// Pin puts a member on duty for the shifts of a schedule starting on a date,
+// in place of the member the rotation gives. Pins never shift the rotation,
+// the turns after a pinned day are the same as without it.
+type Pin struct {
+	ID int64
+	// Date is midnight UTC of the pinned day, see PinDate.
+	Date time.Time
+	// ShiftStart limits the pin to the single shift of the date starting at
+	// it, as forcing the next shift does. It is zero for the whole date.
+	ShiftStart time.Time
+	Member     string
+	CreatedAt  time.Time
+}
+
+// PinDate returns midnight UTC of the date of the instant in UTC, which is
+// the date pins are matched on.
+func PinDate(at time.Time) time.Time {
+	return rotation.PinDate(at)
+}
@@ -2,11 +2,16 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/1995parham-learning/oncall-schedule/internal/db"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"go.uber.org/zap"
 )
 
@@ -24,14 +29,12 @@ func NewPostgresStorage(database *db.DB, logger *zap.Logger) *PostgresStorage {
 	}
 }
 
-// AddSchedule adds a schedule to a team.
-func (s *PostgresStorage) AddSchedule(teamName string, schedule Schedule) error {
-	ctx := context.Background()
-
+// AddSchedule adds a schedule to a team and returns the serial ID of it.
+func (s *PostgresStorage) AddSchedule(ctx context.Context, teamName string, schedule Schedule) (string, error) {
 	// Start a transaction
 	tx, err := s.db.Pool.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer func() {
 		if rbErr := tx.Rollback(ctx); rbErr != nil {
@@ -39,7 +42,7 @@ func (s *PostgresStorage) AddSchedule(teamName string, schedule Schedule) error
 		}
 	}()
 
-	// Get or create team
+	// Get or create team, which locks its row until the transaction ends
 	var teamID int
 	err = tx.QueryRow(ctx,
 		`INSERT INTO teams (name) VALUES ($1)
@@ -48,94 +51,118 @@ func (s *PostgresStorage) AddSchedule(teamName string, schedule Schedule) error
 		teamName,
 	).Scan(&teamID)
 	if err != nil {
-		return fmt.Errorf("failed to get/create team: %w", err)
+		return "", fmt.Errorf("failed to get/create team: %w", err)
 	}
 
-	// Get or create users for each member
-	userIDs := make(map[string]int)
-	for _, member := range schedule.Members {
-		var userID int
-		// For now, we'll use member name as both username and email
-		// In a real system, these would be proper user objects
+	// A team created with the name of a merged one is not merged
+	if _, err = tx.Exec(ctx, `DELETE FROM team_merges WHERE source = $1`, teamName); err != nil {
+		return "", fmt.Errorf("failed to clear team merge: %w", err)
+	}
+
+	// Concurrent additions to the team wait for the lock, so the count holds until commit
+	if hasQuota(ctx, QuotaSchedules) {
+		var count int
 		err = tx.QueryRow(ctx,
-			`INSERT INTO users (username, email) VALUES ($1, $2)
-			 ON CONFLICT (username) DO UPDATE SET username = EXCLUDED.username
-			 RETURNING id`,
-			member,
-			fmt.Sprintf("%s@example.com", member),
-		).Scan(&userID)
+			`SELECT COUNT(*) FROM schedules WHERE team_id = $1 AND deleted_at IS NULL`,
+			teamID,
+		).Scan(&count)
 		if err != nil {
-			return fmt.Errorf("failed to get/create user %s: %w", member, err)
+			return "", fmt.Errorf("failed to count schedules: %w", err)
 		}
-		userIDs[member] = userID
-
-		// Add user to team if not already a member
-		_, err = tx.Exec(ctx,
-			`INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, $3)
-			 ON CONFLICT (team_id, user_id) DO NOTHING`,
-			teamID, userID, "member",
-		)
-		if err != nil {
-			return fmt.Errorf("failed to add user to team: %w", err)
+		if err := checkQuota(ctx, QuotaSchedules, teamName, count); err != nil {
+			return "", err
 		}
 	}
 
+	userIDs, err := upsertTeamMembers(ctx, tx, teamID, schedule.Members)
+	if err != nil {
+		return "", err
+	}
+
 	// Insert schedule
 	var scheduleID int
 	err = tx.QueryRow(ctx,
-		`INSERT INTO schedules (team_id, name, start_time, end_time, timezone)
-		 VALUES ($1, $2, $3, $4, $5)
+		`INSERT INTO schedules (team_id, name, description, notes, start_time, end_time, timezone, cron, rrule, anchor, valid_until, rotation_offset, rotation_manual, shift_split, required_count, handoff_day, handoff_time, routing, team_members, compensation)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
 		 RETURNING id`,
 		teamID,
 		schedule.Name,
+		schedule.Description,
+		schedule.Notes,
 		schedule.Start.Format("15:04:05"),
 		schedule.End.Format("15:04:05"),
 		"UTC",
+		schedule.Cron,
+		schedule.RRule,
+		nullableDate(schedule.Anchor),
+		nullableDate(schedule.ValidUntil),
+		schedule.RotationOffset,
+		schedule.Manual,
+		schedule.Split,
+		schedule.RequiredCount,
+		handoffDay(schedule.Handoff),
+		handoffTime(schedule.Handoff),
+		routingColumn(schedule.Routing),
+		schedule.TeamMembers,
+		schedule.Compensation,
 	).Scan(&scheduleID)
+	if uniqueViolation(err, scheduleNameIndex) {
+		return "", ErrScheduleExists
+	}
 	if err != nil {
-		return fmt.Errorf("failed to insert schedule: %w", err)
+		return "", fmt.Errorf("failed to insert schedule: %w", err)
 	}
 
-	// Insert schedule days
-	for _, day := range schedule.Days {
-		_, err = tx.Exec(ctx,
-			`INSERT INTO schedule_days (schedule_id, day_of_week) VALUES ($1, $2)`,
-			scheduleID, int(day),
-		)
-		if err != nil {
-			return fmt.Errorf("failed to insert schedule day: %w", err)
-		}
+	if err = insertScheduleDays(ctx, tx, scheduleID, schedule.Days, schedule.DayAssignments, userIDs); err != nil {
+		return "", err
+	}
+
+	if err = insertScheduleMembers(ctx, tx, scheduleID, schedule.Members, schedule.MemberRefs, userIDs); err != nil {
+		return "", err
+	}
+
+	if err = insertScheduleTags(ctx, tx, scheduleID, schedule.Tags); err != nil {
+		return "", err
 	}
 
-	// Insert schedule members with their position in rotation
-	for position, member := range schedule.Members {
-		userID := userIDs[member]
+	// Insert the pins the schedule comes with
+	for _, pin := range schedule.Pins {
 		_, err = tx.Exec(ctx,
-			`INSERT INTO schedule_members (schedule_id, user_id, position)
-			 VALUES ($1, $2, $3)`,
-			scheduleID, userID, position,
+			`INSERT INTO schedule_pins (schedule_id, pin_date, member, shift_start) VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (schedule_id, pin_date) DO UPDATE SET member = EXCLUDED.member, shift_start = EXCLUDED.shift_start`,
+			scheduleID, PinDate(pin.Date), pin.Member, pinShiftStart(pin),
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert schedule member: %w", err)
+			return "", fmt.Errorf("failed to insert schedule pin: %w", err)
 		}
 	}
 
-	// Initialize rotation state for the schedule
-	if len(schedule.Members) > 0 {
-		firstUserID := userIDs[schedule.Members[0]]
+	// Initialize rotation state for the schedule, the first member of the
+	// team or of a group is only known once the schedule is read
+	if len(schedule.Members) > 0 || schedule.TeamMembers || len(schedule.MemberRefs) > 0 {
+		var firstUserID *int
+		if len(schedule.Members) > 0 {
+			id := userIDs[schedule.Members[0]]
+			firstUserID = &id
+		}
 		_, err = tx.Exec(ctx,
 			`INSERT INTO rotations (schedule_id, current_user_id, current_position, last_rotation_at)
 			 VALUES ($1, $2, $3, $4)`,
 			scheduleID, firstUserID, 0, time.Now(),
 		)
 		if err != nil {
-			return fmt.Errorf("failed to initialize rotation: %w", err)
+			return "", fmt.Errorf("failed to initialize rotation: %w", err)
 		}
 	}
 
+	schedule.ID = strconv.Itoa(scheduleID)
+	if err = insertVersion(ctx, tx, scheduleID, schedule); err != nil {
+		return "", err
+	}
+
 	// Commit transaction
 	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	s.log.Info("schedule added successfully",
@@ -144,13 +171,112 @@ func (s *PostgresStorage) AddSchedule(teamName string, schedule Schedule) error
 		zap.Int("schedule_id", scheduleID),
 	)
 
+	return schedule.ID, nil
+}
+
+// upsertTeamMembers gets or creates the users of the members and adds them
+// to the team, returning their IDs by name.
+func upsertTeamMembers(ctx context.Context, tx pgx.Tx, teamID int, members []string) (map[string]int, error) {
+	userIDs := make(map[string]int, len(members))
+	for _, member := range members {
+		var userID int
+		// For now, we'll use member name as both username and email
+		// In a real system, these would be proper user objects
+		err := tx.QueryRow(ctx,
+			`INSERT INTO users (username, email) VALUES ($1, $2)
+			 ON CONFLICT (username) DO UPDATE SET username = EXCLUDED.username
+			 RETURNING id`,
+			member,
+			fmt.Sprintf("%s@example.com", member),
+		).Scan(&userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get/create user %s: %w", member, err)
+		}
+		userIDs[member] = userID
+
+		// Add user to team if not already a member
+		_, err = tx.Exec(ctx,
+			`INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, $3)
+			 ON CONFLICT (team_id, user_id) DO NOTHING`,
+			teamID, userID, "member",
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add user to team: %w", err)
+		}
+	}
+
+	return userIDs, nil
+}
+
+// insertScheduleDays inserts the days of a schedule with their assignee, if
+// any, whose user is looked up in userIDs.
+func insertScheduleDays(
+	ctx context.Context, tx pgx.Tx, scheduleID int, days []time.Weekday, assignments map[time.Weekday]string, userIDs map[string]int,
+) error {
+	for _, day := range days {
+		var assigneeID *int
+		if member, ok := assignments[day]; ok {
+			id := userIDs[member]
+			assigneeID = &id
+		}
+
+		_, err := tx.Exec(ctx,
+			`INSERT INTO schedule_days (schedule_id, day_of_week, assignee_id) VALUES ($1, $2, $3)`,
+			scheduleID, int(day), assigneeID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert schedule day: %w", err)
+		}
+	}
+
 	return nil
 }
 
-// GetTeam retrieves a team's schedules.
-func (s *PostgresStorage) GetTeam(teamName string) (Team, bool, error) {
-	ctx := context.Background()
+// insertScheduleMembers inserts the members of a schedule with their
+// position in rotation, along with the members as given when they reference
+// groups.
+func insertScheduleMembers(ctx context.Context, tx pgx.Tx, scheduleID int, members, refs []string, userIDs map[string]int) error {
+	for position, member := range members {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO schedule_members (schedule_id, user_id, position)
+			 VALUES ($1, $2, $3)`,
+			scheduleID, userIDs[member], position,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert schedule member: %w", err)
+		}
+	}
+
+	for position, member := range refs {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO schedule_member_refs (schedule_id, member, position) VALUES ($1, $2, $3)`,
+			scheduleID, member, position,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert schedule member reference: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// insertScheduleTags inserts the tags of a schedule in their given order.
+func insertScheduleTags(ctx context.Context, tx pgx.Tx, scheduleID int, tags []string) error {
+	for position, tag := range tags {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO schedule_tags (schedule_id, tag, position) VALUES ($1, $2, $3)`,
+			scheduleID, tag, position,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert schedule tag: %w", err)
+		}
+	}
+
+	return nil
+}
 
+// GetTeam retrieves a team's schedules.
+func (s *PostgresStorage) GetTeam(ctx context.Context, teamName string) (Team, error) {
 	// Get team ID
 	var teamID int
 	err := s.db.Pool.QueryRow(ctx,
@@ -158,141 +284,3602 @@ func (s *PostgresStorage) GetTeam(teamName string) (Team, bool, error) {
 		teamName,
 	).Scan(&teamID)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return Team{}, false, nil
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Team{}, ErrTeamNotFound
 		}
-		return Team{}, false, fmt.Errorf("failed to get team: %w", err)
+		return Team{}, fmt.Errorf("failed to get team: %w", err)
 	}
 
 	// Get all schedules for the team
 	rows, err := s.db.Pool.Query(ctx,
-		`SELECT id, name, start_time, end_time FROM schedules WHERE team_id = $1`,
+		`SELECT id, name, description, notes, start_time, end_time, COALESCE(cron, ''), COALESCE(rrule, ''), anchor, valid_until, rotation_offset, rotation_manual, shift_split, required_count, handoff_day, handoff_time,
+		        NULLIF(routing, '{}'), team_members, compensation
+		 FROM schedules WHERE team_id = $1 AND deleted_at IS NULL
+		 ORDER BY id`,
 		teamID,
 	)
 	if err != nil {
-		return Team{}, false, fmt.Errorf("failed to query schedules: %w", err)
+		return Team{}, fmt.Errorf("failed to query schedules: %w", err)
 	}
 	defer rows.Close()
 
 	var schedules []Schedule
 	for rows.Next() {
 		var scheduleID int
-		var name string
-		var startTime, endTime time.Time
+		var sched Schedule
+		var anchor, validUntil, clock *time.Time
+		var day *int16
 
-		err = rows.Scan(&scheduleID, &name, &startTime, &endTime)
+		err = rows.Scan(&scheduleID, &sched.Name, &sched.Description, &sched.Notes, &sched.Start, &sched.End,
+			&sched.Cron, &sched.RRule, &anchor, &validUntil, &sched.RotationOffset, &sched.Manual, &sched.Split, &sched.RequiredCount, &day, &clock, &sched.Routing, &sched.TeamMembers, &sched.Compensation)
 		if err != nil {
-			return Team{}, false, fmt.Errorf("failed to scan schedule: %w", err)
+			return Team{}, fmt.Errorf("failed to scan schedule: %w", err)
 		}
+		sched.ID = strconv.Itoa(scheduleID)
+		sched.Anchor = derefTime(anchor)
+		sched.ValidUntil = derefTime(validUntil)
+		sched.Handoff = scanHandoff(day, clock)
 
-		// Get days for this schedule
-		dayRows, err := s.db.Pool.Query(ctx,
-			`SELECT day_of_week FROM schedule_days WHERE schedule_id = $1 ORDER BY day_of_week`,
-			scheduleID,
-		)
-		if err != nil {
-			return Team{}, false, fmt.Errorf("failed to query schedule days: %w", err)
+		if err = s.loadScheduleDetails(ctx, scheduleID, &sched); err != nil {
+			return Team{}, err
 		}
 
-		var days []time.Weekday
-		for dayRows.Next() {
-			var day int
-			if err = dayRows.Scan(&day); err != nil {
-				dayRows.Close()
-				return Team{}, false, fmt.Errorf("failed to scan day: %w", err)
+		schedules = append(schedules, sched)
+	}
+
+	if err = rows.Err(); err != nil {
+		return Team{}, fmt.Errorf("error iterating schedules: %w", err)
+	}
+
+	return Team{Schedules: schedules}, nil
+}
+
+// loadMembers sets the members of the schedule with the given ID in rotation
+// order, along with the ones whose user is deactivated. Schedules using the
+// members of their team rotate through its roster in the order they joined
+// it, whose join times are set too. References to groups are expanded to the
+// members of the groups, see expandMembers.
+func (s *PostgresStorage) loadMembers(ctx context.Context, scheduleID int, sched *Schedule) error {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT u.username, u.active, m.created_at,
+		        ROW_NUMBER() OVER (ORDER BY m.created_at, u.username) AS position
+		 FROM schedules s
+		 JOIN team_members m ON m.team_id = s.team_id AND COALESCE(m.role, $2) = $2
+		 JOIN users u ON m.user_id = u.id
+		 WHERE s.id = $1 AND s.team_members
+		 UNION ALL
+		 SELECT u.username, u.active, NULL, sm.position
+		 FROM schedule_members sm
+		 JOIN users u ON sm.user_id = u.id
+		 WHERE sm.schedule_id = $1
+		 ORDER BY position`,
+		scheduleID, MemberRoleMember,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query schedule members: %w", err)
+	}
+	defer rows.Close()
+
+	sched.Members, sched.Inactive, sched.Joined = nil, nil, nil
+	for rows.Next() {
+		var username string
+		var active bool
+		var joinedAt *time.Time
+		var position int64
+		if err = rows.Scan(&username, &active, &joinedAt, &position); err != nil {
+			return fmt.Errorf("failed to scan member: %w", err)
+		}
+		sched.Members = append(sched.Members, username)
+		if !active {
+			sched.Inactive = append(sched.Inactive, username)
+		}
+		if joinedAt != nil {
+			if sched.Joined == nil {
+				sched.Joined = make(map[string]time.Time)
 			}
-			days = append(days, time.Weekday(day))
-		}
-		dayRows.Close()
-
-		// Get members for this schedule (in rotation order)
-		memberRows, err := s.db.Pool.Query(ctx,
-			`SELECT u.username
-			 FROM schedule_members sm
-			 JOIN users u ON sm.user_id = u.id
-			 WHERE sm.schedule_id = $1
-			 ORDER BY sm.position`,
-			scheduleID,
-		)
-		if err != nil {
-			return Team{}, false, fmt.Errorf("failed to query schedule members: %w", err)
+			sched.Joined[username] = *joinedAt
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating schedule members: %w", err)
+	}
+	rows.Close()
+
+	return s.expandGroups(ctx, scheduleID, sched)
+}
+
+// expandGroups expands the references to groups of the schedule with the
+// given ID, if any, into the members of the groups.
+func (s *PostgresStorage) expandGroups(ctx context.Context, scheduleID int, sched *Schedule) error {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT member FROM schedule_member_refs WHERE schedule_id = $1 ORDER BY position`,
+		scheduleID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query schedule member references: %w", err)
+	}
+
+	sched.MemberRefs = nil
+	for rows.Next() {
+		var member string
+		if err = rows.Scan(&member); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan schedule member reference: %w", err)
+		}
+		sched.MemberRefs = append(sched.MemberRefs, member)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating schedule member references: %w", err)
+	}
+
+	if len(sched.MemberRefs) == 0 {
+		return nil
+	}
+
+	rows, err = s.db.Pool.Query(ctx,
+		`SELECT g.name, gm.member, gm.added_at, gm.removed_at, s.created_at
+		 FROM schedules s
+		 JOIN team_groups g ON g.team_id = s.team_id
+		 JOIN team_group_members gm ON gm.group_id = g.id
+		 WHERE s.id = $1
+		 ORDER BY g.name, gm.position`,
+		scheduleID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query group members: %w", err)
+	}
+
+	groups := make(map[string][]groupMember)
+	var created *time.Time
+	for rows.Next() {
+		var name string
+		var member groupMember
+		var added, removed *time.Time
+		if err = rows.Scan(&name, &member.Name, &added, &removed, &created); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan group member: %w", err)
+		}
+		member.Added, member.Removed = derefTime(added), derefTime(removed)
+		groups[name] = append(groups[name], member)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating group members: %w", err)
+	}
+
+	expandMembers(sched, groups, derefTime(created))
+
+	// Members of groups are matched to users by name, like the others
+	rows, err = s.db.Pool.Query(ctx,
+		`SELECT username FROM users WHERE username = ANY($1) AND NOT active ORDER BY username`,
+		sched.Members,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query inactive members: %w", err)
+	}
+	defer rows.Close()
+
+	sched.Inactive = nil
+	for rows.Next() {
+		var username string
+		if err = rows.Scan(&username); err != nil {
+			return fmt.Errorf("failed to scan inactive member: %w", err)
+		}
+		sched.Inactive = append(sched.Inactive, username)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("error iterating inactive members: %w", err)
+	}
+
+	return nil
+}
+
+// loadScheduleDetails loads the days with their assignees, members in
+// rotation order, tags and pins of the schedule with the given ID.
+func (s *PostgresStorage) loadScheduleDetails(ctx context.Context, scheduleID int, sched *Schedule) error {
+	dayRows, err := s.db.Pool.Query(ctx,
+		`SELECT sd.day_of_week, a.username
+		 FROM schedule_days sd
+		 LEFT JOIN users a ON sd.assignee_id = a.id
+		 WHERE sd.schedule_id = $1
+		 ORDER BY sd.day_of_week`,
+		scheduleID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query schedule days: %w", err)
+	}
+
+	for dayRows.Next() {
+		var day int
+		var assignee *string
+		if err = dayRows.Scan(&day, &assignee); err != nil {
+			dayRows.Close()
+			return fmt.Errorf("failed to scan day: %w", err)
 		}
+		sched.Days = append(sched.Days, time.Weekday(day))
 
-		var members []string
-		for memberRows.Next() {
-			var username string
-			if err = memberRows.Scan(&username); err != nil {
-				memberRows.Close()
-				return Team{}, false, fmt.Errorf("failed to scan member: %w", err)
+		if assignee != nil {
+			if sched.DayAssignments == nil {
+				sched.DayAssignments = make(map[time.Weekday]string)
 			}
-			members = append(members, username)
+			sched.DayAssignments[time.Weekday(day)] = *assignee
+		}
+	}
+	dayRows.Close()
+
+	if err = s.loadMembers(ctx, scheduleID, sched); err != nil {
+		return err
+	}
+
+	tagRows, err := s.db.Pool.Query(ctx,
+		`SELECT tag FROM schedule_tags WHERE schedule_id = $1 ORDER BY position`,
+		scheduleID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query schedule tags: %w", err)
+	}
+
+	for tagRows.Next() {
+		var tag string
+		if err = tagRows.Scan(&tag); err != nil {
+			tagRows.Close()
+			return fmt.Errorf("failed to scan tag: %w", err)
 		}
-		memberRows.Close()
+		sched.Tags = append(sched.Tags, tag)
+	}
+	tagRows.Close()
+
+	if sched.Pins, err = s.loadPins(ctx, scheduleID, time.Time{}); err != nil {
+		return err
+	}
+
+	return nil
+}
 
-		schedules = append(schedules, Schedule{
-			Name:    name,
-			Members: members,
-			Days:    days,
-			Start:   startTime,
-			End:     endTime,
-		})
+// pinShiftStart returns the shift_start column of a pin, NULL for whole dates.
+func pinShiftStart(pin Pin) *time.Time {
+	if pin.ShiftStart.IsZero() {
+		return nil
+	}
+
+	return &pin.ShiftStart
+}
+
+// loadPins loads the pins of the schedule with the given ID dated on or
+// after since, ordered by date.
+func (s *PostgresStorage) loadPins(ctx context.Context, scheduleID int, since time.Time) ([]Pin, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT id, pin_date, member, shift_start, created_at
+		 FROM schedule_pins
+		 WHERE schedule_id = $1 AND pin_date >= $2
+		 ORDER BY pin_date`,
+		scheduleID, PinDate(since),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedule pins: %w", err)
+	}
+	defer rows.Close()
+
+	var pins []Pin
+	for rows.Next() {
+		var pin Pin
+		var shiftStart *time.Time
+		if err = rows.Scan(&pin.ID, &pin.Date, &pin.Member, &shiftStart, &pin.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan pin: %w", err)
+		}
+		pin.Date = PinDate(pin.Date)
+		if shiftStart != nil {
+			pin.ShiftStart = shiftStart.UTC()
+		}
+		pins = append(pins, pin)
 	}
 
 	if err = rows.Err(); err != nil {
-		return Team{}, false, fmt.Errorf("error iterating schedules: %w", err)
+		return nil, fmt.Errorf("error iterating schedule pins: %w", err)
 	}
 
-	return Team{Schedules: schedules}, true, nil
+	return pins, nil
 }
 
 // GetCurrentOncall returns the currently oncall member for a team at the specified time.
 // This implements proper rotation logic instead of returning all members.
-func (s *PostgresStorage) GetCurrentOncall(teamName string, at time.Time) (string, bool, error) {
-	ctx := context.Background()
-
-	// Get team ID
+func (s *PostgresStorage) GetCurrentOncall(ctx context.Context, teamName string, at time.Time) (string, error) {
+	// Get team ID, telling unknown teams from uncovered times
 	var teamID int
 	err := s.db.Pool.QueryRow(ctx,
 		`SELECT id FROM teams WHERE name = $1`,
 		teamName,
 	).Scan(&teamID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrTeamNotFound
+	}
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return "", false, nil
-		}
-		return "", false, fmt.Errorf("failed to get team: %w", err)
+		return "", fmt.Errorf("failed to get team: %w", err)
+	}
+
+	member, found, err := s.currentOncall(ctx, teamID, at)
+	if err != nil {
+		return "", err
 	}
+	if !found {
+		return "", ErrNoCoverage
+	}
+
+	return member, nil
+}
 
-	// Find matching schedule for the given time
+// currentOncall returns the member on duty of the first schedule of the team
+// matching the time, reporting false when none does.
+func (s *PostgresStorage) currentOncall(ctx context.Context, teamID int, at time.Time) (string, bool, error) {
+	// Find matching schedule for the given time; schedules are stored in UTC
+	at = at.UTC()
 	dayOfWeek := int(at.Weekday())
 	timeOfDay := at.Format("15:04:05")
 
-	var currentUserID *int
-	var username string
-	err = s.db.Pool.QueryRow(ctx,
-		`SELECT r.current_user_id, u.username
+	var scheduleID int
+	var sched Schedule
+	var anchor, clock *time.Time
+	var day *int16
+	var assignee *string
+	err := s.db.Pool.QueryRow(ctx,
+		`SELECT s.id, s.name, s.anchor, s.start_time, s.end_time, s.rotation_offset, s.rotation_manual, s.shift_split, s.required_count, s.handoff_day, s.handoff_time, s.team_members, a.username
 		 FROM schedules s
 		 JOIN schedule_days sd ON s.id = sd.schedule_id
 		 JOIN rotations r ON s.id = r.schedule_id
-		 LEFT JOIN users u ON r.current_user_id = u.id
+		 LEFT JOIN users a ON sd.assignee_id = a.id
 		 WHERE s.team_id = $1
+		   AND s.deleted_at IS NULL
+		   AND (s.valid_until IS NULL OR s.valid_until > $4)
 		   AND sd.day_of_week = $2
 		   AND s.start_time <= $3::time
-		   AND s.end_time >= $3::time
+		   AND s.end_time > $3::time
+		 ORDER BY s.id
 		 LIMIT 1`,
-		teamID, dayOfWeek, timeOfDay,
-	).Scan(&currentUserID, &username)
+		teamID, dayOfWeek, timeOfDay, at,
+	).Scan(&scheduleID, &sched.Name, &anchor, &sched.Start, &sched.End, &sched.RotationOffset, &sched.Manual, &sched.Split, &sched.RequiredCount, &day, &clock, &sched.TeamMembers, &assignee)
 
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return "", false, nil
+		if errors.Is(err, pgx.ErrNoRows) {
+			// Cron and RRULE schedules have no schedule_days rows, evaluate them separately
+			return s.getCurrentRecurringOncall(ctx, teamID, at, 0)
 		}
 		return "", false, fmt.Errorf("failed to get current oncall: %w", err)
 	}
 
-	if currentUserID == nil {
-		return "", false, nil
+	// Like overlapping day based schedules, a recurring schedule only wins
+	// if it was added before the day based match
+	member, found, err := s.getCurrentRecurringOncall(ctx, teamID, at, scheduleID)
+	if err != nil || found {
+		return member, found, err
+	}
+
+	sched.Anchor = derefTime(anchor)
+	sched.Handoff = scanHandoff(day, clock)
+	sched.Days = []time.Weekday{at.Weekday()}
+	if assignee != nil {
+		sched.DayAssignments = map[time.Weekday]string{at.Weekday(): *assignee}
+	}
+
+	return s.memberAt(ctx, scheduleID, sched, at)
+}
+
+// ListTeams returns the names of all teams in sorted order.
+func (s *PostgresStorage) ListTeams(ctx context.Context) ([]string, error) {
+	rows, err := s.db.Pool.Query(ctx, `SELECT name FROM teams ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query teams: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		names = append(names, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating teams: %w", err)
+	}
+
+	return names, nil
+}
+
+// FindSchedulesByTags returns the schedules of every team carrying all of the
+// tags. The tags are matched through the tag index of schedule_tags.
+func (s *PostgresStorage) FindSchedulesByTags(ctx context.Context, tags []string) ([]TeamSchedule, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT s.id, t.name, s.name, s.description, s.notes, s.start_time, s.end_time,
+		        COALESCE(s.cron, ''), COALESCE(s.rrule, ''), s.anchor, s.valid_until, s.rotation_offset, s.rotation_manual, s.shift_split, s.required_count, s.handoff_day, s.handoff_time,
+		        NULLIF(s.routing, '{}'), s.team_members, s.compensation
+		 FROM schedules s
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE s.deleted_at IS NULL
+		   AND s.id IN (
+		     SELECT schedule_id FROM schedule_tags
+		     WHERE tag = ANY($1)
+		     GROUP BY schedule_id
+		     HAVING COUNT(*) = $2
+		   )
+		 ORDER BY t.name, s.id`,
+		tags, len(tags),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedules by tags: %w", err)
+	}
+	defer rows.Close()
+
+	type match struct {
+		id int
+		TeamSchedule
+	}
+
+	var matches []match
+	for rows.Next() {
+		var m match
+		var anchor, validUntil, clock *time.Time
+		var day *int16
+
+		err = rows.Scan(&m.id, &m.Team, &m.Schedule.Name, &m.Schedule.Description, &m.Schedule.Notes,
+			&m.Schedule.Start, &m.Schedule.End, &m.Schedule.Cron, &m.Schedule.RRule, &anchor, &validUntil,
+			&m.Schedule.RotationOffset, &m.Schedule.Manual, &m.Schedule.Split, &m.Schedule.RequiredCount, &day, &clock, &m.Schedule.Routing, &m.Schedule.TeamMembers, &m.Schedule.Compensation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		m.Schedule.ID = strconv.Itoa(m.id)
+		m.Schedule.Anchor = derefTime(anchor)
+		m.Schedule.ValidUntil = derefTime(validUntil)
+		m.Schedule.Handoff = scanHandoff(day, clock)
+
+		matches = append(matches, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schedules: %w", err)
 	}
 
-	return username, true, nil
+	result := make([]TeamSchedule, 0, len(matches))
+	for _, m := range matches {
+		if err := s.loadScheduleDetails(ctx, m.id, &m.Schedule); err != nil {
+			return nil, err
+		}
+		result = append(result, m.TeamSchedule)
+	}
+
+	return result, nil
+}
+
+// FindTeamsByMember returns the teams with a schedule listing the member or
+// pinning them to a date.
+func (s *PostgresStorage) FindTeamsByMember(ctx context.Context, member string) ([]string, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT DISTINCT t.name
+		 FROM schedules s
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE s.deleted_at IS NULL
+		   AND s.id IN (
+		     SELECT sm.schedule_id FROM schedule_members sm
+		     JOIN users u ON sm.user_id = u.id
+		     WHERE u.username = $1
+		     UNION
+		     SELECT schedule_id FROM schedule_pins WHERE member = $1
+		   )
+		 ORDER BY t.name`,
+		member,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query teams by member: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		names = append(names, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating teams: %w", err)
+	}
+
+	return names, nil
+}
+
+// FindSchedulesByMembers returns the schedules of every team listing any of
+// the members, going from the users to their schedules.
+func (s *PostgresStorage) FindSchedulesByMembers(ctx context.Context, members []string) ([]MemberSchedule, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT s.id, t.name, s.name, s.description, s.notes, s.start_time, s.end_time,
+		        COALESCE(s.cron, ''), COALESCE(s.rrule, ''), s.anchor, s.valid_until, s.rotation_offset, s.rotation_manual, s.shift_split, s.required_count, s.handoff_day, s.handoff_time,
+		        NULLIF(s.routing, '{}'), s.compensation, u.username, sm.position
+		 FROM users u
+		 JOIN schedule_members sm ON sm.user_id = u.id
+		 JOIN schedules s ON s.id = sm.schedule_id
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE u.username = ANY($1) AND s.deleted_at IS NULL
+		 ORDER BY t.name, s.id, sm.position`,
+		members,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedules by members: %w", err)
+	}
+	defer rows.Close()
+
+	type match struct {
+		id int
+		MemberSchedule
+	}
+
+	var matches []match
+	for rows.Next() {
+		var m match
+		var anchor, validUntil, clock *time.Time
+		var day *int16
+
+		err = rows.Scan(&m.id, &m.Team, &m.Schedule.Name, &m.Schedule.Description, &m.Schedule.Notes,
+			&m.Schedule.Start, &m.Schedule.End, &m.Schedule.Cron, &m.Schedule.RRule, &anchor, &validUntil,
+			&m.Schedule.RotationOffset, &m.Schedule.Manual, &m.Schedule.Split, &m.Schedule.RequiredCount, &day, &clock,
+			&m.Schedule.Routing, &m.Schedule.Compensation, &m.Member, &m.Position)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		m.Schedule.ID = strconv.Itoa(m.id)
+		m.Schedule.Anchor = derefTime(anchor)
+		m.Schedule.ValidUntil = derefTime(validUntil)
+		m.Schedule.Handoff = scanHandoff(day, clock)
+
+		matches = append(matches, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schedules: %w", err)
+	}
+
+	result := make([]MemberSchedule, 0, len(matches))
+	for _, m := range matches {
+		if err := s.loadScheduleDetails(ctx, m.id, &m.Schedule); err != nil {
+			return nil, err
+		}
+		result = append(result, m.MemberSchedule)
+	}
+
+	return result, nil
+}
+
+// GetSchedule returns the schedule with the given ID along with its team.
+func (s *PostgresStorage) GetSchedule(ctx context.Context, id string) (TeamSchedule, error) {
+	scheduleID, err := strconv.Atoi(id)
+	if err != nil {
+		return TeamSchedule{}, ErrScheduleNotFound
+	}
+
+	var result TeamSchedule
+	var anchor, validUntil, clock *time.Time
+	var day *int16
+	err = s.db.Pool.QueryRow(ctx,
+		`SELECT t.name, s.name, s.description, s.notes, s.start_time, s.end_time,
+		        COALESCE(s.cron, ''), COALESCE(s.rrule, ''), s.anchor, s.valid_until, s.rotation_offset, s.rotation_manual, s.shift_split, s.required_count, s.handoff_day, s.handoff_time,
+		        NULLIF(s.routing, '{}'), s.team_members, s.compensation
+		 FROM schedules s
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE s.id = $1 AND s.deleted_at IS NULL`,
+		scheduleID,
+	).Scan(&result.Team, &result.Schedule.Name, &result.Schedule.Description, &result.Schedule.Notes,
+		&result.Schedule.Start, &result.Schedule.End, &result.Schedule.Cron, &result.Schedule.RRule,
+		&anchor, &validUntil, &result.Schedule.RotationOffset, &result.Schedule.Manual, &result.Schedule.Split, &result.Schedule.RequiredCount, &day, &clock,
+		&result.Schedule.Routing, &result.Schedule.TeamMembers, &result.Schedule.Compensation)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return TeamSchedule{}, ErrScheduleNotFound
+	}
+	if err != nil {
+		return TeamSchedule{}, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	result.Schedule.ID = id
+	result.Schedule.Anchor = derefTime(anchor)
+	result.Schedule.ValidUntil = derefTime(validUntil)
+	result.Schedule.Handoff = scanHandoff(day, clock)
+
+	if err = s.loadScheduleDetails(ctx, scheduleID, &result.Schedule); err != nil {
+		return TeamSchedule{}, err
+	}
+
+	return result, nil
+}
+
+// AddPin pins a member to a date of a schedule of the team, replacing any
+// earlier pin of the date.
+func (s *PostgresStorage) AddPin(ctx context.Context, team, scheduleID string, pin Pin) (Pin, bool, error) {
+	id, err := strconv.Atoi(scheduleID)
+	if err != nil {
+		return Pin{}, false, nil
+	}
+
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return Pin{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	// Locking the team keeps the count of its pins until commit
+	var teamID int
+	err = tx.QueryRow(ctx,
+		`SELECT t.id
+		 FROM schedules s
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE s.id = $1 AND t.name = $2 AND s.deleted_at IS NULL
+		 FOR UPDATE OF t`,
+		id, team,
+	).Scan(&teamID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Pin{}, false, nil
+	}
+	if err != nil {
+		return Pin{}, false, fmt.Errorf("failed to query schedule: %w", err)
+	}
+
+	pin.Date = PinDate(pin.Date)
+
+	if hasQuota(ctx, QuotaPins) {
+		// A pin replacing the one of its date does not add to the count
+		var count int
+		err = tx.QueryRow(ctx,
+			`SELECT COUNT(*)
+			 FROM schedule_pins p
+			 JOIN schedules s ON p.schedule_id = s.id
+			 WHERE s.team_id = $1 AND s.deleted_at IS NULL
+			   AND NOT (p.schedule_id = $2 AND p.pin_date = $3)`,
+			teamID, id, pin.Date,
+		).Scan(&count)
+		if err != nil {
+			return Pin{}, false, fmt.Errorf("failed to count schedule pins: %w", err)
+		}
+		if err := checkQuota(ctx, QuotaPins, team, count); err != nil {
+			return Pin{}, false, err
+		}
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO schedule_pins (schedule_id, pin_date, member, shift_start) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (schedule_id, pin_date) DO UPDATE
+		 SET member = EXCLUDED.member, shift_start = EXCLUDED.shift_start, created_at = NOW()
+		 RETURNING id, created_at`,
+		id, pin.Date, pin.Member, pinShiftStart(pin),
+	).Scan(&pin.ID, &pin.CreatedAt)
+	if err != nil {
+		return Pin{}, false, fmt.Errorf("failed to insert schedule pin: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return Pin{}, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pin, true, nil
+}
+
+// DeletePin removes a pin of a schedule of the team.
+func (s *PostgresStorage) DeletePin(ctx context.Context, team, scheduleID string, id int64) (bool, error) {
+	sid, err := strconv.Atoi(scheduleID)
+	if err != nil {
+		return false, nil
+	}
+
+	tag, err := s.db.Pool.Exec(ctx,
+		`DELETE FROM schedule_pins p
+		 USING schedules s, teams t
+		 WHERE p.id = $3 AND p.schedule_id = $1
+		   AND s.id = p.schedule_id AND t.id = s.team_id AND t.name = $2`,
+		sid, team, id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete schedule pin: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// swapRequestColumns are the columns scanned by scanSwapRequest, with the
+// swap_requests table as r and teams as t.
+const swapRequestColumns = `r.id, t.name, r.schedule_id, r.shift_start, r.shift_end,
+	r.from_member, r.to_member, r.status, r.token_hash, r.created_at, r.decided_at`
+
+// AddSwapRequest stores a pending swap request for a schedule of the team.
+func (s *PostgresStorage) AddSwapRequest(ctx context.Context, team string, request SwapRequest) (SwapRequest, bool, error) {
+	id, err := strconv.Atoi(request.ScheduleID)
+	if err != nil {
+		return SwapRequest{}, false, nil
+	}
+
+	request.Team = team
+	request.Status = SwapPending
+	request.DecidedAt = time.Time{}
+
+	err = s.db.Pool.QueryRow(ctx,
+		`INSERT INTO swap_requests (schedule_id, shift_start, shift_end, from_member, to_member, token_hash)
+		 SELECT s.id, $3, $4, $5, $6, $7
+		 FROM schedules s
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE s.id = $1 AND t.name = $2 AND s.deleted_at IS NULL
+		 RETURNING id, created_at`,
+		id, team, request.ShiftStart, request.ShiftEnd, request.From, request.To, request.TokenHash,
+	).Scan(&request.ID, &request.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SwapRequest{}, false, nil
+	}
+	if err != nil {
+		return SwapRequest{}, false, fmt.Errorf("failed to insert swap request: %w", err)
+	}
+
+	return request, true, nil
+}
+
+// GetSwapRequest returns the swap request with the given ID.
+func (s *PostgresStorage) GetSwapRequest(ctx context.Context, id int64) (SwapRequest, bool, error) {
+	request, err := scanSwapRequest(s.db.Pool.QueryRow(ctx,
+		`SELECT `+swapRequestColumns+`
+		 FROM swap_requests r
+		 JOIN schedules s ON r.schedule_id = s.id
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE r.id = $1`,
+		id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SwapRequest{}, false, nil
+	}
+	if err != nil {
+		return SwapRequest{}, false, fmt.Errorf("failed to query swap request: %w", err)
+	}
+
+	return request, true, nil
+}
+
+// ListSwapRequests returns the swap requests of a schedule of the team.
+func (s *PostgresStorage) ListSwapRequests(ctx context.Context, team, scheduleID string) ([]SwapRequest, error) {
+	id, err := strconv.Atoi(scheduleID)
+	if err != nil {
+		return nil, nil
+	}
+
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT `+swapRequestColumns+`
+		 FROM swap_requests r
+		 JOIN schedules s ON r.schedule_id = s.id
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE r.schedule_id = $1 AND t.name = $2
+		 ORDER BY r.id`,
+		id, team,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query swap requests: %w", err)
+	}
+	defer rows.Close()
+
+	var result []SwapRequest
+	for rows.Next() {
+		request, err := scanSwapRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan swap request: %w", err)
+		}
+		result = append(result, request)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating swap requests: %w", err)
+	}
+
+	return result, nil
+}
+
+// DecideSwapRequest accepts or declines a swap request, pinning its target
+// in the same transaction when accepting.
+func (s *PostgresStorage) DecideSwapRequest(ctx context.Context, id int64, accept bool, at time.Time) (SwapRequest, bool, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return SwapRequest{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	// Locking the request keeps it from being decided twice
+	request, err := scanSwapRequest(tx.QueryRow(ctx,
+		`SELECT `+swapRequestColumns+`
+		 FROM swap_requests r
+		 JOIN schedules s ON r.schedule_id = s.id
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE r.id = $1 AND s.deleted_at IS NULL
+		 FOR UPDATE OF r`,
+		id,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return SwapRequest{}, false, nil
+	}
+	if err != nil {
+		return SwapRequest{}, false, fmt.Errorf("failed to query swap request: %w", err)
+	}
+
+	request, err = request.decide(accept, at)
+	if err != nil {
+		return SwapRequest{}, false, err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE swap_requests SET status = $2, decided_at = $3 WHERE id = $1`,
+		id, request.Status, request.DecidedAt,
+	)
+	if err != nil {
+		return SwapRequest{}, false, fmt.Errorf("failed to update swap request: %w", err)
+	}
+
+	if accept {
+		scheduleID, err := strconv.Atoi(request.ScheduleID)
+		if err != nil {
+			return SwapRequest{}, false, fmt.Errorf("invalid schedule id %q: %w", request.ScheduleID, err)
+		}
+
+		pin := request.pin()
+		_, err = tx.Exec(ctx,
+			`INSERT INTO schedule_pins (schedule_id, pin_date, member) VALUES ($1, $2, $3)
+			 ON CONFLICT (schedule_id, pin_date) DO UPDATE
+			 SET member = EXCLUDED.member, created_at = NOW()`,
+			scheduleID, pin.Date, pin.Member,
+		)
+		if err != nil {
+			return SwapRequest{}, false, fmt.Errorf("failed to insert schedule pin: %w", err)
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+			ActorFrom(ctx), AuditAcceptSwap, request.Team, request.auditDetail(),
+		)
+		if err != nil {
+			return SwapRequest{}, false, fmt.Errorf("failed to record audit entry: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return SwapRequest{}, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return request, true, nil
+}
+
+// SetRotation sets the rotation of a schedule and moves the rotation state
+// to the member it puts on duty.
+func (s *PostgresStorage) SetRotation(ctx context.Context, team, scheduleID string, rotation Rotation) (bool, error) {
+	id, err := strconv.Atoi(scheduleID)
+	if err != nil {
+		return false, nil
+	}
+
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	var name string
+	err = tx.QueryRow(ctx,
+		`UPDATE schedules s
+		 SET rotation_manual = $3, anchor = $4, rotation_offset = $5, updated_at = NOW()
+		 FROM teams t
+		 WHERE s.id = $1 AND t.id = s.team_id AND t.name = $2 AND s.deleted_at IS NULL
+		 RETURNING s.name`,
+		id, team, rotation.Manual, nullableDate(rotation.Anchor), rotation.Offset,
+	).Scan(&name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update schedule rotation: %w", err)
+	}
+
+	var member string
+	var userID *int
+	err = tx.QueryRow(ctx,
+		`SELECT u.id, u.username
+		 FROM schedule_members sm
+		 JOIN users u ON sm.user_id = u.id
+		 WHERE sm.schedule_id = $1 AND sm.position = $2`,
+		id, rotation.Offset,
+	).Scan(&userID, &member)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return false, fmt.Errorf("failed to get rotation member: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE rotations
+		 SET current_user_id = $2, current_position = $3, last_rotation_at = NOW(), updated_at = NOW()
+		 WHERE schedule_id = $1`,
+		id, userID, rotation.Offset,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update rotation state: %w", err)
+	}
+
+	// The new version is the latest one with the rotation changed
+	_, err = tx.Exec(ctx,
+		`INSERT INTO schedule_versions (schedule_id, definition)
+		 SELECT schedule_id, definition || jsonb_build_object('Manual', $2::boolean, 'Anchor', $3::timestamptz, 'RotationOffset', $4::integer)
+		 FROM schedule_versions
+		 WHERE schedule_id = $1
+		 ORDER BY since DESC, id DESC
+		 LIMIT 1`,
+		id, rotation.Manual, nullableDate(rotation.Anchor), rotation.Offset,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record schedule version: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditSetRotation, team, rotation.auditDetail(name, member),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// UpdateScheduleFields changes the given fields of a schedule of a team. The
+// row of the schedule is locked and read again in the transaction, so the
+// fields are applied to the schedule as it is then.
+func (s *PostgresStorage) UpdateScheduleFields(ctx context.Context, team, scheduleID string, update ScheduleUpdate) (Schedule, error) {
+	id, err := strconv.Atoi(scheduleID)
+	if err != nil {
+		return Schedule{}, ErrScheduleNotFound
+	}
+
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	var teamID int
+	var current Schedule
+	err = tx.QueryRow(ctx,
+		`SELECT s.team_id, s.name, s.description, s.notes, s.start_time, s.end_time, s.rotation_offset
+		 FROM schedules s
+		 JOIN teams t ON s.team_id = t.id
+		 WHERE s.id = $1 AND t.name = $2 AND s.deleted_at IS NULL
+		 FOR UPDATE OF s`,
+		id, team,
+	).Scan(&teamID, &current.Name, &current.Description, &current.Notes, &current.Start, &current.End, &current.RotationOffset)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Schedule{}, ErrScheduleNotFound
+	}
+	if err != nil {
+		return Schedule{}, fmt.Errorf("failed to get schedule: %w", err)
+	}
+
+	if err = update.apply(&current); err != nil {
+		return Schedule{}, err
+	}
+
+	_, err = tx.Exec(ctx,
+		`UPDATE schedules SET name = $2, description = $3, notes = $4, start_time = $5, end_time = $6, rotation_offset = $7, updated_at = NOW()
+		 WHERE id = $1`,
+		id, current.Name, current.Description, current.Notes, current.Start.Format("15:04:05"), current.End.Format("15:04:05"), current.RotationOffset,
+	)
+	if uniqueViolation(err, scheduleNameIndex) {
+		return Schedule{}, ErrScheduleExists
+	}
+	if err != nil {
+		return Schedule{}, fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	// The assignees of the days are members, whose users are looked up
+	var assignees []string
+	for _, member := range update.DayAssignments {
+		assignees = append(assignees, member)
+	}
+	userIDs, err := upsertTeamMembers(ctx, tx, teamID, slices.Concat(update.Members, assignees))
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	// The version changes the fields on top of the latest one
+	changes := make(map[string]any)
+	if update.Name != nil {
+		changes["Name"] = current.Name
+	}
+	if update.Description != nil {
+		changes["Description"] = current.Description
+	}
+	if update.Notes != nil {
+		changes["Notes"] = current.Notes
+	}
+	if update.Start != nil {
+		changes["Start"] = current.Start
+	}
+	if update.End != nil {
+		changes["End"] = current.End
+	}
+	if update.RotationOffset != nil {
+		changes["RotationOffset"] = current.RotationOffset
+	}
+
+	if update.Members != nil {
+		if _, err = tx.Exec(ctx, `DELETE FROM schedule_members WHERE schedule_id = $1`, id); err != nil {
+			return Schedule{}, fmt.Errorf("failed to clear schedule members: %w", err)
+		}
+		if _, err = tx.Exec(ctx, `DELETE FROM schedule_member_refs WHERE schedule_id = $1`, id); err != nil {
+			return Schedule{}, fmt.Errorf("failed to clear schedule member references: %w", err)
+		}
+		if err = insertScheduleMembers(ctx, tx, id, update.Members, update.MemberRefs, userIDs); err != nil {
+			return Schedule{}, err
+		}
+
+		// Schedules always have a rotation once they have members
+		_, err = tx.Exec(ctx,
+			`INSERT INTO rotations (schedule_id, current_position, last_rotation_at) VALUES ($1, 0, NOW())
+			 ON CONFLICT (schedule_id) DO NOTHING`,
+			id,
+		)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("failed to initialize rotation: %w", err)
+		}
+
+		changes["Members"], changes["MemberRefs"] = update.Members, update.MemberRefs
+	}
+
+	if update.Days != nil {
+		if _, err = tx.Exec(ctx, `DELETE FROM schedule_days WHERE schedule_id = $1`, id); err != nil {
+			return Schedule{}, fmt.Errorf("failed to clear schedule days: %w", err)
+		}
+		if err = insertScheduleDays(ctx, tx, id, update.Days, update.DayAssignments, userIDs); err != nil {
+			return Schedule{}, err
+		}
+
+		changes["Days"], changes["DayAssignments"] = update.Days, update.DayAssignments
+	}
+
+	if update.Tags != nil {
+		if _, err = tx.Exec(ctx, `DELETE FROM schedule_tags WHERE schedule_id = $1`, id); err != nil {
+			return Schedule{}, fmt.Errorf("failed to clear schedule tags: %w", err)
+		}
+		if err = insertScheduleTags(ctx, tx, id, update.Tags); err != nil {
+			return Schedule{}, err
+		}
+
+		changes["Tags"] = update.Tags
+	}
+
+	definition, err := json.Marshal(changes)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("failed to encode schedule version: %w", err)
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO schedule_versions (schedule_id, definition)
+		 SELECT schedule_id, definition || $2::jsonb
+		 FROM schedule_versions
+		 WHERE schedule_id = $1
+		 ORDER BY since DESC, id DESC
+		 LIMIT 1`,
+		id, definition,
+	)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("failed to record schedule version: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditUpdateSchedule, team, update.auditDetail(current.Name),
+	)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return Schedule{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	updated, err := s.GetSchedule(ctx, scheduleID)
+	if err != nil {
+		return Schedule{}, err
+	}
+
+	return updated.Schedule, nil
+}
+
+// TeamHistory returns the versions of a team. Schedules created before
+// versions were recorded have none, and the history of their team does not
+// reach back before their first change. The tombstones of soft-deleted
+// schedules are taken from when they were deleted.
+func (s *PostgresStorage) TeamHistory(ctx context.Context, team string, from, to time.Time) ([]TeamVersion, error) {
+	var teamID int
+	err := s.db.Pool.QueryRow(ctx, `SELECT id FROM teams WHERE name = $1`, team).Scan(&teamID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrTeamNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	var missing bool
+	err = s.db.Pool.QueryRow(ctx,
+		`SELECT EXISTS (
+		   SELECT 1 FROM schedules s
+		   WHERE s.team_id = $1
+		     AND COALESCE(s.created_at, '-infinity') <= $2
+		     AND NOT EXISTS (SELECT 1 FROM schedule_versions v WHERE v.schedule_id = s.id AND v.since <= $2)
+		 )`,
+		teamID, from,
+	).Scan(&missing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schedule history: %w", err)
+	}
+	if missing {
+		return nil, ErrNoHistory
+	}
+
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT schedule_id, since, definition, deleted, name
+		 FROM (
+		   SELECT v.schedule_id, v.since, v.definition, FALSE AS deleted, '' AS name, v.id AS seq
+		   FROM schedule_versions v
+		   JOIN schedules s ON s.id = v.schedule_id
+		   WHERE s.team_id = $1 AND v.since <= $2
+		   UNION ALL
+		   SELECT s.id, s.deleted_at, NULL, TRUE, s.name, 0
+		   FROM schedules s
+		   WHERE s.team_id = $1 AND s.deleted_at <= $2
+		 ) h
+		 ORDER BY since, deleted, seq`,
+		teamID, to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query schedule versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []ScheduleVersion
+	pins := make(map[string][]Pin)
+	for rows.Next() {
+		var scheduleID int
+		var version ScheduleVersion
+		var definition []byte
+		var name string
+
+		if err = rows.Scan(&scheduleID, &version.Since, &definition, &version.Deleted, &name); err != nil {
+			return nil, fmt.Errorf("failed to scan schedule version: %w", err)
+		}
+		if version.Deleted {
+			version.Schedule = Schedule{ID: strconv.Itoa(scheduleID), Name: name}
+			versions = append(versions, version)
+			continue
+		}
+		if err = json.Unmarshal(definition, &version.Schedule); err != nil {
+			return nil, fmt.Errorf("failed to decode schedule version: %w", err)
+		}
+		version.Schedule.ID = strconv.Itoa(scheduleID)
+
+		versions = append(versions, version)
+		pins[version.Schedule.ID] = nil
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating schedule versions: %w", err)
+	}
+
+	for id := range pins {
+		scheduleID, _ := strconv.Atoi(id)
+		if pins[id], err = s.loadPins(ctx, scheduleID, time.Time{}); err != nil {
+			return nil, err
+		}
+	}
+
+	aliases, err := s.loadAliases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return teamHistory(resolveAliases(versions, aliases), from, to, func(id string) []Pin { return pins[id] }), nil
+}
+
+// HistoryStart returns the earliest instant the history of a team reaches
+// back to, the latest first version of its schedules created before
+// versions were recorded. It reports false when one of them was never
+// changed since.
+func (s *PostgresStorage) HistoryStart(ctx context.Context, team string) (time.Time, bool, error) {
+	var teamID int
+	err := s.db.Pool.QueryRow(ctx, `SELECT id FROM teams WHERE name = $1`, team).Scan(&teamID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	var start *time.Time
+	var unversioned *bool
+	err = s.db.Pool.QueryRow(ctx,
+		`SELECT MAX(f.first) FILTER (WHERE f.first > COALESCE(f.created_at, '-infinity')), BOOL_OR(f.first IS NULL)
+		 FROM (
+		   SELECT s.created_at, (SELECT MIN(v.since) FROM schedule_versions v WHERE v.schedule_id = s.id) AS first
+		   FROM schedules s
+		   WHERE s.team_id = $1
+		 ) f`,
+		teamID,
+	).Scan(&start, &unversioned)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get start of schedule history: %w", err)
+	}
+	if unversioned != nil && *unversioned {
+		return time.Time{}, false, nil
+	}
+
+	return derefTime(start), true, nil
+}
+
+// ScheduleVersions returns the versions of a schedule. Schedules created
+// before versions were recorded have none until their first change.
+func (s *PostgresStorage) ScheduleVersions(ctx context.Context, id string) ([]ScheduleVersion, bool, error) {
+	scheduleID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	var exists bool
+	err = s.db.Pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM schedules WHERE id = $1 AND deleted_at IS NULL)`,
+		scheduleID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check schedule: %w", err)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT since, definition
+		 FROM schedule_versions
+		 WHERE schedule_id = $1
+		 ORDER BY since, id`,
+		scheduleID,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to query schedule versions: %w", err)
+	}
+	defer rows.Close()
+
+	var versions []ScheduleVersion
+	for rows.Next() {
+		var version ScheduleVersion
+		var definition []byte
+
+		if err = rows.Scan(&version.Since, &definition); err != nil {
+			return nil, false, fmt.Errorf("failed to scan schedule version: %w", err)
+		}
+		if err = json.Unmarshal(definition, &version.Schedule); err != nil {
+			return nil, false, fmt.Errorf("failed to decode schedule version: %w", err)
+		}
+		version.Schedule.ID = id
+
+		versions = append(versions, version)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("error iterating schedule versions: %w", err)
+	}
+
+	aliases, err := s.loadAliases(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return resolveAliases(versions, aliases), true, nil
+}
+
+// loadAliases returns the current name of every renamed member by former name.
+func (s *PostgresStorage) loadAliases(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.Pool.Query(ctx, `SELECT alias, member FROM member_aliases`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query member aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := make(map[string]string)
+	for rows.Next() {
+		var alias, member string
+		if err = rows.Scan(&alias, &member); err != nil {
+			return nil, fmt.Errorf("failed to scan member alias: %w", err)
+		}
+		aliases[alias] = member
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating member aliases: %w", err)
+	}
+
+	return aliases, nil
+}
+
+// insertVersion records the definition of the schedule as its latest version.
+func insertVersion(ctx context.Context, tx pgx.Tx, scheduleID int, schedule Schedule) error {
+	schedule.Pins, schedule.Inactive = nil, nil
+
+	definition, err := json.Marshal(schedule)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule version: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO schedule_versions (schedule_id, definition) VALUES ($1, $2)`,
+		scheduleID, definition,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record schedule version: %w", err)
+	}
+
+	return nil
+}
+
+// AddUser provisions a user. A user only known from the members of schedules
+// is taken over, while one provisioned before is left untouched.
+func (s *PostgresStorage) AddUser(ctx context.Context, user User) (User, bool, error) {
+	// The column is not nullable, which a nil slice would be encoded as
+	emails := user.Emails
+	if emails == nil {
+		emails = []string{}
+	}
+
+	var id int
+	var createdAt, updatedAt *time.Time
+	err := s.db.Pool.QueryRow(ctx,
+		`INSERT INTO users (username, email, emails, active, timezone, provisioned)
+		 VALUES ($1, $2, $3, $4, $5, TRUE)
+		 ON CONFLICT (username) DO UPDATE
+		   SET emails = EXCLUDED.emails, active = EXCLUDED.active, timezone = EXCLUDED.timezone,
+		       provisioned = TRUE, updated_at = NOW()
+		   WHERE users.provisioned = FALSE
+		 RETURNING id, created_at, updated_at`,
+		user.UserName,
+		fmt.Sprintf("%s@example.com", user.UserName),
+		emails,
+		user.Active,
+		user.Timezone,
+	).Scan(&id, &createdAt, &updatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return User{}, false, nil
+		}
+		return User{}, false, fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	user.ID = strconv.Itoa(id)
+	user.CreatedAt = derefTime(createdAt)
+	user.UpdatedAt = derefTime(updatedAt)
+
+	return user, true, nil
+}
+
+// GetUser returns the provisioned user with the given ID.
+func (s *PostgresStorage) GetUser(ctx context.Context, id string) (User, bool, error) {
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return User{}, false, nil
+	}
+
+	users, err := s.queryUsers(ctx,
+		`SELECT id, username, emails, active, timezone, created_at, updated_at
+		 FROM users WHERE id = $1 AND provisioned`,
+		userID,
+	)
+	if err != nil || len(users) == 0 {
+		return User{}, false, err
+	}
+
+	return users[0], true, nil
+}
+
+// FindUsers returns the provisioned users ordered by user name.
+func (s *PostgresStorage) FindUsers(ctx context.Context, userName string) ([]User, error) {
+	return s.queryUsers(ctx,
+		`SELECT id, username, emails, active, timezone, created_at, updated_at
+		 FROM users WHERE provisioned AND ($1 = '' OR username = $1)
+		 ORDER BY username`,
+		userName,
+	)
+}
+
+// SetUserActive activates or deactivates a provisioned user.
+func (s *PostgresStorage) SetUserActive(ctx context.Context, id string, active bool) (User, bool, error) {
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return User{}, false, nil
+	}
+
+	users, err := s.queryUsers(ctx,
+		`UPDATE users SET active = $2, updated_at = CASE WHEN active = $2 THEN updated_at ELSE NOW() END
+		 WHERE id = $1 AND provisioned
+		 RETURNING id, username, emails, active, timezone, created_at, updated_at`,
+		userID, active,
+	)
+	if err != nil || len(users) == 0 {
+		return User{}, false, err
+	}
+
+	return users[0], true, nil
+}
+
+// RenameMember renames a user or member everywhere they are referenced in a
+// single transaction, keeping the schedule versions as they were recorded and
+// resolving the former name through an alias. Tables referencing the user by
+// ID follow the user row.
+func (s *PostgresStorage) RenameMember(ctx context.Context, from, to string) (bool, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	// Renames are serialized, so the names checked cannot be taken meanwhile
+	if _, err = tx.Exec(ctx, `LOCK TABLE member_aliases IN EXCLUSIVE MODE`); err != nil {
+		return false, fmt.Errorf("failed to lock member aliases: %w", err)
+	}
+
+	known := func(name string) (bool, error) {
+		var exists bool
+		err := tx.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM users WHERE username = $1)
+			     OR EXISTS (SELECT 1 FROM team_group_members WHERE member = $1)
+			     OR EXISTS (SELECT 1 FROM schedule_pins WHERE member = $1)`,
+			name,
+		).Scan(&exists)
+		if err != nil {
+			return false, fmt.Errorf("failed to check member: %w", err)
+		}
+		return exists, nil
+	}
+
+	taken, err := known(to)
+	if err != nil {
+		return false, err
+	}
+	if taken {
+		return false, ErrMemberExists
+	}
+
+	found, err := known(from)
+	if err != nil || !found {
+		return false, err
+	}
+
+	rows, err := tx.Query(ctx,
+		`SELECT t.name FROM teams t
+		 WHERE EXISTS (
+		         SELECT 1 FROM schedules s
+		         JOIN schedule_members sm ON sm.schedule_id = s.id
+		         JOIN users u ON u.id = sm.user_id
+		         WHERE s.team_id = t.id AND u.username = $1)
+		    OR EXISTS (
+		         SELECT 1 FROM schedules s
+		         JOIN schedule_pins p ON p.schedule_id = s.id
+		         WHERE s.team_id = t.id AND p.member = $1)
+		    OR EXISTS (
+		         SELECT 1 FROM team_members tm
+		         JOIN users u ON u.id = tm.user_id
+		         WHERE tm.team_id = t.id AND u.username = $1)
+		    OR EXISTS (
+		         SELECT 1 FROM team_groups g
+		         JOIN team_group_members gm ON gm.group_id = g.id
+		         WHERE g.team_id = t.id AND gm.member = $1)
+		 ORDER BY t.name`,
+		from,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to query teams of member: %w", err)
+	}
+
+	var teams []string
+	for rows.Next() {
+		var team string
+		if err = rows.Scan(&team); err != nil {
+			rows.Close()
+			return false, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams = append(teams, team)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return false, fmt.Errorf("error iterating teams: %w", err)
+	}
+
+	// The new name is no longer an alias now that it is taken again
+	if _, err = tx.Exec(ctx, `DELETE FROM member_aliases WHERE alias = $1`, to); err != nil {
+		return false, fmt.Errorf("failed to rename member: %w", err)
+	}
+
+	statements := []string{
+		`UPDATE users SET username = $2, updated_at = NOW() WHERE username = $1`,
+		`UPDATE schedule_pins SET member = $2 WHERE member = $1`,
+		`UPDATE schedule_member_refs SET member = $2 WHERE member = $1`,
+		`UPDATE team_group_members SET member = $2 WHERE member = $1`,
+		`UPDATE swap_requests SET from_member = $2 WHERE from_member = $1 AND status = 'pending'`,
+		`UPDATE swap_requests SET to_member = $2 WHERE to_member = $1 AND status = 'pending'`,
+		`UPDATE member_unavailability SET member = $2 WHERE member = $1`,
+		`UPDATE calendar_tokens SET member = $2 WHERE member = $1`,
+		`UPDATE member_aliases SET member = $2 WHERE member = $1`,
+		`INSERT INTO member_aliases (alias, member) VALUES ($1, $2)`,
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(ctx, statement, from, to); err != nil {
+			return false, fmt.Errorf("failed to rename member: %w", err)
+		}
+	}
+
+	for _, team := range teams {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+			ActorFrom(ctx), AuditRenameMember, team, renameDetail(from, to),
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to record audit entry: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.log.Info("member renamed", zap.String("from", from), zap.String("to", to), zap.Strings("teams", teams))
+	return true, nil
+}
+
+// SetUserTimezone sets the zone of a provisioned user.
+func (s *PostgresStorage) SetUserTimezone(ctx context.Context, id, timezone string) (User, bool, error) {
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return User{}, false, nil
+	}
+
+	users, err := s.queryUsers(ctx,
+		`UPDATE users SET timezone = $2, updated_at = CASE WHEN timezone = $2 THEN updated_at ELSE NOW() END
+		 WHERE id = $1 AND provisioned
+		 RETURNING id, username, emails, active, timezone, created_at, updated_at`,
+		userID, timezone,
+	)
+	if err != nil || len(users) == 0 {
+		return User{}, false, err
+	}
+
+	return users[0], true, nil
+}
+
+// SetTeamMember adds a member to the roster of a team or changes its role,
+// and records it in the audit log. Members are matched to users by name,
+// like the members of schedules.
+func (s *PostgresStorage) SetTeamMember(ctx context.Context, teamName string, member TeamMember) (TeamMember, bool, error) {
+	found, err := s.inTeamTx(ctx, teamName, AuditSetTeamMember, member.auditDetail(), func(tx pgx.Tx, teamID int) error {
+		var userID int
+		err := tx.QueryRow(ctx,
+			`INSERT INTO users (username, email) VALUES ($1, $2)
+			 ON CONFLICT (username) DO UPDATE SET username = EXCLUDED.username
+			 RETURNING id`,
+			member.Name,
+			fmt.Sprintf("%s@example.com", member.Name),
+		).Scan(&userID)
+		if err != nil {
+			return fmt.Errorf("failed to get/create user %s: %w", member.Name, err)
+		}
+
+		err = tx.QueryRow(ctx,
+			`INSERT INTO team_members (team_id, user_id, role) VALUES ($1, $2, $3)
+			 ON CONFLICT (team_id, user_id) DO UPDATE SET role = EXCLUDED.role
+			 RETURNING created_at`,
+			teamID, userID, member.Role,
+		).Scan(&member.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to set team member: %w", err)
+		}
+		return nil
+	})
+	if err != nil || !found {
+		return TeamMember{}, found, err
+	}
+
+	return member, true, nil
+}
+
+// ListTeamMembers returns the roster of a team ordered by name.
+func (s *PostgresStorage) ListTeamMembers(ctx context.Context, teamName string) ([]TeamMember, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT u.username, m.role, m.created_at
+		 FROM team_members m
+		 JOIN teams t ON m.team_id = t.id
+		 JOIN users u ON m.user_id = u.id
+		 WHERE t.name = $1
+		 ORDER BY u.username`,
+		teamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query team members: %w", err)
+	}
+	defer rows.Close()
+
+	var members []TeamMember
+	for rows.Next() {
+		var member TeamMember
+		var role *string
+		var createdAt *time.Time
+		if err = rows.Scan(&member.Name, &role, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan team member: %w", err)
+		}
+		member.Role = MemberRoleMember
+		if role != nil {
+			member.Role = *role
+		}
+		member.CreatedAt = derefTime(createdAt)
+		members = append(members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating team members: %w", err)
+	}
+
+	return members, nil
+}
+
+// RemoveTeamMember removes a member from the roster of a team and records it
+// in the audit log.
+func (s *PostgresStorage) RemoveTeamMember(ctx context.Context, teamName, name string) (bool, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM team_members m
+		 USING teams t, users u
+		 WHERE m.team_id = t.id AND m.user_id = u.id AND t.name = $1 AND u.username = $2`,
+		teamName, name,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove team member: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditRemoveTeamMember, teamName, name,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// queryUsers runs a query returning users.
+func (s *PostgresStorage) queryUsers(ctx context.Context, query string, args ...any) ([]User, error) {
+	rows, err := s.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []User
+	for rows.Next() {
+		var id int
+		var user User
+		var createdAt, updatedAt *time.Time
+		if err = rows.Scan(&id, &user.UserName, &user.Emails, &user.Active, &user.Timezone, &createdAt, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		user.ID = strconv.Itoa(id)
+		user.CreatedAt = derefTime(createdAt)
+		user.UpdatedAt = derefTime(updatedAt)
+		users = append(users, user)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, nil
+}
+
+// DeleteTeam removes a team with its schedules, memberships and pause, and
+// records it in the audit log.
+func (s *PostgresStorage) DeleteTeam(ctx context.Context, teamName string) error {
+	found, err := s.inTeamTx(ctx, teamName, AuditDeleteTeam, "", func(tx pgx.Tx, teamID int) error {
+		statements := []string{
+			`DELETE FROM schedule_days WHERE schedule_id IN (SELECT id FROM schedules WHERE team_id = $1)`,
+			`DELETE FROM schedule_members WHERE schedule_id IN (SELECT id FROM schedules WHERE team_id = $1)`,
+			`DELETE FROM schedule_tags WHERE schedule_id IN (SELECT id FROM schedules WHERE team_id = $1)`,
+			`DELETE FROM schedule_member_refs WHERE schedule_id IN (SELECT id FROM schedules WHERE team_id = $1)`,
+			`DELETE FROM schedule_pins WHERE schedule_id IN (SELECT id FROM schedules WHERE team_id = $1)`,
+			`DELETE FROM rotations WHERE schedule_id IN (SELECT id FROM schedules WHERE team_id = $1)`,
+			`DELETE FROM schedules WHERE team_id = $1`,
+			`DELETE FROM team_members WHERE team_id = $1`,
+			`DELETE FROM team_pauses WHERE team_id = $1`,
+			`DELETE FROM team_freezes WHERE team_id = $1`,
+			`DELETE FROM team_blackouts WHERE team_id = $1`,
+			`DELETE FROM handoff_notes WHERE team_id = $1`,
+			`DELETE FROM team_groups WHERE team_id = $1`,
+			`DELETE FROM teams WHERE id = $1`,
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement, teamID); err != nil {
+				return fmt.Errorf("failed to delete team: %w", err)
+			}
+		}
+
+		// Settings kept by the name would pass on to a team created with it
+		byName := []string{
+			`DELETE FROM team_parents WHERE team = $1 OR parent = $1`,
+			`DELETE FROM team_public_coverage WHERE team = $1`,
+			`DELETE FROM team_google_calendars WHERE team = $1`,
+			`DELETE FROM calendar_tokens WHERE team = $1`,
+			`DELETE FROM webhooks WHERE team = $1`,
+		}
+		for _, statement := range byName {
+			if _, err := tx.Exec(ctx, statement, teamName); err != nil {
+				return fmt.Errorf("failed to delete team settings: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrTeamNotFound
+	}
+
+	s.log.Info("team deleted", zap.String("team", teamName))
+	return nil
+}
+
+// MergeTeams merges the source team into the target in a transaction,
+// locking both teams in ID order so concurrent merges cannot deadlock.
+func (s *PostgresStorage) MergeTeams(ctx context.Context, target, source, policy string) (TeamMerge, bool, error) {
+	if target == source {
+		return TeamMerge{}, false, ErrMergeSelf
+	}
+
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return TeamMerge{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	rows, err := tx.Query(ctx,
+		`SELECT id, name FROM teams WHERE name = ANY($1) ORDER BY id FOR UPDATE`,
+		[]string{target, source},
+	)
+	if err != nil {
+		return TeamMerge{}, false, fmt.Errorf("failed to get teams: %w", err)
+	}
+
+	ids := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return TeamMerge{}, false, fmt.Errorf("failed to scan team: %w", err)
+		}
+		ids[name] = id
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return TeamMerge{}, false, fmt.Errorf("error iterating teams: %w", err)
+	}
+
+	srcID, ok := ids[source]
+	if !ok {
+		return TeamMerge{}, false, nil
+	}
+	dstID, ok := ids[target]
+	if !ok {
+		return TeamMerge{}, false, nil
+	}
+
+	rows, err = tx.Query(ctx,
+		`SELECT id, team_id, name FROM schedules
+		 WHERE team_id = ANY($1) AND deleted_at IS NULL
+		 ORDER BY id`,
+		[]int{srcID, dstID},
+	)
+	if err != nil {
+		return TeamMerge{}, false, fmt.Errorf("failed to query schedules: %w", err)
+	}
+
+	var schedules []Schedule
+	var taken []string
+	for rows.Next() {
+		var id, teamID int
+		var name string
+		if err = rows.Scan(&id, &teamID, &name); err != nil {
+			rows.Close()
+			return TeamMerge{}, false, fmt.Errorf("failed to scan schedule: %w", err)
+		}
+		if teamID == dstID {
+			taken = append(taken, name)
+		} else {
+			schedules = append(schedules, Schedule{ID: strconv.Itoa(id), Name: name})
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return TeamMerge{}, false, fmt.Errorf("error iterating schedules: %w", err)
+	}
+
+	renamed, err := mergeNames(source, schedules, taken, policy)
+	if err != nil {
+		return TeamMerge{}, true, err
+	}
+
+	merge := newTeamMerge(target, source, schedules, renamed)
+
+	for id, name := range renamed {
+		scheduleID, _ := strconv.Atoi(id)
+
+		if _, err = tx.Exec(ctx, `UPDATE schedules SET name = $2, updated_at = NOW() WHERE id = $1`, scheduleID, name); err != nil {
+			return TeamMerge{}, false, fmt.Errorf("failed to rename schedule: %w", err)
+		}
+
+		// The new version is the latest one with the schedule renamed
+		_, err = tx.Exec(ctx,
+			`INSERT INTO schedule_versions (schedule_id, definition)
+			 SELECT schedule_id, definition || jsonb_build_object('Name', $2::text)
+			 FROM schedule_versions
+			 WHERE schedule_id = $1
+			 ORDER BY since DESC, id DESC
+			 LIMIT 1`,
+			scheduleID, name,
+		)
+		if err != nil {
+			return TeamMerge{}, false, fmt.Errorf("failed to record schedule version: %w", err)
+		}
+	}
+
+	// The target keeps its own members, groups and pause, the ones left
+	// behind go with the source
+	statements := []string{
+		`UPDATE schedules SET team_id = $1, updated_at = NOW() WHERE team_id = $2`,
+		`INSERT INTO team_members (team_id, user_id, role, created_at)
+		 SELECT $1, user_id, role, created_at FROM team_members WHERE team_id = $2
+		 ON CONFLICT (team_id, user_id) DO NOTHING`,
+		`UPDATE team_groups SET team_id = $1, updated_at = NOW()
+		 WHERE team_id = $2 AND name NOT IN (SELECT name FROM team_groups WHERE team_id = $1)`,
+		`UPDATE team_freezes SET team_id = $1 WHERE team_id = $2`,
+		`UPDATE team_blackouts SET team_id = $1 WHERE team_id = $2`,
+		`UPDATE handoff_notes SET team_id = $1 WHERE team_id = $2`,
+		`INSERT INTO team_pauses (team_id, reason, since, until)
+		 SELECT $1, reason, since, until FROM team_pauses WHERE team_id = $2
+		 ON CONFLICT (team_id) DO NOTHING`,
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(ctx, statement, dstID, srcID); err != nil {
+			return TeamMerge{}, false, fmt.Errorf("failed to merge team: %w", err)
+		}
+	}
+
+	statements = []string{
+		`DELETE FROM team_members WHERE team_id = $1`,
+		`DELETE FROM team_pauses WHERE team_id = $1`,
+		`DELETE FROM team_groups WHERE team_id = $1`,
+		`DELETE FROM teams WHERE id = $1`,
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(ctx, statement, srcID); err != nil {
+			return TeamMerge{}, false, fmt.Errorf("failed to remove merged team: %w", err)
+		}
+	}
+
+	// Teams merged into the source earlier are part of the target now
+	statements = []string{
+		`UPDATE calendar_tokens SET team = $1 WHERE team = $2`,
+		`UPDATE webhooks SET team = $1 WHERE team = $2`,
+		`UPDATE team_merges SET target = $1 WHERE target = $2`,
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(ctx, statement, target, source); err != nil {
+			return TeamMerge{}, false, fmt.Errorf("failed to merge team: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO team_merges (source, target) VALUES ($1, $2)
+		 ON CONFLICT (source) DO UPDATE SET target = EXCLUDED.target, merged_at = NOW()`,
+		source, target,
+	)
+	if err != nil {
+		return TeamMerge{}, false, fmt.Errorf("failed to record team merge: %w", err)
+	}
+
+	for _, team := range []string{source, target} {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+			ActorFrom(ctx), AuditMergeTeam, team, merge.auditDetail(),
+		)
+		if err != nil {
+			return TeamMerge{}, false, fmt.Errorf("failed to record audit entry: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return TeamMerge{}, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.log.Info("team merged", zap.String("source", source), zap.String("target", target), zap.Int("schedules", merge.Schedules))
+	return merge, true, nil
+}
+
+// MergedInto returns the team the given one was merged into.
+func (s *PostgresStorage) MergedInto(ctx context.Context, team string) (string, bool, error) {
+	var target string
+	err := s.db.Pool.QueryRow(ctx, `SELECT target FROM team_merges WHERE source = $1`, team).Scan(&target)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get team merge: %w", err)
+	}
+
+	return target, true, nil
+}
+
+// SetTeamParent sets or clears the parent of a team and records it in the
+// audit log.
+func (s *PostgresStorage) SetTeamParent(ctx context.Context, team, parent string) error {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	// Parents are set one at a time, so two changes cannot close a cycle together
+	if _, err = tx.Exec(ctx, `LOCK TABLE team_parents IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("failed to lock team parents: %w", err)
+	}
+
+	if parent == "" {
+		_, err = tx.Exec(ctx, `DELETE FROM team_parents WHERE team = $1`, team)
+		if err != nil {
+			return fmt.Errorf("failed to clear team parent: %w", err)
+		}
+	} else {
+		var cycle bool
+		err = tx.QueryRow(ctx,
+			`WITH RECURSIVE ancestors (team) AS (
+			     SELECT $1::VARCHAR
+			     UNION
+			     SELECT p.parent FROM team_parents p JOIN ancestors a ON p.team = a.team
+			 )
+			 SELECT EXISTS (SELECT 1 FROM ancestors WHERE team = $2)`,
+			parent, team,
+		).Scan(&cycle)
+		if err != nil {
+			return fmt.Errorf("failed to check team ancestors: %w", err)
+		}
+		if cycle {
+			return ErrParentCycle
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO team_parents (team, parent) VALUES ($1, $2)
+			 ON CONFLICT (team) DO UPDATE SET parent = EXCLUDED.parent, updated_at = NOW()`,
+			team, parent,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to set team parent: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditSetParent, team, parentDetail(parent),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// TeamParent returns the parent of a team.
+func (s *PostgresStorage) TeamParent(ctx context.Context, team string) (string, bool, error) {
+	var parent string
+	err := s.db.Pool.QueryRow(ctx, `SELECT parent FROM team_parents WHERE team = $1`, team).Scan(&parent)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get team parent: %w", err)
+	}
+
+	return parent, true, nil
+}
+
+// SetPublicCoverage enables or disables the public coverage of a team and
+// records it in the audit log.
+func (s *PostgresStorage) SetPublicCoverage(ctx context.Context, team string, enabled bool) error {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	if enabled {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO team_public_coverage (team) VALUES ($1)
+			 ON CONFLICT (team) DO UPDATE SET updated_at = NOW()`,
+			team,
+		)
+	} else {
+		_, err = tx.Exec(ctx, `DELETE FROM team_public_coverage WHERE team = $1`, team)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set team public coverage: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditSetPublicCoverage, team, publicCoverageDetail(enabled),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// PublicCoverage reports whether the public coverage of a team is enabled.
+func (s *PostgresStorage) PublicCoverage(ctx context.Context, team string) (bool, error) {
+	var enabled bool
+	err := s.db.Pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM team_public_coverage WHERE team = $1)`, team,
+	).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("failed to get team public coverage: %w", err)
+	}
+
+	return enabled, nil
+}
+
+// SetGoogleCalendar sets or clears the Google calendar of a team and records
+// it in the audit log.
+func (s *PostgresStorage) SetGoogleCalendar(ctx context.Context, team, calendarID string) error {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	if calendarID == "" {
+		_, err = tx.Exec(ctx, `DELETE FROM team_google_calendars WHERE team = $1`, team)
+	} else {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO team_google_calendars (team, calendar_id) VALUES ($1, $2)
+			 ON CONFLICT (team) DO UPDATE SET calendar_id = EXCLUDED.calendar_id, updated_at = NOW()`,
+			team, calendarID,
+		)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set team google calendar: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditSetGoogleCalendar, team, googleCalendarDetail(calendarID),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GoogleCalendar returns the Google calendar of a team.
+func (s *PostgresStorage) GoogleCalendar(ctx context.Context, team string) (string, bool, error) {
+	var calendarID string
+	err := s.db.Pool.QueryRow(ctx,
+		`SELECT calendar_id FROM team_google_calendars WHERE team = $1`, team,
+	).Scan(&calendarID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get team google calendar: %w", err)
+	}
+
+	return calendarID, true, nil
+}
+
+// ListGoogleCalendars returns the Google calendars by team.
+func (s *PostgresStorage) ListGoogleCalendars(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.Pool.Query(ctx, `SELECT team, calendar_id FROM team_google_calendars`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list team google calendars: %w", err)
+	}
+	defer rows.Close()
+
+	calendars := make(map[string]string)
+	for rows.Next() {
+		var team, calendarID string
+		if err := rows.Scan(&team, &calendarID); err != nil {
+			return nil, fmt.Errorf("failed to scan team google calendar: %w", err)
+		}
+		calendars[team] = calendarID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list team google calendars: %w", err)
+	}
+
+	return calendars, nil
+}
+
+// PauseTeam pauses a team and records it in the audit log.
+func (s *PostgresStorage) PauseTeam(ctx context.Context, teamName string, pause Pause) (bool, error) {
+	found, err := s.inTeamTx(ctx, teamName, AuditPauseTeam, pause.auditDetail(), func(tx pgx.Tx, teamID int) error {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO team_pauses (team_id, reason, since, until) VALUES ($1, $2, $3, $4)
+			 ON CONFLICT (team_id) DO UPDATE
+			 SET reason = EXCLUDED.reason, since = EXCLUDED.since, until = EXCLUDED.until`,
+			teamID, pause.Reason, pause.Since, nullableDate(pause.Until),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to pause team: %w", err)
+		}
+		return nil
+	})
+	if err != nil || !found {
+		return found, err
+	}
+
+	s.log.Info("team paused", zap.String("team", teamName), zap.Time("until", pause.Until))
+	return true, nil
+}
+
+// UnpauseTeam lifts the pause of a team and records it in the audit log.
+func (s *PostgresStorage) UnpauseTeam(ctx context.Context, teamName string) (bool, error) {
+	found, err := s.inTeamTx(ctx, teamName, AuditUnpauseTeam, "", func(tx pgx.Tx, teamID int) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM team_pauses WHERE team_id = $1`, teamID); err != nil {
+			return fmt.Errorf("failed to unpause team: %w", err)
+		}
+		return nil
+	})
+	if err != nil || !found {
+		return found, err
+	}
+
+	s.log.Info("team unpaused", zap.String("team", teamName))
+	return true, nil
+}
+
+// GetPause returns the latest pause of a team.
+func (s *PostgresStorage) GetPause(ctx context.Context, teamName string) (Pause, bool, error) {
+	var pause Pause
+	var until *time.Time
+
+	err := s.db.Pool.QueryRow(ctx,
+		`SELECT p.reason, p.since, p.until
+		 FROM team_pauses p
+		 JOIN teams t ON p.team_id = t.id
+		 WHERE t.name = $1`,
+		teamName,
+	).Scan(&pause.Reason, &pause.Since, &until)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Pause{}, false, nil
+		}
+		return Pause{}, false, fmt.Errorf("failed to get pause: %w", err)
+	}
+	pause.Until = derefTime(until)
+
+	return pause, true, nil
+}
+
+// SetGroup creates or replaces a group of a team and records it in the audit
+// log. Members of the group are kept with when they were added and removed,
+// so schedules referencing it only take the changes into account from their
+// next handoff.
+func (s *PostgresStorage) SetGroup(ctx context.Context, teamName string, group Group) (Group, bool, error) {
+	found, err := s.inTeamTx(ctx, teamName, AuditSetGroup, group.auditDetail(), func(tx pgx.Tx, teamID int) error {
+		var groupID int
+		err := tx.QueryRow(ctx,
+			`INSERT INTO team_groups (team_id, name) VALUES ($1, $2)
+			 ON CONFLICT (team_id, name) DO UPDATE SET updated_at = NOW()
+			 RETURNING id, created_at, updated_at`,
+			teamID, group.Name,
+		).Scan(&groupID, &group.CreatedAt, &group.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to set group: %w", err)
+		}
+
+		rows, err := tx.Query(ctx,
+			`SELECT member, added_at, removed_at FROM team_group_members WHERE group_id = $1 ORDER BY position`,
+			groupID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to query group members: %w", err)
+		}
+
+		var current []groupMember
+		for rows.Next() {
+			var member groupMember
+			var added, removed *time.Time
+			if err = rows.Scan(&member.Name, &added, &removed); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan group member: %w", err)
+			}
+			member.Added, member.Removed = derefTime(added), derefTime(removed)
+			current = append(current, member)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("error iterating group members: %w", err)
+		}
+
+		members := setGroupMembers(current, group.Members, group.UpdatedAt)
+		if _, err = tx.Exec(ctx, `DELETE FROM team_group_members WHERE group_id = $1`, groupID); err != nil {
+			return fmt.Errorf("failed to replace group members: %w", err)
+		}
+		for position, member := range members {
+			_, err = tx.Exec(ctx,
+				`INSERT INTO team_group_members (group_id, member, position, added_at, removed_at)
+				 VALUES ($1, $2, $3, $4, $5)`,
+				groupID, member.Name, position, nullableDate(member.Added), nullableDate(member.Removed),
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert group member: %w", err)
+			}
+		}
+
+		group.Members = currentMembers(members)
+		return nil
+	})
+	if err != nil || !found {
+		return Group{}, found, err
+	}
+
+	return group, true, nil
+}
+
+// ListGroups returns the groups of a team ordered by name, with their current
+// members in the order they were added.
+func (s *PostgresStorage) ListGroups(ctx context.Context, teamName string) ([]Group, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT g.name, g.created_at, g.updated_at, gm.member
+		 FROM team_groups g
+		 JOIN teams t ON g.team_id = t.id
+		 LEFT JOIN team_group_members gm ON gm.group_id = g.id AND gm.removed_at IS NULL
+		 WHERE t.name = $1
+		 ORDER BY g.name, gm.position`,
+		teamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []Group
+	for rows.Next() {
+		var group Group
+		var member *string
+		if err = rows.Scan(&group.Name, &group.CreatedAt, &group.UpdatedAt, &member); err != nil {
+			return nil, fmt.Errorf("failed to scan group: %w", err)
+		}
+		if len(groups) == 0 || groups[len(groups)-1].Name != group.Name {
+			groups = append(groups, group)
+		}
+		if member != nil {
+			last := &groups[len(groups)-1]
+			last.Members = append(last.Members, *member)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// DeleteGroup removes a group of a team unless a schedule references it, and
+// records it in the audit log.
+func (s *PostgresStorage) DeleteGroup(ctx context.Context, teamName, name string) (bool, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	var inUse bool
+	err = tx.QueryRow(ctx,
+		`SELECT EXISTS (
+		   SELECT 1 FROM schedule_member_refs r
+		   JOIN schedules s ON r.schedule_id = s.id
+		   JOIN teams t ON s.team_id = t.id
+		   WHERE t.name = $1 AND s.deleted_at IS NULL AND r.member = $2
+		 )`,
+		teamName, GroupPrefix+name,
+	).Scan(&inUse)
+	if err != nil {
+		return false, fmt.Errorf("failed to check group references: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM team_groups g
+		 USING teams t
+		 WHERE g.team_id = t.id AND t.name = $1 AND g.name = $2`,
+		teamName, name,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete group: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+	if inUse {
+		return false, ErrGroupInUse
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditDeleteGroup, teamName, name,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// AddFreeze freezes a team for a window and records it in the audit log.
+func (s *PostgresStorage) AddFreeze(ctx context.Context, teamName string, freeze Freeze) (Freeze, bool, error) {
+	found, err := s.inTeamTx(ctx, teamName, AuditFreezeTeam, freeze.auditDetail(), func(tx pgx.Tx, teamID int) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO team_freezes (team_id, reason, starts_at, ends_at) VALUES ($1, $2, $3, $4)
+			 RETURNING id, created_at`,
+			teamID, freeze.Reason, freeze.Start, freeze.End,
+		).Scan(&freeze.ID, &freeze.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to freeze team: %w", err)
+		}
+		return nil
+	})
+	if err != nil || !found {
+		return Freeze{}, found, err
+	}
+
+	s.log.Info("team frozen", zap.String("team", teamName), zap.Time("until", freeze.End))
+	return freeze, true, nil
+}
+
+// AddBlackout adds a blackout window to a team and records it in the audit log.
+func (s *PostgresStorage) AddBlackout(ctx context.Context, teamName string, blackout Blackout) (Blackout, bool, error) {
+	found, err := s.inTeamTx(ctx, teamName, AuditAddBlackout, blackout.auditDetail(), func(tx pgx.Tx, teamID int) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO team_blackouts (team_id, reason, starts_at, ends_at) VALUES ($1, $2, $3, $4)
+			 RETURNING id, created_at`,
+			teamID, blackout.Reason, blackout.Start, blackout.End,
+		).Scan(&blackout.ID, &blackout.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to insert blackout: %w", err)
+		}
+		return nil
+	})
+	if err != nil || !found {
+		return Blackout{}, found, err
+	}
+
+	s.log.Info("blackout added", zap.String("team", teamName), zap.Time("start", blackout.Start), zap.Time("end", blackout.End))
+	return blackout, true, nil
+}
+
+// ListBlackouts returns the blackouts of a team ordered by start.
+func (s *PostgresStorage) ListBlackouts(ctx context.Context, teamName string) ([]Blackout, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT b.id, b.reason, b.starts_at, b.ends_at, b.created_at
+		 FROM team_blackouts b
+		 JOIN teams t ON b.team_id = t.id
+		 WHERE t.name = $1
+		 ORDER BY b.starts_at, b.id`,
+		teamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blackouts: %w", err)
+	}
+	defer rows.Close()
+
+	var blackouts []Blackout
+	for rows.Next() {
+		var blackout Blackout
+		if err = rows.Scan(&blackout.ID, &blackout.Reason, &blackout.Start, &blackout.End, &blackout.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan blackout: %w", err)
+		}
+		blackouts = append(blackouts, blackout)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating blackouts: %w", err)
+	}
+
+	return blackouts, nil
+}
+
+// CancelBlackout removes a blackout of a team and records it in the audit log.
+func (s *PostgresStorage) CancelBlackout(ctx context.Context, teamName string, id int64) (bool, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM team_blackouts b
+		 USING teams t
+		 WHERE b.team_id = t.id AND t.name = $1 AND b.id = $2`,
+		teamName, id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel blackout: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditCancelBlackout, teamName, strconv.FormatInt(id, 10),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// handoffNoteColumns are the columns scanHandoffNote reads, of handoff_notes n.
+const handoffNoteColumns = `n.id, n.schedule_id, n.member, n.shift_start, n.shift_end,
+	n.author, n.text, n.expires_at, n.created_at, n.updated_at`
+
+// AddHandoffNote adds a handoff note to a shift of a schedule of the team and
+// records it in the audit log.
+func (s *PostgresStorage) AddHandoffNote(ctx context.Context, teamName string, note HandoffNote) (HandoffNote, bool, error) {
+	scheduleID, err := strconv.Atoi(note.ScheduleID)
+	if err != nil {
+		return HandoffNote{}, false, nil
+	}
+
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return HandoffNote{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	var teamID int
+	err = tx.QueryRow(ctx, `SELECT id FROM teams WHERE name = $1 FOR UPDATE`, teamName).Scan(&teamID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return HandoffNote{}, false, nil
+	}
+	if err != nil {
+		return HandoffNote{}, false, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO handoff_notes (team_id, schedule_id, member, shift_start, shift_end, author, text, expires_at)
+		 SELECT $1, s.id, $3, $4, $5, $6, $7, $8
+		 FROM schedules s
+		 WHERE s.id = $2 AND s.team_id = $1
+		 ON CONFLICT (schedule_id, shift_start) DO NOTHING
+		 RETURNING id, created_at, updated_at`,
+		teamID, scheduleID, note.Member, note.ShiftStart, note.ShiftEnd, note.Author, note.Text, note.ExpiresAt,
+	).Scan(&note.ID, &note.CreatedAt, &note.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Either the shift has a note or the schedule is not of the team
+		var exists bool
+		err = tx.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM handoff_notes WHERE schedule_id = $1 AND shift_start = $2)`,
+			scheduleID, note.ShiftStart,
+		).Scan(&exists)
+		if err != nil {
+			return HandoffNote{}, false, fmt.Errorf("failed to check handoff note: %w", err)
+		}
+		if exists {
+			return HandoffNote{}, true, ErrHandoffNoteExists
+		}
+		return HandoffNote{}, false, nil
+	}
+	if err != nil {
+		return HandoffNote{}, false, fmt.Errorf("failed to insert handoff note: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditAddHandoffNote, teamName, note.auditDetail(),
+	)
+	if err != nil {
+		return HandoffNote{}, false, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return HandoffNote{}, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	s.log.Info("handoff note added", zap.String("team", teamName), zap.Int64("id", note.ID), zap.String("member", note.Member))
+	return note, true, nil
+}
+
+// ListHandoffNotes returns the handoff notes of a team ordered by ID.
+func (s *PostgresStorage) ListHandoffNotes(ctx context.Context, teamName string) ([]HandoffNote, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT `+handoffNoteColumns+`
+		 FROM handoff_notes n
+		 JOIN teams t ON n.team_id = t.id
+		 WHERE t.name = $1
+		 ORDER BY n.id`,
+		teamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query handoff notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []HandoffNote
+	for rows.Next() {
+		note, err := scanHandoffNote(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan handoff note: %w", err)
+		}
+		notes = append(notes, note)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating handoff notes: %w", err)
+	}
+
+	return notes, nil
+}
+
+// UpdateHandoffNote replaces the text of a handoff note of a team and records
+// it in the audit log.
+func (s *PostgresStorage) UpdateHandoffNote(ctx context.Context, teamName string, id int64, text string) (HandoffNote, bool, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return HandoffNote{}, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	note, err := scanHandoffNote(tx.QueryRow(ctx,
+		`UPDATE handoff_notes n SET text = $3, updated_at = NOW()
+		 FROM teams t
+		 WHERE n.team_id = t.id AND t.name = $1 AND n.id = $2
+		 RETURNING `+handoffNoteColumns,
+		teamName, id, text,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return HandoffNote{}, false, nil
+	}
+	if err != nil {
+		return HandoffNote{}, false, fmt.Errorf("failed to update handoff note: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditUpdateHandoffNote, teamName, note.auditDetail(),
+	)
+	if err != nil {
+		return HandoffNote{}, false, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return HandoffNote{}, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return note, true, nil
+}
+
+// DeleteHandoffNote removes a handoff note of a team and records it in the
+// audit log.
+func (s *PostgresStorage) DeleteHandoffNote(ctx context.Context, teamName string, id int64) (bool, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM handoff_notes n
+		 USING teams t
+		 WHERE n.team_id = t.id AND t.name = $1 AND n.id = $2`,
+		teamName, id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete handoff note: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditDeleteHandoffNote, teamName, strconv.FormatInt(id, 10),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// scanHandoffNote scans a row of handoffNoteColumns.
+func scanHandoffNote(row pgx.Row) (HandoffNote, error) {
+	var (
+		note       HandoffNote
+		scheduleID int
+	)
+
+	err := row.Scan(&note.ID, &scheduleID, &note.Member, &note.ShiftStart, &note.ShiftEnd,
+		&note.Author, &note.Text, &note.ExpiresAt, &note.CreatedAt, &note.UpdatedAt)
+	if err != nil {
+		return HandoffNote{}, err
+	}
+
+	note.ScheduleID = strconv.Itoa(scheduleID)
+
+	return note, nil
+}
+
+// AddUnavailability marks a member unavailable for a window.
+func (s *PostgresStorage) AddUnavailability(ctx context.Context, unavailability Unavailability) (Unavailability, error) {
+	err := s.db.Pool.QueryRow(ctx,
+		`INSERT INTO member_unavailability (member, reason, starts_at, ends_at) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		unavailability.Member, unavailability.Reason, unavailability.Start, unavailability.End,
+	).Scan(&unavailability.ID, &unavailability.CreatedAt)
+	if err != nil {
+		return Unavailability{}, fmt.Errorf("failed to insert unavailability: %w", err)
+	}
+
+	return unavailability, nil
+}
+
+// ListUnavailability returns the unavailability windows of a member ordered by start.
+func (s *PostgresStorage) ListUnavailability(ctx context.Context, member string) ([]Unavailability, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT id, member, reason, starts_at, ends_at, created_at
+		 FROM member_unavailability
+		 WHERE member = $1
+		 ORDER BY starts_at, id`,
+		member,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unavailability: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []Unavailability
+	for rows.Next() {
+		var window Unavailability
+		if err = rows.Scan(&window.ID, &window.Member, &window.Reason, &window.Start, &window.End, &window.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan unavailability: %w", err)
+		}
+		windows = append(windows, window)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unavailability: %w", err)
+	}
+
+	return windows, nil
+}
+
+// CancelUnavailability removes an unavailability window of a member.
+func (s *PostgresStorage) CancelUnavailability(ctx context.Context, member string, id int64) (bool, error) {
+	tag, err := s.db.Pool.Exec(ctx,
+		`DELETE FROM member_unavailability WHERE member = $1 AND id = $2`,
+		member, id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel unavailability: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// UnavailableMembers returns the given members who are unavailable at the instant.
+func (s *PostgresStorage) UnavailableMembers(ctx context.Context, members []string, at time.Time) ([]string, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT DISTINCT member
+		 FROM member_unavailability
+		 WHERE member = ANY($1) AND starts_at <= $2 AND ends_at > $2`,
+		members, at,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unavailable members: %w", err)
+	}
+	defer rows.Close()
+
+	var unavailable []string
+	for rows.Next() {
+		var member string
+		if err = rows.Scan(&member); err != nil {
+			return nil, fmt.Errorf("failed to scan unavailable member: %w", err)
+		}
+		unavailable = append(unavailable, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating unavailable members: %w", err)
+	}
+
+	return unavailable, nil
+}
+
+// ListFreezes returns the freezes of a team ordered by start.
+func (s *PostgresStorage) ListFreezes(ctx context.Context, teamName string) ([]Freeze, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT f.id, f.reason, f.starts_at, f.ends_at, f.created_at
+		 FROM team_freezes f
+		 JOIN teams t ON f.team_id = t.id
+		 WHERE t.name = $1
+		 ORDER BY f.starts_at, f.id`,
+		teamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query freezes: %w", err)
+	}
+	defer rows.Close()
+
+	var freezes []Freeze
+	for rows.Next() {
+		var freeze Freeze
+		if err = rows.Scan(&freeze.ID, &freeze.Reason, &freeze.Start, &freeze.End, &freeze.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan freeze: %w", err)
+		}
+		freezes = append(freezes, freeze)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating freezes: %w", err)
+	}
+
+	return freezes, nil
+}
+
+// CancelFreeze removes a freeze of a team and records it in the audit log.
+func (s *PostgresStorage) CancelFreeze(ctx context.Context, teamName string, id int64) (bool, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	tag, err := tx.Exec(ctx,
+		`DELETE FROM team_freezes f
+		 USING teams t
+		 WHERE f.team_id = t.id AND t.name = $1 AND f.id = $2`,
+		teamName, id,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel freeze: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), AuditCancelFreeze, teamName, strconv.FormatInt(id, 10),
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// RecordAudit records a change made outside of the storage layer in the
+// audit log of a team.
+func (s *PostgresStorage) RecordAudit(ctx context.Context, teamName, action, detail string) error {
+	_, err := s.db.Pool.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), action, teamName, detail,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// AuditLog returns the audit entries of a team, oldest first.
+func (s *PostgresStorage) AuditLog(ctx context.Context, teamName string) ([]AuditEntry, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT at, actor, action, team, detail FROM audit_log WHERE team = $1 ORDER BY id`,
+		teamName,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []AuditEntry
+	for rows.Next() {
+		var entry AuditEntry
+		if err = rows.Scan(&entry.At, &entry.Actor, &entry.Action, &entry.Team, &entry.Detail); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteExpiredSchedules soft-deletes the expired schedules of every team and
+// records each of them in the audit log.
+func (s *PostgresStorage) DeleteExpiredSchedules(ctx context.Context, before time.Time) (int, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	rows, err := tx.Query(ctx,
+		`UPDATE schedules s SET deleted_at = NOW()
+		 FROM teams t
+		 WHERE s.team_id = t.id
+		   AND s.deleted_at IS NULL
+		   AND s.valid_until <= $1
+		 RETURNING t.name, s.name`,
+		before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired schedules: %w", err)
+	}
+
+	var teams, names []string
+	for rows.Next() {
+		var team, name string
+		if err = rows.Scan(&team, &name); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan expired schedule: %w", err)
+		}
+		teams = append(teams, team)
+		names = append(names, name)
+	}
+	rows.Close()
+
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating expired schedules: %w", err)
+	}
+
+	for i := range names {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+			ActorFrom(ctx), AuditDeleteSchedule, teams[i], names[i],
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to record audit entry: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(names), nil
+}
+
+// PurgeExpiredPauses deletes the expired pauses of every team and records
+// each of them in the audit log.
+func (s *PostgresStorage) PurgeExpiredPauses(ctx context.Context, before time.Time) (int, error) {
+	tag, err := s.db.Pool.Exec(ctx,
+		`WITH purged AS (
+		   DELETE FROM team_pauses p
+		   USING teams t
+		   WHERE p.team_id = t.id AND p.until <= $1
+		   RETURNING t.name
+		 )
+		 INSERT INTO audit_log (actor, action, team, detail)
+		 SELECT $2, $3, name, 'expired' FROM purged`,
+		before, ActorFrom(ctx), AuditUnpauseTeam,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired pauses: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// TrimAuditLog deletes the audit entries recorded before the given instant.
+func (s *PostgresStorage) TrimAuditLog(ctx context.Context, before time.Time) (int, error) {
+	tag, err := s.db.Pool.Exec(ctx, `DELETE FROM audit_log WHERE at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to trim audit log: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// AddWebhook stores a webhook subscription.
+func (s *PostgresStorage) AddWebhook(ctx context.Context, webhook Webhook) (Webhook, error) {
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	if hasQuota(ctx, QuotaWebhooks) {
+		// Subscriptions name teams that may not exist, so an advisory lock
+		// on the team name keeps the count until commit
+		if _, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('webhooks:' || $1))`, webhook.Team); err != nil {
+			return Webhook{}, fmt.Errorf("failed to lock webhooks: %w", err)
+		}
+
+		var count int
+		if err = tx.QueryRow(ctx, `SELECT COUNT(*) FROM webhooks WHERE team = $1`, webhook.Team).Scan(&count); err != nil {
+			return Webhook{}, fmt.Errorf("failed to count webhooks: %w", err)
+		}
+		if err := checkQuota(ctx, QuotaWebhooks, webhook.Team, count); err != nil {
+			return Webhook{}, err
+		}
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO webhooks (team, url, secret, events) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		webhook.Team, webhook.URL, webhook.Secret, webhook.Events,
+	).Scan(&webhook.ID, &webhook.CreatedAt)
+	if err != nil {
+		return Webhook{}, fmt.Errorf("failed to insert webhook: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return Webhook{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// ListWebhooks returns every webhook subscription.
+func (s *PostgresStorage) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT id, team, url, secret, events, created_at FROM webhooks ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []Webhook
+	for rows.Next() {
+		var w Webhook
+		if err = rows.Scan(&w.ID, &w.Team, &w.URL, &w.Secret, &w.Events, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes a webhook subscription.
+func (s *PostgresStorage) DeleteWebhook(ctx context.Context, id int64) (bool, error) {
+	tag, err := s.db.Pool.Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// MarkReminderSent records a sent reminder.
+func (s *PostgresStorage) MarkReminderSent(ctx context.Context, key string) (bool, error) {
+	tag, err := s.db.Pool.Exec(ctx,
+		`INSERT INTO sent_reminders (key) VALUES ($1) ON CONFLICT (key) DO NOTHING`,
+		key,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record reminder: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// AddCalendarToken stores a calendar feed token.
+func (s *PostgresStorage) AddCalendarToken(ctx context.Context, token CalendarToken) (CalendarToken, error) {
+	var expiresAt *time.Time
+	if !token.ExpiresAt.IsZero() {
+		expiresAt = &token.ExpiresAt
+	}
+
+	err := s.db.Pool.QueryRow(ctx,
+		`INSERT INTO calendar_tokens (team, member, hash, expires_at) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		token.Team, token.Member, token.Hash, expiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return CalendarToken{}, fmt.Errorf("failed to insert calendar token: %w", err)
+	}
+
+	return token, nil
+}
+
+// GetCalendarToken returns a calendar feed token by its hash.
+func (s *PostgresStorage) GetCalendarToken(ctx context.Context, hash string) (CalendarToken, bool, error) {
+	var (
+		token     CalendarToken
+		expiresAt *time.Time
+	)
+
+	err := s.db.Pool.QueryRow(ctx,
+		`SELECT id, team, member, hash, expires_at, created_at FROM calendar_tokens WHERE hash = $1`,
+		hash,
+	).Scan(&token.ID, &token.Team, &token.Member, &token.Hash, &expiresAt, &token.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return CalendarToken{}, false, nil
+	}
+	if err != nil {
+		return CalendarToken{}, false, fmt.Errorf("failed to query calendar token: %w", err)
+	}
+
+	if expiresAt != nil {
+		token.ExpiresAt = *expiresAt
+	}
+
+	return token, true, nil
+}
+
+// DeleteCalendarToken revokes a calendar feed token.
+func (s *PostgresStorage) DeleteCalendarToken(ctx context.Context, team string, id int64) (bool, error) {
+	tag, err := s.db.Pool.Exec(ctx, `DELETE FROM calendar_tokens WHERE id = $1 AND team = $2`, id, team)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete calendar token: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// AddAPIKey stores an API key.
+func (s *PostgresStorage) AddAPIKey(ctx context.Context, key APIKey) (APIKey, error) {
+	var expiresAt *time.Time
+	if !key.ExpiresAt.IsZero() {
+		expiresAt = &key.ExpiresAt
+	}
+
+	err := s.db.Pool.QueryRow(ctx,
+		`INSERT INTO api_keys (name, role, hash, expires_at) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		key.Name, key.Role, key.Hash, expiresAt,
+	).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return APIKey{}, fmt.Errorf("failed to insert api key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetAPIKey returns an API key by its hash.
+func (s *PostgresStorage) GetAPIKey(ctx context.Context, hash string) (APIKey, bool, error) {
+	row := s.db.Pool.QueryRow(ctx,
+		`SELECT id, name, role, hash, expires_at, revoked_at, created_at FROM api_keys WHERE hash = $1`,
+		hash,
+	)
+
+	key, err := scanAPIKey(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return APIKey{}, false, nil
+	}
+	if err != nil {
+		return APIKey{}, false, fmt.Errorf("failed to query api key: %w", err)
+	}
+
+	return key, true, nil
+}
+
+// ListAPIKeys returns every API key.
+func (s *PostgresStorage) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT id, name, role, hash, expires_at, revoked_at, created_at FROM api_keys ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an API key.
+func (s *PostgresStorage) RevokeAPIKey(ctx context.Context, id int64, at time.Time) (bool, error) {
+	tag, err := s.db.Pool.Exec(ctx,
+		`UPDATE api_keys SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`,
+		id, at,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// scopedTokenColumns are the columns scanScopedToken scans.
+const scopedTokenColumns = `id, name, scopes, teams, rate_limit, hash, uses, last_used_at, revoked_at, created_at`
+
+// AddScopedToken stores a scoped token.
+func (s *PostgresStorage) AddScopedToken(ctx context.Context, token ScopedToken) (ScopedToken, error) {
+	scopes, teams := token.Scopes, token.Teams
+	if scopes == nil {
+		scopes = []string{}
+	}
+	if teams == nil {
+		teams = []string{}
+	}
+
+	err := s.db.Pool.QueryRow(ctx,
+		`INSERT INTO scoped_tokens (name, scopes, teams, rate_limit, hash) VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, created_at`,
+		token.Name, scopes, teams, token.RateLimit, token.Hash,
+	).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return ScopedToken{}, fmt.Errorf("failed to insert scoped token: %w", err)
+	}
+
+	return token, nil
+}
+
+// GetScopedToken returns a scoped token by its hash.
+func (s *PostgresStorage) GetScopedToken(ctx context.Context, hash string) (ScopedToken, bool, error) {
+	row := s.db.Pool.QueryRow(ctx,
+		`SELECT `+scopedTokenColumns+` FROM scoped_tokens WHERE hash = $1`,
+		hash,
+	)
+
+	token, err := scanScopedToken(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ScopedToken{}, false, nil
+	}
+	if err != nil {
+		return ScopedToken{}, false, fmt.Errorf("failed to query scoped token: %w", err)
+	}
+
+	return token, true, nil
+}
+
+// ListScopedTokens returns every scoped token.
+func (s *PostgresStorage) ListScopedTokens(ctx context.Context) ([]ScopedToken, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT `+scopedTokenColumns+` FROM scoped_tokens ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query scoped tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []ScopedToken
+	for rows.Next() {
+		token, err := scanScopedToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan scoped token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating scoped tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+// RevokeScopedToken revokes a scoped token.
+func (s *PostgresStorage) RevokeScopedToken(ctx context.Context, id int64, at time.Time) (bool, error) {
+	tag, err := s.db.Pool.Exec(ctx,
+		`UPDATE scoped_tokens SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL`,
+		id, at,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke scoped token: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// RecordScopedTokenUse counts a use of a scoped token.
+func (s *PostgresStorage) RecordScopedTokenUse(ctx context.Context, id int64, at time.Time) error {
+	_, err := s.db.Pool.Exec(ctx,
+		`UPDATE scoped_tokens SET uses = uses + 1, last_used_at = GREATEST(last_used_at, $2) WHERE id = $1`,
+		id, at,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record scoped token use: %w", err)
+	}
+
+	return nil
+}
+
+// scanScopedToken scans a scoped token row selected with scopedTokenColumns,
+// whose optional instants are nullable.
+func scanScopedToken(row pgx.Row) (ScopedToken, error) {
+	var (
+		token                 ScopedToken
+		lastUsedAt, revokedAt *time.Time
+	)
+
+	err := row.Scan(&token.ID, &token.Name, &token.Scopes, &token.Teams, &token.RateLimit, &token.Hash,
+		&token.Uses, &lastUsedAt, &revokedAt, &token.CreatedAt)
+	if err != nil {
+		return ScopedToken{}, err
+	}
+
+	if lastUsedAt != nil {
+		token.LastUsedAt = *lastUsedAt
+	}
+	if revokedAt != nil {
+		token.RevokedAt = *revokedAt
+	}
+
+	return token, nil
+}
+
+// scanAPIKey scans an API key row, whose optional instants are nullable.
+func scanAPIKey(row pgx.Row) (APIKey, error) {
+	var (
+		key                  APIKey
+		expiresAt, revokedAt *time.Time
+	)
+
+	if err := row.Scan(&key.ID, &key.Name, &key.Role, &key.Hash, &expiresAt, &revokedAt, &key.CreatedAt); err != nil {
+		return APIKey{}, err
+	}
+
+	if expiresAt != nil {
+		key.ExpiresAt = *expiresAt
+	}
+	if revokedAt != nil {
+		key.RevokedAt = *revokedAt
+	}
+
+	return key, nil
+}
+
+// scanSwapRequest scans a swap request row selected with swapRequestColumns,
+// whose decision instant is nullable.
+func scanSwapRequest(row pgx.Row) (SwapRequest, error) {
+	var (
+		request    SwapRequest
+		scheduleID int
+		decidedAt  *time.Time
+	)
+
+	err := row.Scan(&request.ID, &request.Team, &scheduleID, &request.ShiftStart, &request.ShiftEnd,
+		&request.From, &request.To, &request.Status, &request.TokenHash, &request.CreatedAt, &decidedAt)
+	if err != nil {
+		return SwapRequest{}, err
+	}
+
+	request.ScheduleID = strconv.Itoa(scheduleID)
+	if decidedAt != nil {
+		request.DecidedAt = *decidedAt
+	}
+
+	return request, nil
+}
+
+// inTeamTx runs fn for an existing team in a transaction that also records
+// the change in the audit log. It reports false when the team does not exist.
+func (s *PostgresStorage) inTeamTx(
+	ctx context.Context, teamName, action, detail string, fn func(tx pgx.Tx, teamID int) error,
+) (bool, error) {
+	tx, err := s.db.Pool.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			s.log.Debug("transaction rollback returned error (may be already committed)", zap.Error(rbErr))
+		}
+	}()
+
+	var teamID int
+	err = tx.QueryRow(ctx, `SELECT id FROM teams WHERE name = $1 FOR UPDATE`, teamName).Scan(&teamID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get team: %w", err)
+	}
+
+	if err = fn(tx, teamID); err != nil {
+		return false, err
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO audit_log (actor, action, team, detail) VALUES ($1, $2, $3, $4)`,
+		ActorFrom(ctx), action, teamName, detail,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record audit entry: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// getCurrentRecurringOncall evaluates the cron and RRULE based schedules of a
+// team in Go, since their occurrences cannot be matched in SQL. Only the
+// schedules added before the one with the given ID are evaluated, all of
+// them when it is zero.
+func (s *PostgresStorage) getCurrentRecurringOncall(ctx context.Context, teamID int, at time.Time, before int) (string, bool, error) {
+	rows, err := s.db.Pool.Query(ctx,
+		`SELECT s.id, s.name, COALESCE(s.cron, ''), COALESCE(s.rrule, ''), s.anchor, s.start_time, s.end_time, s.valid_until, s.rotation_offset, s.rotation_manual, s.shift_split, s.required_count, s.handoff_day, s.handoff_time, s.team_members
+		 FROM schedules s
+		 JOIN rotations r ON s.id = r.schedule_id
+		 WHERE s.team_id = $1
+		   AND s.deleted_at IS NULL
+		   AND (s.cron IS NOT NULL OR s.rrule IS NOT NULL)
+		   AND ($2 = 0 OR s.id < $2)
+		 ORDER BY s.id`,
+		teamID, before,
+	)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to query recurring schedules: %w", err)
+	}
+	defer rows.Close()
+
+	matchID := 0
+	var match Schedule
+	for rows.Next() {
+		var scheduleID int
+		var sched Schedule
+		var anchor, validUntil, clock *time.Time
+		var day *int16
+
+		err = rows.Scan(&scheduleID, &sched.Name, &sched.Cron, &sched.RRule, &anchor, &sched.Start, &sched.End, &validUntil, &sched.RotationOffset, &sched.Manual, &sched.Split, &sched.RequiredCount, &day, &clock, &sched.TeamMembers)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to scan recurring schedule: %w", err)
+		}
+		sched.Anchor = derefTime(anchor)
+		sched.ValidUntil = derefTime(validUntil)
+		sched.Handoff = scanHandoff(day, clock)
+
+		if sched.covers(at) {
+			matchID, match = scheduleID, sched
+			break
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return "", false, fmt.Errorf("error iterating recurring schedules: %w", err)
+	}
+	rows.Close()
+
+	if matchID == 0 {
+		return "", false, nil
+	}
+
+	return s.memberAt(ctx, matchID, match, at)
+}
+
+// memberAt returns the member on duty at the given instant of the schedule
+// with the given ID, whose members and pins are loaded on demand. Shifts are
+// shorter than a day, so only pins from the day before on can apply.
+func (s *PostgresStorage) memberAt(ctx context.Context, scheduleID int, sched Schedule, at time.Time) (string, bool, error) {
+	err := s.loadMembers(ctx, scheduleID, &sched)
+	if err != nil {
+		return "", false, err
+	}
+
+	if sched.Pins, err = s.loadPins(ctx, scheduleID, at.AddDate(0, 0, -1)); err != nil {
+		return "", false, err
+	}
+
+	member, found := sched.memberAt(at)
+	return member, found, nil
+}
+
+// scheduleNameIndex is the unique index of the names of the live schedules
+// of a team.
+const scheduleNameIndex = "idx_schedules_team_name"
+
+// uniqueViolation reports whether the error is a unique violation, SQLSTATE
+// 23505, of the constraint or index with the given name.
+func uniqueViolation(err error, constraint string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == constraint
+}
+
+// nullableDate maps the zero time to NULL.
+func nullableDate(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// handoffDay returns the handoff_day column of a handoff, NULL without one.
+func handoffDay(h *Handoff) *int16 {
+	if h == nil {
+		return nil
+	}
+	day := int16(h.Day)
+	return &day
+}
+
+// handoffTime returns the handoff_time column of a handoff, NULL without one.
+func handoffTime(h *Handoff) *string {
+	if h == nil {
+		return nil
+	}
+	clock := h.Time.Format("15:04:05")
+	return &clock
+}
+
+// scanHandoff maps the handoff_day and handoff_time columns to a handoff,
+// NULL to none.
+func scanHandoff(day *int16, clock *time.Time) *Handoff {
+	if day == nil || clock == nil {
+		return nil
+	}
+	return &Handoff{Day: time.Weekday(*day), Time: *clock}
+}
+
+// routingColumn returns the routing column of a schedule, which is not
+// nullable.
+func routingColumn(routing map[string]string) map[string]string {
+	if routing == nil {
+		return map[string]string{}
+	}
+	return routing
+}
+
+// derefTime maps NULL to the zero time.
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
 }
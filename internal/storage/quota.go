@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+// Resources limited by quotas, which are counted per team.
+const (
+	QuotaSchedules = "schedules"
+	QuotaPins      = "pins"
+	QuotaWebhooks  = "webhooks"
+)
+
+// QuotaError is returned by writes that would take a team past its quota of
+// a resource. Current is the count found when the write was attempted.
+type QuotaError struct {
+	Resource string
+	Team     string
+	Current  int
+	Limit    int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("quota of %d %s of team %q exceeded", e.Limit, e.Resource, e.Team)
+}
+
+type quotaKey struct{ resource string }
+
+// WithQuota returns a context that limits how many of the resource a team
+// may have after a write made with it. Writes made without one, or with a
+// limit that is not positive, are not limited.
+func WithQuota(ctx context.Context, resource string, limit int) context.Context {
+	return context.WithValue(ctx, quotaKey{resource}, limit)
+}
+
+// checkQuota returns a QuotaError when a team having current of the resource
+// may not get another one.
+func checkQuota(ctx context.Context, resource, team string, current int) error {
+	limit, ok := ctx.Value(quotaKey{resource}).(int)
+	if !ok || limit <= 0 || current < limit {
+		return nil
+	}
+
+	return &QuotaError{Resource: resource, Team: team, Current: current, Limit: limit}
+}
+
+// hasQuota reports whether writes of the resource made with ctx are limited,
+// so counting can be skipped when they are not.
+func hasQuota(ctx context.Context, resource string) bool {
+	limit, ok := ctx.Value(quotaKey{resource}).(int)
+
+	return ok && limit > 0
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"errors"
+	"maps"
+	"time"
+)
+
+// ErrMemberExists is returned when renaming a member to a name another user
+// or member already has.
+var ErrMemberExists = errors.New("member already exists")
+
+// renameDetail describes a rename for the audit log.
+func renameDetail(from, to string) string {
+	return from + " to " + to
+}
+
+// renamer returns a function renaming from to to, leaving other names as
+// they are.
+func renamer(from, to string) func(string) string {
+	return func(member string) string {
+		if member == from {
+			return to
+		}
+
+		return member
+	}
+}
+
+// aliaser returns a function resolving former names of renamed members to
+// their current one through the aliases, leaving other names as they are.
+func aliaser(aliases map[string]string) func(string) string {
+	return func(member string) string {
+		if current, ok := aliases[member]; ok {
+			return current
+		}
+
+		return member
+	}
+}
+
+// addAlias records from as a former name of to in the aliases, which map
+// every former name to the current one. Names that were aliases of from
+// follow it, and to is no longer an alias now that it is taken again.
+func addAlias(aliases map[string]string, from, to string) {
+	for alias, current := range aliases {
+		if current == from {
+			aliases[alias] = to
+		}
+	}
+	delete(aliases, to)
+	aliases[from] = to
+}
+
+// resolveAliases resolves the members of the versions through the aliases,
+// so versions recorded before a rename answer with the current names while
+// staying as they were recorded.
+func resolveAliases(versions []ScheduleVersion, aliases map[string]string) []ScheduleVersion {
+	if len(aliases) == 0 {
+		return versions
+	}
+
+	resolved := make([]ScheduleVersion, len(versions))
+	for i, version := range versions {
+		version.Schedule = mapMembers(version.Schedule, aliaser(aliases))
+		resolved[i] = version
+	}
+
+	return resolved
+}
+
+// mapMembers returns the schedule with every member name passed through
+// name: its members, direct references, day assignments, pins, inactive
+// members and when members joined or left. The slices and maps of the
+// schedule are copied, so versions sharing them are left untouched.
+func mapMembers(schedule Schedule, name func(string) string) Schedule {
+	schedule.Members = mapNames(schedule.Members, name)
+	schedule.Inactive = mapNames(schedule.Inactive, name)
+	schedule.Joined = mapTimes(schedule.Joined, name)
+	schedule.Left = mapTimes(schedule.Left, name)
+
+	if schedule.MemberRefs != nil {
+		refs := make([]string, len(schedule.MemberRefs))
+		for i, ref := range schedule.MemberRefs {
+			if _, ok := GroupRef(ref); ok {
+				refs[i] = ref
+			} else {
+				refs[i] = name(ref)
+			}
+		}
+		schedule.MemberRefs = refs
+	}
+
+	if schedule.DayAssignments != nil {
+		assignments := maps.Clone(schedule.DayAssignments)
+		for day, member := range assignments {
+			assignments[day] = name(member)
+		}
+		schedule.DayAssignments = assignments
+	}
+
+	if schedule.Pins != nil {
+		pins := make([]Pin, len(schedule.Pins))
+		for i, pin := range schedule.Pins {
+			pin.Member = name(pin.Member)
+			pins[i] = pin
+		}
+		schedule.Pins = pins
+	}
+
+	return schedule
+}
+
+// mapNames returns a copy of the names passed through name.
+func mapNames(names []string, name func(string) string) []string {
+	if names == nil {
+		return nil
+	}
+
+	result := make([]string, len(names))
+	for i, member := range names {
+		result[i] = name(member)
+	}
+
+	return result
+}
+
+// mapTimes returns a copy of the times keyed by the names passed through name.
+func mapTimes(times map[string]time.Time, name func(string) string) map[string]time.Time {
+	if times == nil {
+		return nil
+	}
+
+	result := make(map[string]time.Time, len(times))
+	for member, at := range times {
+		result[name(member)] = at
+	}
+
+	return result
+}
@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddAlias(t *testing.T) {
+	aliases := make(map[string]string)
+
+	addAlias(aliases, "Alice", "Alicia")
+	assert.Equal(t, map[string]string{"Alice": "Alicia"}, aliases)
+
+	// Former names follow the member through later renames
+	addAlias(aliases, "Alicia", "Ali")
+	assert.Equal(t, map[string]string{"Alice": "Ali", "Alicia": "Ali"}, aliases)
+
+	// A former name taken back is no longer an alias
+	addAlias(aliases, "Ali", "Alice")
+	assert.Equal(t, map[string]string{"Alicia": "Alice", "Ali": "Alice"}, aliases)
+}
+
+func TestMapMembers(t *testing.T) {
+	schedule := Schedule{
+		Members:    []string{"Alice", "Bob"},
+		MemberRefs: []string{"Alice", GroupPrefix + "Alice"},
+		Pins:       []Pin{{Member: "Alice"}},
+	}
+
+	renamed := mapMembers(schedule, renamer("Alice", "Alicia"))
+	assert.Equal(t, []string{"Alicia", "Bob"}, renamed.Members)
+	assert.Equal(t, []string{"Alicia", GroupPrefix + "Alice"}, renamed.MemberRefs)
+	assert.Equal(t, "Alicia", renamed.Pins[0].Member)
+
+	// The original schedule is left untouched
+	assert.Equal(t, []string{"Alice", "Bob"}, schedule.Members)
+	assert.Equal(t, "Alice", schedule.Pins[0].Member)
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+)
+
+// RotationPeriod is how long a member of a schedule is on duty before the
+// next one in the list takes over.
+const RotationPeriod = rotation.Period
+
+// MemberOnDuty returns the member on duty for the shift of the schedule
+// starting at the given instant, see rotation.Schedule.MemberOnDuty.
+func (s Schedule) MemberOnDuty(shiftStart time.Time) (string, bool) {
+	return s.RotationSchedule().MemberOnDuty(shiftStart)
+}
+
+// RotationIndex returns the index in Members of the member the rotation puts
+// on duty at the given instant, ignoring pins and day assignments.
+func (s Schedule) RotationIndex(at time.Time) (int, bool) {
+	return s.RotationSchedule().RotationIndex(at)
+}
+
+// Turns returns the indices in Members of the members taking turns in the
+// rotation period of the given instant, in rotation order. Members who
+// joined the team of a schedule using its members, or a group the schedule
+// references, take turns from the period after the one they joined in, and
+// those who left a group until the end of the period they left in. Members
+// leaving the team are out of the rotation right away.
+func (s Schedule) Turns(at time.Time) []int {
+	return s.RotationSchedule().Turns(at)
+}
+
+// RotationPeriods returns how many rotation periods passed from the anchor
+// to the given instant, see rotation.Schedule.RotationPeriods.
+func (s Schedule) RotationPeriods(at time.Time) int {
+	return s.RotationSchedule().RotationPeriods(at)
+}
+
+// memberAt returns the member on duty at the given UTC instant, see
+// rotation.Schedule.MemberAt.
+func (s Schedule) memberAt(at time.Time) (string, bool) {
+	return s.RotationSchedule().MemberAt(at)
+}
+
+// Rotation is how the members of a schedule take turns, see MemberOnDuty.
+type Rotation struct {
+	// Manual rotations keep the member at Offset on duty until it is changed.
+	Manual bool
+	// Anchor is the date the automatic rotation counts its periods from.
+	Anchor time.Time
+	Offset int
+}
+
+// Rotation returns the rotation of the schedule.
+func (s Schedule) Rotation() Rotation {
+	return Rotation{Manual: s.Manual, Anchor: s.Anchor, Offset: s.RotationOffset}
+}
+
+// auditDetail describes the rotation of a schedule for the audit log, with
+// the member at its offset.
+func (r Rotation) auditDetail(schedule, member string) string {
+	if r.Manual {
+		return fmt.Sprintf("%s: manual, %s on duty", schedule, member)
+	}
+
+	return fmt.Sprintf("%s: automatic from %s, %s on duty first", schedule, r.Anchor.UTC().Format(time.DateOnly), member)
+}
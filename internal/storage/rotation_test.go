@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedule_MemberOnDuty(t *testing.T) {
+	sched := Schedule{
+		Name:    "Weekly",
+		Members: []string{"Alice", "Bob", "Charlie"},
+		Days:    []time.Weekday{time.Monday, time.Friday},
+		// Wednesday, the weeks of the rotation run from Wednesday to Tuesday
+		Anchor: time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+		Start:  parseTime(t, "9:00AM"),
+		End:    parseTime(t, "5:00PM"),
+	}
+
+	offset := sched
+	offset.RotationOffset = 2
+
+	unanchored := offset
+	unanchored.Anchor = time.Time{}
+
+	manual := offset
+	manual.Manual = true
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		at       time.Time
+		want     string
+	}{
+		{"first week", sched, time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC), "Alice"},
+		{"last day of first week", sched, time.Date(2025, 5, 6, 23, 0, 0, 0, time.UTC), "Alice"},
+		{"second week", sched, time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC), "Bob"},
+		{"wraps around", sched, time.Date(2025, 5, 23, 9, 0, 0, 0, time.UTC), "Alice"},
+		{"week before the anchor", sched, time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC), "Charlie"},
+		{"two weeks before the anchor", sched, time.Date(2025, 4, 22, 23, 0, 0, 0, time.UTC), "Bob"},
+		{"offset", offset, time.Date(2025, 5, 2, 9, 0, 0, 0, time.UTC), "Charlie"},
+		{"offset second week", offset, time.Date(2025, 5, 9, 9, 0, 0, 0, time.UTC), "Alice"},
+		{"no anchor", unanchored, time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC), "Charlie"},
+		{"manual", manual, time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC), "Charlie"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.schedule.MemberOnDuty(tt.at)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, ok := Schedule{}.MemberOnDuty(time.Now())
+	assert.False(t, ok)
+}
+
+func TestSchedule_MemberAtKeepsShift(t *testing.T) {
+	// A shift from Tuesday 22:00 to Wednesday 06:00 crosses into the next rotation week
+	sched := Schedule{
+		Name:    "Night",
+		Members: []string{"Alice", "Bob"},
+		Cron:    "0 22 * * TUE",
+		Anchor:  time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC),
+		Start:   parseTime(t, "10:00PM"),
+		End:     parseTime(t, "6:00AM").Add(24 * time.Hour),
+	}
+
+	got, ok := sched.memberAt(time.Date(2025, 5, 7, 3, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, "Alice", got)
+}
+
+func TestSchedule_MemberOnDutySkipsInactive(t *testing.T) {
+	sched := Schedule{
+		Name:     "Weekly",
+		Members:  []string{"Alice", "Bob", "Charlie"},
+		Days:     []time.Weekday{time.Monday},
+		Anchor:   time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:    parseTime(t, "9:00AM"),
+		End:      parseTime(t, "5:00PM"),
+		Inactive: []string{"Bob"},
+		Pins:     []Pin{{Date: time.Date(2025, 5, 19, 0, 0, 0, 0, time.UTC), Member: "Bob"}},
+	}
+
+	week := func(n int) time.Time {
+		return time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC).AddDate(0, 0, 7*n)
+	}
+
+	for n, want := range []string{"Alice", "Charlie", "Charlie", "Bob"} {
+		got, ok := sched.MemberOnDuty(week(n))
+		assert.True(t, ok)
+		assert.Equal(t, want, got, "week %d", n)
+	}
+
+	fixed := sched
+	fixed.DayAssignments = map[time.Weekday]string{time.Monday: "Bob"}
+	_, ok := fixed.MemberOnDuty(week(0))
+	assert.False(t, ok)
+
+	everyone := sched
+	everyone.Pins = nil
+	everyone.Inactive = sched.Members
+	_, ok = everyone.MemberOnDuty(week(0))
+	assert.False(t, ok)
+}
+
+func TestSchedule_TeamMembersJoinAndLeave(t *testing.T) {
+	sched := Schedule{
+		Name:        "Everyone",
+		TeamMembers: true,
+		Members:     []string{"Alice", "Bob", "Carol"},
+		Joined: map[string]time.Time{
+			"Alice": time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC),
+			"Bob":   time.Date(2025, 4, 2, 0, 0, 0, 0, time.UTC),
+			"Carol": time.Date(2025, 4, 3, 0, 0, 0, 0, time.UTC),
+		},
+		Days:   []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Anchor: time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:  parseTime(t, "9:00AM"),
+		End:    parseTime(t, "5:00PM"),
+	}
+
+	week := func(n int, day time.Weekday) time.Time {
+		return time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC).AddDate(0, 0, 7*n+int(day-time.Monday))
+	}
+
+	// Dave joins on the Wednesday of the second week
+	joined := sched
+	joined.Members = append(slices.Clone(sched.Members), "Dave")
+	joined.Joined = maps.Clone(sched.Joined)
+	joined.Joined["Dave"] = time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC)
+
+	// Bob leaves on the Wednesday of the second week
+	left := sched
+	left.Members = []string{"Alice", "Carol"}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		at       time.Time
+		want     string
+	}{
+		{"rotates in join order", sched, week(0, time.Monday), "Alice"},
+		{"second week", sched, week(1, time.Monday), "Bob"},
+		{"third week", sched, week(2, time.Monday), "Carol"},
+		{"wraps around", sched, week(3, time.Monday), "Alice"},
+		// Joining does not change who is on duty until the next handoff
+		{"joined before", joined, week(1, time.Monday), "Bob"},
+		{"joined mid-week", joined, week(1, time.Thursday), "Bob"},
+		{"joined next week", joined, week(2, time.Monday), "Carol"},
+		{"newcomer takes turns", joined, week(3, time.Monday), "Dave"},
+		{"newcomer wraps around", joined, week(4, time.Monday), "Alice"},
+		// Leaving takes the member out right away
+		{"left mid-week", left, week(1, time.Thursday), "Carol"},
+		{"left next week", left, week(2, time.Monday), "Alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.schedule.MemberOnDuty(tt.at)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Before anybody joined, like on the day the team is set up, everyone takes turns
+	early := sched
+	early.Anchor = time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, []int{0, 1, 2}, early.Turns(time.Date(2025, 3, 5, 9, 0, 0, 0, time.UTC)))
+	assert.Equal(t, []int{0, 1, 2}, Schedule{Members: []string{"Alice", "Bob", "Carol"}}.Turns(week(0, time.Monday)))
+}
+
+func TestSchedule_GroupMembersChangeAtHandoff(t *testing.T) {
+	sched := Schedule{
+		Name:       "Storage",
+		MemberRefs: []string{GroupPrefix + "storage"},
+		Days:       []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Anchor:     time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:      parseTime(t, "9:00AM"),
+		End:        parseTime(t, "5:00PM"),
+	}
+
+	week := func(n int, day time.Weekday) time.Time {
+		return time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC).AddDate(0, 0, 7*n+int(day-time.Monday))
+	}
+
+	created := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	members := setGroupMembers(nil, []string{"Alice", "Bob", "Carol"}, created)
+
+	// Bob is swapped for Dave on the Wednesday of the second week
+	changed := setGroupMembers(members, []string{"Alice", "Carol", "Dave"}, time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC))
+
+	before := sched
+	expandMembers(&before, map[string][]groupMember{"storage": members}, created)
+	after := sched
+	expandMembers(&after, map[string][]groupMember{"storage": changed}, created)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		at       time.Time
+		want     string
+	}{
+		{"rotates in group order", before, week(0, time.Monday), "Alice"},
+		{"second week", before, week(1, time.Monday), "Bob"},
+		// The change is not retroactive, Bob finishes his turn
+		{"changed before", after, week(1, time.Monday), "Bob"},
+		{"changed mid-week", after, week(1, time.Thursday), "Bob"},
+		{"next handoff", after, week(2, time.Monday), "Dave"},
+		{"wraps around", after, week(3, time.Monday), "Alice"},
+		{"after the wrap", after, week(4, time.Monday), "Carol"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.schedule.MemberOnDuty(tt.at)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSchedule_Handoff(t *testing.T) {
+	// Weekdays around the clock, handing over on Wednesdays at 9:00AM
+	sched := Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice", "Bob", "Charlie"},
+		Days:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   parseTime(t, "12:00AM"),
+		End:     parseTime(t, "11:59PM"),
+		Handoff: &Handoff{Day: time.Wednesday, Time: parseTime(t, "9:00AM")},
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"before the first handoff", time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC), "Charlie"},
+		{"just before the handoff", time.Date(2025, 4, 30, 8, 59, 59, 0, time.UTC), "Charlie"},
+		{"at the handoff", time.Date(2025, 4, 30, 9, 0, 0, 0, time.UTC), "Alice"},
+		{"just before the next handoff", time.Date(2025, 5, 7, 8, 59, 59, 0, time.UTC), "Alice"},
+		{"just after the next handoff", time.Date(2025, 5, 7, 9, 0, 1, 0, time.UTC), "Bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sched.memberAt(tt.at)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// The shift running at the handoff is cut at it
+	wednesday := time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)
+	duties := DutyTimeline([]Schedule{sched}, wednesday, wednesday.Add(24*time.Hour))
+	assert.Len(t, duties, 2)
+	assert.Equal(t, "Charlie", duties[0].Member)
+	assert.Equal(t, wednesday.Add(9*time.Hour), duties[0].End)
+	assert.Equal(t, "Alice", duties[1].Member)
+	assert.Equal(t, wednesday.Add(23*time.Hour+59*time.Minute), duties[1].End)
+
+	// Schedules that do not rotate ignore it
+	manual := sched
+	manual.Manual = true
+	assert.Len(t, manual.Parts(Shift{Start: wednesday, End: wednesday.Add(23 * time.Hour)}), 1)
+}
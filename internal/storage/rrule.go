@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+)
+
+// RRuleSpec is a parsed RFC 5545 recurrence rule. Each occurrence marks the start of a shift.
+type RRuleSpec = rotation.RRuleSpec
+
+// ParseRRule parses an RRULE value starting at dtstart, see
+// rotation.ParseRRule.
+func ParseRRule(value string, dtstart time.Time) (RRuleSpec, error) {
+	return rotation.ParseRRule(value, dtstart)
+}
+
+// RRuleStart returns the DTSTART of a schedule's rule, which is its anchor
+// date at the schedule's start time.
+func (s Schedule) RRuleStart() time.Time {
+	return s.RotationSchedule().RRuleStart()
+}
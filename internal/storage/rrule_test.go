@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRRuleSpec_Covers(t *testing.T) {
+	dtstart := time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC) // Monday 9:00
+	shift := 8 * time.Hour
+
+	tests := []struct {
+		name     string
+		rule     string
+		at       time.Time
+		expected bool
+	}{
+		{"interval first week Monday", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC), true},
+		{"interval first week Wednesday", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", time.Date(2025, 4, 30, 16, 59, 0, 0, time.UTC), true},
+		{"interval skipped week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC), false},
+		{"interval third week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", time.Date(2025, 5, 12, 10, 0, 0, 0, time.UTC), true},
+		{"before dtstart", "FREQ=WEEKLY;BYDAY=MO", time.Date(2025, 4, 21, 10, 0, 0, 0, time.UTC), false},
+		{"at shift end", "FREQ=WEEKLY;BYDAY=MO", time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC), false},
+		{"within count", "FREQ=DAILY;COUNT=3", time.Date(2025, 4, 30, 10, 0, 0, 0, time.UTC), true},
+		{"after count", "FREQ=DAILY;COUNT=3", time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC), false},
+		{"before until", "FREQ=DAILY;UNTIL=20250430T235959Z", time.Date(2025, 4, 30, 10, 0, 0, 0, time.UTC), true},
+		{"after until", "FREQ=DAILY;UNTIL=20250430T235959Z", time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC), false},
+		{"last weekday of month", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", time.Date(2025, 5, 30, 10, 0, 0, 0, time.UTC), true},
+		{"not last weekday of month", "FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", time.Date(2025, 5, 29, 10, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := ParseRRule(tt.rule, dtstart)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, spec.Covers(tt.at, shift))
+		})
+	}
+}
+
+func TestParseRRule_Unsupported(t *testing.T) {
+	dtstart := time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule        string
+		expectedErr string
+	}{
+		{"FREQ=WEEKLY;BYHOUR=9", "unsupported rrule part BYHOUR"},
+		{"FREQ=YEARLY;BYWEEKNO=20", "unsupported rrule part BYWEEKNO"},
+		{"FREQ=WEEKLY;BYDAY=MO;BYSETPOS=1", "unsupported rrule part BYSETPOS"},
+		{"FREQ=MONTHLY;BYDAY=MO;BYSETPOS=2,3", "unsupported rrule part BYSETPOS"},
+		{"FREQ=MINUTELY", "unsupported rrule part FREQ"},
+		{"DTSTART:20250428T090000Z\nRRULE:FREQ=DAILY", "expected a single RRULE value"},
+		{"BYDAY=MO", "invalid rrule"},
+		{"", "invalid rrule"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			_, err := ParseRRule(tt.rule, dtstart)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+		})
+	}
+}
+
+func TestMemoryStorage_GetCurrentOncall_RRule(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	schedule := Schedule{
+		Name:    "Biweekly",
+		Members: []string{"Alice"},
+		RRule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+
+	_, err := storage.AddSchedule(context.Background(), "backend-team", schedule)
+	require.NoError(t, err)
+
+	oncall, err := storage.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 5, 14, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+
+	_, err = storage.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, ErrNoCoverage)
+}
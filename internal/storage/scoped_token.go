@@ -0,0 +1,27 @@
+package storage
+
+import "time"
+
+// ScopedToken authenticates read-only automation, such as dashboards, for
+// the Scopes it is granted. When Teams is set it is limited to those teams,
+// and when RateLimit is positive to that many requests a minute. Only the
+// hex SHA-256 Hash of the token is stored. Uses and LastUsedAt track how
+// much it is used, so unused tokens can be told apart and revoked; revoked
+// tokens are kept with RevokedAt set.
+type ScopedToken struct {
+	ID         int64
+	Name       string
+	Scopes     []string
+	Teams      []string
+	RateLimit  int
+	Hash       string
+	Uses       int64
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+	CreatedAt  time.Time
+}
+
+// Valid reports whether the token is not revoked.
+func (t ScopedToken) Valid() bool {
+	return t.RevokedAt.IsZero()
+}
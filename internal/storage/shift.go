@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+)
+
+// Shift is a single occurrence of a schedule.
+type Shift = rotation.Shift
+
+// Duty is a shift along with the schedule it belongs to and the member on
+// duty for it, see rotation.Duty.
+type Duty = rotation.Duty
+
+// Gap is a stretch of time during which nobody is on call.
+type Gap = rotation.Gap
+
+// Explanation is the trace of how the on-call lookup resolves an instant,
+// see rotation.Explanation.
+type Explanation = rotation.Explanation
+
+// Candidate is a schedule considered by the on-call lookup, see
+// rotation.Candidate.
+type Candidate = rotation.Candidate
+
+// Outcomes of the schedules of an Explanation and the rules putting the
+// member on duty, see rotation.Explain.
+const (
+	OutcomeSelected   = rotation.OutcomeSelected
+	OutcomeShadowed   = rotation.OutcomeShadowed
+	OutcomeNoShift    = rotation.OutcomeNoShift
+	OutcomeEnded      = rotation.OutcomeEnded
+	OutcomeNoMembers  = rotation.OutcomeNoMembers
+	RuleDayAssignment = rotation.RuleDayAssignment
+)
+
+// RotationSchedule returns the schedule as the rotation package resolves it.
+func (s Schedule) RotationSchedule() rotation.Schedule {
+	var pins []rotation.Pin
+	if len(s.Pins) > 0 {
+		pins = make([]rotation.Pin, 0, len(s.Pins))
+		for _, pin := range s.Pins {
+			pins = append(pins, rotation.Pin{Date: pin.Date, ShiftStart: pin.ShiftStart, Member: pin.Member})
+		}
+	}
+
+	return rotation.Schedule{
+		ID:             s.ID,
+		Name:           s.Name,
+		Members:        s.Members,
+		Days:           s.Days,
+		Cron:           s.Cron,
+		RRule:          s.RRule,
+		Anchor:         s.Anchor,
+		Start:          s.Start,
+		End:            s.End,
+		ValidUntil:     s.ValidUntil,
+		Offset:         s.RotationOffset,
+		Manual:         s.Manual,
+		Split:          s.Split,
+		RequiredCount:  s.RequiredCount,
+		Handoff:        s.Handoff,
+		DayAssignments: s.DayAssignments,
+		Pins:           pins,
+		Inactive:       s.Inactive,
+		Joined:         s.Joined,
+		Left:           s.Left,
+	}
+}
+
+// rotationSchedules returns the schedules as the rotation package resolves
+// them, in the same order.
+func rotationSchedules(schedules []Schedule) []rotation.Schedule {
+	converted := make([]rotation.Schedule, 0, len(schedules))
+	for _, sched := range schedules {
+		converted = append(converted, sched.RotationSchedule())
+	}
+
+	return converted
+}
+
+// ShiftAt returns the shift of the schedule running at the given instant.
+func (s Schedule) ShiftAt(at time.Time) (Shift, bool) {
+	return s.RotationSchedule().ShiftAt(at)
+}
+
+// NextShift returns the first shift of the schedule starting strictly after
+// the given instant.
+func (s Schedule) NextShift(after time.Time) (Shift, bool) {
+	return s.RotationSchedule().NextShift(after)
+}
+
+// DutyAt returns the duty of the schedule running at the given instant,
+// which is the running part of split shifts.
+func (s Schedule) DutyAt(at time.Time) (Duty, bool) {
+	return s.RotationSchedule().DutyAt(at)
+}
+
+// CurrentShift returns the shift of the first schedule, in insertion order,
+// running at the given UTC instant, which is the schedule the on-call lookup
+// answers from, see rotation.CurrentShift.
+func CurrentShift(schedules []Schedule, at time.Time) (Shift, bool) {
+	return rotation.CurrentShift(rotationSchedules(schedules), at)
+}
+
+// ScheduleAt returns the schedule the on-call lookup answers from at the
+// given instant, which CurrentShift is a shift of.
+func ScheduleAt(schedules []Schedule, at time.Time) (Schedule, bool) {
+	i, _, ok := rotation.Current(rotationSchedules(schedules), at.UTC())
+	if !ok {
+		return Schedule{}, false
+	}
+
+	return schedules[i], true
+}
+
+// Explain returns the trace of how the on-call lookup resolves the instant
+// from the schedules, see rotation.Explain.
+func Explain(schedules []Schedule, at time.Time) Explanation {
+	return rotation.Explain(rotationSchedules(schedules), at)
+}
+
+// UpcomingShifts returns the shifts starting in (from, to], ordered by start,
+// see rotation.UpcomingShifts.
+func UpcomingShifts(schedules []Schedule, from, to time.Time) []Shift {
+	return rotation.UpcomingShifts(rotationSchedules(schedules), from, to)
+}
+
+// Duties returns the shifts overlapping [from, to) along with the member on
+// duty for each, ordered by start, see rotation.Duties.
+func Duties(schedules []Schedule, from, to time.Time) []Duty {
+	return rotation.Duties(rotationSchedules(schedules), from, to)
+}
+
+// Gaps returns the stretches of [from, to) not covered by any shift of the
+// schedules, ordered by start, see rotation.Gaps.
+func Gaps(schedules []Schedule, from, to time.Time) []Gap {
+	return rotation.Gaps(rotationSchedules(schedules), from, to)
+}
+
+// Timeline returns the stretches of [from, to) during which the on-call
+// lookup answers from a shift, see rotation.Timeline.
+func Timeline(schedules []Schedule, from, to time.Time) []Shift {
+	return rotation.Timeline(rotationSchedules(schedules), from, to)
+}
+
+// DutyTimeline returns the Timeline of the schedules along with the member
+// on duty for each stretch, see rotation.DutyTimeline.
+func DutyTimeline(schedules []Schedule, from, to time.Time) []Duty {
+	return rotation.DutyTimeline(rotationSchedules(schedules), from, to)
+}
+
+// NextChange returns the earliest instant after at and before limit at which
+// the on-call lookup may answer differently, see rotation.NextChange.
+func NextChange(schedules []Schedule, at, limit time.Time) time.Time {
+	return rotation.NextChange(rotationSchedules(schedules), at, limit)
+}
@@ -0,0 +1,229 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_ShiftAt(t *testing.T) {
+	days := Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+
+	cron := days
+	cron.Days = nil
+	cron.Cron = "0 22 * * MON"
+
+	rrule := days
+	rrule.Days = nil
+	rrule.RRule = "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO"
+	rrule.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		at       time.Time
+		start    time.Time
+		ok       bool
+	}{
+		{"days", days, time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC), time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC), true},
+		{"days outside", days, time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC), time.Time{}, false},
+		{"cron overnight", cron, time.Date(2025, 4, 29, 3, 0, 0, 0, time.UTC), time.Date(2025, 4, 28, 22, 0, 0, 0, time.UTC), true},
+		{"rrule", rrule, time.Date(2025, 5, 12, 10, 0, 0, 0, time.UTC), time.Date(2025, 5, 12, 9, 0, 0, 0, time.UTC), true},
+		{"rrule off week", rrule, time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC), time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shift, ok := tt.schedule.ShiftAt(tt.at)
+			require.Equal(t, tt.ok, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, "Weekday", shift.Schedule)
+			assert.Equal(t, tt.start, shift.Start)
+			assert.Equal(t, tt.start.Add(8*time.Hour), shift.End)
+		})
+	}
+}
+
+func TestCurrentShift_FirstScheduleWins(t *testing.T) {
+	first := Schedule{Name: "First", Members: []string{"Alice"}, Days: []time.Weekday{time.Monday}, Start: parseTime(t, "9:00AM"), End: parseTime(t, "5:00PM")}
+	second := first
+	second.Name = "Second"
+	empty := first
+	empty.Name = "Empty"
+	empty.Members = nil
+
+	shift, ok := CurrentShift([]Schedule{empty, first, second}, time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, "First", shift.Schedule)
+}
+
+func TestSchedule_NextShift(t *testing.T) {
+	days := Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+
+	cron := days
+	cron.Days = nil
+	cron.Cron = "0 22 * * MON"
+
+	rrule := days
+	rrule.Days = nil
+	rrule.RRule = "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO"
+	rrule.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+
+	expiring := days
+	expiring.ValidUntil = time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		after    time.Time
+		start    time.Time
+		ok       bool
+	}{
+		{"days same day", days, time.Date(2025, 4, 28, 8, 0, 0, 0, time.UTC), time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC), true},
+		{"days next week", days, time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC), time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC), true},
+		{"cron", cron, time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC), time.Date(2025, 4, 28, 22, 0, 0, 0, time.UTC), true},
+		{"rrule skips off week", rrule, time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC), time.Date(2025, 5, 12, 9, 0, 0, 0, time.UTC), true},
+		{"expired", expiring, time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC), time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shift, ok := tt.schedule.NextShift(tt.after)
+			require.Equal(t, tt.ok, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.start, shift.Start)
+			assert.Equal(t, tt.start.Add(8*time.Hour), shift.End)
+		})
+	}
+}
+
+func TestUpcomingShifts(t *testing.T) {
+	first := Schedule{Name: "First", Members: []string{"Alice"}, Days: []time.Weekday{time.Monday}, Start: parseTime(t, "9:00AM"), End: parseTime(t, "5:00PM")}
+	// Shadowed by the first schedule, so its shifts never start anybody's on-call
+	second := first
+	second.Name = "Second"
+	evening := Schedule{Name: "Evening", Members: []string{"Bob"}, Days: []time.Weekday{time.Monday}, Start: parseTime(t, "5:00PM"), End: parseTime(t, "11:00PM")}
+
+	shifts := UpcomingShifts([]Schedule{evening, first, second}, time.Date(2025, 4, 28, 8, 0, 0, 0, time.UTC), time.Date(2025, 4, 28, 18, 0, 0, 0, time.UTC))
+	require.Len(t, shifts, 2)
+	assert.Equal(t, "First", shifts[0].Schedule)
+	assert.Equal(t, time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC), shifts[0].Start)
+	assert.Equal(t, "Evening", shifts[1].Schedule)
+	assert.Equal(t, time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC), shifts[1].Start)
+}
+
+func TestGaps(t *testing.T) {
+	day := Schedule{Name: "Day", Members: []string{"Alice"}, Days: []time.Weekday{time.Monday}, Start: parseTime(t, "9:00AM"), End: parseTime(t, "5:00PM")}
+	evening := Schedule{Name: "Evening", Members: []string{"Bob"}, Days: []time.Weekday{time.Monday}, Start: parseTime(t, "4:00PM"), End: parseTime(t, "10:00PM")}
+	// Shifts without members leave their time uncovered
+	empty := Schedule{Name: "Night", Days: []time.Weekday{time.Monday}, Start: parseTime(t, "10:00PM"), End: parseTime(t, "11:00PM")}
+
+	from := time.Date(2025, 4, 28, 8, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 29, 0, 0, 0, 0, time.UTC)
+
+	gaps := Gaps([]Schedule{day, evening, empty}, from, to)
+	assert.Equal(t, []Gap{
+		{Start: from, End: time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2025, 4, 28, 22, 0, 0, 0, time.UTC), End: to},
+	}, gaps)
+
+	// A shift running at from covers the start
+	assert.Equal(t, []Gap{
+		{Start: time.Date(2025, 4, 28, 22, 0, 0, 0, time.UTC), End: to},
+	}, Gaps([]Schedule{day, evening}, time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC), to))
+
+	assert.Equal(t, []Gap{{Start: from, End: to}}, Gaps(nil, from, to))
+}
+
+func TestTimeline(t *testing.T) {
+	day := Schedule{Name: "Day", Members: []string{"Alice"}, Days: []time.Weekday{time.Monday}, Start: parseTime(t, "9:00AM"), End: parseTime(t, "5:00PM")}
+	// The lunch cover comes first, so it interrupts the day shift
+	lunch := Schedule{Name: "Lunch", Members: []string{"Bob"}, Days: []time.Weekday{time.Monday}, Start: parseTime(t, "12:00PM"), End: parseTime(t, "1:00PM")}
+	night := Schedule{Name: "Night", Members: []string{"Carol"}, Cron: "0 22 * * MON", Start: parseTime(t, "10:00AM"), End: parseTime(t, "6:00PM")}
+
+	from := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 29, 4, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, []Shift{
+		{Schedule: "Day", Start: from, End: time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC)},
+		{Schedule: "Lunch", Start: time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC), End: time.Date(2025, 4, 28, 13, 0, 0, 0, time.UTC)},
+		{Schedule: "Day", Start: time.Date(2025, 4, 28, 13, 0, 0, 0, time.UTC), End: time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC)},
+		{Schedule: "Night", Start: time.Date(2025, 4, 28, 22, 0, 0, 0, time.UTC), End: to},
+	}, Timeline([]Schedule{lunch, day, night}, from, to))
+
+	// The day shift keeps its member on both sides of the lunch cover
+	day.Members = []string{"Alice", "Dana"}
+	day.Pins = []Pin{{Date: time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC), Member: "Dana"}}
+
+	duties := DutyTimeline([]Schedule{lunch, day, night}, from, to)
+	require.Len(t, duties, 4)
+	assert.Equal(t, Duty{Shift: Shift{Schedule: "Day", Start: from, End: time.Date(2025, 4, 28, 12, 0, 0, 0, time.UTC)}, Member: "Dana", Pinned: true}, duties[0])
+	assert.Equal(t, "Bob", duties[1].Member)
+	assert.Equal(t, Duty{Shift: Shift{Schedule: "Day", Start: time.Date(2025, 4, 28, 13, 0, 0, 0, time.UTC), End: time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC)}, Member: "Dana", Pinned: true}, duties[2])
+	assert.Equal(t, "Carol", duties[3].Member)
+}
+
+func TestDuties(t *testing.T) {
+	anchor := time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	day := Schedule{
+		ID: "1", Name: "Day", Members: []string{"Alice", "Bob"}, Days: []time.Weekday{time.Monday, time.Tuesday},
+		Anchor: anchor, Start: parseTime(t, "9:00AM"), End: parseTime(t, "5:00PM"),
+		Pins: []Pin{{Date: time.Date(2025, 4, 29, 0, 0, 0, 0, time.UTC), Member: "Carol"}},
+	}
+	// Shadowed by the day schedule, so none of its shifts are duties
+	shadowed := day
+	shadowed.ID, shadowed.Name, shadowed.Pins = "2", "Shadowed", nil
+
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2025, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	assert.Equal(t, []Duty{
+		{Shift: Shift{Schedule: "Day", Start: at(4, 28, 9), End: at(4, 28, 17)}, ScheduleID: "1", Member: "Alice"},
+		{Shift: Shift{Schedule: "Day", Start: at(4, 29, 9), End: at(4, 29, 17)}, ScheduleID: "1", Member: "Carol", Pinned: true},
+		{Shift: Shift{Schedule: "Day", Start: at(5, 5, 9), End: at(5, 5, 17)}, ScheduleID: "1", Member: "Bob"},
+	}, Duties([]Schedule{day, shadowed}, at(4, 28, 10), at(5, 6, 0)))
+
+	assert.Empty(t, Duties([]Schedule{day}, at(4, 29, 17), at(5, 5, 9)))
+}
+
+func TestNextChange(t *testing.T) {
+	day := Schedule{
+		ID: "1", Name: "Day", Members: []string{"Alice", "Bob"}, Days: []time.Weekday{time.Monday, time.Tuesday},
+		Anchor: time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC), Start: parseTime(t, "9:00AM"), End: parseTime(t, "5:00PM"),
+	}
+	// Takes over where the day schedule ends
+	evening := Schedule{
+		ID: "2", Name: "Evening", Members: []string{"Carol"}, Days: []time.Weekday{time.Monday},
+		Start: parseTime(t, "3:00PM"), End: parseTime(t, "9:00PM"),
+	}
+
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 4, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	schedules := []Schedule{day, evening}
+	assert.Equal(t, at(28, 17), NextChange(schedules, at(28, 10), at(29, 0)))
+	assert.Equal(t, at(28, 21), NextChange(schedules, at(28, 17), at(29, 0)))
+	assert.Equal(t, at(29, 9), NextChange(schedules, at(28, 22), at(30, 0)))
+	assert.Equal(t, at(28, 12), NextChange(schedules, at(28, 10), at(28, 12)))
+	assert.Equal(t, at(30, 0), NextChange(nil, at(28, 10), at(30, 0)))
+}
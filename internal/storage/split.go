@@ -0,0 +1,15 @@
+package storage
+
+import "time"
+
+// Parts returns the parts of the shift, one for each of the Split members
+// sharing it, or the shift itself when the schedule does not split its
+// shifts, see rotation.Schedule.Parts.
+func (s Schedule) Parts(shift Shift) []Shift {
+	return s.RotationSchedule().Parts(shift)
+}
+
+// covers reports whether a shift of the schedule is running at the given UTC instant.
+func (s Schedule) covers(at time.Time) bool {
+	return s.RotationSchedule().Covers(at)
+}
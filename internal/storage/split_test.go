@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Parts(t *testing.T) {
+	shift := Shift{
+		Schedule: "Weekday",
+		Start:    time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC),
+		End:      time.Date(2025, 4, 28, 17, 0, 0, 0, time.UTC),
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2025, 4, 28, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		split int
+		shift Shift
+		want  []Shift
+	}{
+		{"no split", 0, shift, []Shift{shift}},
+		{"two members", 2, shift, []Shift{
+			{Schedule: "Weekday", Start: at(9, 0), End: at(13, 0)},
+			{Schedule: "Weekday", Start: at(13, 0), End: at(17, 0)},
+		}},
+		{"three members", 3, shift, []Shift{
+			{Schedule: "Weekday", Start: at(9, 0), End: at(11, 40)},
+			{Schedule: "Weekday", Start: at(11, 40), End: at(14, 20)},
+			{Schedule: "Weekday", Start: at(14, 20), End: at(17, 0)},
+		}},
+		// 61 minutes do not divide by 3, the later parts take the remainder
+		{"rounds down", 3, Shift{Schedule: "Weekday", Start: at(9, 0), End: at(10, 1)}, []Shift{
+			{Schedule: "Weekday", Start: at(9, 0), End: at(9, 20)},
+			{Schedule: "Weekday", Start: at(9, 20), End: at(9, 40)},
+			{Schedule: "Weekday", Start: at(9, 40), End: at(10, 1)},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sched := Schedule{Members: []string{"Alice", "Bob", "Charlie"}, Split: tt.split}
+			assert.Equal(t, tt.want, sched.Parts(tt.shift))
+		})
+	}
+}
+
+func TestSchedule_Split(t *testing.T) {
+	sched := Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice", "Bob", "Charlie"},
+		Days:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+		Split:   3,
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 4, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		schedule func(Schedule) Schedule
+		at       time.Time
+		want     string
+	}{
+		{"first part", nil, at(28, 9, 0), "Alice"},
+		{"second part", nil, at(28, 11, 40), "Bob"},
+		{"last part", nil, at(28, 16, 59), "Charlie"},
+		{"skips inactive", func(s Schedule) Schedule {
+			s.Inactive = []string{"Bob"}
+			return s
+		}, at(28, 12, 0), "Charlie"},
+		{"pin keeps the shift", func(s Schedule) Schedule {
+			s.Pins = []Pin{{Date: at(28, 0, 0), Member: "Bob"}}
+			return s
+		}, at(28, 15, 0), "Bob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := sched
+			if tt.schedule != nil {
+				s = tt.schedule(s)
+			}
+			duty, ok := s.DutyAt(tt.at)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, duty.Member)
+		})
+	}
+
+	// Two members share the shift of the rotation week: Bob's week starts
+	// with Bob followed by Charlie
+	two := sched
+	two.Split = 2
+	duty, ok := two.DutyAt(time.Date(2025, 5, 5, 12, 59, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, "Bob", duty.Member)
+	duty, ok = two.DutyAt(time.Date(2025, 5, 5, 13, 0, 0, 0, time.UTC))
+	require.True(t, ok)
+	assert.Equal(t, "Charlie", duty.Member)
+
+	shift, ok := CurrentShift([]Schedule{sched}, at(28, 12, 0))
+	require.True(t, ok)
+	assert.Equal(t, at(28, 11, 40), shift.Start)
+	assert.Equal(t, at(28, 14, 20), shift.End)
+
+	duties := Duties([]Schedule{sched}, at(28, 0, 0), at(29, 0, 0))
+	require.Len(t, duties, 3)
+	for i, member := range []string{"Alice", "Bob", "Charlie"} {
+		assert.Equal(t, member, duties[i].Member)
+	}
+}
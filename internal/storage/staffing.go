@@ -0,0 +1,14 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+)
+
+// OncallsAt returns the members the on-call lookup answers with at the
+// given instant, the one OncallAt returns first. It reports false when
+// nobody is on call.
+func OncallsAt(schedules []Schedule, at time.Time) ([]string, bool) {
+	return rotation.OncallsAt(rotationSchedules(schedules), at)
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_RequiredCount(t *testing.T) {
+	sched := Schedule{
+		ID:            "1",
+		Name:          "Critical",
+		Members:       []string{"Alice", "Bob", "Charlie"},
+		Days:          []time.Weekday{time.Monday},
+		Anchor:        time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:         parseTime(t, "9:00AM"),
+		End:           parseTime(t, "5:00PM"),
+		RequiredCount: 2,
+	}
+
+	// Every week the pair moves on by one member, so each member is on duty
+	// for two weeks out of three, once first and once second
+	duties := Duties([]Schedule{sched}, sched.Anchor, sched.Anchor.AddDate(0, 0, 7*7))
+	require.Len(t, duties, 7)
+
+	var pairs [][]string
+	for _, duty := range duties {
+		pairs = append(pairs, duty.Members())
+	}
+	assert.Equal(t, [][]string{
+		{"Alice", "Bob"},
+		{"Bob", "Charlie"},
+		{"Charlie", "Alice"},
+		{"Alice", "Bob"},
+		{"Bob", "Charlie"},
+		{"Charlie", "Alice"},
+		{"Alice", "Bob"},
+	}, pairs)
+
+	at := time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC)
+
+	members, ok := OncallsAt([]Schedule{sched}, at)
+	require.True(t, ok)
+	assert.Equal(t, []string{"Bob", "Charlie"}, members)
+
+	member, ok := OncallAt([]Schedule{sched}, at)
+	require.True(t, ok)
+	assert.Equal(t, "Bob", member)
+
+	_, ok = OncallsAt([]Schedule{sched}, time.Date(2025, 5, 5, 18, 0, 0, 0, time.UTC))
+	assert.False(t, ok)
+
+	tests := []struct {
+		name     string
+		schedule func(Schedule) Schedule
+		want     []string
+	}{
+		{"single member", func(s Schedule) Schedule {
+			s.RequiredCount = 1
+			return s
+		}, []string{"Bob"}},
+		{"skips inactive", func(s Schedule) Schedule {
+			s.Inactive = []string{"Charlie"}
+			return s
+		}, []string{"Bob", "Alice"}},
+		{"pin replaces the first member", func(s Schedule) Schedule {
+			s.Pins = []Pin{{Date: PinDate(at), Member: "Dave"}}
+			return s
+		}, []string{"Dave", "Charlie"}},
+		{"pin of the second member", func(s Schedule) Schedule {
+			s.Pins = []Pin{{Date: PinDate(at), Member: "Charlie"}}
+			return s
+		}, []string{"Charlie", "Alice"}},
+		{"all members", func(s Schedule) Schedule {
+			s.RequiredCount = 3
+			return s
+		}, []string{"Bob", "Charlie", "Alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			duty, ok := tt.schedule(sched).DutyAt(at)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, duty.Members())
+		})
+	}
+}
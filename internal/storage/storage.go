@@ -1,8 +1,17 @@
 package storage
 
 import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // Team represents a team with their schedules.
@@ -11,95 +20,2491 @@ type Team struct {
 }
 
 // Schedule represents an on-call schedule.
+// A schedule recurs either on Days, on the occurrences of Cron, or on the
+// occurrences of RRule starting at Anchor. For Cron the occurrence sets the
+// shift start, so Start and End only define the shift duration.
+// A non-zero ValidUntil ends the schedule: it covers nothing from then on,
+// and the janitor eventually soft-deletes it. Description and Notes are
+// free-form context, kept verbatim. Tags group schedules across teams, and
+// Routing tells the alert router where to page the member on duty, e.g. the
+// Slack channel under "slack_channel".
 type Schedule struct {
-	Name    string
-	Members []string
-	Days    []time.Weekday
-	Start   time.Time
-	End     time.Time
+	// ID identifies the schedule across teams. It is set by the storage when
+	// the schedule is added.
+	ID          string
+	Name        string
+	Description string
+	Notes       string
+	Members     []string
+	Days        []time.Weekday
+	Cron        string
+	RRule       string
+	Anchor      time.Time
+	Start       time.Time
+	End         time.Time
+	ValidUntil  time.Time
+	Tags        []string
+	Routing     map[string]string
+	// RotationOffset is the index of the member on duty during the first
+	// rotation period, see MemberOnDuty.
+	RotationOffset int
+	// Manual schedules do not rotate with time: the member at RotationOffset
+	// stays on duty until the rotation is set again, see SetRotation.
+	Manual bool
+	// Split divides every shift evenly between as many consecutive members
+	// of the rotation, see Parts. Zero and one leave shifts whole.
+	Split int
+	// RequiredCount is how many members are on duty together for every
+	// shift, the member on duty and the next ones of the rotation, see
+	// Duty.Others. Zero and one put a single member on duty.
+	RequiredCount int
+	// Compensation tags the hours on duty for the schedule with the rate
+	// class they are paid at, nil when untagged.
+	Compensation *Compensation
+	// Handoff is when the rotation moves on to the next member, see
+	// RotationPeriods. Without it members take over at midnight UTC of the
+	// anchor's weekday and keep the shift running then.
+	Handoff *Handoff
+	// DayAssignments maps weekdays to the member always on duty on them.
+	// Schedules with assignments do not rotate, and their Members are the
+	// assignees.
+	DayAssignments map[time.Weekday]string
+	// Pins put members on duty on single dates, ordered by date.
+	Pins []Pin
+	// Inactive lists the members whose user is deactivated. It is set by the
+	// storage whenever the schedule is read.
+	Inactive []string
+	// TeamMembers schedules rotate through the roster of their team instead
+	// of a list of their own: their Members are the members of the team
+	// with the member role, in the order they joined it, see Turns.
+	TeamMembers bool
+	// MemberRefs are the members of a schedule referencing groups of its
+	// team, as given with GroupPrefix entries. Members expands them into
+	// the members of the groups as they are when the schedule is read. It
+	// is empty for schedules without references.
+	MemberRefs []string
+	// Joined maps the members of a TeamMembers schedule to when they joined
+	// the team, and those added to groups after the schedule was created to
+	// when they were.
+	// Left maps the members removed from groups to when they were. Like
+	// Members, they are set by the storage whenever the schedule is read.
+	Joined map[string]time.Time
+	Left   map[string]time.Time
+}
+
+// validAt reports whether the schedule has not ended at the given instant.
+func (s Schedule) validAt(at time.Time) bool {
+	return s.ValidUntil.IsZero() || at.Before(s.ValidUntil)
+}
+
+// expired reports whether the schedule ended at or before the given instant.
+func (s Schedule) expired(before time.Time) bool {
+	return !s.ValidUntil.IsZero() && !s.ValidUntil.After(before)
 }
 
 // Storage defines the interface for storing and retrieving schedules.
+// Implementations must honor ctx cancellation so a timed out request does not
+// keep a query running.
 type Storage interface {
-	AddSchedule(team string, schedule Schedule) error
-	GetTeam(team string) (Team, bool, error)
-	GetCurrentOncall(team string, at time.Time) (string, bool, error)
+	// AddSchedule adds a schedule to the team, creating the team if needed,
+	// and returns the ID assigned to it. It returns ErrScheduleExists when
+	// the team has a schedule with the name already.
+	AddSchedule(ctx context.Context, team string, schedule Schedule) (string, error)
+	// GetTeam returns the schedules of a team. It returns ErrTeamNotFound
+	// when the team does not exist.
+	GetTeam(ctx context.Context, team string) (Team, error)
+	// GetCurrentOncall returns the member on duty at the time. It returns
+	// ErrTeamNotFound when the team does not exist, and ErrNoCoverage when
+	// nobody of the team is on call then.
+	GetCurrentOncall(ctx context.Context, team string, at time.Time) (string, error)
+	ListTeams(ctx context.Context) ([]string, error)
+	// DeleteTeam removes a team with all of its schedules and of what is kept
+	// by its name, its parent, public coverage, Google calendar, calendar
+	// tokens and webhooks, so a team later created with the name starts
+	// afresh. The teams inheriting from it no longer do. It returns
+	// ErrTeamNotFound when the team does not exist.
+	DeleteTeam(ctx context.Context, team string) error
+	// PauseTeam pauses a team, replacing any earlier pause. It reports false
+	// when the team does not exist.
+	PauseTeam(ctx context.Context, team string, pause Pause) (bool, error)
+	// UnpauseTeam lifts the pause of a team, if any. It reports false when
+	// the team does not exist.
+	UnpauseTeam(ctx context.Context, team string) (bool, error)
+	// GetPause returns the latest pause of a team, whether or not it is still active.
+	GetPause(ctx context.Context, team string) (Pause, bool, error)
+	// AuditLog returns the audit entries of a team, oldest first.
+	AuditLog(ctx context.Context, team string) ([]AuditEntry, error)
+	// DeleteExpiredSchedules soft-deletes the schedules of every team that
+	// ended at or before the given instant, and returns how many it deleted.
+	DeleteExpiredSchedules(ctx context.Context, before time.Time) (int, error)
+	// PurgeExpiredPauses deletes the pauses of every team that ended at or
+	// before the given instant, and returns how many it deleted.
+	PurgeExpiredPauses(ctx context.Context, before time.Time) (int, error)
+	// TrimAuditLog deletes the audit entries recorded before the given
+	// instant, and returns how many it deleted.
+	TrimAuditLog(ctx context.Context, before time.Time) (int, error)
+	// AddWebhook stores a webhook subscription and returns it with its ID
+	// and creation time set.
+	AddWebhook(ctx context.Context, webhook Webhook) (Webhook, error)
+	// ListWebhooks returns every webhook subscription, oldest first.
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	// DeleteWebhook removes a webhook subscription. It reports false when it
+	// does not exist.
+	DeleteWebhook(ctx context.Context, id int64) (bool, error)
+	// MarkReminderSent records that the reminder, or digest, with the given
+	// key was sent. It reports false when it was already recorded, so
+	// concurrent and restarted workers send every reminder once.
+	MarkReminderSent(ctx context.Context, key string) (bool, error)
+	// AddCalendarToken stores a calendar feed token and returns it with its
+	// ID and creation time set.
+	AddCalendarToken(ctx context.Context, token CalendarToken) (CalendarToken, error)
+	// GetCalendarToken returns the calendar feed token with the given hash.
+	GetCalendarToken(ctx context.Context, hash string) (CalendarToken, bool, error)
+	// DeleteCalendarToken revokes a calendar feed token of the team. It
+	// reports false when the team has no such token.
+	DeleteCalendarToken(ctx context.Context, team string, id int64) (bool, error)
+	// AddAPIKey stores an API key and returns it with its ID and creation
+	// time set.
+	AddAPIKey(ctx context.Context, key APIKey) (APIKey, error)
+	// GetAPIKey returns the API key with the given hash, revoked or not.
+	GetAPIKey(ctx context.Context, hash string) (APIKey, bool, error)
+	// ListAPIKeys returns every API key, revoked ones included, oldest first.
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	// RevokeAPIKey revokes an API key at the given instant. It reports false
+	// when there is no such key or it is already revoked.
+	RevokeAPIKey(ctx context.Context, id int64, at time.Time) (bool, error)
+	// AddScopedToken stores a scoped token and returns it with its ID and
+	// creation time set.
+	AddScopedToken(ctx context.Context, token ScopedToken) (ScopedToken, error)
+	// GetScopedToken returns the scoped token with the given hash, revoked
+	// or not.
+	GetScopedToken(ctx context.Context, hash string) (ScopedToken, bool, error)
+	// ListScopedTokens returns every scoped token, revoked ones included,
+	// oldest first.
+	ListScopedTokens(ctx context.Context) ([]ScopedToken, error)
+	// RevokeScopedToken revokes a scoped token at the given instant. It
+	// reports false when there is no such token or it is already revoked.
+	RevokeScopedToken(ctx context.Context, id int64, at time.Time) (bool, error)
+	// RecordScopedTokenUse counts a use of a scoped token made at the given
+	// instant.
+	RecordScopedTokenUse(ctx context.Context, id int64, at time.Time) error
+	// FindSchedulesByTags returns the schedules of every team that carry all
+	// of the tags, ordered by team.
+	FindSchedulesByTags(ctx context.Context, tags []string) ([]TeamSchedule, error)
+	// FindTeamsByMember returns the teams, in sorted order, with a schedule
+	// listing the member or pinning them to a date.
+	FindTeamsByMember(ctx context.Context, member string) ([]string, error)
+	// FindSchedulesByMembers returns the schedules of every team listing any
+	// of the members, once for every member listed, ordered by team and then
+	// by schedule and position. Pins are not considered.
+	FindSchedulesByMembers(ctx context.Context, members []string) ([]MemberSchedule, error)
+	// GetSchedule returns the schedule with the given ID along with its team.
+	// Soft-deleted schedules are not found, ErrScheduleNotFound is returned
+	// for them like for unknown IDs.
+	GetSchedule(ctx context.Context, id string) (TeamSchedule, error)
+	// TeamHistory returns the configuration of a team during [from, to] as
+	// it was then: the first version is the one live at from, and the others
+	// start at the changes after it. Schedules added after an instant are not
+	// part of its version. It returns ErrTeamNotFound when the team does not
+	// exist, and ErrNoHistory when its history does not reach back to from.
+	TeamHistory(ctx context.Context, team string, from, to time.Time) ([]TeamVersion, error)
+	// ScheduleVersions returns the versions of the schedule with the given ID
+	// ordered by Since, the first being the definition it was added with.
+	// Soft-deleted schedules are not found.
+	ScheduleVersions(ctx context.Context, id string) ([]ScheduleVersion, bool, error)
+	// HistoryStart returns the earliest instant TeamHistory reaches back to
+	// for a team and every instant after, the zero time when it reaches back
+	// to the creation of the team. It reports false when the team does not
+	// exist, or when some of its schedules were never versioned, in which
+	// case it reaches no instant after they were created.
+	HistoryStart(ctx context.Context, team string) (time.Time, bool, error)
+	// AddPin pins a member to a date of a schedule of the team, replacing
+	// any earlier pin of the date, and returns it with its ID and creation
+	// time set. It reports false when the team has no such schedule.
+	AddPin(ctx context.Context, team, scheduleID string, pin Pin) (Pin, bool, error)
+	// DeletePin removes a pin of a schedule of the team. It reports false
+	// when the schedule has no such pin.
+	DeletePin(ctx context.Context, team, scheduleID string, id int64) (bool, error)
+	// AddSwapRequest stores a pending swap request for a schedule of the team
+	// and returns it with its ID and creation time set. It reports false when
+	// the team has no such schedule.
+	AddSwapRequest(ctx context.Context, team string, request SwapRequest) (SwapRequest, bool, error)
+	// GetSwapRequest returns the swap request with the given ID.
+	GetSwapRequest(ctx context.Context, id int64) (SwapRequest, bool, error)
+	// ListSwapRequests returns the swap requests of a schedule of the team
+	// ordered by ID, decided ones included.
+	ListSwapRequests(ctx context.Context, team, scheduleID string) ([]SwapRequest, error)
+	// DecideSwapRequest accepts or declines a swap request at the given
+	// instant and returns it. Accepting pins its target to the date of the
+	// shift, replacing any pin of the date, and is recorded in the audit log.
+	// It reports false when there is no such request or its schedule is
+	// gone, and fails with ErrSwapDecided when it is not pending anymore.
+	DecideSwapRequest(ctx context.Context, id int64, accept bool, at time.Time) (SwapRequest, bool, error)
+	// AddUser provisions a user and returns it with its ID and timestamps
+	// set. It reports false when the user name is already provisioned.
+	AddUser(ctx context.Context, user User) (User, bool, error)
+	// GetUser returns the provisioned user with the given ID.
+	GetUser(ctx context.Context, id string) (User, bool, error)
+	// FindUsers returns the provisioned users ordered by user name, only the
+	// one with the user name when it is not empty.
+	FindUsers(ctx context.Context, userName string) ([]User, error)
+	// SetUserActive activates or deactivates a provisioned user and returns
+	// it. It reports false when there is no such user.
+	SetUserActive(ctx context.Context, id string, active bool) (User, bool, error)
+	// SetUserTimezone sets the zone of a provisioned user, an IANA name or
+	// empty for UTC, and returns it. It reports false when there is no such
+	// user.
+	SetUserTimezone(ctx context.Context, id, timezone string) (User, bool, error)
+	// SetTeamMember adds a member to the roster of the team, or changes the
+	// role of an existing one, and returns it with its creation time set. It
+	// reports false when the team does not exist. Adding a schedule puts its
+	// members on the roster too, keeping the role of the known ones.
+	SetTeamMember(ctx context.Context, team string, member TeamMember) (TeamMember, bool, error)
+	// ListTeamMembers returns the roster of the team ordered by name.
+	ListTeamMembers(ctx context.Context, team string) ([]TeamMember, error)
+	// RemoveTeamMember removes a member from the roster of the team. It
+	// reports false when the team has no such member.
+	RemoveTeamMember(ctx context.Context, team, name string) (bool, error)
+	// SetGroup creates a group of the team or replaces the members of an
+	// existing one, and returns it with its times set. Members who stay keep
+	// their place, and schedules referencing the group only take the changes
+	// into account from their next handoff. It reports false when the team
+	// does not exist.
+	SetGroup(ctx context.Context, team string, group Group) (Group, bool, error)
+	// ListGroups returns the groups of the team ordered by name.
+	ListGroups(ctx context.Context, team string) ([]Group, error)
+	// DeleteGroup removes a group of the team. It reports false when the
+	// team has no such group, and returns ErrGroupInUse when a schedule of
+	// the team references it.
+	DeleteGroup(ctx context.Context, team, name string) (bool, error)
+	// AddFreeze freezes a team for a window and returns the freeze with its
+	// ID and creation time set. It reports false when the team does not exist.
+	AddFreeze(ctx context.Context, team string, freeze Freeze) (Freeze, bool, error)
+	// ListFreezes returns the freezes of the team ordered by start, ended
+	// ones included.
+	ListFreezes(ctx context.Context, team string) ([]Freeze, error)
+	// CancelFreeze removes a freeze of the team. It reports false when the
+	// team has no such freeze.
+	CancelFreeze(ctx context.Context, team string, id int64) (bool, error)
+	// AddBlackout adds a blackout window to a team and returns it with its
+	// ID and creation time set. It reports false when the team does not exist.
+	AddBlackout(ctx context.Context, team string, blackout Blackout) (Blackout, bool, error)
+	// ListBlackouts returns the blackouts of the team ordered by start, ended
+	// ones included.
+	ListBlackouts(ctx context.Context, team string) ([]Blackout, error)
+	// CancelBlackout removes a blackout of the team. It reports false when
+	// the team has no such blackout.
+	CancelBlackout(ctx context.Context, team string, id int64) (bool, error)
+	// AddHandoffNote adds a handoff note to the shift of a schedule of the
+	// team and returns it with its ID and times set. It reports false when
+	// the team does not exist, and returns ErrHandoffNoteExists when the
+	// shift has a note already.
+	AddHandoffNote(ctx context.Context, team string, note HandoffNote) (HandoffNote, bool, error)
+	// ListHandoffNotes returns the handoff notes of the team ordered by ID,
+	// expired ones included.
+	ListHandoffNotes(ctx context.Context, team string) ([]HandoffNote, error)
+	// UpdateHandoffNote replaces the text of a handoff note of the team. It
+	// reports false when the team has no such note.
+	UpdateHandoffNote(ctx context.Context, team string, id int64, text string) (HandoffNote, bool, error)
+	// DeleteHandoffNote removes a handoff note of the team. It reports false
+	// when the team has no such note.
+	DeleteHandoffNote(ctx context.Context, team string, id int64) (bool, error)
+	// AddUnavailability marks a member unavailable for a window and returns
+	// it with its ID and creation time set.
+	AddUnavailability(ctx context.Context, unavailability Unavailability) (Unavailability, error)
+	// ListUnavailability returns the unavailability windows of a member
+	// ordered by start, ended ones included.
+	ListUnavailability(ctx context.Context, member string) ([]Unavailability, error)
+	// CancelUnavailability removes an unavailability window of a member. It
+	// reports false when the member has no such window.
+	CancelUnavailability(ctx context.Context, member string, id int64) (bool, error)
+	// UnavailableMembers returns the given members who are unavailable at
+	// the given instant.
+	UnavailableMembers(ctx context.Context, members []string, at time.Time) ([]string, error)
+	// SetRotation sets the rotation of a schedule of the team, which is
+	// recorded in the audit log along with the member it puts on duty. It
+	// reports false when the team has no such schedule.
+	SetRotation(ctx context.Context, team, scheduleID string, rotation Rotation) (bool, error)
+	// UpdateScheduleFields changes the given fields of a schedule of the
+	// team, recording a new version of it and the change in the audit log,
+	// and returns the updated schedule. The fields are applied to the
+	// schedule as it is when the update runs, failing with ErrScheduleTimes
+	// when its shifts would no longer start before they end, and with
+	// ErrScheduleExists when another schedule of the team has the new name.
+	// It returns ErrScheduleNotFound when the team has no such schedule.
+	UpdateScheduleFields(ctx context.Context, team, scheduleID string, update ScheduleUpdate) (Schedule, error)
+	// RecordAudit records a change made outside of the storage layer, such
+	// as a freeze being overridden, in the audit log of the team.
+	RecordAudit(ctx context.Context, team, action, detail string) error
+	// RenameMember renames a user or member everywhere they are referenced:
+	// the user, schedules, rosters, groups, pins, pending swap requests,
+	// unavailability and calendar tokens. Schedule versions are kept as they
+	// were recorded and resolve the former name through an alias. The rename
+	// is recorded in the audit log of every team referencing the member. It
+	// reports false when nobody has the name, and returns ErrMemberExists
+	// when the new name is taken.
+	RenameMember(ctx context.Context, from, to string) (bool, error)
+	// MergeTeams moves the schedules, with their history, overrides and
+	// swap requests, along with the roster, groups, freezes, blackouts,
+	// pause, calendar tokens and webhooks of the source team to the target
+	// and removes the source, remembering the team it was merged into. The
+	// target keeps its own members, groups and pause when both have one.
+	// Schedules of the source named like one of the target are renamed or
+	// fail the merge with a MergeConflictError, depending on the policy. The
+	// merge is recorded in the audit log of both teams. It reports false
+	// when either team does not exist.
+	MergeTeams(ctx context.Context, target, source, policy string) (TeamMerge, bool, error)
+	// MergedInto returns the team the given one was merged into, following
+	// later merges of that team, until a team with its name is created again.
+	MergedInto(ctx context.Context, team string) (string, bool, error)
+	// SetTeamParent sets the team whose schedules the team inherits when
+	// none of its own matches, clearing it when parent is empty. Neither
+	// team has to exist. It fails with ErrParentCycle when the team would
+	// become its own ancestor.
+	SetTeamParent(ctx context.Context, team, parent string) error
+	// TeamParent returns the parent of a team, false when it has none.
+	TeamParent(ctx context.Context, team string) (string, bool, error)
+	// SetPublicCoverage enables or disables the anonymized coverage of a
+	// team meant for public status pages. The team does not have to exist.
+	SetPublicCoverage(ctx context.Context, team string, enabled bool) error
+	// PublicCoverage reports whether the public coverage of a team is
+	// enabled, which it is not until set.
+	PublicCoverage(ctx context.Context, team string) (bool, error)
+	// SetGoogleCalendar sets the ID of the Google calendar the shifts of a
+	// team are pushed to, clearing it when empty. The team does not have to
+	// exist.
+	SetGoogleCalendar(ctx context.Context, team, calendarID string) error
+	// GoogleCalendar returns the ID of the Google calendar of a team, false
+	// when it has none.
+	GoogleCalendar(ctx context.Context, team string) (string, bool, error)
+	// ListGoogleCalendars returns the IDs of the Google calendars by team.
+	ListGoogleCalendars(ctx context.Context) (map[string]string, error)
 }
 
 // MemoryStorage implements Storage interface with thread-safe in-memory storage.
+// Locking is per team: mu only guards the teams map, so a burst of writes for
+// one team does not delay reads for the others. Code that visits every team
+// should snapshot the map under mu and then lock each team on its own, never
+// holding more than one team lock at a time.
 type MemoryStorage struct {
 	mu   sync.RWMutex
-	data map[string]Team
+	data map[string]*memoryTeam
+
+	auditMu sync.Mutex
+	audit   []AuditEntry
+
+	webhookMu   sync.Mutex
+	webhooks    []Webhook
+	nextWebhook int64
+
+	remindersMu sync.Mutex
+	reminders   map[string]bool
+
+	calendarTokenMu   sync.Mutex
+	calendarTokens    []CalendarToken
+	nextCalendarToken int64
+
+	apiKeyMu   sync.Mutex
+	apiKeys    []APIKey
+	nextAPIKey int64
+
+	scopedTokenMu   sync.Mutex
+	scopedTokens    []ScopedToken
+	nextScopedToken int64
+
+	unavailabilityMu   sync.Mutex
+	unavailability     []Unavailability
+	nextUnavailability int64
+
+	// nextPin, nextFreeze, nextBlackout, nextSwap and nextNote hand out IDs
+	// across teams. Schedules get UUIDs instead.
+	nextPin      atomic.Int64
+	nextFreeze   atomic.Int64
+	nextBlackout atomic.Int64
+	nextSwap     atomic.Int64
+	nextNote     atomic.Int64
+
+	// usersMu is taken before any team lock, so the inactive members of the
+	// schedules never miss a change.
+	usersMu  sync.RWMutex
+	users    []User
+	inactive map[string]bool
+	nextUser int64
+	// aliases maps the former names of renamed members to their current one.
+	aliases map[string]string
+
+	// merged maps the teams merged into another to the team they are part
+	// of now, guarded by mu.
+	merged map[string]string
+	// parents maps teams to the team they inherit schedules from, guarded
+	// by mu.
+	parents map[string]string
+	// public holds the teams whose public coverage is enabled, guarded by mu.
+	public map[string]bool
+	// calendars maps teams to the ID of their Google calendar, guarded by mu.
+	calendars map[string]string
+}
+
+// memoryTeam holds the schedules of a team along with a per-weekday index
+// that is maintained on mutation, so lookups only touch schedules that could match.
+type memoryTeam struct {
+	mu        sync.RWMutex
+	schedules []Schedule
+	// byDay lists the day based schedules covering each weekday in insertion order.
+	byDay [7][]dayEntry
+	// recurring lists the cron and RRULE schedules, which cannot be indexed by weekday.
+	recurring []int
+	// pause is the latest pause of the team, nil when it is not paused.
+	pause *Pause
+	// members is the roster of the team by name.
+	members map[string]TeamMember
+	// groups are the groups of the team by name.
+	groups map[string]*memoryGroup
+	// freezes are the freezes of the team ordered by start.
+	freezes []Freeze
+	// blackouts are the blackouts of the team ordered by start.
+	blackouts []Blackout
+	// deleted keeps the soft-deleted schedules, which no lookup sees.
+	deleted []Schedule
+	// history holds the versions of the schedules ordered by Since.
+	history []ScheduleVersion
+	// swaps are the swap requests of the schedules ordered by ID.
+	swaps []SwapRequest
+	// notes are the handoff notes of the team ordered by ID.
+	notes []HandoffNote
+}
+
+// memoryGroup is a group of a team along with its former members.
+type memoryGroup struct {
+	members   []groupMember
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// group returns the group with the given name and its current members.
+func (g *memoryGroup) group(name string) Group {
+	return Group{
+		Name:      name,
+		Members:   currentMembers(g.members),
+		CreatedAt: g.createdAt,
+		UpdatedAt: g.updatedAt,
+	}
+}
+
+// dayEntry is a day based schedule with its window precomputed as seconds since midnight.
+type dayEntry struct {
+	index int
+	start int
+	end   int
 }
 
 // NewMemoryStorage creates a new memory storage instance.
 func NewMemoryStorage() *MemoryStorage {
 	return &MemoryStorage{
-		data: make(map[string]Team),
+		data:      make(map[string]*memoryTeam),
+		inactive:  make(map[string]bool),
+		aliases:   make(map[string]string),
+		merged:    make(map[string]string),
+		parents:   make(map[string]string),
+		public:    make(map[string]bool),
+		calendars: make(map[string]string),
 	}
 }
 
 // AddSchedule adds a schedule to a team (thread-safe).
-func (s *MemoryStorage) AddSchedule(team string, schedule Schedule) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+func (s *MemoryStorage) AddSchedule(ctx context.Context, team string, schedule Schedule) (string, error) {
+	t := s.getOrCreateTeam(team)
 
-	t := s.data[team]
-	t.Schedules = append(t.Schedules, schedule)
-	s.data[team] = t
-	return nil
+	schedule.ID = uuid.NewString()
+
+	s.usersMu.RLock()
+	defer s.usersMu.RUnlock()
+
+	schedule.Inactive = inactiveMembers(schedule.Members, s.inactive)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := checkQuota(ctx, QuotaSchedules, team, len(t.schedules)); err != nil {
+		return "", err
+	}
+
+	if t.named(schedule.Name, "") {
+		return "", ErrScheduleExists
+	}
+
+	t.add(schedule)
+
+	// Members of schedules join the roster, the roles of known ones are kept
+	for _, member := range schedule.Members {
+		if _, ok := t.members[member]; !ok {
+			t.members[member] = TeamMember{Name: member, Role: MemberRoleMember, CreatedAt: time.Now()}
+		}
+	}
+	t.refreshMembers(s.inactive)
+
+	t.version(t.schedules[len(t.schedules)-1])
+
+	return schedule.ID, nil
 }
 
 // GetTeam retrieves a team's schedules (thread-safe).
-func (s *MemoryStorage) GetTeam(team string) (Team, bool, error) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+func (s *MemoryStorage) GetTeam(_ context.Context, team string) (Team, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return Team{}, ErrTeamNotFound
+	}
 
-	t, ok := s.data[team]
-	return t, ok, nil
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return Team{Schedules: slices.Clone(t.schedules)}, nil
+}
+
+// GetCurrentOncall returns the member on duty of the first matching schedule.
+func (s *MemoryStorage) GetCurrentOncall(_ context.Context, team string, at time.Time) (string, error) {
+	// Schedules are defined in UTC, so the same instant must match
+	// regardless of the offset it was given in.
+	at = at.UTC()
+
+	t, ok := s.getTeam(team)
+	if !ok {
+		return "", ErrTeamNotFound
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	oncall, found := t.oncall(at)
+	if !found {
+		return "", ErrNoCoverage
+	}
+
+	return oncall, nil
 }
 
-// GetCurrentOncall returns the first member of the first matching schedule.
-// Note: This is a simplified implementation for in-memory storage.
-// It doesn't implement proper rotation tracking.
-func (s *MemoryStorage) GetCurrentOncall(team string, at time.Time) (string, bool, error) {
+// ListTeams returns the names of all teams in sorted order (thread-safe).
+func (s *MemoryStorage) ListTeams(_ context.Context) ([]string, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	t, ok := s.data[team]
+	names := make([]string, 0, len(s.data))
+	for name := range s.data {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names, nil
+}
+
+// DeleteTeam removes a team with all of its schedules and settings (thread-safe).
+func (s *MemoryStorage) DeleteTeam(ctx context.Context, team string) error {
+	s.mu.Lock()
+	_, ok := s.data[team]
+	if ok {
+		delete(s.data, team)
+		delete(s.parents, team)
+		delete(s.public, team)
+		delete(s.calendars, team)
+		maps.DeleteFunc(s.parents, func(_, parent string) bool { return parent == team })
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return ErrTeamNotFound
+	}
+
+	s.webhookMu.Lock()
+	s.webhooks = slices.DeleteFunc(s.webhooks, func(w Webhook) bool { return w.Team == team })
+	s.webhookMu.Unlock()
+
+	s.calendarTokenMu.Lock()
+	s.calendarTokens = slices.DeleteFunc(s.calendarTokens, func(t CalendarToken) bool { return t.Team == team })
+	s.calendarTokenMu.Unlock()
+
+	s.record(ctx, AuditDeleteTeam, team, "")
+	return nil
+}
+
+// PauseTeam pauses a team (thread-safe).
+func (s *MemoryStorage) PauseTeam(ctx context.Context, team string, pause Pause) (bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return false, nil
+	}
+
+	t.mu.Lock()
+	t.pause = &pause
+	t.mu.Unlock()
+
+	s.record(ctx, AuditPauseTeam, team, pause.auditDetail())
+	return true, nil
+}
+
+// UnpauseTeam lifts the pause of a team (thread-safe).
+func (s *MemoryStorage) UnpauseTeam(ctx context.Context, team string) (bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return false, nil
+	}
+
+	t.mu.Lock()
+	t.pause = nil
+	t.mu.Unlock()
+
+	s.record(ctx, AuditUnpauseTeam, team, "")
+	return true, nil
+}
+
+// GetPause returns the latest pause of a team (thread-safe).
+func (s *MemoryStorage) GetPause(_ context.Context, team string) (Pause, bool, error) {
+	t, ok := s.getTeam(team)
 	if !ok {
-		return "", false, nil
+		return Pause{}, false, nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.pause == nil {
+		return Pause{}, false, nil
+	}
+	return *t.pause, true, nil
+}
+
+// AuditLog returns the audit entries of a team (thread-safe).
+func (s *MemoryStorage) AuditLog(_ context.Context, team string) ([]AuditEntry, error) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	var entries []AuditEntry
+	for _, entry := range s.audit {
+		if entry.Team == team {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// DeleteExpiredSchedules soft-deletes the expired schedules of every team (thread-safe).
+func (s *MemoryStorage) DeleteExpiredSchedules(ctx context.Context, before time.Time) (int, error) {
+	deleted := 0
+
+	for name, t := range s.snapshot() {
+		t.mu.Lock()
+		dropped := t.prune(func(sched Schedule) bool { return sched.expired(before) })
+		t.mu.Unlock()
+
+		for _, sched := range dropped {
+			s.record(ctx, AuditDeleteSchedule, name, sched.Name)
+		}
+		deleted += len(dropped)
+	}
+
+	return deleted, nil
+}
+
+// PurgeExpiredPauses deletes the expired pauses of every team (thread-safe).
+func (s *MemoryStorage) PurgeExpiredPauses(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+
+	for name, t := range s.snapshot() {
+		t.mu.Lock()
+		expired := t.pause != nil && !t.pause.Until.IsZero() && !t.pause.Until.After(before)
+		if expired {
+			t.pause = nil
+		}
+		t.mu.Unlock()
+
+		if expired {
+			s.record(ctx, AuditUnpauseTeam, name, "expired")
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// TrimAuditLog deletes the audit entries recorded before the given instant (thread-safe).
+func (s *MemoryStorage) TrimAuditLog(_ context.Context, before time.Time) (int, error) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	kept := s.audit[:0]
+	for _, entry := range s.audit {
+		if !entry.At.Before(before) {
+			kept = append(kept, entry)
+		}
+	}
+
+	trimmed := len(s.audit) - len(kept)
+	s.audit = kept
+
+	return trimmed, nil
+}
+
+// AddWebhook stores a webhook subscription (thread-safe).
+func (s *MemoryStorage) AddWebhook(ctx context.Context, webhook Webhook) (Webhook, error) {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+
+	count := 0
+	for _, w := range s.webhooks {
+		if w.Team == webhook.Team {
+			count++
+		}
+	}
+	if err := checkQuota(ctx, QuotaWebhooks, webhook.Team, count); err != nil {
+		return Webhook{}, err
+	}
+
+	s.nextWebhook++
+	webhook.ID = s.nextWebhook
+	webhook.CreatedAt = time.Now()
+	webhook.Events = slices.Clone(webhook.Events)
+	s.webhooks = append(s.webhooks, webhook)
+
+	return webhook, nil
+}
+
+// ListWebhooks returns every webhook subscription (thread-safe).
+func (s *MemoryStorage) ListWebhooks(_ context.Context) ([]Webhook, error) {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+
+	return slices.Clone(s.webhooks), nil
+}
+
+// DeleteWebhook removes a webhook subscription (thread-safe).
+func (s *MemoryStorage) DeleteWebhook(_ context.Context, id int64) (bool, error) {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+
+	for i, webhook := range s.webhooks {
+		if webhook.ID == id {
+			s.webhooks = slices.Delete(s.webhooks, i, i+1)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// MarkReminderSent records a sent reminder (thread-safe).
+func (s *MemoryStorage) MarkReminderSent(_ context.Context, key string) (bool, error) {
+	s.remindersMu.Lock()
+	defer s.remindersMu.Unlock()
+
+	if s.reminders[key] {
+		return false, nil
+	}
+
+	if s.reminders == nil {
+		s.reminders = make(map[string]bool)
+	}
+	s.reminders[key] = true
+
+	return true, nil
+}
+
+// AddCalendarToken stores a calendar feed token (thread-safe).
+func (s *MemoryStorage) AddCalendarToken(_ context.Context, token CalendarToken) (CalendarToken, error) {
+	s.calendarTokenMu.Lock()
+	defer s.calendarTokenMu.Unlock()
+
+	s.nextCalendarToken++
+	token.ID = s.nextCalendarToken
+	token.CreatedAt = time.Now()
+	s.calendarTokens = append(s.calendarTokens, token)
+
+	return token, nil
+}
+
+// GetCalendarToken returns a calendar feed token by its hash (thread-safe).
+func (s *MemoryStorage) GetCalendarToken(_ context.Context, hash string) (CalendarToken, bool, error) {
+	s.calendarTokenMu.Lock()
+	defer s.calendarTokenMu.Unlock()
+
+	for _, token := range s.calendarTokens {
+		if token.Hash == hash {
+			return token, true, nil
+		}
+	}
+
+	return CalendarToken{}, false, nil
+}
+
+// DeleteCalendarToken revokes a calendar feed token (thread-safe).
+func (s *MemoryStorage) DeleteCalendarToken(_ context.Context, team string, id int64) (bool, error) {
+	s.calendarTokenMu.Lock()
+	defer s.calendarTokenMu.Unlock()
+
+	for i, token := range s.calendarTokens {
+		if token.ID == id && token.Team == team {
+			s.calendarTokens = slices.Delete(s.calendarTokens, i, i+1)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// AddAPIKey stores an API key (thread-safe).
+func (s *MemoryStorage) AddAPIKey(_ context.Context, key APIKey) (APIKey, error) {
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	s.nextAPIKey++
+	key.ID = s.nextAPIKey
+	key.CreatedAt = time.Now()
+	s.apiKeys = append(s.apiKeys, key)
+
+	return key, nil
+}
+
+// GetAPIKey returns an API key by its hash (thread-safe).
+func (s *MemoryStorage) GetAPIKey(_ context.Context, hash string) (APIKey, bool, error) {
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	for _, key := range s.apiKeys {
+		if key.Hash == hash {
+			return key, true, nil
+		}
+	}
+
+	return APIKey{}, false, nil
+}
+
+// ListAPIKeys returns every API key (thread-safe).
+func (s *MemoryStorage) ListAPIKeys(_ context.Context) ([]APIKey, error) {
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	return slices.Clone(s.apiKeys), nil
+}
+
+// RevokeAPIKey revokes an API key (thread-safe).
+func (s *MemoryStorage) RevokeAPIKey(_ context.Context, id int64, at time.Time) (bool, error) {
+	s.apiKeyMu.Lock()
+	defer s.apiKeyMu.Unlock()
+
+	for i, key := range s.apiKeys {
+		if key.ID == id && key.RevokedAt.IsZero() {
+			s.apiKeys[i].RevokedAt = at
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// AddScopedToken stores a scoped token (thread-safe).
+func (s *MemoryStorage) AddScopedToken(_ context.Context, token ScopedToken) (ScopedToken, error) {
+	s.scopedTokenMu.Lock()
+	defer s.scopedTokenMu.Unlock()
+
+	s.nextScopedToken++
+	token.ID = s.nextScopedToken
+	token.Scopes = slices.Clone(token.Scopes)
+	token.Teams = slices.Clone(token.Teams)
+	token.CreatedAt = time.Now()
+	s.scopedTokens = append(s.scopedTokens, token)
+
+	return cloneScopedToken(token), nil
+}
+
+// GetScopedToken returns a scoped token by its hash (thread-safe).
+func (s *MemoryStorage) GetScopedToken(_ context.Context, hash string) (ScopedToken, bool, error) {
+	s.scopedTokenMu.Lock()
+	defer s.scopedTokenMu.Unlock()
+
+	for _, token := range s.scopedTokens {
+		if token.Hash == hash {
+			return cloneScopedToken(token), true, nil
+		}
+	}
+
+	return ScopedToken{}, false, nil
+}
+
+// ListScopedTokens returns every scoped token (thread-safe).
+func (s *MemoryStorage) ListScopedTokens(_ context.Context) ([]ScopedToken, error) {
+	s.scopedTokenMu.Lock()
+	defer s.scopedTokenMu.Unlock()
+
+	tokens := make([]ScopedToken, 0, len(s.scopedTokens))
+	for _, token := range s.scopedTokens {
+		tokens = append(tokens, cloneScopedToken(token))
+	}
+
+	return tokens, nil
+}
+
+// RevokeScopedToken revokes a scoped token (thread-safe).
+func (s *MemoryStorage) RevokeScopedToken(_ context.Context, id int64, at time.Time) (bool, error) {
+	s.scopedTokenMu.Lock()
+	defer s.scopedTokenMu.Unlock()
+
+	for i, token := range s.scopedTokens {
+		if token.ID == id && token.RevokedAt.IsZero() {
+			s.scopedTokens[i].RevokedAt = at
+			return true, nil
+		}
 	}
 
-	// Check each schedule to find a match
-	for _, sched := range t.Schedules {
-		// Check if day matches
-		dayMatches := false
-		for _, day := range sched.Days {
-			if day == at.Weekday() {
-				dayMatches = true
-				break
+	return false, nil
+}
+
+// RecordScopedTokenUse counts a use of a scoped token (thread-safe).
+func (s *MemoryStorage) RecordScopedTokenUse(_ context.Context, id int64, at time.Time) error {
+	s.scopedTokenMu.Lock()
+	defer s.scopedTokenMu.Unlock()
+
+	for i, token := range s.scopedTokens {
+		if token.ID == id {
+			s.scopedTokens[i].Uses++
+			if at.After(token.LastUsedAt) {
+				s.scopedTokens[i].LastUsedAt = at
 			}
 		}
-		if !dayMatches {
-			continue
+	}
+
+	return nil
+}
+
+// cloneScopedToken copies a scoped token so callers cannot change the stored
+// scopes and teams.
+func cloneScopedToken(token ScopedToken) ScopedToken {
+	token.Scopes = slices.Clone(token.Scopes)
+	token.Teams = slices.Clone(token.Teams)
+
+	return token
+}
+
+// AddUnavailability marks a member unavailable for a window (thread-safe).
+func (s *MemoryStorage) AddUnavailability(_ context.Context, unavailability Unavailability) (Unavailability, error) {
+	s.unavailabilityMu.Lock()
+	defer s.unavailabilityMu.Unlock()
+
+	s.nextUnavailability++
+	unavailability.ID = s.nextUnavailability
+	unavailability.CreatedAt = time.Now()
+	s.unavailability = append(s.unavailability, unavailability)
+
+	return unavailability, nil
+}
+
+// ListUnavailability returns the unavailability windows of a member ordered
+// by start (thread-safe).
+func (s *MemoryStorage) ListUnavailability(_ context.Context, member string) ([]Unavailability, error) {
+	s.unavailabilityMu.Lock()
+	defer s.unavailabilityMu.Unlock()
+
+	var windows []Unavailability
+	for _, window := range s.unavailability {
+		if window.Member == member {
+			windows = append(windows, window)
+		}
+	}
+	slices.SortStableFunc(windows, func(a, b Unavailability) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return windows, nil
+}
+
+// CancelUnavailability removes an unavailability window of a member (thread-safe).
+func (s *MemoryStorage) CancelUnavailability(_ context.Context, member string, id int64) (bool, error) {
+	s.unavailabilityMu.Lock()
+	defer s.unavailabilityMu.Unlock()
+
+	for i, window := range s.unavailability {
+		if window.ID == id && window.Member == member {
+			s.unavailability = slices.Delete(s.unavailability, i, i+1)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// UnavailableMembers returns the given members who are unavailable at the
+// instant (thread-safe).
+func (s *MemoryStorage) UnavailableMembers(_ context.Context, members []string, at time.Time) ([]string, error) {
+	s.unavailabilityMu.Lock()
+	defer s.unavailabilityMu.Unlock()
+
+	var unavailable []string
+	for _, window := range s.unavailability {
+		if window.Active(at) && slices.Contains(members, window.Member) && !slices.Contains(unavailable, window.Member) {
+			unavailable = append(unavailable, window.Member)
+		}
+	}
+
+	return unavailable, nil
+}
+
+// FindSchedulesByTags returns the schedules carrying all of the tags (thread-safe).
+func (s *MemoryStorage) FindSchedulesByTags(_ context.Context, tags []string) ([]TeamSchedule, error) {
+	teams := s.snapshot()
+
+	names := make([]string, 0, len(teams))
+	for name := range teams {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var result []TeamSchedule
+	for _, name := range names {
+		t := teams[name]
+
+		t.mu.RLock()
+		for _, sched := range t.schedules {
+			if sched.HasTags(tags) {
+				result = append(result, TeamSchedule{Team: name, Schedule: sched})
+			}
 		}
+		t.mu.RUnlock()
+	}
+
+	return result, nil
+}
+
+// FindTeamsByMember returns the teams with a schedule listing or pinning the
+// member (thread-safe).
+func (s *MemoryStorage) FindTeamsByMember(_ context.Context, member string) ([]string, error) {
+	var names []string
+	for name, t := range s.snapshot() {
+		t.mu.RLock()
+		if t.hasMember(member) {
+			names = append(names, name)
+		}
+		t.mu.RUnlock()
+	}
+	slices.Sort(names)
+
+	return names, nil
+}
 
-		// Check if time is within schedule
-		schedTime := time.Date(at.Year(), at.Month(), at.Day(),
-			at.Hour(), at.Minute(), at.Second(), at.Nanosecond(), at.Location())
-		schedStart := time.Date(at.Year(), at.Month(), at.Day(),
-			sched.Start.Hour(), sched.Start.Minute(), sched.Start.Second(), 0, at.Location())
-		schedEnd := time.Date(at.Year(), at.Month(), at.Day(),
-			sched.End.Hour(), sched.End.Minute(), sched.End.Second(), 0, at.Location())
+// FindSchedulesByMembers returns the schedules listing any of the members
+// (thread-safe).
+func (s *MemoryStorage) FindSchedulesByMembers(_ context.Context, members []string) ([]MemberSchedule, error) {
+	teams := s.snapshot()
 
-		if schedTime.After(schedStart) && schedTime.Before(schedEnd) || schedTime.Equal(schedStart) {
-			if len(sched.Members) > 0 {
-				// Return first member (no rotation tracking in memory storage)
-				return sched.Members[0], true, nil
+	names := make([]string, 0, len(teams))
+	for name := range teams {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var result []MemberSchedule
+	for _, name := range names {
+		t := teams[name]
+
+		t.mu.RLock()
+		for _, sched := range t.schedules {
+			for position, member := range sched.Members {
+				if slices.Contains(members, member) {
+					result = append(result, MemberSchedule{
+						TeamSchedule: TeamSchedule{Team: name, Schedule: sched},
+						Member:       member,
+						Position:     position,
+					})
+				}
 			}
 		}
+		t.mu.RUnlock()
+	}
+
+	return result, nil
+}
+
+// GetSchedule returns a schedule by its ID (thread-safe).
+func (s *MemoryStorage) GetSchedule(_ context.Context, id string) (TeamSchedule, error) {
+	for name, t := range s.snapshot() {
+		t.mu.RLock()
+		i := t.find(id)
+		if i != -1 {
+			sched := t.schedules[i]
+			t.mu.RUnlock()
+			return TeamSchedule{Team: name, Schedule: sched}, nil
+		}
+		t.mu.RUnlock()
 	}
 
-	return "", false, nil
+	return TeamSchedule{}, ErrScheduleNotFound
+}
+
+// TeamHistory returns the versions of a team (thread-safe). The history of
+// every schedule is kept from its creation, so it always reaches back.
+func (s *MemoryStorage) TeamHistory(_ context.Context, team string, from, to time.Time) ([]TeamVersion, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return nil, ErrTeamNotFound
+	}
+
+	s.usersMu.RLock()
+	defer s.usersMu.RUnlock()
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return teamHistory(resolveAliases(t.history, s.aliases), from, to, t.pins), nil
+}
+
+// HistoryStart returns the zero time for every team (thread-safe), as the
+// history of every schedule is kept from its creation.
+func (s *MemoryStorage) HistoryStart(_ context.Context, team string) (time.Time, bool, error) {
+	_, ok := s.getTeam(team)
+	return time.Time{}, ok, nil
+}
+
+// ScheduleVersions returns the versions of a schedule (thread-safe).
+func (s *MemoryStorage) ScheduleVersions(_ context.Context, id string) ([]ScheduleVersion, bool, error) {
+	for _, t := range s.snapshot() {
+		t.mu.RLock()
+		if t.find(id) == -1 {
+			t.mu.RUnlock()
+			continue
+		}
+
+		var versions []ScheduleVersion
+		for _, version := range t.history {
+			if version.Schedule.ID == id && !version.Deleted {
+				versions = append(versions, version)
+			}
+		}
+		t.mu.RUnlock()
+
+		s.usersMu.RLock()
+		defer s.usersMu.RUnlock()
+
+		return resolveAliases(versions, s.aliases), true, nil
+	}
+
+	return nil, false, nil
+}
+
+// AddPin pins a member to a date of a schedule (thread-safe).
+func (s *MemoryStorage) AddPin(ctx context.Context, team, scheduleID string, pin Pin) (Pin, bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return Pin{}, false, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := t.find(scheduleID)
+	if i == -1 {
+		return Pin{}, false, nil
+	}
+
+	pin.Date = PinDate(pin.Date)
+
+	// A pin replacing the one of its date does not add to the count
+	count := 0
+	for j, sched := range t.schedules {
+		for _, p := range sched.Pins {
+			if j != i || !p.Date.Equal(pin.Date) {
+				count++
+			}
+		}
+	}
+	if err := checkQuota(ctx, QuotaPins, team, count); err != nil {
+		return Pin{}, false, err
+	}
+
+	pin.ID = s.nextPin.Add(1)
+	pin.CreatedAt = time.Now()
+
+	// Copies handed out by GetTeam share the old slice, so it is replaced
+	pins := slices.DeleteFunc(slices.Clone(t.schedules[i].Pins), func(p Pin) bool {
+		return p.Date.Equal(pin.Date)
+	})
+	pins = append(pins, pin)
+	slices.SortFunc(pins, func(a, b Pin) int {
+		return a.Date.Compare(b.Date)
+	})
+	t.schedules[i].Pins = pins
+
+	return pin, true, nil
+}
+
+// AddSwapRequest stores a pending swap request (thread-safe).
+func (s *MemoryStorage) AddSwapRequest(_ context.Context, team string, request SwapRequest) (SwapRequest, bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return SwapRequest{}, false, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.find(request.ScheduleID) == -1 {
+		return SwapRequest{}, false, nil
+	}
+
+	request.ID = s.nextSwap.Add(1)
+	request.Team = team
+	request.Status = SwapPending
+	request.CreatedAt = time.Now()
+	request.DecidedAt = time.Time{}
+
+	t.swaps = append(t.swaps, request)
+
+	return request, true, nil
+}
+
+// GetSwapRequest returns a swap request by its ID (thread-safe).
+func (s *MemoryStorage) GetSwapRequest(_ context.Context, id int64) (SwapRequest, bool, error) {
+	for _, t := range s.snapshot() {
+		t.mu.RLock()
+		i := slices.IndexFunc(t.swaps, func(r SwapRequest) bool { return r.ID == id })
+		if i != -1 {
+			request := t.swaps[i]
+			t.mu.RUnlock()
+			return request, true, nil
+		}
+		t.mu.RUnlock()
+	}
+
+	return SwapRequest{}, false, nil
+}
+
+// ListSwapRequests returns the swap requests of a schedule (thread-safe).
+func (s *MemoryStorage) ListSwapRequests(_ context.Context, team, scheduleID string) ([]SwapRequest, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return nil, nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var result []SwapRequest
+	for _, request := range t.swaps {
+		if request.ScheduleID == scheduleID {
+			result = append(result, request)
+		}
+	}
+
+	return result, nil
+}
+
+// DecideSwapRequest accepts or declines a swap request along with pinning
+// its target (thread-safe).
+func (s *MemoryStorage) DecideSwapRequest(ctx context.Context, id int64, accept bool, at time.Time) (SwapRequest, bool, error) {
+	for name, t := range s.snapshot() {
+		t.mu.Lock()
+		i := slices.IndexFunc(t.swaps, func(r SwapRequest) bool { return r.ID == id })
+		if i == -1 {
+			t.mu.Unlock()
+			continue
+		}
+
+		j := t.find(t.swaps[i].ScheduleID)
+		if j == -1 {
+			t.mu.Unlock()
+			return SwapRequest{}, false, nil
+		}
+
+		request, err := t.swaps[i].decide(accept, at)
+		if err != nil {
+			t.mu.Unlock()
+			return SwapRequest{}, false, err
+		}
+		t.swaps[i] = request
+
+		if accept {
+			pin := request.pin()
+			pin.ID = s.nextPin.Add(1)
+			pin.CreatedAt = time.Now()
+
+			// Copies handed out by GetTeam share the old slice, so it is replaced
+			pins := slices.DeleteFunc(slices.Clone(t.schedules[j].Pins), func(p Pin) bool {
+				return p.Date.Equal(pin.Date)
+			})
+			pins = append(pins, pin)
+			slices.SortFunc(pins, func(a, b Pin) int {
+				return a.Date.Compare(b.Date)
+			})
+			t.schedules[j].Pins = pins
+		}
+		t.mu.Unlock()
+
+		if accept {
+			s.record(ctx, AuditAcceptSwap, name, request.auditDetail())
+		}
+		return request, true, nil
+	}
+
+	return SwapRequest{}, false, nil
+}
+
+// DeletePin removes a pin of a schedule (thread-safe).
+func (s *MemoryStorage) DeletePin(_ context.Context, team, scheduleID string, id int64) (bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return false, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := t.find(scheduleID)
+	if i == -1 {
+		return false, nil
+	}
+
+	index := slices.IndexFunc(t.schedules[i].Pins, func(p Pin) bool {
+		return p.ID == id
+	})
+	if index == -1 {
+		return false, nil
+	}
+	t.schedules[i].Pins = slices.Delete(slices.Clone(t.schedules[i].Pins), index, index+1)
+
+	return true, nil
+}
+
+// SetRotation sets the rotation of a schedule (thread-safe).
+func (s *MemoryStorage) SetRotation(ctx context.Context, team, scheduleID string, rotation Rotation) (bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return false, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := t.find(scheduleID)
+	if i == -1 {
+		return false, nil
+	}
+
+	sched := &t.schedules[i]
+	sched.Manual = rotation.Manual
+	sched.Anchor = rotation.Anchor
+	sched.RotationOffset = rotation.Offset
+	t.version(*sched)
+
+	var member string
+	if rotation.Offset >= 0 && rotation.Offset < len(sched.Members) {
+		member = sched.Members[rotation.Offset]
+	}
+	s.record(ctx, AuditSetRotation, team, rotation.auditDetail(sched.Name, member))
+
+	return true, nil
+}
+
+// UpdateScheduleFields changes the given fields of a schedule of a team (thread-safe).
+func (s *MemoryStorage) UpdateScheduleFields(ctx context.Context, team, scheduleID string, update ScheduleUpdate) (Schedule, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return Schedule{}, ErrScheduleNotFound
+	}
+
+	s.usersMu.RLock()
+	defer s.usersMu.RUnlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := t.find(scheduleID)
+	if i == -1 {
+		return Schedule{}, ErrScheduleNotFound
+	}
+
+	if update.Name != nil && t.named(*update.Name, scheduleID) {
+		return Schedule{}, ErrScheduleExists
+	}
+
+	sched := t.schedules[i]
+	if err := update.apply(&sched); err != nil {
+		return Schedule{}, err
+	}
+
+	if update.Members != nil {
+		sched.Inactive = inactiveMembers(sched.Members, s.inactive)
+
+		// Members of schedules join the roster, the roles of known ones are kept
+		for _, member := range sched.Members {
+			if _, ok := t.members[member]; !ok {
+				t.members[member] = TeamMember{Name: member, Role: MemberRoleMember, CreatedAt: time.Now()}
+			}
+		}
+	}
+
+	t.schedules[i] = sched
+	t.reindex()
+	t.refreshMembers(s.inactive)
+	t.version(t.schedules[i])
+
+	s.record(ctx, AuditUpdateSchedule, team, update.auditDetail(sched.Name))
+
+	return t.schedules[i], nil
+}
+
+// SetTeamMember adds a member to the roster of a team or changes its role (thread-safe).
+func (s *MemoryStorage) SetTeamMember(ctx context.Context, team string, member TeamMember) (TeamMember, bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return TeamMember{}, false, nil
+	}
+
+	s.usersMu.RLock()
+	t.mu.Lock()
+	if existing, ok := t.members[member.Name]; ok {
+		member.CreatedAt = existing.CreatedAt
+	} else {
+		member.CreatedAt = time.Now()
+	}
+	t.members[member.Name] = member
+	t.refreshMembers(s.inactive)
+	t.mu.Unlock()
+	s.usersMu.RUnlock()
+
+	s.record(ctx, AuditSetTeamMember, team, member.auditDetail())
+	return member, true, nil
+}
+
+// ListTeamMembers returns the roster of a team ordered by name (thread-safe).
+func (s *MemoryStorage) ListTeamMembers(_ context.Context, team string) ([]TeamMember, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return nil, nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	members := make([]TeamMember, 0, len(t.members))
+	for _, member := range t.members {
+		members = append(members, member)
+	}
+	slices.SortFunc(members, func(a, b TeamMember) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return members, nil
+}
+
+// RemoveTeamMember removes a member from the roster of a team (thread-safe).
+func (s *MemoryStorage) RemoveTeamMember(ctx context.Context, team, name string) (bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return false, nil
+	}
+
+	s.usersMu.RLock()
+	t.mu.Lock()
+	_, ok = t.members[name]
+	delete(t.members, name)
+	t.refreshMembers(s.inactive)
+	t.mu.Unlock()
+	s.usersMu.RUnlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	s.record(ctx, AuditRemoveTeamMember, team, name)
+	return true, nil
+}
+
+// SetGroup creates or replaces a group of a team (thread-safe).
+func (s *MemoryStorage) SetGroup(ctx context.Context, team string, group Group) (Group, bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return Group{}, false, nil
+	}
+
+	now := time.Now()
+
+	s.usersMu.RLock()
+	t.mu.Lock()
+	existing, ok := t.groups[group.Name]
+	if !ok {
+		existing = &memoryGroup{createdAt: now}
+		t.groups[group.Name] = existing
+	}
+	existing.members = setGroupMembers(existing.members, group.Members, now)
+	existing.updatedAt = now
+	group = existing.group(group.Name)
+	t.refreshMembers(s.inactive)
+	t.mu.Unlock()
+	s.usersMu.RUnlock()
+
+	s.record(ctx, AuditSetGroup, team, group.auditDetail())
+	return group, true, nil
+}
+
+// ListGroups returns the groups of a team ordered by name (thread-safe).
+func (s *MemoryStorage) ListGroups(_ context.Context, team string) ([]Group, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return nil, nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	groups := make([]Group, 0, len(t.groups))
+	for name, group := range t.groups {
+		groups = append(groups, group.group(name))
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return groups, nil
+}
+
+// DeleteGroup removes a group of a team unless a schedule references it (thread-safe).
+func (s *MemoryStorage) DeleteGroup(ctx context.Context, team, name string) (bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return false, nil
+	}
+
+	t.mu.Lock()
+	if _, ok = t.groups[name]; ok {
+		for _, sched := range t.schedules {
+			if slices.Contains(sched.MemberRefs, GroupPrefix+name) {
+				t.mu.Unlock()
+				return false, ErrGroupInUse
+			}
+		}
+		delete(t.groups, name)
+	}
+	t.mu.Unlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	s.record(ctx, AuditDeleteGroup, team, name)
+	return true, nil
+}
+
+// AddFreeze freezes a team for a window (thread-safe).
+func (s *MemoryStorage) AddFreeze(ctx context.Context, team string, freeze Freeze) (Freeze, bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return Freeze{}, false, nil
+	}
+
+	freeze.ID = s.nextFreeze.Add(1)
+	freeze.CreatedAt = time.Now()
+
+	t.mu.Lock()
+	// Copies handed out by ListFreezes share the old slice, so it is replaced
+	freezes := append(slices.Clone(t.freezes), freeze)
+	slices.SortStableFunc(freezes, func(a, b Freeze) int {
+		return a.Start.Compare(b.Start)
+	})
+	t.freezes = freezes
+	t.mu.Unlock()
+
+	s.record(ctx, AuditFreezeTeam, team, freeze.auditDetail())
+	return freeze, true, nil
+}
+
+// ListFreezes returns the freezes of a team ordered by start (thread-safe).
+func (s *MemoryStorage) ListFreezes(_ context.Context, team string) ([]Freeze, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return nil, nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return slices.Clone(t.freezes), nil
+}
+
+// CancelFreeze removes a freeze of a team (thread-safe).
+func (s *MemoryStorage) CancelFreeze(ctx context.Context, team string, id int64) (bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return false, nil
+	}
+
+	t.mu.Lock()
+	index := slices.IndexFunc(t.freezes, func(f Freeze) bool { return f.ID == id })
+	if index != -1 {
+		t.freezes = slices.Delete(slices.Clone(t.freezes), index, index+1)
+	}
+	t.mu.Unlock()
+
+	if index == -1 {
+		return false, nil
+	}
+
+	s.record(ctx, AuditCancelFreeze, team, strconv.FormatInt(id, 10))
+	return true, nil
+}
+
+// AddBlackout adds a blackout window to a team (thread-safe).
+func (s *MemoryStorage) AddBlackout(ctx context.Context, team string, blackout Blackout) (Blackout, bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return Blackout{}, false, nil
+	}
+
+	blackout.ID = s.nextBlackout.Add(1)
+	blackout.CreatedAt = time.Now()
+
+	t.mu.Lock()
+	// Copies handed out by ListBlackouts share the old slice, so it is replaced
+	blackouts := append(slices.Clone(t.blackouts), blackout)
+	slices.SortStableFunc(blackouts, func(a, b Blackout) int {
+		return a.Start.Compare(b.Start)
+	})
+	t.blackouts = blackouts
+	t.mu.Unlock()
+
+	s.record(ctx, AuditAddBlackout, team, blackout.auditDetail())
+	return blackout, true, nil
+}
+
+// ListBlackouts returns the blackouts of a team ordered by start (thread-safe).
+func (s *MemoryStorage) ListBlackouts(_ context.Context, team string) ([]Blackout, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return nil, nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return slices.Clone(t.blackouts), nil
+}
+
+// CancelBlackout removes a blackout of a team (thread-safe).
+func (s *MemoryStorage) CancelBlackout(ctx context.Context, team string, id int64) (bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return false, nil
+	}
+
+	t.mu.Lock()
+	index := slices.IndexFunc(t.blackouts, func(b Blackout) bool { return b.ID == id })
+	if index != -1 {
+		t.blackouts = slices.Delete(slices.Clone(t.blackouts), index, index+1)
+	}
+	t.mu.Unlock()
+
+	if index == -1 {
+		return false, nil
+	}
+
+	s.record(ctx, AuditCancelBlackout, team, strconv.FormatInt(id, 10))
+	return true, nil
+}
+
+// AddHandoffNote adds a handoff note to a shift of a team (thread-safe).
+func (s *MemoryStorage) AddHandoffNote(ctx context.Context, team string, note HandoffNote) (HandoffNote, bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return HandoffNote{}, false, nil
+	}
+
+	t.mu.Lock()
+	exists := slices.ContainsFunc(t.notes, func(n HandoffNote) bool {
+		return n.ScheduleID == note.ScheduleID && n.ShiftStart.Equal(note.ShiftStart)
+	})
+	if exists {
+		t.mu.Unlock()
+		return HandoffNote{}, true, ErrHandoffNoteExists
+	}
+
+	note.ID = s.nextNote.Add(1)
+	note.CreatedAt = time.Now()
+	note.UpdatedAt = note.CreatedAt
+	// Copies handed out by ListHandoffNotes share the old slice, so it is replaced
+	t.notes = append(slices.Clone(t.notes), note)
+	t.mu.Unlock()
+
+	s.record(ctx, AuditAddHandoffNote, team, note.auditDetail())
+	return note, true, nil
+}
+
+// ListHandoffNotes returns the handoff notes of a team ordered by ID
+// (thread-safe).
+func (s *MemoryStorage) ListHandoffNotes(_ context.Context, team string) ([]HandoffNote, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return nil, nil
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return slices.Clone(t.notes), nil
+}
+
+// UpdateHandoffNote replaces the text of a handoff note of a team
+// (thread-safe).
+func (s *MemoryStorage) UpdateHandoffNote(ctx context.Context, team string, id int64, text string) (HandoffNote, bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return HandoffNote{}, false, nil
+	}
+
+	t.mu.Lock()
+	index := slices.IndexFunc(t.notes, func(n HandoffNote) bool { return n.ID == id })
+	if index == -1 {
+		t.mu.Unlock()
+		return HandoffNote{}, false, nil
+	}
+
+	note := t.notes[index]
+	note.Text = text
+	note.UpdatedAt = time.Now()
+	t.notes = slices.Clone(t.notes)
+	t.notes[index] = note
+	t.mu.Unlock()
+
+	s.record(ctx, AuditUpdateHandoffNote, team, note.auditDetail())
+	return note, true, nil
+}
+
+// DeleteHandoffNote removes a handoff note of a team (thread-safe).
+func (s *MemoryStorage) DeleteHandoffNote(ctx context.Context, team string, id int64) (bool, error) {
+	t, ok := s.getTeam(team)
+	if !ok {
+		return false, nil
+	}
+
+	t.mu.Lock()
+	index := slices.IndexFunc(t.notes, func(n HandoffNote) bool { return n.ID == id })
+	if index != -1 {
+		t.notes = slices.Delete(slices.Clone(t.notes), index, index+1)
+	}
+	t.mu.Unlock()
+
+	if index == -1 {
+		return false, nil
+	}
+
+	s.record(ctx, AuditDeleteHandoffNote, team, strconv.FormatInt(id, 10))
+	return true, nil
+}
+
+// RecordAudit appends an audit entry of a team (thread-safe).
+func (s *MemoryStorage) RecordAudit(ctx context.Context, team, action, detail string) error {
+	s.record(ctx, action, team, detail)
+	return nil
+}
+
+// record appends an audit entry attributed to the actor of ctx.
+func (s *MemoryStorage) record(ctx context.Context, action, team, detail string) {
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	s.audit = append(s.audit, AuditEntry{
+		At:     time.Now(),
+		Actor:  ActorFrom(ctx),
+		Action: action,
+		Team:   team,
+		Detail: detail,
+	})
+}
+
+// getTeam looks up a team, only holding the map lock for the lookup itself.
+func (s *MemoryStorage) getTeam(team string) (*memoryTeam, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.data[team]
+	return t, ok
+}
+
+// snapshot copies the teams map, so every team can be visited without holding mu.
+func (s *MemoryStorage) snapshot() map[string]*memoryTeam {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	teams := make(map[string]*memoryTeam, len(s.data))
+	for name, t := range s.data {
+		teams[name] = t
+	}
+
+	return teams
+}
+
+// getOrCreateTeam looks up a team and creates it when it does not exist yet.
+func (s *MemoryStorage) getOrCreateTeam(team string) *memoryTeam {
+	if t, ok := s.getTeam(team); ok {
+		return t
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another writer may have created it in the meantime
+	t, ok := s.data[team]
+	if !ok {
+		t = &memoryTeam{members: make(map[string]TeamMember), groups: make(map[string]*memoryGroup)}
+		s.data[team] = t
+		// A team created with the name of a merged one is not merged
+		delete(s.merged, team)
+	}
+	return t
+}
+
+// add appends a schedule and indexes it.
+func (t *memoryTeam) add(schedule Schedule) {
+	index := len(t.schedules)
+	t.schedules = append(t.schedules, schedule)
+
+	if schedule.Cron != "" || schedule.RRule != "" {
+		t.recurring = append(t.recurring, index)
+		return
+	}
+
+	entry := dayEntry{
+		index: index,
+		start: secondsOfDay(schedule.Start),
+		end:   secondsOfDay(schedule.End),
+	}
+	for _, day := range schedule.Days {
+		if day >= time.Sunday && day <= time.Saturday {
+			t.byDay[day] = append(t.byDay[day], entry)
+		}
+	}
+}
+
+// reindex rebuilds the index of the schedules, after their days or times
+// changed.
+func (t *memoryTeam) reindex() {
+	schedules := t.schedules
+	t.schedules, t.byDay, t.recurring = nil, [7][]dayEntry{}, nil
+
+	for _, sched := range schedules {
+		t.add(sched)
+	}
+}
+
+// version records the definition of the schedule as its latest version.
+func (t *memoryTeam) version(schedule Schedule) {
+	schedule.Pins, schedule.Inactive = nil, nil
+	t.history = append(t.history, ScheduleVersion{Schedule: schedule, Since: time.Now()})
+}
+
+// pins returns the pins of the schedule with the given ID, soft-deleted or not.
+func (t *memoryTeam) pins(id string) []Pin {
+	for _, sched := range slices.Concat(t.schedules, t.deleted) {
+		if sched.ID == id {
+			return sched.Pins
+		}
+	}
+
+	return nil
+}
+
+// find returns the index of the schedule with the given ID, or -1.
+func (t *memoryTeam) find(id string) int {
+	return slices.IndexFunc(t.schedules, func(s Schedule) bool {
+		return s.ID == id
+	})
+}
+
+// named reports whether a schedule other than the one with the given ID has
+// the name.
+func (t *memoryTeam) named(name, id string) bool {
+	return slices.ContainsFunc(t.schedules, func(s Schedule) bool {
+		return s.Name == name && s.ID != id
+	})
+}
+
+// prune soft-deletes the schedules for which drop reports true and rebuilds
+// the index from the remaining ones, recording their tombstones. It returns
+// the deleted schedules.
+func (t *memoryTeam) prune(drop func(Schedule) bool) []Schedule {
+	var dropped []Schedule
+	t.schedules = slices.DeleteFunc(t.schedules, func(sched Schedule) bool {
+		if drop(sched) {
+			dropped = append(dropped, sched)
+			return true
+		}
+		return false
+	})
+	t.reindex()
+
+	t.deleted = append(t.deleted, dropped...)
+	for _, sched := range dropped {
+		t.history = append(t.history, ScheduleVersion{Schedule: Schedule{ID: sched.ID, Name: sched.Name}, Since: time.Now(), Deleted: true})
+	}
+	return dropped
+}
+
+// oncall returns the member on duty of the first schedule, in insertion
+// order, that covers the given UTC instant. Schedules without members are skipped.
+func (t *memoryTeam) oncall(at time.Time) (string, bool) {
+	match := -1
+
+	sec := secondsOfDay(at)
+	for _, e := range t.byDay[at.Weekday()] {
+		if sec >= e.start && sec < e.end && len(t.schedules[e.index].Members) > 0 && t.schedules[e.index].validAt(at) {
+			match = e.index
+			break
+		}
+	}
+
+	// A recurring schedule only wins if it was added before the day based match
+	for _, i := range t.recurring {
+		if match != -1 && i > match {
+			break
+		}
+		if len(t.schedules[i].Members) > 0 && t.schedules[i].covers(at) {
+			match = i
+			break
+		}
+	}
+
+	if match == -1 {
+		return "", false
+	}
+
+	return t.schedules[match].memberAt(at)
+}
+
+// refreshMembers sets the members of the schedules using the roster or the
+// groups of the team, along with their inactive members, after either
+// changed. The caller must hold the team lock and usersMu.
+func (t *memoryTeam) refreshMembers(inactive map[string]bool) {
+	members, joined := rotationMembers(slices.Collect(maps.Values(t.members)))
+	for i := range t.schedules {
+		sched := &t.schedules[i]
+		switch {
+		case sched.TeamMembers:
+			sched.Members, sched.Joined = slices.Clone(members), maps.Clone(joined)
+		case len(sched.MemberRefs) > 0:
+			expandMembers(sched, t.groupMembers(), t.created(sched.ID))
+		default:
+			continue
+		}
+		sched.Inactive = inactiveMembers(sched.Members, inactive)
+	}
+}
+
+// created returns when the schedule with the given ID was added, which is
+// now for the one being added.
+func (t *memoryTeam) created(id string) time.Time {
+	for _, version := range t.history {
+		if version.Schedule.ID == id {
+			return version.Since
+		}
+	}
+
+	return time.Now()
+}
+
+// groupMembers returns the members of the groups of the team by name.
+func (t *memoryTeam) groupMembers() map[string][]groupMember {
+	groups := make(map[string][]groupMember, len(t.groups))
+	for name, group := range t.groups {
+		groups[name] = group.members
+	}
+
+	return groups
+}
+
+// hasMember reports whether a schedule of the team lists or pins the member.
+func (t *memoryTeam) hasMember(member string) bool {
+	for _, sched := range t.schedules {
+		if slices.Contains(sched.Members, member) {
+			return true
+		}
+		for _, pin := range sched.Pins {
+			if pin.Member == member {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// references reports whether a schedule, pin, the roster or a group of the
+// team references the member.
+func (t *memoryTeam) references(member string) bool {
+	if t.hasMember(member) {
+		return true
+	}
+	if _, ok := t.members[member]; ok {
+		return true
+	}
+
+	for _, group := range t.groups {
+		if slices.ContainsFunc(group.members, func(m groupMember) bool { return m.Name == member }) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rename renames the member in the schedules, soft-deleted ones included,
+// the roster, the groups and the pending swap requests of the team. The
+// history is left as it was recorded. It reports whether the team
+// referenced the member.
+func (t *memoryTeam) rename(from, to string) bool {
+	if !t.references(from) && !slices.ContainsFunc(t.deleted, func(sched Schedule) bool {
+		return slices.Contains(sched.Members, from)
+	}) {
+		return false
+	}
+
+	rename := renamer(from, to)
+
+	for i := range t.schedules {
+		t.schedules[i] = mapMembers(t.schedules[i], rename)
+	}
+	for i := range t.deleted {
+		t.deleted[i] = mapMembers(t.deleted[i], rename)
+	}
+
+	if member, ok := t.members[from]; ok {
+		delete(t.members, from)
+		member.Name = to
+		t.members[to] = member
+	}
+
+	for _, group := range t.groups {
+		for i := range group.members {
+			group.members[i].Name = rename(group.members[i].Name)
+		}
+	}
+
+	for i := range t.swaps {
+		if t.swaps[i].Status == SwapPending {
+			t.swaps[i].From = rename(t.swaps[i].From)
+			t.swaps[i].To = rename(t.swaps[i].To)
+		}
+	}
+
+	return true
+}
+
+// secondsOfDay returns the wall clock time of t as seconds since midnight.
+func secondsOfDay(t time.Time) int {
+	return t.Hour()*3600 + t.Minute()*60 + t.Second()
+}
+
+// AddUser provisions a user (thread-safe).
+func (s *MemoryStorage) AddUser(_ context.Context, user User) (User, bool, error) {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	if slices.ContainsFunc(s.users, func(u User) bool { return u.UserName == user.UserName }) {
+		return User{}, false, nil
+	}
+
+	s.nextUser++
+	user.ID = strconv.FormatInt(s.nextUser, 10)
+	user.Emails = slices.Clone(user.Emails)
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+	s.users = append(s.users, user)
+
+	if !user.Active {
+		s.refreshInactive()
+	}
+
+	return user, true, nil
+}
+
+// GetUser returns a provisioned user by its ID (thread-safe).
+func (s *MemoryStorage) GetUser(_ context.Context, id string) (User, bool, error) {
+	s.usersMu.RLock()
+	defer s.usersMu.RUnlock()
+
+	for _, user := range s.users {
+		if user.ID == id {
+			return user, true, nil
+		}
+	}
+
+	return User{}, false, nil
+}
+
+// FindUsers returns the provisioned users by user name (thread-safe).
+func (s *MemoryStorage) FindUsers(_ context.Context, userName string) ([]User, error) {
+	s.usersMu.RLock()
+	defer s.usersMu.RUnlock()
+
+	var result []User
+	for _, user := range s.users {
+		if userName == "" || user.UserName == userName {
+			result = append(result, user)
+		}
+	}
+
+	slices.SortFunc(result, func(a, b User) int {
+		return strings.Compare(a.UserName, b.UserName)
+	})
+
+	return result, nil
+}
+
+// SetUserActive activates or deactivates a provisioned user and updates the
+// inactive members of every schedule (thread-safe).
+func (s *MemoryStorage) SetUserActive(_ context.Context, id string, active bool) (User, bool, error) {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	i := slices.IndexFunc(s.users, func(u User) bool { return u.ID == id })
+	if i == -1 {
+		return User{}, false, nil
+	}
+
+	if s.users[i].Active != active {
+		s.users[i].Active = active
+		s.users[i].UpdatedAt = time.Now()
+		s.refreshInactive()
+	}
+
+	return s.users[i], true, nil
+}
+
+// SetUserTimezone sets the zone of a provisioned user (thread-safe).
+func (s *MemoryStorage) SetUserTimezone(_ context.Context, id, timezone string) (User, bool, error) {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	i := slices.IndexFunc(s.users, func(u User) bool { return u.ID == id })
+	if i == -1 {
+		return User{}, false, nil
+	}
+
+	if s.users[i].Timezone != timezone {
+		s.users[i].Timezone = timezone
+		s.users[i].UpdatedAt = time.Now()
+	}
+
+	return s.users[i], true, nil
+}
+
+// RenameMember renames a user or member everywhere they are referenced,
+// keeping the history as it was recorded (thread-safe). Teams are renamed one
+// at a time, like any change visiting every team.
+func (s *MemoryStorage) RenameMember(ctx context.Context, from, to string) (bool, error) {
+	s.usersMu.Lock()
+	defer s.usersMu.Unlock()
+
+	teams := s.snapshot()
+	if s.knownMember(teams, to) {
+		return false, ErrMemberExists
+	}
+	if !s.knownMember(teams, from) {
+		return false, nil
+	}
+
+	rename := renamer(from, to)
+
+	for i := range s.users {
+		if s.users[i].UserName == from {
+			s.users[i].UserName = to
+			s.users[i].UpdatedAt = time.Now()
+		}
+	}
+	if s.inactive[from] {
+		delete(s.inactive, from)
+		s.inactive[to] = true
+	}
+	addAlias(s.aliases, from, to)
+
+	var renamed []string
+	for name, t := range teams {
+		t.mu.Lock()
+		if t.rename(from, to) {
+			renamed = append(renamed, name)
+		}
+		t.mu.Unlock()
+	}
+
+	s.unavailabilityMu.Lock()
+	for i := range s.unavailability {
+		s.unavailability[i].Member = rename(s.unavailability[i].Member)
+	}
+	s.unavailabilityMu.Unlock()
+
+	s.calendarTokenMu.Lock()
+	for i := range s.calendarTokens {
+		s.calendarTokens[i].Member = rename(s.calendarTokens[i].Member)
+	}
+	s.calendarTokenMu.Unlock()
+
+	slices.Sort(renamed)
+	for _, name := range renamed {
+		s.record(ctx, AuditRenameMember, name, renameDetail(from, to))
+	}
+
+	return true, nil
+}
+
+// knownMember reports whether a user, or a schedule, roster or group of any
+// of the teams, has the name. The caller must hold usersMu.
+func (s *MemoryStorage) knownMember(teams map[string]*memoryTeam, name string) bool {
+	if slices.ContainsFunc(s.users, func(u User) bool { return u.UserName == name }) {
+		return true
+	}
+
+	for _, t := range teams {
+		t.mu.RLock()
+		found := t.references(name)
+		t.mu.RUnlock()
+
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MergeTeams merges the source team into the target (thread-safe). Both
+// teams are locked at once, which is safe as mu is held exclusively, so no
+// one else is visiting the teams meanwhile.
+func (s *MemoryStorage) MergeTeams(ctx context.Context, target, source, policy string) (TeamMerge, bool, error) {
+	if target == source {
+		return TeamMerge{}, false, ErrMergeSelf
+	}
+
+	s.usersMu.RLock()
+	defer s.usersMu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	src, ok := s.data[source]
+	if !ok {
+		return TeamMerge{}, false, nil
+	}
+	dst, ok := s.data[target]
+	if !ok {
+		return TeamMerge{}, false, nil
+	}
+
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+
+	taken := make([]string, 0, len(dst.schedules))
+	for _, sched := range dst.schedules {
+		taken = append(taken, sched.Name)
+	}
+
+	renamed, err := mergeNames(source, src.schedules, taken, policy)
+	if err != nil {
+		return TeamMerge{}, true, err
+	}
+
+	merge := newTeamMerge(target, source, src.schedules, renamed)
+
+	// Schedules of the target keep precedence over the merged ones
+	dst.history = append(dst.history, src.history...)
+	slices.SortStableFunc(dst.history, func(a, b ScheduleVersion) int {
+		return a.Since.Compare(b.Since)
+	})
+	for _, sched := range src.schedules {
+		name, ok := renamed[sched.ID]
+		if ok {
+			sched.Name = name
+		}
+		dst.add(sched)
+		if ok {
+			dst.version(sched)
+		}
+	}
+	dst.deleted = append(dst.deleted, src.deleted...)
+
+	dst.freezes = append(dst.freezes, src.freezes...)
+	slices.SortStableFunc(dst.freezes, func(a, b Freeze) int {
+		return a.Start.Compare(b.Start)
+	})
+	dst.blackouts = append(dst.blackouts, src.blackouts...)
+	slices.SortStableFunc(dst.blackouts, func(a, b Blackout) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	dst.notes = append(dst.notes, src.notes...)
+	slices.SortFunc(dst.notes, func(a, b HandoffNote) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	for _, swap := range src.swaps {
+		swap.Team = target
+		dst.swaps = append(dst.swaps, swap)
+	}
+	slices.SortFunc(dst.swaps, func(a, b SwapRequest) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	for name, member := range src.members {
+		if _, ok := dst.members[name]; !ok {
+			dst.members[name] = member
+		}
+	}
+	for name, group := range src.groups {
+		if _, ok := dst.groups[name]; !ok {
+			dst.groups[name] = group
+		}
+	}
+	if dst.pause == nil {
+		dst.pause = src.pause
+	}
+	dst.refreshMembers(s.inactive)
+
+	delete(s.data, source)
+
+	// Teams merged into the source earlier are part of the target now
+	for team, into := range s.merged {
+		if into == source {
+			s.merged[team] = target
+		}
+	}
+	s.merged[source] = target
+
+	s.calendarTokenMu.Lock()
+	for i := range s.calendarTokens {
+		if s.calendarTokens[i].Team == source {
+			s.calendarTokens[i].Team = target
+		}
+	}
+	s.calendarTokenMu.Unlock()
+
+	s.webhookMu.Lock()
+	for i := range s.webhooks {
+		if s.webhooks[i].Team == source {
+			s.webhooks[i].Team = target
+		}
+	}
+	s.webhookMu.Unlock()
+
+	s.record(ctx, AuditMergeTeam, source, merge.auditDetail())
+	s.record(ctx, AuditMergeTeam, target, merge.auditDetail())
+
+	return merge, true, nil
+}
+
+// MergedInto returns the team the given one was merged into (thread-safe).
+func (s *MemoryStorage) MergedInto(_ context.Context, team string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	into, ok := s.merged[team]
+	return into, ok, nil
+}
+
+// SetTeamParent sets or clears the parent of a team (thread-safe).
+func (s *MemoryStorage) SetTeamParent(ctx context.Context, team, parent string) error {
+	s.mu.Lock()
+	if parentCycle(s.parents, team, parent) {
+		s.mu.Unlock()
+		return ErrParentCycle
+	}
+
+	if parent == "" {
+		delete(s.parents, team)
+	} else {
+		s.parents[team] = parent
+	}
+	s.mu.Unlock()
+
+	s.record(ctx, AuditSetParent, team, parentDetail(parent))
+
+	return nil
+}
+
+// TeamParent returns the parent of a team (thread-safe).
+func (s *MemoryStorage) TeamParent(_ context.Context, team string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	parent, ok := s.parents[team]
+	return parent, ok, nil
+}
+
+// SetPublicCoverage enables or disables the public coverage of a team
+// (thread-safe).
+func (s *MemoryStorage) SetPublicCoverage(ctx context.Context, team string, enabled bool) error {
+	s.mu.Lock()
+	if enabled {
+		s.public[team] = true
+	} else {
+		delete(s.public, team)
+	}
+	s.mu.Unlock()
+
+	s.record(ctx, AuditSetPublicCoverage, team, publicCoverageDetail(enabled))
+
+	return nil
+}
+
+// PublicCoverage reports whether the public coverage of a team is enabled
+// (thread-safe).
+func (s *MemoryStorage) PublicCoverage(_ context.Context, team string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.public[team], nil
+}
+
+// SetGoogleCalendar sets or clears the Google calendar of a team
+// (thread-safe).
+func (s *MemoryStorage) SetGoogleCalendar(ctx context.Context, team, calendarID string) error {
+	s.mu.Lock()
+	if calendarID == "" {
+		delete(s.calendars, team)
+	} else {
+		s.calendars[team] = calendarID
+	}
+	s.mu.Unlock()
+
+	s.record(ctx, AuditSetGoogleCalendar, team, googleCalendarDetail(calendarID))
+
+	return nil
+}
+
+// GoogleCalendar returns the Google calendar of a team (thread-safe).
+func (s *MemoryStorage) GoogleCalendar(_ context.Context, team string) (string, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	calendarID, ok := s.calendars[team]
+	return calendarID, ok, nil
+}
+
+// ListGoogleCalendars returns the Google calendars by team (thread-safe).
+func (s *MemoryStorage) ListGoogleCalendars(_ context.Context) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.calendars), nil
+}
+
+// refreshInactive rebuilds the set of deactivated user names and the
+// inactive members of every schedule. The caller must hold usersMu.
+func (s *MemoryStorage) refreshInactive() {
+	s.inactive = make(map[string]bool)
+	for _, user := range s.users {
+		if !user.Active {
+			s.inactive[user.UserName] = true
+		}
+	}
+
+	for _, t := range s.snapshot() {
+		t.mu.Lock()
+		for i := range t.schedules {
+			t.schedules[i].Inactive = inactiveMembers(t.schedules[i].Members, s.inactive)
+		}
+		t.mu.Unlock()
+	}
 }
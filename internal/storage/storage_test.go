@@ -1,6 +1,9 @@
 package storage
 
 import (
+	"context"
+	"fmt"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -19,13 +22,12 @@ func TestMemoryStorage_AddSchedule(t *testing.T) {
 		End:     parseTime(t, "5:00PM"),
 	}
 
-	err := storage.AddSchedule("backend-team", schedule)
+	_, err := storage.AddSchedule(context.Background(), "backend-team", schedule)
 	require.NoError(t, err)
 
 	// Verify the schedule was added
-	team, ok, err := storage.GetTeam("backend-team")
+	team, err := storage.GetTeam(context.Background(), "backend-team")
 	require.NoError(t, err)
-	assert.True(t, ok)
 	assert.Len(t, team.Schedules, 1)
 	assert.Equal(t, "Weekend Coverage", team.Schedules[0].Name)
 	assert.Equal(t, []string{"Alice", "Bob", "Charlie"}, team.Schedules[0].Members)
@@ -50,25 +52,23 @@ func TestMemoryStorage_AddMultipleSchedules(t *testing.T) {
 		End:     parseTime(t, "11:00PM"),
 	}
 
-	err := storage.AddSchedule("backend-team", schedule1)
+	_, err := storage.AddSchedule(context.Background(), "backend-team", schedule1)
 	require.NoError(t, err)
 
-	err = storage.AddSchedule("backend-team", schedule2)
+	_, err = storage.AddSchedule(context.Background(), "backend-team", schedule2)
 	require.NoError(t, err)
 
 	// Verify both schedules exist
-	team, ok, err := storage.GetTeam("backend-team")
+	team, err := storage.GetTeam(context.Background(), "backend-team")
 	require.NoError(t, err)
-	assert.True(t, ok)
 	assert.Len(t, team.Schedules, 2)
 }
 
 func TestMemoryStorage_GetTeam_NotFound(t *testing.T) {
 	storage := NewMemoryStorage()
 
-	team, ok, err := storage.GetTeam("non-existent-team")
-	require.NoError(t, err)
-	assert.False(t, ok)
+	team, err := storage.GetTeam(context.Background(), "non-existent-team")
+	require.ErrorIs(t, err, ErrTeamNotFound)
 	assert.Empty(t, team.Schedules)
 }
 
@@ -83,7 +83,7 @@ func TestMemoryStorage_GetCurrentOncall(t *testing.T) {
 		End:     parseTime(t, "5:00PM"),
 	}
 
-	err := storage.AddSchedule("backend-team", schedule)
+	_, err := storage.AddSchedule(context.Background(), "backend-team", schedule)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -123,22 +123,72 @@ func TestMemoryStorage_GetCurrentOncall(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			oncall, ok, err := storage.GetCurrentOncall("backend-team", tt.queryTime)
-			require.NoError(t, err)
-			assert.Equal(t, tt.expectedOk, ok)
-			if tt.expectedOk {
-				assert.Equal(t, tt.expectedMember, oncall)
+			oncall, err := storage.GetCurrentOncall(context.Background(), "backend-team", tt.queryTime)
+			if !tt.expectedOk {
+				require.ErrorIs(t, err, ErrNoCoverage)
+				return
 			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedMember, oncall)
 		})
 	}
 }
 
-func TestMemoryStorage_GetCurrentOncall_TeamNotFound(t *testing.T) {
+func TestMemoryStorage_GetCurrentOncall_ZoneIndependent(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	schedule := Schedule{
+		Name:    "Weekday Coverage",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+
+	_, err := storage.AddSchedule(context.Background(), "backend-team", schedule)
+	require.NoError(t, err)
+
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	require.NoError(t, err)
+
+	// Monday 15:00 UTC is Monday 18:30 in Tehran, which would fall outside
+	// the window if the wall clock of the given offset were used.
+	instant := time.Date(2025, 4, 28, 15, 0, 0, 0, time.UTC)
+
+	for _, at := range []time.Time{instant, instant.In(tehran)} {
+		oncall, err := storage.GetCurrentOncall(context.Background(), "backend-team", at)
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", oncall)
+	}
+}
+
+func TestMemoryStorage_GetCurrentOncall_Cron(t *testing.T) {
 	storage := NewMemoryStorage()
 
-	oncall, ok, err := storage.GetCurrentOncall("non-existent-team", time.Now())
+	schedule := Schedule{
+		Name:    "First Monday",
+		Members: []string{"Alice"},
+		Cron:    "0 9 1-7 * MON",
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+
+	_, err := storage.AddSchedule(context.Background(), "backend-team", schedule)
+	require.NoError(t, err)
+
+	oncall, err := storage.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC))
 	require.NoError(t, err)
-	assert.False(t, ok)
+	assert.Equal(t, "Alice", oncall)
+
+	_, err = storage.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 6, 9, 10, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, ErrNoCoverage)
+}
+
+func TestMemoryStorage_GetCurrentOncall_TeamNotFound(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	oncall, err := storage.GetCurrentOncall(context.Background(), "non-existent-team", time.Now())
+	require.ErrorIs(t, err, ErrTeamNotFound)
 	assert.Empty(t, oncall)
 }
 
@@ -153,57 +203,189 @@ func TestMemoryStorage_GetCurrentOncall_EmptyMembers(t *testing.T) {
 		End:     parseTime(t, "5:00PM"),
 	}
 
-	err := storage.AddSchedule("backend-team", schedule)
+	_, err := storage.AddSchedule(context.Background(), "backend-team", schedule)
 	require.NoError(t, err)
 
 	queryTime := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC) // Monday 10:00 AM
-	oncall, ok, err := storage.GetCurrentOncall("backend-team", queryTime)
-	require.NoError(t, err)
-	assert.False(t, ok)
+	oncall, err := storage.GetCurrentOncall(context.Background(), "backend-team", queryTime)
+	require.ErrorIs(t, err, ErrNoCoverage)
 	assert.Empty(t, oncall)
 }
 
 func TestMemoryStorage_ThreadSafety(t *testing.T) {
 	storage := NewMemoryStorage()
+	teams := []string{"team-a", "team-b", "team-c"}
 
-	// Spawn multiple goroutines to test thread safety
+	// Spawn multiple goroutines to test thread safety, mixing teams so
+	// per-team locks and the shared teams map are both exercised
 	done := make(chan bool)
 
 	// Writers
-	for i := 0; i < 10; i++ {
+	for i := 0; i < 30; i++ {
 		go func(idx int) {
 			schedule := Schedule{
-				Name:    "Schedule",
+				Name:    fmt.Sprintf("Schedule %d", idx),
 				Members: []string{"Alice"},
 				Days:    []time.Weekday{time.Monday},
 				Start:   parseTime(t, "9:00AM"),
 				End:     parseTime(t, "5:00PM"),
 			}
-			_ = storage.AddSchedule("team", schedule)
+			_, _ = storage.AddSchedule(context.Background(), teams[idx%len(teams)], schedule)
 			done <- true
 		}(i)
 	}
 
 	// Readers
-	for i := 0; i < 10; i++ {
-		go func() {
-			_, _, _ = storage.GetTeam("team")
+	for i := 0; i < 30; i++ {
+		go func(idx int) {
+			_, _ = storage.GetTeam(context.Background(), teams[idx%len(teams)])
 			done <- true
-		}()
+		}(i)
 	}
 
 	// Oncall readers
-	for i := 0; i < 10; i++ {
-		go func() {
-			_, _, _ = storage.GetCurrentOncall("team", time.Now())
+	for i := 0; i < 30; i++ {
+		go func(idx int) {
+			_, _ = storage.GetCurrentOncall(context.Background(), teams[idx%len(teams)], time.Now())
 			done <- true
-		}()
+		}(i)
 	}
 
 	// Wait for all goroutines
-	for i := 0; i < 30; i++ {
+	for i := 0; i < 90; i++ {
 		<-done
 	}
+
+	// Every write must have landed on its own team
+	for _, name := range teams {
+		team, err := storage.GetTeam(context.Background(), name)
+		require.NoError(t, err)
+		assert.Len(t, team.Schedules, 10)
+	}
+}
+
+func TestMemoryStorage_GetCurrentOncall_InsertionOrder(t *testing.T) {
+	storage := NewMemoryStorage()
+
+	// Overlapping schedules resolve to the one added first, whatever its kind
+	cron := Schedule{
+		Name:    "Cron",
+		Members: []string{"Alice"},
+		Cron:    "0 9 * * MON",
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+	days := Schedule{
+		Name:    "Days",
+		Members: []string{"Bob"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "5:00PM"),
+	}
+	late := Schedule{
+		Name:    "Late Cron",
+		Members: []string{"Charlie"},
+		Cron:    "0 9 * * MON",
+		Start:   parseTime(t, "9:00AM"),
+		End:     parseTime(t, "11:00PM"),
+	}
+
+	_, err := storage.AddSchedule(context.Background(), "backend-team", cron)
+	require.NoError(t, err)
+	_, err = storage.AddSchedule(context.Background(), "backend-team", days)
+	require.NoError(t, err)
+	_, err = storage.AddSchedule(context.Background(), "backend-team", late)
+	require.NoError(t, err)
+
+	oncall, err := storage.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+
+	oncall, err = storage.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 4, 28, 20, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Charlie", oncall)
+}
+
+// BenchmarkGetCurrentOncall queries a team of 500 schedules spread over the
+// week at a time none of them covers, which is the worst case for a lookup.
+func BenchmarkGetCurrentOncall(b *testing.B) {
+	storage := NewMemoryStorage()
+
+	start, _ := time.Parse(time.Kitchen, "9:00AM")
+	end, _ := time.Parse(time.Kitchen, "5:00PM")
+
+	for i := 0; i < 500; i++ {
+		schedule := Schedule{
+			Name:    fmt.Sprintf("Schedule %d", i),
+			Members: []string{"Alice"},
+			Days:    []time.Weekday{time.Weekday(i % 7)},
+			Start:   start,
+			End:     end,
+		}
+		if _, err := storage.AddSchedule(context.Background(), "backend-team", schedule); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	at := time.Date(2025, 4, 28, 20, 0, 0, 0, time.UTC) // Monday 8:00 PM
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_, _ = storage.GetCurrentOncall(context.Background(), "backend-team", at)
+	}
+}
+
+// BenchmarkMemoryStorage_MixedTeams runs lookups against several teams while
+// a quarter of the goroutines keep writing to an unrelated team.
+func BenchmarkMemoryStorage_MixedTeams(b *testing.B) {
+	storage := NewMemoryStorage()
+
+	start, _ := time.Parse(time.Kitchen, "9:00AM")
+	end, _ := time.Parse(time.Kitchen, "5:00PM")
+	schedule := Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice"},
+		Days:    []time.Weekday{time.Monday},
+		Start:   start,
+		End:     end,
+	}
+
+	teams := make([]string, 8)
+	for i := range teams {
+		teams[i] = fmt.Sprintf("team-%d", i)
+		if _, err := storage.AddSchedule(context.Background(), teams[i], schedule); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	at := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)
+
+	var workers, writes atomic.Int64
+
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		id := workers.Add(1)
+		writer := id%4 == 0
+		team := teams[id%int64(len(teams))]
+
+		for pb.Next() {
+			if writer {
+				// Every write adds a schedule, names are unique per team
+				s := schedule
+				s.Name = fmt.Sprintf("Weekday-%d", writes.Add(1))
+				if _, err := storage.AddSchedule(context.Background(), "busy-team", s); err != nil {
+					b.Error(err)
+					return
+				}
+			} else if _, err := storage.GetCurrentOncall(context.Background(), team, at); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
 }
 
 // parseTime is a helper function to parse time strings in tests
@@ -0,0 +1,2481 @@
+// Package storagetest provides a conformance suite that every storage.Storage
+// implementation is expected to pass.
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns an empty storage for a single test.
+type Factory func(t *testing.T) storage.Storage
+
+// Run runs the conformance suite against the storage returned by factory.
+func Run(t *testing.T, factory Factory) {
+	t.Run("AddAndGetTeam", func(t *testing.T) { testAddAndGetTeam(t, factory(t)) })
+	t.Run("GetTeamNotFound", func(t *testing.T) { testGetTeamNotFound(t, factory(t)) })
+	t.Run("CurrentOncallByDay", func(t *testing.T) { testCurrentOncallByDay(t, factory(t)) })
+	t.Run("CurrentOncallTeamNotFound", func(t *testing.T) { testCurrentOncallTeamNotFound(t, factory(t)) })
+	t.Run("CurrentOncallZoneIndependent", func(t *testing.T) { testCurrentOncallZoneIndependent(t, factory(t)) })
+	t.Run("CurrentOncallAdjacentSchedules", func(t *testing.T) { testCurrentOncallAdjacentSchedules(t, factory(t)) })
+	t.Run("CurrentOncallOverlappingSchedules", func(t *testing.T) { testCurrentOncallOverlappingSchedules(t, factory(t)) })
+	t.Run("CurrentOncallCron", func(t *testing.T) { testCurrentOncallCron(t, factory(t)) })
+	t.Run("CurrentOncallRRule", func(t *testing.T) { testCurrentOncallRRule(t, factory(t)) })
+	t.Run("ListTeams", func(t *testing.T) { testListTeams(t, factory(t)) })
+	t.Run("DeleteTeam", func(t *testing.T) { testDeleteTeam(t, factory(t)) })
+	t.Run("DeleteTeamRecreated", func(t *testing.T) { testDeleteTeamRecreated(t, factory(t)) })
+	t.Run("PauseTeam", func(t *testing.T) { testPauseTeam(t, factory(t)) })
+	t.Run("ScheduleValidUntil", func(t *testing.T) { testScheduleValidUntil(t, factory(t)) })
+	t.Run("CalendarTokens", func(t *testing.T) { testCalendarTokens(t, factory(t)) })
+	t.Run("APIKeys", func(t *testing.T) { testAPIKeys(t, factory(t)) })
+	t.Run("ScopedTokens", func(t *testing.T) { testScopedTokens(t, factory(t)) })
+	t.Run("FindSchedulesByTags", func(t *testing.T) { testFindSchedulesByTags(t, factory(t)) })
+	t.Run("Rotation", func(t *testing.T) { testRotation(t, factory(t)) })
+	t.Run("RequiredCount", func(t *testing.T) { testRequiredCount(t, factory(t)) })
+	t.Run("DayAssignments", func(t *testing.T) { testDayAssignments(t, factory(t)) })
+	t.Run("Pins", func(t *testing.T) { testPins(t, factory(t)) })
+	t.Run("ShiftPins", func(t *testing.T) { testShiftPins(t, factory(t)) })
+	t.Run("SwapRequests", func(t *testing.T) { testSwapRequests(t, factory(t)) })
+	t.Run("FindTeamsByMember", func(t *testing.T) { testFindTeamsByMember(t, factory(t)) })
+	t.Run("FindSchedulesByMembers", func(t *testing.T) { testFindSchedulesByMembers(t, factory(t)) })
+	t.Run("Users", func(t *testing.T) { testUsers(t, factory(t)) })
+	t.Run("UserTimezone", func(t *testing.T) { testUserTimezone(t, factory(t)) })
+	t.Run("TeamMembers", func(t *testing.T) { testTeamMembers(t, factory(t)) })
+	t.Run("TeamMemberSchedules", func(t *testing.T) { testTeamMemberSchedules(t, factory(t)) })
+	t.Run("Groups", func(t *testing.T) { testGroups(t, factory(t)) })
+	t.Run("Freezes", func(t *testing.T) { testFreezes(t, factory(t)) })
+	t.Run("Blackouts", func(t *testing.T) { testBlackouts(t, factory(t)) })
+	t.Run("Unavailability", func(t *testing.T) { testUnavailability(t, factory(t)) })
+	t.Run("ManualRotation", func(t *testing.T) { testManualRotation(t, factory(t)) })
+	t.Run("Handoff", func(t *testing.T) { testHandoff(t, factory(t)) })
+	t.Run("Routing", func(t *testing.T) { testRouting(t, factory(t)) })
+	t.Run("Compensation", func(t *testing.T) { testCompensation(t, factory(t)) })
+	t.Run("TeamHistory", func(t *testing.T) { testTeamHistory(t, factory(t)) })
+	t.Run("TeamHistoryTombstones", func(t *testing.T) { testTeamHistoryTombstones(t, factory(t)) })
+	t.Run("ScheduleVersions", func(t *testing.T) { testScheduleVersions(t, factory(t)) })
+	t.Run("UpdateScheduleFields", func(t *testing.T) { testUpdateScheduleFields(t, factory(t)) })
+	t.Run("ScheduleNames", func(t *testing.T) { testScheduleNames(t, factory(t)) })
+	t.Run("Quotas", func(t *testing.T) { testQuotas(t, factory(t)) })
+	t.Run("QuotasConcurrent", func(t *testing.T) { testQuotasConcurrent(t, factory(t)) })
+	t.Run("RenameMember", func(t *testing.T) { testRenameMember(t, factory(t)) })
+	t.Run("MergeTeams", func(t *testing.T) { testMergeTeams(t, factory(t)) })
+	t.Run("TeamParents", func(t *testing.T) { testTeamParents(t, factory(t)) })
+	t.Run("PublicCoverage", func(t *testing.T) { testPublicCoverage(t, factory(t)) })
+	t.Run("GoogleCalendars", func(t *testing.T) { testGoogleCalendars(t, factory(t)) })
+	t.Run("HandoffNotes", func(t *testing.T) { testHandoffNotes(t, factory(t)) })
+}
+
+func testAddAndGetTeam(t *testing.T, s storage.Storage) {
+	weekend := Schedule(t, "Weekend Coverage", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Saturday, time.Sunday)
+	weekend.Description = "Covers the weekends"
+	weekend.Notes = "Escalate to **#sre**\n\n- page the lead"
+	evening := Schedule(t, "Weekday Evening", []string{"Charlie"}, "5:00PM", "11:00PM", time.Monday, time.Friday)
+
+	weekendID, err := s.AddSchedule(context.Background(), "backend-team", weekend)
+	require.NoError(t, err)
+	eveningID, err := s.AddSchedule(context.Background(), "backend-team", evening)
+	require.NoError(t, err)
+	assert.NotEmpty(t, weekendID)
+	assert.NotEqual(t, weekendID, eveningID)
+
+	team, err := s.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 2)
+	assert.Equal(t, []string{weekendID, eveningID}, []string{team.Schedules[0].ID, team.Schedules[1].ID})
+
+	names := []string{team.Schedules[0].Name, team.Schedules[1].Name}
+	assert.ElementsMatch(t, []string{"Weekend Coverage", "Weekday Evening"}, names)
+
+	for _, sched := range team.Schedules {
+		if sched.Name == "Weekend Coverage" {
+			assert.Equal(t, []string{"Alice", "Bob"}, sched.Members)
+			assert.ElementsMatch(t, []time.Weekday{time.Saturday, time.Sunday}, sched.Days)
+			assert.Equal(t, "09:00", sched.Start.Format("15:04"))
+			assert.Equal(t, "17:00", sched.End.Format("15:04"))
+			assert.Equal(t, weekend.Description, sched.Description)
+			assert.Equal(t, weekend.Notes, sched.Notes)
+		}
+	}
+}
+
+func testGetTeamNotFound(t *testing.T, s storage.Storage) {
+	team, err := s.GetTeam(context.Background(), "non-existent-team")
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+	assert.Empty(t, team.Schedules)
+}
+
+func testCurrentOncallByDay(t *testing.T, s storage.Storage) {
+	weekdays := Schedule(t, "Weekday Coverage", []string{"Alice", "Bob"}, "9:00AM", "5:00PM",
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+	_, err := s.AddSchedule(context.Background(), "backend-team", weekdays)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected string
+		found    bool
+	}{
+		{"monday morning", time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC), "Alice", true},
+		{"friday afternoon", time.Date(2025, 5, 2, 14, 0, 0, 0, time.UTC), "Alice", true},
+		{"at start", time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC), "Alice", true},
+		{"just before end", time.Date(2025, 4, 28, 16, 59, 59, 0, time.UTC), "Alice", true},
+		{"saturday", time.Date(2025, 4, 26, 10, 0, 0, 0, time.UTC), "", false},
+		{"too early", time.Date(2025, 4, 28, 8, 59, 59, 0, time.UTC), "", false},
+		{"too late", time.Date(2025, 4, 28, 18, 0, 0, 0, time.UTC), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oncall, err := s.GetCurrentOncall(context.Background(), "backend-team", tt.at)
+			if tt.found {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, storage.ErrNoCoverage)
+			}
+			assert.Equal(t, tt.expected, oncall)
+		})
+	}
+}
+
+func testCurrentOncallTeamNotFound(t *testing.T, s storage.Storage) {
+	oncall, err := s.GetCurrentOncall(context.Background(), "non-existent-team", time.Now())
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+	assert.True(t, storage.NobodyOnCall(err))
+	assert.Empty(t, oncall)
+}
+
+func testCurrentOncallZoneIndependent(t *testing.T, s storage.Storage) {
+	monday := Schedule(t, "Monday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	_, err := s.AddSchedule(context.Background(), "backend-team", monday)
+	require.NoError(t, err)
+
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	require.NoError(t, err)
+
+	// Monday 15:00 UTC is 18:30 in Tehran
+	instant := time.Date(2025, 4, 28, 15, 0, 0, 0, time.UTC)
+
+	for _, at := range []time.Time{instant, instant.In(tehran)} {
+		oncall, err := s.GetCurrentOncall(context.Background(), "backend-team", at)
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", oncall)
+	}
+}
+
+func testCurrentOncallAdjacentSchedules(t *testing.T, s storage.Storage) {
+	day := Schedule(t, "Day", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	evening := Schedule(t, "Evening", []string{"Bob"}, "5:00PM", "11:00PM", time.Monday)
+
+	_, err := s.AddSchedule(context.Background(), "backend-team", day)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(context.Background(), "backend-team", evening)
+	require.NoError(t, err)
+
+	oncall, err := s.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 4, 28, 16, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+
+	oncall, err = s.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 4, 28, 20, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", oncall)
+}
+
+// testCurrentOncallOverlappingSchedules checks the schedule added first
+// answers whenever several are running, whatever they recur on, and that
+// shifts end right before their end.
+func testCurrentOncallOverlappingSchedules(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	monday := time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+
+	early := Schedule(t, "Early", []string{"Carol"}, "6:00AM", "10:00AM")
+	early.Cron = "0 6 * * MON"
+	_, err := s.AddSchedule(ctx, "backend-team", early)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Primary", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Fallback", []string{"Bob"}, "12:00AM", "11:00PM", time.Monday))
+	require.NoError(t, err)
+
+	tests := []struct {
+		at   time.Duration
+		want string
+	}{
+		{7 * time.Hour, "Carol"},
+		{9*time.Hour + 30*time.Minute, "Carol"},
+		{10 * time.Hour, "Alice"},
+		{16*time.Hour + 59*time.Minute, "Alice"},
+		{17 * time.Hour, "Bob"},
+	}
+	for _, tt := range tests {
+		oncall, err := s.GetCurrentOncall(ctx, "backend-team", monday.Add(tt.at))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, oncall, "at %s", monday.Add(tt.at))
+	}
+}
+
+func testCurrentOncallCron(t *testing.T, s storage.Storage) {
+	firstMonday := Schedule(t, "First Monday", []string{"Alice"}, "9:00AM", "5:00PM")
+	firstMonday.Cron = "0 9 1-7 * MON"
+	_, err := s.AddSchedule(context.Background(), "backend-team", firstMonday)
+	require.NoError(t, err)
+
+	oncall, err := s.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 6, 2, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+
+	_, err = s.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 6, 9, 10, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, storage.ErrNoCoverage)
+}
+
+func testCurrentOncallRRule(t *testing.T, s storage.Storage) {
+	biweekly := Schedule(t, "Biweekly", []string{"Alice"}, "9:00AM", "5:00PM")
+	biweekly.RRule = "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE"
+	biweekly.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	_, err := s.AddSchedule(context.Background(), "backend-team", biweekly)
+	require.NoError(t, err)
+
+	oncall, err := s.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 5, 14, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+
+	_, err = s.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, storage.ErrNoCoverage)
+}
+
+func testScheduleValidUntil(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	temporary := Schedule(t, "Temporary", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	temporary.ValidUntil = time.Date(2025, 5, 5, 12, 0, 0, 0, time.UTC)
+	_, err := s.AddSchedule(ctx, "backend-team", temporary)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Fallback", []string{"Bob"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+
+	oncall, err := s.GetCurrentOncall(ctx, "backend-team", time.Date(2025, 5, 5, 11, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+
+	// The schedule no longer covers anything once it ended
+	oncall, err = s.GetCurrentOncall(ctx, "backend-team", time.Date(2025, 5, 5, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", oncall)
+
+	deleted, err := s.DeleteExpiredSchedules(ctx, time.Date(2025, 5, 5, 11, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	deleted, err = s.DeleteExpiredSchedules(ctx, time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 1)
+	assert.Equal(t, "Fallback", team.Schedules[0].Name)
+}
+
+func testCalendarTokens(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	team, err := s.AddCalendarToken(ctx, storage.CalendarToken{Team: "backend-team", Hash: "team-hash"})
+	require.NoError(t, err)
+	member, err := s.AddCalendarToken(ctx, storage.CalendarToken{Team: "backend-team", Member: "Alice", Hash: "member-hash", ExpiresAt: expiresAt})
+	require.NoError(t, err)
+	assert.NotEqual(t, team.ID, member.ID)
+
+	got, found, err := s.GetCalendarToken(ctx, "member-hash")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "Alice", got.Member)
+	assert.True(t, got.ExpiresAt.Equal(expiresAt))
+
+	got, found, err = s.GetCalendarToken(ctx, "team-hash")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.True(t, got.ExpiresAt.IsZero())
+
+	// Tokens are revoked through their own team only
+	deleted, err := s.DeleteCalendarToken(ctx, "frontend-team", team.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = s.DeleteCalendarToken(ctx, "backend-team", team.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	_, found, err = s.GetCalendarToken(ctx, "team-hash")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func testTeamMembers(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	// Teams must exist to have a roster
+	_, found, err := s.SetTeamMember(ctx, "backend-team", storage.TeamMember{Name: "Carol", Role: storage.MemberRoleObserver})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// Members of schedules join the roster
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Bob", "Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+
+	carol, found, err := s.SetTeamMember(ctx, "backend-team", storage.TeamMember{Name: "Carol", Role: storage.MemberRoleObserver})
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.False(t, carol.CreatedAt.IsZero())
+
+	members, err := s.ListTeamMembers(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+	assert.Equal(t, "Alice", members[0].Name)
+	assert.Equal(t, storage.MemberRoleMember, members[0].Role)
+	assert.Equal(t, "Carol", members[2].Name)
+	assert.Equal(t, storage.MemberRoleObserver, members[2].Role)
+
+	// Promoting a member keeps when they joined
+	promoted, found, err := s.SetTeamMember(ctx, "backend-team", storage.TeamMember{Name: "Carol", Role: storage.MemberRoleMember})
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.True(t, promoted.CreatedAt.Equal(carol.CreatedAt))
+
+	// Schedules keep the role of the known members
+	_, _, err = s.SetTeamMember(ctx, "backend-team", storage.TeamMember{Name: "Bob", Role: storage.MemberRoleObserver})
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekend", []string{"Bob"}, "9:00AM", "5:00PM", time.Saturday))
+	require.NoError(t, err)
+
+	members, err = s.ListTeamMembers(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, members, 3)
+	assert.Equal(t, storage.MemberRoleObserver, members[1].Role)
+	assert.Equal(t, storage.MemberRoleMember, members[2].Role)
+
+	removed, err := s.RemoveTeamMember(ctx, "backend-team", "Carol")
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = s.RemoveTeamMember(ctx, "backend-team", "Carol")
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	members, err = s.ListTeamMembers(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Len(t, members, 2)
+
+	members, err = s.ListTeamMembers(ctx, "unknown-team")
+	require.NoError(t, err)
+	assert.Empty(t, members)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, storage.AuditRemoveTeamMember, entries[len(entries)-1].Action)
+	assert.Equal(t, "Carol", entries[len(entries)-1].Detail)
+}
+
+func testUnavailability(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	later, err := s.AddUnavailability(ctx, storage.Unavailability{Member: "Alice", Reason: "vacation", Start: start.Add(24 * time.Hour), End: start.Add(48 * time.Hour)})
+	require.NoError(t, err)
+	sick, err := s.AddUnavailability(ctx, storage.Unavailability{Member: "Alice", Reason: "sick", Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.NotEqual(t, later.ID, sick.ID)
+	assert.False(t, sick.CreatedAt.IsZero())
+	_, err = s.AddUnavailability(ctx, storage.Unavailability{Member: "Bob", Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	// Windows are ordered by start
+	windows, err := s.ListUnavailability(ctx, "Alice")
+	require.NoError(t, err)
+	require.Len(t, windows, 2)
+	assert.Equal(t, "sick", windows[0].Reason)
+	assert.True(t, windows[0].Start.Equal(start))
+	assert.True(t, windows[0].End.Equal(start.Add(time.Hour)))
+	assert.Equal(t, "vacation", windows[1].Reason)
+
+	unavailable, err := s.UnavailableMembers(ctx, []string{"Alice", "Charlie"}, start.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice"}, unavailable)
+
+	// Windows end at their end
+	unavailable, err = s.UnavailableMembers(ctx, []string{"Alice", "Bob"}, start.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, unavailable)
+
+	canceled, err := s.CancelUnavailability(ctx, "Bob", sick.ID)
+	require.NoError(t, err)
+	assert.False(t, canceled)
+
+	canceled, err = s.CancelUnavailability(ctx, "Alice", sick.ID)
+	require.NoError(t, err)
+	assert.True(t, canceled)
+
+	unavailable, err = s.UnavailableMembers(ctx, []string{"Alice"}, start.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, unavailable)
+
+	windows, err = s.ListUnavailability(ctx, "Alice")
+	require.NoError(t, err)
+	require.Len(t, windows, 1)
+	assert.Equal(t, later.ID, windows[0].ID)
+}
+
+func testFreezes(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	_, found, err := s.AddFreeze(ctx, "backend-team", storage.Freeze{Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+
+	later, found, err := s.AddFreeze(ctx, "backend-team", storage.Freeze{Reason: "release", Start: start.Add(24 * time.Hour), End: start.Add(48 * time.Hour)})
+	require.NoError(t, err)
+	require.True(t, found)
+	incident, _, err := s.AddFreeze(ctx, "backend-team", storage.Freeze{Reason: "incident", Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.NotEqual(t, later.ID, incident.ID)
+	assert.False(t, incident.CreatedAt.IsZero())
+
+	// Freezes are ordered by start
+	freezes, err := s.ListFreezes(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, freezes, 2)
+	assert.Equal(t, "incident", freezes[0].Reason)
+	assert.True(t, freezes[0].Start.Equal(start))
+	assert.True(t, freezes[0].End.Equal(start.Add(time.Hour)))
+	assert.Equal(t, "release", freezes[1].Reason)
+
+	canceled, err := s.CancelFreeze(ctx, "backend-team", incident.ID)
+	require.NoError(t, err)
+	assert.True(t, canceled)
+
+	canceled, err = s.CancelFreeze(ctx, "backend-team", incident.ID)
+	require.NoError(t, err)
+	assert.False(t, canceled)
+
+	canceled, err = s.CancelFreeze(ctx, "frontend-team", later.ID)
+	require.NoError(t, err)
+	assert.False(t, canceled)
+
+	freezes, err = s.ListFreezes(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, freezes, 1)
+	assert.Equal(t, later.ID, freezes[0].ID)
+
+	require.NoError(t, s.RecordAudit(storage.WithActor(ctx, "admin"), "backend-team", storage.AuditOverrideFreeze, "create schedule Weekend"))
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, storage.AuditFreezeTeam, entries[0].Action)
+	assert.Equal(t, storage.AuditCancelFreeze, entries[2].Action)
+	assert.Equal(t, storage.AuditOverrideFreeze, entries[3].Action)
+	assert.Equal(t, "admin", entries[3].Actor)
+	assert.Equal(t, "create schedule Weekend", entries[3].Detail)
+}
+
+func testBlackouts(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	start := time.Date(2026, 12, 31, 18, 0, 0, 0, time.UTC)
+
+	_, found, err := s.AddBlackout(ctx, "backend-team", storage.Blackout{Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+
+	later, found, err := s.AddBlackout(ctx, "backend-team", storage.Blackout{Reason: "new year", Start: start.Add(24 * time.Hour), End: start.Add(72 * time.Hour)})
+	require.NoError(t, err)
+	require.True(t, found)
+	eve, _, err := s.AddBlackout(ctx, "backend-team", storage.Blackout{Reason: "eve", Start: start, End: start.Add(6 * time.Hour)})
+	require.NoError(t, err)
+	assert.NotEqual(t, later.ID, eve.ID)
+	assert.False(t, eve.CreatedAt.IsZero())
+
+	// Blackouts are ordered by start
+	blackouts, err := s.ListBlackouts(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, blackouts, 2)
+	assert.Equal(t, "eve", blackouts[0].Reason)
+	assert.True(t, blackouts[0].Start.Equal(start))
+	assert.True(t, blackouts[0].End.Equal(start.Add(6*time.Hour)))
+	assert.Equal(t, "new year", blackouts[1].Reason)
+
+	canceled, err := s.CancelBlackout(ctx, "backend-team", eve.ID)
+	require.NoError(t, err)
+	assert.True(t, canceled)
+
+	canceled, err = s.CancelBlackout(ctx, "backend-team", eve.ID)
+	require.NoError(t, err)
+	assert.False(t, canceled)
+
+	canceled, err = s.CancelBlackout(ctx, "frontend-team", later.ID)
+	require.NoError(t, err)
+	assert.False(t, canceled)
+
+	blackouts, err = s.ListBlackouts(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, blackouts, 1)
+	assert.Equal(t, later.ID, blackouts[0].ID)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, storage.AuditAddBlackout, entries[0].Action)
+	assert.Equal(t, storage.AuditCancelBlackout, entries[2].Action)
+}
+
+func testManualRotation(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	monday := time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC)
+
+	weekday := Schedule(t, "Weekday", []string{"Alice", "Bob", "Charlie"}, "9:00AM", "5:00PM", time.Monday)
+	weekday.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	weekday.Manual = true
+	_, err := s.AddSchedule(ctx, "backend-team", weekday)
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+	assert.True(t, team.Schedules[0].Manual)
+
+	// Manual schedules keep their member whatever the time
+	for _, at := range []time.Time{monday, monday.AddDate(0, 0, 7), monday.AddDate(0, 0, 14)} {
+		oncall, err := s.GetCurrentOncall(ctx, "backend-team", at)
+		require.NoError(t, err)
+		assert.Equal(t, "Alice", oncall)
+	}
+
+	found, err := s.SetRotation(ctx, "backend-team", id, storage.Rotation{Manual: true, Anchor: weekday.Anchor, Offset: 1})
+	require.NoError(t, err)
+	require.True(t, found)
+
+	oncall, err := s.GetCurrentOncall(ctx, "backend-team", monday.AddDate(0, 0, 21))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", oncall)
+
+	// Rotating automatically again from a new anchor
+	anchor := time.Date(2025, 5, 19, 0, 0, 0, 0, time.UTC)
+	found, err = s.SetRotation(ctx, "backend-team", id, storage.Rotation{Anchor: anchor, Offset: 1})
+	require.NoError(t, err)
+	require.True(t, found)
+
+	team, err = s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.False(t, team.Schedules[0].Manual)
+	assert.True(t, team.Schedules[0].Anchor.Equal(anchor))
+	assert.Equal(t, 1, team.Schedules[0].RotationOffset)
+
+	oncall, err = s.GetCurrentOncall(ctx, "backend-team", anchor.Add(9*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", oncall)
+	oncall, err = s.GetCurrentOncall(ctx, "backend-team", anchor.AddDate(0, 0, 7).Add(9*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Charlie", oncall)
+
+	found, err = s.SetRotation(ctx, "backend-team", "999999", storage.Rotation{Manual: true})
+	require.NoError(t, err)
+	assert.False(t, found)
+	found, err = s.SetRotation(ctx, "frontend-team", id, storage.Rotation{Manual: true})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, storage.AuditSetRotation, entries[0].Action)
+	assert.Equal(t, "Weekday: manual, Bob on duty", entries[0].Detail)
+	assert.Equal(t, "Weekday: automatic from 2025-05-19, Bob on duty first", entries[1].Detail)
+}
+
+func testAPIKeys(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	deploy, err := s.AddAPIKey(ctx, storage.APIKey{Name: "deploy", Role: "admin", Hash: "deploy-hash"})
+	require.NoError(t, err)
+	dashboard, err := s.AddAPIKey(ctx, storage.APIKey{Name: "dashboard", Role: "reader", Hash: "dashboard-hash", ExpiresAt: expiresAt})
+	require.NoError(t, err)
+	assert.NotEqual(t, deploy.ID, dashboard.ID)
+	assert.False(t, deploy.CreatedAt.IsZero())
+
+	got, found, err := s.GetAPIKey(ctx, "dashboard-hash")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "dashboard", got.Name)
+	assert.Equal(t, "reader", got.Role)
+	assert.True(t, got.ExpiresAt.Equal(expiresAt))
+	assert.True(t, got.RevokedAt.IsZero())
+
+	_, found, err = s.GetAPIKey(ctx, "unknown-hash")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	revokedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	revoked, err := s.RevokeAPIKey(ctx, deploy.ID, revokedAt)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// Keys are revoked once
+	revoked, err = s.RevokeAPIKey(ctx, deploy.ID, revokedAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = s.RevokeAPIKey(ctx, dashboard.ID+100, revokedAt)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// Revoked keys are still found, so they fail as revoked rather than unknown
+	got, found, err = s.GetAPIKey(ctx, "deploy-hash")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.True(t, got.RevokedAt.Equal(revokedAt))
+	assert.False(t, got.Valid(revokedAt))
+
+	keys, err := s.ListAPIKeys(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "deploy", keys[0].Name)
+	assert.True(t, keys[0].RevokedAt.Equal(revokedAt))
+	assert.Equal(t, "dashboard", keys[1].Name)
+}
+
+func testScopedTokens(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	dashboard, err := s.AddScopedToken(ctx, storage.ScopedToken{
+		Name:      "dashboard",
+		Scopes:    []string{"oncall:read"},
+		Teams:     []string{"backend-team", "frontend-team"},
+		RateLimit: 60,
+		Hash:      "dashboard-hash",
+	})
+	require.NoError(t, err)
+	script, err := s.AddScopedToken(ctx, storage.ScopedToken{Name: "script", Scopes: []string{"oncall:read"}, Hash: "script-hash"})
+	require.NoError(t, err)
+	assert.NotEqual(t, dashboard.ID, script.ID)
+	assert.False(t, dashboard.CreatedAt.IsZero())
+
+	got, found, err := s.GetScopedToken(ctx, "dashboard-hash")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "dashboard", got.Name)
+	assert.Equal(t, []string{"oncall:read"}, got.Scopes)
+	assert.Equal(t, []string{"backend-team", "frontend-team"}, got.Teams)
+	assert.Equal(t, 60, got.RateLimit)
+	assert.Zero(t, got.Uses)
+	assert.True(t, got.LastUsedAt.IsZero())
+	assert.True(t, got.Valid())
+
+	_, found, err = s.GetScopedToken(ctx, "unknown-hash")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	usedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, s.RecordScopedTokenUse(ctx, dashboard.ID, usedAt))
+	require.NoError(t, s.RecordScopedTokenUse(ctx, dashboard.ID, usedAt.Add(time.Minute)))
+
+	got, _, err = s.GetScopedToken(ctx, "dashboard-hash")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), got.Uses)
+	assert.True(t, got.LastUsedAt.Equal(usedAt.Add(time.Minute)))
+
+	revoked, err := s.RevokeScopedToken(ctx, script.ID, usedAt)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	// Tokens are revoked once
+	revoked, err = s.RevokeScopedToken(ctx, script.ID, usedAt.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	revoked, err = s.RevokeScopedToken(ctx, dashboard.ID+100, usedAt)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	tokens, err := s.ListScopedTokens(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	assert.Equal(t, "dashboard", tokens[0].Name)
+	assert.Equal(t, "script", tokens[1].Name)
+	assert.Empty(t, tokens[1].Teams)
+	assert.True(t, tokens[1].RevokedAt.Equal(usedAt))
+	assert.False(t, tokens[1].Valid())
+}
+
+func testFindSchedulesByTags(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	add := func(team, name string, tags ...string) {
+		sched := Schedule(t, name, []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+		sched.Tags = tags
+		_, err := s.AddSchedule(ctx, team, sched)
+		require.NoError(t, err)
+	}
+	add("frontend-team", "EU Prod", "prod", "eu", "tier1")
+	add("backend-team", "US Prod", "tier1", "prod", "us")
+	add("backend-team", "EU Staging", "eu")
+	add("backend-team", "Untagged")
+
+	names := func(tags ...string) []string {
+		found, err := s.FindSchedulesByTags(ctx, tags)
+		require.NoError(t, err)
+
+		var result []string
+		for _, sched := range found {
+			result = append(result, sched.Team+"/"+sched.Schedule.Name)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"backend-team/US Prod", "frontend-team/EU Prod"}, names("prod"))
+	assert.Equal(t, []string{"backend-team/EU Staging", "frontend-team/EU Prod"}, names("eu"))
+	assert.Equal(t, []string{"frontend-team/EU Prod"}, names("prod", "eu"))
+	assert.Empty(t, names("prod", "staging"))
+
+	found, err := s.FindSchedulesByTags(ctx, []string{"us"})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, []string{"tier1", "prod", "us"}, found[0].Schedule.Tags)
+	assert.Equal(t, []string{"Alice"}, found[0].Schedule.Members)
+	assert.Equal(t, []time.Weekday{time.Monday}, found[0].Schedule.Days)
+}
+
+func testFindSchedulesByMembers(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	_, err := s.AddSchedule(ctx, "frontend-team", Schedule(t, "Weekday", []string{"Bob", "Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Alice", "Charlie", "Bob"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekend", []string{"Charlie"}, "9:00AM", "5:00PM", time.Saturday))
+	require.NoError(t, err)
+
+	matches := func(members ...string) []string {
+		found, err := s.FindSchedulesByMembers(ctx, members)
+		require.NoError(t, err)
+
+		var result []string
+		for _, m := range found {
+			result = append(result, fmt.Sprintf("%s/%s/%s@%d", m.Team, m.Schedule.Name, m.Member, m.Position))
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"backend-team/Weekday/Bob@2", "frontend-team/Weekday/Bob@0"}, matches("Bob"))
+	assert.Equal(t, []string{
+		"backend-team/Weekday/Charlie@1",
+		"backend-team/Weekday/Bob@2",
+		"backend-team/Weekend/Charlie@0",
+		"frontend-team/Weekday/Bob@0",
+	}, matches("Bob", "Charlie"))
+	assert.Empty(t, matches("Zed"))
+
+	found, err := s.FindSchedulesByMembers(ctx, []string{"Alice"})
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, []string{"Alice", "Charlie", "Bob"}, found[0].Schedule.Members)
+	assert.Equal(t, []time.Weekday{time.Monday}, found[0].Schedule.Days)
+}
+
+func testRotation(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	weekday := Schedule(t, "Weekday", []string{"Alice", "Bob", "Charlie", "Dana"}, "9:00AM", "5:00PM", time.Monday, time.Friday)
+	weekday.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	weekday.RotationOffset = 2
+	_, err := s.AddSchedule(ctx, "backend-team", weekday)
+	require.NoError(t, err)
+
+	night := Schedule(t, "Night", []string{"Erin", "Frank"}, "10:00PM", "11:00PM")
+	night.Cron = "0 22 * * *"
+	night.Anchor = weekday.Anchor
+	_, err = s.AddSchedule(ctx, "backend-team", night)
+	require.NoError(t, err)
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC), "Charlie"},
+		{time.Date(2025, 5, 2, 10, 0, 0, 0, time.UTC), "Charlie"},
+		{time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC), "Dana"},
+		{time.Date(2025, 5, 12, 10, 0, 0, 0, time.UTC), "Alice"},
+		{time.Date(2025, 4, 21, 10, 0, 0, 0, time.UTC), "Bob"},
+		{time.Date(2025, 4, 29, 22, 30, 0, 0, time.UTC), "Erin"},
+		{time.Date(2025, 5, 6, 22, 30, 0, 0, time.UTC), "Frank"},
+	}
+
+	for _, tt := range tests {
+		got, err := s.GetCurrentOncall(ctx, "backend-team", tt.at)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.at.String())
+	}
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Equal(t, 2, team.Schedules[0].RotationOffset)
+}
+
+func testRequiredCount(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	weekday := Schedule(t, "Weekday", []string{"Alice", "Bob", "Charlie"}, "9:00AM", "5:00PM", time.Monday, time.Friday)
+	weekday.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	weekday.RequiredCount = 2
+	_, err := s.AddSchedule(ctx, "backend-team", weekday)
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 1)
+	assert.Equal(t, 2, team.Schedules[0].RequiredCount)
+
+	members, found := storage.OncallsAt(team.Schedules, time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC))
+	require.True(t, found)
+	assert.Equal(t, []string{"Bob", "Charlie"}, members)
+
+	// The first member is the one the lookup answers with
+	got, err := s.GetCurrentOncall(ctx, "backend-team", time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", got)
+}
+
+func testDayAssignments(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	fixed := Schedule(t, "Fixed", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Monday, time.Tuesday, time.Wednesday)
+	fixed.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	fixed.DayAssignments = map[time.Weekday]string{
+		time.Monday:    "Alice",
+		time.Tuesday:   "Bob",
+		time.Wednesday: "Alice",
+	}
+	_, err := s.AddSchedule(ctx, "backend-team", fixed)
+	require.NoError(t, err)
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC), "Alice"},
+		{time.Date(2025, 4, 29, 10, 0, 0, 0, time.UTC), "Bob"},
+		{time.Date(2025, 4, 30, 10, 0, 0, 0, time.UTC), "Alice"},
+		// Assignments do not rotate from week to week
+		{time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), "Bob"},
+		{time.Date(2025, 6, 3, 10, 0, 0, 0, time.UTC), "Bob"},
+	}
+
+	for _, tt := range tests {
+		got, err := s.GetCurrentOncall(ctx, "backend-team", tt.at)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.at.String())
+	}
+
+	_, err = s.GetCurrentOncall(ctx, "backend-team", time.Date(2025, 5, 1, 10, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, storage.ErrNoCoverage)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 1)
+	assert.Equal(t, fixed.DayAssignments, team.Schedules[0].DayAssignments)
+	assert.Equal(t, []string{"Alice", "Bob"}, team.Schedules[0].Members)
+}
+
+func testShiftPins(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	hourly := Schedule(t, "Hourly", []string{"Alice"}, "12:00AM", "1:00AM")
+	hourly.Cron = "0 * * * *"
+	_, err := s.AddSchedule(ctx, "backend-team", hourly)
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+
+	shift := time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC)
+	pin, found, err := s.AddPin(ctx, "backend-team", id, storage.Pin{Date: shift, ShiftStart: shift, Member: "Dana"})
+	require.NoError(t, err)
+	require.True(t, found)
+
+	got, err := s.GetSchedule(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, got.Schedule.Pins, 1)
+	assert.Equal(t, pin.ID, got.Schedule.Pins[0].ID)
+	assert.True(t, got.Schedule.Pins[0].ShiftStart.Equal(shift))
+
+	// Only the shift starting at it is pinned, the others of the date are not
+	for at, want := range map[time.Time]string{
+		shift.Add(-time.Minute):   "Alice",
+		shift.Add(time.Minute):    "Dana",
+		shift.Add(time.Hour):      "Alice",
+		shift.Add(2 * time.Hour):  "Alice",
+		shift.Add(-9 * time.Hour): "Alice",
+	} {
+		oncall, err := s.GetCurrentOncall(ctx, "backend-team", at)
+		require.NoError(t, err)
+		assert.Equal(t, want, oncall, at)
+	}
+}
+
+func testPins(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	daily := Schedule(t, "Daily", []string{"Alice", "Bob", "Charlie"}, "9:00AM", "5:00PM",
+		time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday)
+	daily.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	_, err := s.AddSchedule(ctx, "backend-team", daily)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "frontend-team", Schedule(t, "Other", []string{"Erin"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+	require.NotEmpty(t, id)
+
+	got, err := s.GetSchedule(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "backend-team", got.Team)
+	assert.Equal(t, "Daily", got.Schedule.Name)
+
+	_, err = s.GetSchedule(ctx, "999999")
+	require.ErrorIs(t, err, storage.ErrScheduleNotFound)
+
+	// Monday of the second week is the first day of Bob's turn
+	boundary := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	pin, found, err := s.AddPin(ctx, "backend-team", id, storage.Pin{Date: boundary, Member: "Alice"})
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.NotZero(t, pin.ID)
+
+	// A later pin of the same date replaces it
+	pin, found, err = s.AddPin(ctx, "backend-team", id, storage.Pin{Date: boundary.Add(10 * time.Hour), Member: "Dana"})
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.True(t, pin.Date.Equal(boundary))
+
+	_, found, err = s.AddPin(ctx, "frontend-team", id, storage.Pin{Date: boundary, Member: "Dana"})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2025, 5, 4, 10, 0, 0, 0, time.UTC), "Alice"},
+		{time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC), "Dana"},
+		// The pin does not shift the turns after it
+		{time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), "Bob"},
+		{time.Date(2025, 5, 12, 10, 0, 0, 0, time.UTC), "Charlie"},
+	}
+
+	for _, tt := range tests {
+		got, err := s.GetCurrentOncall(ctx, "backend-team", tt.at)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, tt.at.String())
+	}
+
+	got, err = s.GetSchedule(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, got.Schedule.Pins, 1)
+	assert.Equal(t, "Dana", got.Schedule.Pins[0].Member)
+	assert.True(t, got.Schedule.Pins[0].Date.Equal(boundary))
+
+	deleted, err := s.DeletePin(ctx, "frontend-team", id, pin.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = s.DeletePin(ctx, "backend-team", id, pin.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	oncall, err := s.GetCurrentOncall(ctx, "backend-team", time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", oncall)
+
+	deleted, err = s.DeletePin(ctx, "backend-team", id, pin.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
+
+func testSwapRequests(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	daily := Schedule(t, "Daily", []string{"Alice", "Bob"}, "9:00AM", "5:00PM",
+		time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday)
+	daily.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	_, err := s.AddSchedule(ctx, "backend-team", daily)
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+
+	shift := func(day int) storage.SwapRequest {
+		return storage.SwapRequest{
+			ScheduleID: id,
+			ShiftStart: time.Date(2025, 5, day, 9, 0, 0, 0, time.UTC),
+			ShiftEnd:   time.Date(2025, 5, day, 17, 0, 0, 0, time.UTC),
+			From:       "Alice",
+			To:         "Bob",
+			TokenHash:  fmt.Sprintf("hash-%d", day),
+		}
+	}
+
+	_, found, err := s.AddSwapRequest(ctx, "frontend-team", shift(1))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	accepted, found, err := s.AddSwapRequest(ctx, "backend-team", shift(1))
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.NotZero(t, accepted.ID)
+	assert.Equal(t, storage.SwapPending, accepted.Status)
+
+	declined, _, err := s.AddSwapRequest(ctx, "backend-team", shift(2))
+	require.NoError(t, err)
+	expired, _, err := s.AddSwapRequest(ctx, "backend-team", shift(3))
+	require.NoError(t, err)
+
+	got, found, err := s.GetSwapRequest(ctx, accepted.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "backend-team", got.Team)
+	assert.Equal(t, id, got.ScheduleID)
+	assert.Equal(t, "hash-1", got.TokenHash)
+	assert.True(t, got.ShiftStart.Equal(time.Date(2025, 5, 1, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, got.DecidedAt.IsZero())
+
+	_, found, err = s.GetSwapRequest(ctx, 999999)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	before := time.Date(2025, 4, 30, 12, 0, 0, 0, time.UTC)
+
+	decided, found, err := s.DecideSwapRequest(storage.WithActor(ctx, "Bob"), accepted.ID, true, before)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, storage.SwapAccepted, decided.Status)
+	assert.True(t, decided.DecidedAt.Equal(before))
+
+	_, _, err = s.DecideSwapRequest(ctx, accepted.ID, false, before)
+	require.ErrorIs(t, err, storage.ErrSwapDecided)
+
+	decided, _, err = s.DecideSwapRequest(ctx, declined.ID, false, before)
+	require.NoError(t, err)
+	assert.Equal(t, storage.SwapDeclined, decided.Status)
+
+	// Pending requests expire when their shift starts
+	_, _, err = s.DecideSwapRequest(ctx, expired.ID, true, time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, storage.ErrSwapDecided)
+
+	_, found, err = s.DecideSwapRequest(ctx, 999999, true, before)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// Only the accepted request put its target on duty
+	for day, want := range map[int]string{1: "Bob", 2: "Alice", 3: "Alice"} {
+		oncall, err := s.GetCurrentOncall(ctx, "backend-team", time.Date(2025, 5, day, 10, 0, 0, 0, time.UTC))
+		require.NoError(t, err)
+		assert.Equal(t, want, oncall, day)
+	}
+
+	requests, err := s.ListSwapRequests(ctx, "backend-team", id)
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+	assert.Equal(t, accepted.ID, requests[0].ID)
+	assert.Equal(t, storage.SwapDeclined, requests[1].Status)
+	assert.Equal(t, storage.SwapPending, requests[2].Status)
+	assert.Equal(t, storage.SwapExpired, requests[2].StatusAt(time.Date(2025, 5, 3, 9, 0, 0, 0, time.UTC)))
+
+	requests, err = s.ListSwapRequests(ctx, "frontend-team", id)
+	require.NoError(t, err)
+	assert.Empty(t, requests)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	last := entries[len(entries)-1]
+	assert.Equal(t, storage.AuditAcceptSwap, last.Action)
+	assert.Equal(t, "Bob", last.Actor)
+}
+
+func testListTeams(t *testing.T, s storage.Storage) {
+	names, err := s.ListTeams(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	monday := Schedule(t, "Monday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	_, err = s.AddSchedule(context.Background(), "frontend-team", monday)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(context.Background(), "backend-team", monday)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(context.Background(), "backend-team", Schedule(t, "Tuesday", []string{"Alice"}, "9:00AM", "5:00PM", time.Tuesday))
+	require.NoError(t, err)
+
+	names, err = s.ListTeams(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"backend-team", "frontend-team"}, names)
+}
+
+func testDeleteTeam(t *testing.T, s storage.Storage) {
+	require.ErrorIs(t, s.DeleteTeam(context.Background(), "backend-team"), storage.ErrTeamNotFound)
+
+	monday := Schedule(t, "Monday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	_, err := s.AddSchedule(context.Background(), "backend-team", monday)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(context.Background(), "frontend-team", monday)
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteTeam(context.Background(), "backend-team"))
+
+	_, err = s.GetTeam(context.Background(), "backend-team")
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+
+	_, err = s.GetCurrentOncall(context.Background(), "backend-team", time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC))
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+
+	names, err := s.ListTeams(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"frontend-team"}, names)
+}
+
+func testDeleteTeamRecreated(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	monday := Schedule(t, "Monday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	_, err := s.AddSchedule(ctx, "backend-team", monday)
+	require.NoError(t, err)
+
+	require.NoError(t, s.SetTeamParent(ctx, "backend-team", "platform"))
+	require.NoError(t, s.SetTeamParent(ctx, "payments", "backend-team"))
+	require.NoError(t, s.SetPublicCoverage(ctx, "backend-team", true))
+	require.NoError(t, s.SetGoogleCalendar(ctx, "backend-team", "backend@group.calendar.google.com"))
+	_, err = s.AddCalendarToken(ctx, storage.CalendarToken{Team: "backend-team", Hash: "backend-hash"})
+	require.NoError(t, err)
+	_, err = s.AddCalendarToken(ctx, storage.CalendarToken{Team: "frontend-team", Hash: "frontend-hash"})
+	require.NoError(t, err)
+	_, err = s.AddWebhook(ctx, storage.Webhook{Team: "backend-team", URL: "https://backend.example.com/hook"})
+	require.NoError(t, err)
+	_, err = s.AddWebhook(ctx, storage.Webhook{URL: "https://example.com/hook"})
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteTeam(ctx, "backend-team"))
+
+	// A team created with the name starts afresh
+	_, err = s.AddSchedule(ctx, "backend-team", monday)
+	require.NoError(t, err)
+
+	_, found, err := s.TeamParent(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = s.TeamParent(ctx, "payments")
+	require.NoError(t, err)
+	assert.False(t, found, "children no longer inherit from the deleted team")
+
+	public, err := s.PublicCoverage(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.False(t, public)
+
+	_, found, err = s.GoogleCalendar(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, found, err = s.GetCalendarToken(ctx, "backend-hash")
+	require.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = s.GetCalendarToken(ctx, "frontend-hash")
+	require.NoError(t, err)
+	assert.True(t, found, "tokens of other teams are kept")
+
+	webhooks, err := s.ListWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1, "webhooks of every team are kept")
+	assert.Empty(t, webhooks[0].Team)
+}
+
+func testPauseTeam(t *testing.T, s storage.Storage) {
+	ctx := storage.WithActor(context.Background(), "alice")
+
+	found, err := s.PauseTeam(ctx, "backend-team", storage.Pause{Since: time.Now()})
+	require.NoError(t, err)
+	assert.False(t, found, "unknown teams cannot be paused")
+
+	monday := Schedule(t, "Monday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	_, err = s.AddSchedule(ctx, "backend-team", monday)
+	require.NoError(t, err)
+
+	_, found, err = s.GetPause(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	pause := storage.Pause{
+		Reason: "migration",
+		Since:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Until:  time.Date(2025, 4, 29, 0, 0, 0, 0, time.UTC),
+	}
+	found, err = s.PauseTeam(ctx, "backend-team", pause)
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	got, found, err := s.GetPause(ctx, "backend-team")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "migration", got.Reason)
+	assert.True(t, pause.Since.Equal(got.Since))
+	assert.True(t, pause.Until.Equal(got.Until))
+
+	found, err = s.UnpauseTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	_, found, err = s.GetPause(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, storage.AuditPauseTeam, entries[0].Action)
+	assert.Equal(t, storage.AuditUnpauseTeam, entries[1].Action)
+	for _, entry := range entries {
+		assert.Equal(t, "alice", entry.Actor)
+		assert.Equal(t, "backend-team", entry.Team)
+	}
+}
+
+// Schedule builds a schedule from Kitchen formatted start and end times.
+func Schedule(t testing.TB, name string, members []string, start, end string, days ...time.Weekday) storage.Schedule {
+	t.Helper()
+
+	startTime, err := time.Parse(time.Kitchen, start)
+	require.NoError(t, err)
+
+	endTime, err := time.Parse(time.Kitchen, end)
+	require.NoError(t, err)
+
+	return storage.Schedule{
+		Name:    name,
+		Members: members,
+		Days:    days,
+		Start:   startTime,
+		End:     endTime,
+	}
+}
+
+func testTeamMemberSchedules(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	saturday := time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC)
+
+	_, err := s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	_, _, err = s.SetTeamMember(ctx, "backend-team", storage.TeamMember{Name: "Bob", Role: storage.MemberRoleMember})
+	require.NoError(t, err)
+	_, _, err = s.SetTeamMember(ctx, "backend-team", storage.TeamMember{Name: "Carol", Role: storage.MemberRoleObserver})
+	require.NoError(t, err)
+
+	everyone := Schedule(t, "Everyone", nil, "9:00AM", "5:00PM", time.Saturday)
+	everyone.TeamMembers = true
+	_, err = s.AddSchedule(ctx, "backend-team", everyone)
+	require.NoError(t, err)
+
+	members := func() []string {
+		t.Helper()
+
+		team, err := s.GetTeam(ctx, "backend-team")
+		require.NoError(t, err)
+		require.Len(t, team.Schedules, 2)
+		assert.False(t, team.Schedules[0].TeamMembers)
+		assert.True(t, team.Schedules[1].TeamMembers)
+
+		return team.Schedules[1].Members
+	}
+
+	// Members take turns in the order they joined, observers are left out
+	assert.Equal(t, []string{"Alice", "Bob"}, members())
+
+	oncall, err := s.GetCurrentOncall(ctx, "backend-team", saturday)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall)
+
+	_, _, err = s.SetTeamMember(ctx, "backend-team", storage.TeamMember{Name: "Dave", Role: storage.MemberRoleMember})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice", "Bob", "Dave"}, members())
+
+	// Promoting an observer keeps when they joined
+	_, _, err = s.SetTeamMember(ctx, "backend-team", storage.TeamMember{Name: "Carol", Role: storage.MemberRoleMember})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice", "Bob", "Carol", "Dave"}, members())
+
+	removed, err := s.RemoveTeamMember(ctx, "backend-team", "Alice")
+	require.NoError(t, err)
+	require.True(t, removed)
+	assert.Equal(t, []string{"Bob", "Carol", "Dave"}, members())
+
+	oncall, err = s.GetCurrentOncall(ctx, "backend-team", saturday)
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", oncall)
+}
+
+func testGroups(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	saturday := time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC)
+
+	_, found, err := s.SetGroup(ctx, "backend-team", storage.Group{Name: "storage", Members: []string{"Alice"}})
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+
+	group, found, err := s.SetGroup(ctx, "backend-team", storage.Group{Name: "storage", Members: []string{"Bob", "Carol"}})
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []string{"Bob", "Carol"}, group.Members)
+	assert.False(t, group.CreatedAt.IsZero())
+
+	_, _, err = s.SetGroup(ctx, "backend-team", storage.Group{Name: "network", Members: []string{"Dave"}})
+	require.NoError(t, err)
+
+	storageGroup := Schedule(t, "Storage", []string{"Erin"}, "9:00AM", "5:00PM", time.Saturday)
+	storageGroup.MemberRefs = []string{storage.GroupPrefix + "storage", "Erin"}
+	_, err = s.AddSchedule(ctx, "backend-team", storageGroup)
+	require.NoError(t, err)
+
+	schedule := func() storage.Schedule {
+		t.Helper()
+
+		team, err := s.GetTeam(ctx, "backend-team")
+		require.NoError(t, err)
+		require.Len(t, team.Schedules, 2)
+
+		return team.Schedules[1]
+	}
+
+	// The references are kept, the members are those of the groups
+	sched := schedule()
+	assert.Equal(t, []string{storage.GroupPrefix + "storage", "Erin"}, sched.MemberRefs)
+	assert.Equal(t, []string{"Bob", "Carol", "Erin"}, sched.Members)
+
+	oncall, err := s.GetCurrentOncall(ctx, "backend-team", saturday)
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", oncall)
+
+	// Members who stay keep their place, former ones stay until the next handoff
+	_, _, err = s.SetGroup(ctx, "backend-team", storage.Group{Name: "storage", Members: []string{"Frank", "Carol"}})
+	require.NoError(t, err)
+	sched = schedule()
+	assert.Equal(t, []string{"Bob", "Carol", "Frank", "Erin"}, sched.Members)
+	assert.Contains(t, sched.Left, "Bob")
+	assert.Contains(t, sched.Joined, "Frank")
+
+	groups, err := s.ListGroups(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	assert.Equal(t, "network", groups[0].Name)
+	assert.Equal(t, []string{"Dave"}, groups[0].Members)
+	assert.Equal(t, "storage", groups[1].Name)
+	assert.Equal(t, []string{"Carol", "Frank"}, groups[1].Members)
+
+	_, err = s.DeleteGroup(ctx, "backend-team", "storage")
+	require.ErrorIs(t, err, storage.ErrGroupInUse)
+
+	deleted, err := s.DeleteGroup(ctx, "backend-team", "network")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = s.DeleteGroup(ctx, "backend-team", "network")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	groups, err = s.ListGroups(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Len(t, groups, 1)
+}
+
+func testFindTeamsByMember(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	_, err := s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "frontend-team", Schedule(t, "Weekday", []string{"Bob"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "frontend-team", Schedule(t, "Weekend", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Saturday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "mobile-team", Schedule(t, "Weekday", []string{"Charlie"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+
+	// A pin makes an outside member part of the team
+	team, err := s.GetTeam(ctx, "mobile-team")
+	require.NoError(t, err)
+	_, found, err := s.AddPin(ctx, "mobile-team", team.Schedules[0].ID, storage.Pin{Date: time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC), Member: "Dana"})
+	require.NoError(t, err)
+	require.True(t, found)
+
+	for member, want := range map[string][]string{
+		"Alice": {"backend-team", "frontend-team"},
+		"Bob":   {"backend-team", "frontend-team"},
+		"Dana":  {"mobile-team"},
+		"alice": nil,
+		"Erin":  nil,
+	} {
+		teams, err := s.FindTeamsByMember(ctx, member)
+		require.NoError(t, err)
+		assert.Equal(t, want, teams, member)
+	}
+}
+
+func testUsers(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	weekly := Schedule(t, "Weekly", []string{"Alice", "Bob", "Charlie"}, "9:00AM", "5:00PM", time.Monday)
+	weekly.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	_, err := s.AddSchedule(ctx, "backend-team", weekly)
+	require.NoError(t, err)
+
+	fixed := Schedule(t, "Fixed", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Tuesday, time.Wednesday)
+	fixed.DayAssignments = map[time.Weekday]string{time.Tuesday: "Alice", time.Wednesday: "Bob"}
+	_, err = s.AddSchedule(ctx, "backend-team", fixed)
+	require.NoError(t, err)
+
+	// Members already in schedules can be provisioned
+	alice, added, err := s.AddUser(ctx, storage.User{UserName: "Alice", Emails: []string{"alice@example.org"}, Active: true})
+	require.NoError(t, err)
+	require.True(t, added)
+	assert.NotEmpty(t, alice.ID)
+	assert.False(t, alice.CreatedAt.IsZero())
+
+	_, added, err = s.AddUser(ctx, storage.User{UserName: "Alice", Active: true})
+	require.NoError(t, err)
+	assert.False(t, added)
+
+	_, added, err = s.AddUser(ctx, storage.User{UserName: "Dana", Active: true})
+	require.NoError(t, err)
+	require.True(t, added)
+
+	got, found, err := s.GetUser(ctx, alice.ID)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "Alice", got.UserName)
+	assert.Equal(t, []string{"alice@example.org"}, got.Emails)
+	assert.True(t, got.Active)
+
+	_, found, err = s.GetUser(ctx, "999999")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	users, err := s.FindUsers(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, "Alice", users[0].UserName)
+	assert.Equal(t, "Dana", users[1].UserName)
+
+	users, err = s.FindUsers(ctx, "Dana")
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+
+	users, err = s.FindUsers(ctx, "Bob")
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	oncall := func(at time.Time) string {
+		member, err := s.GetCurrentOncall(ctx, "backend-team", at)
+		if errors.Is(err, storage.ErrNoCoverage) {
+			return ""
+		}
+		require.NoError(t, err)
+		return member
+	}
+
+	monday := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)
+	tuesday := monday.AddDate(0, 0, 1)
+	assert.Equal(t, "Alice", oncall(monday))
+	assert.Equal(t, "Alice", oncall(tuesday))
+
+	// The rotation skips Alice while she is deactivated, and her fixed day is uncovered
+	got, found, err = s.SetUserActive(ctx, alice.ID, false)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.False(t, got.Active)
+
+	assert.Equal(t, "Bob", oncall(monday))
+	assert.Equal(t, "Bob", oncall(monday.AddDate(0, 0, 7)))
+	assert.Equal(t, "Charlie", oncall(monday.AddDate(0, 0, 14)))
+	assert.Empty(t, oncall(tuesday))
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice", "Bob", "Charlie"}, team.Schedules[0].Members)
+	assert.Equal(t, []string{"Alice"}, team.Schedules[0].Inactive)
+
+	// Schedules added later see the deactivation too
+	_, err = s.AddSchedule(ctx, "frontend-team", Schedule(t, "Weekly", []string{"Alice", "Erin"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	member, err := s.GetCurrentOncall(ctx, "frontend-team", monday)
+	require.NoError(t, err)
+	assert.Equal(t, "Erin", member)
+
+	_, _, err = s.SetUserActive(ctx, alice.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", oncall(monday))
+
+	_, found, err = s.SetUserActive(ctx, "999999", false)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func testUserTimezone(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	alice, added, err := s.AddUser(ctx, storage.User{UserName: "Alice", Active: true, Timezone: "Asia/Tehran"})
+	require.NoError(t, err)
+	require.True(t, added)
+	assert.Equal(t, "Asia/Tehran", alice.Timezone)
+
+	bob, _, err := s.AddUser(ctx, storage.User{UserName: "Bob", Active: true})
+	require.NoError(t, err)
+
+	got, _, err := s.GetUser(ctx, alice.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Asia/Tehran", got.Timezone)
+
+	loc, ok, err := storage.MemberLocation(ctx, s, "Alice")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "Asia/Tehran", loc.String())
+
+	// Members without a zone or a user are rendered in UTC
+	for _, member := range []string{"Bob", "Charlie"} {
+		loc, ok, err = storage.MemberLocation(ctx, s, member)
+		require.NoError(t, err)
+		assert.False(t, ok, member)
+		assert.Equal(t, time.UTC, loc)
+	}
+
+	got, found, err := s.SetUserTimezone(ctx, bob.ID, "America/New_York")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "America/New_York", got.Timezone)
+	assert.True(t, got.Active)
+
+	users, err := s.FindUsers(ctx, "Bob")
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "America/New_York", users[0].Timezone)
+
+	got, found, err = s.SetUserTimezone(ctx, alice.ID, "")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Empty(t, got.Timezone)
+
+	_, found, err = s.SetUserTimezone(ctx, "999999", "UTC")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func testQuotas(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	weekday := func(name string) storage.Schedule {
+		return Schedule(t, name, []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Monday, time.Tuesday)
+	}
+
+	// Schedules are counted per team up to exactly the limit
+	limited := storage.WithQuota(ctx, storage.QuotaSchedules, 2)
+	_, err := s.AddSchedule(limited, "backend-team", weekday("Weekday"))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(limited, "backend-team", weekday("Weekday 2"))
+	require.NoError(t, err)
+
+	var quotaErr *storage.QuotaError
+	_, err = s.AddSchedule(limited, "backend-team", weekday("Weekday 3"))
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, storage.QuotaSchedules, quotaErr.Resource)
+	assert.Equal(t, "backend-team", quotaErr.Team)
+	assert.Equal(t, 2, quotaErr.Current)
+	assert.Equal(t, 2, quotaErr.Limit)
+
+	_, err = s.AddSchedule(limited, "frontend-team", weekday("Weekday"))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", weekday("Weekday 3"))
+	require.NoError(t, err, "writes without a quota are not limited")
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 3)
+	first, second := team.Schedules[0].ID, team.Schedules[1].ID
+
+	// Pins are counted across the schedules of the team
+	limited = storage.WithQuota(ctx, storage.QuotaPins, 2)
+	monday := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	_, found, err := s.AddPin(limited, "backend-team", first, storage.Pin{Date: monday, Member: "Alice"})
+	require.NoError(t, err)
+	require.True(t, found)
+	_, _, err = s.AddPin(limited, "backend-team", second, storage.Pin{Date: monday, Member: "Bob"})
+	require.NoError(t, err)
+
+	_, _, err = s.AddPin(limited, "backend-team", first, storage.Pin{Date: monday.AddDate(0, 0, 1), Member: "Bob"})
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, storage.QuotaPins, quotaErr.Resource)
+	assert.Equal(t, 2, quotaErr.Current)
+
+	// Replacing the pin of a date does not add to the count
+	pin, _, err := s.AddPin(limited, "backend-team", first, storage.Pin{Date: monday, Member: "Bob"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", pin.Member)
+
+	// Webhooks are counted per team they subscribe to
+	limited = storage.WithQuota(ctx, storage.QuotaWebhooks, 1)
+	_, err = s.AddWebhook(limited, storage.Webhook{Team: "backend-team", URL: "https://example.org/a"})
+	require.NoError(t, err)
+	_, err = s.AddWebhook(limited, storage.Webhook{Team: "backend-team", URL: "https://example.org/b"})
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, storage.QuotaWebhooks, quotaErr.Resource)
+	assert.Equal(t, 1, quotaErr.Current)
+	_, err = s.AddWebhook(limited, storage.Webhook{Team: "frontend-team", URL: "https://example.org/a"})
+	require.NoError(t, err)
+
+	webhooks, err := s.ListWebhooks(ctx)
+	require.NoError(t, err)
+	assert.Len(t, webhooks, 2)
+}
+
+func testQuotasConcurrent(t *testing.T, s storage.Storage) {
+	ctx := storage.WithQuota(context.Background(), storage.QuotaSchedules, 5)
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		added    int
+		rejected int
+	)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			weekday := Schedule(t, fmt.Sprintf("Weekday %d", i), []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+			_, err := s.AddSchedule(ctx, "backend-team", weekday)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			var quotaErr *storage.QuotaError
+			switch {
+			case err == nil:
+				added++
+			case errors.As(err, &quotaErr):
+				rejected++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, added)
+	assert.Equal(t, 15, rejected)
+
+	team, err := s.GetTeam(context.Background(), "backend-team")
+	require.NoError(t, err)
+	assert.Len(t, team.Schedules, 5)
+}
+
+func testHandoff(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	allDay := Schedule(t, "Around the clock", []string{"Alice", "Bob"}, "12:00AM", "11:59PM",
+		time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday)
+	allDay.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	allDay.Handoff = &storage.Handoff{Day: time.Monday, Time: allDay.Start.Add(9 * time.Hour)}
+	_, err := s.AddSchedule(ctx, "backend-team", allDay)
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.NotNil(t, team.Schedules[0].Handoff)
+	assert.Equal(t, time.Monday, team.Schedules[0].Handoff.Day)
+	assert.Equal(t, "09:00", team.Schedules[0].Handoff.Time.Format("15:04"))
+
+	// The first week starts at the first handoff on or after the anchor
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2025, 4, 28, 8, 59, 0, 0, time.UTC), "Bob"},
+		{time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC), "Alice"},
+		{time.Date(2025, 5, 1, 22, 0, 0, 0, time.UTC), "Alice"},
+		{time.Date(2025, 5, 5, 8, 59, 59, 0, time.UTC), "Alice"},
+		{time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC), "Bob"},
+	}
+
+	for _, tt := range tests {
+		oncall, err := s.GetCurrentOncall(ctx, "backend-team", tt.at)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, oncall, tt.at)
+	}
+}
+
+func testRouting(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	routing := map[string]string{"pagerduty_service_id": "PXXXXXX", "slack_channel": "#backend"}
+
+	routed := Schedule(t, "Routed", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	routed.Routing = routing
+	_, err := s.AddSchedule(ctx, "backend-team", routed)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Unrouted", []string{"Bob"}, "9:00AM", "5:00PM", time.Tuesday))
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 2)
+	assert.Equal(t, routing, team.Schedules[0].Routing)
+	assert.Empty(t, team.Schedules[1].Routing)
+
+	sched, err := s.GetSchedule(ctx, team.Schedules[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, routing, sched.Schedule.Routing)
+
+	// The lookup answers from the schedule the routing belongs to
+	var found bool
+	sched.Schedule, found = storage.ScheduleAt(team.Schedules, time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC))
+	require.True(t, found)
+	assert.Equal(t, routing, sched.Schedule.Routing)
+}
+
+func testCompensation(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	compensation := &storage.Compensation{RateClass: "night", Multiplier: 1.5, WeekendRateClass: "weekend", WeekendMultiplier: 2}
+
+	paid := Schedule(t, "Paid", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	paid.Compensation = compensation
+	_, err := s.AddSchedule(ctx, "backend-team", paid)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Untagged", []string{"Bob"}, "9:00AM", "5:00PM", time.Tuesday))
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 2)
+	assert.Equal(t, compensation, team.Schedules[0].Compensation)
+	assert.Nil(t, team.Schedules[1].Compensation)
+
+	sched, err := s.GetSchedule(ctx, team.Schedules[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, compensation, sched.Schedule.Compensation)
+}
+
+func testTeamHistory(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	monday := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)
+
+	weekday := Schedule(t, "Weekday", []string{"Alice", "Bob", "Charlie"}, "9:00AM", "5:00PM", time.Monday)
+	weekday.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	weekday.Manual = true
+	_, err := s.AddSchedule(ctx, "backend-team", weekday)
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+
+	time.Sleep(10 * time.Millisecond)
+	before := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	found, err := s.SetRotation(ctx, "backend-team", id, storage.Rotation{Manual: true, Anchor: weekday.Anchor, Offset: 1})
+	require.NoError(t, err)
+	require.True(t, found)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Evening", []string{"Dana"}, "5:00PM", "11:00PM", time.Monday))
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	after := time.Now()
+
+	// The schedule as it was, without the one added later
+	history, err := s.TeamHistory(ctx, "backend-team", before, before)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.True(t, history[0].Since.Equal(before))
+	require.Len(t, history[0].Team.Schedules, 1)
+	assert.Equal(t, id, history[0].Team.Schedules[0].ID)
+	assert.Equal(t, 0, history[0].Team.Schedules[0].RotationOffset)
+
+	oncall, found := storage.OncallAt(history[0].Team.Schedules, monday)
+	require.True(t, found)
+	assert.Equal(t, "Alice", oncall)
+
+	history, err = s.TeamHistory(ctx, "backend-team", after, after)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Len(t, history[0].Team.Schedules, 2)
+	assert.Equal(t, "Evening", history[0].Team.Schedules[1].Name)
+
+	oncall, found = storage.OncallAt(history[0].Team.Schedules, monday)
+	require.True(t, found)
+	assert.Equal(t, "Bob", oncall)
+
+	// A range holds a version for every change within it
+	history, err = s.TeamHistory(ctx, "backend-team", before, after)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	assert.Len(t, history[1].Team.Schedules, 1)
+	assert.Len(t, history[2].Team.Schedules, 2)
+
+	_, err = s.TeamHistory(ctx, "no-such-team", before, after)
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+}
+
+func testTeamHistoryTombstones(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	legacy := Schedule(t, "Legacy", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	legacy.ValidUntil = time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	_, err := s.AddSchedule(ctx, "backend-team", legacy)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Bob"}, "9:00AM", "5:00PM", time.Tuesday))
+	require.NoError(t, err)
+
+	// Schedules versioned from their creation reach back to it
+	start, found, err := s.HistoryStart(ctx, "backend-team")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.True(t, start.IsZero())
+
+	_, found, err = s.HistoryStart(ctx, "no-such-team")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	time.Sleep(10 * time.Millisecond)
+	before := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	deleted, err := s.DeleteExpiredSchedules(ctx, time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+
+	time.Sleep(10 * time.Millisecond)
+	after := time.Now()
+
+	// The deleted schedule is part of the team until its deletion only
+	history, err := s.TeamHistory(ctx, "backend-team", before, after)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+
+	var names []string
+	for _, sched := range history[0].Team.Schedules {
+		names = append(names, sched.Name)
+	}
+	assert.Equal(t, []string{"Legacy", "Weekday"}, names)
+	require.Len(t, history[1].Team.Schedules, 1)
+	assert.Equal(t, "Weekday", history[1].Team.Schedules[0].Name)
+}
+
+func testScheduleVersions(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	weekday := Schedule(t, "Weekday", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Monday)
+	weekday.Anchor = time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC)
+	_, err := s.AddSchedule(ctx, "backend-team", weekday)
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Evening", []string{"Dana"}, "5:00PM", "11:00PM", time.Monday))
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+
+	time.Sleep(10 * time.Millisecond)
+	found, err := s.SetRotation(ctx, "backend-team", id, storage.Rotation{Manual: true, Anchor: weekday.Anchor, Offset: 1})
+	require.NoError(t, err)
+	require.True(t, found)
+
+	// Only the versions of the schedule, oldest first
+	versions, found, err := s.ScheduleVersions(ctx, id)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Len(t, versions, 2)
+	assert.True(t, versions[0].Since.Before(versions[1].Since))
+	for _, version := range versions {
+		assert.Equal(t, id, version.Schedule.ID)
+		assert.Equal(t, "Weekday", version.Schedule.Name)
+		assert.Equal(t, []string{"Alice", "Bob"}, version.Schedule.Members)
+	}
+	assert.False(t, versions[0].Schedule.Manual)
+	assert.Equal(t, 0, versions[0].Schedule.RotationOffset)
+	assert.True(t, versions[1].Schedule.Manual)
+	assert.Equal(t, 1, versions[1].Schedule.RotationOffset)
+
+	_, found, err = s.ScheduleVersions(ctx, "999999")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func testUpdateScheduleFields(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	monday := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+
+	_, err := s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+
+	// Only the given fields change
+	name := "Daytime"
+	updated, err := s.UpdateScheduleFields(ctx, "backend-team", id, storage.ScheduleUpdate{
+		Name:    &name,
+		Members: []string{"Bob"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, id, updated.ID)
+	assert.Equal(t, "Daytime", updated.Name)
+	assert.Equal(t, []string{"Bob"}, updated.Members)
+	assert.Equal(t, []time.Weekday{time.Monday}, updated.Days)
+	assert.Equal(t, 9, updated.Start.Hour())
+	assert.Equal(t, 17, updated.End.Hour())
+
+	team, err = s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, team.Schedules, 1)
+	assert.Equal(t, "Daytime", team.Schedules[0].Name)
+	assert.Equal(t, []string{"Bob"}, team.Schedules[0].Members)
+
+	member, err := s.GetCurrentOncall(ctx, "backend-team", monday.Add(10*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", member)
+
+	// Shifts move to other days and hours
+	end, err := time.Parse(time.Kitchen, "8:00PM")
+	require.NoError(t, err)
+	_, err = s.UpdateScheduleFields(ctx, "backend-team", id, storage.ScheduleUpdate{
+		Days: []time.Weekday{time.Tuesday},
+		End:  &end,
+	})
+	require.NoError(t, err)
+
+	_, err = s.GetCurrentOncall(ctx, "backend-team", monday.Add(10*time.Hour))
+	require.ErrorIs(t, err, storage.ErrNoCoverage)
+	member, err = s.GetCurrentOncall(ctx, "backend-team", monday.Add(24*time.Hour+19*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", member)
+
+	// Shifts must still start before they end
+	start, err := time.Parse(time.Kitchen, "9:00PM")
+	require.NoError(t, err)
+	_, err = s.UpdateScheduleFields(ctx, "backend-team", id, storage.ScheduleUpdate{Start: &start})
+	require.ErrorIs(t, err, storage.ErrScheduleTimes)
+
+	sched, err := s.GetSchedule(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 9, sched.Schedule.Start.Hour())
+	assert.Equal(t, 20, sched.Schedule.End.Hour())
+
+	_, err = s.UpdateScheduleFields(ctx, "backend-team", "999999", storage.ScheduleUpdate{Name: &name})
+	require.ErrorIs(t, err, storage.ErrScheduleNotFound)
+	_, err = s.UpdateScheduleFields(ctx, "unknown-team", id, storage.ScheduleUpdate{Name: &name})
+	require.ErrorIs(t, err, storage.ErrScheduleNotFound)
+
+	versions, _, err := s.ScheduleVersions(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, "Weekday", versions[0].Schedule.Name)
+	assert.Equal(t, "Daytime", versions[1].Schedule.Name)
+	assert.Equal(t, []time.Weekday{time.Tuesday}, versions[2].Schedule.Days)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	assert.Equal(t, storage.AuditUpdateSchedule, entries[len(entries)-1].Action)
+	assert.Equal(t, "Daytime: days, end", entries[len(entries)-1].Detail)
+
+	// Description, notes and tags are set and cleared
+	description, notes := "Business hours", "Escalate to the platform team"
+	_, err = s.UpdateScheduleFields(ctx, "backend-team", id, storage.ScheduleUpdate{
+		Description: &description,
+		Notes:       &notes,
+		Tags:        []string{"payments"},
+	})
+	require.NoError(t, err)
+
+	sched, err = s.GetSchedule(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, description, sched.Schedule.Description)
+	assert.Equal(t, notes, sched.Schedule.Notes)
+	assert.Equal(t, []string{"payments"}, sched.Schedule.Tags)
+
+	tagged, err := s.FindSchedulesByTags(ctx, []string{"payments"})
+	require.NoError(t, err)
+	require.Len(t, tagged, 1)
+
+	description = ""
+	_, err = s.UpdateScheduleFields(ctx, "backend-team", id, storage.ScheduleUpdate{Description: &description, Tags: []string{}})
+	require.NoError(t, err)
+
+	sched, err = s.GetSchedule(ctx, id)
+	require.NoError(t, err)
+	assert.Empty(t, sched.Schedule.Description)
+	assert.Equal(t, notes, sched.Schedule.Notes)
+	assert.Empty(t, sched.Schedule.Tags)
+
+	// The rotation moves along with the members
+	offset := 1
+	updated, err = s.UpdateScheduleFields(ctx, "backend-team", id, storage.ScheduleUpdate{
+		Members:        []string{"Bob", "Carol"},
+		RotationOffset: &offset,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated.RotationOffset)
+
+	sched, err = s.GetSchedule(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sched.Schedule.RotationOffset)
+	member, err = s.GetCurrentOncall(ctx, "backend-team", monday.Add(24*time.Hour+19*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, "Carol", member)
+}
+
+func testScheduleNames(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	weekday := Schedule(t, "Weekday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday)
+	weekday.ValidUntil = time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	_, err := s.AddSchedule(ctx, "backend-team", weekday)
+	require.NoError(t, err)
+	evening, err := s.AddSchedule(ctx, "backend-team", Schedule(t, "Evening", []string{"Bob"}, "5:00PM", "11:00PM", time.Monday))
+	require.NoError(t, err)
+
+	// Names are unique per team
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Bob"}, "9:00AM", "5:00PM", time.Tuesday))
+	require.ErrorIs(t, err, storage.ErrScheduleExists)
+	_, err = s.AddSchedule(ctx, "frontend-team", weekday)
+	require.NoError(t, err)
+
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Len(t, team.Schedules, 2)
+
+	// Renames too, a schedule keeping its own name aside
+	name := "Weekday"
+	_, err = s.UpdateScheduleFields(ctx, "backend-team", evening, storage.ScheduleUpdate{Name: &name})
+	require.ErrorIs(t, err, storage.ErrScheduleExists)
+
+	name = "Evening"
+	_, err = s.UpdateScheduleFields(ctx, "backend-team", evening, storage.ScheduleUpdate{Name: &name})
+	require.NoError(t, err)
+
+	// The names of expired schedules can be taken again
+	deleted, err := s.DeleteExpiredSchedules(ctx, time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Bob"}, "9:00AM", "5:00PM", time.Tuesday))
+	require.NoError(t, err)
+}
+
+func testRenameMember(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	_, err := s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	id := team.Schedules[0].ID
+
+	_, _, err = s.AddPin(ctx, "backend-team", id, storage.Pin{Date: start, Member: "Alice"})
+	require.NoError(t, err)
+	_, _, err = s.SetGroup(ctx, "backend-team", storage.Group{Name: "storage", Members: []string{"Alice", "Carol"}})
+	require.NoError(t, err)
+	_, err = s.AddUnavailability(ctx, storage.Unavailability{Member: "Alice", Start: start, End: start.Add(time.Hour)})
+	require.NoError(t, err)
+
+	_, err = s.RenameMember(ctx, "Alice", "Bob")
+	require.ErrorIs(t, err, storage.ErrMemberExists)
+
+	renamed, err := s.RenameMember(ctx, "Nobody", "Zed")
+	require.NoError(t, err)
+	assert.False(t, renamed)
+
+	renamed, err = s.RenameMember(storage.WithActor(ctx, "admin"), "Alice", "Alicia")
+	require.NoError(t, err)
+	require.True(t, renamed)
+
+	team, err = s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alicia", "Bob"}, team.Schedules[0].Members)
+	require.Len(t, team.Schedules[0].Pins, 1)
+	assert.Equal(t, "Alicia", team.Schedules[0].Pins[0].Member)
+
+	groups, err := s.ListGroups(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, []string{"Alicia", "Carol"}, groups[0].Members)
+
+	windows, err := s.ListUnavailability(ctx, "Alicia")
+	require.NoError(t, err)
+	assert.Len(t, windows, 1)
+	windows, err = s.ListUnavailability(ctx, "Alice")
+	require.NoError(t, err)
+	assert.Empty(t, windows)
+
+	teams, err := s.FindTeamsByMember(ctx, "Alice")
+	require.NoError(t, err)
+	assert.Empty(t, teams)
+
+	// Versions are kept as recorded and resolve the former name
+	versions, _, err := s.ScheduleVersions(ctx, id)
+	require.NoError(t, err)
+	require.Len(t, versions, 1)
+	assert.Equal(t, []string{"Alicia", "Bob"}, versions[0].Schedule.Members)
+
+	history, err := s.TeamHistory(ctx, "backend-team", time.Now(), time.Now())
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, []string{"Alicia", "Bob"}, history[0].Team.Schedules[0].Members)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	last := entries[len(entries)-1]
+	assert.Equal(t, storage.AuditRenameMember, last.Action)
+	assert.Equal(t, "admin", last.Actor)
+	assert.Equal(t, "Alice to Alicia", last.Detail)
+
+	// Renaming again follows the chain, and the former name can be taken back
+	renamed, err = s.RenameMember(ctx, "Alicia", "Alice")
+	require.NoError(t, err)
+	require.True(t, renamed)
+
+	versions, _, err = s.ScheduleVersions(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Alice", "Bob"}, versions[0].Schedule.Members)
+}
+
+func testMergeTeams(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	tuesday := time.Date(2026, 3, 3, 10, 0, 0, 0, time.UTC)
+
+	_, err := s.AddSchedule(ctx, "payments", Schedule(t, "Weekday", []string{"Alice"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "payments-edge", Schedule(t, "Weekday", []string{"Carol"}, "9:00AM", "5:00PM", time.Tuesday))
+	require.NoError(t, err)
+	_, err = s.AddSchedule(ctx, "payments-edge", Schedule(t, "Weekend", []string{"Erin"}, "9:00AM", "5:00PM", time.Saturday))
+	require.NoError(t, err)
+
+	_, _, err = s.SetTeamMember(ctx, "payments", storage.TeamMember{Name: "Bob", Role: storage.MemberRoleObserver})
+	require.NoError(t, err)
+	_, _, err = s.SetTeamMember(ctx, "payments-edge", storage.TeamMember{Name: "Bob", Role: storage.MemberRoleMember})
+	require.NoError(t, err)
+	_, _, err = s.AddFreeze(ctx, "payments-edge", storage.Freeze{Start: tuesday, End: tuesday.Add(time.Hour)})
+	require.NoError(t, err)
+
+	_, found, err := s.MergeTeams(ctx, "payments", "nonexistent", storage.MergeRename)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// Nothing moves when the schedule names collide
+	_, _, err = s.MergeTeams(ctx, "payments", "payments-edge", storage.MergeFail)
+	var conflictErr *storage.MergeConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	assert.Equal(t, []string{"Weekday"}, conflictErr.Schedules)
+
+	_, err = s.GetTeam(ctx, "payments-edge")
+	require.NoError(t, err)
+
+	merge, found, err := s.MergeTeams(storage.WithActor(ctx, "admin"), "payments", "payments-edge", storage.MergeRename)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, 2, merge.Schedules)
+	assert.Equal(t, map[string]string{"Weekday": "Weekday (payments-edge)"}, merge.Renamed)
+
+	team, err := s.GetTeam(ctx, "payments")
+	require.NoError(t, err)
+	var names []string
+	for _, sched := range team.Schedules {
+		names = append(names, sched.Name)
+	}
+	assert.ElementsMatch(t, []string{"Weekday", "Weekday (payments-edge)", "Weekend"}, names)
+
+	_, err = s.GetTeam(ctx, "payments-edge")
+	require.ErrorIs(t, err, storage.ErrTeamNotFound)
+
+	oncall, err := s.GetCurrentOncall(ctx, "payments", tuesday)
+	require.NoError(t, err)
+	assert.Equal(t, "Carol", oncall)
+
+	// The target keeps the role it gave
+	members, err := s.ListTeamMembers(ctx, "payments")
+	require.NoError(t, err)
+	roles := make(map[string]string)
+	for _, member := range members {
+		roles[member.Name] = member.Role
+	}
+	assert.Equal(t, storage.MemberRoleObserver, roles["Bob"])
+	assert.Equal(t, storage.MemberRoleMember, roles["Carol"])
+
+	freezes, err := s.ListFreezes(ctx, "payments")
+	require.NoError(t, err)
+	assert.Len(t, freezes, 1)
+
+	for _, name := range []string{"payments", "payments-edge"} {
+		entries, err := s.AuditLog(ctx, name)
+		require.NoError(t, err)
+		require.NotEmpty(t, entries)
+		last := entries[len(entries)-1]
+		assert.Equal(t, storage.AuditMergeTeam, last.Action)
+		assert.Equal(t, "admin", last.Actor)
+		assert.Equal(t, "payments-edge into payments, renamed Weekday to Weekday (payments-edge)", last.Detail)
+	}
+
+	into, found, err := s.MergedInto(ctx, "payments-edge")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "payments", into)
+
+	// Merging the target on follows the chain
+	_, err = s.AddSchedule(ctx, "billing", Schedule(t, "Nights", []string{"Dave"}, "5:00PM", "11:00PM", time.Monday))
+	require.NoError(t, err)
+	_, _, err = s.MergeTeams(ctx, "billing", "payments", storage.MergeFail)
+	require.NoError(t, err)
+
+	into, _, err = s.MergedInto(ctx, "payments-edge")
+	require.NoError(t, err)
+	assert.Equal(t, "billing", into)
+
+	_, found, err = s.MergedInto(ctx, "billing")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// A team created with the name of a merged one is not merged
+	_, err = s.AddSchedule(ctx, "payments-edge", Schedule(t, "Weekday", []string{"Carol"}, "9:00AM", "5:00PM", time.Tuesday))
+	require.NoError(t, err)
+	_, found, err = s.MergedInto(ctx, "payments-edge")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func testTeamParents(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	_, found, err := s.TeamParent(ctx, "payments")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// Neither team has to exist yet
+	require.NoError(t, s.SetTeamParent(storage.WithActor(ctx, "admin"), "payments", "platform"))
+	require.NoError(t, s.SetTeamParent(ctx, "platform", "engineering"))
+
+	parent, found, err := s.TeamParent(ctx, "payments")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "platform", parent)
+
+	entries, err := s.AuditLog(ctx, "payments")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	last := entries[len(entries)-1]
+	assert.Equal(t, storage.AuditSetParent, last.Action)
+	assert.Equal(t, "admin", last.Actor)
+	assert.Equal(t, "parent platform", last.Detail)
+
+	// Neither the team itself nor a team below it can become its parent
+	require.ErrorIs(t, s.SetTeamParent(ctx, "engineering", "engineering"), storage.ErrParentCycle)
+	require.ErrorIs(t, s.SetTeamParent(ctx, "engineering", "payments"), storage.ErrParentCycle)
+	require.ErrorIs(t, s.SetTeamParent(ctx, "platform", "payments"), storage.ErrParentCycle)
+
+	_, found, err = s.TeamParent(ctx, "engineering")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// Moving a team elsewhere and clearing its parent
+	require.NoError(t, s.SetTeamParent(ctx, "payments", "engineering"))
+	parent, _, err = s.TeamParent(ctx, "payments")
+	require.NoError(t, err)
+	assert.Equal(t, "engineering", parent)
+
+	require.NoError(t, s.SetTeamParent(ctx, "payments", ""))
+	_, found, err = s.TeamParent(ctx, "payments")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	// Without it, the former descendant may become the parent
+	require.NoError(t, s.SetTeamParent(ctx, "engineering", "payments"))
+}
+
+func testPublicCoverage(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	enabled, err := s.PublicCoverage(ctx, "payments")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	// The team does not have to exist yet, and enabling twice is fine
+	require.NoError(t, s.SetPublicCoverage(storage.WithActor(ctx, "admin"), "payments", true))
+	require.NoError(t, s.SetPublicCoverage(ctx, "payments", true))
+
+	enabled, err = s.PublicCoverage(ctx, "payments")
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	enabled, err = s.PublicCoverage(ctx, "billing")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	require.NoError(t, s.SetPublicCoverage(storage.WithActor(ctx, "admin"), "payments", false))
+	enabled, err = s.PublicCoverage(ctx, "payments")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	entries, err := s.AuditLog(ctx, "payments")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	last := entries[len(entries)-1]
+	assert.Equal(t, storage.AuditSetPublicCoverage, last.Action)
+	assert.Equal(t, "admin", last.Actor)
+	assert.Equal(t, "public coverage disabled", last.Detail)
+}
+
+func testGoogleCalendars(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	_, found, err := s.GoogleCalendar(ctx, "payments")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	calendars, err := s.ListGoogleCalendars(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, calendars)
+
+	// The team does not have to exist yet
+	require.NoError(t, s.SetGoogleCalendar(storage.WithActor(ctx, "admin"), "payments", "payments@group.calendar.google.com"))
+	require.NoError(t, s.SetGoogleCalendar(ctx, "billing", "billing@group.calendar.google.com"))
+	require.NoError(t, s.SetGoogleCalendar(ctx, "billing", "finance@group.calendar.google.com"))
+
+	calendarID, found, err := s.GoogleCalendar(ctx, "payments")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "payments@group.calendar.google.com", calendarID)
+
+	calendars, err = s.ListGoogleCalendars(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"payments": "payments@group.calendar.google.com",
+		"billing":  "finance@group.calendar.google.com",
+	}, calendars)
+
+	entries, err := s.AuditLog(ctx, "payments")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+	last := entries[len(entries)-1]
+	assert.Equal(t, storage.AuditSetGoogleCalendar, last.Action)
+	assert.Equal(t, "admin", last.Actor)
+	assert.Equal(t, "google calendar payments@group.calendar.google.com", last.Detail)
+
+	require.NoError(t, s.SetGoogleCalendar(ctx, "payments", ""))
+	_, found, err = s.GoogleCalendar(ctx, "payments")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	calendars, err = s.ListGoogleCalendars(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"billing": "finance@group.calendar.google.com"}, calendars)
+}
+
+func testHandoffNotes(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	monday := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	note := storage.HandoffNote{
+		Member:     "Alice",
+		ShiftStart: monday,
+		ShiftEnd:   monday.Add(8 * time.Hour),
+		Author:     "user:alice",
+		Text:       "deploy freeze until Monday",
+		ExpiresAt:  monday.Add(32 * time.Hour),
+	}
+
+	_, found, err := s.AddHandoffNote(ctx, "backend-team", note)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, err = s.AddSchedule(ctx, "backend-team", Schedule(t, "Weekday", []string{"Alice", "Bob"}, "9:00AM", "5:00PM", time.Monday))
+	require.NoError(t, err)
+	team, err := s.GetTeam(ctx, "backend-team")
+	require.NoError(t, err)
+	note.ScheduleID = team.Schedules[0].ID
+
+	added, found, err := s.AddHandoffNote(storage.WithActor(ctx, "user:alice"), "backend-team", note)
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.NotZero(t, added.ID)
+	assert.False(t, added.CreatedAt.IsZero())
+
+	// A shift has a single note
+	_, _, err = s.AddHandoffNote(ctx, "backend-team", note)
+	require.ErrorIs(t, err, storage.ErrHandoffNoteExists)
+
+	updated, found, err := s.UpdateHandoffNote(ctx, "backend-team", added.ID, "watch the payments queue")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "watch the payments queue", updated.Text)
+	assert.Equal(t, "user:alice", updated.Author)
+
+	_, found, err = s.UpdateHandoffNote(ctx, "frontend-team", added.ID, "elsewhere")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	notes, err := s.ListHandoffNotes(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, added.ID, notes[0].ID)
+	assert.Equal(t, note.ScheduleID, notes[0].ScheduleID)
+	assert.Equal(t, "Alice", notes[0].Member)
+	assert.Equal(t, "watch the payments queue", notes[0].Text)
+	assert.True(t, notes[0].ShiftStart.Equal(monday))
+	assert.True(t, notes[0].ShiftEnd.Equal(monday.Add(8*time.Hour)))
+	assert.True(t, notes[0].ExpiresAt.Equal(monday.Add(32*time.Hour)))
+
+	deleted, err := s.DeleteHandoffNote(ctx, "frontend-team", added.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = s.DeleteHandoffNote(ctx, "backend-team", added.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	notes, err = s.ListHandoffNotes(ctx, "backend-team")
+	require.NoError(t, err)
+	assert.Empty(t, notes)
+
+	entries, err := s.AuditLog(ctx, "backend-team")
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, storage.AuditAddHandoffNote, entries[0].Action)
+	assert.Equal(t, "user:alice", entries[0].Actor)
+	assert.Contains(t, entries[0].Detail, "deploy freeze until Monday")
+	assert.Equal(t, storage.AuditUpdateHandoffNote, entries[1].Action)
+	assert.Contains(t, entries[1].Detail, "watch the payments queue")
+	assert.Equal(t, storage.AuditDeleteHandoffNote, entries[2].Action)
+}
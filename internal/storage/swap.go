@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Swap request statuses. Only Pending, Accepted and Declined are stored,
+// pending requests expire when their shift starts, see SwapRequest.StatusAt.
+const (
+	SwapPending  = "pending"
+	SwapAccepted = "accepted"
+	SwapDeclined = "declined"
+	SwapExpired  = "expired"
+)
+
+// ErrSwapDecided is returned when deciding a swap request that is not
+// pending anymore, either decided already or expired.
+var ErrSwapDecided = errors.New("swap request is not pending")
+
+// SwapRequest asks To to take the shift of a schedule over from From.
+// Nothing changes until To accepts it, which pins them to the date of the
+// shift like an admin pin would.
+type SwapRequest struct {
+	ID         int64
+	Team       string
+	ScheduleID string
+	ShiftStart time.Time
+	ShiftEnd   time.Time
+	From       string
+	To         string
+	Status     string
+	// TokenHash is the hash of the token of the link To decides with.
+	TokenHash string
+	CreatedAt time.Time
+	// DecidedAt is zero while the request is pending.
+	DecidedAt time.Time
+}
+
+// StatusAt returns the status of the request at the given instant, pending
+// requests being expired once their shift starts.
+func (r SwapRequest) StatusAt(at time.Time) string {
+	if r.Status == SwapPending && !at.Before(r.ShiftStart) {
+		return SwapExpired
+	}
+
+	return r.Status
+}
+
+// decide checks the request can be decided at the given instant and returns
+// it decided.
+func (r SwapRequest) decide(accept bool, at time.Time) (SwapRequest, error) {
+	if r.StatusAt(at) != SwapPending {
+		return SwapRequest{}, ErrSwapDecided
+	}
+
+	r.Status = SwapDeclined
+	if accept {
+		r.Status = SwapAccepted
+	}
+	r.DecidedAt = at
+
+	return r, nil
+}
+
+// pin returns the pin an accepted request puts in place.
+func (r SwapRequest) pin() Pin {
+	return Pin{Date: PinDate(r.ShiftStart), Member: r.To}
+}
+
+// auditDetail describes the accepted request for the audit log.
+func (r SwapRequest) auditDetail() string {
+	return fmt.Sprintf("%s takes over the shift of %s starting %s of schedule %s",
+		r.To, r.From, r.ShiftStart.UTC().Format(time.RFC3339), r.ScheduleID)
+}
@@ -0,0 +1,29 @@
+package storage
+
+import "slices"
+
+// TeamSchedule is a schedule along with the team it belongs to, as returned
+// by lookups across teams.
+type TeamSchedule struct {
+	Team     string
+	Schedule Schedule
+}
+
+// MemberSchedule is a schedule listing a member, along with its team and the
+// position of the member among its members, as returned by member searches.
+type MemberSchedule struct {
+	TeamSchedule
+	Member   string
+	Position int
+}
+
+// HasTags reports whether the schedule carries every one of the tags.
+func (s Schedule) HasTags(tags []string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(s.Tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Roles of team members.
+const (
+	// MemberRoleMember is the role of members who may be put on call.
+	MemberRoleMember = "member"
+	// MemberRoleObserver is the role of members who are part of the team and
+	// may be notified of its handoffs, but are never put on call.
+	MemberRoleObserver = "observer"
+)
+
+// TeamMember is a person on the roster of a team. Schedules are not bound
+// to the roster, only its observers are kept out of them, unless they use
+// the members of their team, see Schedule.TeamMembers.
+type TeamMember struct {
+	Name      string
+	Role      string
+	CreatedAt time.Time
+}
+
+// auditDetail describes the membership for the audit log.
+func (m TeamMember) auditDetail() string {
+	return m.Name + " as " + m.Role
+}
+
+// rotationMembers returns the members of the roster who may be put on call,
+// in the order they joined the team and by name when they joined at once,
+// along with when they joined. They are the members of the schedules using
+// the roster of their team.
+func rotationMembers(roster []TeamMember) ([]string, map[string]time.Time) {
+	roster = slices.DeleteFunc(slices.Clone(roster), func(m TeamMember) bool {
+		return m.Role != MemberRoleMember
+	})
+	slices.SortFunc(roster, func(a, b TeamMember) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	members := make([]string, 0, len(roster))
+	joined := make(map[string]time.Time, len(roster))
+	for _, member := range roster {
+		members = append(members, member.Name)
+		joined[member.Name] = member.CreatedAt
+	}
+
+	return members, joined
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+)
+
+// ErrAllUnavailable is returned when the member on call and every member who
+// could take over from them are unavailable.
+var ErrAllUnavailable = errors.New("every member of the schedule is unavailable")
+
+// Unavailability is a window during which a member cannot take pages, e.g.
+// because they are sick. It applies to every team of the member.
+type Unavailability struct {
+	ID        int64
+	Member    string
+	Reason    string
+	Start     time.Time
+	End       time.Time
+	CreatedAt time.Time
+}
+
+// Active reports whether the window covers the given instant.
+func (u Unavailability) Active(at time.Time) bool {
+	return !at.Before(u.Start) && at.Before(u.End)
+}
+
+// Substitute returns the member taking the pages of the member on call at
+// the given instant. That is the member themselves unless they are
+// unavailable, in which case it is the first available member after them in
+// the rotation order of the schedule answering at the instant. Deactivated
+// members are skipped as well. It returns ErrAllUnavailable when nobody is
+// left.
+func Substitute(ctx context.Context, s Storage, schedules []Schedule, member string, at time.Time) (string, error) {
+	sched, ok := ScheduleAt(schedules, at)
+
+	candidates := []string{member}
+	if ok {
+		candidates = append(candidates, sched.Members...)
+	}
+
+	unavailable, err := s.UnavailableMembers(ctx, candidates, at)
+	if err != nil {
+		return "", fmt.Errorf("get unavailable members: %w", err)
+	}
+
+	if substitute, found := rotation.Substitute(rotationSchedules(schedules), member, at.UTC(), unavailable); found {
+		return substitute, nil
+	}
+
+	return "", ErrAllUnavailable
+}
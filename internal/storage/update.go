@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrScheduleTimes is returned when an update would leave a schedule with
+// shifts not starting before they end, e.g. as another update changed the
+// other end of its shifts in the meantime.
+var ErrScheduleTimes = errors.New("start time must be before end time")
+
+// ScheduleUpdate holds the fields of a schedule to change, the nil ones are
+// left as they are.
+type ScheduleUpdate struct {
+	Name        *string
+	Description *string
+	Notes       *string
+	// Members replaces the members, along with MemberRefs, the members as
+	// given when they reference groups.
+	Members    []string
+	MemberRefs []string
+	// Days replaces the days, along with DayAssignments, the members always
+	// on duty on some of them.
+	Days           []time.Weekday
+	DayAssignments map[time.Weekday]string
+	Start          *time.Time
+	End            *time.Time
+	// Tags replaces the tags, an empty non-nil slice removes them all.
+	Tags []string
+	// RotationOffset moves the rotation, e.g. to keep it within fewer members.
+	RotationOffset *int
+}
+
+// apply changes the fields of the schedule, failing with ErrScheduleTimes
+// when its shifts would not start before they end.
+func (u ScheduleUpdate) apply(schedule *Schedule) error {
+	start, end := schedule.Start, schedule.End
+	if u.Start != nil {
+		start = *u.Start
+	}
+	if u.End != nil {
+		end = *u.End
+	}
+	if !start.Before(end) {
+		return ErrScheduleTimes
+	}
+	schedule.Start, schedule.End = start, end
+
+	if u.Name != nil {
+		schedule.Name = *u.Name
+	}
+	if u.Description != nil {
+		schedule.Description = *u.Description
+	}
+	if u.Notes != nil {
+		schedule.Notes = *u.Notes
+	}
+	if u.Members != nil {
+		schedule.Members = slices.Clone(u.Members)
+		schedule.MemberRefs = slices.Clone(u.MemberRefs)
+	}
+	if u.Days != nil {
+		schedule.Days = slices.Clone(u.Days)
+		schedule.DayAssignments = maps.Clone(u.DayAssignments)
+	}
+	if u.Tags != nil {
+		schedule.Tags = slices.Clone(u.Tags)
+	}
+	if u.RotationOffset != nil {
+		schedule.RotationOffset = *u.RotationOffset
+	}
+
+	return nil
+}
+
+// fields returns the names of the fields changed by the update, in the order
+// of the request.
+func (u ScheduleUpdate) fields() []string {
+	var fields []string
+	if u.Name != nil {
+		fields = append(fields, "name")
+	}
+	if u.Description != nil {
+		fields = append(fields, "description")
+	}
+	if u.Notes != nil {
+		fields = append(fields, "notes")
+	}
+	if u.Members != nil {
+		fields = append(fields, "members")
+	}
+	if u.Days != nil {
+		fields = append(fields, "days")
+	}
+	if u.Start != nil {
+		fields = append(fields, "start")
+	}
+	if u.End != nil {
+		fields = append(fields, "end")
+	}
+	if u.Tags != nil {
+		fields = append(fields, "tags")
+	}
+	if u.RotationOffset != nil {
+		fields = append(fields, "rotation_offset")
+	}
+
+	return fields
+}
+
+// auditDetail describes the update of a schedule for the audit log, with the
+// fields it changed.
+func (u ScheduleUpdate) auditDetail(schedule string) string {
+	return fmt.Sprintf("%s: %s", schedule, strings.Join(u.fields(), ", "))
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// User is a person provisioned by an identity provider. Members of schedules
+// are matched to users by their UserName. A deactivated user stays in the
+// schedules they are a member of, but rotations skip them, see MemberOnDuty.
+type User struct {
+	ID       string
+	UserName string
+	Emails   []string
+	Active   bool
+	// Timezone is the IANA zone the times of the user are rendered in, empty
+	// for UTC. Schedules are still evaluated in their own zone.
+	Timezone  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Location returns the zone of the user, UTC when it is not set or no longer
+// known.
+func (u User) Location() *time.Location {
+	if u.Timezone == "" {
+		return time.UTC
+	}
+
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// MemberLocation returns the zone of the provisioned user the member is
+// matched to, and false when the member is not provisioned or has no zone.
+func MemberLocation(ctx context.Context, s Storage, member string) (*time.Location, bool, error) {
+	users, err := s.FindUsers(ctx, member)
+	if err != nil || len(users) == 0 || users[0].Timezone == "" {
+		return time.UTC, false, err
+	}
+
+	return users[0].Location(), true, nil
+}
+
+// inactiveMembers returns the members of the schedule in the set of
+// deactivated user names, nil when there are none.
+func inactiveMembers(members []string, inactive map[string]bool) []string {
+	var result []string
+	for _, member := range members {
+		if inactive[member] {
+			result = append(result, member)
+		}
+	}
+
+	return result
+}
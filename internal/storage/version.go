@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"slices"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+)
+
+// ScheduleVersion is the definition of a schedule from Since on, until its
+// next version. A version is recorded whenever a schedule is added or its
+// definition changes. Pins are dated already and are not versioned, and
+// neither are user deactivations.
+type ScheduleVersion struct {
+	Schedule Schedule
+	Since    time.Time
+	// Deleted versions are the tombstones of schedules soft-deleted at
+	// Since, their Schedule only holds the ID and name.
+	Deleted bool
+}
+
+// TeamVersion is the configuration of a team from Since on, until the next
+// version, as the schedules it had then.
+type TeamVersion struct {
+	Since time.Time
+	Team  Team
+}
+
+// teamHistory returns the versions of a team live during [from, to] out of
+// the versions of its schedules ordered by Since, the first one being the
+// version live at from. Schedules are in insertion order and take the pins
+// returned by pins, if any. Schedules are left out from their tombstone on.
+func teamHistory(versions []ScheduleVersion, from, to time.Time, pins func(id string) []Pin) []TeamVersion {
+	// The configuration changes at from and at every version after it
+	changes := []time.Time{from}
+	for _, version := range versions {
+		if version.Since.After(from) && !version.Since.After(to) {
+			changes = append(changes, version.Since)
+		}
+	}
+	changes = slices.CompactFunc(changes, func(a, b time.Time) bool {
+		return a.Equal(b)
+	})
+
+	history := make([]TeamVersion, 0, len(changes))
+	for _, at := range changes {
+		var schedules []Schedule
+		for _, version := range versions {
+			if version.Since.After(at) {
+				break
+			}
+
+			sched := version.Schedule
+			if pins != nil {
+				sched.Pins = pins(sched.ID)
+			}
+
+			i := slices.IndexFunc(schedules, func(s Schedule) bool { return s.ID == sched.ID })
+			switch {
+			case version.Deleted:
+				if i != -1 {
+					schedules = slices.Delete(schedules, i, i+1)
+				}
+			case i != -1:
+				schedules[i] = sched
+			default:
+				schedules = append(schedules, sched)
+			}
+		}
+
+		history = append(history, TeamVersion{Since: at, Team: Team{Schedules: schedules}})
+	}
+
+	return history
+}
+
+// OncallAt returns the member the on-call lookup answers with at the given
+// instant from the schedules, like GetCurrentOncall does from the stored ones.
+func OncallAt(schedules []Schedule, at time.Time) (string, bool) {
+	return rotation.OncallAt(rotationSchedules(schedules), at)
+}
+
+// HistoryTimeline returns the DutyTimeline of [from, to) resolved against the
+// versions of a team, each stretch against the version live at it. Stretches
+// running over a change of version are cut at it.
+func HistoryTimeline(history []TeamVersion, from, to time.Time) []Duty {
+	var duties []Duty
+
+	for i, version := range history {
+		start, end := from, to
+		if version.Since.After(start) {
+			start = version.Since
+		}
+		if i+1 < len(history) && history[i+1].Since.Before(end) {
+			end = history[i+1].Since
+		}
+
+		if start.Before(end) {
+			duties = append(duties, DutyTimeline(version.Team.Schedules, start, end)...)
+		}
+	}
+
+	return duties
+}
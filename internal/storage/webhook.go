@@ -0,0 +1,34 @@
+package storage
+
+import "time"
+
+// Webhook is a subscription to on-call events delivered as HTTP requests to
+// URL. An empty Team subscribes to every team and empty Events to every
+// event kind. An empty Secret means deliveries are not signed.
+type Webhook struct {
+	ID        int64
+	Team      string
+	URL       string
+	Secret    string
+	Events    []string
+	CreatedAt time.Time
+}
+
+// Matches reports whether the subscription covers an event of the given kind and team.
+func (w Webhook) Matches(kind, team string) bool {
+	if w.Team != "" && w.Team != team {
+		return false
+	}
+
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, event := range w.Events {
+		if event == kind {
+			return true
+		}
+	}
+
+	return false
+}
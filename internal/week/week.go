@@ -0,0 +1,168 @@
+// Package week holds the week conventions of the teams, the day their week
+// starts on and the days of their weekend, which differ by region. They
+// shape how schedules are written, summarized and checked, never who is on
+// call.
+package week
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+)
+
+// Week is the week convention of a team.
+type Week struct {
+	Start   time.Weekday
+	Weekend []time.Weekday
+}
+
+// Default is the week starting on Monday with a Saturday and Sunday weekend.
+var Default = Week{Start: time.Monday, Weekend: []time.Weekday{time.Saturday, time.Sunday}}
+
+// Parse parses the week starting on the start day with the weekend days,
+// given as English names or three-letter abbreviations in any case. The
+// weekend must leave at least one weekday.
+func Parse(start string, weekend []string) (Week, error) {
+	first, err := parseDay(start)
+	if err != nil {
+		return Week{}, fmt.Errorf("invalid week_start: %w", err)
+	}
+
+	week := Week{Start: first}
+	for _, day := range weekend {
+		wd, err := parseDay(day)
+		if err != nil {
+			return Week{}, fmt.Errorf("invalid weekend_days: %w", err)
+		}
+		if !slices.Contains(week.Weekend, wd) {
+			week.Weekend = append(week.Weekend, wd)
+		}
+	}
+	if len(week.Weekend) == 7 {
+		return Week{}, errors.New("invalid weekend_days: the weekend must leave at least one weekday")
+	}
+
+	return week, nil
+}
+
+// parseDay parses an English weekday name or its three-letter abbreviation.
+func parseDay(day string) (time.Weekday, error) {
+	day = strings.TrimSpace(day)
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if strings.EqualFold(day, wd.String()) || strings.EqualFold(day, wd.String()[:3]) {
+			return wd, nil
+		}
+	}
+
+	return time.Sunday, fmt.Errorf("unknown weekday %q", day)
+}
+
+// Days returns the days of the week in order from its start.
+func (w Week) Days() []time.Weekday {
+	days := make([]time.Weekday, 7)
+	for i := range days {
+		days[i] = (w.Start + time.Weekday(i)) % 7
+	}
+
+	return days
+}
+
+// IsWeekend reports whether the day is on the weekend.
+func (w Week) IsWeekend(day time.Weekday) bool {
+	return slices.Contains(w.Weekend, day)
+}
+
+// Weekdays returns the days outside the weekend, in week order.
+func (w Week) Weekdays() []time.Weekday {
+	return slices.DeleteFunc(w.Days(), w.IsWeekend)
+}
+
+// WeekendDays returns the days of the weekend, in week order.
+func (w Week) WeekendDays() []time.Weekday {
+	return slices.DeleteFunc(w.Days(), func(day time.Weekday) bool {
+		return !w.IsWeekend(day)
+	})
+}
+
+// StartOf returns the start of the week holding t, midnight of its first day
+// in the location of t.
+func (w Week) StartOf(t time.Time) time.Time {
+	back := (int(t.Weekday()) - int(w.Start) + 7) % 7
+
+	return time.Date(t.Year(), t.Month(), t.Day()-back, 0, 0, 0, 0, t.Location())
+}
+
+// WeekendTime returns how much of [start, end) falls on weekend days, which
+// are told apart in the location of start.
+func (w Week) WeekendTime(start, end time.Time) time.Duration {
+	var total time.Duration
+
+	for day := start; day.Before(end); {
+		midnight := time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location())
+		next := midnight
+		if end.Before(next) {
+			next = end
+		}
+
+		if w.IsWeekend(day.Weekday()) {
+			total += next.Sub(day)
+		}
+		day = next
+	}
+
+	return total
+}
+
+// Conventions holds the week conventions of every team.
+type Conventions struct {
+	defaults Week
+	teams    map[string]Week
+}
+
+// New creates the conventions from the configuration. It fails on an
+// unknown day.
+func New(cfg *config.Config) (*Conventions, error) {
+	wc := cfg.Week
+
+	defaults, err := Parse(wc.Start, wc.WeekendDays)
+	if err != nil {
+		return nil, fmt.Errorf("week: %w", err)
+	}
+
+	teams := make(map[string]Week, len(wc.Teams))
+	for team, tc := range wc.Teams {
+		start := tc.Start
+		if start == "" {
+			start = wc.Start
+		}
+		weekend := tc.WeekendDays
+		if len(weekend) == 0 {
+			weekend = wc.WeekendDays
+		}
+
+		week, err := Parse(start, weekend)
+		if err != nil {
+			return nil, fmt.Errorf("week of %s: %w", team, err)
+		}
+		teams[team] = week
+	}
+
+	return &Conventions{defaults: defaults, teams: teams}, nil
+}
+
+// Of returns the week convention of the team. Without conventions every team
+// has the Default one.
+func (c *Conventions) Of(team string) Week {
+	if c == nil {
+		return Default
+	}
+	if week, ok := c.teams[team]; ok {
+		return week
+	}
+
+	return c.defaults
+}
@@ -0,0 +1,108 @@
+package week
+
+import (
+	"testing"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gulf is a week starting on Saturday with a Thursday and Friday weekend.
+var gulf = Week{Start: time.Saturday, Weekend: []time.Weekday{time.Thursday, time.Friday}}
+
+func TestParse(t *testing.T) {
+	week, err := Parse("Saturday", []string{"fri", "THURSDAY", "Fri"})
+	require.NoError(t, err)
+	assert.Equal(t, Week{Start: time.Saturday, Weekend: []time.Weekday{time.Friday, time.Thursday}}, week)
+
+	_, err = Parse("Sat", nil)
+	require.NoError(t, err)
+
+	_, err = Parse("someday", nil)
+	require.ErrorContains(t, err, "week_start")
+
+	_, err = Parse("monday", []string{"sa"})
+	require.ErrorContains(t, err, "weekend_days")
+
+	_, err = Parse("monday", []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"})
+	require.ErrorContains(t, err, "at least one weekday")
+}
+
+func TestWeek_Days(t *testing.T) {
+	assert.Equal(t, []time.Weekday{
+		time.Saturday, time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday,
+	}, gulf.Days())
+	assert.Equal(t, []time.Weekday{
+		time.Saturday, time.Sunday, time.Monday, time.Tuesday, time.Wednesday,
+	}, gulf.Weekdays())
+	assert.Equal(t, []time.Weekday{time.Thursday, time.Friday}, gulf.WeekendDays())
+
+	assert.Equal(t, []time.Weekday{
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday,
+	}, Default.Weekdays())
+	assert.Equal(t, []time.Weekday{time.Saturday, time.Sunday}, Default.WeekendDays())
+}
+
+func TestWeek_StartOf(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want time.Time
+	}{
+		{"first instant", time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"weekday", time.Date(2026, 3, 9, 15, 30, 0, 0, time.UTC), time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"last instant", time.Date(2026, 3, 13, 23, 59, 59, 0, time.UTC), time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC)},
+		{"in zone", time.Date(2026, 3, 14, 1, 0, 0, 0, tehran), time.Date(2026, 3, 14, 0, 0, 0, 0, tehran)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, gulf.StartOf(tt.at))
+		})
+	}
+
+	// Friday closes the gulf week and is in the middle of the default one
+	friday := time.Date(2026, 3, 13, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2026, 3, 7, 0, 0, 0, 0, time.UTC), gulf.StartOf(friday))
+	assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), Default.StartOf(friday))
+}
+
+func TestWeek_WeekendTime(t *testing.T) {
+	// Wednesday 20:00 to Saturday 02:00 holds the whole Thursday and Friday
+	start := time.Date(2026, 3, 11, 20, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 3, 14, 2, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 48*time.Hour, gulf.WeekendTime(start, end))
+	assert.Equal(t, 2*time.Hour, Default.WeekendTime(start, end))
+	assert.Zero(t, gulf.WeekendTime(start, start.Add(4*time.Hour)))
+}
+
+func TestConventions(t *testing.T) {
+	conventions, err := New(&config.Config{Week: config.WeekConfig{
+		Start:       "monday",
+		WeekendDays: []string{"saturday", "sunday"},
+		Teams: map[string]config.WeekTeamConfig{
+			"gulf-team":   {Start: "saturday", WeekendDays: []string{"thursday", "friday"}},
+			"sunday-team": {Start: "sunday"},
+		},
+	}})
+	require.NoError(t, err)
+
+	assert.Equal(t, gulf, conventions.Of("gulf-team"))
+	assert.Equal(t, Week{Start: time.Sunday, Weekend: Default.Weekend}, conventions.Of("sunday-team"))
+	assert.Equal(t, Default, conventions.Of("backend-team"))
+
+	var none *Conventions
+	assert.Equal(t, Default, none.Of("gulf-team"))
+
+	_, err = New(&config.Config{Week: config.WeekConfig{
+		Start: "monday",
+		Teams: map[string]config.WeekTeamConfig{"gulf-team": {Start: "sabbath"}},
+	}})
+	require.ErrorContains(t, err, "week of gulf-team")
+}
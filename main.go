@@ -3,18 +3,41 @@ package main
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"time"
 
+	"github.com/1995parham-learning/oncall-schedule/internal/auth"
 	"github.com/1995parham-learning/oncall-schedule/internal/config"
 	"github.com/1995parham-learning/oncall-schedule/internal/db"
+	"github.com/1995parham-learning/oncall-schedule/internal/gcal"
 	"github.com/1995parham-learning/oncall-schedule/internal/handler"
+	"github.com/1995parham-learning/oncall-schedule/internal/integrity"
+	"github.com/1995parham-learning/oncall-schedule/internal/janitor"
+	"github.com/1995parham-learning/oncall-schedule/internal/logging"
+	"github.com/1995parham-learning/oncall-schedule/internal/notify"
 	"github.com/1995parham-learning/oncall-schedule/internal/storage"
+	"github.com/1995parham-learning/oncall-schedule/internal/week"
+	"github.com/1995parham-learning/oncall-schedule/migrations"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
 
+// stopTimeout bounds the whole shutdown, it must leave room for the drain
+// delay and the shutdown timeout of the server.
+const stopTimeout = time.Minute
+
+// notifyTimeout bounds a single call to a notification channel.
+const notifyTimeout = 10 * time.Second
+
+// oidcTimeout bounds the discovery of the OpenID Connect provider on start
+// and every call to it.
+const oidcTimeout = 10 * time.Second
+
 func main() {
 	// Check if we should use database storage
 	useDatabase := os.Getenv("ONCALL_USE_DATABASE") != "false"
@@ -28,20 +51,63 @@ func main() {
 				// Provide configuration
 				config.Load,
 				// Provide logger
-				zap.NewProduction,
+				newLogger,
 				// Provide Echo server
 				newEchoServer,
 			),
 			// Database module
 			db.Module,
 			fx.Provide(
-				// Provide PostgreSQL storage
-				func(database *db.DB, logger *zap.Logger) storage.Storage {
-					return storage.NewPostgresStorage(database, logger)
+				// Provide PostgreSQL storage behind a circuit breaker and a cache.
+				// Instrumentation sits right on top of PostgreSQL, so it measures
+				// the calls that reach the database and not the cached or
+				// rejected ones
+				func(database *db.DB, cfg *config.Config, logger *zap.Logger) (*storage.BreakerStorage, error) {
+					postgres, err := storage.NewInstrumentedStorage(
+						storage.NewPostgresStorage(database, logger),
+						"postgres",
+						storageMetrics(cfg),
+					)
+					if err != nil {
+						return nil, fmt.Errorf("instrument storage: %w", err)
+					}
+
+					return storage.NewBreakerStorage(
+						postgres,
+						cfg.Database.Breaker.Threshold,
+						cfg.Database.Breaker.Cooldown,
+					), nil
+				},
+				func(breaker *storage.BreakerStorage, cfg *config.Config) *storage.CacheStorage {
+					return storage.NewCacheStorage(breaker, cfg.Cache.TTL)
+				},
+				func(cache *storage.CacheStorage) storage.Storage {
+					return cache
+				},
+				// Instances sharing the database elect the one running the janitor
+				func(database *db.DB) janitor.Elector {
+					return database
+				},
+				func(database *db.DB) notify.Elector {
+					return database
+				},
+				// Health reports the migration status of the database
+				func(database *db.DB) handler.Migrations {
+					return database
+				},
+				// Readiness pings the database
+				func(database *db.DB) handler.Pinger {
+					return database
+				},
+				// Requests fail fast while the breaker is open
+				func(breaker *storage.BreakerStorage) handler.StorageHealth {
+					return breaker
 				},
 				// Provide handler
 				handler.New,
 			),
+			// Warm the cache before the server starts listening
+			fx.Invoke(warmCache),
 		}
 	} else {
 		// Use in-memory storage
@@ -50,10 +116,28 @@ func main() {
 				// Provide configuration
 				config.Load,
 				// Provide logger
-				zap.NewProduction,
+				newLogger,
 				// Provide in-memory storage
-				func() storage.Storage {
-					return storage.NewMemoryStorage()
+				func(cfg *config.Config) (storage.Storage, error) {
+					return storage.NewInstrumentedStorage(storage.NewMemoryStorage(), "memory", storageMetrics(cfg))
+				},
+				// A single instance owns its memory, there is nothing to elect
+				func() janitor.Elector {
+					return nil
+				},
+				func() notify.Elector {
+					return nil
+				},
+				// There are no migrations to report on
+				func() handler.Migrations {
+					return nil
+				},
+				// Memory never goes down
+				func() handler.StorageHealth {
+					return nil
+				},
+				func() handler.Pinger {
+					return nil
 				},
 				// Provide handler
 				handler.New,
@@ -65,71 +149,515 @@ func main() {
 
 	app := fx.New(
 		fx.Options(providers...),
+		fx.Provide(newOIDC),
+		fx.Provide(week.New),
+		fx.Provide(integrity.New),
+		fx.Provide(gcal.New),
 		fx.Invoke(registerRoutes),
+		fx.Invoke(seedStorage),
+		fx.Provide(janitor.New),
+		fx.Invoke(startJanitor),
+		fx.Provide(
+			func() notify.HTTPClient {
+				return &http.Client{Timeout: notifyTimeout}
+			},
+			notify.NewNotifiers,
+			notify.NewDispatcher,
+			notify.NewWatcher,
+			notify.NewMonitor,
+			notify.NewDigest,
+			notify.NewDryRun,
+		),
+		fx.Invoke(startWatcher),
+		fx.Invoke(startMonitor),
+		fx.Invoke(startDigest),
+		fx.Invoke(startIntegrity),
+		fx.Invoke(startCalendarSync),
 		fx.Invoke(startServer),
+		fx.StopTimeout(stopTimeout),
 	)
 
 	app.Run()
 }
 
+// newLogger builds the logger from the logging configuration.
+func newLogger(cfg *config.Config) (*zap.Logger, error) {
+	return logging.New(cfg.Logging)
+}
+
 // newEchoServer creates a new Echo server with middleware.
-func newEchoServer(logger *zap.Logger) *echo.Echo {
+func newEchoServer(cfg *config.Config, logger *zap.Logger) *echo.Echo {
 	e := echo.New()
 	e.HideBanner = true
+	// Requests and responses may be protobuf in place of JSON
+	e.Binder = &handler.Binder{}
+	e.JSONSerializer = handler.Serializer{}
+	// Internal error text never reaches clients
+	e.HTTPErrorHandler = handler.ErrorHandler(e)
 
 	// Add middleware
 	e.Use(middleware.RequestID())
 	e.Use(middleware.Recover())
-	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
-		LogURI:    true,
-		LogStatus: true,
-		LogError:  true,
-		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
-			if v.Error != nil {
-				logger.Error("request failed",
-					zap.String("uri", v.URI),
-					zap.Int("status", v.Status),
-					zap.Error(v.Error),
-				)
-			} else {
-				logger.Info("request",
-					zap.String("uri", v.URI),
-					zap.Int("status", v.Status),
-				)
-			}
-			return nil
-		},
-	}))
+	if cfg.Logging.AccessLog {
+		e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+			LogURI:       true,
+			LogStatus:    true,
+			LogError:     true,
+			LogRequestID: true,
+			LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+				if v.Error != nil {
+					logger.Error("request failed",
+						zap.String("uri", handler.RedactURI(v.URI)),
+						zap.Int("status", v.Status),
+						zap.String("request_id", v.RequestID),
+						zap.Error(v.Error),
+					)
+				} else {
+					logger.Info("request",
+						zap.String("uri", handler.RedactURI(v.URI)),
+						zap.Int("status", v.Status),
+						zap.String("request_id", v.RequestID),
+					)
+				}
+				return nil
+			},
+		}))
+	}
+	e.Use(handler.Limit(cfg.Server.MaxInFlight, cfg.Server.QueueSize, cfg.Server.QueueTimeout))
+	e.Use(handler.Timeout(cfg.Server.RequestTimeout))
 
 	return e
 }
 
 // registerRoutes registers all HTTP routes.
-func registerRoutes(e *echo.Echo, h *handler.Handler) {
+func registerRoutes(e *echo.Echo, h *handler.Handler, d *notify.Dispatcher, o *auth.OIDC, m handler.Migrations, sh handler.StorageHealth, p handler.Pinger, w *week.Conventions, ic *integrity.Checker, cs *gcal.Syncer, dr *notify.DryRun, cfg *config.Config) {
+	h.ReadOnly().Configure(cfg.Server.ReadOnly, cfg.Server.ReadOnlyReason)
+	h.SetDispatcher(d)
+	h.SetOIDC(o)
+	h.SetAPIKeys(cfg.Admin.Keys)
+	h.SetQuotas(cfg.Quota)
+	h.SetRoutingKeys(cfg.Routing.Keys)
+	h.SetWeeks(w)
+	h.SetOncallMaxAge(cfg.Server.OncallMaxAge)
+	h.SetHierarchyDepth(cfg.Hierarchy.MaxDepth)
+	h.SetIntegrity(ic)
+	h.SetUI(cfg.UI)
+	h.SetCalendarSync(cs)
+	h.SetDryRun(dr)
+	h.SetHandoffNoteTTL(cfg.HandoffNotes.TTL)
+	h.AllowUnsignedWebhooks(cfg.Notify.Webhook.AllowUnsigned)
+	h.SetMigrations(m, migrations.Latest())
+	h.SetStorageHealth(sh)
+	h.SetPinger(p)
+	e.Use(h.Drain().Middleware())
+	e.Use(h.ReadOnly().Middleware())
+	e.Use(h.StorageGuard())
+	e.Use(h.ScopedTokens())
+
 	e.GET("/health", h.Health)
-	e.POST("/schedule", h.CreateSchedule)
+	e.GET("/healthz", h.Liveness)
+	e.GET("/readyz", h.Readiness)
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	e.POST("/schedule", h.CreateSchedule, h.Force(cfg.Admin.Token))
 	e.GET("/schedule", h.GetSchedule)
+	e.GET("/schedule/:id", h.GetScheduleByID)
+	e.PATCH("/schedule/:id", h.UpdateSchedule, h.Force(cfg.Admin.Token))
+	e.GET("/oncall", h.Oncall)
+	e.GET("/oncall/all", h.AllOncall)
+	e.GET("/schema/schedule-request", h.ScheduleRequestSchema)
+	e.POST("/schedule/:id/pins", h.CreatePin, h.Force(cfg.Admin.Token))
+	e.GET("/schedule/:id/pins", h.ListPins)
+	e.GET("/schedule/:id/diff", h.ScheduleDiff)
+	e.DELETE("/schedule/:id/pins/:pin", h.DeletePin, h.Force(cfg.Admin.Token))
+	e.POST("/schedule/:id/force-next", h.ForceNext, h.Force(cfg.Admin.Token))
+	e.DELETE("/schedule/:id/force-next/:pin", h.CancelForceNext, h.Force(cfg.Admin.Token))
+	e.GET("/schedule/:id/swaps/suggestions", h.SwapSuggestions)
+	e.POST("/schedule/:id/swap-requests", h.CreateSwapRequest)
+	e.GET("/schedule/:id/swap-requests", h.ListSwapRequests)
+	e.POST("/swap-requests/:id/accept", h.AcceptSwapRequest, h.Force(cfg.Admin.Token))
+	e.POST("/swap-requests/:id/decline", h.DeclineSwapRequest)
+	e.PUT("/schedule/:id/rotation", h.SetRotation, h.Force(cfg.Admin.Token))
+	e.POST("/schedule/:id/advance", h.AdvanceRotation, h.Force(cfg.Admin.Token))
+	e.GET("/schedules", h.FindSchedules)
+	e.GET("/schedules/search", h.SearchSchedules)
+	e.GET("/members/:name/shifts", h.MemberShifts)
+	e.GET("/members/:name/availability", h.MemberAvailability)
+	e.POST("/members/:name/unavailability", h.AddUnavailability)
+	e.GET("/members/:name/unavailability", h.ListUnavailability)
+	e.DELETE("/members/:name/unavailability/:id", h.CancelUnavailability)
+	e.POST("/users/:name/rename", h.RenameMember, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.GET("/teams/:team", h.GetTeamSchedules)
+	e.DELETE("/teams/:team", h.DeleteTeam, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token), h.Force(cfg.Admin.Token))
+	e.GET("/teams/:team/schedules", h.ListTeamSchedules)
+	e.GET("/teams/:team/calendar.ics", h.ExportCalendar)
+	e.GET("/teams/:team/calendar/sync", h.CalendarSync)
+	e.POST("/teams/:team/calendar/token", h.CreateCalendarToken, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.DELETE("/teams/:team/calendar/token/:id", h.DeleteCalendarToken, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.GET("/teams/:team/oncall/export.csv", h.ExportOncall)
+	e.GET("/teams/:team/oncall/week", h.WeekOncall)
+	e.GET("/teams/:team/oncall/explain", h.ExplainOncall)
+	e.GET("/teams/:team/timeline", h.TeamTimeline)
+	e.GET("/teams/:team/stats", h.TeamStats)
+	e.GET("/teams/:team/compensation", h.TeamCompensation)
+	e.GET("/teams/:team/recommendations", h.TeamRecommendations)
+	e.GET("/teams/:team/handoffs", h.TeamHandoffs)
+	e.POST("/teams/:team/handoff-notes", h.CreateHandoffNote, h.Authenticate(auth.RoleReader, cfg.Admin.Token))
+	e.GET("/teams/:team/handoff-notes", h.ListHandoffNotes)
+	e.PUT("/teams/:team/handoff-notes/:id", h.UpdateHandoffNote, h.Authenticate(auth.RoleReader, cfg.Admin.Token))
+	e.DELETE("/teams/:team/handoff-notes/:id", h.DeleteHandoffNote, h.Authenticate(auth.RoleReader, cfg.Admin.Token))
+	e.GET("/teams/:team/coverage/public", h.PublicCoverage)
+	e.GET("/teams/:team/export", h.ExportTeam)
+	e.GET("/teams/:team/export/grafana-oncall", h.ExportGrafanaOnCall)
+	e.POST("/teams/:team/notifications/dry-run", h.NotificationDryRun, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.POST("/schedules/import/ics", h.ImportCalendar, h.Force(cfg.Admin.Token))
+	e.POST("/schedules/import/opsgenie", h.ImportOpsgenie, h.Force(cfg.Admin.Token))
+	e.POST("/teams/:team/pause", h.PauseTeam)
+	e.POST("/teams/:team/unpause", h.UnpauseTeam)
+	e.GET("/teams/:team/members", h.ListTeamMembers)
+	e.POST("/teams/:team/freeze", h.FreezeTeam, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.GET("/teams/:team/freezes", h.ListFreezes)
+	e.DELETE("/teams/:team/freezes/:id", h.CancelFreeze, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.POST("/teams/:team/blackouts", h.AddBlackout, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.GET("/teams/:team/blackouts", h.ListBlackouts)
+	e.DELETE("/teams/:team/blackouts/:id", h.CancelBlackout, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.PUT("/teams/:team/members/:name", h.SetTeamMember, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.DELETE("/teams/:team/members/:name", h.RemoveTeamMember, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.GET("/teams/:team/groups", h.ListGroups)
+	e.POST("/teams/:team/groups", h.CreateGroup, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.PUT("/teams/:team/groups/:name", h.UpdateGroup, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.DELETE("/teams/:team/groups/:name", h.DeleteGroup, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	e.POST("/teams/:team/merge", h.MergeTeams, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token), h.Force(cfg.Admin.Token))
+	e.GET("/teams/:team/settings", h.GetTeamSettings)
+	e.PATCH("/teams/:team/settings", h.UpdateTeamSettings, h.Authenticate(auth.RoleAdmin, cfg.Admin.Token), h.Force(cfg.Admin.Token))
+	e.GET("/teams/:team/integrity", h.TeamIntegrity)
+
+	e.GET("/ui", h.UI)
+	e.GET("/ui/*", h.UI)
+
+	e.GET("/auth/login", h.Login)
+	e.GET("/auth/callback", h.Callback)
+	e.POST("/auth/logout", h.Logout)
+	e.GET("/auth/me", h.Me, h.Authenticate(auth.RoleReader, cfg.Admin.Token))
+
+	admin := e.Group("/admin", h.Authenticate(auth.RoleAdmin, cfg.Admin.Token))
+	admin.PUT("/readonly", h.SetReadOnly)
+	admin.GET("/backup", h.Backup)
+	admin.POST("/restore", h.Restore)
+	admin.POST("/webhooks", h.CreateWebhook)
+	admin.GET("/webhooks", h.ListWebhooks)
+	admin.DELETE("/webhooks/:id", h.DeleteWebhook)
+	admin.POST("/apikeys", h.CreateAPIKey)
+	admin.GET("/apikeys", h.ListAPIKeys)
+	admin.DELETE("/apikeys/:id", h.RevokeAPIKey)
+	admin.POST("/tokens", h.CreateScopedToken)
+	admin.GET("/tokens", h.ListScopedTokens)
+	admin.DELETE("/tokens/:id", h.RevokeScopedToken)
+	admin.GET("/integrity", h.Integrity)
+
+	scim := e.Group("/scim/v2", handler.SCIM(cfg.SCIM.Token))
+	scim.POST("/Users", h.CreateSCIMUser)
+	scim.GET("/Users", h.ListSCIMUsers)
+	scim.GET("/Users/:id", h.GetSCIMUser)
+	scim.PATCH("/Users/:id", h.PatchSCIMUser)
+	scim.DELETE("/Users/:id", h.DeleteSCIMUser)
 }
 
-// startServer starts the HTTP server with graceful shutdown.
-func startServer(lc fx.Lifecycle, e *echo.Echo, cfg *config.Config, logger *zap.Logger) {
+// newOIDC discovers the OpenID Connect provider interactive users sign in
+// with, it is nil when sign-in is disabled.
+func newOIDC(cfg *config.Config) (*auth.OIDC, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), oidcTimeout)
+	defer cancel()
+
+	return auth.NewOIDC(ctx, cfg.OIDC, &http.Client{Timeout: oidcTimeout})
+}
+
+// storageMetrics returns the registry the storage calls are measured on, nil
+// when storage metrics are disabled.
+func storageMetrics(cfg *config.Config) prometheus.Registerer {
+	if !cfg.Metrics.Storage {
+		return nil
+	}
+
+	return prometheus.DefaultRegisterer
+}
+
+// warmCache loads all teams into the cache on start when enabled in the config.
+func warmCache(lc fx.Lifecycle, cache *storage.CacheStorage, cfg *config.Config, logger *zap.Logger) {
+	if !cfg.Cache.Warmup {
+		return
+	}
+
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			addr := fmt.Sprintf("%s:%d", cfg.Server.Address, cfg.Server.Port)
-			logger.Info("starting server", zap.String("address", addr))
+			ctx, cancel := context.WithTimeout(ctx, cfg.Cache.WarmupBudget)
+			defer cancel()
 
-			// Start server in a goroutine
-			go func() {
-				if err := e.Start(addr); err != nil {
-					logger.Error("server failed", zap.Error(err))
+			cache.Warm(ctx, logger.Named("cache"))
+			return nil
+		},
+	})
+}
+
+// seedStorage loads the seed file on start when one is configured. A missing
+// or malformed file only fails the start in strict mode.
+func seedStorage(lc fx.Lifecycle, h *handler.Handler, cfg *config.Config, logger *zap.Logger) {
+	if cfg.Seed.File == "" {
+		return
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			f, err := os.Open(cfg.Seed.File)
+			if err != nil {
+				if cfg.Seed.Strict {
+					return fmt.Errorf("failed to open seed file: %w", err)
 				}
+				logger.Warn("failed to open seed file, skipping it", zap.Error(err))
+				return nil
+			}
+			defer f.Close()
+
+			if _, err := h.Seed(ctx, f, cfg.Seed.Strict); err != nil {
+				return fmt.Errorf("failed to seed storage: %w", err)
+			}
+			return nil
+		},
+	})
+}
+
+// startJanitor runs the janitor in the background when enabled in the config.
+func startJanitor(lc fx.Lifecycle, j *janitor.Janitor, cfg *config.Config) {
+	if !cfg.Janitor.Enabled {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				j.Loop(ctx, cfg.Janitor.Interval)
 			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// startWatcher watches the teams for handoffs and gaps in the background when
+// a notification channel is configured.
+func startWatcher(lc fx.Lifecycle, w *notify.Watcher, d *notify.Dispatcher, cfg *config.Config) {
+	if !d.Enabled() || cfg.Notify.Interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
 
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				w.Loop(ctx, cfg.Notify.Interval)
+			}()
 			return nil
 		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// startMonitor evaluates the upcoming coverage of the teams in the background
+// when a notification channel is configured.
+func startMonitor(lc fx.Lifecycle, m *notify.Monitor, d *notify.Dispatcher, cfg *config.Config) {
+	if !d.Enabled() || cfg.Notify.Gaps.Interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				m.Loop(ctx, cfg.Notify.Gaps.Interval)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// startIntegrity checks the members the schedules reference in the
+// background, keeping the findings metric current, when an interval is
+// configured.
+func startIntegrity(lc fx.Lifecycle, ic *integrity.Checker, cfg *config.Config) {
+	if cfg.Integrity.Interval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				ic.Loop(ctx, cfg.Integrity.Interval)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// startCalendarSync pushes the shifts of the teams to their Google calendars
+// in the background when enabled in the config.
+func startCalendarSync(lc fx.Lifecycle, cs *gcal.Syncer, cfg *config.Config) {
+	if !cs.Enabled() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				cs.Loop(ctx, cfg.GoogleCalendar.Interval)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// startDigest sends the daily digests in the background when a notification
+// channel and a digest time are configured.
+func startDigest(lc fx.Lifecycle, digest *notify.Digest, d *notify.Dispatcher) {
+	if !d.Enabled() || !digest.Enabled() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				digest.Loop(ctx, notify.DigestInterval)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// startServer starts a server on every listener and drains them on shutdown.
+// Each listener is shut down on its own once all of them are drained.
+func startServer(lc fx.Lifecycle, e *echo.Echo, h *handler.Handler, cfg *config.Config, logger *zap.Logger) error {
+	// Plain listeners redirect to the first TLS listener
+	httpsPort := 0
+	for _, listener := range cfg.Server.Listeners {
+		if listener.TLS.Enabled() {
+			httpsPort = listener.Port
+			break
+		}
+	}
+
+	// The socket is served next to the listeners, unless only on it
+	listeners := []*handler.Listener{}
+	if !cfg.Server.Socket.Enabled() || !cfg.Server.Socket.Only {
+		for _, listenerCfg := range cfg.Server.Listeners {
+			if listenerCfg.RedirectToHTTPS && httpsPort == 0 {
+				return fmt.Errorf("listener on port %d redirects to https without any tls listener", listenerCfg.Port)
+			}
+
+			listeners = append(listeners, handler.NewListener(listenerCfg, e, httpsPort, logger))
+		}
+	}
+	if cfg.Server.Socket.Enabled() {
+		if _, err := cfg.Server.Socket.FileMode(); err != nil {
+			return err
+		}
+
+		listeners = append(listeners, handler.NewSocketListener(cfg.Server.Socket, e, logger))
+	}
+
+	for _, listener := range listeners {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				return listener.Start()
+			},
+			OnStop: func(ctx context.Context) error {
+				return listener.Shutdown(ctx, cfg.Server.ShutdownTimeout)
+			},
+		})
+	}
+
+	// Appended last, so every listener is drained at once before any of them stops
+	lc.Append(fx.Hook{
 		OnStop: func(ctx context.Context) error {
-			logger.Info("shutting down server")
-			return e.Shutdown(ctx)
+			logger.Info("draining server",
+				zap.Duration("drain_delay", cfg.Server.DrainDelay),
+				zap.Duration("shutdown_timeout", cfg.Server.ShutdownTimeout),
+			)
+			h.Drain().Begin(ctx, cfg.Server.DrainDelay)
+			return nil
 		},
 	})
+
+	return nil
 }
@@ -0,0 +1,2 @@
+ALTER TABLE schedules
+DROP COLUMN IF EXISTS cron;
@@ -0,0 +1,3 @@
+-- Schedules may recur on a cron expression instead of schedule_days
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS cron VARCHAR(255);
@@ -0,0 +1,3 @@
+ALTER TABLE schedules
+DROP COLUMN IF EXISTS anchor,
+DROP COLUMN IF EXISTS rrule;
@@ -0,0 +1,4 @@
+-- Schedules may recur on an RFC 5545 RRULE anchored at a date
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS rrule VARCHAR(1024),
+ADD COLUMN IF NOT EXISTS anchor DATE;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_audit_log_team;
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS team_pauses;
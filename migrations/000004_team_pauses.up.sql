@@ -0,0 +1,19 @@
+-- Maintenance windows during which a team has no on-call member
+CREATE TABLE IF NOT EXISTS team_pauses (
+  team_id INTEGER PRIMARY KEY REFERENCES teams (id) ON DELETE CASCADE,
+  reason TEXT NOT NULL DEFAULT '',
+  since TIMESTAMP WITH TIME ZONE NOT NULL,
+  until TIMESTAMP WITH TIME ZONE
+);
+
+-- Changes made through the storage layer
+CREATE TABLE IF NOT EXISTS audit_log (
+  id SERIAL PRIMARY KEY,
+  at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+  actor VARCHAR(255) NOT NULL,
+  action VARCHAR(255) NOT NULL,
+  team VARCHAR(255) NOT NULL,
+  detail TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_team ON audit_log (team, id);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_schedules_valid_until;
+
+ALTER TABLE schedules
+DROP COLUMN IF EXISTS deleted_at,
+DROP COLUMN IF EXISTS valid_until;
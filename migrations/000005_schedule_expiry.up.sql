@@ -0,0 +1,8 @@
+-- Schedules may end at a point in time, after which they are soft-deleted
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS valid_until TIMESTAMP WITH TIME ZONE,
+ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_schedules_valid_until ON schedules (valid_until)
+WHERE
+  deleted_at IS NULL;
@@ -0,0 +1,9 @@
+-- Subscriptions to on-call events delivered as HTTP requests
+CREATE TABLE IF NOT EXISTS webhooks (
+  id BIGSERIAL PRIMARY KEY,
+  team VARCHAR(255) NOT NULL DEFAULT '',
+  url TEXT NOT NULL,
+  secret TEXT NOT NULL DEFAULT '',
+  events TEXT[] NOT NULL DEFAULT '{}',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
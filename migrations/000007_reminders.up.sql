@@ -0,0 +1,5 @@
+-- Shift reminders already sent, so a restart does not send them again
+CREATE TABLE IF NOT EXISTS sent_reminders (
+  key TEXT PRIMARY KEY,
+  sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
@@ -0,0 +1,9 @@
+-- Tokens granting calendar clients access to the feeds, only their hashes are stored
+CREATE TABLE IF NOT EXISTS calendar_tokens (
+  id BIGSERIAL PRIMARY KEY,
+  team VARCHAR(255) NOT NULL,
+  member VARCHAR(255) NOT NULL DEFAULT '',
+  hash TEXT NOT NULL UNIQUE,
+  expires_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
@@ -0,0 +1,3 @@
+ALTER TABLE schedules
+DROP COLUMN IF EXISTS notes,
+DROP COLUMN IF EXISTS description;
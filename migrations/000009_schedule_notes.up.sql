@@ -0,0 +1,4 @@
+-- Free-form context of a schedule, the notes may hold markdown and are stored verbatim
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+ADD COLUMN IF NOT EXISTS notes TEXT NOT NULL DEFAULT '';
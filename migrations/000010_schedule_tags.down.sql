@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_schedule_tags_tag;
+
+DROP TABLE IF EXISTS schedule_tags;
@@ -0,0 +1,10 @@
+-- Tags group schedules across teams, in the order they were given
+CREATE TABLE IF NOT EXISTS schedule_tags (
+  schedule_id INTEGER REFERENCES schedules (id) ON DELETE CASCADE,
+  tag VARCHAR(32) NOT NULL,
+  position INTEGER NOT NULL,
+  PRIMARY KEY (schedule_id, tag)
+);
+
+-- Tag filters look schedules up by tag
+CREATE INDEX IF NOT EXISTS idx_schedule_tags_tag ON schedule_tags (tag, schedule_id);
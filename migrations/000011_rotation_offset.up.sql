@@ -0,0 +1,3 @@
+-- Index of the member on duty during the first week of the rotation
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS rotation_offset INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE schedule_days
+DROP COLUMN IF EXISTS assignee_id;
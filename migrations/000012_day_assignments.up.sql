@@ -0,0 +1,3 @@
+-- Member always on duty on the day, for schedules that do not rotate
+ALTER TABLE schedule_days
+ADD COLUMN IF NOT EXISTS assignee_id INTEGER REFERENCES users (id) ON DELETE SET NULL;
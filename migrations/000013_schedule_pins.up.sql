@@ -0,0 +1,9 @@
+-- Members put on duty for the shifts of a schedule on a date, in place of the rotation
+CREATE TABLE IF NOT EXISTS schedule_pins (
+  id BIGSERIAL PRIMARY KEY,
+  schedule_id INTEGER REFERENCES schedules (id) ON DELETE CASCADE,
+  pin_date DATE NOT NULL,
+  member VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+  UNIQUE (schedule_id, pin_date)
+);
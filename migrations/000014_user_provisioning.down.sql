@@ -0,0 +1,4 @@
+ALTER TABLE users
+DROP COLUMN IF EXISTS provisioned,
+DROP COLUMN IF EXISTS active,
+DROP COLUMN IF EXISTS emails;
@@ -0,0 +1,5 @@
+-- Users provisioned by an identity provider, whose rotations skip them while deactivated
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS emails TEXT[] NOT NULL DEFAULT '{}',
+ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN IF NOT EXISTS provisioned BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,11 @@
+-- Windows during which the schedules and pins of a team must not change
+CREATE TABLE IF NOT EXISTS team_freezes (
+  id BIGSERIAL PRIMARY KEY,
+  team_id INTEGER NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+  reason TEXT NOT NULL DEFAULT '',
+  starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_freezes_team ON team_freezes (team_id, starts_at);
@@ -0,0 +1,3 @@
+-- Manual schedules keep the member at rotation_offset on duty until it is changed
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS rotation_manual BOOLEAN NOT NULL DEFAULT FALSE;
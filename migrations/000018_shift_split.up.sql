@@ -0,0 +1,3 @@
+-- Number of consecutive members sharing every shift, 0 and 1 leave shifts whole
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS shift_split INTEGER NOT NULL DEFAULT 0;
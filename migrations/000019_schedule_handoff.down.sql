@@ -0,0 +1,3 @@
+ALTER TABLE schedules
+DROP COLUMN IF EXISTS handoff_time,
+DROP COLUMN IF EXISTS handoff_day;
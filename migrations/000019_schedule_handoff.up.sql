@@ -0,0 +1,4 @@
+-- Weekly instant in UTC the rotation moves on at, instead of the shift starts
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS handoff_day SMALLINT CHECK (handoff_day BETWEEN 0 AND 6),
+ADD COLUMN IF NOT EXISTS handoff_time TIME;
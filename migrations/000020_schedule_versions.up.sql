@@ -0,0 +1,9 @@
+-- Definitions of schedules over time, one row each time a schedule is added or changed
+CREATE TABLE IF NOT EXISTS schedule_versions (
+  id BIGSERIAL PRIMARY KEY,
+  schedule_id INTEGER NOT NULL REFERENCES schedules (id) ON DELETE CASCADE,
+  since TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+  definition JSONB NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_versions_schedule_since ON schedule_versions (schedule_id, since);
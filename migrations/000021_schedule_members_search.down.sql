@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_schedule_members_user_schedule;
@@ -0,0 +1,2 @@
+-- Lets member searches go from a user to their schedules and positions
+CREATE INDEX IF NOT EXISTS idx_schedule_members_user_schedule ON schedule_members (user_id, schedule_id, position);
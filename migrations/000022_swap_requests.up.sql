@@ -0,0 +1,15 @@
+-- Requests to hand a shift of a schedule over, taking effect once the target accepts
+CREATE TABLE IF NOT EXISTS swap_requests (
+  id BIGSERIAL PRIMARY KEY,
+  schedule_id INTEGER REFERENCES schedules (id) ON DELETE CASCADE,
+  shift_start TIMESTAMP WITH TIME ZONE NOT NULL,
+  shift_end TIMESTAMP WITH TIME ZONE NOT NULL,
+  from_member VARCHAR(255) NOT NULL,
+  to_member VARCHAR(255) NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'pending',
+  token_hash TEXT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+  decided_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_swap_requests_schedule ON swap_requests (schedule_id, id);
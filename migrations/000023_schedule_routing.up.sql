@@ -0,0 +1,3 @@
+-- Where the alert router pages the member on duty, by destination key
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS routing JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,11 @@
+-- Windows during which a member cannot take pages, their turns go to the next available member
+CREATE TABLE IF NOT EXISTS member_unavailability (
+  id BIGSERIAL PRIMARY KEY,
+  member VARCHAR(255) NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
+
+CREATE INDEX IF NOT EXISTS idx_member_unavailability_member ON member_unavailability (member, ends_at);
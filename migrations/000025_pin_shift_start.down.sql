@@ -0,0 +1,2 @@
+ALTER TABLE schedule_pins
+DROP COLUMN IF EXISTS shift_start;
@@ -0,0 +1,3 @@
+-- Pins limited to a single shift of their date, as forcing the next shift records
+ALTER TABLE schedule_pins
+ADD COLUMN IF NOT EXISTS shift_start TIMESTAMP WITH TIME ZONE;
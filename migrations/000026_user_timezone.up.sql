@@ -0,0 +1,3 @@
+-- IANA zone the times of a user are rendered in, empty for UTC
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '';
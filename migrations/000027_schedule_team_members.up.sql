@@ -0,0 +1,3 @@
+-- Schedules rotating through the members of their team instead of a list of their own
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS team_members BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS schedule_member_refs;
+
+DROP INDEX IF EXISTS idx_team_group_members_group;
+
+DROP TABLE IF EXISTS team_group_members;
+
+DROP TABLE IF EXISTS team_groups;
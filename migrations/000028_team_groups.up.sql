@@ -0,0 +1,30 @@
+-- Named groups of members of a team, such as sub-teams, schedules can rotate through
+CREATE TABLE IF NOT EXISTS team_groups (
+  id SERIAL PRIMARY KEY,
+  team_id INTEGER NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+  name VARCHAR(64) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+  UNIQUE (team_id, name)
+);
+
+-- Members of the groups in the order they were added, former ones keep
+-- their turns until the next handoff after their removal. The members a
+-- group is created with have no added_at.
+CREATE TABLE IF NOT EXISTS team_group_members (
+  group_id INTEGER NOT NULL REFERENCES team_groups (id) ON DELETE CASCADE,
+  member VARCHAR(255) NOT NULL,
+  position INTEGER NOT NULL,
+  added_at TIMESTAMP WITH TIME ZONE,
+  removed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_group_members_group ON team_group_members (group_id, position);
+
+-- Members of schedules as given, group references included, in order
+CREATE TABLE IF NOT EXISTS schedule_member_refs (
+  schedule_id INTEGER REFERENCES schedules (id) ON DELETE CASCADE,
+  member VARCHAR(255) NOT NULL,
+  position INTEGER NOT NULL,
+  PRIMARY KEY (schedule_id, position)
+);
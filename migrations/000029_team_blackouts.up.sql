@@ -0,0 +1,11 @@
+-- Windows during which nobody is expected on call for a team
+CREATE TABLE IF NOT EXISTS team_blackouts (
+  id BIGSERIAL PRIMARY KEY,
+  team_id INTEGER NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+  reason TEXT NOT NULL DEFAULT '',
+  starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_blackouts_team ON team_blackouts (team_id, starts_at);
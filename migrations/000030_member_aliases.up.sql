@@ -0,0 +1,9 @@
+-- Former names of renamed members, schedule versions recorded before a rename
+-- keep the former name and resolve it to the current one
+CREATE TABLE IF NOT EXISTS member_aliases (
+  alias VARCHAR(255) PRIMARY KEY,
+  member VARCHAR(255) NOT NULL,
+  renamed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
+
+CREATE INDEX IF NOT EXISTS idx_member_aliases_member ON member_aliases (member);
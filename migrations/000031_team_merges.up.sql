@@ -0,0 +1,9 @@
+-- Teams merged into another, so requests for them can point at the team
+-- they are part of now
+CREATE TABLE IF NOT EXISTS team_merges (
+  source VARCHAR(255) PRIMARY KEY,
+  target VARCHAR(255) NOT NULL,
+  merged_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_merges_target ON team_merges (target);
@@ -0,0 +1,9 @@
+-- Teams inheriting the schedules of a parent team, e.g. sub-teams of an
+-- umbrella team sharing its night schedule
+CREATE TABLE IF NOT EXISTS team_parents (
+  team VARCHAR(255) PRIMARY KEY,
+  parent VARCHAR(255) NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
+
+CREATE INDEX IF NOT EXISTS idx_team_parents_parent ON team_parents (parent);
@@ -0,0 +1,5 @@
+-- Teams whose anonymized coverage is served to public status pages
+CREATE TABLE IF NOT EXISTS team_public_coverage (
+  team VARCHAR(255) PRIMARY KEY,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
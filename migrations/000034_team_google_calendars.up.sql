@@ -0,0 +1,6 @@
+-- Google calendars the shifts of teams are pushed to
+CREATE TABLE IF NOT EXISTS team_google_calendars (
+  team VARCHAR(255) PRIMARY KEY,
+  calendar_id VARCHAR(1024) NOT NULL,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
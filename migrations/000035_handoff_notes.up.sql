@@ -0,0 +1,17 @@
+-- Context left by the member on duty for a shift to whoever takes the next one over
+CREATE TABLE IF NOT EXISTS handoff_notes (
+  id BIGSERIAL PRIMARY KEY,
+  team_id INTEGER NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+  schedule_id INTEGER NOT NULL REFERENCES schedules (id) ON DELETE CASCADE,
+  member VARCHAR(255) NOT NULL,
+  shift_start TIMESTAMP WITH TIME ZONE NOT NULL,
+  shift_end TIMESTAMP WITH TIME ZONE NOT NULL,
+  author VARCHAR(255) NOT NULL,
+  text TEXT NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW (),
+  UNIQUE (schedule_id, shift_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_handoff_notes_team ON handoff_notes (team_id, id);
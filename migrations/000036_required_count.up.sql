@@ -0,0 +1,3 @@
+-- Number of consecutive members on duty together for every shift, 0 and 1 put a single member on duty
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS required_count INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,13 @@
+-- Tokens of read-only automation limited to scopes, teams and a rate, only their hashes are stored
+CREATE TABLE IF NOT EXISTS scoped_tokens (
+  id BIGSERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL,
+  scopes TEXT[] NOT NULL DEFAULT '{}',
+  teams TEXT[] NOT NULL DEFAULT '{}',
+  rate_limit INTEGER NOT NULL DEFAULT 0,
+  hash TEXT NOT NULL UNIQUE,
+  uses BIGINT NOT NULL DEFAULT 0,
+  last_used_at TIMESTAMP WITH TIME ZONE,
+  revoked_at TIMESTAMP WITH TIME ZONE,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+);
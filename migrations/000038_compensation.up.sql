@@ -0,0 +1,3 @@
+-- Rate classes and multipliers the hours on duty for a schedule are paid at, NULL when untagged
+ALTER TABLE schedules
+ADD COLUMN IF NOT EXISTS compensation JSONB;
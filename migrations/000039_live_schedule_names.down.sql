@@ -0,0 +1,15 @@
+DROP INDEX IF EXISTS idx_schedules_team_name;
+
+-- Expired schedules named like another schedule of their team take their ID along
+UPDATE schedules s
+SET
+  name = s.name || ' (deleted ' || s.id || ')'
+WHERE
+  s.deleted_at IS NOT NULL
+  AND EXISTS (
+    SELECT 1 FROM schedules o
+    WHERE o.team_id = s.team_id AND o.name = s.name AND o.id <> s.id
+  );
+
+ALTER TABLE schedules
+ADD CONSTRAINT schedules_team_id_name_key UNIQUE (team_id, name);
@@ -0,0 +1,8 @@
+-- Only the live schedules of a team have unique names, the ones of expired
+-- schedules can be taken again
+ALTER TABLE schedules
+DROP CONSTRAINT IF EXISTS schedules_team_id_name_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_schedules_team_name ON schedules (team_id, name)
+WHERE
+  deleted_at IS NULL;
@@ -0,0 +1,42 @@
+// Package migrations embeds the database migrations, so the binary knows the
+// schema version it expects.
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// FS holds the migrations, named <version>_<title>.<up|down>.sql.
+//
+//go:embed *.sql
+var FS embed.FS
+
+// Latest returns the version of the last up migration, the one a database
+// migrated by this binary is at.
+func Latest() uint {
+	entries, err := fs.ReadDir(FS, ".")
+	if err != nil {
+		return 0
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".up.sql")
+		if !ok {
+			continue
+		}
+
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		latest = max(latest, uint(version))
+	}
+
+	return latest
+}
@@ -0,0 +1,21 @@
+package migrations
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatest(t *testing.T) {
+	ups, err := fs.Glob(FS, "*.up.sql")
+	require.NoError(t, err)
+	downs, err := fs.Glob(FS, "*.down.sql")
+	require.NoError(t, err)
+
+	// Versions are numbered from one without gaps, each with its down migration
+	require.NotEmpty(t, ups)
+	assert.Len(t, downs, len(ups))
+	assert.Equal(t, uint(len(ups)), Latest())
+}
@@ -0,0 +1,858 @@
+// Protobuf mirrors of the JSON messages of the REST API, exchanged as
+// application/x-protobuf. Fields keep the names and meaning of their JSON
+// counterparts, see the README.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.8
+// 	protoc        (unknown)
+// source: pkg/oncallpb/oncall.proto
+
+package oncallpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ScheduleRequest mirrors the schedule creation request.
+type ScheduleRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name           string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description    string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Notes          string                 `protobuf:"bytes,4,opt,name=notes,proto3" json:"notes,omitempty"`
+	Team           string                 `protobuf:"bytes,5,opt,name=team,proto3" json:"team,omitempty"`
+	Members        []string               `protobuf:"bytes,6,rep,name=members,proto3" json:"members,omitempty"`
+	Days           []string               `protobuf:"bytes,7,rep,name=days,proto3" json:"days,omitempty"`
+	Cron           string                 `protobuf:"bytes,8,opt,name=cron,proto3" json:"cron,omitempty"`
+	Rrule          string                 `protobuf:"bytes,9,opt,name=rrule,proto3" json:"rrule,omitempty"`
+	Anchor         string                 `protobuf:"bytes,10,opt,name=anchor,proto3" json:"anchor,omitempty"`
+	Start          string                 `protobuf:"bytes,11,opt,name=start,proto3" json:"start,omitempty"`
+	End            string                 `protobuf:"bytes,12,opt,name=end,proto3" json:"end,omitempty"`
+	ValidUntil     string                 `protobuf:"bytes,13,opt,name=valid_until,json=validUntil,proto3" json:"valid_until,omitempty"`
+	Tags           []string               `protobuf:"bytes,14,rep,name=tags,proto3" json:"tags,omitempty"`
+	RotationOffset int32                  `protobuf:"varint,15,opt,name=rotation_offset,json=rotationOffset,proto3" json:"rotation_offset,omitempty"`
+	CurrentMember  string                 `protobuf:"bytes,16,opt,name=current_member,json=currentMember,proto3" json:"current_member,omitempty"`
+	Assignment     string                 `protobuf:"bytes,17,opt,name=assignment,proto3" json:"assignment,omitempty"`
+	DayAssignments map[string]string      `protobuf:"bytes,18,rep,name=day_assignments,json=dayAssignments,proto3" json:"day_assignments,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Rotation       string                 `protobuf:"bytes,19,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	Split          int32                  `protobuf:"varint,20,opt,name=split,proto3" json:"split,omitempty"`
+	Handoff        *Handoff               `protobuf:"bytes,21,opt,name=handoff,proto3" json:"handoff,omitempty"`
+	Routing        map[string]string      `protobuf:"bytes,22,rep,name=routing,proto3" json:"routing,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	UseTeamMembers bool                   `protobuf:"varint,23,opt,name=use_team_members,json=useTeamMembers,proto3" json:"use_team_members,omitempty"`
+	CurrentUntil   string                 `protobuf:"bytes,24,opt,name=current_until,json=currentUntil,proto3" json:"current_until,omitempty"`
+	RequiredCount  int32                  `protobuf:"varint,25,opt,name=required_count,json=requiredCount,proto3" json:"required_count,omitempty"`
+	Compensation   *Compensation          `protobuf:"bytes,26,opt,name=compensation,proto3" json:"compensation,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ScheduleRequest) Reset() {
+	*x = ScheduleRequest{}
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduleRequest) ProtoMessage() {}
+
+func (x *ScheduleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduleRequest.ProtoReflect.Descriptor instead.
+func (*ScheduleRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_oncallpb_oncall_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ScheduleRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetNotes() string {
+	if x != nil {
+		return x.Notes
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *ScheduleRequest) GetDays() []string {
+	if x != nil {
+		return x.Days
+	}
+	return nil
+}
+
+func (x *ScheduleRequest) GetCron() string {
+	if x != nil {
+		return x.Cron
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetRrule() string {
+	if x != nil {
+		return x.Rrule
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetAnchor() string {
+	if x != nil {
+		return x.Anchor
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetStart() string {
+	if x != nil {
+		return x.Start
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetEnd() string {
+	if x != nil {
+		return x.End
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetValidUntil() string {
+	if x != nil {
+		return x.ValidUntil
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ScheduleRequest) GetRotationOffset() int32 {
+	if x != nil {
+		return x.RotationOffset
+	}
+	return 0
+}
+
+func (x *ScheduleRequest) GetCurrentMember() string {
+	if x != nil {
+		return x.CurrentMember
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetAssignment() string {
+	if x != nil {
+		return x.Assignment
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetDayAssignments() map[string]string {
+	if x != nil {
+		return x.DayAssignments
+	}
+	return nil
+}
+
+func (x *ScheduleRequest) GetRotation() string {
+	if x != nil {
+		return x.Rotation
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetSplit() int32 {
+	if x != nil {
+		return x.Split
+	}
+	return 0
+}
+
+func (x *ScheduleRequest) GetHandoff() *Handoff {
+	if x != nil {
+		return x.Handoff
+	}
+	return nil
+}
+
+func (x *ScheduleRequest) GetRouting() map[string]string {
+	if x != nil {
+		return x.Routing
+	}
+	return nil
+}
+
+func (x *ScheduleRequest) GetUseTeamMembers() bool {
+	if x != nil {
+		return x.UseTeamMembers
+	}
+	return false
+}
+
+func (x *ScheduleRequest) GetCurrentUntil() string {
+	if x != nil {
+		return x.CurrentUntil
+	}
+	return ""
+}
+
+func (x *ScheduleRequest) GetRequiredCount() int32 {
+	if x != nil {
+		return x.RequiredCount
+	}
+	return 0
+}
+
+func (x *ScheduleRequest) GetCompensation() *Compensation {
+	if x != nil {
+		return x.Compensation
+	}
+	return nil
+}
+
+// Handoff mirrors the weekly handoff of a schedule request.
+type Handoff struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Day           string                 `protobuf:"bytes,1,opt,name=day,proto3" json:"day,omitempty"`
+	Time          string                 `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Handoff) Reset() {
+	*x = Handoff{}
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Handoff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Handoff) ProtoMessage() {}
+
+func (x *Handoff) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Handoff.ProtoReflect.Descriptor instead.
+func (*Handoff) Descriptor() ([]byte, []int) {
+	return file_pkg_oncallpb_oncall_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Handoff) GetDay() string {
+	if x != nil {
+		return x.Day
+	}
+	return ""
+}
+
+func (x *Handoff) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+// Compensation mirrors the rate class of a schedule request.
+type Compensation struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	RateClass         string                 `protobuf:"bytes,1,opt,name=rate_class,json=rateClass,proto3" json:"rate_class,omitempty"`
+	Multiplier        float64                `protobuf:"fixed64,2,opt,name=multiplier,proto3" json:"multiplier,omitempty"`
+	WeekendRateClass  string                 `protobuf:"bytes,3,opt,name=weekend_rate_class,json=weekendRateClass,proto3" json:"weekend_rate_class,omitempty"`
+	WeekendMultiplier float64                `protobuf:"fixed64,4,opt,name=weekend_multiplier,json=weekendMultiplier,proto3" json:"weekend_multiplier,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Compensation) Reset() {
+	*x = Compensation{}
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Compensation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Compensation) ProtoMessage() {}
+
+func (x *Compensation) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Compensation.ProtoReflect.Descriptor instead.
+func (*Compensation) Descriptor() ([]byte, []int) {
+	return file_pkg_oncallpb_oncall_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Compensation) GetRateClass() string {
+	if x != nil {
+		return x.RateClass
+	}
+	return ""
+}
+
+func (x *Compensation) GetMultiplier() float64 {
+	if x != nil {
+		return x.Multiplier
+	}
+	return 0
+}
+
+func (x *Compensation) GetWeekendRateClass() string {
+	if x != nil {
+		return x.WeekendRateClass
+	}
+	return ""
+}
+
+func (x *Compensation) GetWeekendMultiplier() float64 {
+	if x != nil {
+		return x.WeekendMultiplier
+	}
+	return 0
+}
+
+// OncallResponse mirrors the on-call lookup response.
+type OncallResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Oncall         string                 `protobuf:"bytes,1,opt,name=oncall,proto3" json:"oncall,omitempty"`
+	Time           string                 `protobuf:"bytes,2,opt,name=time,proto3" json:"time,omitempty"`
+	Local          string                 `protobuf:"bytes,3,opt,name=local,proto3" json:"local,omitempty"`
+	Stale          bool                   `protobuf:"varint,4,opt,name=stale,proto3" json:"stale,omitempty"`
+	Routing        map[string]string      `protobuf:"bytes,5,rep,name=routing,proto3" json:"routing,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	SubstitutedFor string                 `protobuf:"bytes,6,opt,name=substituted_for,json=substitutedFor,proto3" json:"substituted_for,omitempty"`
+	Oncalls        []string               `protobuf:"bytes,7,rep,name=oncalls,proto3" json:"oncalls,omitempty"`
+	Warning        string                 `protobuf:"bytes,8,opt,name=warning,proto3" json:"warning,omitempty"`
+	InheritedFrom  string                 `protobuf:"bytes,9,opt,name=inherited_from,json=inheritedFrom,proto3" json:"inherited_from,omitempty"`
+	HandoffNote    *HandoffNote           `protobuf:"bytes,10,opt,name=handoff_note,json=handoffNote,proto3" json:"handoff_note,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *OncallResponse) Reset() {
+	*x = OncallResponse{}
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OncallResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OncallResponse) ProtoMessage() {}
+
+func (x *OncallResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OncallResponse.ProtoReflect.Descriptor instead.
+func (*OncallResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_oncallpb_oncall_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *OncallResponse) GetOncall() string {
+	if x != nil {
+		return x.Oncall
+	}
+	return ""
+}
+
+func (x *OncallResponse) GetTime() string {
+	if x != nil {
+		return x.Time
+	}
+	return ""
+}
+
+func (x *OncallResponse) GetLocal() string {
+	if x != nil {
+		return x.Local
+	}
+	return ""
+}
+
+func (x *OncallResponse) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+func (x *OncallResponse) GetRouting() map[string]string {
+	if x != nil {
+		return x.Routing
+	}
+	return nil
+}
+
+func (x *OncallResponse) GetSubstitutedFor() string {
+	if x != nil {
+		return x.SubstitutedFor
+	}
+	return ""
+}
+
+func (x *OncallResponse) GetOncalls() []string {
+	if x != nil {
+		return x.Oncalls
+	}
+	return nil
+}
+
+func (x *OncallResponse) GetWarning() string {
+	if x != nil {
+		return x.Warning
+	}
+	return ""
+}
+
+func (x *OncallResponse) GetInheritedFrom() string {
+	if x != nil {
+		return x.InheritedFrom
+	}
+	return ""
+}
+
+func (x *OncallResponse) GetHandoffNote() *HandoffNote {
+	if x != nil {
+		return x.HandoffNote
+	}
+	return nil
+}
+
+// HandoffNote mirrors the handoff note of the on-call lookup response.
+type HandoffNote struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Team          string                 `protobuf:"bytes,2,opt,name=team,proto3" json:"team,omitempty"`
+	ScheduleId    string                 `protobuf:"bytes,3,opt,name=schedule_id,json=scheduleId,proto3" json:"schedule_id,omitempty"`
+	Member        string                 `protobuf:"bytes,4,opt,name=member,proto3" json:"member,omitempty"`
+	Author        string                 `protobuf:"bytes,5,opt,name=author,proto3" json:"author,omitempty"`
+	Text          string                 `protobuf:"bytes,6,opt,name=text,proto3" json:"text,omitempty"`
+	ShiftStart    string                 `protobuf:"bytes,7,opt,name=shift_start,json=shiftStart,proto3" json:"shift_start,omitempty"`
+	ShiftEnd      string                 `protobuf:"bytes,8,opt,name=shift_end,json=shiftEnd,proto3" json:"shift_end,omitempty"`
+	ExpiresAt     string                 `protobuf:"bytes,9,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	Status        string                 `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt     string                 `protobuf:"bytes,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     string                 `protobuf:"bytes,12,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HandoffNote) Reset() {
+	*x = HandoffNote{}
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HandoffNote) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HandoffNote) ProtoMessage() {}
+
+func (x *HandoffNote) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HandoffNote.ProtoReflect.Descriptor instead.
+func (*HandoffNote) Descriptor() ([]byte, []int) {
+	return file_pkg_oncallpb_oncall_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *HandoffNote) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *HandoffNote) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetScheduleId() string {
+	if x != nil {
+		return x.ScheduleId
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetMember() string {
+	if x != nil {
+		return x.Member
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetShiftStart() string {
+	if x != nil {
+		return x.ShiftStart
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetShiftEnd() string {
+	if x != nil {
+		return x.ShiftEnd
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetExpiresAt() string {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *HandoffNote) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
+}
+
+// ErrorResponse mirrors the error response of every endpoint.
+type ErrorResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Error         string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Code          string                 `protobuf:"bytes,2,opt,name=code,proto3" json:"code,omitempty"`
+	CorrelationId string                 `protobuf:"bytes,3,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorResponse) Reset() {
+	*x = ErrorResponse{}
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorResponse) ProtoMessage() {}
+
+func (x *ErrorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_oncallpb_oncall_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorResponse.ProtoReflect.Descriptor instead.
+func (*ErrorResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_oncallpb_oncall_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ErrorResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ErrorResponse) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *ErrorResponse) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+var File_pkg_oncallpb_oncall_proto protoreflect.FileDescriptor
+
+const file_pkg_oncallpb_oncall_proto_rawDesc = "" +
+	"\n" +
+	"\x19pkg/oncallpb/oncall.proto\x12\toncall.v1\"\xec\a\n" +
+	"\x0fScheduleRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05notes\x18\x04 \x01(\tR\x05notes\x12\x12\n" +
+	"\x04team\x18\x05 \x01(\tR\x04team\x12\x18\n" +
+	"\amembers\x18\x06 \x03(\tR\amembers\x12\x12\n" +
+	"\x04days\x18\a \x03(\tR\x04days\x12\x12\n" +
+	"\x04cron\x18\b \x01(\tR\x04cron\x12\x14\n" +
+	"\x05rrule\x18\t \x01(\tR\x05rrule\x12\x16\n" +
+	"\x06anchor\x18\n" +
+	" \x01(\tR\x06anchor\x12\x14\n" +
+	"\x05start\x18\v \x01(\tR\x05start\x12\x10\n" +
+	"\x03end\x18\f \x01(\tR\x03end\x12\x1f\n" +
+	"\vvalid_until\x18\r \x01(\tR\n" +
+	"validUntil\x12\x12\n" +
+	"\x04tags\x18\x0e \x03(\tR\x04tags\x12'\n" +
+	"\x0frotation_offset\x18\x0f \x01(\x05R\x0erotationOffset\x12%\n" +
+	"\x0ecurrent_member\x18\x10 \x01(\tR\rcurrentMember\x12\x1e\n" +
+	"\n" +
+	"assignment\x18\x11 \x01(\tR\n" +
+	"assignment\x12W\n" +
+	"\x0fday_assignments\x18\x12 \x03(\v2..oncall.v1.ScheduleRequest.DayAssignmentsEntryR\x0edayAssignments\x12\x1a\n" +
+	"\brotation\x18\x13 \x01(\tR\brotation\x12\x14\n" +
+	"\x05split\x18\x14 \x01(\x05R\x05split\x12,\n" +
+	"\ahandoff\x18\x15 \x01(\v2\x12.oncall.v1.HandoffR\ahandoff\x12A\n" +
+	"\arouting\x18\x16 \x03(\v2'.oncall.v1.ScheduleRequest.RoutingEntryR\arouting\x12(\n" +
+	"\x10use_team_members\x18\x17 \x01(\bR\x0euseTeamMembers\x12#\n" +
+	"\rcurrent_until\x18\x18 \x01(\tR\fcurrentUntil\x12%\n" +
+	"\x0erequired_count\x18\x19 \x01(\x05R\rrequiredCount\x12;\n" +
+	"\fcompensation\x18\x1a \x01(\v2\x17.oncall.v1.CompensationR\fcompensation\x1aA\n" +
+	"\x13DayAssignmentsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a:\n" +
+	"\fRoutingEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"/\n" +
+	"\aHandoff\x12\x10\n" +
+	"\x03day\x18\x01 \x01(\tR\x03day\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\tR\x04time\"\xaa\x01\n" +
+	"\fCompensation\x12\x1d\n" +
+	"\n" +
+	"rate_class\x18\x01 \x01(\tR\trateClass\x12\x1e\n" +
+	"\n" +
+	"multiplier\x18\x02 \x01(\x01R\n" +
+	"multiplier\x12,\n" +
+	"\x12weekend_rate_class\x18\x03 \x01(\tR\x10weekendRateClass\x12-\n" +
+	"\x12weekend_multiplier\x18\x04 \x01(\x01R\x11weekendMultiplier\"\xa5\x03\n" +
+	"\x0eOncallResponse\x12\x16\n" +
+	"\x06oncall\x18\x01 \x01(\tR\x06oncall\x12\x12\n" +
+	"\x04time\x18\x02 \x01(\tR\x04time\x12\x14\n" +
+	"\x05local\x18\x03 \x01(\tR\x05local\x12\x14\n" +
+	"\x05stale\x18\x04 \x01(\bR\x05stale\x12@\n" +
+	"\arouting\x18\x05 \x03(\v2&.oncall.v1.OncallResponse.RoutingEntryR\arouting\x12'\n" +
+	"\x0fsubstituted_for\x18\x06 \x01(\tR\x0esubstitutedFor\x12\x18\n" +
+	"\aoncalls\x18\a \x03(\tR\aoncalls\x12\x18\n" +
+	"\awarning\x18\b \x01(\tR\awarning\x12%\n" +
+	"\x0einherited_from\x18\t \x01(\tR\rinheritedFrom\x129\n" +
+	"\fhandoff_note\x18\n" +
+	" \x01(\v2\x16.oncall.v1.HandoffNoteR\vhandoffNote\x1a:\n" +
+	"\fRoutingEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc9\x02\n" +
+	"\vHandoffNote\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04team\x18\x02 \x01(\tR\x04team\x12\x1f\n" +
+	"\vschedule_id\x18\x03 \x01(\tR\n" +
+	"scheduleId\x12\x16\n" +
+	"\x06member\x18\x04 \x01(\tR\x06member\x12\x16\n" +
+	"\x06author\x18\x05 \x01(\tR\x06author\x12\x12\n" +
+	"\x04text\x18\x06 \x01(\tR\x04text\x12\x1f\n" +
+	"\vshift_start\x18\a \x01(\tR\n" +
+	"shiftStart\x12\x1b\n" +
+	"\tshift_end\x18\b \x01(\tR\bshiftEnd\x12\x1d\n" +
+	"\n" +
+	"expires_at\x18\t \x01(\tR\texpiresAt\x12\x16\n" +
+	"\x06status\x18\n" +
+	" \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\v \x01(\tR\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\f \x01(\tR\tupdatedAt\"`\n" +
+	"\rErrorResponse\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\x12\x12\n" +
+	"\x04code\x18\x02 \x01(\tR\x04code\x12%\n" +
+	"\x0ecorrelation_id\x18\x03 \x01(\tR\rcorrelationIdB=Z;github.com/1995parham-learning/oncall-schedule/pkg/oncallpbb\x06proto3"
+
+var (
+	file_pkg_oncallpb_oncall_proto_rawDescOnce sync.Once
+	file_pkg_oncallpb_oncall_proto_rawDescData []byte
+)
+
+func file_pkg_oncallpb_oncall_proto_rawDescGZIP() []byte {
+	file_pkg_oncallpb_oncall_proto_rawDescOnce.Do(func() {
+		file_pkg_oncallpb_oncall_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_oncallpb_oncall_proto_rawDesc), len(file_pkg_oncallpb_oncall_proto_rawDesc)))
+	})
+	return file_pkg_oncallpb_oncall_proto_rawDescData
+}
+
+var file_pkg_oncallpb_oncall_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_pkg_oncallpb_oncall_proto_goTypes = []any{
+	(*ScheduleRequest)(nil), // 0: oncall.v1.ScheduleRequest
+	(*Handoff)(nil),         // 1: oncall.v1.Handoff
+	(*Compensation)(nil),    // 2: oncall.v1.Compensation
+	(*OncallResponse)(nil),  // 3: oncall.v1.OncallResponse
+	(*HandoffNote)(nil),     // 4: oncall.v1.HandoffNote
+	(*ErrorResponse)(nil),   // 5: oncall.v1.ErrorResponse
+	nil,                     // 6: oncall.v1.ScheduleRequest.DayAssignmentsEntry
+	nil,                     // 7: oncall.v1.ScheduleRequest.RoutingEntry
+	nil,                     // 8: oncall.v1.OncallResponse.RoutingEntry
+}
+var file_pkg_oncallpb_oncall_proto_depIdxs = []int32{
+	6, // 0: oncall.v1.ScheduleRequest.day_assignments:type_name -> oncall.v1.ScheduleRequest.DayAssignmentsEntry
+	1, // 1: oncall.v1.ScheduleRequest.handoff:type_name -> oncall.v1.Handoff
+	7, // 2: oncall.v1.ScheduleRequest.routing:type_name -> oncall.v1.ScheduleRequest.RoutingEntry
+	2, // 3: oncall.v1.ScheduleRequest.compensation:type_name -> oncall.v1.Compensation
+	8, // 4: oncall.v1.OncallResponse.routing:type_name -> oncall.v1.OncallResponse.RoutingEntry
+	4, // 5: oncall.v1.OncallResponse.handoff_note:type_name -> oncall.v1.HandoffNote
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_pkg_oncallpb_oncall_proto_init() }
+func file_pkg_oncallpb_oncall_proto_init() {
+	if File_pkg_oncallpb_oncall_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_oncallpb_oncall_proto_rawDesc), len(file_pkg_oncallpb_oncall_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_pkg_oncallpb_oncall_proto_goTypes,
+		DependencyIndexes: file_pkg_oncallpb_oncall_proto_depIdxs,
+		MessageInfos:      file_pkg_oncallpb_oncall_proto_msgTypes,
+	}.Build()
+	File_pkg_oncallpb_oncall_proto = out.File
+	file_pkg_oncallpb_oncall_proto_goTypes = nil
+	file_pkg_oncallpb_oncall_proto_depIdxs = nil
+}
@@ -0,0 +1,78 @@
+package rotation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronStarBit mirrors the bit robfig/cron sets on a field written as "*" or "?".
+const cronStarBit = 1 << 63
+
+// cronLookahead bounds the search for an occurrence, like robfig/cron does.
+const cronLookahead = 5 * 365 * 24 * time.Hour
+
+// cronParser only accepts the standard 5-field format, so expressions with
+// seconds and descriptors such as "@every 5s" are rejected.
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// CronSpec is a parsed shift recurrence. Each occurrence marks the start of a shift.
+type CronSpec struct {
+	spec *cron.SpecSchedule
+}
+
+// ParseCron parses a standard 5-field cron expression evaluated in UTC.
+//
+// Unlike classic cron, when both the day-of-month and the day-of-week fields
+// are restricted an occurrence has to match both of them, so "0 9 1-7 * MON"
+// is 9:00 on the first Monday of each month.
+func ParseCron(expr string) (CronSpec, error) {
+	if len(strings.Fields(expr)) != 5 {
+		return CronSpec{}, fmt.Errorf("invalid cron expression %q: expected 5 fields (minute hour day month weekday)", expr)
+	}
+
+	sched, err := cronParser.Parse(expr)
+	if err != nil {
+		return CronSpec{}, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+
+	spec, ok := sched.(*cron.SpecSchedule)
+	if !ok {
+		return CronSpec{}, fmt.Errorf("invalid cron expression %q", expr)
+	}
+
+	return CronSpec{spec: spec}, nil
+}
+
+// Next returns the first occurrence strictly after t, or the zero time when
+// there is none within five years.
+func (c CronSpec) Next(t time.Time) time.Time {
+	limit := t.Add(cronLookahead)
+
+	for n := c.spec.Next(t); !n.IsZero() && n.Before(limit); n = c.spec.Next(n) {
+		if c.dayMatches(n) {
+			return n
+		}
+	}
+
+	return time.Time{}
+}
+
+// Covers reports whether at falls within [occurrence, occurrence+duration)
+// of the most recent occurrence.
+func (c CronSpec) Covers(at time.Time, duration time.Duration) bool {
+	n := c.Next(at.Add(-duration))
+	return !n.IsZero() && !n.After(at)
+}
+
+// dayMatches applies the day-of-month and day-of-week fields together when both
+// are restricted; robfig/cron accepts a match on either of them in that case.
+func (c CronSpec) dayMatches(t time.Time) bool {
+	if c.spec.Dom&cronStarBit != 0 || c.spec.Dow&cronStarBit != 0 {
+		return true
+	}
+
+	return c.spec.Dom&(1<<uint(t.Day())) != 0 && c.spec.Dow&(1<<uint(t.Weekday())) != 0
+}
@@ -0,0 +1,34 @@
+package rotation_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/1995parham-learning/oncall-schedule/pkg/rotation"
+)
+
+func ExampleDuties() {
+	weekday := rotation.Schedule{
+		ID:      "1",
+		Name:    "Weekday",
+		Members: []string{"Alice", "Bob"},
+		Days:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC),
+		End:     time.Date(0, 1, 1, 17, 0, 0, 0, time.UTC),
+		// An accepted swap, Bob takes the Wednesday of Alice
+		Pins: []rotation.Pin{{Date: time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC), Member: "Bob"}},
+	}
+
+	from := time.Date(2025, 4, 29, 0, 0, 0, 0, time.UTC)
+	for _, duty := range rotation.Duties([]rotation.Schedule{weekday}, from, from.AddDate(0, 0, 7)) {
+		fmt.Println(duty.Start.Format("Mon Jan 2 15:04"), "to", duty.End.Format("15:04"), duty.Member)
+	}
+
+	// Output:
+	// Tue Apr 29 09:00 to 17:00 Alice
+	// Wed Apr 30 09:00 to 17:00 Bob
+	// Thu May 1 09:00 to 17:00 Alice
+	// Fri May 2 09:00 to 17:00 Alice
+	// Mon May 5 09:00 to 17:00 Bob
+}
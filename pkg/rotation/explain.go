@@ -0,0 +1,216 @@
+package rotation
+
+import (
+	"slices"
+	"time"
+)
+
+// Outcomes of the schedules considered by Explain.
+const (
+	// OutcomeSelected is the schedule the lookup answers from.
+	OutcomeSelected = "selected"
+	// OutcomeShadowed is a schedule with a shift running that an earlier
+	// schedule takes precedence over.
+	OutcomeShadowed = "shadowed"
+	// OutcomeNoShift is a schedule without a shift running.
+	OutcomeNoShift = "no_shift"
+	// OutcomeEnded is a schedule past its ValidUntil.
+	OutcomeEnded = "ended"
+	// OutcomeNoMembers is a schedule without members, which is skipped.
+	OutcomeNoMembers = "no_members"
+)
+
+// Rules putting the member on duty for the shift of the selected schedule.
+const (
+	RulePin           = "pin"
+	RuleDayAssignment = "day_assignment"
+	RuleRotation      = "rotation"
+)
+
+// Explanation is the trace of how the lookup resolves an instant, which is
+// what OncallAt and OncallsAt answer with.
+type Explanation struct {
+	// At is the instant in UTC, which the schedules are matched in.
+	At time.Time
+	// Candidates are the schedules considered, in the order they take
+	// precedence in.
+	Candidates []Candidate
+	// Selected is the index in Candidates of the schedule the lookup
+	// answers from, -1 when nobody is on call.
+	Selected int
+	// Duty is the duty of the selected schedule, nil when nobody is on duty
+	// for it, e.g. on a day assigned to an inactive member.
+	Duty *Duty
+}
+
+// Candidate is a schedule considered by the lookup. Priority is its
+// position in the list, the first schedule with a shift running answers.
+type Candidate struct {
+	ID       string
+	Name     string
+	Priority int
+	Outcome  string
+	// Shift is the shift running at the instant, nil when none is.
+	Shift *Shift
+	// Part is the index of the part of the shift running at the instant,
+	// out of Parts, see Schedule.Parts.
+	Part  int
+	Parts int
+	// Resolution is how the member on duty was worked out, only set for
+	// the selected schedule.
+	Resolution *Resolution
+}
+
+// Resolution is how the member on duty for a part of a shift is worked out.
+type Resolution struct {
+	// Rule is the rule the member comes from, a pin taking precedence over
+	// day assignments and the rotation.
+	Rule string
+	// Pin is the pin applied under RulePin.
+	Pin *Pin
+	// Weekday is the UTC weekday of the start of the shift, which day
+	// assignments are matched on.
+	Weekday time.Weekday
+	// Rotation is the rotation math of schedules without day assignments,
+	// set under RulePin too to show who the pin took the place of.
+	Rotation *RotationMath
+}
+
+// RotationMath is how RotationIndex puts a member on duty: the turn is
+// Offset plus the rotation periods elapsed from Anchor to At, taken modulo
+// the members in Turns, and inactive members are skipped from it. Split
+// parts then move on by SplitSteps active members.
+type RotationMath struct {
+	// At is the instant the rotation is evaluated at, the start of the
+	// shift, or of its part with a handoff.
+	At time.Time
+	// Manual schedules, and schedules without an anchor, do not rotate.
+	Manual bool
+	// Anchor is the instant the periods are counted from, zero when the
+	// members do not rotate.
+	Anchor         time.Time
+	Period         time.Duration
+	Elapsed        time.Duration
+	ElapsedPeriods int
+	Offset         int
+	Turn           int
+	Turns          []string
+	Skipped        []string
+	Index          int
+	SplitSteps     int
+}
+
+// Explain returns the trace of how the lookup resolves the instant: every
+// schedule considered and why it answers or not, and for the one answering
+// the rule and the rotation math putting its member on duty.
+func Explain(schedules []Schedule, at time.Time) Explanation {
+	at = at.UTC()
+
+	explanation := Explanation{At: at, Candidates: make([]Candidate, 0, len(schedules)), Selected: -1}
+
+	for i, s := range schedules {
+		candidate := Candidate{ID: s.ID, Name: s.Name, Priority: i}
+
+		shift, running := s.ShiftAt(at)
+		if running {
+			candidate.Shift = &shift
+			candidate.Part = s.part(shift, at)
+			candidate.Parts = len(s.Parts(shift))
+		}
+
+		switch {
+		case len(s.Members) == 0:
+			candidate.Outcome = OutcomeNoMembers
+		case !s.validAt(at):
+			candidate.Outcome = OutcomeEnded
+		case !running:
+			candidate.Outcome = OutcomeNoShift
+		case explanation.Selected != -1:
+			candidate.Outcome = OutcomeShadowed
+		default:
+			candidate.Outcome = OutcomeSelected
+			candidate.Resolution = s.resolution(shift, candidate.Part)
+			explanation.Selected = i
+
+			if duty, ok := s.DutyAt(at); ok {
+				explanation.Duty = &duty
+			}
+		}
+
+		explanation.Candidates = append(explanation.Candidates, candidate)
+	}
+
+	return explanation
+}
+
+// resolution returns how the member on duty for the part of the shift is
+// worked out, following partMember.
+func (s Schedule) resolution(shift Shift, part int) *Resolution {
+	resolution := &Resolution{Rule: RuleRotation, Weekday: shift.Start.UTC().Weekday()}
+
+	if len(s.DayAssignments) > 0 {
+		resolution.Rule = RuleDayAssignment
+	} else {
+		resolution.Rotation = s.rotationMath(shift, part)
+	}
+
+	for _, pin := range s.Pins {
+		if pin.Date.Equal(PinDate(shift.Start)) && (pin.ShiftStart.IsZero() || pin.ShiftStart.Equal(shift.Start)) {
+			resolution.Rule = RulePin
+			resolution.Pin = &pin
+			break
+		}
+	}
+
+	return resolution
+}
+
+// rotationMath returns the rotation math putting the member of the rotation
+// on duty for the part of the shift.
+func (s Schedule) rotationMath(shift Shift, part int) *RotationMath {
+	at := shift.Start
+	if s.handsOff() {
+		at = s.Parts(shift)[part].Start
+	}
+
+	trace := &RotationMath{
+		At:             at,
+		Manual:         s.Manual || s.Anchor.IsZero(),
+		ElapsedPeriods: s.RotationPeriods(at),
+		Offset:         s.Offset,
+		Index:          -1,
+	}
+	if !trace.Manual {
+		trace.Anchor = s.rotationAnchor()
+		trace.Period = Period
+		trace.Elapsed = at.Sub(trace.Anchor)
+	}
+	trace.Turn = trace.Offset + trace.ElapsedPeriods
+
+	turns := s.Turns(at)
+	for _, index := range turns {
+		trace.Turns = append(trace.Turns, s.Members[index])
+	}
+
+	// The walk of RotationIndex, recording the inactive members it skips
+	n := len(turns)
+	for i := range n {
+		index := turns[((trace.Turn+i)%n+n)%n]
+		if !slices.Contains(s.Inactive, s.Members[index]) {
+			trace.Index = index
+			break
+		}
+		trace.Skipped = append(trace.Skipped, s.Members[index])
+	}
+
+	if s.splits() {
+		start := s.Parts(shift)[part].Start
+		for k, p := range s.splitParts(shift) {
+			if !start.Before(p.Start) {
+				trace.SplitSteps = k
+			}
+		}
+	}
+
+	return trace
+}
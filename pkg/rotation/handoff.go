@@ -0,0 +1,50 @@
+package rotation
+
+import "time"
+
+// Handoff is the weekly instant, in UTC, at which the rotation of a schedule
+// moves on to the next member, instead of the start of the shifts.
+type Handoff struct {
+	Day time.Weekday
+	// Time holds the time of day of the handoff, its date is ignored.
+	Time time.Time
+}
+
+// handsOff reports whether the rotation of the schedule moves on at its
+// handoff. Schedules that do not rotate ignore it.
+func (s Schedule) handsOff() bool {
+	return s.Handoff != nil && len(s.DayAssignments) == 0 && !s.Manual && !s.Anchor.IsZero()
+}
+
+// nextHandoff returns the first handoff of the schedule at or after the
+// given instant.
+func (s Schedule) nextHandoff(from time.Time) time.Time {
+	from = from.UTC()
+
+	for d := 0; ; d++ {
+		day := from.AddDate(0, 0, d)
+		handoff := time.Date(day.Year(), day.Month(), day.Day(),
+			s.Handoff.Time.Hour(), s.Handoff.Time.Minute(), s.Handoff.Time.Second(), 0, time.UTC)
+		if handoff.Weekday() == s.Handoff.Day && !handoff.Before(from) {
+			return handoff
+		}
+	}
+}
+
+// handoffParts cuts the parts of a shift at the handoffs falling inside
+// them, so each part has a single member of the rotation.
+func (s Schedule) handoffParts(parts []Shift) []Shift {
+	cut := make([]Shift, 0, len(parts))
+
+	for _, part := range parts {
+		for handoff := s.nextHandoff(part.Start); handoff.Before(part.End); handoff = handoff.Add(Period) {
+			if handoff.After(part.Start) {
+				cut = append(cut, Shift{Schedule: part.Schedule, Start: part.Start, End: handoff})
+				part.Start = handoff
+			}
+		}
+		cut = append(cut, part)
+	}
+
+	return cut
+}
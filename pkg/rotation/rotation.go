@@ -0,0 +1,282 @@
+// Package rotation expands on-call schedules into their shifts and works out
+// the members on duty for each of them, the way the on-call schedule service
+// answers its lookups. It is pure: it holds no state, reads no storage and
+// makes no calls, so other services can resolve schedules without running
+// the server.
+//
+// A Schedule recurs on weekdays, on a cron expression or on an RFC 5545
+// recurrence rule, and its members take turns every Period from its anchor.
+// Overrides are expressed as pins: a Pin puts a member on duty for a date,
+// or for the single shift starting at an instant, which is how forced shifts
+// and accepted swaps are applied. Members unavailable at an instant are
+// replaced with Substitute.
+//
+// Lists of schedules are resolved like the lookup does: at any instant the
+// first schedule, in order, with a shift running answers, and schedules
+// without members are skipped. Duties expands the shifts of a window along
+// with their members, Timeline and DutyTimeline split the window into the
+// stretches each shift answers for, and Gaps returns what is left uncovered.
+// Explain traces how the lookup resolves an instant.
+// Every instant is matched in UTC.
+package rotation
+
+import (
+	"slices"
+	"time"
+)
+
+// Period is how long a member of a schedule is on duty before the next one in
+// the list takes over.
+const Period = 7 * 24 * time.Hour
+
+// Schedule is a recurring shift along with the members taking turns for it.
+type Schedule struct {
+	// ID and Name identify the schedule in the shifts and duties of it.
+	ID   string
+	Name string
+	// Members take turns in order, see MemberOnDuty.
+	Members []string
+	// Days are the weekdays the shift runs on from Start to End, unless the
+	// schedule recurs on Cron or RRule.
+	Days []time.Weekday
+	// Cron is a standard 5-field cron expression the shifts start on, see
+	// ParseCron.
+	Cron string
+	// RRule is a recurrence rule the shifts start on, starting from the
+	// anchor, see ParseRRule.
+	RRule string
+	// Anchor is the date the rotation counts its periods from. Without one
+	// the members do not rotate.
+	Anchor time.Time
+	// Start and End hold the time of day of the shifts, their dates are
+	// ignored. The shifts last from Start to End.
+	Start time.Time
+	End   time.Time
+	// ValidUntil ends the schedule, it runs forever when zero.
+	ValidUntil time.Time
+	// Offset is the index of the member on duty during the first rotation
+	// period.
+	Offset int
+	// Manual schedules do not rotate with time: the member at Offset stays
+	// on duty.
+	Manual bool
+	// Split divides every shift evenly between as many consecutive members
+	// of the rotation, see Parts. Zero and one leave shifts whole.
+	Split int
+	// RequiredCount is how many members are on duty together for every
+	// shift, the member on duty and the next ones of the rotation, see
+	// Duty.Others. Zero and one put a single member on duty.
+	RequiredCount int
+	// Handoff is when the rotation moves on to the next member. Without it
+	// members take over at midnight UTC of the anchor's weekday and keep
+	// the shift running then.
+	Handoff *Handoff
+	// DayAssignments maps weekdays to the member always on duty on them.
+	// Schedules with assignments do not rotate.
+	DayAssignments map[time.Weekday]string
+	// Pins put members on duty on single dates or shifts.
+	Pins []Pin
+	// Inactive lists the members who are skipped by the rotation.
+	Inactive []string
+	// Joined maps the members who joined the rotation late to when they
+	// did, and Left those who left it to when they did, see Turns.
+	Joined map[string]time.Time
+	Left   map[string]time.Time
+}
+
+// Pin puts a member on duty for the shifts of a schedule starting on a date,
+// in place of the member the rotation gives. Pins never shift the rotation,
+// the turns after a pinned day are the same as without it.
+type Pin struct {
+	// Date is midnight UTC of the pinned day, see PinDate.
+	Date time.Time
+	// ShiftStart limits the pin to the single shift of the date starting at
+	// it. It is zero for the whole date.
+	ShiftStart time.Time
+	Member     string
+}
+
+// PinDate returns midnight UTC of the date of the instant in UTC, which is
+// the date pins are matched on.
+func PinDate(at time.Time) time.Time {
+	at = at.UTC()
+	return time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// pinnedMember returns the member pinned to the shift starting at the
+// instant, by its UTC date.
+func (s Schedule) pinnedMember(shiftStart time.Time) (string, bool) {
+	date := PinDate(shiftStart)
+	for _, pin := range s.Pins {
+		if pin.Date.Equal(date) && (pin.ShiftStart.IsZero() || pin.ShiftStart.Equal(shiftStart)) {
+			return pin.Member, true
+		}
+	}
+
+	return "", false
+}
+
+// MemberOnDuty returns the member on duty for the shift of the schedule
+// starting at the given instant. Members take turns every Period, counted
+// from midnight UTC of the anchor, and Offset is the index of the member on
+// duty during the first period. Without an anchor, or in manual schedules,
+// the members do not rotate and the one at Offset is always on duty.
+// Schedules with day assignments return the member assigned to the weekday
+// the shift starts on in UTC instead. A pin on the UTC date the shift starts
+// on takes precedence over both. Inactive members are skipped: the rotation
+// moves on to the next active member, and a day assigned to one is
+// uncovered. Pins still apply, as they are explicit.
+func (s Schedule) MemberOnDuty(shiftStart time.Time) (string, bool) {
+	if member, ok := s.pinnedMember(shiftStart); ok {
+		return member, true
+	}
+
+	if len(s.DayAssignments) > 0 {
+		member, ok := s.DayAssignments[shiftStart.UTC().Weekday()]
+		return member, ok && !slices.Contains(s.Inactive, member)
+	}
+
+	index, ok := s.RotationIndex(shiftStart)
+	if !ok {
+		return "", false
+	}
+
+	return s.Members[index], true
+}
+
+// RotationIndex returns the index in Members of the member the rotation puts
+// on duty at the given instant, ignoring pins and day assignments. Only the
+// members in Turns take turns, and inactive ones are skipped.
+func (s Schedule) RotationIndex(at time.Time) (int, bool) {
+	turns := s.Turns(at)
+	n := len(turns)
+	turn := s.Offset + s.RotationPeriods(at)
+	for i := range n {
+		if index := turns[((turn+i)%n+n)%n]; !slices.Contains(s.Inactive, s.Members[index]) {
+			return index, true
+		}
+	}
+
+	return 0, false
+}
+
+// Turns returns the indices in Members of the members taking turns in the
+// rotation period of the given instant, in rotation order. Members who
+// joined take turns from the period after the one they joined in, and those
+// who left until the end of the period they left in, so changes never apply
+// retroactively nor change who is on duty. When nobody would take turns all
+// of them do.
+func (s Schedule) Turns(at time.Time) []int {
+	turns := make([]int, 0, len(s.Members))
+	start := s.periodStart(at)
+	for i, member := range s.Members {
+		if s.Joined[member].After(start) {
+			continue
+		}
+		if left, ok := s.Left[member]; ok && !left.After(start) {
+			continue
+		}
+		turns = append(turns, i)
+	}
+
+	if len(turns) == 0 {
+		for i := range s.Members {
+			turns = append(turns, i)
+		}
+	}
+
+	return turns
+}
+
+// RotationPeriods returns how many rotation periods passed from midnight UTC
+// of the anchor to the given instant, negative before the anchor. With a
+// handoff the periods are counted from the first handoff at or after it
+// instead. It is always zero without an anchor and in manual schedules.
+func (s Schedule) RotationPeriods(at time.Time) int {
+	if s.Anchor.IsZero() || s.Manual {
+		return 0
+	}
+
+	elapsed := at.Sub(s.rotationAnchor())
+	periods := int(elapsed / Period)
+	// Periods before the anchor count backwards
+	if elapsed < 0 && elapsed%Period != 0 {
+		periods--
+	}
+
+	return periods
+}
+
+// rotationAnchor returns the instant the rotation periods are counted from,
+// midnight UTC of the anchor or the first handoff at or after it.
+func (s Schedule) rotationAnchor() time.Time {
+	anchor := time.Date(s.Anchor.Year(), s.Anchor.Month(), s.Anchor.Day(), 0, 0, 0, 0, time.UTC)
+	if s.handsOff() {
+		anchor = s.nextHandoff(anchor)
+	}
+
+	return anchor
+}
+
+// periodStart returns the start of the rotation period of the given
+// instant, the instant itself when the members do not rotate.
+func (s Schedule) periodStart(at time.Time) time.Time {
+	if s.Anchor.IsZero() || s.Manual {
+		return at
+	}
+
+	return s.rotationAnchor().Add(time.Duration(s.RotationPeriods(at)) * Period)
+}
+
+// MemberAt returns the member on duty at the given instant, using the start
+// of the running shift so a member keeps a shift crossing the end of a
+// rotation period. Split shifts, and shifts crossing the handoff of the
+// schedule, return the member of the running part. Outside of the shifts it
+// is the member of a shift starting at the instant.
+func (s Schedule) MemberAt(at time.Time) (string, bool) {
+	at = at.UTC()
+
+	shift, ok := s.ShiftAt(at)
+	if !ok {
+		return s.MemberOnDuty(at)
+	}
+
+	return s.partMember(shift, s.part(shift, at))
+}
+
+// Substitute returns the member taking the pages of the member on call at
+// the given instant, given the members unavailable then. That is the member
+// themselves unless they are unavailable, in which case it is the first
+// available and active member after them in the rotation order of the
+// schedule answering at the instant. It reports false when nobody is left.
+func Substitute(schedules []Schedule, member string, at time.Time, unavailable []string) (string, bool) {
+	if !slices.Contains(unavailable, member) {
+		return member, true
+	}
+
+	i, _, ok := Current(schedules, at)
+	if !ok {
+		return "", false
+	}
+
+	return schedules[i].nextAvailable(member, unavailable)
+}
+
+// nextAvailable returns the first member after the given one in rotation
+// order who is neither unavailable nor inactive. The walk starts from the
+// first member when the given one is not in rotation, e.g. pinned.
+func (s Schedule) nextAvailable(member string, unavailable []string) (string, bool) {
+	start := slices.Index(s.Members, member)
+
+	n := len(s.Members)
+	for i := 1; i <= n; i++ {
+		candidate := s.Members[((start+i)%n+n)%n]
+		if candidate == member || slices.Contains(s.Inactive, candidate) || slices.Contains(unavailable, candidate) {
+			continue
+		}
+
+		return candidate, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,353 @@
+package rotation
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock returns the time of day the schedules hold their start and end in.
+func clock(hour, minute int) time.Time {
+	return time.Date(0, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+// randomSchedule returns a schedule whose shifts never overlap one another,
+// recurring on weekdays, on a cron expression or on a rule, with any of the
+// features changing who is on duty.
+func randomSchedule(r *rand.Rand, name string) Schedule {
+	members := []string{"Alice", "Bob", "Charlie", "Dave", "Erin"}[:1+r.IntN(5)]
+
+	start := clock(r.IntN(20), 15*r.IntN(4))
+	sched := Schedule{
+		ID:      name,
+		Name:    name,
+		Members: members,
+		Start:   start,
+		// Shifts fit in their day, so they never run into the next one
+		End:    start.Add(time.Duration(1+r.IntN(23-start.Hour())) * time.Hour),
+		Offset: r.IntN(len(members)),
+	}
+
+	for day := range 7 {
+		if r.IntN(2) == 0 {
+			sched.Days = append(sched.Days, time.Weekday(day))
+		}
+	}
+	if len(sched.Days) == 0 {
+		sched.Days = []time.Weekday{time.Monday}
+	}
+
+	switch r.IntN(4) {
+	case 0:
+		sched.Cron = fmt.Sprintf("%d %d * * *", start.Minute(), start.Hour())
+	case 1:
+		sched.RRule = fmt.Sprintf("FREQ=DAILY;INTERVAL=%d", 1+r.IntN(3))
+		sched.Anchor = time.Date(2025, 4, 1+r.IntN(28), 0, 0, 0, 0, time.UTC)
+	}
+
+	if r.IntN(3) > 0 {
+		sched.Anchor = time.Date(2025, 4, 1+r.IntN(28), 0, 0, 0, 0, time.UTC)
+	}
+	if r.IntN(5) == 0 {
+		sched.Manual = true
+	}
+	if r.IntN(4) == 0 {
+		sched.Split = 1 + r.IntN(3)
+	}
+	if r.IntN(4) == 0 {
+		sched.RequiredCount = 1 + r.IntN(len(members))
+	}
+	if r.IntN(4) == 0 {
+		sched.Handoff = &Handoff{Day: time.Weekday(r.IntN(7)), Time: clock(r.IntN(24), 0)}
+	}
+	if r.IntN(6) == 0 && sched.Cron == "" && sched.RRule == "" {
+		sched.DayAssignments = make(map[time.Weekday]string)
+		for _, day := range sched.Days {
+			sched.DayAssignments[day] = members[r.IntN(len(members))]
+		}
+	}
+	if r.IntN(4) == 0 && len(members) > 1 {
+		sched.Inactive = []string{members[r.IntN(len(members))]}
+	}
+	for range r.IntN(3) {
+		sched.Pins = append(sched.Pins, Pin{
+			Date:   time.Date(2025, 5, 1+r.IntN(30), 0, 0, 0, 0, time.UTC),
+			Member: "Pat",
+		})
+	}
+	if r.IntN(5) == 0 {
+		sched.ValidUntil = time.Date(2025, 5, 1+r.IntN(30), r.IntN(24), 0, 0, 0, time.UTC)
+	}
+
+	return sched
+}
+
+// randomCases returns seeded lists of schedules along with a window in May
+// 2025, so failures are reproducible.
+func randomCases(t *testing.T) []struct {
+	schedules []Schedule
+	from, to  time.Time
+} {
+	t.Helper()
+
+	r := rand.New(rand.NewPCG(1373, 2025))
+
+	var cases []struct {
+		schedules []Schedule
+		from, to  time.Time
+	}
+	for range 200 {
+		var schedules []Schedule
+		for k := range 1 + r.IntN(3) {
+			schedules = append(schedules, randomSchedule(r, fmt.Sprintf("schedule-%d", k)))
+		}
+
+		from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(r.IntN(14*24*60)) * time.Minute)
+		to := from.Add(time.Duration(1+r.IntN(14*24*60)) * time.Minute)
+
+		cases = append(cases, struct {
+			schedules []Schedule
+			from, to  time.Time
+		}{schedules, from, to})
+	}
+
+	return cases
+}
+
+func TestDuties_NoOverlapWithinSchedule(t *testing.T) {
+	for i, c := range randomCases(t) {
+		for _, sched := range c.schedules {
+			duties := Duties([]Schedule{sched}, c.from, c.to)
+
+			for k, duty := range duties {
+				require.True(t, duty.Start.Before(duty.End), "case %d: empty duty %+v", i, duty)
+
+				members := duty.Members()
+				assert.Len(t, slices.Compact(slices.Sorted(slices.Values(members))), len(members),
+					"case %d: member on duty twice in %+v", i, duty)
+
+				if k > 0 {
+					assert.False(t, duties[k-1].End.After(duty.Start),
+						"case %d: %+v overlaps %+v", i, duties[k-1], duty)
+				}
+			}
+		}
+	}
+}
+
+func TestDuties_WithinWindow(t *testing.T) {
+	for i, c := range randomCases(t) {
+		for _, duty := range Duties(c.schedules, c.from, c.to) {
+			assert.True(t, duty.End.After(c.from) && duty.Start.Before(c.to),
+				"case %d: %+v outside [%s, %s)", i, duty, c.from, c.to)
+		}
+
+		for _, shift := range UpcomingShifts(c.schedules, c.from, c.to) {
+			assert.True(t, shift.Start.After(c.from) && !shift.Start.After(c.to),
+				"case %d: %+v outside (%s, %s]", i, shift, c.from, c.to)
+		}
+	}
+}
+
+func TestTimeline_PartitionsWindow(t *testing.T) {
+	for i, c := range randomCases(t) {
+		// Gaps count the shifts of schedules ending in the middle of one as
+		// covered whole, so the schedules run forever here
+		schedules := slices.Clone(c.schedules)
+		for k := range schedules {
+			schedules[k].ValidUntil = time.Time{}
+		}
+
+		stretches := Timeline(schedules, c.from, c.to)
+		gaps := Gaps(schedules, c.from, c.to)
+
+		// Stretches and gaps tile the window, in order and without overlap
+		var covered time.Duration
+		for k, stretch := range stretches {
+			require.True(t, !stretch.Start.Before(c.from) && !stretch.End.After(c.to) && stretch.Start.Before(stretch.End),
+				"case %d: %+v outside [%s, %s)", i, stretch, c.from, c.to)
+			if k > 0 {
+				require.False(t, stretches[k-1].End.After(stretch.Start), "case %d: %+v overlaps %+v", i, stretches[k-1], stretch)
+			}
+			for _, gap := range gaps {
+				require.False(t, gap.Overlaps(Gap{Start: stretch.Start, End: stretch.End}),
+					"case %d: %+v overlaps gap %+v", i, stretch, gap)
+			}
+			covered += stretch.End.Sub(stretch.Start)
+		}
+		for _, gap := range gaps {
+			covered += gap.End.Sub(gap.Start)
+		}
+
+		assert.Equal(t, c.to.Sub(c.from), covered, "case %d", i)
+
+		// Duties are the stretches with somebody on duty, e.g. not days
+		// assigned to an inactive member
+		for _, duty := range DutyTimeline(schedules, c.from, c.to) {
+			assert.Contains(t, stretches, duty.Shift, "case %d", i)
+		}
+	}
+}
+
+func TestDutyTimeline_MatchesLookup(t *testing.T) {
+	for i, c := range randomCases(t) {
+		for _, duty := range DutyTimeline(c.schedules, c.from, c.to) {
+			// Every stretch answers like the lookup, at its start and end
+			for _, at := range []time.Time{duty.Start, duty.End.Add(-time.Second)} {
+				member, ok := OncallAt(c.schedules, at)
+				require.True(t, ok, "case %d: nobody on call at %s", i, at)
+				assert.Equal(t, duty.Member, member, "case %d at %s", i, at)
+
+				members, ok := OncallsAt(c.schedules, at)
+				require.True(t, ok)
+				assert.Equal(t, duty.Members(), members, "case %d at %s", i, at)
+
+				shift, ok := CurrentShift(c.schedules, at)
+				require.True(t, ok)
+				assert.Equal(t, duty.Schedule, shift.Schedule, "case %d at %s", i, at)
+			}
+		}
+
+		for _, gap := range Gaps(c.schedules, c.from, c.to) {
+			_, ok := OncallAt(c.schedules, gap.Start)
+			assert.False(t, ok, "case %d: somebody on call in gap %+v", i, gap)
+		}
+	}
+}
+
+func TestExplain_MatchesLookup(t *testing.T) {
+	for i, c := range randomCases(t) {
+		for at := c.from; at.Before(c.to); at = at.Add(37 * time.Minute) {
+			explanation := Explain(c.schedules, at)
+			require.Len(t, explanation.Candidates, len(c.schedules))
+
+			index, _, running := Current(c.schedules, at)
+			assert.Equal(t, index, explanation.Selected, "case %d at %s", i, at)
+
+			member, ok := OncallAt(c.schedules, at)
+			if !ok {
+				assert.Nil(t, explanation.Duty, "case %d at %s", i, at)
+				continue
+			}
+			require.True(t, running)
+			require.NotNil(t, explanation.Duty, "case %d at %s", i, at)
+			assert.Equal(t, member, explanation.Duty.Member, "case %d at %s", i, at)
+
+			members, _ := OncallsAt(c.schedules, at)
+			assert.Equal(t, members, explanation.Duty.Members(), "case %d at %s", i, at)
+
+			// The rule of the resolution is the one the member comes from
+			resolution := explanation.Candidates[index].Resolution
+			sched := c.schedules[index]
+			switch resolution.Rule {
+			case RulePin:
+				assert.Equal(t, resolution.Pin.Member, member, "case %d at %s", i, at)
+			case RuleDayAssignment:
+				assert.Equal(t, sched.DayAssignments[resolution.Weekday], member, "case %d at %s", i, at)
+			case RuleRotation:
+				if resolution.Rotation.SplitSteps == 0 {
+					assert.Equal(t, sched.Members[resolution.Rotation.Index], member, "case %d at %s", i, at)
+				}
+			}
+		}
+	}
+}
+
+func TestDuties_Deterministic(t *testing.T) {
+	for i, c := range randomCases(t) {
+		// Reordering the pins or the map iteration of day assignments never
+		// changes the answer
+		shuffled := slices.Clone(c.schedules)
+		for k := range shuffled {
+			shuffled[k].Pins = slices.Clone(shuffled[k].Pins)
+			slices.Reverse(shuffled[k].Pins)
+		}
+
+		assert.Equal(t, Duties(c.schedules, c.from, c.to), Duties(shuffled, c.from, c.to), "case %d", i)
+		assert.Equal(t, DutyTimeline(c.schedules, c.from, c.to), DutyTimeline(c.schedules, c.from, c.to), "case %d", i)
+		assert.Equal(t, NextChange(c.schedules, c.from, c.to), NextChange(c.schedules, c.from, c.to), "case %d", i)
+
+		// Splitting the window does not change the stretches, the one
+		// running over the split is only cut at it
+		mid := c.from.Add(c.to.Sub(c.from) / 2).Truncate(time.Minute)
+		if !mid.After(c.from) {
+			continue
+		}
+		joined := append(DutyTimeline(c.schedules, c.from, mid), DutyTimeline(c.schedules, mid, c.to)...)
+		for k := 1; k < len(joined); k++ {
+			if joined[k-1].End.Equal(mid) && joined[k].Start.Equal(mid) && joined[k-1].Member == joined[k].Member &&
+				joined[k-1].ScheduleID == joined[k].ScheduleID && slices.Equal(joined[k-1].Others, joined[k].Others) {
+				joined[k-1].End = joined[k].End
+				joined = slices.Delete(joined, k, k+1)
+				break
+			}
+		}
+		assert.Equal(t, DutyTimeline(c.schedules, c.from, c.to), joined, "case %d", i)
+	}
+}
+
+func TestSchedule_MemberOnDuty(t *testing.T) {
+	sched := Schedule{
+		Name:    "Weekday",
+		Members: []string{"Alice", "Bob", "Charlie"},
+		Days:    []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Anchor:  time.Date(2025, 4, 28, 0, 0, 0, 0, time.UTC),
+		Start:   clock(9, 0),
+		End:     clock(17, 0),
+		Pins:    []Pin{{Date: time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC), Member: "Dave"}},
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"first period", time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC), "Alice"},
+		{"second period", time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC), "Bob"},
+		{"pinned", time.Date(2025, 5, 6, 9, 0, 0, 0, time.UTC), "Dave"},
+		{"third period", time.Date(2025, 5, 12, 9, 0, 0, 0, time.UTC), "Charlie"},
+		{"wraps around", time.Date(2025, 5, 19, 9, 0, 0, 0, time.UTC), "Alice"},
+		{"before the anchor", time.Date(2025, 4, 21, 9, 0, 0, 0, time.UTC), "Charlie"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			member, ok := sched.MemberOnDuty(tt.at)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, member)
+		})
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	schedules := []Schedule{{
+		Name:     "Weekday",
+		Members:  []string{"Alice", "Bob", "Charlie"},
+		Days:     []time.Weekday{time.Monday},
+		Start:    clock(9, 0),
+		End:      clock(17, 0),
+		Inactive: []string{"Bob"},
+	}}
+	at := time.Date(2025, 4, 28, 10, 0, 0, 0, time.UTC)
+
+	member, ok := Substitute(schedules, "Alice", at, nil)
+	require.True(t, ok)
+	assert.Equal(t, "Alice", member)
+
+	// Inactive members are skipped like unavailable ones
+	member, ok = Substitute(schedules, "Alice", at, []string{"Alice"})
+	require.True(t, ok)
+	assert.Equal(t, "Charlie", member)
+
+	_, ok = Substitute(schedules, "Alice", at, []string{"Alice", "Charlie"})
+	assert.False(t, ok)
+
+	// Outside of the shifts there is nobody to take over
+	_, ok = Substitute(schedules, "Alice", at.Add(12*time.Hour), []string{"Alice"})
+	assert.False(t, ok)
+}
@@ -0,0 +1,94 @@
+package rotation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/teambition/rrule-go"
+)
+
+// rrulePartReasons lists the RRULE parts that are rejected, with the reason
+// included in the validation message.
+var rrulePartReasons = map[string]string{
+	"DTSTART":   "use the schedule anchor instead",
+	"BYHOUR":    "shift times come from start and end",
+	"BYMINUTE":  "shift times come from start and end",
+	"BYSECOND":  "shift times come from start and end",
+	"BYYEARDAY": "it is not supported",
+	"BYWEEKNO":  "it is not supported",
+	"BYEASTER":  "it is not supported",
+}
+
+// RRuleSpec is a parsed RFC 5545 recurrence rule. Each occurrence marks the start of a shift.
+type RRuleSpec struct {
+	rule *rrule.RRule
+}
+
+// ParseRRule parses an RRULE value such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE",
+// with or without the "RRULE:" prefix. The rule starts at dtstart, which also
+// sets the time of day of every occurrence.
+//
+// FREQ, INTERVAL, BYDAY, COUNT, UNTIL, WKST, BYMONTH and BYMONTHDAY are
+// supported. BYSETPOS is only accepted as a single position (1 to 4, or -1)
+// on a monthly rule, e.g. the last weekday of the month.
+func ParseRRule(value string, dtstart time.Time) (RRuleSpec, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" || strings.Contains(value, "\n") {
+		return RRuleSpec{}, fmt.Errorf("invalid rrule %q: expected a single RRULE value", value)
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		key, _, _ := strings.Cut(part, "=")
+		if reason, ok := rrulePartReasons[key]; ok {
+			return RRuleSpec{}, fmt.Errorf("unsupported rrule part %s: %s", key, reason)
+		}
+	}
+
+	opt, err := rrule.StrToROption(value)
+	if err != nil {
+		return RRuleSpec{}, fmt.Errorf("invalid rrule %q: %w", value, err)
+	}
+
+	switch opt.Freq {
+	case rrule.DAILY, rrule.WEEKLY, rrule.MONTHLY, rrule.YEARLY:
+	default:
+		return RRuleSpec{}, fmt.Errorf("unsupported rrule part FREQ=%s: shifts recur at most daily", opt.Freq)
+	}
+
+	if len(opt.Bysetpos) > 0 {
+		pos := opt.Bysetpos[0]
+		if len(opt.Bysetpos) > 1 || opt.Freq != rrule.MONTHLY || pos == 0 || pos < -1 || pos > 4 {
+			return RRuleSpec{}, fmt.Errorf("unsupported rrule part BYSETPOS: only a single position from 1 to 4 or -1 on a monthly rule is supported")
+		}
+	}
+
+	opt.Dtstart = dtstart.UTC()
+
+	r, err := rrule.NewRRule(*opt)
+	if err != nil {
+		return RRuleSpec{}, fmt.Errorf("invalid rrule %q: %w", value, err)
+	}
+
+	return RRuleSpec{rule: r}, nil
+}
+
+// First returns the first occurrence of the rule, or the zero time when there is none.
+func (r RRuleSpec) First() time.Time {
+	return r.rule.After(r.rule.GetDTStart(), true)
+}
+
+// Covers reports whether at falls within [occurrence, occurrence+duration)
+// of the most recent occurrence.
+func (r RRuleSpec) Covers(at time.Time, duration time.Duration) bool {
+	o := r.rule.Before(at, true)
+	return !o.IsZero() && at.Before(o.Add(duration))
+}
+
+// RRuleStart returns the DTSTART of a schedule's rule, which is its anchor
+// date at the schedule's start time.
+func (s Schedule) RRuleStart() time.Time {
+	a := s.Anchor.UTC()
+	return time.Date(a.Year(), a.Month(), a.Day(),
+		s.Start.Hour(), s.Start.Minute(), s.Start.Second(), 0, time.UTC)
+}
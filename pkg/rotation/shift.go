@@ -0,0 +1,502 @@
+package rotation
+
+import (
+	"slices"
+	"time"
+)
+
+// Shift is a single occurrence of a schedule, or a part of one.
+type Shift struct {
+	Schedule string
+	Start    time.Time
+	End      time.Time
+}
+
+// validAt reports whether the schedule has not ended at the given instant.
+func (s Schedule) validAt(at time.Time) bool {
+	return s.ValidUntil.IsZero() || at.Before(s.ValidUntil)
+}
+
+// Covers reports whether a shift of the schedule is running at the given UTC
+// instant.
+func (s Schedule) Covers(at time.Time) bool {
+	if !s.validAt(at) {
+		return false
+	}
+
+	switch {
+	case s.Cron != "":
+		spec, err := ParseCron(s.Cron)
+		return err == nil && spec.Covers(at, s.End.Sub(s.Start))
+	case s.RRule != "":
+		spec, err := ParseRRule(s.RRule, s.RRuleStart())
+		return err == nil && spec.Covers(at, s.End.Sub(s.Start))
+	}
+
+	if !slices.Contains(s.Days, at.Weekday()) {
+		return false
+	}
+
+	start := time.Date(at.Year(), at.Month(), at.Day(),
+		s.Start.Hour(), s.Start.Minute(), s.Start.Second(), 0, at.Location())
+	end := time.Date(at.Year(), at.Month(), at.Day(),
+		s.End.Hour(), s.End.Minute(), s.End.Second(), 0, at.Location())
+
+	return at.After(start) && at.Before(end) || at.Equal(start)
+}
+
+// ShiftAt returns the shift of the schedule running at the given instant.
+func (s Schedule) ShiftAt(at time.Time) (Shift, bool) {
+	// Schedules are defined in UTC, like the lookup the instant is matched in it
+	at = at.UTC()
+
+	if !s.validAt(at) {
+		return Shift{}, false
+	}
+
+	duration := s.End.Sub(s.Start)
+
+	var start time.Time
+
+	switch {
+	case s.Cron != "":
+		spec, err := ParseCron(s.Cron)
+		if err != nil {
+			return Shift{}, false
+		}
+		start = spec.Next(at.Add(-duration))
+		if start.IsZero() || start.After(at) {
+			return Shift{}, false
+		}
+	case s.RRule != "":
+		spec, err := ParseRRule(s.RRule, s.RRuleStart())
+		if err != nil {
+			return Shift{}, false
+		}
+		start = spec.rule.Before(at, true)
+		if start.IsZero() || !at.Before(start.Add(duration)) {
+			return Shift{}, false
+		}
+	default:
+		if !s.Covers(at) {
+			return Shift{}, false
+		}
+		start = time.Date(at.Year(), at.Month(), at.Day(),
+			s.Start.Hour(), s.Start.Minute(), s.Start.Second(), 0, at.Location())
+	}
+
+	return Shift{Schedule: s.Name, Start: start, End: start.Add(duration)}, true
+}
+
+// NextShift returns the first shift of the schedule starting strictly after
+// the given instant.
+func (s Schedule) NextShift(after time.Time) (Shift, bool) {
+	after = after.UTC()
+	duration := s.End.Sub(s.Start)
+
+	var start time.Time
+
+	switch {
+	case s.Cron != "":
+		spec, err := ParseCron(s.Cron)
+		if err != nil {
+			return Shift{}, false
+		}
+		start = spec.Next(after)
+	case s.RRule != "":
+		spec, err := ParseRRule(s.RRule, s.RRuleStart())
+		if err != nil {
+			return Shift{}, false
+		}
+		start = spec.rule.After(after, false)
+	default:
+		for d := 0; d <= 7 && start.IsZero(); d++ {
+			day := after.AddDate(0, 0, d)
+			candidate := time.Date(day.Year(), day.Month(), day.Day(),
+				s.Start.Hour(), s.Start.Minute(), s.Start.Second(), 0, after.Location())
+			if candidate.After(after) && slices.Contains(s.Days, candidate.Weekday()) {
+				start = candidate
+			}
+		}
+	}
+
+	if start.IsZero() || !s.validAt(start) {
+		return Shift{}, false
+	}
+
+	return Shift{Schedule: s.Name, Start: start, End: start.Add(duration)}, true
+}
+
+// Current returns the index of the first schedule, in order, with a shift
+// running at the given instant along with the shift, which is the schedule
+// the on-call lookup answers from. Schedules without members are skipped.
+func Current(schedules []Schedule, at time.Time) (int, Shift, bool) {
+	for i, sched := range schedules {
+		if len(sched.Members) == 0 {
+			continue
+		}
+		if shift, ok := sched.ShiftAt(at); ok {
+			return i, shift, true
+		}
+	}
+
+	return -1, Shift{}, false
+}
+
+// CurrentShift returns the shift of the schedule Current answers from at the
+// given instant. For split shifts it returns the running part.
+func CurrentShift(schedules []Schedule, at time.Time) (Shift, bool) {
+	i, shift, ok := Current(schedules, at)
+	if !ok {
+		return Shift{}, false
+	}
+
+	return schedules[i].Parts(shift)[schedules[i].part(shift, at)], true
+}
+
+// UpcomingShifts returns the shifts starting in (from, to], ordered by start.
+// A shift is only included when its schedule is the one the on-call lookup
+// answers from at its start, so shifts hidden by an earlier schedule are left
+// out. Shifts with several parts, see Parts, are returned as their parts, the
+// running shift included.
+func UpcomingShifts(schedules []Schedule, from, to time.Time) []Shift {
+	var shifts []Shift
+
+	for _, sched := range schedules {
+		if len(sched.Members) == 0 {
+			continue
+		}
+
+		var occurrences []Shift
+		if shift, ok := sched.ShiftAt(from); ok && len(sched.Parts(shift)) > 1 {
+			occurrences = append(occurrences, shift)
+		}
+		for shift, ok := sched.NextShift(from); ok && !shift.Start.After(to); shift, ok = sched.NextShift(shift.Start) {
+			occurrences = append(occurrences, shift)
+		}
+
+		for _, shift := range occurrences {
+			if _, current, found := Current(schedules, shift.Start); !found || current != shift {
+				continue
+			}
+
+			for _, part := range sched.Parts(shift) {
+				if part.Start.After(from) && !part.Start.After(to) {
+					shifts = append(shifts, part)
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(shifts, func(a, b Shift) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return shifts
+}
+
+// Duty is a shift along with the schedule it belongs to and the member on
+// duty for it. Pinned is set when a pin put the member on duty.
+type Duty struct {
+	Shift
+	ScheduleID string
+	Member     string
+	Pinned     bool
+	// Others are the members on duty along with Member in schedules
+	// requiring more than one, see Schedule.RequiredCount.
+	Others []string
+}
+
+// Members returns the members on duty, Member first.
+func (d Duty) Members() []string {
+	return append([]string{d.Member}, d.Others...)
+}
+
+// duty returns the duty for a stretch of the given part of the shift of the
+// schedule, which is the shift the member is resolved from.
+func (s Schedule) duty(stretch, shift Shift, part int) (Duty, bool) {
+	member, ok := s.partMember(shift, part)
+	if !ok {
+		return Duty{}, false
+	}
+
+	_, pinned := s.pinnedMember(shift.Start)
+
+	return Duty{Shift: stretch, ScheduleID: s.ID, Member: member, Pinned: pinned, Others: s.others(shift, part, member)}, true
+}
+
+// DutyAt returns the duty of the schedule running at the given instant,
+// which is the running part of split shifts.
+func (s Schedule) DutyAt(at time.Time) (Duty, bool) {
+	shift, ok := s.ShiftAt(at)
+	if !ok {
+		return Duty{}, false
+	}
+
+	part := s.part(shift, at)
+
+	return s.duty(s.Parts(shift)[part], shift, part)
+}
+
+// Duties returns the shifts overlapping [from, to) along with the member on
+// duty for each, ordered by start. Like UpcomingShifts, a shift is only
+// included when its schedule is the one the on-call lookup answers from at
+// its start, and its member is the one the lookup returns, so pins and day
+// assignments are respected. Split shifts return a duty for each of their
+// parts overlapping the range.
+func Duties(schedules []Schedule, from, to time.Time) []Duty {
+	var duties []Duty
+
+	for _, sched := range schedules {
+		if len(sched.Members) == 0 {
+			continue
+		}
+
+		var shifts []Shift
+		if shift, ok := sched.ShiftAt(from); ok {
+			shifts = append(shifts, shift)
+		}
+		for shift, ok := sched.NextShift(from); ok && shift.Start.Before(to); shift, ok = sched.NextShift(shift.Start) {
+			shifts = append(shifts, shift)
+		}
+
+		for _, shift := range shifts {
+			if _, current, found := Current(schedules, shift.Start); !found || current != shift {
+				continue
+			}
+
+			for k, part := range sched.Parts(shift) {
+				if !part.End.After(from) || !part.Start.Before(to) {
+					continue
+				}
+				if duty, ok := sched.duty(part, shift, k); ok {
+					duties = append(duties, duty)
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(duties, func(a, b Duty) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return duties
+}
+
+// Gap is a stretch of time during which nobody is on call.
+type Gap struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Overlaps reports whether the two gaps share any instant.
+func (g Gap) Overlaps(other Gap) bool {
+	return g.Start.Before(other.End) && other.Start.Before(g.End)
+}
+
+// Gaps returns the stretches of [from, to) not covered by any shift of the
+// schedules, ordered by start. Schedules without members are skipped like the
+// lookup does, so they leave their shifts uncovered.
+func Gaps(schedules []Schedule, from, to time.Time) []Gap {
+	var shifts []Shift
+
+	for _, sched := range schedules {
+		if len(sched.Members) == 0 {
+			continue
+		}
+
+		if shift, ok := sched.ShiftAt(from); ok {
+			shifts = append(shifts, shift)
+		}
+		for shift, ok := sched.NextShift(from); ok && shift.Start.Before(to); shift, ok = sched.NextShift(shift.Start) {
+			shifts = append(shifts, shift)
+		}
+	}
+
+	slices.SortFunc(shifts, func(a, b Shift) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	var gaps []Gap
+
+	// covered is the end of the covered stretch starting at from
+	covered := from
+	for _, shift := range shifts {
+		if shift.Start.After(covered) {
+			gaps = append(gaps, Gap{Start: covered, End: shift.Start})
+		}
+		if shift.End.After(covered) {
+			covered = shift.End
+		}
+	}
+
+	if covered.Before(to) {
+		gaps = append(gaps, Gap{Start: covered, End: to})
+	}
+
+	return gaps
+}
+
+// Timeline returns the stretches of [from, to) during which the on-call
+// lookup answers from a shift, ordered by start and clipped to the window.
+// Overlapping schedules are resolved like the lookup does, so a shift
+// interrupted by an earlier schedule is split around it, and split shifts
+// are cut at the boundaries of their parts. Uncovered time is left out.
+func Timeline(schedules []Schedule, from, to time.Time) []Shift {
+	stretches := timeline(schedules, from, to)
+
+	shifts := make([]Shift, 0, len(stretches))
+	for _, stretch := range stretches {
+		shifts = append(shifts, stretch.Shift)
+	}
+
+	return shifts
+}
+
+// DutyTimeline returns the Timeline of the schedules along with the member
+// on duty for each stretch, who is the one of the shift it belongs to. The
+// parts of split shifts are separate stretches.
+func DutyTimeline(schedules []Schedule, from, to time.Time) []Duty {
+	var duties []Duty
+
+	for _, stretch := range timeline(schedules, from, to) {
+		if duty, ok := schedules[stretch.index].duty(stretch.Shift, stretch.occurrence, stretch.part); ok {
+			duties = append(duties, duty)
+		}
+	}
+
+	return duties
+}
+
+// NextChange returns the earliest instant after at and before limit at which
+// the on-call lookup may answer differently, which is where the stretch of
+// DutyTimeline running at at ends or the next one starts: the end of a
+// shift, or the start of a shift handing over to another member or of a pin.
+// It is limit when there is none before it.
+func NextChange(schedules []Schedule, at, limit time.Time) time.Time {
+	at = at.UTC()
+
+	for _, duty := range DutyTimeline(schedules, at, limit) {
+		if duty.Start.After(at) {
+			return duty.Start
+		}
+		if duty.End.Before(limit) {
+			return duty.End
+		}
+	}
+
+	return limit
+}
+
+// OncallAt returns the member the on-call lookup answers with at the given
+// instant.
+func OncallAt(schedules []Schedule, at time.Time) (string, bool) {
+	at = at.UTC()
+
+	i, _, ok := Current(schedules, at)
+	if !ok {
+		return "", false
+	}
+
+	return schedules[i].MemberAt(at)
+}
+
+// OncallsAt returns the members the on-call lookup answers with at the
+// given instant, the one OncallAt returns first. It reports false when
+// nobody is on call.
+func OncallsAt(schedules []Schedule, at time.Time) ([]string, bool) {
+	at = at.UTC()
+
+	i, _, ok := Current(schedules, at)
+	if !ok {
+		return nil, false
+	}
+
+	duty, ok := schedules[i].DutyAt(at)
+	if !ok {
+		return nil, false
+	}
+
+	return duty.Members(), true
+}
+
+// stretch is a stretch of the timeline along with the index of its schedule,
+// the shift it belongs to and the part of the shift it is in.
+type stretch struct {
+	Shift
+	index      int
+	occurrence Shift
+	part       int
+}
+
+// timeline builds the stretches of Timeline.
+func timeline(schedules []Schedule, from, to time.Time) []stretch {
+	from, to = from.UTC(), to.UTC()
+
+	// The answer only changes where a shift, or a part of it, starts or ends
+	boundaries := []time.Time{from}
+	add := func(at time.Time) {
+		if at.After(from) && at.Before(to) {
+			boundaries = append(boundaries, at)
+		}
+	}
+
+	for _, sched := range schedules {
+		if len(sched.Members) == 0 {
+			continue
+		}
+
+		if shift, ok := sched.ShiftAt(from); ok {
+			for _, part := range sched.Parts(shift) {
+				add(part.End)
+			}
+		}
+		for shift, ok := sched.NextShift(from); ok && shift.Start.Before(to); shift, ok = sched.NextShift(shift.Start) {
+			add(shift.Start)
+			for _, part := range sched.Parts(shift) {
+				add(part.End)
+			}
+		}
+		if !sched.ValidUntil.IsZero() {
+			add(sched.ValidUntil)
+		}
+	}
+
+	slices.SortFunc(boundaries, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+	boundaries = slices.CompactFunc(boundaries, func(a, b time.Time) bool {
+		return a.Equal(b)
+	})
+
+	var stretches []stretch
+
+	for i, start := range boundaries {
+		end := to
+		if i+1 < len(boundaries) {
+			end = boundaries[i+1]
+		}
+
+		index, shift, ok := Current(schedules, start)
+		if !ok {
+			continue
+		}
+		part := schedules[index].part(shift, start)
+
+		// Stretches of the same part of a shift are joined back together
+		if n := len(stretches); n > 0 && stretches[n-1].End.Equal(start) &&
+			stretches[n-1].index == index && stretches[n-1].occurrence.Start.Equal(shift.Start) && stretches[n-1].part == part {
+			stretches[n-1].End = end
+			continue
+		}
+
+		stretches = append(stretches, stretch{
+			Shift:      Shift{Schedule: shift.Schedule, Start: start, End: end},
+			index:      index,
+			occurrence: shift,
+			part:       part,
+		})
+	}
+
+	return stretches
+}
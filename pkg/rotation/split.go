@@ -0,0 +1,104 @@
+package rotation
+
+import (
+	"slices"
+	"time"
+)
+
+// splits reports whether the schedule divides its shifts, see Schedule.Split.
+func (s Schedule) splits() bool {
+	return s.Split > 1 && len(s.DayAssignments) == 0
+}
+
+// Parts returns the parts of the shift, one for each of the Split members
+// sharing it, or the shift itself when the schedule does not split its
+// shifts. The boundaries are rounded down to the minute, so the parts differ
+// by at most a minute and the last ones take the remainder. Parts crossing
+// the handoff of the schedule are cut at it.
+func (s Schedule) Parts(shift Shift) []Shift {
+	parts := s.splitParts(shift)
+	if s.handsOff() {
+		parts = s.handoffParts(parts)
+	}
+
+	return parts
+}
+
+// splitParts returns the parts of the shift shared between the Split members.
+func (s Schedule) splitParts(shift Shift) []Shift {
+	if !s.splits() {
+		return []Shift{shift}
+	}
+
+	minutes := int(shift.End.Sub(shift.Start) / time.Minute)
+
+	parts := make([]Shift, 0, s.Split)
+	for k := range s.Split {
+		part := Shift{
+			Schedule: shift.Schedule,
+			Start:    shift.Start.Add(time.Duration(minutes*k/s.Split) * time.Minute),
+			End:      shift.Start.Add(time.Duration(minutes*(k+1)/s.Split) * time.Minute),
+		}
+		if k == s.Split-1 {
+			part.End = shift.End
+		}
+		parts = append(parts, part)
+	}
+
+	return parts
+}
+
+// part returns the index of the part of the shift running at the instant.
+func (s Schedule) part(shift Shift, at time.Time) int {
+	parts := s.Parts(shift)
+	for k, part := range parts {
+		if at.Before(part.End) {
+			return k
+		}
+	}
+
+	return len(parts) - 1
+}
+
+// partMember returns the member on duty for a part of the shift. Each split
+// part goes to the next active member of the rotation after the one of the
+// part before, starting from the member on duty for the shift, which is the
+// one at the start of the part for schedules with a handoff. A pin keeps its
+// member on duty for the whole shift.
+func (s Schedule) partMember(shift Shift, part int) (string, bool) {
+	if _, pinned := s.pinnedMember(shift.Start); pinned || !s.splits() && !s.handsOff() {
+		return s.MemberOnDuty(shift.Start)
+	}
+
+	start := s.Parts(shift)[part].Start
+
+	at := shift.Start
+	if s.handsOff() {
+		at = start
+	}
+
+	index, ok := s.RotationIndex(at)
+	if !ok {
+		return "", false
+	}
+
+	// The split part the part belongs to, handoffs may cut it further
+	split := 0
+	for k, p := range s.splitParts(shift) {
+		if !start.Before(p.Start) {
+			split = k
+		}
+	}
+
+	turns := s.Turns(at)
+	n := len(turns)
+	position := slices.Index(turns, index)
+	for split > 0 {
+		position = (position + 1) % n
+		if !slices.Contains(s.Inactive, s.Members[turns[position]]) {
+			split--
+		}
+	}
+
+	return s.Members[turns[position]], true
+}
@@ -0,0 +1,46 @@
+package rotation
+
+import "slices"
+
+// staffed reports whether the schedule puts more than one member on duty,
+// see Schedule.RequiredCount.
+func (s Schedule) staffed() bool {
+	return s.RequiredCount > 1 && len(s.DayAssignments) == 0
+}
+
+// others returns the members on duty along with the member on duty for a
+// part of the shift: the next active members of the rotation after them,
+// as many as the schedule requires on top of them. A member put on duty by
+// a pin only takes the place of the member of the rotation, who is followed
+// by the same members.
+func (s Schedule) others(shift Shift, part int, member string) []string {
+	if !s.staffed() {
+		return nil
+	}
+
+	at := shift.Start
+	if s.handsOff() {
+		at = s.Parts(shift)[part].Start
+	}
+
+	turns := s.Turns(at)
+	n := len(turns)
+	position := slices.IndexFunc(turns, func(index int) bool { return s.Members[index] == member })
+	if _, pinned := s.pinnedMember(shift.Start); pinned || position < 0 {
+		index, ok := s.RotationIndex(at)
+		if !ok {
+			return nil
+		}
+		position = slices.Index(turns, index)
+	}
+
+	others := make([]string, 0, s.RequiredCount-1)
+	for k := 1; k < n && len(others) < s.RequiredCount-1; k++ {
+		next := s.Members[turns[(position+k)%n]]
+		if next != member && !slices.Contains(s.Inactive, next) {
+			others = append(others, next)
+		}
+	}
+
+	return others
+}
@@ -0,0 +1,83 @@
+// Package webhook signs and verifies the webhook deliveries of the on-call
+// schedule service. Receivers written in Go can use Verify to authenticate
+// a delivery before trusting it.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Delivery headers.
+const (
+	// HeaderSignature holds "sha256=" followed by the hex encoded HMAC of the delivery.
+	HeaderSignature = "X-Oncall-Signature"
+	// HeaderTimestamp holds the Unix time, in seconds, the delivery was signed at.
+	HeaderTimestamp = "X-Oncall-Timestamp"
+	// HeaderEventID holds the ID of the event, which stays the same across retries.
+	HeaderEventID = "X-Oncall-Event-Id"
+)
+
+// signaturePrefix names the hash of the signature.
+const signaturePrefix = "sha256="
+
+// DefaultTolerance is how far the timestamp of a delivery may be from the
+// current time before Verify rejects it as a replay.
+const DefaultTolerance = 5 * time.Minute
+
+// Verification errors.
+var (
+	ErrMissingSignature = errors.New("webhook: missing signature")
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+	ErrInvalidTimestamp = errors.New("webhook: invalid timestamp")
+	ErrExpired          = errors.New("webhook: timestamp outside of the tolerance")
+)
+
+// Sign returns the signature header value of a delivery. The HMAC-SHA256 is
+// computed with the secret over the timestamp, a dot, and the raw body, so a
+// captured delivery cannot be replayed with a fresh timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a delivery, given the values of its
+// timestamp and signature headers and its raw body, with DefaultTolerance.
+func Verify(secret, timestamp string, body []byte, signature string) error {
+	return VerifyAt(secret, timestamp, body, signature, time.Now(), DefaultTolerance)
+}
+
+// VerifyAt is Verify at the given time with the given tolerance.
+func VerifyAt(secret, timestamp string, body []byte, signature string, now time.Time, tolerance time.Duration) error {
+	if signature == "" {
+		return ErrMissingSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidTimestamp
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+
+	if skew := now.Sub(time.Unix(unix, 0)); skew > tolerance || skew < -tolerance {
+		return ErrExpired
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1745830800.{"kind":"handoff"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=f1a97f55a8ae0b705d3a51c60f58c2d196ccef5c493a3c94aea6445302f4f18a",
+		Sign("secret", "1745830800", []byte(`{"kind":"handoff"}`)),
+	)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2025, 4, 28, 9, 0, 0, 0, time.UTC)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	body := []byte(`{"kind":"handoff"}`)
+	signature := Sign("secret", timestamp, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		signature string
+		at        time.Time
+		err       error
+	}{
+		{"valid", "secret", timestamp, body, signature, now, nil},
+		{"within tolerance", "secret", timestamp, body, signature, now.Add(4 * time.Minute), nil},
+		{"replayed", "secret", timestamp, body, signature, now.Add(6 * time.Minute), ErrExpired},
+		{"from the future", "secret", timestamp, body, signature, now.Add(-6 * time.Minute), ErrExpired},
+		{"wrong secret", "other", timestamp, body, signature, now, ErrInvalidSignature},
+		{"tampered body", "secret", timestamp, []byte(`{"kind":"gap"}`), signature, now, ErrInvalidSignature},
+		{"tampered timestamp", "secret", strconv.FormatInt(now.Unix()+60, 10), body, signature, now, ErrInvalidSignature},
+		{"missing signature", "secret", timestamp, body, "", now, ErrMissingSignature},
+		{"malformed signature", "secret", timestamp, body, "md5=abc", now, ErrInvalidSignature},
+		{"malformed timestamp", "secret", "yesterday", body, signature, now, ErrInvalidTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyAt(tt.secret, tt.timestamp, tt.body, tt.signature, tt.at, DefaultTolerance)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}